@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/abramin/flowlens/internal/store"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// annotationsFileName is where annotations are exported in the project by
+// default, to be committed alongside flowlens.yaml.
+const annotationsFileName = "flowlens-annotations.yaml"
+
+var annotationsFile string
+
+var annotationsCmd = &cobra.Command{
+	Use:   "annotations",
+	Short: "Export or import pinned symbols, starred entrypoints, manual edges, and views",
+	Long: `Move what you added to an index by hand between indexes.
+
+Pinned symbols, starred entrypoints, manual edges, and saved views, with
+their notes, live in the index database and survive re-indexing, but not a
+fresh clone or a deleted index. Export them to a YAML file that can be
+committed to the repository, and import it after indexing on any machine:
+
+  flowlens annotations export
+  git add flowlens-annotations.yaml
+  ...
+  flowlens index && flowlens annotations import
+
+Symbols are referred to by package, name, and receiver, so annotations
+whose symbols are not indexed are kept and apply once they are.`,
+}
+
+var annotationsExportCmd = &cobra.Command{
+	Use:   "export [project-dir]",
+	Short: "Write the index's annotations to a YAML file",
+	Long: `Write the annotations of the index to flowlens-annotations.yaml in the
+project, or to --file ("-" for stdout). An existing file is replaced.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, absDir, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		a, err := st.ExportAnnotations(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("exporting annotations: %w", err)
+		}
+		data, err := yaml.Marshal(a)
+		if err != nil {
+			return fmt.Errorf("encoding annotations: %w", err)
+		}
+
+		path := annotationsPath(absDir)
+		if path == "-" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		fmt.Printf("  %d pinned, %d starred, %d manual edges, %d views\n",
+			len(a.Pinned), len(a.Starred), len(a.ManualEdges), len(a.Views))
+		return nil
+	},
+}
+
+var annotationsImportCmd = &cobra.Command{
+	Use:   "import [project-dir]",
+	Short: "Merge annotations from a YAML file into the index",
+	Long: `Merge the annotations in flowlens-annotations.yaml in the project, or in
+--file ("-" for stdin), into the index. Annotations already in the index
+are kept; those in the file take precedence for the same symbol,
+entrypoint, edge, or view name.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, absDir, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		path := annotationsPath(absDir)
+		var data []byte
+		if path == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return fmt.Errorf("reading annotations: %w", err)
+		}
+		var a store.Annotations
+		if err := yaml.Unmarshal(data, &a); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+
+		counts, err := st.ImportAnnotations(cmd.Context(), &a)
+		if err != nil {
+			return fmt.Errorf("importing annotations: %w", err)
+		}
+		fmt.Printf("Imported %d pinned, %d starred, %d manual edges (%d in the call graph), %d views\n",
+			counts.Pinned, counts.Starred, counts.ManualEdges, counts.Applied, counts.Views)
+		return nil
+	},
+}
+
+// annotationsPath returns the --file path, defaulting to the project's
+// annotations file.
+func annotationsPath(absDir string) string {
+	if annotationsFile == "" {
+		return filepath.Join(absDir, annotationsFileName)
+	}
+	return annotationsFile
+}
+
+func init() {
+	rootCmd.AddCommand(annotationsCmd)
+	annotationsCmd.AddCommand(annotationsExportCmd)
+	annotationsExportCmd.Flags().StringVarP(&annotationsFile, "file", "f", "", "output file, or - for stdout (default: <project-dir>/"+annotationsFileName+")")
+	annotationsExportCmd.ValidArgsFunction = completeProjectDir
+
+	annotationsCmd.AddCommand(annotationsImportCmd)
+	annotationsImportCmd.Flags().StringVarP(&annotationsFile, "file", "f", "", "input file, or - for stdin (default: <project-dir>/"+annotationsFileName+")")
+	annotationsImportCmd.ValidArgsFunction = completeProjectDir
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/abramin/flowlens/internal/bench"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchRuns         int
+	benchServices     int
+	benchJSON         bool
+	benchBaseline     string
+	benchSaveBaseline string
+	benchTolerance    float64
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [path]",
+	Short: "Time indexing runs and compare them against a baseline",
+	Long: `Index a project several times into a temporary database and report the
+median, fastest, and slowest time of each indexer phase, with the memory it
+allocated and the heap in use when it ended.
+
+Without a path, a synthetic corpus is generated and indexed: a module of
+HTTP handlers, services, and SQL repositories using only the standard
+library, sized with --services. Its timings are comparable across FlowLens
+versions on the same machine.
+
+Use --save-baseline to store the report, and --baseline to compare a later
+run against it. The command fails when a phase got slower, or allocated
+more, than --tolerance allows (differences under 50ms or 4MiB are ignored):
+
+  flowlens bench --save-baseline bench.json
+  flowlens bench --baseline bench.json
+
+Use --json for machine-readable output.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if benchTolerance < 0 {
+			return fmt.Errorf("invalid --tolerance %g (want 0 or more)", benchTolerance)
+		}
+		opts := bench.Options{Services: benchServices, Runs: benchRuns, Config: GetConfig(), Log: os.Stderr}
+		if len(args) > 0 {
+			absDir, err := filepath.Abs(args[0])
+			if err != nil {
+				return fmt.Errorf("resolving path: %w", err)
+			}
+			opts.Dir = absDir
+		}
+
+		var baseline *bench.Report
+		if benchBaseline != "" {
+			data, err := os.ReadFile(benchBaseline)
+			if err != nil {
+				return fmt.Errorf("reading baseline: %w", err)
+			}
+			baseline = &bench.Report{}
+			if err := json.Unmarshal(data, baseline); err != nil {
+				return fmt.Errorf("parsing baseline %s: %w", benchBaseline, err)
+			}
+		}
+
+		report, err := bench.Run(cmd.Context(), opts)
+		if err != nil {
+			return fmt.Errorf("benchmark failed: %w", err)
+		}
+
+		var regressions []bench.Regression
+		if baseline != nil {
+			if regressions, err = bench.Compare(baseline, report, benchTolerance); err != nil {
+				return err
+			}
+		}
+
+		if benchSaveBaseline != "" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(benchSaveBaseline, append(data, '\n'), 0644); err != nil {
+				return fmt.Errorf("writing baseline: %w", err)
+			}
+		}
+
+		if benchJSON {
+			out := struct {
+				*bench.Report
+				Regressions []bench.Regression `json:"regressions,omitempty"`
+			}{report, regressions}
+			if err := writeReportJSON(os.Stdout, out); err != nil {
+				return err
+			}
+		} else {
+			writeBenchText(os.Stdout, report, regressions)
+		}
+		if len(regressions) > 0 {
+			return fmt.Errorf("%d performance regressions against %s", len(regressions), benchBaseline)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	benchCmd.Flags().IntVar(&benchRuns, "runs", 3, "number of indexing runs")
+	benchCmd.Flags().IntVar(&benchServices, "services", 50, "services in the synthetic corpus")
+	benchCmd.Flags().BoolVar(&benchJSON, "json", false, "output as JSON")
+	benchCmd.Flags().StringVar(&benchBaseline, "baseline", "", "compare against a report saved with --save-baseline")
+	benchCmd.Flags().StringVar(&benchSaveBaseline, "save-baseline", "", "save the report as a baseline")
+	benchCmd.Flags().Float64Var(&benchTolerance, "tolerance", 0.2, "allowed slowdown or extra allocation per phase (0.2 = 20%)")
+}
+
+// writeBenchText prints one line per phase followed by the regressions.
+func writeBenchText(w io.Writer, r *bench.Report, regressions []bench.Regression) {
+	fmt.Fprintf(w, "Corpus:     %s (%d packages, %d symbols, %d call edges)\n", r.Corpus, r.Packages, r.Symbols, r.CallEdges)
+	fmt.Fprintf(w, "Runs:       %d with %s\n", r.Runs, r.GoVersion)
+	fmt.Fprintf(w, "\n%-12s  %10s  %10s  %10s  %10s  %10s\n", "PHASE", "MEDIAN", "MIN", "MAX", "ALLOC", "HEAP")
+	for _, p := range r.Phases {
+		fmt.Fprintf(w, "%-12s  %8.1fms  %8.1fms  %8.1fms  %8.1fMB  %8.1fMB\n",
+			p.Name, p.MedianMS, p.MinMS, p.MaxMS, mb(p.AllocBytes), mb(p.HeapBytes))
+	}
+	if len(regressions) > 0 {
+		fmt.Fprintf(w, "\nRegressions:\n")
+		for _, reg := range regressions {
+			if reg.Metric == "alloc_bytes" {
+				fmt.Fprintf(w, "  %-12s  alloc %.1fMB -> %.1fMB\n", reg.Phase, reg.Baseline/(1<<20), reg.Current/(1<<20))
+			} else {
+				fmt.Fprintf(w, "  %-12s  time %.1fms -> %.1fms\n", reg.Phase, reg.Baseline, reg.Current)
+			}
+		}
+	}
+}
+
+func mb(b uint64) float64 {
+	return float64(b) / (1 << 20)
+}
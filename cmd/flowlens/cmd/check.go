@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkFormat string
+	checkStrict bool
+)
+
+// CheckReport is the JSON form of 'flowlens check'.
+type CheckReport struct {
+	Passed         bool                     `json:"passed"`
+	RouteConflicts []store.RouteConflict    `json:"route_conflicts"`
+	LayerSkips     []LayerSkip              `json:"layer_skips"`
+	Entrypoints    []store.EntrypointLayers `json:"entrypoints"` // Call path length and layer transitions of each entrypoint
+}
+
+// LayerSkip is a call in an entrypoint's call tree that bypasses a layer,
+// such as a handler calling the store without going through a service.
+type LayerSkip struct {
+	Entrypoint store.EntrypointRef `json:"entrypoint"`
+	Call       store.LayerCall     `json:"call"`
+	Skipped    []string            `json:"skipped"` // Layers passed over, outermost first
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check [project-dir]",
+	Short: "Check the index for wiring problems, for CI",
+	Long: `Check the indexed project for problems the compiler doesn't catch and
+routers resolve silently at runtime:
+
+- duplicate routes: the same method and path registered twice, including
+  patterns that differ only in parameter names (/users/{id}, /users/:uid)
+- overlapping routes: different patterns matching a common request
+  (/users/{id} and /users/me), served by whichever handler the router's
+  precedence rules pick
+- layer skips: calls in an entrypoint's call tree that pass over a layer
+  the project has, such as a handler calling the store directly instead of
+  a service (calls into domain types are fine from any layer)
+
+The command fails when it finds duplicates, or with --strict any problem.
+Routes are compared across all routers in the project. The JSON output
+also has each entrypoint's call depth and the layers along its call tree.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch checkFormat {
+		case "text", "json":
+		default:
+			return fmt.Errorf("invalid format %q (want text or json)", checkFormat)
+		}
+
+		st, _, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		report := CheckReport{}
+		report.RouteConflicts, err = st.GetRouteConflicts(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("checking routes: %w", err)
+		}
+		if report.RouteConflicts == nil {
+			report.RouteConflicts = []store.RouteConflict{}
+		}
+
+		report.Entrypoints, err = st.GetEntrypointLayers(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("checking layers: %w", err)
+		}
+		report.LayerSkips, err = findLayerSkips(cmd.Context(), st, report.Entrypoints)
+		if err != nil {
+			return err
+		}
+
+		failures := 0
+		for _, c := range report.RouteConflicts {
+			if c.Kind == store.RouteDuplicate || checkStrict {
+				failures++
+			}
+		}
+		if checkStrict {
+			failures += len(report.LayerSkips)
+		}
+		report.Passed = failures == 0
+
+		if checkFormat == "json" {
+			if err := writeReportJSON(os.Stdout, report); err != nil {
+				return err
+			}
+		} else {
+			writeCheckText(os.Stdout, &report)
+		}
+		if !report.Passed {
+			return fmt.Errorf("check failed: %d problems", failures)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().StringVarP(&checkFormat, "format", "f", "text", "output format: text or json")
+	checkCmd.Flags().BoolVar(&checkStrict, "strict", false, "fail on overlapping routes and layer skips too")
+	checkCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// findLayerSkips returns the calls of each entrypoint's call tree that pass
+// over a layer, counting only the layers the index has packages in: a
+// project without services has no service layer to skip.
+func findLayerSkips(ctx context.Context, st *store.Store, eps []store.EntrypointLayers) ([]LayerSkip, error) {
+	sizes, err := st.GetLayerSizes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("checking layers: %w", err)
+	}
+	present := make(map[string]bool)
+	for _, size := range sizes {
+		present[size.Layer] = true
+	}
+
+	skips := []LayerSkip{}
+	for _, ep := range eps {
+		for _, c := range ep.Crossings {
+			var skipped []string
+			for _, layer := range config.SkippedLayers(c.CallerLayer, c.CalleeLayer) {
+				if present[layer] {
+					skipped = append(skipped, layer)
+				}
+			}
+			if len(skipped) > 0 {
+				skips = append(skips, LayerSkip{Entrypoint: ep.Entrypoint, Call: c, Skipped: skipped})
+			}
+		}
+	}
+	return skips, nil
+}
+
+// writeCheckText prints each problem found, one per line, and the
+// entrypoints with the deepest call tree and the most layer transitions.
+func writeCheckText(w io.Writer, r *CheckReport) {
+	fmt.Fprintln(w, "Routes:")
+	if len(r.RouteConflicts) == 0 {
+		fmt.Fprintln(w, "  No duplicate or overlapping routes.")
+	}
+	for _, c := range r.RouteConflicts {
+		fmt.Fprintf(w, "  %-9s  %s  and  %s\n", c.Kind, c.A.Label, c.B.Label)
+	}
+
+	fmt.Fprintln(w, "Layers:")
+	if len(r.LayerSkips) == 0 {
+		fmt.Fprintln(w, "  No calls skip a layer.")
+	}
+	for _, s := range r.LayerSkips {
+		fmt.Fprintf(w, "  %s: %s (%s) calls %s (%s), skipping %s\n", s.Entrypoint.Label,
+			s.Call.Caller, s.Call.CallerLayer, s.Call.Callee, s.Call.CalleeLayer, strings.Join(s.Skipped, ", "))
+	}
+
+	var deepest, layered *store.EntrypointLayers
+	for i := range r.Entrypoints {
+		ep := &r.Entrypoints[i]
+		if deepest == nil || ep.MaxDepth > deepest.MaxDepth {
+			deepest = ep
+		}
+		if layered == nil || ep.Transitions > layered.Transitions {
+			layered = ep
+		}
+	}
+	if deepest != nil {
+		fmt.Fprintf(w, "Deepest call: %d calls (%s)\n", deepest.MaxDepth, deepest.Entrypoint.Label)
+	}
+	if layered != nil && layered.Transitions > 0 {
+		fmt.Fprintf(w, "Most layer transitions: %d (%s: %s)\n", layered.Transitions, layered.Entrypoint.Label, strings.Join(layered.Path, " -> "))
+	}
+}
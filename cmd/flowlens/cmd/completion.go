@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// completionLimit caps dynamic completion candidates read from the index.
+const completionLimit = 50
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a shell completion script for flowlens.
+
+Bash:
+  source <(flowlens completion bash)
+  # or, permanently:
+  flowlens completion bash > /etc/bash_completion.d/flowlens
+
+Zsh:
+  flowlens completion zsh > "${fpath[1]}/_flowlens"
+
+Fish:
+  flowlens completion fish > ~/.config/fish/completions/flowlens.fish
+
+PowerShell:
+  flowlens completion powershell | Out-String | Invoke-Expression
+
+Besides commands and flags, completion suggests project directories and,
+where a command takes them, entrypoint labels and symbol names from the
+local index.`,
+	Args:                  cobra.ExactArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	// Completion must work without a config file
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(out)
+		default:
+			return fmt.Errorf("unsupported shell %q (expected bash, zsh, fish, or powershell)", args[0])
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+
+	// Positional project-dir arguments complete to directories
+	for _, c := range []*cobra.Command{indexCmd, uiCmd, docsCmd, exportStructurizrCmd, exportCFGCmd, exportRoutesCmd, reportAuthCmd, reportDepsCmd, doctorCmd} {
+		c.ValidArgsFunction = completeProjectDir
+	}
+
+	// Flag completions are registered where the flags are defined, since
+	// this file's init runs before theirs
+}
+
+// completeProjectDir completes the optional [project-dir] argument.
+func completeProjectDir(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
+
+// completeEntrypointLabels completes entrypoint labels (e.g. "GET /api/users")
+// from the index of the project named by the command's arguments.
+func completeEntrypointLabels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	st, ok := openIndexForCompletion(cmd, args)
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer st.Close()
+
+	eps, err := st.GetEntrypoints(cmd.Context(), store.EntrypointFilter{Query: toComplete, Limit: completionLimit})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	completions := make([]string, 0, len(eps))
+	for _, ep := range eps {
+		completions = append(completions, fmt.Sprintf("%s\t%s %s.%s", ep.Label, ep.Type, ep.Symbol.PkgPath, ep.Symbol.Name))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSymbolNames completes symbol names from the index of the project
+// named by the command's arguments.
+func completeSymbolNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	st, ok := openIndexForCompletion(cmd, args)
+	if !ok || toComplete == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer st.Close()
+
+	results, err := st.SearchSymbols(cmd.Context(), store.SearchFilter{Query: toComplete, Limit: completionLimit})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	seen := make(map[string]bool)
+	completions := make([]string, 0, len(results))
+	for _, r := range results {
+		name := r.Symbol.Name
+		if r.Symbol.RecvType != "" {
+			name = r.Symbol.RecvType + "." + r.Symbol.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		completions = append(completions, fmt.Sprintf("%s\t%s", name, r.Symbol.PkgPath))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// openIndexForCompletion opens the index of the project directory given as
+// the first argument (or the current directory), at the command's --db or
+// the configured database. It never creates or changes an index.
+func openIndexForCompletion(cmd *cobra.Command, args []string) (*store.Store, bool) {
+	projectDir := "."
+	if len(args) > 0 {
+		projectDir = args[0]
+	}
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, false
+	}
+
+	var dbPath string
+	if f := cmd.Flags().Lookup("db"); f != nil {
+		dbPath = f.Value.String()
+	}
+	if dbPath == "" {
+		// Completion runs without the root command's config loading
+		c, err := config.Load(cfgFile)
+		if err != nil {
+			return nil, false
+		}
+		dbPath = c.DatabasePath(absDir)
+	}
+
+	st, err := openIndex(dbPath, absDir, true)
+	if err != nil {
+		return nil, false
+	}
+	return st, true
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/abramin/flowlens/internal/index"
+	"github.com/abramin/flowlens/internal/server"
+)
+
+// daemonEnv marks the background process 'flowlens ui --daemon' starts.
+const daemonEnv = "FLOWLENS_UI_DAEMON"
+
+// daemonStartTimeout is how long 'flowlens ui --daemon' waits for the
+// background server to listen.
+const daemonStartTimeout = 30 * time.Second
+
+// isDaemonChild reports whether this process is a background server started
+// by 'flowlens ui --daemon'.
+func isDaemonChild() bool {
+	return os.Getenv(daemonEnv) == "1"
+}
+
+// startDaemon runs this command again in the background, without --daemon,
+// with its output in a log file next to the index, and waits until the
+// server has written its pidfile. A server already running for the index
+// is reused.
+func startDaemon(dbPath string) error {
+	pidPath := server.PIDFilePath(dbPath)
+	if pf, err := server.ReadPIDFile(pidPath); err != nil {
+		return err
+	} else if pf != nil {
+		fmt.Printf("FlowLens UI already running at %s (PID %d)\n", pf.URL, pf.PID)
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding the flowlens executable: %w", err)
+	}
+	logPath := dbPath + ".ui.log"
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	defer logFile.Close()
+
+	var args []string
+	for _, arg := range os.Args[1:] {
+		if arg != "--daemon" && !strings.HasPrefix(arg, "--daemon=") {
+			args = append(args, arg)
+		}
+	}
+	child := exec.Command(exe, append(args, "--no-browser")...)
+	child.Env = append(os.Environ(), daemonEnv+"=1")
+	child.Stdout = logFile
+	child.Stderr = logFile
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("starting background server: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+
+	deadline := time.After(daemonStartTimeout)
+	for {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("background server exited (%v); see %s", err, logPath)
+		case <-deadline:
+			return fmt.Errorf("background server (PID %d) didn't start within %s; see %s", child.Process.Pid, daemonStartTimeout, logPath)
+		case <-time.After(50 * time.Millisecond):
+		}
+		pf, err := server.ReadPIDFile(pidPath)
+		if err != nil {
+			return err
+		}
+		if pf != nil && pf.PID == child.Process.Pid {
+			fmt.Printf("FlowLens UI running at %s (PID %d)\n", pf.URL, pf.PID)
+			fmt.Printf("Log: %s\n", logPath)
+			return child.Process.Release()
+		}
+	}
+}
+
+// stopDaemon stops the background server for the index at dbPath, if one
+// runs, and waits for it to exit.
+func stopDaemon(dbPath string) error {
+	pf, err := server.ReadPIDFile(server.PIDFilePath(dbPath))
+	if err != nil {
+		return err
+	}
+	if pf == nil {
+		fmt.Println("No FlowLens UI server running for this index")
+		return nil
+	}
+	p, err := os.FindProcess(pf.PID)
+	if err != nil {
+		return fmt.Errorf("finding PID %d: %w", pf.PID, err)
+	}
+	// Windows has no SIGTERM, and processes there can be waited for; the
+	// server is killed without a graceful shutdown
+	if runtime.GOOS == "windows" {
+		if err := p.Kill(); err != nil {
+			return fmt.Errorf("stopping PID %d: %w", pf.PID, err)
+		}
+		p.Wait()
+	} else {
+		if err := p.Signal(syscall.SIGTERM); err != nil {
+			return fmt.Errorf("stopping PID %d: %w", pf.PID, err)
+		}
+		deadline := time.Now().Add(15 * time.Second)
+		for index.ProcessAlive(pf.PID) {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("FlowLens UI server (PID %d) didn't stop within 15s", pf.PID)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	fmt.Printf("Stopped FlowLens UI server at %s (PID %d)\n", pf.URL, pf.PID)
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/abramin/flowlens/internal/docs"
+	"github.com/abramin/flowlens/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	docsOut   string
+	docsDepth int
+)
+
+var docsCmd = &cobra.Command{
+	Use:   "docs [project-dir]",
+	Short: "Generate markdown architecture docs from the index",
+	Long: `Generate one markdown document per entrypoint type (http.md, grpc.md,
+cli.md, main.md) from the FlowLens index.
+
+Each document contains:
+- A table of entrypoints and their handlers
+- An I/O summary per entrypoint
+- A Mermaid diagram of cross-layer calls
+- The main call spine of each entrypoint
+
+Output is deterministic so the docs can be committed and diffed.
+Run 'flowlens index' first to create the index.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectDir := "."
+		if len(args) > 0 {
+			projectDir = args[0]
+		}
+
+		absDir, err := filepath.Abs(projectDir)
+		if err != nil {
+			return fmt.Errorf("resolving path: %w", err)
+		}
+
+		indexPath := GetConfig().DatabasePath(absDir)
+		if !store.Exists(indexPath) {
+			return fmt.Errorf("no FlowLens index found at %s\nRun 'flowlens index %s' first to create the index", indexPath, absDir)
+		}
+
+		st, err := openIndex(indexPath, absDir, false)
+		if err != nil {
+			return fmt.Errorf("opening store: %w", err)
+		}
+		defer st.Close()
+
+		documents, err := docs.NewGenerator(st, absDir, docsDepth).Generate(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("generating docs: %w", err)
+		}
+
+		outDir := docsOut
+		if !filepath.IsAbs(outDir) {
+			outDir = filepath.Join(absDir, outDir)
+		}
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return fmt.Errorf("creating output directory: %w", err)
+		}
+
+		for _, d := range documents {
+			path := filepath.Join(outDir, d.FileName)
+			if err := os.WriteFile(path, []byte(d.Content), 0644); err != nil {
+				return fmt.Errorf("writing %s: %w", path, err)
+			}
+			fmt.Printf("Wrote %s\n", path)
+		}
+		if len(documents) == 0 {
+			fmt.Println("No entrypoints found; nothing to document")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(docsCmd)
+	docsCmd.Flags().StringVarP(&docsOut, "out", "o", filepath.Join("docs", "architecture"), "output directory (relative to the project directory)")
+	docsCmd.Flags().IntVar(&docsDepth, "depth", docs.DefaultDepth, "how deep to follow call spines and I/O")
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abramin/flowlens/internal/index"
+	"github.com/abramin/flowlens/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorFormat   string
+	doctorSeverity string
+)
+
+// DoctorReport is the JSON form of 'flowlens doctor'.
+type DoctorReport struct {
+	DBPath           string             `json:"db_path"`
+	IndexedAt        string             `json:"indexed_at,omitempty"`
+	IndexStatus      string             `json:"index_status,omitempty"`
+	IndexError       string             `json:"index_error,omitempty"`
+	SchemaVersion    int                `json:"schema_version"`          // Schema the index was written with (0 = unknown)
+	ExpectedSchema   int                `json:"expected_schema_version"` // Schema this binary writes
+	NewerSourceFile  string             `json:"newer_source_file,omitempty"`
+	Diagnostics      []store.Diagnostic `json:"diagnostics"`
+	UnresolvedCalls  map[string]int     `json:"unresolved_calls"` // Call sites with no edge, by reason
+	SkippedFunctions int                `json:"skipped_functions"`
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [project-dir]",
+	Short: "Explain gaps in the index",
+	Long: `Check the index for problems that make the graph incomplete: a failed or
+stale indexing run, an outdated schema, calls that could not be resolved,
+and the package loading errors recorded while indexing.
+
+Errors mean a package or file could not be loaded and is missing from the
+graph; warnings (type errors) mean it was indexed but some calls may be
+missing. Use --severity to show only one of them.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch doctorFormat {
+		case "text", "json":
+		default:
+			return fmt.Errorf("invalid format %q (want text or json)", doctorFormat)
+		}
+		switch doctorSeverity {
+		case "", store.SeverityError, store.SeverityWarning:
+		default:
+			return fmt.Errorf("invalid severity %q (want error or warning)", doctorSeverity)
+		}
+
+		st, absDir, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		ctx := cmd.Context()
+		report := DoctorReport{
+			DBPath:         st.DBPath(),
+			ExpectedSchema: store.SchemaVersion,
+		}
+		report.IndexedAt, _ = st.GetMetadata(ctx, "indexed_at")
+		report.IndexStatus, _ = st.GetMetadata(ctx, "index_status")
+		if report.IndexStatus == "failed" {
+			report.IndexError, _ = st.GetMetadata(ctx, "index_error")
+		}
+		if v, err := st.GetMetadata(ctx, "schema_version"); err == nil {
+			report.SchemaVersion, _ = strconv.Atoi(v)
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, report.IndexedAt); err == nil {
+			report.NewerSourceFile, _ = index.NewerSource(absDir, ts)
+		}
+
+		stats, err := st.GetStats(ctx)
+		if err != nil {
+			return fmt.Errorf("getting stats: %w", err)
+		}
+		report.UnresolvedCalls, report.SkippedFunctions = stats.UnresolvedCalls, stats.SkippedFunctions
+
+		report.Diagnostics, err = st.GetDiagnostics(ctx, store.DiagnosticFilter{Severity: doctorSeverity})
+		if err != nil {
+			return fmt.Errorf("getting diagnostics: %w", err)
+		}
+		if report.Diagnostics == nil {
+			report.Diagnostics = []store.Diagnostic{}
+		}
+
+		if doctorFormat == "json" {
+			return writeReportJSON(os.Stdout, report)
+		}
+		writeDoctorText(os.Stdout, &report, absDir)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringVarP(&doctorFormat, "format", "f", "text", "output format: text or json")
+	doctorCmd.Flags().StringVar(&doctorSeverity, "severity", "", "show only diagnostics of this severity: error or warning")
+	doctorCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	doctorCmd.RegisterFlagCompletionFunc("severity", cobra.FixedCompletions([]string{store.SeverityError, store.SeverityWarning}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// writeDoctorText prints the index checks and call graph gaps, followed by
+// the diagnostics grouped by package.
+func writeDoctorText(w io.Writer, r *DoctorReport, projectDir string) {
+	fmt.Fprintf(w, "Index:    %s\n", r.DBPath)
+	if r.IndexedAt != "" {
+		fmt.Fprintf(w, "Indexed:  %s\n", r.IndexedAt)
+	}
+	switch r.IndexStatus {
+	case "failed":
+		fmt.Fprintf(w, "Status:   last run failed: %s\n", r.IndexError)
+	case "running":
+		fmt.Fprintln(w, "Status:   indexing in progress")
+	}
+	if r.SchemaVersion != r.ExpectedSchema {
+		fmt.Fprintf(w, "Schema:   v%d, expected v%d; re-run 'flowlens index'\n", r.SchemaVersion, r.ExpectedSchema)
+	}
+	if r.NewerSourceFile != "" {
+		fmt.Fprintf(w, "Stale:    %s changed since indexing; re-run 'flowlens index'\n", relPath(projectDir, r.NewerSourceFile))
+	}
+	unresolved := 0
+	var reasons []string
+	for _, reason := range store.UnresolvedReasons {
+		if n := r.UnresolvedCalls[reason]; n > 0 {
+			unresolved += n
+			reasons = append(reasons, fmt.Sprintf("%d %s", n, reason))
+		}
+	}
+	if unresolved > 0 || r.SkippedFunctions > 0 {
+		fmt.Fprintf(w, "Calls:    %d unresolved", unresolved)
+		if len(reasons) > 0 {
+			fmt.Fprintf(w, " (%s)", strings.Join(reasons, ", "))
+		}
+		fmt.Fprintf(w, ", %d functions skipped; see /api/stats/unresolved\n", r.SkippedFunctions)
+	}
+
+	if len(r.Diagnostics) == 0 {
+		fmt.Fprintln(w, "\nNo package loading errors.")
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, d := range r.Diagnostics {
+		counts[d.Severity]++
+	}
+	fmt.Fprintf(w, "\n%d errors, %d warnings\n", counts[store.SeverityError], counts[store.SeverityWarning])
+
+	pkg := ""
+	for _, d := range r.Diagnostics {
+		if d.PkgPath != pkg {
+			pkg = d.PkgPath
+			fmt.Fprintf(w, "\n%s\n", pkg)
+		}
+		pos := "-"
+		if d.File != "" {
+			pos = relPath(projectDir, d.File)
+			if d.Line > 0 {
+				pos += fmt.Sprintf(":%d", d.Line)
+			}
+			if d.Column > 0 {
+				pos += fmt.Sprintf(":%d", d.Column)
+			}
+		}
+		fmt.Fprintf(w, "  %-7s  %s: %s (%s)\n", d.Severity, pos, d.Message, d.Kind)
+	}
+}
+
+// relPath shortens a path inside projectDir to a relative one.
+func relPath(projectDir, path string) string {
+	if rel, err := filepath.Rel(projectDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
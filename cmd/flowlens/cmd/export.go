@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/docs"
+	"github.com/abramin/flowlens/internal/index"
+	"github.com/abramin/flowlens/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOut        string
+	exportName       string
+	exportSymbol     string
+	exportEntrypoint string
+
+	exportFormat   string
+	exportUpstream string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the index to other documentation formats",
+}
+
+var exportStructurizrCmd = &cobra.Command{
+	Use:   "structurizr [project-dir]",
+	Short: "Export the architecture as a Structurizr DSL workspace",
+	Long: `Export the indexed project as a C4 model in Structurizr DSL.
+
+The model contains:
+- One software system for the project
+- Containers for each layer (or module, for unlayered packages)
+- Components for each package, with package-to-package call relationships
+- People for HTTP/gRPC clients and operators, linked to entrypoint handlers
+
+Writes to stdout unless --out is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectDir := "."
+		if len(args) > 0 {
+			projectDir = args[0]
+		}
+
+		absDir, err := filepath.Abs(projectDir)
+		if err != nil {
+			return fmt.Errorf("resolving path: %w", err)
+		}
+
+		indexPath := GetConfig().DatabasePath(absDir)
+		if !store.Exists(indexPath) {
+			return fmt.Errorf("no FlowLens index found at %s\nRun 'flowlens index %s' first to create the index", indexPath, absDir)
+		}
+
+		st, err := openIndex(indexPath, absDir, false)
+		if err != nil {
+			return fmt.Errorf("opening store: %w", err)
+		}
+		defer st.Close()
+
+		name := exportName
+		if name == "" {
+			name = filepath.Base(absDir)
+		}
+
+		dsl, err := docs.NewGenerator(st, absDir, 0).GenerateStructurizr(cmd.Context(), name)
+		if err != nil {
+			return fmt.Errorf("generating DSL: %w", err)
+		}
+
+		if exportOut == "" {
+			fmt.Print(dsl)
+			return nil
+		}
+		if err := os.WriteFile(exportOut, []byte(dsl), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", exportOut, err)
+		}
+		fmt.Printf("Wrote %s\n", exportOut)
+		return nil
+	},
+}
+
+var exportCFGCmd = &cobra.Command{
+	Use:   "cfg [project-dir]",
+	Short: "Export a function's control flow graph as Graphviz DOT",
+	Long: `Export the control flow graph of one function as a Graphviz digraph.
+
+Blocks list their SSA instructions and source lines; conditional edges are
+labelled with the branch condition and loop back edges are dashed. Render
+with e.g. 'dot -Tsvg'.
+
+The --symbol flag takes a symbol ID or a name ("Handle" or "Server.Handle");
+--entrypoint exports the handler of an entrypoint by its label instead
+(e.g. "GET /api/users"). Writes to stdout unless --out is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportSymbol == "" && exportEntrypoint == "" {
+			return fmt.Errorf("--symbol or --entrypoint is required")
+		}
+
+		projectDir := "."
+		if len(args) > 0 {
+			projectDir = args[0]
+		}
+
+		absDir, err := filepath.Abs(projectDir)
+		if err != nil {
+			return fmt.Errorf("resolving path: %w", err)
+		}
+
+		indexPath := GetConfig().DatabasePath(absDir)
+		if !store.Exists(indexPath) {
+			return fmt.Errorf("no FlowLens index found at %s\nRun 'flowlens index %s' first to create the index", indexPath, absDir)
+		}
+
+		st, err := openIndex(indexPath, absDir, false)
+		if err != nil {
+			return fmt.Errorf("opening store: %w", err)
+		}
+		defer st.Close()
+
+		var symbolID store.SymbolID
+		if exportEntrypoint != "" {
+			symbolID, err = resolveEntrypoint(cmd.Context(), st, exportEntrypoint)
+		} else {
+			symbolID, err = resolveSymbol(cmd.Context(), st, exportSymbol)
+		}
+		if err != nil {
+			return err
+		}
+
+		cfg, err := index.NewCFGBuilder(st).BuildCFG(cmd.Context(), symbolID)
+		if err != nil {
+			return fmt.Errorf("building CFG: %w", err)
+		}
+		dot := cfg.DOT()
+
+		if exportOut == "" {
+			fmt.Print(dot)
+			return nil
+		}
+		if err := os.WriteFile(exportOut, []byte(dot), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", exportOut, err)
+		}
+		fmt.Printf("Wrote %s\n", exportOut)
+		return nil
+	},
+}
+
+var exportRoutesCmd = &cobra.Command{
+	Use:   "routes [project-dir]",
+	Short: "Export the HTTP routes for API gateway configuration",
+	Long: `Export the detected HTTP entrypoints as a route table, to seed or review
+API gateway and infrastructure configuration.
+
+Formats (--format):
+- yaml: a plain routes.yaml with each route's method, path (in OpenAPI
+  form, with the registered pattern when it differs), path parameters,
+  handler, location, and middleware
+- kong: a Kong declarative configuration (decK) with one service at
+  --upstream and a route per entrypoint
+- envoy: an Envoy route_config sending every route to the cluster --name
+
+Path parameters of chi, gorilla/mux, gin, echo, and Go 1.22 ServeMux
+patterns are recognized. The gateway stubs are a starting point to review,
+not a drop-in configuration. Writes to stdout unless --out is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, absDir, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		name := exportName
+		if name == "" {
+			name = filepath.Base(absDir)
+		}
+		out, err := docs.NewGenerator(st, absDir, 0).GenerateRoutes(cmd.Context(), docs.RouteOptions{
+			Format:   exportFormat,
+			Name:     name,
+			Upstream: exportUpstream,
+		})
+		if err != nil {
+			return err
+		}
+
+		if exportOut == "" {
+			fmt.Print(out)
+			return nil
+		}
+		if err := os.WriteFile(exportOut, []byte(out), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", exportOut, err)
+		}
+		fmt.Printf("Wrote %s\n", exportOut)
+		return nil
+	},
+}
+
+// resolveSymbol finds a function by ID or by name. Names may be qualified
+// with a receiver type ("Server.Handle"); ambiguous names are an error that
+// lists the candidates.
+func resolveSymbol(ctx context.Context, st *store.Store, ref string) (store.SymbolID, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		if _, err := st.GetSymbolByID(ctx, store.SymbolID(id)); err != nil {
+			return 0, fmt.Errorf("symbol %d not found: %w", id, err)
+		}
+		return store.SymbolID(id), nil
+	}
+
+	recv, name, qualified := strings.Cut(ref, ".")
+	if !qualified {
+		recv, name = "", ref
+	}
+	results, err := st.SearchSymbols(ctx, store.SearchFilter{Query: name, Limit: 200})
+	if err != nil {
+		return 0, fmt.Errorf("searching symbols: %w", err)
+	}
+
+	var matches []store.Symbol
+	for _, r := range results {
+		sym := r.Symbol
+		if sym.Name != name || (qualified && strings.TrimPrefix(sym.RecvType, "*") != strings.TrimPrefix(recv, "*")) {
+			continue
+		}
+		if sym.Kind != store.SymbolKindFunc && sym.Kind != store.SymbolKindMethod {
+			continue
+		}
+		matches = append(matches, sym)
+	}
+
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("no function named %q in the index", ref)
+	case 1:
+		return matches[0].ID, nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q is ambiguous; use a symbol ID:", ref)
+	for _, sym := range matches {
+		fmt.Fprintf(&b, "\n  %d\t%s %s:%d", sym.ID, sym.PkgPath, sym.File, sym.Line)
+	}
+	return 0, fmt.Errorf("%s", b.String())
+}
+
+// resolveEntrypoint returns the handler of the entrypoint labelled label.
+func resolveEntrypoint(ctx context.Context, st *store.Store, label string) (store.SymbolID, error) {
+	eps, err := st.GetEntrypoints(ctx, store.EntrypointFilter{Query: label})
+	if err != nil {
+		return 0, fmt.Errorf("searching entrypoints: %w", err)
+	}
+	var matches []store.EntrypointWithSymbol
+	for _, ep := range eps {
+		if ep.Label == label {
+			matches = append(matches, ep)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("no entrypoint labelled %q in the index", label)
+	case 1:
+		return matches[0].SymbolID, nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q is ambiguous; use --symbol with a symbol ID:", label)
+	for _, ep := range matches {
+		fmt.Fprintf(&b, "\n  %d\t%s %s.%s", ep.SymbolID, ep.Type, ep.Symbol.PkgPath, ep.Symbol.Name)
+	}
+	return 0, fmt.Errorf("%s", b.String())
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportStructurizrCmd)
+	exportStructurizrCmd.Flags().StringVarP(&exportOut, "out", "o", "", "output file (default: stdout)")
+	exportStructurizrCmd.Flags().StringVar(&exportName, "name", "", "workspace and system name (default: project directory name)")
+
+	exportCmd.AddCommand(exportCFGCmd)
+	exportCFGCmd.Flags().StringVarP(&exportOut, "out", "o", "", "output file (default: stdout)")
+	exportCFGCmd.Flags().StringVarP(&exportSymbol, "symbol", "s", "", "function to export, by ID or name (e.g. Server.Handle)")
+	exportCFGCmd.Flags().StringVarP(&exportEntrypoint, "entrypoint", "e", "", "export the handler of the entrypoint with this label (e.g. \"GET /api/users\")")
+	exportCFGCmd.MarkFlagsMutuallyExclusive("symbol", "entrypoint")
+	exportCFGCmd.RegisterFlagCompletionFunc("symbol", completeSymbolNames)
+	exportCFGCmd.RegisterFlagCompletionFunc("entrypoint", completeEntrypointLabels)
+
+	exportCmd.AddCommand(exportRoutesCmd)
+	exportRoutesCmd.Flags().StringVarP(&exportOut, "out", "o", "", "output file (default: stdout)")
+	exportRoutesCmd.Flags().StringVarP(&exportFormat, "format", "f", docs.RouteFormatYAML, "route table format: "+strings.Join(docs.RouteFormats, ", "))
+	exportRoutesCmd.Flags().StringVar(&exportName, "name", "", "service, cluster, and route name prefix (default: project directory name)")
+	exportRoutesCmd.Flags().StringVar(&exportUpstream, "upstream", "http://localhost:8080", "upstream URL of the Kong service")
+	exportRoutesCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(docs.RouteFormats, cobra.ShellCompDirectiveNoFileComp))
+}
@@ -2,12 +2,24 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/abramin/flowlens/internal/index"
 	"github.com/spf13/cobra"
 )
 
+var (
+	indexPackages     []string
+	indexExclude      []string
+	indexDeps         bool
+	indexKeepWrappers bool
+	indexRepo         string
+	indexDB           string
+	indexSince        string
+	indexForce        bool
+)
+
 var indexCmd = &cobra.Command{
 	Use:   "index [path]",
 	Short: "Index a Go project and build the call graph",
@@ -18,7 +30,49 @@ The index command:
 - Builds SSA representation for accurate call graph
 - Detects entrypoints (HTTP, gRPC, CLI, main)
 - Tags functions with I/O boundaries and layer info
-- Persists results to .flowlens/index.db`,
+- Persists results to .flowlens/index.db
+
+Use --packages and --exclude (or the packages and exclude.packages config
+keys) to index only part of a monorepo, e.g.:
+
+  flowlens index --packages ./internal/billing/... --exclude ./cmd/legacytool/...
+
+Use --deps (or dependencies.index: true) to also record calls into
+third-party modules, for 'flowlens report deps'.
+
+Calls through the functions SSA synthesizes (method value and expression
+wrappers, promoted methods, generic instantiations) are stored against the
+function they wrap. Use --keep-wrappers (or wrappers.keep: true) to store
+the wrappers as symbols of their own when debugging the call graph.
+
+Use --repo and --db (or the repo and database config keys) to index several
+repositories into one database. Re-indexing a repository replaces only its
+own data, and calls between repositories are linked by module path:
+
+  flowlens index ./billing --repo billing --db ~/org/index.db
+  flowlens index ./billing-client --repo billing-client --db ~/org/index.db
+  flowlens ui --db ~/org/index.db
+
+Use --since to re-extract only the packages with files changed since a git
+ref (committed, uncommitted, or untracked), reusing the stored index for the
+rest. Entrypoints, tags and findings are still rebuilt for the whole project.
+Without a usable previous index, or when go.mod or go.sum changed, a full
+index runs instead:
+
+  flowlens index --since origin/main
+
+Interface calls from unchanged packages are not re-resolved against
+implementations added since the last full index.
+
+The index is written to .flowlens/index.db in the project by default. To
+keep it out of the worktree, e.g. for a read-only checkout, set
+index_location: cache in flowlens.yaml to use a directory per module under
+the user cache directory ($XDG_CACHE_HOME/flowlens), or set FLOWLENS_HOME
+to hold every project's index there. Other commands find it the same way.
+
+Only one run may write an index at a time; a second run fails with the PID
+of the one in progress. Locks left by runs that died are taken over; use
+--force to take over one held from another host (e.g. on a network mount).`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := "."
@@ -27,12 +81,34 @@ The index command:
 		}
 
 		cfg := GetConfig()
+		if len(indexPackages) > 0 {
+			cfg.Packages = indexPackages
+		}
+		cfg.Exclude.Packages = append(cfg.Exclude.Packages, indexExclude...)
+		if indexDeps {
+			cfg.Dependencies.Index = true
+		}
+		if indexKeepWrappers {
+			cfg.Wrappers.Keep = true
+		}
+		if indexRepo != "" {
+			cfg.Repo = indexRepo
+		}
+		if indexDB != "" {
+			absDB, err := filepath.Abs(indexDB)
+			if err != nil {
+				return fmt.Errorf("resolving database path: %w", err)
+			}
+			cfg.Database = absDB
+		}
 		fmt.Printf("Indexing project at: %s\n", path)
 		fmt.Printf("Config loaded with %d excluded dirs\n", len(cfg.Exclude.Dirs))
 
 		// Run the indexer
 		indexer := index.NewIndexer(cfg, path)
-		result, err := indexer.Run()
+		indexer.SetSince(indexSince)
+		indexer.SetForce(indexForce)
+		result, err := indexer.Run(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("indexing failed: %w", err)
 		}
@@ -40,16 +116,45 @@ The index command:
 		fmt.Println()
 		fmt.Printf("Indexing complete!\n")
 		fmt.Printf("  Packages:    %d\n", result.PackageCount)
+		if result.Since != "" {
+			fmt.Printf("    Changed:   %d since %s\n", result.ChangedPackages, result.Since)
+		}
 		fmt.Printf("  Symbols:     %d\n", result.SymbolCount)
 		fmt.Printf("  Call edges:  %d\n", result.CallEdgeCount)
 		fmt.Printf("    Static:    %d\n", result.StaticCalls)
 		fmt.Printf("    Defer:     %d\n", result.DeferCalls)
 		fmt.Printf("    Go:        %d\n", result.GoCalls)
+		if result.UnresolvedCalls > 0 || result.SkippedFunctions > 0 {
+			fmt.Printf("    Missing:   %d unresolved calls, %d functions skipped (see flowlens doctor)\n",
+				result.UnresolvedCalls, result.SkippedFunctions)
+		}
+		if cfg.Dependencies.Index {
+			fmt.Printf("  External:    %d calls into third-party modules\n", result.ExternalCalls)
+		}
 		fmt.Printf("  Entrypoints: %d\n", result.EntrypointCount)
 		fmt.Printf("    HTTP:      %d\n", result.HTTPEntrypoints)
 		fmt.Printf("    gRPC:      %d\n", result.GRPCEntrypoints)
 		fmt.Printf("    CLI:       %d\n", result.CLIEntrypoints)
 		fmt.Printf("    Main:      %d\n", result.MainEntrypoints)
+		if result.CustomEntrypoints > 0 {
+			fmt.Printf("    Custom:    %d\n", result.CustomEntrypoints)
+		}
+		if result.TaintFindings > 0 {
+			fmt.Printf("  Taint:       %d findings (see /api/reports/taint)\n", result.TaintFindings)
+		}
+		if result.MissingAuth > 0 {
+			fmt.Printf("  No auth:     %d HTTP entrypoints (see flowlens report auth)\n", result.MissingAuth)
+		}
+		if result.UnrecoveredPanics > 0 {
+			fmt.Printf("  Panics:      %d entrypoints without recover (see /api/reports/panics)\n", result.UnrecoveredPanics)
+		}
+		if result.Diagnostics > 0 {
+			fmt.Printf("  Diagnostics: %d package loading errors (see flowlens doctor)\n", result.Diagnostics)
+		}
+		if result.Changes != nil {
+			fmt.Printf("  Changes:     %d added, %d removed, %d relocated, %d moved\n",
+				result.Changes.Added, result.Changes.Removed, result.Changes.Relocated, result.Changes.Moved)
+		}
 		fmt.Printf("  Duration:    %s\n", result.Duration.Round(time.Millisecond))
 		fmt.Printf("  Database:    %s\n", result.DBPath)
 		return nil
@@ -58,4 +163,12 @@ The index command:
 
 func init() {
 	rootCmd.AddCommand(indexCmd)
+	indexCmd.Flags().StringSliceVar(&indexPackages, "packages", nil, "package patterns to index (default: ./..., overrides config)")
+	indexCmd.Flags().StringSliceVar(&indexExclude, "exclude", nil, "package patterns to skip (added to config exclude.packages)")
+	indexCmd.Flags().BoolVar(&indexDeps, "deps", false, "record calls into third-party modules (dependencies.index)")
+	indexCmd.Flags().BoolVar(&indexKeepWrappers, "keep-wrappers", false, "store SSA wrapper functions as symbols instead of collapsing calls through them (wrappers.keep)")
+	indexCmd.Flags().StringVar(&indexRepo, "repo", "", "repository name within a shared index (overrides config repo)")
+	indexCmd.Flags().StringVar(&indexDB, "db", "", "index database path (default: <path>/.flowlens/index.db, overrides config database)")
+	indexCmd.Flags().StringVar(&indexSince, "since", "", "re-extract only packages changed since this git ref")
+	indexCmd.Flags().BoolVar(&indexForce, "force", false, "take over the index lock of another run")
 }
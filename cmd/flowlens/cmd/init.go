@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var initForce bool
+
+var initCmd = &cobra.Command{
+	Use:   "init [project-dir]",
+	Short: "Write a starter flowlens.yaml for a project",
+	Long: `Inspect a project and write a starter flowlens.yaml.
+
+The init command:
+- Detects layers from directory names (handlers/, service/, repo/, domain/, ...)
+- Keeps only the I/O packages the project uses (standard library and go.mod)
+- Suggests noise packages (logging, metrics, tracing) found in go.mod
+
+An existing flowlens.yaml is left untouched unless --force is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectDir := "."
+		if len(args) > 0 {
+			projectDir = args[0]
+		}
+
+		absDir, err := filepath.Abs(projectDir)
+		if err != nil {
+			return fmt.Errorf("resolving path: %w", err)
+		}
+
+		outPath := filepath.Join(absDir, "flowlens.yaml")
+		if _, err := os.Stat(outPath); err == nil && !initForce {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", outPath)
+		}
+
+		sc, err := config.Detect(absDir)
+		if err != nil {
+			return fmt.Errorf("detecting project layout: %w", err)
+		}
+		data, err := sc.YAML()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(outPath, data, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", outPath, err)
+		}
+
+		fmt.Printf("Wrote %s\n", outPath)
+		if sc.FromLayout {
+			layers := make([]string, 0, len(sc.LayerDirs))
+			for layer := range sc.LayerDirs {
+				layers = append(layers, layer)
+			}
+			sort.Strings(layers)
+			for _, layer := range layers {
+				fmt.Printf("  %-8s %s\n", layer+":", strings.Join(sc.LayerDirs[layer], ", "))
+			}
+		} else {
+			fmt.Println("  No layer directories detected; using default layer patterns")
+		}
+		fmt.Printf("  I/O categories: %d, noise packages: %d\n", len(sc.Config.IOPackages), len(sc.Config.NoisePackages))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().BoolVar(&initForce, "force", false, "overwrite an existing flowlens.yaml")
+	initCmd.ValidArgsFunction = completeProjectDir
+}
@@ -0,0 +1,416 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/sarif"
+	"github.com/abramin/flowlens/internal/store"
+	"github.com/abramin/flowlens/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportFormat string
+	reportOut    string
+	reportAll    bool
+	reportModule string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Print analysis reports from the index",
+}
+
+var reportAuthCmd = &cobra.Command{
+	Use:   "auth [project-dir]",
+	Short: "Report HTTP entrypoints without authentication",
+	Long: `Report HTTP routes that are neither wrapped by a configured auth middleware
+nor reach an auth check through their handler's calls.
+
+Auth middleware and public routes are configured under 'auth:' in
+flowlens.yaml; re-run 'flowlens index' after changing them.
+
+Formats:
+- text:  routes missing auth (all routes with --all)
+- json:  auth check results with the middleware chain or call path found
+- sarif: SARIF 2.1.0 for code scanning, one result per route missing auth`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch reportFormat {
+		case "text", "json", "sarif":
+		default:
+			return fmt.Errorf("invalid format %q (want text, json, or sarif)", reportFormat)
+		}
+
+		st, absDir, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		status := store.AuthMissing
+		if reportAll {
+			status = ""
+		}
+		checks, err := st.GetAuthChecks(cmd.Context(), status)
+		if err != nil {
+			return fmt.Errorf("getting auth checks: %w", err)
+		}
+
+		out, closeOut, err := reportWriter()
+		if err != nil {
+			return err
+		}
+		defer closeOut()
+
+		switch reportFormat {
+		case "json":
+			if checks == nil {
+				checks = []store.AuthCheck{}
+			}
+			err = writeReportJSON(out, checks)
+		case "sarif":
+			err = writeReportJSON(out, sarif.FromAuthChecks(checks, absDir, version.Get().Version))
+		default:
+			writeAuthText(out, checks, absDir)
+		}
+		if err != nil {
+			return err
+		}
+		if reportOut != "" {
+			fmt.Printf("Wrote %s\n", reportOut)
+		}
+		return nil
+	},
+}
+
+var reportDepsCmd = &cobra.Command{
+	Use:   "deps [project-dir]",
+	Short: "Report the third-party modules reachable from each entrypoint",
+	Long: `Report, for every entrypoint, the third-party modules its call graph reaches
+and the functions of each module it calls, to scope dependency risk
+("only the webhook route uses libfoo").
+
+Requires an index built with 'flowlens index --deps' (or dependencies.index:
+true in flowlens.yaml). Use --module to list only the entrypoints reaching
+one module.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch reportFormat {
+		case "text", "json":
+		default:
+			return fmt.Errorf("invalid format %q (want text or json)", reportFormat)
+		}
+
+		st, _, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		if indexed, _ := st.GetMetadata(cmd.Context(), "dependencies_indexed"); indexed != "true" {
+			return fmt.Errorf("the index has no dependency calls\nRe-run 'flowlens index --deps' to record them")
+		}
+		deps, err := st.GetEntrypointDependencies(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("getting dependencies: %w", err)
+		}
+		if reportModule != "" {
+			deps = store.FilterDependencies(deps, reportModule)
+		}
+
+		out, closeOut, err := reportWriter()
+		if err != nil {
+			return err
+		}
+		defer closeOut()
+
+		if reportFormat == "json" {
+			if deps == nil {
+				deps = []store.EntrypointDependencies{}
+			}
+			err = writeReportJSON(out, deps)
+		} else {
+			writeDepsText(out, deps)
+		}
+		if err != nil {
+			return err
+		}
+		if reportOut != "" {
+			fmt.Printf("Wrote %s\n", reportOut)
+		}
+		return nil
+	},
+}
+
+var reportOrphansCmd = &cobra.Command{
+	Use:   "orphans [project-dir]",
+	Short: "Report HTTP handlers that no route or entrypoint reaches",
+	Long: `Report functions with HTTP handler signatures (func(http.ResponseWriter,
+*http.Request) and the gin, echo, and chi equivalents) that are not wired
+up: no router registration or other entrypoint calls them or references
+them from code it reaches. These are likely dead endpoints or forgotten
+registrations.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch reportFormat {
+		case "text", "json":
+		default:
+			return fmt.Errorf("invalid format %q (want text or json)", reportFormat)
+		}
+
+		st, absDir, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		orphans, err := st.GetOrphanedHandlers(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("finding orphaned handlers: %w", err)
+		}
+
+		out, closeOut, err := reportWriter()
+		if err != nil {
+			return err
+		}
+		defer closeOut()
+
+		if reportFormat == "json" {
+			if orphans == nil {
+				orphans = []store.EntrypointWithSymbol{}
+			}
+			err = writeReportJSON(out, orphans)
+		} else {
+			writeOrphansText(out, orphans, absDir)
+		}
+		if err != nil {
+			return err
+		}
+		if reportOut != "" {
+			fmt.Printf("Wrote %s\n", reportOut)
+		}
+		return nil
+	},
+}
+
+var reportGRPCCmd = &cobra.Command{
+	Use:   "grpc [project-dir]",
+	Short: "Report gRPC service methods without an implementation",
+	Long: `Compare the gRPC service interfaces generated from .proto files with the
+project types implementing them: methods no registered type implements,
+which fall through to the embedded UnimplementedXServer and return
+codes.Unimplemented, and implementations never passed to RegisterXServer.
+
+Services are found through their generated RegisterXServer functions, so
+the *.pb.go files need not be indexed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch reportFormat {
+		case "text", "json":
+		default:
+			return fmt.Errorf("invalid format %q (want text or json)", reportFormat)
+		}
+
+		st, absDir, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		services, err := st.GetGRPCCoverage(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("getting gRPC coverage: %w", err)
+		}
+
+		out, closeOut, err := reportWriter()
+		if err != nil {
+			return err
+		}
+		defer closeOut()
+
+		if reportFormat == "json" {
+			err = writeReportJSON(out, services)
+		} else {
+			writeGRPCText(out, services, absDir)
+		}
+		if err != nil {
+			return err
+		}
+		if reportOut != "" {
+			fmt.Printf("Wrote %s\n", reportOut)
+		}
+		return nil
+	},
+}
+
+// openReportStore opens the index of the project in args (default: the
+// current directory) and returns it with the project's absolute path.
+func openReportStore(args []string) (*store.Store, string, error) {
+	projectDir := "."
+	if len(args) > 0 {
+		projectDir = args[0]
+	}
+
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving path: %w", err)
+	}
+
+	indexPath := GetConfig().DatabasePath(absDir)
+	if !store.Exists(indexPath) {
+		return nil, "", fmt.Errorf("no FlowLens index found at %s\nRun 'flowlens index %s' first to create the index", indexPath, absDir)
+	}
+
+	st, err := openIndex(indexPath, absDir, false)
+	if err != nil {
+		return nil, "", fmt.Errorf("opening store: %w", err)
+	}
+	return st, absDir, nil
+}
+
+// reportWriter returns the --out file, or stdout when it is not set.
+func reportWriter() (io.Writer, func(), error) {
+	if reportOut == "" {
+		return os.Stdout, func() {}, nil
+	}
+	f, err := os.Create(reportOut)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating %s: %w", reportOut, err)
+	}
+	return f, func() { f.Close() }, nil
+}
+
+func writeReportJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeAuthText prints one line per route: status, label, handler location,
+// and the middleware or call path that authenticates it.
+func writeAuthText(w io.Writer, checks []store.AuthCheck, projectDir string) {
+	missing := 0
+	for _, c := range checks {
+		if c.Status == store.AuthMissing {
+			missing++
+		}
+		file := c.File
+		if rel, err := filepath.Rel(projectDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+		line := fmt.Sprintf("%-10s  %s  (%s:%d)", c.Status, c.EntrypointLabel, file, c.Line)
+		if len(c.Via) > 0 {
+			line += "  via " + strings.Join(c.Via, " -> ")
+		}
+		fmt.Fprintln(w, line)
+	}
+	if missing == 0 {
+		fmt.Fprintln(w, "All HTTP entrypoints are authenticated or public.")
+		return
+	}
+	fmt.Fprintf(w, "\n%d HTTP entrypoints without auth\n", missing)
+}
+
+// writeDepsText prints each entrypoint that reaches third-party code with
+// its modules and the functions called in them.
+func writeDepsText(w io.Writer, deps []store.EntrypointDependencies) {
+	shown := 0
+	for _, ep := range deps {
+		if len(ep.Modules) == 0 {
+			continue
+		}
+		shown++
+		fmt.Fprintf(w, "%s\n", ep.Label)
+		for _, m := range ep.Modules {
+			if m.Version != "" {
+				fmt.Fprintf(w, "  %s %s\n", m.Module, m.Version)
+			} else {
+				fmt.Fprintf(w, "  %s\n", m.Module)
+			}
+			for _, fn := range m.Funcs {
+				fmt.Fprintf(w, "    %s\n", fn)
+			}
+		}
+	}
+	if shown == 0 {
+		fmt.Fprintln(w, "No entrypoint reaches third-party modules.")
+	}
+}
+
+// writeOrphansText prints one line per orphaned handler with its location.
+func writeOrphansText(w io.Writer, orphans []store.EntrypointWithSymbol, projectDir string) {
+	for _, ep := range orphans {
+		file := ep.Symbol.File
+		if rel, err := filepath.Rel(projectDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+		fmt.Fprintf(w, "%s.%s  (%s:%d)\n", ep.Symbol.PkgPath, ep.Label, file, ep.Symbol.Line)
+	}
+	if len(orphans) == 0 {
+		fmt.Fprintln(w, "Every handler is reached from a route or entrypoint.")
+		return
+	}
+	fmt.Fprintf(w, "\n%d orphaned handlers\n", len(orphans))
+}
+
+// writeGRPCText prints each service with its unimplemented methods and its
+// implementations.
+func writeGRPCText(w io.Writer, services []store.GRPCServiceCoverage, projectDir string) {
+	if len(services) == 0 {
+		fmt.Fprintln(w, "No generated gRPC services found.")
+		return
+	}
+	for i, svc := range services {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s.%s (%d methods)\n", svc.PkgPath, svc.Service, len(svc.Methods))
+		if len(svc.Unimplemented) > 0 {
+			fmt.Fprintf(w, "  unimplemented: %s\n", strings.Join(svc.Unimplemented, ", "))
+		}
+		if len(svc.Implementations) == 0 {
+			fmt.Fprintln(w, "  no implementation")
+		}
+		for _, impl := range svc.Implementations {
+			line := fmt.Sprintf("  %s.%s  (%s:%d)", impl.Type.PkgPath, impl.Type.Name, relPath(projectDir, impl.Type.File), impl.Type.Line)
+			if !impl.Registered {
+				line += "  not registered"
+			}
+			if len(impl.Missing) > 0 {
+				line += "  inherits " + strings.Join(impl.Missing, ", ")
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportAuthCmd)
+	reportAuthCmd.Flags().StringVarP(&reportFormat, "format", "f", "text", "output format: text, json, or sarif")
+	reportAuthCmd.Flags().StringVarP(&reportOut, "out", "o", "", "output file (default: stdout)")
+	reportAuthCmd.Flags().BoolVar(&reportAll, "all", false, "include authenticated and public routes (text and json)")
+	reportAuthCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json", "sarif"}, cobra.ShellCompDirectiveNoFileComp))
+
+	reportCmd.AddCommand(reportDepsCmd)
+	reportDepsCmd.Flags().StringVarP(&reportFormat, "format", "f", "text", "output format: text or json")
+	reportDepsCmd.Flags().StringVarP(&reportOut, "out", "o", "", "output file (default: stdout)")
+	reportDepsCmd.Flags().StringVar(&reportModule, "module", "", "only entrypoints reaching this module path")
+	reportDepsCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	reportCmd.AddCommand(reportOrphansCmd)
+	reportOrphansCmd.Flags().StringVarP(&reportFormat, "format", "f", "text", "output format: text or json")
+	reportOrphansCmd.Flags().StringVarP(&reportOut, "out", "o", "", "output file (default: stdout)")
+	reportOrphansCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	reportCmd.AddCommand(reportGRPCCmd)
+	reportGRPCCmd.Flags().StringVarP(&reportFormat, "format", "f", "text", "output format: text or json")
+	reportGRPCCmd.Flags().StringVarP(&reportOut, "out", "o", "", "output file (default: stdout)")
+	reportGRPCCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
 	"github.com/spf13/cobra"
 )
 
@@ -41,3 +42,13 @@ func init() {
 func GetConfig() *config.Config {
 	return cfg
 }
+
+// openIndex opens the index at indexPath for the project in absDir,
+// decrypting it while open when FLOWLENS_INDEX_KEY is set.
+func openIndex(indexPath, absDir string, readOnly bool) (*store.Store, error) {
+	key, err := config.IndexKey()
+	if err != nil {
+		return nil, err
+	}
+	return store.OpenEncrypted(indexPath, absDir, key, readOnly)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/abramin/flowlens/internal/selftest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	selftestJSON    bool
+	selftestVerbose bool
+)
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest [path]",
+	Short: "Index FlowLens itself and check the results",
+	Long: `Index the FlowLens source tree into a temporary directory and query the
+result through the API: entrypoint discovery, symbol search, the call graph
+from the index command, the spine of (*Indexer).Run, and its CFG.
+
+Without a path, the source is the FlowLens checkout containing the working
+directory, or the module cache copy matching this binary's version when it
+was installed with go install.
+
+The command fails when any check fails. Use --json for machine-readable
+output and --verbose to see indexer progress.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := selftest.Options{}
+		if len(args) > 0 {
+			absDir, err := filepath.Abs(args[0])
+			if err != nil {
+				return fmt.Errorf("resolving path: %w", err)
+			}
+			opts.Dir = absDir
+		}
+		if selftestVerbose {
+			opts.Log = os.Stderr
+		}
+
+		report, err := selftest.Run(cmd.Context(), opts)
+		if err != nil {
+			return fmt.Errorf("self-test failed: %w", err)
+		}
+
+		if selftestJSON {
+			if err := writeReportJSON(os.Stdout, report); err != nil {
+				return err
+			}
+		} else {
+			writeSelftestText(os.Stdout, report)
+		}
+		if !report.Passed {
+			return fmt.Errorf("self-test failed")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(selftestCmd)
+	selftestCmd.Flags().BoolVar(&selftestJSON, "json", false, "output as JSON")
+	selftestCmd.Flags().BoolVarP(&selftestVerbose, "verbose", "v", false, "show indexer progress")
+}
+
+// writeSelftestText prints one line per check.
+func writeSelftestText(w io.Writer, r *selftest.Report) {
+	fmt.Fprintf(w, "Source: %s\n\n", r.Dir)
+	for _, c := range r.Checks {
+		status := "PASS"
+		if !c.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s  %-12s  %s (%s)\n", status, c.Name, c.Detail, c.Duration.Round(time.Millisecond))
+	}
+}
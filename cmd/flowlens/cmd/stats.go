@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/abramin/flowlens/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsJSON bool
+	statsTop  int
+)
+
+// StatsReport is the JSON form of 'flowlens stats'.
+type StatsReport struct {
+	store.Stats
+	Packages []store.PackageStats `json:"packages"`
+	Tags     []store.TagCount     `json:"tags"`
+	TopFanIn []store.FanIn        `json:"top_fan_in"` // Most called symbols, by distinct callers
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [project-dir]",
+	Short: "Print index statistics",
+	Long: `Print the size of the index: totals of packages, symbols, call edges,
+entrypoints, and tags, followed by the symbols and call edges of each
+package, how many symbols carry each tag, and the symbols with the most
+distinct callers.
+
+Use --json for machine-readable output.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if statsTop < 0 {
+			return fmt.Errorf("invalid --top %d (want 0 or more)", statsTop)
+		}
+
+		st, _, err := openReportStore(args)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		ctx := cmd.Context()
+		stats, err := st.GetStats(ctx)
+		if err != nil {
+			return fmt.Errorf("getting stats: %w", err)
+		}
+		report := StatsReport{Stats: *stats}
+		if report.Packages, err = st.GetPackageStats(ctx); err != nil {
+			return fmt.Errorf("getting package stats: %w", err)
+		}
+		if report.Tags, err = st.GetTagCounts(ctx); err != nil {
+			return fmt.Errorf("getting tag counts: %w", err)
+		}
+		if report.TopFanIn, err = st.GetTopFanIn(ctx, statsTop); err != nil {
+			return fmt.Errorf("getting fan-in: %w", err)
+		}
+
+		if statsJSON {
+			if report.Packages == nil {
+				report.Packages = []store.PackageStats{}
+			}
+			if report.Tags == nil {
+				report.Tags = []store.TagCount{}
+			}
+			if report.TopFanIn == nil {
+				report.TopFanIn = []store.FanIn{}
+			}
+			return writeReportJSON(os.Stdout, report)
+		}
+		writeStatsText(os.Stdout, &report)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "output as JSON")
+	statsCmd.Flags().IntVar(&statsTop, "top", 10, "number of most called symbols to show")
+}
+
+// writeStatsText prints the totals followed by the per-package, tag, and
+// fan-in tables.
+func writeStatsText(w io.Writer, r *StatsReport) {
+	if !r.IndexedAt.IsZero() {
+		fmt.Fprintf(w, "Indexed:      %s\n", r.IndexedAt.Format("2006-01-02 15:04:05"))
+	}
+	if r.VCS != nil {
+		fmt.Fprintf(w, "Revision:     %s\n", formatVCSInfo(r.VCS))
+	}
+	fmt.Fprintf(w, "Packages:     %d\n", r.PackageCount)
+	fmt.Fprintf(w, "Symbols:      %d\n", r.SymbolCount)
+	fmt.Fprintf(w, "Call edges:   %d\n", r.CallEdgeCount)
+	fmt.Fprintf(w, "Entrypoints:  %d\n", r.EntrypointCount)
+	fmt.Fprintf(w, "Tags:         %d\n", r.TagCount)
+	unresolved := 0
+	for _, n := range r.UnresolvedCalls {
+		unresolved += n
+	}
+	if unresolved > 0 || r.SkippedFunctions > 0 {
+		fmt.Fprintf(w, "Unresolved:   %d calls, %d functions skipped (see 'flowlens doctor')\n", unresolved, r.SkippedFunctions)
+	}
+
+	if len(r.Packages) > 0 {
+		fmt.Fprintf(w, "\n%8s  %8s  %s\n", "SYMBOLS", "EDGES", "PACKAGE")
+		for _, p := range r.Packages {
+			line := fmt.Sprintf("%8d  %8d  %s", p.SymbolCount, p.EdgeCount, p.PkgPath)
+			if p.Layer != "" {
+				line += "  [" + p.Layer + "]"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	if len(r.Tags) > 0 {
+		fmt.Fprintf(w, "\n%8s  %s\n", "SYMBOLS", "TAG")
+		for _, t := range r.Tags {
+			fmt.Fprintf(w, "%8d  %s\n", t.Count, t.Tag)
+		}
+	}
+
+	if len(r.TopFanIn) > 0 {
+		fmt.Fprintf(w, "\n%8s  %s\n", "CALLERS", "SYMBOL")
+		for _, f := range r.TopFanIn {
+			fmt.Fprintf(w, "%8d  %s\n", f.Callers, symbolDisplayName(&f.Symbol))
+		}
+	}
+}
+
+// symbolDisplayName formats a symbol as pkg.Name or pkg.(Recv).Name.
+func symbolDisplayName(sym *store.Symbol) string {
+	if sym.RecvType != "" {
+		return fmt.Sprintf("%s.(%s).%s", sym.PkgPath, sym.RecvType, sym.Name)
+	}
+	return sym.PkgPath + "." + sym.Name
+}
+
+// formatVCSInfo formats a revision as "abc1234 on main (dirty)".
+func formatVCSInfo(v *store.VCSInfo) string {
+	s := v.Commit
+	if len(s) > 12 {
+		s = s[:12]
+	}
+	if v.Branch != "" {
+		s += " on " + v.Branch
+	}
+	if v.Dirty {
+		s += " (dirty)"
+	}
+	return s
+}
@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"syscall"
+	"time"
 
+	"github.com/abramin/flowlens/internal/config"
 	"github.com/abramin/flowlens/internal/server"
+	"github.com/abramin/flowlens/internal/store"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +20,24 @@ var (
 	uiPort      int
 	uiNoBrowser bool
 	uiDir       string
+	uiTimeout   time.Duration
+	uiDB        string
+	uiReadOnly  bool
+
+	uiMaxGraphNodes int
+	uiMaxGraphEdges int
+	uiGraphTimeout  time.Duration
+	uiWarmGraphs    int
+	uiMemoryCalls   int
+
+	uiWorkers       int
+	uiQueueSize     int
+	uiQueueWait     time.Duration
+	uiRequestBudget time.Duration
+
+	uiDaemon      bool
+	uiStop        bool
+	uiIdleTimeout time.Duration
 )
 
 var uiCmd = &cobra.Command{
@@ -29,7 +52,37 @@ The UI provides:
 - Filtering and export capabilities
 
 The server connects to the SQLite index created by 'flowlens index'.
-Make sure to run 'flowlens index' first to create the index.`,
+Make sure to run 'flowlens index' first to create the index.
+
+Use --read-only to serve the index without changing it, e.g. from a shared
+mount; saving bookmarks, views, shares, and manual edges is then disabled.
+An index that can't be written is always served read-only.
+
+On startup and after each reindex the server builds the graphs of the first
+--warm-graphs entrypoints (starred ones first) in the background, so opening
+them doesn't wait on the index.
+
+For indexes with up to --memory-graph-max-calls calls, the server also loads
+the call graph (symbols, tags, callers, and callees) into memory on startup
+and after each reindex; graph, spine, and symbol requests then traverse it
+without querying the index.
+
+When several people share one server, at most --workers graph, spine, and
+CFG builds run at once; up to --queue-size more wait for a worker for at
+most --queue-wait, and the rest get a 503 to retry. The index queries of
+each API request must finish within --request-budget. Queue and budget
+counters are reported by /api/health.
+
+Use --daemon to start the server in the background, e.g. from an editor
+plugin: it returns once the server listens, printing its URL, and the
+server writes a pidfile (<index>.ui.pid, JSON with the PID, port, and URL)
+and logs to <index>.ui.log. If a server is already running for the index
+it is reused. With --idle-timeout the server shuts down after that long
+without requests. 'flowlens ui --stop' stops the background server of the
+project.
+
+  flowlens ui --daemon --idle-timeout 2h
+  flowlens ui --stop`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Determine project directory
@@ -53,15 +106,57 @@ Make sure to run 'flowlens index' first to create the index.`,
 		}
 
 		// Check if index exists
-		indexPath := filepath.Join(absDir, ".flowlens", "index.db")
-		if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+		indexPath := GetConfig().DatabasePath(absDir)
+		if uiDB != "" {
+			if indexPath, err = filepath.Abs(uiDB); err != nil {
+				return fmt.Errorf("resolving database path: %w", err)
+			}
+		}
+		if uiStop {
+			return stopDaemon(indexPath)
+		}
+		if !store.Exists(indexPath) {
 			return fmt.Errorf("no FlowLens index found at %s\nRun 'flowlens index %s' first to create the index", indexPath, absDir)
 		}
 
+		if uiDaemon && !isDaemonChild() {
+			return startDaemon(indexPath)
+		}
+		var pidFile string
+		if isDaemonChild() {
+			// Outlive the terminal the daemon was started from
+			signal.Ignore(syscall.SIGHUP)
+			pidFile = server.PIDFilePath(indexPath)
+		}
+
 		// Create and start server
+		readOnly := uiReadOnly || !writable(indexPath)
+		key, err := config.IndexKey()
+		if err != nil {
+			return err
+		}
+
 		srv, err := server.New(server.Config{
-			Port:       uiPort,
-			ProjectDir: absDir,
+			Port:                uiPort,
+			ProjectDir:          absDir,
+			DBPath:              indexPath,
+			ReadOnly:            readOnly,
+			IndexKey:            key,
+			QueryTimeout:        uiTimeout,
+			StdlibAllow:         GetConfig().StdlibAllow,
+			WarmGraphs:          uiWarmGraphs,
+			MemoryGraphMaxCalls: uiMemoryCalls,
+			Workers:             uiWorkers,
+			QueueSize:           uiQueueSize,
+			QueueWait:           uiQueueWait,
+			RequestBudget:       uiRequestBudget,
+			IdleTimeout:         uiIdleTimeout,
+			PIDFile:             pidFile,
+			GraphLimits: server.GraphLimits{
+				MaxNodes: uiMaxGraphNodes,
+				MaxEdges: uiMaxGraphEdges,
+				Timeout:  uiGraphTimeout,
+			},
 		})
 		if err != nil {
 			return fmt.Errorf("creating server: %w", err)
@@ -70,6 +165,9 @@ Make sure to run 'flowlens index' first to create the index.`,
 		url := fmt.Sprintf("http://localhost:%d", uiPort)
 		fmt.Printf("Starting FlowLens UI server at %s\n", url)
 		fmt.Printf("Project: %s\n", absDir)
+		if readOnly {
+			fmt.Println("Serving the index read-only")
+		}
 		fmt.Println("Press Ctrl+C to stop")
 
 		// Open browser
@@ -86,6 +184,42 @@ func init() {
 	uiCmd.Flags().IntVarP(&uiPort, "port", "p", 8080, "port to run the UI server on")
 	uiCmd.Flags().BoolVar(&uiNoBrowser, "no-browser", false, "don't open browser automatically")
 	uiCmd.Flags().StringVarP(&uiDir, "dir", "d", "", "project directory (default: current directory)")
+	uiCmd.Flags().BoolVar(&uiReadOnly, "read-only", false, "open the index read-only (bookmarks, views, shares, and manual edges can't be saved)")
+	uiCmd.Flags().StringVar(&uiDB, "db", "", "index database, e.g. one shared by several repositories (default: <project-dir>/.flowlens/index.db)")
+	uiCmd.Flags().DurationVar(&uiTimeout, "query-timeout", 10*time.Second, "timeout for each index query (0 = none)")
+	uiCmd.Flags().IntVar(&uiMaxGraphNodes, "max-graph-nodes", server.DefaultMaxGraphNodes, "reject graphs with more nodes than this (0 = no limit)")
+	uiCmd.Flags().IntVar(&uiMaxGraphEdges, "max-graph-edges", server.DefaultMaxGraphEdges, "reject graphs with more edges than this (0 = no limit)")
+	uiCmd.Flags().DurationVar(&uiGraphTimeout, "graph-timeout", server.DefaultGraphTimeout, "reject graphs that take longer than this to build (0 = no limit)")
+	uiCmd.Flags().IntVar(&uiWarmGraphs, "warm-graphs", server.DefaultWarmGraphs, "entrypoint graphs to build in the background on startup and after a reindex (0 = none)")
+	uiCmd.Flags().IntVar(&uiMemoryCalls, "memory-graph-max-calls", server.DefaultMemoryGraphMaxCalls, "hold the call graph in memory for indexes with up to this many calls (negative = never)")
+	uiCmd.Flags().IntVar(&uiWorkers, "workers", server.DefaultWorkers, "graph, spine, and CFG builds to run at once (0 = no limit)")
+	uiCmd.Flags().IntVar(&uiQueueSize, "queue-size", server.DefaultQueueSize, "builds to queue for a worker before rejecting more (0 = no limit)")
+	uiCmd.Flags().DurationVar(&uiQueueWait, "queue-wait", server.DefaultQueueWait, "longest a build waits for a worker before it is rejected (0 = no limit)")
+	uiCmd.Flags().DurationVar(&uiRequestBudget, "request-budget", server.DefaultRequestBudget, "time allowed for the index queries of each API request (0 = no limit)")
+	uiCmd.Flags().BoolVar(&uiDaemon, "daemon", false, "run the server in the background, writing a pidfile next to the index")
+	uiCmd.Flags().DurationVar(&uiIdleTimeout, "idle-timeout", 0, "shut down after this long without requests (0 = never)")
+	uiCmd.Flags().BoolVar(&uiStop, "stop", false, "stop the background server of the project")
+	uiCmd.MarkFlagsMutuallyExclusive("daemon", "stop")
+}
+
+// writable reports whether the index file and its directory can be written,
+// which SQLite needs for the index's WAL.
+func writable(path string) bool {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		path = store.EncryptedPath(path) // Decrypted only while open
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	probe, err := os.CreateTemp(filepath.Dir(path), ".flowlens-probe-*")
+	if err != nil {
+		return false
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return true
 }
 
 // openBrowser opens the default browser to the given URL.
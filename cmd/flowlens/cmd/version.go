@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/abramin/flowlens/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var versionJSON bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version and build information",
+	Long: `Print the flowlens version, commit, Go version, and the index schema
+version this binary supports. Include this output in bug reports.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := version.Get()
+		if versionJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(info)
+		}
+
+		fmt.Printf("flowlens %s\n", info.Version)
+		fmt.Printf("  commit:         %s\n", info.Commit)
+		if info.BuildDate != "" {
+			fmt.Printf("  built:          %s\n", info.BuildDate)
+		}
+		fmt.Printf("  go:             %s\n", info.GoVersion)
+		fmt.Printf("  schema version: %d\n", info.SchemaVersion)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "output as JSON")
+
+	// Also support `flowlens --version`
+	info := version.Get()
+	rootCmd.Version = info.Version
+	rootCmd.SetVersionTemplate(fmt.Sprintf("flowlens {{.Version}} (commit %s, %s, schema %d)\n",
+		info.Commit, info.GoVersion, info.SchemaVersion))
+}
@@ -0,0 +1,186 @@
+// Package bench times indexing runs and compares them against a baseline,
+// so performance regressions in the indexer are caught.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/index"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// Options configures a benchmark.
+type Options struct {
+	Dir      string         // Project to index; empty generates the synthetic corpus
+	Services int            // Size of the synthetic corpus (see WriteCorpus)
+	Runs     int            // Indexing runs to time
+	Config   *config.Config // Config for Dir; the synthetic corpus uses the defaults
+	Log      io.Writer      // Receives a line per run; nil for none
+}
+
+// Report summarizes the runs of a benchmark.
+type Report struct {
+	Corpus    string         `json:"corpus"` // "synthetic:<services>" or the indexed directory
+	GoVersion string         `json:"go_version"`
+	Runs      int            `json:"runs"`
+	Packages  int            `json:"packages"`
+	Symbols   int            `json:"symbols"`
+	CallEdges int            `json:"call_edges"`
+	Phases    []PhaseSummary `json:"phases"` // Indexer phases in run order, then "total"
+}
+
+// PhaseSummary aggregates one phase over the runs of a benchmark.
+type PhaseSummary struct {
+	Name       string  `json:"name"`
+	MedianMS   float64 `json:"median_ms"`
+	MinMS      float64 `json:"min_ms"`
+	MaxMS      float64 `json:"max_ms"`
+	AllocBytes uint64  `json:"alloc_bytes"` // Median heap allocated during the phase
+	HeapBytes  uint64  `json:"heap_bytes"`  // Largest heap in use at the end of the phase
+}
+
+// Run indexes the project, or the synthetic corpus, opts.Runs times into a
+// temporary database. Every run is a full index of an empty database.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if opts.Runs < 1 {
+		return nil, fmt.Errorf("invalid run count %d (want 1 or more)", opts.Runs)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "flowlens-bench-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dir, corpus := opts.Dir, opts.Dir
+	cfg := config.Default()
+	if dir == "" {
+		dir = filepath.Join(tmpDir, "corpus")
+		corpus = fmt.Sprintf("synthetic:%d", opts.Services)
+		if err := WriteCorpus(dir, opts.Services); err != nil {
+			return nil, fmt.Errorf("writing corpus: %w", err)
+		}
+	} else if opts.Config != nil {
+		c := *opts.Config
+		cfg = &c
+	}
+	cfg.Database = filepath.Join(tmpDir, "index.db")
+
+	report := &Report{Corpus: corpus, GoVersion: runtime.Version(), Runs: opts.Runs}
+	var runs [][]index.PhaseStats
+	for i := range opts.Runs {
+		if err := store.RemoveFiles(cfg.Database); err != nil {
+			return nil, err
+		}
+		idx := index.NewIndexer(cfg, dir)
+		idx.SetOutput(io.Discard)
+		result, err := idx.Run(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("run %d: %w", i+1, err)
+		}
+		if opts.Log != nil {
+			fmt.Fprintf(opts.Log, "Run %d/%d: %s\n", i+1, opts.Runs, result.Duration.Round(time.Millisecond))
+		}
+		phases := append(result.Phases, index.PhaseStats{Name: "total", Duration: result.Duration})
+		for _, p := range result.Phases {
+			phases[len(phases)-1].AllocBytes += p.AllocBytes
+			phases[len(phases)-1].HeapBytes = max(phases[len(phases)-1].HeapBytes, p.HeapBytes)
+		}
+		runs = append(runs, phases)
+		report.Packages, report.Symbols, report.CallEdges = result.PackageCount, result.SymbolCount, result.CallEdgeCount
+	}
+	report.Phases = summarize(runs)
+	return report, nil
+}
+
+// summarize aggregates the phases of each run by name, in the order of the
+// first run.
+func summarize(runs [][]index.PhaseStats) []PhaseSummary {
+	var summaries []PhaseSummary
+	for _, first := range runs[0] {
+		var durations []time.Duration
+		var allocs []uint64
+		s := PhaseSummary{Name: first.Name}
+		for _, phases := range runs {
+			for _, p := range phases {
+				if p.Name != first.Name {
+					continue
+				}
+				durations = append(durations, p.Duration)
+				allocs = append(allocs, p.AllocBytes)
+				s.HeapBytes = max(s.HeapBytes, p.HeapBytes)
+			}
+		}
+		slices.Sort(durations)
+		slices.Sort(allocs)
+		s.MedianMS = ms(median(durations))
+		s.MinMS = ms(durations[0])
+		s.MaxMS = ms(durations[len(durations)-1])
+		s.AllocBytes = median(allocs)
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+// median returns the middle value of sorted, or the mean of the middle two.
+func median[T time.Duration | uint64](sorted []T) T {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Phases shorter than minTimeDeltaMS, or allocating less than
+// minAllocDelta, more than the baseline are never reported as regressions:
+// at that scale the difference is noise.
+const (
+	minTimeDeltaMS = 50
+	minAllocDelta  = 4 << 20
+)
+
+// Regression is a phase that got slower, or allocated more, than the
+// baseline allows.
+type Regression struct {
+	Phase    string  `json:"phase"`
+	Metric   string  `json:"metric"` // "median_ms" or "alloc_bytes"
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+}
+
+// Compare returns the phases of current whose median time or allocation
+// exceeds the baseline's by more than tolerance (0.2 allows 20%). Phases
+// missing from either report are skipped. It fails when the reports were
+// taken on different corpora.
+func Compare(baseline, current *Report, tolerance float64) ([]Regression, error) {
+	if baseline.Corpus != current.Corpus {
+		return nil, fmt.Errorf("baseline was taken on %s, not %s", baseline.Corpus, current.Corpus)
+	}
+	var regressions []Regression
+	for _, cur := range current.Phases {
+		i := slices.IndexFunc(baseline.Phases, func(p PhaseSummary) bool { return p.Name == cur.Name })
+		if i < 0 {
+			continue
+		}
+		base := baseline.Phases[i]
+		if cur.MedianMS > base.MedianMS*(1+tolerance) && cur.MedianMS-base.MedianMS >= minTimeDeltaMS {
+			regressions = append(regressions, Regression{Phase: cur.Name, Metric: "median_ms", Baseline: base.MedianMS, Current: cur.MedianMS})
+		}
+		if float64(cur.AllocBytes) > float64(base.AllocBytes)*(1+tolerance) && cur.AllocBytes-base.AllocBytes >= minAllocDelta {
+			regressions = append(regressions, Regression{Phase: cur.Name, Metric: "alloc_bytes", Baseline: float64(base.AllocBytes), Current: float64(cur.AllocBytes)})
+		}
+	}
+	return regressions, nil
+}
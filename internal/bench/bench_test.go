@@ -0,0 +1,82 @@
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abramin/flowlens/internal/index"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestWriteCorpus(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteCorpus(dir, 3); err != nil {
+		t.Fatalf("WriteCorpus failed: %v", err)
+	}
+
+	pkgs, err := packages.Load(&packages.Config{Mode: index.LoadMode, Dir: dir}, "./...")
+	if err != nil {
+		t.Fatalf("loading corpus: %v", err)
+	}
+	// main, api, and a service and repository per service
+	if len(pkgs) != 8 {
+		t.Errorf("expected 8 packages, got %d", len(pkgs))
+	}
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			t.Errorf("%s: %v", pkg.PkgPath, e)
+		}
+	}
+
+	if err := WriteCorpus(t.TempDir(), 0); err == nil {
+		t.Error("expected an error for an empty corpus")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	runs := [][]index.PhaseStats{
+		{{Name: "load", Duration: 30 * time.Millisecond, AllocBytes: 100, HeapBytes: 10}, {Name: "total", Duration: 90 * time.Millisecond}},
+		{{Name: "load", Duration: 10 * time.Millisecond, AllocBytes: 300, HeapBytes: 30}, {Name: "total", Duration: 50 * time.Millisecond}},
+	}
+	phases := summarize(runs)
+	if len(phases) != 2 || phases[0].Name != "load" || phases[1].Name != "total" {
+		t.Fatalf("expected load and total phases, got %+v", phases)
+	}
+	load := phases[0]
+	if load.MedianMS != 20 || load.MinMS != 10 || load.MaxMS != 30 || load.AllocBytes != 200 || load.HeapBytes != 30 {
+		t.Errorf("unexpected load summary %+v", load)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{Corpus: "synthetic:50", Phases: []PhaseSummary{
+		{Name: "load", MedianMS: 1000, AllocBytes: 100 << 20},
+		{Name: "tags", MedianMS: 10, AllocBytes: 1 << 20},
+		{Name: "callgraph", MedianMS: 2000, AllocBytes: 500 << 20},
+	}}
+	current := &Report{Corpus: "synthetic:50", Phases: []PhaseSummary{
+		{Name: "load", MedianMS: 1100, AllocBytes: 200 << 20}, // Within tolerance; allocates twice as much
+		{Name: "tags", MedianMS: 30, AllocBytes: 3 << 20},     // Tripled, but by less than the noise floor
+		{Name: "callgraph", MedianMS: 3000, AllocBytes: 500 << 20},
+		{Name: "finalize", MedianMS: 500}, // Not in the baseline
+	}}
+
+	regressions, err := Compare(baseline, current, 0.2)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if len(regressions) != 2 {
+		t.Fatalf("expected 2 regressions, got %+v", regressions)
+	}
+	if r := regressions[0]; r.Phase != "load" || r.Metric != "alloc_bytes" {
+		t.Errorf("expected load allocations to regress, got %+v", r)
+	}
+	if r := regressions[1]; r.Phase != "callgraph" || r.Metric != "median_ms" || r.Baseline != 2000 || r.Current != 3000 {
+		t.Errorf("expected the call graph to get slower, got %+v", r)
+	}
+
+	current.Corpus = "/src/other"
+	if _, err := Compare(baseline, current, 0.2); err == nil {
+		t.Error("expected an error comparing different corpora")
+	}
+}
@@ -0,0 +1,209 @@
+package bench
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// corpusModule is the module path of the synthetic corpus.
+const corpusModule = "example.com/benchcorpus"
+
+// helpersPerService is the number of chained helper functions in each
+// service package, which gives the call graph some depth.
+const helpersPerService = 8
+
+// WriteCorpus generates a synthetic Go module in dir with the shape FlowLens
+// is built for: HTTP handlers calling services through interfaces, services
+// calling repositories backed by database/sql. Each of the services packages
+// gets a handler, a service, and a repository. It only imports the standard
+// library, so it loads without network access, and the output depends only
+// on services, so timings are comparable across machines and versions.
+func WriteCorpus(dir string, services int) error {
+	if services < 1 {
+		return fmt.Errorf("invalid corpus size %d (want 1 or more services)", services)
+	}
+
+	files := map[string]string{
+		"go.mod":             "module " + corpusModule + "\n\ngo 1.22\n",
+		"cmd/server/main.go": mainFile(),
+		"api/api.go":         apiFile(services),
+	}
+	for i := range services {
+		files[fmt.Sprintf("svc%d/service.go", i)] = serviceFile(i)
+		files[fmt.Sprintf("repo%d/repo.go", i)] = repoFile(i)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mainFile() string {
+	return `package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"` + corpusModule + `/api"
+)
+
+func main() {
+	db, err := sql.Open("postgres", "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	api.Register(mux, db)
+	log.Fatal(http.ListenAndServe(":8080", mux))
+}
+`
+}
+
+func apiFile(services int) string {
+	var b strings.Builder
+	b.WriteString("package api\n\nimport (\n\t\"database/sql\"\n\t\"encoding/json\"\n\t\"net/http\"\n\n")
+	for i := range services {
+		fmt.Fprintf(&b, "\t\"%s/repo%d\"\n\t\"%s/svc%d\"\n", corpusModule, i, corpusModule, i)
+	}
+	b.WriteString(`)
+
+// Handlers serves the API.
+type Handlers struct {
+	db *sql.DB
+}
+
+type response struct {
+	ID    string ` + "`json:\"id\"`" + `
+	Value string ` + "`json:\"value\"`" + `
+}
+
+// Register adds the API routes to mux.
+func Register(mux *http.ServeMux, db *sql.DB) {
+	h := &Handlers{db: db}
+`)
+	for i := range services {
+		fmt.Fprintf(&b, "\tmux.HandleFunc(\"/svc%d\", h.Handle%d)\n", i, i)
+	}
+	b.WriteString("}\n")
+	for i := range services {
+		fmt.Fprintf(&b, `
+// Handle%[1]d serves /svc%[1]d.
+func (h *Handlers) Handle%[1]d(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	value, err := svc%[1]d.New(repo%[1]d.New(h.db)).Process(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response{ID: id, Value: value})
+}
+`, i)
+	}
+	return b.String()
+}
+
+func serviceFile(i int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `package svc%[1]d
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Repository stores values.
+type Repository interface {
+	Load(ctx context.Context, id string) (string, error)
+	Save(ctx context.Context, id, value string) error
+}
+
+// Service processes values.
+type Service struct {
+	repo Repository
+}
+
+// New returns a service backed by repo.
+func New(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Process loads, normalizes, and saves a value.
+func (s *Service) Process(ctx context.Context, id string) (string, error) {
+	if id == "" {
+		return "", errors.New("missing id")
+	}
+	value, err := s.repo.Load(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("loading %%s: %%w", id, err)
+	}
+	value = step0(value)
+	if err := s.repo.Save(ctx, id, value); err != nil {
+		return "", fmt.Errorf("saving %%s: %%w", id, err)
+	}
+	return value, nil
+}
+`, i)
+	for h := range helpersPerService {
+		next := "strings.TrimSpace(v)"
+		if h+1 < helpersPerService {
+			next = fmt.Sprintf("step%d(v)", h+1)
+		}
+		fmt.Fprintf(&b, `
+func step%d(v string) string {
+	v = strings.ToLower(v)
+	if strings.HasPrefix(v, "-") {
+		v = strings.TrimPrefix(v, "-")
+	}
+	return %s
+}
+`, h, next)
+	}
+	return b.String()
+}
+
+func repoFile(i int) string {
+	return fmt.Sprintf(`package repo%[1]d
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Repo stores values in a SQL table.
+type Repo struct {
+	db *sql.DB
+}
+
+// New returns a repository using db.
+func New(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Load reads a value.
+func (r *Repo) Load(ctx context.Context, id string) (string, error) {
+	var value string
+	err := r.db.QueryRowContext(ctx, "SELECT value FROM values%[1]d WHERE id = $1", id).Scan(&value)
+	return value, err
+}
+
+// Save writes a value.
+func (r *Repo) Save(ctx context.Context, id, value string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE values%[1]d SET value = $2 WHERE id = $1", id, value)
+	return err
+}
+`, i)
+}
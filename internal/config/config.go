@@ -1,26 +1,164 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 
+	"golang.org/x/mod/modfile"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the FlowLens configuration.
 type Config struct {
-	Exclude       ExcludeConfig         `yaml:"exclude"`
-	Layers        map[string][]string   `yaml:"layers"`
-	IOPackages    map[string][]string   `yaml:"io_packages"`
-	NoisePackages []string              `yaml:"noise_packages"`
+	Packages       []string            `yaml:"packages,omitempty"` // Package patterns to index (default: ./...)
+	Exclude        ExcludeConfig       `yaml:"exclude"`
+	Layers         map[string][]string `yaml:"layers"`
+	IOPackages     map[string][]string `yaml:"io_packages"`
+	IOTagging      string              `yaml:"io_tagging,omitempty"`    // IOTaggingCalls (default), IOTaggingDirect, or IOTaggingPackage
+	IODistance     int                 `yaml:"io_distance,omitempty"`   // Farthest "io:<category>@N" tag derived for callers of I/O functions (default 3; negative disables)
+	ReceiverTags   []ReceiverTagRule   `yaml:"receiver_tags,omitempty"` // Tags for methods by receiver type name; first match wins
+	Entrypoints    []EntrypointRule    `yaml:"entrypoints,omitempty"`   // Functions to seed as entrypoints for frameworks FlowLens does not detect
+	NoisePackages  []string            `yaml:"noise_packages"`
+	StdlibAllow    []string            `yaml:"stdlib_allow,omitempty"` // Standard library packages kept in graphs when hideStdlib is on, e.g. net/http, database/sql; "*" matches any suffix
+	Taint          TaintConfig         `yaml:"taint,omitempty"`
+	Auth           AuthConfig          `yaml:"auth,omitempty"`
+	Panics         PanicConfig         `yaml:"panics,omitempty"`
+	FeatureFlags   FeatureFlagConfig   `yaml:"feature_flags,omitempty"`
+	Dependencies   DependencyConfig    `yaml:"dependencies,omitempty"`
+	ProjectModules []string            `yaml:"project_modules,omitempty"` // Third-party modules indexed and traversed as project code, e.g. vendored or internal forks
+	Wrappers       WrapperConfig       `yaml:"wrappers,omitempty"`
+	Snapshots      SnapshotConfig      `yaml:"snapshots,omitempty"`
+	Repo           string              `yaml:"repo,omitempty"`           // Repository name, for indexing several repositories into one database
+	Database       string              `yaml:"database,omitempty"`       // Index database path, relative to the project (default: .flowlens/index.db)
+	IndexLocation  string              `yaml:"index_location,omitempty"` // IndexLocationProject (default) or IndexLocationCache, when no database is set
+}
+
+// I/O tagging modes, from strictest to loosest.
+const (
+	IOTaggingDirect  = "direct"  // Functions calling an I/O package themselves
+	IOTaggingCalls   = "calls"   // Also functions reaching one through helpers in the same package
+	IOTaggingPackage = "package" // Every function in a package that calls an I/O package
+)
+
+// Index locations, used when no database path is configured.
+const (
+	IndexLocationProject = "project" // .flowlens/index.db in the project directory
+	IndexLocationCache   = "cache"   // A directory per module under the user cache directory
+)
+
+// HomeEnv names the environment variable that, when set, holds the indexes
+// of every project in a directory per module, like IndexLocationCache.
+const HomeEnv = "FLOWLENS_HOME"
+
+// IndexKeyEnv names the environment variable that, when set, holds the key
+// indexes are encrypted with at rest: 32 bytes, hex-encoded, e.g. from
+// "openssl rand -hex 32" or a keychain.
+const IndexKeyEnv = "FLOWLENS_INDEX_KEY"
+
+// IndexKey returns the index encryption key from IndexKeyEnv, or nil when
+// indexes aren't encrypted.
+func IndexKey() ([]byte, error) {
+	value := strings.TrimSpace(os.Getenv(IndexKeyEnv))
+	if value == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(value)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s must be 32 bytes, hex-encoded (64 characters)", IndexKeyEnv)
+	}
+	return key, nil
+}
+
+// ReceiverTagRule tags the methods of types whose name (without package or
+// pointer) ends with Suffix, compared case-insensitively, or matches Regex.
+type ReceiverTagRule struct {
+	Suffix string `yaml:"suffix,omitempty"` // e.g. "Repo"
+	Regex  string `yaml:"regex,omitempty"`  // e.g. "(?i)(dao|gateway|adapter)$"
+	Tag    string `yaml:"tag"`              // e.g. "io:db"
+}
+
+// EntrypointRule makes every function or method matching its patterns an
+// entrypoint, for in-house routers and plugin systems. Empty patterns match
+// anything, but a rule needs at least one.
+type EntrypointRule struct {
+	Package string `yaml:"package,omitempty"` // Package pattern as for layers, e.g. "**/plugins/**"
+	Name    string `yaml:"name,omitempty"`    // Regex on the function name, e.g. "^Handle"
+	Recv    string `yaml:"recv,omitempty"`    // Regex on the receiver type without "*", e.g. "Plugin$"; "^$" for plain functions
+	Type    string `yaml:"type,omitempty"`    // http, grpc, cli, main, or custom (default)
+	Label   string `yaml:"label,omitempty"`   // text/template over .Pkg, .PkgName, .Recv, .Name (default "{{.PkgName}}.{{.Name}}", with the receiver for methods)
+}
+
+// MatchesPackage reports whether pkgPath matches the rule's package pattern.
+func (r EntrypointRule) MatchesPackage(pkgPath string) bool {
+	return r.Package == "" || matchLayerPattern(r.Package, pkgPath)
+}
+
+// Entrypoint types a rule may declare.
+var entrypointRuleTypes = map[string]bool{"http": true, "grpc": true, "cli": true, "main": true, "custom": true}
+
+// DependencyConfig controls indexing of calls into third-party modules.
+type DependencyConfig struct {
+	Index bool `yaml:"index,omitempty"` // Record calls from project code into non-stdlib modules
+}
+
+// WrapperConfig controls how calls through the synthetic functions SSA
+// generates (method value and expression wrappers, promoted methods of
+// embedded types, generic instantiations) are stored.
+type WrapperConfig struct {
+	Keep bool `yaml:"keep,omitempty"` // Store wrappers as symbols of their own instead of collapsing calls into the real target, for debugging
+}
+
+// SnapshotConfig controls the copies of past indexes kept for time-travel
+// queries (?as_of= on the API).
+type SnapshotConfig struct {
+	Retain int `yaml:"retain,omitempty"` // Indexes kept in .flowlens/snapshots, newest first (0 disables)
+}
+
+// AuthConfig identifies authentication middleware and routes that are
+// public on purpose. Patterns are matched case-insensitively and * matches
+// any run of characters.
+type AuthConfig struct {
+	Middleware []string `yaml:"middleware,omitempty"` // Middleware or check functions, e.g. "*requireauth*", "auth.*"
+	Public     []string `yaml:"public,omitempty"`     // Entrypoint labels that need no auth, e.g. "GET /healthz"
+}
+
+// PanicConfig identifies middleware that recovers panics and library
+// calls that panic. Patterns are matched as for AuthConfig.
+type PanicConfig struct {
+	Recover []string `yaml:"recover,omitempty"` // Recovering middleware, e.g. "middleware.Recoverer", "*recover*"
+	Calls   []string `yaml:"calls,omitempty"`   // Panicking functions named "pkgpath.Func" or "pkgpath.Type.Method", e.g. "*.Must*"
+}
+
+// FeatureFlagConfig identifies flag evaluation calls of feature-flag SDKs,
+// named "pkgpath.Func" or "pkgpath.Type.Method". The flag key is the first
+// string argument of a matching call.
+type FeatureFlagConfig struct {
+	Calls []string `yaml:"calls,omitempty"` // e.g. "*launchdarkly/go-server-sdk*variation*", "mycorp/flags.*.IsEnabled"
+}
+
+// TaintConfig defines the sources, sinks, and sanitizers for taint analysis.
+// Functions are named "pkgpath.Func" or "pkgpath.Type.Method"; patterns may
+// use * as in path.Match (e.g. "database/sql.*.Query*").
+type TaintConfig struct {
+	Sources    []string            `yaml:"sources,omitempty"`    // Handler parameter types carrying request input, e.g. net/http.Request
+	Sinks      map[string][]string `yaml:"sinks,omitempty"`      // Sink category (exec, sql, file) -> function patterns
+	Sanitizers []string            `yaml:"sanitizers,omitempty"` // Functions whose results are considered clean
 }
 
 // ExcludeConfig defines patterns to exclude from indexing.
 type ExcludeConfig struct {
 	Dirs      []string `yaml:"dirs"`
 	FilesGlob []string `yaml:"files_glob"`
+	Packages  []string `yaml:"packages,omitempty"` // Package patterns, e.g. ./cmd/legacytool/...
 }
 
 // Default returns a Config with sensible defaults.
@@ -57,12 +195,35 @@ func Default() *Config {
 				"io/ioutil",
 				"io/fs",
 			},
+			"cache": {
+				"github.com/redis/go-redis/*",
+				"github.com/go-redis/redis",
+				"github.com/go-redis/redis/*",
+				"github.com/gomodule/redigo/*",
+				"github.com/bradfitz/gomemcache/*",
+				"github.com/allegro/bigcache",
+				"github.com/allegro/bigcache/*",
+				"github.com/dgraph-io/ristretto",
+				"github.com/dgraph-io/ristretto/*",
+				"github.com/golang/groupcache",
+				"github.com/golang/groupcache/*",
+				"github.com/patrickmn/go-cache",
+			},
 			"bus": {
 				"github.com/nats-io/*",
 				"github.com/segmentio/kafka-go",
 				"github.com/rabbitmq/amqp091-go",
 			},
 		},
+		IOTagging:  IOTaggingCalls,
+		IODistance: 3,
+		ReceiverTags: []ReceiverTagRule{
+			{Suffix: "Cache", Tag: "io:cache"},
+			{Suffix: "Store", Tag: "io:db"},
+			{Suffix: "Repo", Tag: "io:db"},
+			{Suffix: "Repository", Tag: "io:db"},
+			{Suffix: "Client", Tag: "io:net"},
+		},
 		NoisePackages: []string{
 			"log",
 			"log/slog",
@@ -74,6 +235,88 @@ func Default() *Config {
 			"github.com/prometheus/client_golang/*",
 			"go.opentelemetry.io/otel/*",
 		},
+		Auth: AuthConfig{
+			Middleware: []string{
+				"*authenticat*",
+				"*authoriz*",
+				"*auth",
+				"*authmiddleware*",
+				"*requireuser*",
+				"*requirelogin*",
+				"*jwt*",
+				"auth.*",
+			},
+			Public: []string{
+				"* /health*",
+				"* /ready*",
+				"* /live*",
+				"* /metrics",
+				"* /ping",
+			},
+		},
+		Panics: PanicConfig{
+			Recover: []string{
+				"*recover*", // chi Recoverer, gin Recovery, echo Recover
+			},
+			Calls: []string{
+				"*.must*", // regexp.MustCompile, template.Must, uuid.MustParse
+				"log.panic*",
+				"log.logger.panic*",
+			},
+		},
+		FeatureFlags: FeatureFlagConfig{
+			Calls: []string{
+				"github.com/launchdarkly/go-server-sdk*.*variation*", // LDClient.BoolVariation, StringVariationDetail
+				"github.com/open-feature/go-sdk/openfeature.*value*", // Client.BooleanValue, StringValueDetails
+			},
+		},
+		Taint: TaintConfig{
+			Sources: []string{
+				"net/http.Request",
+				"github.com/gin-gonic/gin.Context",
+				"github.com/labstack/echo/v4.Context",
+				"github.com/gofiber/fiber/v2.Ctx",
+			},
+			Sinks: map[string][]string{
+				"exec": {
+					"os/exec.Command",
+					"os/exec.CommandContext",
+					"os.StartProcess",
+					"syscall.Exec",
+				},
+				"sql": {
+					"database/sql.*.Query*",
+					"database/sql.*.Exec*",
+					"database/sql.*.Prepare*",
+					"github.com/jackc/pgx/v5.*.Query*",
+					"github.com/jackc/pgx/v5.*.Exec",
+					"github.com/jackc/pgx/v5/pgxpool.*.Query*",
+					"github.com/jackc/pgx/v5/pgxpool.*.Exec",
+					"gorm.io/gorm.DB.Raw",
+					"gorm.io/gorm.DB.Exec",
+					"github.com/jmoiron/sqlx.*.Select*",
+					"github.com/jmoiron/sqlx.*.Get*",
+				},
+				"file": {
+					"os.Create",
+					"os.OpenFile",
+					"os.WriteFile",
+					"os.Mkdir*",
+					"os.Remove*",
+					"os.Rename",
+					"io/ioutil.WriteFile",
+				},
+			},
+			Sanitizers: []string{
+				"strconv.Atoi",
+				"strconv.Parse*",
+				"path/filepath.Base",
+				"html.EscapeString",
+				"net/url.QueryEscape",
+				"net/url.PathEscape",
+				"github.com/google/uuid.Parse",
+			},
+		},
 	}
 }
 
@@ -104,9 +347,54 @@ func Load(configPath string) (*Config, error) {
 
 	// Apply defaults for missing fields
 	defaults.Merge(&fileCfg)
+	if err := defaults.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
+	}
 	return defaults, nil
 }
 
+// validate reports settings that cannot be used, such as a malformed
+// regular expression.
+func (c *Config) validate() error {
+	switch c.IOTagging {
+	case IOTaggingDirect, IOTaggingCalls, IOTaggingPackage:
+	default:
+		return fmt.Errorf("io_tagging: unknown mode %q (want direct, calls, or package)", c.IOTagging)
+	}
+	switch c.IndexLocation {
+	case "", IndexLocationProject, IndexLocationCache:
+	default:
+		return fmt.Errorf("index_location: unknown location %q (want project or cache)", c.IndexLocation)
+	}
+	for i, rule := range c.ReceiverTags {
+		if rule.Tag == "" || (rule.Suffix == "") == (rule.Regex == "") {
+			return fmt.Errorf("receiver_tags[%d]: need a tag and exactly one of suffix or regex", i)
+		}
+		if rule.Regex != "" {
+			if _, err := regexp.Compile(rule.Regex); err != nil {
+				return fmt.Errorf("receiver_tags[%d]: %w", i, err)
+			}
+		}
+	}
+	for i, rule := range c.Entrypoints {
+		if rule.Package == "" && rule.Name == "" && rule.Recv == "" {
+			return fmt.Errorf("entrypoints[%d]: need at least one of package, name, or recv", i)
+		}
+		for _, expr := range []string{rule.Name, rule.Recv} {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("entrypoints[%d]: %w", i, err)
+			}
+		}
+		if rule.Type != "" && !entrypointRuleTypes[rule.Type] {
+			return fmt.Errorf("entrypoints[%d]: unknown type %q (want http, grpc, cli, main, or custom)", i, rule.Type)
+		}
+		if _, err := template.New("label").Parse(rule.Label); err != nil {
+			return fmt.Errorf("entrypoints[%d]: label: %w", i, err)
+		}
+	}
+	return nil
+}
+
 // LoadFromDir loads configuration from the specified directory.
 func LoadFromDir(dir string) (*Config, error) {
 	return Load(filepath.Join(dir, "flowlens.yaml"))
@@ -118,12 +406,18 @@ func (c *Config) Merge(other *Config) {
 		return
 	}
 
+	if len(other.Packages) > 0 {
+		c.Packages = other.Packages
+	}
 	if len(other.Exclude.Dirs) > 0 {
 		c.Exclude.Dirs = other.Exclude.Dirs
 	}
 	if len(other.Exclude.FilesGlob) > 0 {
 		c.Exclude.FilesGlob = other.Exclude.FilesGlob
 	}
+	if len(other.Exclude.Packages) > 0 {
+		c.Exclude.Packages = other.Exclude.Packages
+	}
 	if len(other.Layers) > 0 {
 		c.Layers = other.Layers
 	}
@@ -133,6 +427,66 @@ func (c *Config) Merge(other *Config) {
 	if len(other.NoisePackages) > 0 {
 		c.NoisePackages = other.NoisePackages
 	}
+	if len(other.StdlibAllow) > 0 {
+		c.StdlibAllow = other.StdlibAllow
+	}
+	if other.IOTagging != "" {
+		c.IOTagging = other.IOTagging
+	}
+	if other.IODistance != 0 {
+		c.IODistance = other.IODistance
+	}
+	if len(other.ReceiverTags) > 0 {
+		c.ReceiverTags = other.ReceiverTags
+	}
+	if len(other.Entrypoints) > 0 {
+		c.Entrypoints = other.Entrypoints
+	}
+	if len(other.Auth.Middleware) > 0 {
+		c.Auth.Middleware = other.Auth.Middleware
+	}
+	if len(other.Auth.Public) > 0 {
+		c.Auth.Public = other.Auth.Public
+	}
+	if len(other.Panics.Recover) > 0 {
+		c.Panics.Recover = other.Panics.Recover
+	}
+	if len(other.Panics.Calls) > 0 {
+		c.Panics.Calls = other.Panics.Calls
+	}
+	if len(other.FeatureFlags.Calls) > 0 {
+		c.FeatureFlags.Calls = other.FeatureFlags.Calls
+	}
+	if other.Dependencies.Index {
+		c.Dependencies.Index = true
+	}
+	if len(other.ProjectModules) > 0 {
+		c.ProjectModules = other.ProjectModules
+	}
+	if other.Wrappers.Keep {
+		c.Wrappers.Keep = true
+	}
+	if other.Snapshots.Retain != 0 {
+		c.Snapshots.Retain = other.Snapshots.Retain
+	}
+	if other.Repo != "" {
+		c.Repo = other.Repo
+	}
+	if other.Database != "" {
+		c.Database = other.Database
+	}
+	if other.IndexLocation != "" {
+		c.IndexLocation = other.IndexLocation
+	}
+	if len(other.Taint.Sources) > 0 {
+		c.Taint.Sources = other.Taint.Sources
+	}
+	if len(other.Taint.Sinks) > 0 {
+		c.Taint.Sinks = other.Taint.Sinks
+	}
+	if len(other.Taint.Sanitizers) > 0 {
+		c.Taint.Sanitizers = other.Taint.Sanitizers
+	}
 }
 
 // IsExcludedDir checks if a directory should be excluded from indexing.
@@ -158,6 +512,61 @@ func (c *Config) GetLayerForPackage(pkgPath string) string {
 	return ""
 }
 
+// LayerOrder ranks the default layers from outermost (0) to innermost.
+// Calls are expected to flow inward; layers not listed (custom layers)
+// are never considered violations.
+var LayerOrder = map[string]int{
+	"handler": 0,
+	"service": 1,
+	"store":   2,
+	"domain":  3,
+}
+
+// IsLayerViolation reports whether a call from callerLayer to calleeLayer
+// flows outward, e.g. store calling handler.
+func IsLayerViolation(callerLayer, calleeLayer string) bool {
+	callerRank, ok := LayerOrder[callerLayer]
+	if !ok {
+		return false
+	}
+	calleeRank, ok := LayerOrder[calleeLayer]
+	if !ok {
+		return false
+	}
+	return callerRank > calleeRank
+}
+
+// SkippedLayers returns the layers a call from callerLayer to calleeLayer
+// passes over, outermost first, e.g. service for handler calling store.
+// Calls into the innermost layer skip nothing, since every layer uses its
+// domain types, and neither do calls to or from custom layers.
+func SkippedLayers(callerLayer, calleeLayer string) []string {
+	callerRank, ok := LayerOrder[callerLayer]
+	if !ok {
+		return nil
+	}
+	calleeRank, ok := LayerOrder[calleeLayer]
+	if !ok {
+		return nil
+	}
+	innermost := 0
+	for _, rank := range LayerOrder {
+		innermost = max(innermost, rank)
+	}
+	if calleeRank == innermost {
+		return nil
+	}
+	var skipped []string
+	for rank := callerRank + 1; rank < calleeRank; rank++ {
+		for layer, r := range LayerOrder {
+			if r == rank {
+				skipped = append(skipped, layer)
+			}
+		}
+	}
+	return skipped
+}
+
 // matchLayerPattern matches a package path against a layer pattern.
 // Supports ** for matching any number of path components.
 // Example: "**/handlers/**" matches "myapp/internal/handlers/user"
@@ -203,7 +612,19 @@ func (c *Config) IsNoisePackage(pkgPath string) bool {
 	return false
 }
 
-// GetIOCategory returns the I/O category (db, net, fs, bus) for a package, or empty string if not I/O.
+// IsProjectModule reports whether a dependency package belongs to one of
+// the project_modules, by its module path or, for vendored and module-less
+// packages, its import path.
+func (c *Config) IsProjectModule(modulePath, pkgPath string) bool {
+	for _, m := range c.ProjectModules {
+		if modulePath == m || pkgPath == m || strings.HasPrefix(pkgPath, m+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIOCategory returns the I/O category (db, net, fs, cache, bus) for a package, or empty string if not I/O.
 func (c *Config) GetIOCategory(pkgPath string) string {
 	for category, packages := range c.IOPackages {
 		for _, pkg := range packages {
@@ -221,3 +642,173 @@ func (c *Config) GetIOCategory(pkgPath string) string {
 	}
 	return ""
 }
+
+// IsTaintSource reports whether a handler parameter of the named type
+// (e.g. "net/http.Request") carries request input.
+func (c *Config) IsTaintSource(typeName string) bool {
+	for _, src := range c.Taint.Sources {
+		if matchFuncPattern(src, typeName) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTaintSinkCategory returns the sink category for a function name, or
+// empty string if it is not a sink.
+func (c *Config) GetTaintSinkCategory(funcName string) string {
+	categories := make([]string, 0, len(c.Taint.Sinks))
+	for category := range c.Taint.Sinks {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		for _, pattern := range c.Taint.Sinks[category] {
+			if matchFuncPattern(pattern, funcName) {
+				return category
+			}
+		}
+	}
+	return ""
+}
+
+// IsTaintSanitizer reports whether a function's result is considered clean.
+func (c *Config) IsTaintSanitizer(funcName string) bool {
+	for _, pattern := range c.Taint.Sanitizers {
+		if matchFuncPattern(pattern, funcName) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFuncPattern matches a qualified function or type name against a
+// pattern. * does not cross "/", so it stays within the package's last
+// path element and the type and function names.
+func matchFuncPattern(pattern, name string) bool {
+	if pattern == name {
+		return true
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// DatabasePath returns the index database for the project in projectDir:
+// the configured database (relative paths resolved against projectDir), a
+// directory per module under $FLOWLENS_HOME or, with index_location cache,
+// the user cache directory (e.g. $XDG_CACHE_HOME/flowlens), and otherwise
+// .flowlens/index.db. Indexes kept outside the project leave worktrees
+// clean and work on read-only checkouts.
+func (c *Config) DatabasePath(projectDir string) string {
+	if c.Database != "" {
+		if filepath.IsAbs(c.Database) {
+			return c.Database
+		}
+		return filepath.Join(projectDir, c.Database)
+	}
+	if home := os.Getenv(HomeEnv); home != "" {
+		return filepath.Join(home, indexKey(projectDir), "index.db")
+	}
+	if c.IndexLocation == IndexLocationCache {
+		if dir, err := os.UserCacheDir(); err == nil {
+			return filepath.Join(dir, "flowlens", indexKey(projectDir), "index.db")
+		}
+	}
+	return filepath.Join(projectDir, ".flowlens", "index.db")
+}
+
+// indexKey returns the directory, relative to a shared index location, of
+// the project in projectDir: its module path, so every checkout of a module
+// shares one index, or for a project without go.mod its directory name and
+// a hash of its path.
+func indexKey(projectDir string) string {
+	abs, err := filepath.Abs(projectDir)
+	if err != nil {
+		abs = projectDir
+	}
+	if data, err := os.ReadFile(filepath.Join(abs, "go.mod")); err == nil {
+		if mod := modfile.ModulePath(data); mod != "" {
+			return filepath.FromSlash(mod)
+		}
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return fmt.Sprintf("%s-%x", filepath.Base(abs), sum[:6])
+}
+
+// IsAuthMiddleware reports whether a middleware or function name (e.g.
+// "auth.RequireUser" or "jwtMiddleware") identifies an authentication check.
+func (c *Config) IsAuthMiddleware(name string) bool {
+	for _, pattern := range c.Auth.Middleware {
+		if matchWildcard(strings.ToLower(pattern), strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPublicRoute reports whether an entrypoint label is configured as
+// intentionally unauthenticated.
+func (c *Config) IsPublicRoute(label string) bool {
+	for _, pattern := range c.Auth.Public {
+		if matchWildcard(strings.ToLower(pattern), strings.ToLower(label)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRecoverMiddleware reports whether a middleware name (e.g.
+// "middleware.Recoverer") identifies middleware that recovers panics.
+func (c *Config) IsRecoverMiddleware(name string) bool {
+	for _, pattern := range c.Panics.Recover {
+		if matchWildcard(strings.ToLower(pattern), strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPanicCall reports whether a non-project function, named "pkgpath.Func"
+// or "pkgpath.Type.Method", is configured as panicking.
+func (c *Config) IsPanicCall(name string) bool {
+	for _, pattern := range c.Panics.Calls {
+		if matchWildcard(strings.ToLower(pattern), strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFlagCall reports whether a function, named "pkgpath.Func" or
+// "pkgpath.Type.Method", evaluates a feature flag.
+func (c *Config) IsFlagCall(name string) bool {
+	for _, pattern := range c.FeatureFlags.Calls {
+		if matchWildcard(strings.ToLower(pattern), strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard matches s against a pattern in which * matches any run of
+// characters, including "/".
+func matchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, last)
+}
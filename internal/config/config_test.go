@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -135,6 +137,107 @@ func TestIsNoisePackage(t *testing.T) {
 	}
 }
 
+func TestIsProjectModule(t *testing.T) {
+	cfg := Default()
+	cfg.ProjectModules = []string{"github.com/acme/kit"}
+
+	tests := []struct {
+		module, pkg string
+		project     bool
+	}{
+		{"github.com/acme/kit", "github.com/acme/kit/retry", true},
+		{"", "github.com/acme/kit/retry", true},
+		{"github.com/acme/kit", "github.com/acme/kit", true},
+		{"github.com/acme/kitchen", "github.com/acme/kitchen", false},
+		{"github.com/other/lib", "github.com/other/lib/retry", false},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.IsProjectModule(tt.module, tt.pkg); got != tt.project {
+			t.Errorf("IsProjectModule(%q, %q) = %v, want %v", tt.module, tt.pkg, got, tt.project)
+		}
+	}
+}
+
+func TestLoadReceiverTags(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "flowlens.yaml")
+	content := `
+receiver_tags:
+  - suffix: Gateway
+    tag: io:net
+  - regex: "(?i)(dao|adapter)$"
+    tag: io:db
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	// Configured rules replace the defaults
+	if len(cfg.ReceiverTags) != 2 || cfg.ReceiverTags[0].Suffix != "Gateway" || cfg.ReceiverTags[1].Tag != "io:db" {
+		t.Errorf("unexpected receiver tags: %+v", cfg.ReceiverTags)
+	}
+
+	invalid := map[string]string{
+		"bad regex":   "receiver_tags:\n  - regex: \"(dao\"\n    tag: io:db\n",
+		"no tag":      "receiver_tags:\n  - suffix: Dao\n",
+		"no matcher":  "receiver_tags:\n  - tag: io:db\n",
+		"two matches": "receiver_tags:\n  - suffix: Dao\n    regex: Dao$\n    tag: io:db\n",
+		"bad io mode": "io_tagging: strict\n",
+	}
+	for name, content := range invalid {
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(configPath); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadEntrypointRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "flowlens.yaml")
+	content := `
+entrypoints:
+  - package: "**/plugins/**"
+    recv: "Plugin$"
+    name: "^Run$"
+    label: "plugin {{.Recv}}"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	if len(cfg.Entrypoints) != 1 || cfg.Entrypoints[0].Recv != "Plugin$" || cfg.Entrypoints[0].Label != "plugin {{.Recv}}" {
+		t.Errorf("unexpected entrypoint rules: %+v", cfg.Entrypoints)
+	}
+	if !cfg.Entrypoints[0].MatchesPackage("myapp/internal/plugins/billing") || cfg.Entrypoints[0].MatchesPackage("myapp/internal/service") {
+		t.Errorf("package pattern matched unexpectedly")
+	}
+
+	invalid := map[string]string{
+		"no pattern":   "entrypoints:\n  - type: custom\n",
+		"bad regex":    "entrypoints:\n  - name: \"(Run\"\n",
+		"bad type":     "entrypoints:\n  - name: Run\n    type: cron\n",
+		"bad template": "entrypoints:\n  - name: Run\n    label: \"{{.Name\"\n",
+	}
+	for name, content := range invalid {
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(configPath); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
 func TestGetIOCategory(t *testing.T) {
 	cfg := Default()
 
@@ -148,6 +251,9 @@ func TestGetIOCategory(t *testing.T) {
 		{"google.golang.org/grpc/codes", "net"},
 		{"os", "fs"},
 		{"github.com/nats-io/nats.go", "bus"},
+		{"github.com/redis/go-redis/v9", "cache"},
+		{"github.com/dgraph-io/ristretto", "cache"},
+		{"github.com/golang/groupcache/lru", "cache"},
 		{"myapp/service", ""},
 		{"fmt", ""},
 	}
@@ -159,3 +265,267 @@ func TestGetIOCategory(t *testing.T) {
 		}
 	}
 }
+
+func TestIsLayerViolation(t *testing.T) {
+	tests := []struct {
+		caller, callee string
+		want           bool
+	}{
+		{"handler", "service", false},
+		{"service", "store", false},
+		{"handler", "store", false},
+		{"store", "handler", true},
+		{"domain", "service", true},
+		{"custom", "handler", false},
+		{"store", "", false},
+	}
+	for _, tt := range tests {
+		if got := IsLayerViolation(tt.caller, tt.callee); got != tt.want {
+			t.Errorf("IsLayerViolation(%q, %q) = %v, want %v", tt.caller, tt.callee, got, tt.want)
+		}
+	}
+}
+
+func TestSkippedLayers(t *testing.T) {
+	tests := []struct {
+		caller, callee string
+		want           []string
+	}{
+		{"handler", "service", nil},
+		{"handler", "store", []string{"service"}},
+		{"handler", "domain", nil},
+		{"store", "handler", nil},
+		{"custom", "store", nil},
+		{"handler", "", nil},
+	}
+	for _, tt := range tests {
+		if got := SkippedLayers(tt.caller, tt.callee); !slices.Equal(got, tt.want) {
+			t.Errorf("SkippedLayers(%q, %q) = %v, want %v", tt.caller, tt.callee, got, tt.want)
+		}
+	}
+}
+
+func TestAuthPatterns(t *testing.T) {
+	cfg := Default()
+
+	middleware := []struct {
+		name string
+		want bool
+	}{
+		{"RequireAuth", true},
+		{"auth.Middleware", true},
+		{"jwtauth.Verifier", true},
+		{"mw.Authenticate", true},
+		{"s.authorize", true},
+		{"middleware.Logger", false},
+		{"cors.Handler", false},
+		{"authorsHandler", false},
+	}
+	for _, tt := range middleware {
+		if got := cfg.IsAuthMiddleware(tt.name); got != tt.want {
+			t.Errorf("IsAuthMiddleware(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	routes := []struct {
+		label string
+		want  bool
+	}{
+		{"GET /health", true},
+		{"GET /healthz", true},
+		{"ANY /metrics", true},
+		{"GET /api/users", false},
+		{"GET /metrics/raw", false},
+	}
+	for _, tt := range routes {
+		if got := cfg.IsPublicRoute(tt.label); got != tt.want {
+			t.Errorf("IsPublicRoute(%q) = %v, want %v", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestPanicPatterns(t *testing.T) {
+	cfg := Default()
+
+	middleware := []struct {
+		name string
+		want bool
+	}{
+		{"middleware.Recoverer", true},
+		{"gin.Recovery", true},
+		{"recoverPanics", true},
+		{"middleware.Logger", false},
+	}
+	for _, tt := range middleware {
+		if got := cfg.IsRecoverMiddleware(tt.name); got != tt.want {
+			t.Errorf("IsRecoverMiddleware(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	calls := []struct {
+		name string
+		want bool
+	}{
+		{"regexp.MustCompile", true},
+		{"github.com/google/uuid.MustParse", true},
+		{"log.Panicf", true},
+		{"log.Logger.Panicln", true},
+		{"regexp.Compile", false},
+		{"log.Printf", false},
+	}
+	for _, tt := range calls {
+		if got := cfg.IsPanicCall(tt.name); got != tt.want {
+			t.Errorf("IsPanicCall(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFlagPatterns(t *testing.T) {
+	cfg := Default()
+
+	calls := []struct {
+		name string
+		want bool
+	}{
+		{"github.com/launchdarkly/go-server-sdk/v7.LDClient.BoolVariation", true},
+		{"github.com/launchdarkly/go-server-sdk/v7.LDClient.StringVariationDetail", true},
+		{"github.com/open-feature/go-sdk/openfeature.Client.BooleanValue", true},
+		{"github.com/open-feature/go-sdk/openfeature.IClient.StringValueDetails", true},
+		{"github.com/launchdarkly/go-server-sdk/v7.LDClient.Close", false},
+		{"github.com/open-feature/go-sdk/openfeature.SetProvider", false},
+	}
+	for _, tt := range calls {
+		if got := cfg.IsFlagCall(tt.name); got != tt.want {
+			t.Errorf("IsFlagCall(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDatabasePath(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module example.com/shop\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(HomeEnv, "")
+
+	cfg := Default()
+	if got, want := cfg.DatabasePath(projectDir), filepath.Join(projectDir, ".flowlens", "index.db"); got != want {
+		t.Errorf("default: expected %s, got %s", want, got)
+	}
+
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+	cfg.IndexLocation = IndexLocationCache
+	if dir, err := os.UserCacheDir(); err == nil {
+		want := filepath.Join(dir, "flowlens", "example.com", "shop", "index.db")
+		if got := cfg.DatabasePath(projectDir); got != want {
+			t.Errorf("cache: expected %s, got %s", want, got)
+		}
+	}
+
+	home := t.TempDir()
+	t.Setenv(HomeEnv, home)
+	if got, want := cfg.DatabasePath(projectDir), filepath.Join(home, "example.com", "shop", "index.db"); got != want {
+		t.Errorf("%s: expected %s, got %s", HomeEnv, want, got)
+	}
+
+	// Without go.mod the key is derived from the directory
+	plain := t.TempDir()
+	got := cfg.DatabasePath(plain)
+	if filepath.Dir(filepath.Dir(got)) != home || !strings.HasPrefix(filepath.Base(filepath.Dir(got)), filepath.Base(plain)+"-") {
+		t.Errorf("expected a per-directory index under %s, got %s", home, got)
+	}
+
+	// An explicit database wins
+	cfg.Database = "idx.db"
+	if got, want := cfg.DatabasePath(projectDir), filepath.Join(projectDir, "idx.db"); got != want {
+		t.Errorf("database: expected %s, got %s", want, got)
+	}
+}
+
+func TestIndexKey(t *testing.T) {
+	t.Setenv(IndexKeyEnv, "")
+	if key, err := IndexKey(); key != nil || err != nil {
+		t.Errorf("expected no key when unset, got %x (%v)", key, err)
+	}
+
+	t.Setenv(IndexKeyEnv, strings.Repeat("ab", 32)+"\n")
+	if key, err := IndexKey(); err != nil || len(key) != 32 || key[0] != 0xab {
+		t.Errorf("expected a 32-byte key, got %x (%v)", key, err)
+	}
+
+	for _, bad := range []string{"secret", strings.Repeat("ab", 16), strings.Repeat("zz", 32)} {
+		t.Setenv(IndexKeyEnv, bad)
+		if _, err := IndexKey(); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"cmd/api", "internal/handlers", "internal/repo", "internal/domain", "vendor/x/service"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gomod := "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgithub.com/jackc/pgx/v5 v5.5.0\n\tgo.uber.org/zap v1.27.0\n)\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sc, err := Detect(dir)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	if !sc.FromLayout {
+		t.Fatal("expected layers from layout")
+	}
+	wantLayers := map[string][]string{
+		"handler": {"**/handlers/**"},
+		"store":   {"**/repo/**"},
+		"domain":  {"**/domain/**"},
+	}
+	if len(sc.Config.Layers) != len(wantLayers) {
+		t.Errorf("expected layers %v, got %v (vendor must be skipped)", wantLayers, sc.Config.Layers)
+	}
+	for layer, want := range wantLayers {
+		got := sc.Config.Layers[layer]
+		if len(got) != 1 || got[0] != want[0] {
+			t.Errorf("layer %s: expected %v, got %v", layer, want, got)
+		}
+	}
+	if len(sc.CmdDirs) != 1 || sc.CmdDirs[0] != "api" {
+		t.Errorf("expected cmd dir api, got %v", sc.CmdDirs)
+	}
+
+	// pgx is required; lib/pq and kafka are not
+	db := sc.Config.IOPackages["db"]
+	if !contains(db, "database/sql") || !contains(db, "github.com/jackc/pgx/*") || contains(db, "github.com/lib/pq") {
+		t.Errorf("unexpected db packages: %v", db)
+	}
+	if _, ok := sc.Config.IOPackages["bus"]; ok {
+		t.Error("expected bus category to be dropped")
+	}
+	if !contains(sc.Config.NoisePackages, "go.uber.org/zap") || contains(sc.Config.NoisePackages, "github.com/rs/zerolog") {
+		t.Errorf("unexpected noise packages: %v", sc.Config.NoisePackages)
+	}
+
+	// The rendered YAML loads back to the same layers
+	data, err := sc.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "flowlens.yaml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("loading scaffolded config: %v", err)
+	}
+	if loaded.GetLayerForPackage("example.com/app/internal/repo/users") != "store" {
+		t.Error("expected scaffolded config to classify repo as store")
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+// layerDirNames maps conventional directory names to layers.
+var layerDirNames = map[string]string{
+	"handler":      "handler",
+	"handlers":     "handler",
+	"http":         "handler",
+	"api":          "handler",
+	"transport":    "handler",
+	"controller":   "handler",
+	"controllers":  "handler",
+	"service":      "service",
+	"services":     "service",
+	"usecase":      "service",
+	"usecases":     "service",
+	"store":        "store",
+	"stores":       "store",
+	"repo":         "store",
+	"repos":        "store",
+	"repository":   "store",
+	"repositories": "store",
+	"storage":      "store",
+	"persistence":  "store",
+	"domain":       "domain",
+	"model":        "domain",
+	"models":       "domain",
+	"entity":       "domain",
+	"entities":     "domain",
+}
+
+// Scaffold is a starter configuration detected from a project's layout.
+type Scaffold struct {
+	Config     *Config
+	LayerDirs  map[string][]string // Layer -> directory names that matched it
+	CmdDirs    []string            // Directories under cmd/ (main packages)
+	Requires   []string            // Modules required by go.mod
+	FromLayout bool                // False when no layer directories were found and defaults were used
+}
+
+// Detect inspects projectDir's directory names and go.mod and returns a
+// starter configuration: layer patterns for directories that follow common
+// naming conventions, and the default I/O and noise packages narrowed to the
+// standard library plus modules the project actually requires.
+func Detect(projectDir string) (*Scaffold, error) {
+	cfg := Default()
+	sc := &Scaffold{Config: cfg, LayerDirs: make(map[string][]string)}
+
+	err := filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == projectDir {
+			return nil
+		}
+		name := d.Name()
+		if strings.HasPrefix(name, ".") || name == "node_modules" || cfg.IsExcludedDir(path) {
+			return filepath.SkipDir
+		}
+		if filepath.Base(filepath.Dir(path)) == "cmd" {
+			// cmd/api is a binary, not the api layer
+			sc.CmdDirs = append(sc.CmdDirs, name)
+			return nil
+		}
+		if layer, ok := layerDirNames[name]; ok && !contains(sc.LayerDirs[layer], name) {
+			sc.LayerDirs[layer] = append(sc.LayerDirs[layer], name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", projectDir, err)
+	}
+
+	if len(sc.LayerDirs) > 0 {
+		sc.FromLayout = true
+		cfg.Layers = make(map[string][]string, len(sc.LayerDirs))
+		for layer, dirs := range sc.LayerDirs {
+			sort.Strings(dirs)
+			for _, dir := range dirs {
+				cfg.Layers[layer] = append(cfg.Layers[layer], "**/"+dir+"/**")
+			}
+		}
+	}
+
+	requires, err := readRequires(filepath.Join(projectDir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	sc.Requires = requires
+
+	for category, pkgs := range cfg.IOPackages {
+		cfg.IOPackages[category] = filterUsed(pkgs, requires)
+		if len(cfg.IOPackages[category]) == 0 {
+			delete(cfg.IOPackages, category)
+		}
+	}
+	cfg.NoisePackages = filterUsed(cfg.NoisePackages, requires)
+
+	return sc, nil
+}
+
+// YAML renders the scaffolded configuration as a commented flowlens.yaml.
+func (sc *Scaffold) YAML() ([]byte, error) {
+	body, err := yaml.Marshal(sc.Config)
+	if err != nil {
+		return nil, fmt.Errorf("encoding config: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("# FlowLens configuration generated by `flowlens init`.\n")
+	if sc.FromLayout {
+		b.WriteString("# Layers were detected from directory names; adjust the patterns to taste.\n")
+	} else {
+		b.WriteString("# No conventional layer directories were found; default layer patterns are used.\n")
+	}
+	if len(sc.CmdDirs) > 0 {
+		fmt.Fprintf(&b, "# Main packages under cmd/: %s\n", strings.Join(sc.CmdDirs, ", "))
+	}
+	b.WriteString("# I/O and noise packages are limited to the standard library and modules in go.mod.\n\n")
+	b.Write(body)
+	return []byte(b.String()), nil
+}
+
+// readRequires returns the module paths required by a go.mod file.
+// A missing go.mod yields no requirements.
+func readRequires(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.ParseLax(path, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	requires := make([]string, 0, len(f.Require))
+	for _, r := range f.Require {
+		requires = append(requires, r.Mod.Path)
+	}
+	return requires, nil
+}
+
+// filterUsed keeps standard library patterns and patterns that match a
+// required module.
+func filterUsed(patterns, requires []string) []string {
+	var used []string
+	for _, p := range patterns {
+		if isStdlibPattern(p) {
+			used = append(used, p)
+			continue
+		}
+		for _, mod := range requires {
+			if patternMatchesModule(p, mod) {
+				used = append(used, p)
+				break
+			}
+		}
+	}
+	return used
+}
+
+// isStdlibPattern reports whether a package pattern names the standard
+// library (no dot in the first path element).
+func isStdlibPattern(pattern string) bool {
+	first, _, _ := strings.Cut(pattern, "/")
+	return !strings.Contains(first, ".")
+}
+
+// patternMatchesModule reports whether a package pattern can match packages
+// of the given module, e.g. "github.com/jackc/pgx/*" and "github.com/jackc/pgx/v5".
+func patternMatchesModule(pattern, mod string) bool {
+	base := strings.TrimSuffix(strings.TrimSuffix(pattern, "*"), "/")
+	return mod == base || strings.HasPrefix(mod, base+"/") || strings.HasPrefix(base, mod+"/")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
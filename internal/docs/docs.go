@@ -0,0 +1,293 @@
+package docs
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/server"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// DefaultDepth is how far call spines and I/O reachability are followed.
+const DefaultDepth = 8
+
+// typeTitles are the section titles for each entrypoint type.
+var typeTitles = map[store.EntrypointType]string{
+	store.EntrypointHTTP:   "HTTP Routes",
+	store.EntrypointGRPC:   "gRPC Methods",
+	store.EntrypointCLI:    "CLI Commands",
+	store.EntrypointMain:   "Main Packages",
+	store.EntrypointCustom: "Custom Entrypoints",
+}
+
+// Generator renders markdown architecture documents from an index.
+// Output is deterministic for a given index: no timestamps, stable ordering,
+// and file paths relative to the project directory, so documents can be
+// committed and diffed.
+type Generator struct {
+	store      *store.Store
+	projectDir string
+	depth      int
+}
+
+// NewGenerator creates a document generator for the indexed project.
+func NewGenerator(st *store.Store, projectDir string, depth int) *Generator {
+	if depth <= 0 {
+		depth = DefaultDepth
+	}
+	return &Generator{
+		store:      st,
+		projectDir: projectDir,
+		depth:      depth,
+	}
+}
+
+// Document is a rendered markdown document for one entrypoint type.
+type Document struct {
+	Type     store.EntrypointType
+	FileName string // e.g. "http.md"
+	Content  string
+}
+
+// Generate renders one document per entrypoint type that has entrypoints,
+// ordered by type.
+func (g *Generator) Generate(ctx context.Context) ([]Document, error) {
+	types := []store.EntrypointType{
+		store.EntrypointHTTP,
+		store.EntrypointGRPC,
+		store.EntrypointCLI,
+		store.EntrypointMain,
+		store.EntrypointCustom,
+	}
+
+	var docs []Document
+	for _, t := range types {
+		eps, err := g.store.GetEntrypoints(ctx, store.EntrypointFilter{Type: t})
+		if err != nil {
+			return nil, fmt.Errorf("getting %s entrypoints: %w", t, err)
+		}
+		if len(eps) == 0 {
+			continue
+		}
+		content, err := g.render(ctx, t, eps)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, Document{
+			Type:     t,
+			FileName: string(t) + ".md",
+			Content:  content,
+		})
+	}
+	return docs, nil
+}
+
+// reach is what an entrypoint reaches through its call graph.
+type reach struct {
+	ioTags     []string           // Sorted io:* tags
+	layerEdges map[[2]string]bool // Caller layer -> callee layer
+}
+
+// render builds the markdown for one entrypoint type.
+func (g *Generator) render(ctx context.Context, t store.EntrypointType, eps []store.EntrypointWithSymbol) (string, error) {
+	var b strings.Builder
+
+	title := typeTitles[t]
+	if title == "" {
+		title = string(t)
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	b.WriteString("<!-- Generated by `flowlens docs`. Do not edit by hand. -->\n\n")
+
+	// Route table
+	b.WriteString("## Entrypoints\n\n")
+	b.WriteString("| Entrypoint | Handler | Location |\n")
+	b.WriteString("|---|---|---|\n")
+	for _, ep := range eps {
+		fmt.Fprintf(&b, "| `%s` | `%s` | `%s` |\n",
+			escapeCell(ep.Label), escapeCell(qualifiedName(&ep.Symbol)), g.location(ep.Symbol.File, ep.Symbol.Line))
+	}
+	b.WriteString("\n")
+
+	reaches := make([]reach, len(eps))
+	for i, ep := range eps {
+		r, err := g.reach(ctx, ep.SymbolID)
+		if err != nil {
+			return "", fmt.Errorf("walking %s: %w", ep.Label, err)
+		}
+		reaches[i] = r
+	}
+
+	// I/O summary
+	b.WriteString("## I/O Summary\n\n")
+	b.WriteString("| Entrypoint | I/O |\n")
+	b.WriteString("|---|---|\n")
+	for i, ep := range eps {
+		io := "-"
+		if len(reaches[i].ioTags) > 0 {
+			io = strings.Join(reaches[i].ioTags, ", ")
+		}
+		fmt.Fprintf(&b, "| `%s` | %s |\n", escapeCell(ep.Label), io)
+	}
+	b.WriteString("\n")
+
+	// Layer diagram across all entrypoints of this type
+	layerEdges := make(map[[2]string]bool)
+	for _, r := range reaches {
+		for e := range r.layerEdges {
+			layerEdges[e] = true
+		}
+	}
+	b.WriteString("## Layers\n\n")
+	if len(layerEdges) == 0 {
+		b.WriteString("No cross-layer calls found.\n\n")
+	} else {
+		sorted := make([][2]string, 0, len(layerEdges))
+		for e := range layerEdges {
+			sorted = append(sorted, e)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i][0] != sorted[j][0] {
+				return sorted[i][0] < sorted[j][0]
+			}
+			return sorted[i][1] < sorted[j][1]
+		})
+		b.WriteString("```mermaid\nflowchart TD\n")
+		for _, e := range sorted {
+			fmt.Fprintf(&b, "    %s --> %s\n", e[0], e[1])
+		}
+		b.WriteString("```\n\n")
+	}
+
+	// Per-entrypoint call spines
+	b.WriteString("## Call Spines\n")
+	spines := server.NewSpineBuilder(g.store, server.DefaultGraphFilter())
+	for _, ep := range eps {
+		fmt.Fprintf(&b, "\n### %s\n\n", ep.Label)
+		spine, err := spines.BuildSpine(ctx, ep.SymbolID, g.depth)
+		if err != nil {
+			return "", fmt.Errorf("building spine for %s: %w", ep.Label, err)
+		}
+		for i, node := range spine.Nodes {
+			name := node.Name
+			if node.RecvType != "" {
+				name = "(" + node.RecvType + ")." + name
+			}
+			fmt.Fprintf(&b, "%d. `%s.%s` (`%s`)", i+1, node.PkgPath, name, g.location(node.File, node.Line))
+			if tags := displayTags(node.Tags); len(tags) > 0 {
+				fmt.Fprintf(&b, " %s", strings.Join(tags, " "))
+			}
+			if node.BranchBadge != nil {
+				fmt.Fprintf(&b, " +%d calls", node.BranchBadge.CallCount)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+// reach walks the call graph breadth-first from root up to the generator's
+// depth, collecting I/O tags and cross-layer calls. Callees are visited in
+// callsite order so the result is stable.
+func (g *Generator) reach(ctx context.Context, root store.SymbolID) (reach, error) {
+	r := reach{layerEdges: make(map[[2]string]bool)}
+	ioSet := make(map[string]bool)
+	layers := make(map[store.SymbolID]string)
+
+	rootTags, err := g.store.GetSymbolTags(ctx, root)
+	if err != nil {
+		return r, err
+	}
+	collectIO(rootTags, ioSet)
+	layers[root] = layerOf(rootTags)
+
+	visited := map[store.SymbolID]bool{root: true}
+	frontier := []store.SymbolID{root}
+	for depth := 0; depth < g.depth && len(frontier) > 0; depth++ {
+		var next []store.SymbolID
+		for _, id := range frontier {
+			callees, err := g.store.GetCallees(ctx, id)
+			if err != nil {
+				return r, err
+			}
+			for _, c := range callees {
+				calleeLayer := layerOf(c.Tags)
+				if layers[id] != "" && calleeLayer != "" && layers[id] != calleeLayer {
+					r.layerEdges[[2]string{layers[id], calleeLayer}] = true
+				}
+				if visited[c.Symbol.ID] {
+					continue
+				}
+				visited[c.Symbol.ID] = true
+				layers[c.Symbol.ID] = calleeLayer
+				collectIO(c.Tags, ioSet)
+				next = append(next, c.Symbol.ID)
+			}
+		}
+		frontier = next
+	}
+
+	for tag := range ioSet {
+		r.ioTags = append(r.ioTags, tag)
+	}
+	sort.Strings(r.ioTags)
+	return r, nil
+}
+
+// location formats file:line relative to the project directory when possible.
+func (g *Generator) location(file string, line int) string {
+	if g.projectDir != "" && filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(g.projectDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = filepath.ToSlash(rel)
+		}
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// collectIO adds io:* tags to the set.
+func collectIO(tags []store.Tag, set map[string]bool) {
+	for _, t := range tags {
+		if store.IsIOTag(t.Tag) {
+			set[t.Tag] = true
+		}
+	}
+}
+
+// layerOf returns the layer from a layer:* tag, or empty.
+func layerOf(tags []store.Tag) string {
+	for _, t := range tags {
+		if strings.HasPrefix(t.Tag, "layer:") {
+			return strings.TrimPrefix(t.Tag, "layer:")
+		}
+	}
+	return ""
+}
+
+// displayTags returns sorted io:* tags formatted for a spine line.
+func displayTags(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		if store.IsIOTag(t) {
+			out = append(out, "`"+t+"`")
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// qualifiedName returns pkg.Name or pkg.(Recv).Name.
+func qualifiedName(sym *store.Symbol) string {
+	if sym.RecvType != "" {
+		return fmt.Sprintf("%s.(%s).%s", sym.PkgPath, sym.RecvType, sym.Name)
+	}
+	return sym.PkgPath + "." + sym.Name
+}
+
+// escapeCell escapes pipes so labels don't break markdown tables.
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,206 @@
+package docs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func setupTestStore(t *testing.T) (*store.Store, string) {
+	projectDir := t.TempDir()
+	st, err := store.Open(projectDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	for _, pkg := range []*store.Package{
+		{PkgPath: "myapp/handlers", Dir: projectDir + "/handlers", Layer: "handler"},
+		{PkgPath: "myapp/service", Dir: projectDir + "/service", Layer: "service"},
+		{PkgPath: "myapp/store", Dir: projectDir + "/store", Layer: "store"},
+	} {
+		if err := st.InsertPackage(t.Context(), pkg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler, _ := st.InsertSymbol(t.Context(), &store.Symbol{PkgPath: "myapp/handlers", Name: "GetUser", Kind: store.SymbolKindFunc, File: projectDir + "/handlers/user.go", Line: 10})
+	service, _ := st.InsertSymbol(t.Context(), &store.Symbol{PkgPath: "myapp/service", Name: "GetUser", Kind: store.SymbolKindMethod, RecvType: "*UserService", File: projectDir + "/service/user.go", Line: 20})
+	repo, _ := st.InsertSymbol(t.Context(), &store.Symbol{PkgPath: "myapp/store", Name: "FindUser", Kind: store.SymbolKindFunc, File: projectDir + "/store/user.go", Line: 30})
+
+	for _, e := range []*store.CallEdge{
+		{CallerID: handler, CalleeID: service, CallerFile: "user.go", CallerLine: 11, CallKind: store.CallKindStatic, Count: 1},
+		{CallerID: service, CalleeID: repo, CallerFile: "user.go", CallerLine: 21, CallKind: store.CallKindStatic, Count: 1},
+	} {
+		if err := st.InsertCallEdge(t.Context(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tag := range []*store.Tag{
+		{SymbolID: handler, Tag: "layer:handler"},
+		{SymbolID: service, Tag: "layer:service"},
+		{SymbolID: repo, Tag: "layer:store"},
+		{SymbolID: repo, Tag: "io:db"},
+	} {
+		if err := st.InsertTag(t.Context(), tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := st.InsertEntrypoint(t.Context(), &store.Entrypoint{Type: store.EntrypointHTTP, Label: "GET /users/{id}", SymbolID: handler}); err != nil {
+		t.Fatal(err)
+	}
+
+	return st, projectDir
+}
+
+func TestGenerate(t *testing.T) {
+	st, projectDir := setupTestStore(t)
+	defer st.Close()
+
+	gen := NewGenerator(st, projectDir, 0)
+	documents, err := gen.Generate(t.Context())
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(documents) != 1 {
+		t.Fatalf("expected 1 document (http only), got %d", len(documents))
+	}
+
+	doc := documents[0]
+	if doc.FileName != "http.md" {
+		t.Errorf("expected http.md, got %s", doc.FileName)
+	}
+
+	for _, want := range []string{
+		"# HTTP Routes",
+		"| `GET /users/{id}` | `myapp/handlers.GetUser` | `handlers/user.go:10` |",
+		"| `GET /users/{id}` | io:db |",
+		"    handler --> service\n",
+		"    service --> store\n",
+		"3. `myapp/store.FindUser` (`store/user.go:30`) `io:db`",
+	} {
+		if !strings.Contains(doc.Content, want) {
+			t.Errorf("expected document to contain %q\n%s", want, doc.Content)
+		}
+	}
+	if strings.Contains(doc.Content, projectDir) {
+		t.Error("expected paths relative to the project directory")
+	}
+
+	// Output must be stable across runs
+	again, err := gen.Generate(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[0].Content != doc.Content {
+		t.Error("expected identical output on repeated generation")
+	}
+}
+
+func TestGenerateStructurizr(t *testing.T) {
+	st, projectDir := setupTestStore(t)
+	defer st.Close()
+
+	dsl, err := NewGenerator(st, projectDir, 0).GenerateStructurizr(t.Context(), "myapp")
+	if err != nil {
+		t.Fatalf("GenerateStructurizr failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`workspace "myapp"`,
+		`actor_HTTP_Client = person "HTTP Client"`,
+		`container_handler = container "handler" {`,
+		`pkg_myapp_handlers = component "myapp/handlers" "" "Go package"`,
+		`pkg_myapp_handlers -> pkg_myapp_service "Calls"`,
+		`pkg_myapp_service -> pkg_myapp_store "Calls"`,
+		`actor_HTTP_Client -> pkg_myapp_handlers "GET /users/{id}" "http"`,
+		`component container_store "Components-container_store" {`,
+	} {
+		if !strings.Contains(dsl, want) {
+			t.Errorf("expected DSL to contain %q\n%s", want, dsl)
+		}
+	}
+	if strings.Count(dsl, "{") != strings.Count(dsl, "}") {
+		t.Error("unbalanced braces in DSL")
+	}
+}
+
+func TestGenerateRoutes(t *testing.T) {
+	st, projectDir := setupTestStore(t)
+	defer st.Close()
+
+	handler, err := st.InsertSymbol(t.Context(), &store.Symbol{PkgPath: "myapp/handlers", Name: "Files", Kind: store.SymbolKindFunc, File: projectDir + "/handlers/files.go", Line: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ep := range []*store.Entrypoint{
+		{Type: store.EntrypointHTTP, Label: "ANY GET /files/{path...}", SymbolID: handler, MetaJSON: `{"method":"ANY","path":"GET /files/{path...}"}`},
+		{Type: store.EntrypointHTTP, Label: "POST /orders/:id/items", SymbolID: handler, MetaJSON: `{"method":"POST","path":"/orders/:id/items","middleware":["auth.Required"]}`},
+		{Type: store.EntrypointHTTP, Label: "ANY /health", SymbolID: handler, MetaJSON: `{"method":"ANY","path":"/health"}`},
+		{Type: store.EntrypointHTTP, Label: "Files", SymbolID: handler, MetaJSON: `{"method":"ANY","path":""}`, DiscoveryMethod: "signature"},
+	} {
+		if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gen := NewGenerator(st, projectDir, 0)
+
+	out, err := gen.GenerateRoutes(t.Context(), RouteOptions{Format: RouteFormatYAML, Name: "myapp"})
+	if err != nil {
+		t.Fatalf("GenerateRoutes failed: %v", err)
+	}
+	for _, want := range []string{
+		"# 1 HTTP handlers found by signature have no known route and are left out:\n#   myapp/handlers.Files\n",
+		"service: myapp\n",
+		"  - method: GET\n    path: /files/{path}\n    pattern: /files/{path...}\n    params: [path]\n    handler: myapp/handlers.Files\n    location: handlers/files.go:5\n",
+		"  - method: POST\n    path: /orders/{id}/items\n    pattern: /orders/:id/items\n",
+		"    middleware:\n      - auth.Required\n",
+		"  - method: GET\n    path: /users/{id}\n    params: [id]\n    handler: myapp/handlers.GetUser\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected routes.yaml to contain %q\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "/files/") > strings.Index(out, "/users/") {
+		t.Error("expected routes sorted by path")
+	}
+
+	out, err = gen.GenerateRoutes(t.Context(), RouteOptions{Format: RouteFormatKong, Name: "myapp", Upstream: "http://myapp:8080"})
+	if err != nil {
+		t.Fatalf("GenerateRoutes failed: %v", err)
+	}
+	for _, want := range []string{
+		`_format_version: "3.0"`,
+		"url: http://myapp:8080",
+		"- name: myapp-get-files-path\n        methods: [GET]\n        paths: [/files/]\n",
+		"- name: myapp-any-health\n        paths: [/health]\n",
+		"paths: ['~/orders/(?<id>[^/]+)/items$']",
+		"- name: myapp-get-users-id\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected Kong config to contain %q\n%s", want, out)
+		}
+	}
+
+	out, err = gen.GenerateRoutes(t.Context(), RouteOptions{Format: RouteFormatEnvoy, Name: "myapp"})
+	if err != nil {
+		t.Fatalf("GenerateRoutes failed: %v", err)
+	}
+	for _, want := range []string{
+		"domains: ['*']",
+		"match:\n            prefix: /files/\n",
+		"match:\n            path: /health\n          route:\n            cluster: myapp\n",
+		"safe_regex:\n              regex: ^/users/(?:[^/]+)$\n",
+		"- name: :method\n                string_match:\n                  exact: GET\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected Envoy config to contain %q\n%s", want, out)
+		}
+	}
+
+	if _, err := gen.GenerateRoutes(t.Context(), RouteOptions{Format: "nginx"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,420 @@
+package docs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/index"
+	"github.com/abramin/flowlens/internal/store"
+	"gopkg.in/yaml.v3"
+)
+
+// Route table formats for GenerateRoutes.
+const (
+	RouteFormatYAML  = "yaml"  // Plain routes.yaml listing every route with its handler
+	RouteFormatKong  = "kong"  // Kong declarative configuration (decK)
+	RouteFormatEnvoy = "envoy" // Envoy route configuration
+)
+
+// RouteFormats lists the formats GenerateRoutes accepts.
+var RouteFormats = []string{RouteFormatYAML, RouteFormatKong, RouteFormatEnvoy}
+
+// RouteOptions configures GenerateRoutes.
+type RouteOptions struct {
+	Format   string // One of RouteFormats
+	Name     string // Service (Kong), cluster and virtual host (Envoy) name
+	Upstream string // Upstream URL of the Kong service
+}
+
+// route is an HTTP entrypoint parsed for a gateway.
+type route struct {
+	Method     string // Upper case; "ANY" matches every method
+	Host       string // From Go 1.22 patterns like "example.com/path"; empty for any
+	Path       string // As registered, e.g. "/users/:id"
+	Segments   []store.RouteSegment
+	Handler    string
+	Location   string
+	Middleware []string
+}
+
+// GenerateRoutes renders the HTTP entrypoints as a route table for API
+// gateway and infrastructure configuration: a plain routes.yaml, or Kong or
+// Envoy route stubs that send every route to one upstream. Routes are sorted
+// by path and method. Handlers found by their signature have no known path
+// and are listed in a leading comment instead.
+func (g *Generator) GenerateRoutes(ctx context.Context, opts RouteOptions) (string, error) {
+	eps, err := g.store.GetEntrypoints(ctx, store.EntrypointFilter{Type: store.EntrypointHTTP})
+	if err != nil {
+		return "", fmt.Errorf("getting HTTP entrypoints: %w", err)
+	}
+
+	var routes []route
+	var unrouted []string
+	seen := make(map[string]bool)
+	for _, ep := range eps {
+		r, ok := g.parseRoute(&ep)
+		if !ok {
+			unrouted = append(unrouted, r.Handler)
+			continue
+		}
+		key := r.Method + " " + r.Host + r.Path
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		routes = append(routes, r)
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	sort.Strings(unrouted)
+
+	var doc any
+	switch opts.Format {
+	case RouteFormatYAML, "":
+		doc = routesYAML(opts, routes)
+	case RouteFormatKong:
+		doc = kongConfig(opts, routes)
+	case RouteFormatEnvoy:
+		doc = envoyConfig(opts, routes)
+	default:
+		return "", fmt.Errorf("unknown route format %q (want %s)", opts.Format, strings.Join(RouteFormats, ", "))
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Generated by flowlens export routes; review before use\n")
+	if len(unrouted) > 0 {
+		fmt.Fprintf(&b, "# %d HTTP handlers found by signature have no known route and are left out:\n", len(unrouted))
+		for _, h := range unrouted {
+			fmt.Fprintf(&b, "#   %s\n", h)
+		}
+	}
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return "", fmt.Errorf("encoding routes: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// parseRoute reads the method and path of an HTTP entrypoint from its
+// metadata, or its label for entrypoints without any. Go 1.22 ServeMux
+// patterns ("GET example.com/users/{id}") are split into method, host, and
+// path. It returns false for entrypoints without a path.
+func (g *Generator) parseRoute(ep *store.EntrypointWithSymbol) (route, bool) {
+	r := route{
+		Handler:  qualifiedName(&ep.Symbol),
+		Location: g.location(ep.Symbol.File, ep.Symbol.Line),
+	}
+	var meta index.HTTPMeta
+	if ep.MetaJSON != "" && json.Unmarshal([]byte(ep.MetaJSON), &meta) == nil {
+		r.Method, r.Path, r.Middleware = meta.Method, meta.Path, meta.Middleware
+	} else if method, path, ok := strings.Cut(ep.Label, " "); ok {
+		r.Method, r.Path = method, path
+	}
+
+	if r.Method == "" {
+		r.Method = "ANY"
+	}
+	r.Method, r.Host, r.Path = store.SplitRoutePattern(strings.ToUpper(r.Method), r.Path)
+	if !strings.HasPrefix(r.Path, "/") {
+		return r, false
+	}
+	r.Segments = store.ParseRoutePath(r.Path)
+	return r, true
+}
+
+// paramName returns the name of a parameter segment, naming gin's and
+// chi's bare "*" catch-all "rest".
+func paramName(s store.RouteSegment) string {
+	if s.Name == "" {
+		return "rest"
+	}
+	return s.Name
+}
+
+// literal returns the fixed text of a segment; Go 1.22's {$}, which only
+// marks the end of the path, is empty like a trailing slash.
+func literal(s store.RouteSegment) string {
+	if s.Literal == "{$}" {
+		return ""
+	}
+	return s.Literal
+}
+
+// template returns the path in OpenAPI form, e.g. "/users/{id}".
+func (r *route) template() string {
+	var b strings.Builder
+	for _, s := range r.Segments {
+		b.WriteString("/")
+		if s.Param || s.CatchAll {
+			b.WriteString("{" + paramName(s) + "}")
+		} else {
+			b.WriteString(literal(s))
+		}
+	}
+	return b.String()
+}
+
+// params returns the names of the path parameters.
+func (r *route) params() []string {
+	var names []string
+	for _, s := range r.Segments {
+		if s.Param || s.CatchAll {
+			names = append(names, paramName(s))
+		}
+	}
+	return names
+}
+
+// static reports whether the path has no parameters, and if so whether it
+// ends in a catch-all and so matches as a prefix.
+func (r *route) static() (static, prefix bool) {
+	for i, s := range r.Segments {
+		if !s.Param && !s.CatchAll {
+			continue
+		}
+		if s.CatchAll && i == len(r.Segments)-1 {
+			return true, true
+		}
+		return false, false
+	}
+	return true, false
+}
+
+// literalPrefix returns the path up to a trailing catch-all.
+func (r *route) literalPrefix() string {
+	var b strings.Builder
+	for _, s := range r.Segments {
+		b.WriteString("/")
+		if s.CatchAll {
+			break
+		}
+		b.WriteString(literal(s))
+	}
+	return b.String()
+}
+
+// regex returns an anchored regular expression for the path. With named,
+// parameters become named groups in PCRE syntax, as Kong's router expects.
+func (r *route) regex(named bool) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, s := range r.Segments {
+		b.WriteString("/")
+		if !s.Param && !s.CatchAll {
+			b.WriteString(regexp.QuoteMeta(literal(s)))
+			continue
+		}
+		pattern := "[^/]+"
+		if s.CatchAll {
+			pattern = ".*"
+		} else if s.Pattern != "" {
+			pattern = s.Pattern
+		}
+		if named {
+			fmt.Fprintf(&b, "(?<%s>%s)", paramName(s), pattern)
+		} else {
+			fmt.Fprintf(&b, "(?:%s)", pattern)
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// routesFile is the plain routes.yaml format.
+type routesFile struct {
+	Service string        `yaml:"service"`
+	Routes  []routesEntry `yaml:"routes"`
+}
+
+type routesEntry struct {
+	Method     string   `yaml:"method"`
+	Host       string   `yaml:"host,omitempty"`
+	Path       string   `yaml:"path"`                  // OpenAPI form
+	Pattern    string   `yaml:"pattern,omitempty"`     // As registered, when it differs
+	Params     []string `yaml:"params,omitempty,flow"` // Path parameters
+	Handler    string   `yaml:"handler"`
+	Location   string   `yaml:"location"`
+	Middleware []string `yaml:"middleware,omitempty"`
+}
+
+func routesYAML(opts RouteOptions, routes []route) routesFile {
+	f := routesFile{Service: opts.Name, Routes: []routesEntry{}}
+	for _, r := range routes {
+		e := routesEntry{
+			Method:     r.Method,
+			Host:       r.Host,
+			Path:       r.template(),
+			Params:     r.params(),
+			Handler:    r.Handler,
+			Location:   r.Location,
+			Middleware: r.Middleware,
+		}
+		if e.Path != r.Path {
+			e.Pattern = r.Path
+		}
+		f.Routes = append(f.Routes, e)
+	}
+	return f
+}
+
+// kongFile is a Kong declarative configuration with one service.
+type kongFile struct {
+	FormatVersion string        `yaml:"_format_version"`
+	Services      []kongService `yaml:"services"`
+}
+
+type kongService struct {
+	Name   string      `yaml:"name"`
+	URL    string      `yaml:"url"`
+	Routes []kongRoute `yaml:"routes"`
+}
+
+type kongRoute struct {
+	Name      string   `yaml:"name"`
+	Methods   []string `yaml:"methods,omitempty,flow"`
+	Hosts     []string `yaml:"hosts,omitempty,flow"`
+	Paths     []string `yaml:"paths,flow"`
+	StripPath bool     `yaml:"strip_path"`
+}
+
+// kongConfig sends every route to one service. Paths without parameters
+// are plain (Kong matches them as prefixes); others are regex paths ("~").
+func kongConfig(opts RouteOptions, routes []route) kongFile {
+	svc := kongService{Name: opts.Name, URL: opts.Upstream, Routes: []kongRoute{}}
+	names := make(map[string]int)
+	for _, r := range routes {
+		kr := kongRoute{Name: routeName(opts.Name, &r, names)}
+		if r.Method != "ANY" {
+			kr.Methods = []string{r.Method}
+		}
+		if r.Host != "" {
+			kr.Hosts = []string{r.Host}
+		}
+		if static, prefix := r.static(); static && prefix {
+			kr.Paths = []string{r.literalPrefix()}
+		} else if static {
+			kr.Paths = []string{r.template()}
+		} else {
+			kr.Paths = []string{"~" + strings.TrimPrefix(r.regex(true), "^")}
+		}
+		svc.Routes = append(svc.Routes, kr)
+	}
+	return kongFile{FormatVersion: "3.0", Services: []kongService{svc}}
+}
+
+// routeName returns a unique name for a route, e.g. "myapp-get-users-id".
+func routeName(service string, r *route, used map[string]int) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(service + "-" + r.Method + "-" + r.Host + r.template()) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		} else if s := b.String(); s != "" && !strings.HasSuffix(s, "-") {
+			b.WriteByte('-')
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "-")
+	used[name]++
+	if n := used[name]; n > 1 {
+		name = fmt.Sprintf("%s-%d", name, n)
+	}
+	return name
+}
+
+// envoyFile is an Envoy RouteConfiguration, to be placed under an HTTP
+// connection manager's route_config.
+type envoyFile struct {
+	RouteConfig envoyRouteConfig `yaml:"route_config"`
+}
+
+type envoyRouteConfig struct {
+	Name         string             `yaml:"name"`
+	VirtualHosts []envoyVirtualHost `yaml:"virtual_hosts"`
+}
+
+type envoyVirtualHost struct {
+	Name    string       `yaml:"name"`
+	Domains []string     `yaml:"domains,flow"`
+	Routes  []envoyRoute `yaml:"routes"`
+}
+
+type envoyRoute struct {
+	Name  string      `yaml:"name"`
+	Match envoyMatch  `yaml:"match"`
+	Route envoyAction `yaml:"route"`
+}
+
+type envoyMatch struct {
+	Path      string        `yaml:"path,omitempty"`
+	Prefix    string        `yaml:"prefix,omitempty"`
+	SafeRegex *envoyRegex   `yaml:"safe_regex,omitempty"`
+	Headers   []envoyHeader `yaml:"headers,omitempty"`
+}
+
+type envoyRegex struct {
+	Regex string `yaml:"regex"`
+}
+
+type envoyHeader struct {
+	Name        string            `yaml:"name"`
+	StringMatch map[string]string `yaml:"string_match"`
+}
+
+type envoyAction struct {
+	Cluster string `yaml:"cluster"`
+}
+
+// envoyConfig sends every route to one cluster, with a virtual host per
+// host the routes are registered for and one for any other host.
+func envoyConfig(opts RouteOptions, routes []route) envoyFile {
+	hosts := make(map[string]*envoyVirtualHost)
+	var order []string
+	names := make(map[string]int)
+	for _, r := range routes {
+		vh, ok := hosts[r.Host]
+		if !ok {
+			vh = &envoyVirtualHost{Name: opts.Name, Domains: []string{"*"}, Routes: []envoyRoute{}}
+			if r.Host != "" {
+				vh.Name, vh.Domains = opts.Name+"-"+r.Host, []string{r.Host}
+			}
+			hosts[r.Host] = vh
+			order = append(order, r.Host)
+		}
+
+		er := envoyRoute{Name: routeName(opts.Name, &r, names), Route: envoyAction{Cluster: opts.Name}}
+		if static, prefix := r.static(); static && prefix {
+			er.Match.Prefix = r.literalPrefix()
+		} else if static {
+			er.Match.Path = r.template()
+		} else {
+			er.Match.SafeRegex = &envoyRegex{Regex: r.regex(false)}
+		}
+		if r.Method != "ANY" {
+			er.Match.Headers = []envoyHeader{{Name: ":method", StringMatch: map[string]string{"exact": r.Method}}}
+		}
+		vh.Routes = append(vh.Routes, er)
+	}
+
+	// Envoy picks the first virtual host matching a domain, so "*" goes last
+	sort.SliceStable(order, func(i, j int) bool { return order[i] != "" && order[j] == "" })
+	cfg := envoyFile{RouteConfig: envoyRouteConfig{Name: opts.Name, VirtualHosts: []envoyVirtualHost{}}}
+	for _, h := range order {
+		cfg.RouteConfig.VirtualHosts = append(cfg.RouteConfig.VirtualHosts, *hosts[h])
+	}
+	return cfg
+}
@@ -0,0 +1,156 @@
+package docs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// entrypointActors are the people that invoke each entrypoint type in the C4 model.
+var entrypointActors = map[store.EntrypointType]string{
+	store.EntrypointHTTP: "HTTP Client",
+	store.EntrypointGRPC: "gRPC Client",
+	store.EntrypointCLI:  "Operator",
+	store.EntrypointMain: "Operator",
+}
+
+// GenerateStructurizr renders the index as a Structurizr DSL workspace.
+// The project is one software system; layers (or modules, for unlayered
+// packages) are containers and packages are components. Package-to-package
+// calls become relationships, and each entrypoint becomes a relationship
+// from the actor that invokes it to its handler's package.
+func (g *Generator) GenerateStructurizr(ctx context.Context, name string) (string, error) {
+	pkgs, err := g.store.GetPackages(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getting packages: %w", err)
+	}
+	deps, err := g.store.GetPackageDependencies(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getting package dependencies: %w", err)
+	}
+	eps, err := g.store.GetEntrypoints(ctx, store.EntrypointFilter{})
+	if err != nil {
+		return "", fmt.Errorf("getting entrypoints: %w", err)
+	}
+
+	// Group packages into containers
+	containers := make(map[string][]store.Package)
+	for _, pkg := range pkgs {
+		c := containerFor(pkg)
+		containers[c] = append(containers[c], pkg)
+	}
+	containerNames := make([]string, 0, len(containers))
+	for c := range containers {
+		containerNames = append(containerNames, c)
+	}
+	sort.Strings(containerNames)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "workspace %s \"Generated by flowlens export structurizr\" {\n\n", dslString(name))
+	b.WriteString("    model {\n")
+
+	// Actors for entrypoint types that are present
+	actors := make(map[string]bool)
+	for _, ep := range eps {
+		if actor, ok := entrypointActors[ep.Type]; ok {
+			actors[actor] = true
+		}
+	}
+	actorNames := make([]string, 0, len(actors))
+	for a := range actors {
+		actorNames = append(actorNames, a)
+	}
+	sort.Strings(actorNames)
+	for _, a := range actorNames {
+		fmt.Fprintf(&b, "        %s = person %s\n", dslID("actor", a), dslString(a))
+	}
+	if len(actorNames) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "        system = softwareSystem %s {\n", dslString(name))
+	for _, c := range containerNames {
+		fmt.Fprintf(&b, "            %s = container %s {\n", dslID("container", c), dslString(c))
+		for _, pkg := range containers[c] {
+			fmt.Fprintf(&b, "                %s = component %s \"\" \"Go package\"\n", dslID("pkg", pkg.PkgPath), dslString(pkg.PkgPath))
+		}
+		b.WriteString("            }\n")
+	}
+	b.WriteString("        }\n\n")
+
+	// Package dependencies; calls to packages outside the index are skipped
+	known := make(map[string]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		known[pkg.PkgPath] = true
+	}
+	for _, d := range deps {
+		if !known[d.From] || !known[d.To] {
+			continue
+		}
+		fmt.Fprintf(&b, "        %s -> %s \"Calls\"\n", dslID("pkg", d.From), dslID("pkg", d.To))
+	}
+
+	// Entrypoints
+	for _, ep := range eps {
+		actor, ok := entrypointActors[ep.Type]
+		if !ok || !known[ep.Symbol.PkgPath] {
+			continue
+		}
+		fmt.Fprintf(&b, "        %s -> %s %s %s\n",
+			dslID("actor", actor), dslID("pkg", ep.Symbol.PkgPath), dslString(ep.Label), dslString(string(ep.Type)))
+	}
+	b.WriteString("    }\n\n")
+
+	b.WriteString("    views {\n")
+	b.WriteString("        container system \"Containers\" {\n")
+	b.WriteString("            include *\n")
+	b.WriteString("            autoLayout lr\n")
+	b.WriteString("        }\n")
+	for _, c := range containerNames {
+		id := dslID("container", c)
+		fmt.Fprintf(&b, "\n        component %s %s {\n", id, dslString("Components-"+id))
+		b.WriteString("            include *\n")
+		b.WriteString("            autoLayout lr\n")
+		b.WriteString("        }\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+
+	return b.String(), nil
+}
+
+// containerFor returns the container a package belongs to: its layer,
+// else its module, else "other".
+func containerFor(pkg store.Package) string {
+	if pkg.Layer != "" {
+		return pkg.Layer
+	}
+	if pkg.Module != "" {
+		return pkg.Module
+	}
+	return "other"
+}
+
+// dslID builds a DSL identifier from a prefix and an arbitrary name.
+// Identifiers may only contain letters, digits, and underscores.
+func dslID(prefix, name string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteByte('_')
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// dslString quotes a string for the DSL.
+func dslString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
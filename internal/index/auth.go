@@ -0,0 +1,154 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// AuthChecker flags HTTP entrypoints that are not behind authentication:
+// neither a configured auth middleware in the route's middleware chain nor
+// an auth check reachable through the handler's calls.
+type AuthChecker struct {
+	cfg   *config.Config
+	store *store.Store
+}
+
+// AuthResult holds the results of the auth check.
+type AuthResult struct {
+	Checked int // HTTP entrypoints checked
+	Missing int // Entrypoints with no auth found
+}
+
+// NewAuthChecker creates an auth checker.
+func NewAuthChecker(cfg *config.Config, st *store.Store) *AuthChecker {
+	return &AuthChecker{
+		cfg:   cfg,
+		store: st,
+	}
+}
+
+// Check classifies every HTTP entrypoint and records the results.
+func (a *AuthChecker) Check(ctx context.Context) (*AuthResult, error) {
+	eps, err := a.store.GetEntrypoints(ctx, store.EntrypointFilter{Type: store.EntrypointHTTP})
+	if err != nil {
+		return nil, fmt.Errorf("getting entrypoints: %w", err)
+	}
+	symbols, err := a.store.GetAllSymbolsForTagging(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting symbols: %w", err)
+	}
+	byID := make(map[store.SymbolID]store.SymbolForTagging, len(symbols))
+	for _, sym := range symbols {
+		byID[sym.ID] = sym
+	}
+	callees, err := a.store.GetSymbolCalleesWithTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting callees: %w", err)
+	}
+
+	batch, err := a.store.BeginBatch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("starting batch: %w", err)
+	}
+	defer batch.Rollback()
+
+	result := &AuthResult{}
+	for _, ep := range eps {
+		var meta HTTPMeta
+		if ep.MetaJSON != "" {
+			json.Unmarshal([]byte(ep.MetaJSON), &meta)
+		}
+
+		check := a.classify(ep.Label, ep.SymbolID, meta.Middleware, byID, callees)
+		check.EntrypointID = ep.ID
+		if err := batch.InsertAuthCheck(ctx, check); err != nil {
+			return nil, fmt.Errorf("inserting auth check: %w", err)
+		}
+		result.Checked++
+		if check.Status == store.AuthMissing {
+			result.Missing++
+		}
+	}
+
+	if err := batch.Commit(); err != nil {
+		return nil, fmt.Errorf("committing batch: %w", err)
+	}
+	return result, nil
+}
+
+// classify decides the auth status of one route. Configured public routes
+// win, then middleware, then the shortest call path to an auth function.
+func (a *AuthChecker) classify(label string, handler store.SymbolID, middleware []string,
+	symbols map[store.SymbolID]store.SymbolForTagging, callees map[store.SymbolID][]store.SymbolCallee) *store.AuthCheck {
+	check := &store.AuthCheck{Middleware: middleware}
+
+	if a.cfg.IsPublicRoute(label) {
+		check.Status = store.AuthPublic
+		return check
+	}
+	for _, m := range middleware {
+		if a.cfg.IsAuthMiddleware(m) {
+			check.Status = store.AuthMiddleware
+			check.Via = []string{m}
+			return check
+		}
+	}
+
+	// Breadth-first so the reported path is the shortest
+	parent := map[store.SymbolID]store.SymbolID{handler: 0}
+	queue := []store.SymbolID{handler}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, c := range callees[id] {
+			if _, seen := parent[c.CalleeID]; seen {
+				continue
+			}
+			parent[c.CalleeID] = id
+			if a.isAuthSymbol(symbols[c.CalleeID]) {
+				check.Status = store.AuthCall
+				check.Via = callPath(c.CalleeID, parent, symbols)
+				return check
+			}
+			queue = append(queue, c.CalleeID)
+		}
+	}
+
+	check.Status = store.AuthMissing
+	return check
+}
+
+// isAuthSymbol matches a function against the auth patterns by its name,
+// its package-qualified name (auth.Require), and its receiver-qualified
+// name (Authenticator.Check).
+func (a *AuthChecker) isAuthSymbol(sym store.SymbolForTagging) bool {
+	if sym.Name == "" {
+		return false
+	}
+	names := []string{sym.Name, path.Base(sym.PkgPath) + "." + sym.Name}
+	if sym.RecvType != "" {
+		names = append(names, strings.TrimPrefix(sym.RecvType, "*")+"."+sym.Name)
+	}
+	for _, name := range names {
+		if a.cfg.IsAuthMiddleware(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// callPath returns the symbol keys from the BFS root to id.
+func callPath(id store.SymbolID, parent map[store.SymbolID]store.SymbolID, symbols map[store.SymbolID]store.SymbolForTagging) []string {
+	var keys []string
+	for ; id != 0; id = parent[id] {
+		sym := symbols[id]
+		keys = append([]string{store.SymbolKey(sym.PkgPath, sym.Name, sym.RecvType)}, keys...)
+	}
+	return keys
+}
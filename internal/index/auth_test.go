@@ -0,0 +1,163 @@
+package index
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestAuthChecker(t *testing.T) {
+	// A chi-style router defined locally so the fixture needs no modules
+	tmpDir := t.TempDir()
+	src := `package main
+
+import "net/http"
+
+type Router struct{}
+
+func (r *Router) Use(mw ...func(http.Handler) http.Handler)                  {}
+func (r *Router) With(mw ...func(http.Handler) http.Handler) *Router         { return r }
+func (r *Router) Group(fn func(r *Router))                                   {}
+func (r *Router) Route(pattern string, fn func(r *Router))                   {}
+func (r *Router) Get(pattern string, h http.HandlerFunc)                     {}
+func (r *Router) Post(pattern string, h http.HandlerFunc)                    {}
+
+func RequireAuth(next http.Handler) http.Handler { return next }
+func logging(next http.Handler) http.Handler     { return next }
+func authenticate(r *http.Request) bool          { return true }
+
+func health(w http.ResponseWriter, r *http.Request)     {}
+func listPosts(w http.ResponseWriter, r *http.Request)  {}
+func createPost(w http.ResponseWriter, r *http.Request) {}
+func getUser(w http.ResponseWriter, r *http.Request)    {}
+func adminStats(w http.ResponseWriter, r *http.Request) {}
+func profile(w http.ResponseWriter, r *http.Request)    { authenticate(r) }
+func debug(w http.ResponseWriter, r *http.Request)      {}
+
+func main() {
+	r := &Router{}
+	r.Use(logging)
+	r.Get("/health", health)
+	r.Get("/posts", listPosts)
+	r.With(RequireAuth).Post("/posts", createPost)
+	r.Route("/users", func(r *Router) {
+		r.Use(RequireAuth)
+		r.Get("/{id}", getUser)
+	})
+	r.Group(func(r *Router) {
+		r.Get("/admin/stats", adminStats)
+	})
+	r.Get("/me", profile)
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug", RequireAuth(http.HandlerFunc(debug)))
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module authmod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	cfg := config.Default()
+	loader := NewLoader(cfg, tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	batch, err := st.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatalf("starting batch: %v", err)
+	}
+	if _, err := NewEntrypointDetector(loader).Detect(t.Context(), batch); err != nil {
+		batch.Rollback()
+		t.Fatalf("detecting entrypoints: %v", err)
+	}
+	// Call edges normally come from SSA; profile -> authenticate is enough here
+	profileID, _ := batch.GetSymbolID(t.Context(), "authmod", "profile", "")
+	authID, _ := batch.GetSymbolID(t.Context(), "authmod", "authenticate", "")
+	edge := &store.CallEdge{CallerID: profileID, CalleeID: authID, CallerFile: "main.go", CallerLine: 20, CallKind: store.CallKindStatic, Count: 1}
+	if err := batch.InsertCallEdge(t.Context(), edge); err != nil {
+		t.Fatalf("inserting call edge: %v", err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("committing: %v", err)
+	}
+
+	// Middleware chains are recorded on the route metadata
+	eps, err := st.GetEntrypoints(t.Context(), store.EntrypointFilter{Type: store.EntrypointHTTP})
+	if err != nil {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	chains := make(map[string]string)
+	for _, ep := range eps {
+		var meta HTTPMeta
+		json.Unmarshal([]byte(ep.MetaJSON), &meta)
+		chains[ep.Symbol.Name] = strings.Join(meta.Middleware, ",")
+	}
+	wantChains := map[string]string{
+		"health":     "logging",
+		"listPosts":  "logging",
+		"createPost": "logging,RequireAuth",
+		"getUser":    "logging,RequireAuth",
+		"adminStats": "logging",
+		"profile":    "logging",
+		"debug":      "RequireAuth",
+	}
+	for name, want := range wantChains {
+		if got, ok := chains[name]; !ok || got != want {
+			t.Errorf("%s: expected middleware %q, got %q (found=%v)", name, want, got, ok)
+		}
+	}
+
+	result, err := NewAuthChecker(cfg, st).Check(t.Context())
+	if err != nil {
+		t.Fatalf("checking auth: %v", err)
+	}
+	if result.Checked != len(wantChains) || result.Missing != 2 {
+		t.Errorf("expected %d checked and 2 missing, got %+v", len(wantChains), result)
+	}
+
+	checks, err := st.GetAuthChecks(t.Context(), "")
+	if err != nil {
+		t.Fatalf("getting auth checks: %v", err)
+	}
+	status := make(map[string]store.AuthCheck)
+	for _, c := range checks {
+		status[c.EntrypointLabel] = c
+	}
+	wantStatus := map[string]string{
+		"GET /health":      store.AuthPublic,
+		"GET /posts":       store.AuthMissing,
+		"POST /posts":      store.AuthMiddleware,
+		"GET /{id}":        store.AuthMiddleware,
+		"GET /admin/stats": store.AuthMissing,
+		"GET /me":          store.AuthCall,
+		"ANY /debug":       store.AuthMiddleware,
+	}
+	for label, want := range wantStatus {
+		if got := status[label].Status; got != want {
+			t.Errorf("%s: expected status %q, got %q", label, want, got)
+		}
+	}
+	if via := status["GET /me"].Via; len(via) != 2 || via[1] != "authmod.authenticate" {
+		t.Errorf("expected call path to authmod.authenticate, got %v", via)
+	}
+	if via := status["POST /posts"].Via; len(via) != 1 || via[0] != "RequireAuth" {
+		t.Errorf("expected RequireAuth middleware, got %v", via)
+	}
+}
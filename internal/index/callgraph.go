@@ -1,10 +1,13 @@
 package index
 
 import (
+	"context"
 	"fmt"
+	"go/ast"
 	"go/token"
 	"go/types"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/abramin/flowlens/internal/store"
 	"golang.org/x/tools/go/packages"
@@ -18,6 +21,8 @@ type CallGraphBuilder struct {
 	prog         *ssa.Program
 	projectPkgs  map[string]bool // Set of project package paths (not dependencies)
 	symbolCache  map[string]store.SymbolID
+	modules      map[string]*packages.Module // Dependency package path -> module; nil unless dependency indexing or a repo name is set
+	unresolved   map[string]map[string]int   // Caller package -> unresolved-call reason -> count
 	onProgress   func(current, total int)
 }
 
@@ -27,6 +32,7 @@ func NewCallGraphBuilder(loader *Loader) *CallGraphBuilder {
 		loader:      loader,
 		projectPkgs: make(map[string]bool),
 		symbolCache: make(map[string]store.SymbolID),
+		unresolved:  make(map[string]map[string]int),
 	}
 }
 
@@ -42,6 +48,18 @@ func (b *CallGraphBuilder) Build() error {
 		b.projectPkgs[pkg.PkgPath] = true
 	}
 
+	// Map dependency packages to their modules so calls crossing the
+	// project boundary can be attributed, or resolved into other
+	// repositories sharing the index
+	if b.loader.cfg != nil && (b.loader.cfg.Dependencies.Index || b.loader.cfg.Repo != "") {
+		b.modules = make(map[string]*packages.Module)
+		for _, pkg := range AllPackages(b.loader.pkgs) {
+			if pkg.Module != nil && !pkg.Module.Main && !b.projectPkgs[pkg.PkgPath] {
+				b.modules[pkg.PkgPath] = pkg.Module
+			}
+		}
+	}
+
 	// Build SSA program from all loaded packages
 	prog, _ := ssautil.AllPackages(b.loader.pkgs, ssa.SanityCheckFunctions)
 	prog.Build()
@@ -51,8 +69,8 @@ func (b *CallGraphBuilder) Build() error {
 }
 
 // ExtractCallEdges extracts all call edges and persists them to the store.
-func (b *CallGraphBuilder) ExtractCallEdges(st *store.Store) error {
-	batch, err := st.BeginBatch()
+func (b *CallGraphBuilder) ExtractCallEdges(ctx context.Context, st *store.Store) error {
+	batch, err := st.BeginBatch(ctx)
 	if err != nil {
 		return fmt.Errorf("starting batch: %w", err)
 	}
@@ -92,7 +110,7 @@ func (b *CallGraphBuilder) ExtractCallEdges(st *store.Store) error {
 		}
 
 		for _, edge := range edges {
-			if err := batch.InsertCallEdge(edge); err != nil {
+			if err := batch.InsertCallEdge(ctx, edge); err != nil {
 				return fmt.Errorf("inserting call edge: %w", err)
 			}
 			edgeCount++
@@ -140,6 +158,7 @@ func (b *CallGraphBuilder) extractFunctionCalls(fn *ssa.Function) ([]*store.Call
 func (b *CallGraphBuilder) extractCallFromInstruction(caller *ssa.Function, instr ssa.Instruction, callerID store.SymbolID) *store.CallEdge {
 	var call *ssa.Call
 	var callKind store.CallKind
+	resolvedBy := store.ResolvedSSAStatic
 
 	switch v := instr.(type) {
 	case *ssa.Call:
@@ -182,6 +201,7 @@ func (b *CallGraphBuilder) extractCallFromInstruction(caller *ssa.Function, inst
 	} else if common.IsInvoke() {
 		// Interface method call
 		callKind = store.CallKindInterface
+		resolvedBy = store.ResolvedInterfaceHeuristic
 		calleeID, err = b.resolveInterfaceCall(common)
 		if err != nil || calleeID == 0 {
 			return nil
@@ -189,6 +209,7 @@ func (b *CallGraphBuilder) extractCallFromInstruction(caller *ssa.Function, inst
 	} else {
 		// Function value call
 		callKind = store.CallKindFuncval
+		resolvedBy = store.ResolvedClosureTrace
 		calleeID, err = b.resolveFuncvalCall(common)
 		if err != nil || calleeID == 0 {
 			// Mark as unknown if we can't resolve
@@ -203,6 +224,7 @@ func (b *CallGraphBuilder) extractCallFromInstruction(caller *ssa.Function, inst
 		CallerFile: pos.Filename,
 		CallerLine: pos.Line,
 		CallKind:   callKind,
+		ResolvedBy: resolvedBy,
 		Count:      1,
 	}
 }
@@ -254,12 +276,16 @@ func (b *CallGraphBuilder) resolveFuncvalCall(common *ssa.CallCommon) (store.Sym
 	return 0, nil
 }
 
-// formatSSAReceiverType formats an SSA receiver type as a string.
+// formatSSAReceiverType formats an SSA receiver type as a string, as
+// formatReceiverType does for the declaration.
 func formatSSAReceiverType(t types.Type) string {
 	switch typ := t.(type) {
 	case *types.Pointer:
 		return "*" + formatSSAReceiverType(typ.Elem())
 	case *types.Named:
+		if typ.TypeParams().Len() > 0 || typ.TypeArgs().Len() > 0 {
+			return typ.Obj().Name() + "[...]"
+		}
 		return typ.Obj().Name()
 	default:
 		return types.TypeString(t, nil)
@@ -274,11 +300,17 @@ type CallGraphResult struct {
 	DeferCalls    int
 	GoCalls       int
 	UnknownCalls  int
+	ExternalCalls int // Calls into third-party modules (dependency indexing only)
+	UnresolvedCalls  int // Call sites that produced no edge (see store.UnresolvedReasons)
+	SkippedFunctions int // Functions without a symbol whose calls were dropped
+	GlobalWrites     int // Stores to package-level variables
+	ErrorSites       int // Calls that wrap, drop, or convert errors
+	FlagUses         int // Feature-flag evaluations
 }
 
 // ExtractCallEdgesWithStore extracts call edges using the store directly for lookups.
-func (b *CallGraphBuilder) ExtractCallEdgesWithStore(st *store.Store) (*CallGraphResult, error) {
-	batch, err := st.BeginBatch()
+func (b *CallGraphBuilder) ExtractCallEdgesWithStore(ctx context.Context, st *store.Store) (*CallGraphResult, error) {
+	batch, err := st.BeginBatch(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("starting batch: %w", err)
 	}
@@ -286,6 +318,13 @@ func (b *CallGraphBuilder) ExtractCallEdgesWithStore(st *store.Store) (*CallGrap
 
 	result := &CallGraphResult{}
 
+	// Incremental runs replace only the calls made from scoped packages
+	for pkgPath := range b.loader.scope {
+		if err := batch.DeleteCallsFrom(ctx, pkgPath); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get all functions in the program
 	allFuncs := ssautil.AllFunctions(b.prog)
 
@@ -295,30 +334,62 @@ func (b *CallGraphBuilder) ExtractCallEdgesWithStore(st *store.Store) (*CallGrap
 		if fn.Pkg == nil {
 			continue
 		}
-		if !b.projectPkgs[fn.Pkg.Pkg.Path()] {
+		if !b.projectPkgs[fn.Pkg.Pkg.Path()] || !b.loader.inScope(fn.Pkg.Pkg.Path()) {
 			continue
 		}
 		projectFuncs = append(projectFuncs, fn)
 	}
 
-	fmt.Printf("Processing %d project functions...\n", len(projectFuncs))
-
 	// Process each function
 	for i, fn := range projectFuncs {
 		if b.onProgress != nil && i%100 == 0 {
 			b.onProgress(i, len(projectFuncs))
 		}
 
-		callerID, err := b.lookupSymbolID(batch, fn)
+		for _, w := range b.globalWrites(ctx, batch, fn) {
+			if err := batch.InsertGlobalWrite(ctx, w); err != nil {
+				return nil, fmt.Errorf("inserting global write: %w", err)
+			}
+			result.GlobalWrites++
+		}
+		for _, site := range b.errorSites(ctx, batch, fn) {
+			if err := batch.InsertErrorSite(ctx, site); err != nil {
+				return nil, fmt.Errorf("inserting error site: %w", err)
+			}
+			result.ErrorSites++
+		}
+		for _, use := range b.flagUses(ctx, batch, fn) {
+			if err := batch.InsertFlagUse(ctx, use); err != nil {
+				return nil, fmt.Errorf("inserting flag use: %w", err)
+			}
+			result.FlagUses++
+		}
+
+		callerID, err := b.lookupSymbolID(ctx, batch, fn)
 		if err != nil || callerID == 0 {
+			if skipped := b.skippedFunction(fn); skipped != nil {
+				if err := batch.InsertSkippedFunction(ctx, skipped); err != nil {
+					return nil, fmt.Errorf("inserting skipped function: %w", err)
+				}
+				result.SkippedFunctions++
+			}
 			continue
 		}
 
+		exprs := callExprs(fn)
 		for _, block := range fn.Blocks {
 			for _, instr := range block.Instrs {
-				edge, kind := b.extractCallEdge(batch, fn, instr, callerID)
+				if ext := b.externalCall(instr, callerID); ext != nil {
+					if err := batch.InsertExternalCall(ctx, ext); err != nil {
+						return nil, fmt.Errorf("inserting external call: %w", err)
+					}
+					result.ExternalCalls++
+				}
+
+				edge, kind := b.extractCallEdge(ctx, batch, fn, instr, callerID)
 				if edge != nil {
-					if err := batch.InsertCallEdge(edge); err != nil {
+					edge.Expr = exprs[instr.Pos()]
+					if err := batch.InsertCallEdge(ctx, edge); err != nil {
 						return nil, fmt.Errorf("inserting call edge: %w", err)
 					}
 					result.EdgeCount++
@@ -344,6 +415,15 @@ func (b *CallGraphBuilder) ExtractCallEdgesWithStore(st *store.Store) (*CallGrap
 		b.onProgress(len(projectFuncs), len(projectFuncs))
 	}
 
+	for pkgPath, reasons := range b.unresolved {
+		for reason, n := range reasons {
+			if err := batch.AddUnresolvedCalls(ctx, pkgPath, reason, n); err != nil {
+				return nil, fmt.Errorf("recording unresolved calls: %w", err)
+			}
+			result.UnresolvedCalls += n
+		}
+	}
+
 	if err := batch.Commit(); err != nil {
 		return nil, fmt.Errorf("committing batch: %w", err)
 	}
@@ -351,8 +431,48 @@ func (b *CallGraphBuilder) ExtractCallEdgesWithStore(st *store.Store) (*CallGrap
 	return result, nil
 }
 
+// skippedFunction describes a project function that has no symbol, so its
+// calls cannot be attributed, or returns nil if it makes no calls or is not
+// worth reporting (synthetic wrappers, excluded files).
+func (b *CallGraphBuilder) skippedFunction(fn *ssa.Function) *store.SkippedFunction {
+	if fn.Synthetic != "" {
+		return nil
+	}
+	pos := b.loader.fset.Position(fn.Pos())
+	if !pos.IsValid() || b.loader.shouldExcludeFile(pos.Filename) {
+		return nil
+	}
+	calls := 0
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			if _, ok := instr.(ssa.CallInstruction); ok {
+				calls++
+			}
+		}
+	}
+	if calls == 0 {
+		return nil
+	}
+	return &store.SkippedFunction{
+		PkgPath: fn.Pkg.Pkg.Path(),
+		Name:    fn.RelString(fn.Pkg.Pkg),
+		File:    pos.Filename,
+		Line:    pos.Line,
+		Calls:   calls,
+	}
+}
+
+// noteUnresolved counts a call site in caller that produced no edge.
+func (b *CallGraphBuilder) noteUnresolved(caller *ssa.Function, reason string) {
+	pkgPath := caller.Pkg.Pkg.Path()
+	if b.unresolved[pkgPath] == nil {
+		b.unresolved[pkgPath] = make(map[string]int)
+	}
+	b.unresolved[pkgPath][reason]++
+}
+
 // lookupSymbolID looks up a symbol ID from the database.
-func (b *CallGraphBuilder) lookupSymbolID(batch *store.BatchTx, fn *ssa.Function) (store.SymbolID, error) {
+func (b *CallGraphBuilder) lookupSymbolID(ctx context.Context, batch *store.BatchTx, fn *ssa.Function) (store.SymbolID, error) {
 	if fn == nil || fn.Pkg == nil {
 		return 0, nil
 	}
@@ -367,9 +487,9 @@ func (b *CallGraphBuilder) lookupSymbolID(batch *store.BatchTx, fn *ssa.Function
 	name := fn.Name()
 	recvType := ""
 
-	// Check if this is a method
-	if fn.Signature.Recv() != nil {
-		recvType = formatSSAReceiverType(fn.Signature.Recv().Type())
+	// Check if this is a method; closures inside a method carry its receiver
+	if recv := outermost(fn).Signature.Recv(); recv != nil {
+		recvType = formatSSAReceiverType(recv.Type())
 	}
 
 	// Check cache first
@@ -379,7 +499,7 @@ func (b *CallGraphBuilder) lookupSymbolID(batch *store.BatchTx, fn *ssa.Function
 	}
 
 	// Look up in database
-	id, err := batch.GetSymbolID(pkgPath, name, recvType)
+	id, err := batch.GetSymbolID(ctx, pkgPath, name, recvType)
 	if err != nil {
 		return 0, nil // Symbol not found - might be synthetic
 	}
@@ -390,7 +510,7 @@ func (b *CallGraphBuilder) lookupSymbolID(batch *store.BatchTx, fn *ssa.Function
 }
 
 // extractCallEdge extracts a call edge from an instruction.
-func (b *CallGraphBuilder) extractCallEdge(batch *store.BatchTx, caller *ssa.Function, instr ssa.Instruction, callerID store.SymbolID) (*store.CallEdge, store.CallKind) {
+func (b *CallGraphBuilder) extractCallEdge(ctx context.Context, batch *store.BatchTx, caller *ssa.Function, instr ssa.Instruction, callerID store.SymbolID) (*store.CallEdge, store.CallKind) {
 	var common *ssa.CallCommon
 	var baseKind store.CallKind
 
@@ -421,28 +541,49 @@ func (b *CallGraphBuilder) extractCallEdge(batch *store.BatchTx, caller *ssa.Fun
 	// Determine callee
 	var calleeID store.SymbolID
 	var callKind store.CallKind
+	var resolvedBy store.ResolvedBy
 
 	if callee := common.StaticCallee(); callee != nil {
-		// Static call
+		// Static call, possibly through a synthetic wrapper
 		var err error
-		calleeID, err = b.lookupSymbolID(batch, callee)
+		calleeID, err = b.lookupSymbolID(ctx, batch, callee)
+		resolvedBy = store.ResolvedSSAStatic
+		if id, heuristic, ok := b.wrapperCallee(ctx, batch, caller, callee); ok {
+			calleeID = id
+			if heuristic {
+				resolvedBy = store.ResolvedInterfaceHeuristic
+			}
+		}
 		if err != nil || calleeID == 0 {
+			// Calls leaving the project are expected to have no edge
+			if callee.Pkg != nil && b.projectPkgs[callee.Pkg.Pkg.Path()] && callee.Synthetic == "" {
+				b.noteUnresolved(caller, store.UnresolvedMissingSymbol)
+			}
 			return nil, ""
 		}
 		callKind = baseKind
 	} else if common.IsInvoke() {
 		// Interface method call
 		callKind = store.CallKindInterface
+		resolvedBy = store.ResolvedInterfaceHeuristic
 		// For interface calls, try to find the method in known types
-		calleeID = b.resolveInterfaceMethod(batch, common)
+		calleeID = b.resolveInterfaceMethod(ctx, batch, common)
 		if calleeID == 0 {
+			// Only project interfaces are expected to have project implementations
+			if pkg := common.Method.Pkg(); pkg != nil && b.projectPkgs[pkg.Path()] {
+				b.noteUnresolved(caller, store.UnresolvedInterface)
+			}
 			return nil, "" // Can't resolve - skip for now
 		}
 	} else {
 		// Function value - try to trace it
 		callKind = store.CallKindFuncval
-		calleeID = b.traceFuncValue(batch, common)
+		resolvedBy = store.ResolvedClosureTrace
+		calleeID = b.traceFuncValue(ctx, batch, caller, common)
 		if calleeID == 0 {
+			if _, builtin := common.Value.(*ssa.Builtin); !builtin {
+				b.noteUnresolved(caller, store.UnresolvedFuncval)
+			}
 			return nil, "" // Can't resolve - skip
 		}
 	}
@@ -453,22 +594,130 @@ func (b *CallGraphBuilder) extractCallEdge(batch *store.BatchTx, caller *ssa.Fun
 		CallerFile: pos.Filename,
 		CallerLine: pos.Line,
 		CallKind:   callKind,
+		ResolvedBy: resolvedBy,
 		Count:      1,
 	}, callKind
 }
 
+// maxExprLen caps the length of a stored call expression, in bytes.
+const maxExprLen = 200
+
+// callExprs maps the position SSA reports for each call in fn's source (the
+// opening parenthesis, or the go or defer keyword) to the text of the call
+// expression. Synthetic functions, package initializers included, have no
+// source and get an empty map.
+func callExprs(fn *ssa.Function) map[token.Pos]string {
+	exprs := make(map[token.Pos]string)
+	syntax := fn.Syntax()
+	if syntax == nil {
+		return exprs
+	}
+	ast.Inspect(syntax, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			exprs[n.Lparen] = exprText(n)
+		case *ast.GoStmt:
+			exprs[n.Go] = exprText(n.Call)
+		case *ast.DeferStmt:
+			exprs[n.Defer] = exprText(n.Call)
+		}
+		return true
+	})
+	return exprs
+}
+
+// exprText renders a call expression on one line, truncated to maxExprLen.
+// Function literals among the arguments are abbreviated.
+func exprText(call *ast.CallExpr) string {
+	text := types.ExprString(call)
+	if len(text) <= maxExprLen {
+		return text
+	}
+	cut := maxExprLen
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
+
+// externalCall returns the call into a third-party module made by instr, or
+// nil if instr is not such a call or external calls are not being recorded.
+// Standard library packages have no module and are never recorded.
+func (b *CallGraphBuilder) externalCall(instr ssa.Instruction, callerID store.SymbolID) *store.ExternalCall {
+	if b.modules == nil {
+		return nil
+	}
+	call, ok := instr.(ssa.CallInstruction)
+	if !ok {
+		return nil
+	}
+	common := call.Common()
+
+	kind := store.CallKindStatic
+	switch instr.(type) {
+	case *ssa.Go:
+		kind = store.CallKindGo
+	case *ssa.Defer:
+		kind = store.CallKindDefer
+	}
+
+	var obj *types.Func
+	recvType := ""
+	if common.IsInvoke() {
+		obj = common.Method
+		kind = store.CallKindInterface
+	} else if callee := common.StaticCallee(); callee != nil {
+		if origin := callee.Origin(); origin != nil {
+			callee = origin
+		}
+		obj, _ = callee.Object().(*types.Func)
+		if callee.Signature.Recv() != nil {
+			recvType = formatSSAReceiverType(callee.Signature.Recv().Type())
+		}
+	}
+	if obj == nil || obj.Pkg() == nil {
+		return nil
+	}
+	mod := b.modules[obj.Pkg().Path()]
+	if mod == nil {
+		return nil
+	}
+
+	pos := b.loader.fset.Position(instr.Pos())
+	if !pos.IsValid() {
+		return nil
+	}
+	version := mod.Version
+	if mod.Replace != nil && mod.Replace.Version != "" {
+		version = mod.Replace.Version
+	}
+	return &store.ExternalCall{
+		CallerID:   callerID,
+		Module:     mod.Path,
+		Version:    version,
+		PkgPath:    obj.Pkg().Path(),
+		Func:       qualifiedFuncName(obj),
+		Name:       obj.Name(),
+		RecvType:   recvType,
+		CallKind:   kind,
+		CallerFile: pos.Filename,
+		CallerLine: pos.Line,
+	}
+}
+
 // resolveInterfaceMethod tries to resolve an interface method call.
 // It looks for concrete implementations of the interface method in project packages.
-func (b *CallGraphBuilder) resolveInterfaceMethod(batch *store.BatchTx, common *ssa.CallCommon) store.SymbolID {
+func (b *CallGraphBuilder) resolveInterfaceMethod(ctx context.Context, batch *store.BatchTx, common *ssa.CallCommon) store.SymbolID {
 	if common.Method == nil {
 		return 0
 	}
 
-	methodName := common.Method.Name()
-
-	// Get the interface type
-	recvType := common.Value.Type()
+	return b.resolveMethodByName(ctx, batch, common.Method.Name(), common.Value.Type())
+}
 
+// resolveMethodByName picks the project implementation of an interface
+// method, given the method name and the interface type.
+func (b *CallGraphBuilder) resolveMethodByName(ctx context.Context, batch *store.BatchTx, methodName string, recvType types.Type) store.SymbolID {
 	// Try to find the interface type name
 	var interfaceTypeName string
 	if named, ok := recvType.(*types.Named); ok {
@@ -485,7 +734,7 @@ func (b *CallGraphBuilder) resolveInterfaceMethod(batch *store.BatchTx, common *
 
 	// First, search by method name in project packages
 	// This is a heuristic - we look for methods with the same name
-	candidates := b.findMethodImplementations(batch, methodName, interfaceTypeName)
+	candidates := b.findMethodImplementations(ctx, batch, methodName, interfaceTypeName)
 	if len(candidates) == 1 {
 		return candidates[0]
 	}
@@ -504,7 +753,7 @@ func (b *CallGraphBuilder) resolveInterfaceMethod(batch *store.BatchTx, common *
 }
 
 // findMethodImplementations finds symbols with the given method name.
-func (b *CallGraphBuilder) findMethodImplementations(batch *store.BatchTx, methodName string, interfaceTypeName string) []store.SymbolID {
+func (b *CallGraphBuilder) findMethodImplementations(ctx context.Context, batch *store.BatchTx, methodName string, interfaceTypeName string) []store.SymbolID {
 	var results []store.SymbolID
 	var mockResults []store.SymbolID // Keep mock results separate, use only as fallback
 
@@ -541,7 +790,7 @@ func (b *CallGraphBuilder) findMethodImplementations(batch *store.BatchTx, metho
 					if m.Name() == methodName {
 						// Found a method with the same name
 						recvType := formatSSAReceiverType(m.Type().(*types.Signature).Recv().Type())
-						id, err := batch.GetSymbolID(pkg.Pkg.Path(), methodName, recvType)
+						id, err := batch.GetSymbolID(ctx, pkg.Pkg.Path(), methodName, recvType)
 						if err == nil && id != 0 {
 							if isMock {
 								mockResults = append(mockResults, id)
@@ -560,7 +809,7 @@ func (b *CallGraphBuilder) findMethodImplementations(batch *store.BatchTx, metho
 					if sel.Obj().Name() == methodName {
 						sig := sel.Type().(*types.Signature)
 						recvType := formatSSAReceiverType(sig.Recv().Type())
-						id, err := batch.GetSymbolID(pkg.Pkg.Path(), methodName, recvType)
+						id, err := batch.GetSymbolID(ctx, pkg.Pkg.Path(), methodName, recvType)
 						if err == nil && id != 0 {
 							// Avoid duplicates
 							targetList := &results
@@ -592,33 +841,156 @@ func (b *CallGraphBuilder) findMethodImplementations(batch *store.BatchTx, metho
 }
 
 // traceFuncValue tries to trace a function value to its definition.
-func (b *CallGraphBuilder) traceFuncValue(batch *store.BatchTx, common *ssa.CallCommon) store.SymbolID {
+func (b *CallGraphBuilder) traceFuncValue(ctx context.Context, batch *store.BatchTx, caller *ssa.Function, common *ssa.CallCommon) store.SymbolID {
 	// Try to trace simple cases like passing a function directly
 	value := common.Value
 	if value == nil {
 		return 0
 	}
 
-	// Check if it's a MakeClosure (anonymous function)
+	// Check if it's a MakeClosure (anonymous function, or a method value
+	// such as s.handleX bound to its receiver)
 	if mc, ok := value.(*ssa.MakeClosure); ok {
-		if fn := mc.Fn.(*ssa.Function); fn != nil {
-			id, _ := b.lookupSymbolID(batch, fn)
-			return id
+		if fn, ok := mc.Fn.(*ssa.Function); ok {
+			return b.funcValueSymbol(ctx, batch, caller, fn)
 		}
 	}
 
-	// Check if it's a direct function reference
+	// Check if it's a direct function reference, or a method expression
+	// such as (*T).Run
 	if fn, ok := value.(*ssa.Function); ok {
-		id, _ := b.lookupSymbolID(batch, fn)
-		return id
+		return b.funcValueSymbol(ctx, batch, caller, fn)
 	}
 
 	return 0
 }
 
+// funcValueSymbol returns the symbol of a function used as a value by
+// caller, resolving synthetic wrappers as wrapperCallee does.
+func (b *CallGraphBuilder) funcValueSymbol(ctx context.Context, batch *store.BatchTx, caller, fn *ssa.Function) store.SymbolID {
+	if id, _, ok := b.wrapperCallee(ctx, batch, caller, fn); ok {
+		return id
+	}
+	id, _ := b.lookupSymbolID(ctx, batch, fn)
+	return id
+}
+
+// wrapperCallee returns the symbol a call from caller to a synthetic
+// wrapper is stored against, or ok false if fn isn't one. Calls are
+// collapsed into the wrapper's target unless wrappers are kept, in which
+// case the wrapper gets a symbol of its own in caller's package (see
+// wrapperSymbol). heuristic reports a target picked by resolveMethodByName.
+func (b *CallGraphBuilder) wrapperCallee(ctx context.Context, batch *store.BatchTx, caller, fn *ssa.Function) (id store.SymbolID, heuristic, ok bool) {
+	id, heuristic, ok = b.wrapperTarget(ctx, batch, fn)
+	if !ok || id == 0 || b.loader.cfg == nil || !b.loader.cfg.Wrappers.Keep {
+		return id, heuristic, ok
+	}
+	if wrapperID := b.wrapperSymbol(ctx, batch, caller, fn, id, heuristic); wrapperID != 0 {
+		return wrapperID, false, true
+	}
+	return id, heuristic, true
+}
+
+// wrapperTarget returns the symbol of the function a synthetic wrapper
+// calls, or ok false if fn isn't one. SSA generates wrappers, which belong
+// to no package and have no symbol, for method values ($bound), method
+// expressions ($thunk), methods promoted from embedded fields, and
+// instantiations of generic functions. A wrapped interface method resolves
+// to the implementation resolveMethodByName picks, reported by heuristic.
+func (b *CallGraphBuilder) wrapperTarget(ctx context.Context, batch *store.BatchTx, fn *ssa.Function) (id store.SymbolID, heuristic, ok bool) {
+	if fn.Synthetic == "" || fn.Pkg != nil {
+		return 0, false, false
+	}
+	if origin := fn.Origin(); origin != nil && origin != fn {
+		id, _ = b.lookupSymbolID(ctx, batch, origin)
+		return id, false, true
+	}
+	method, isFunc := fn.Object().(*types.Func)
+	if !isFunc || method.Type().(*types.Signature).Recv() == nil {
+		return 0, false, false
+	}
+	recv := method.Type().(*types.Signature).Recv().Type()
+	if types.IsInterface(recv) {
+		return b.resolveMethodByName(ctx, batch, method.Name(), recv), true, true
+	}
+	target := b.prog.FuncValue(method)
+	if target == nil {
+		return 0, false, true
+	}
+	if origin := target.Origin(); origin != nil {
+		target = origin
+	}
+	id, _ = b.lookupSymbolID(ctx, batch, target)
+	return id, false, true
+}
+
+// wrapperSymbol returns the symbol kept for a synthetic wrapper called from
+// caller's package, creating it with its call to the target on first use.
+// Keeping wrappers in the package calling them lets incremental runs
+// replace them along with the calls. It returns 0 if the symbol can't be
+// stored.
+func (b *CallGraphBuilder) wrapperSymbol(ctx context.Context, batch *store.BatchTx, caller, fn *ssa.Function, target store.SymbolID, heuristic bool) store.SymbolID {
+	if caller.Pkg == nil {
+		return 0
+	}
+	pkgPath := caller.Pkg.Pkg.Path()
+	recvType := ""
+	if recv := fn.Signature.Recv(); recv != nil {
+		recvType = formatSSAReceiverType(recv.Type())
+	}
+	cacheKey := fmt.Sprintf("%s.%s.%s", pkgPath, fn.Name(), recvType)
+	if id, ok := b.symbolCache[cacheKey]; ok {
+		return id
+	}
+
+	kind := store.SymbolKindFunc
+	if recvType != "" {
+		kind = store.SymbolKindMethod
+	}
+	pos := b.prog.Fset.Position(fn.Pos())
+	if !pos.IsValid() {
+		pos = b.prog.Fset.Position(caller.Pos())
+	}
+	repo := ""
+	if b.loader.cfg != nil {
+		repo = b.loader.cfg.Repo
+	}
+	id, err := batch.InsertSymbol(ctx, &store.Symbol{
+		PkgPath:  pkgPath,
+		Name:     fn.Name(),
+		Kind:     kind,
+		RecvType: recvType,
+		File:     pos.Filename,
+		Line:     pos.Line,
+		Sig:      fn.Signature.String(),
+		Repo:     repo,
+		Doc:      fn.Synthetic,
+	})
+	if err != nil {
+		return 0
+	}
+	resolvedBy := store.ResolvedSSAStatic
+	if heuristic {
+		resolvedBy = store.ResolvedInterfaceHeuristic
+	}
+	if err := batch.InsertCallEdge(ctx, &store.CallEdge{
+		CallerID:   id,
+		CalleeID:   target,
+		CallerFile: pos.Filename,
+		CallerLine: pos.Line,
+		CallKind:   store.CallKindStatic,
+		ResolvedBy: resolvedBy,
+		Count:      1,
+	}); err != nil {
+		return 0
+	}
+	b.symbolCache[cacheKey] = id
+	return id
+}
+
 // BuildAndExtract is a convenience method that builds SSA and extracts call edges.
 // Returns the builder so callers can access the SSA program for further analysis.
-func BuildAndExtract(loader *Loader, st *store.Store, onProgress func(current, total int)) (*CallGraphResult, *CallGraphBuilder, error) {
+func BuildAndExtract(ctx context.Context, loader *Loader, st *store.Store, onProgress func(current, total int)) (*CallGraphResult, *CallGraphBuilder, error) {
 	builder := NewCallGraphBuilder(loader)
 	if onProgress != nil {
 		builder.SetProgressCallback(onProgress)
@@ -628,7 +1000,7 @@ func BuildAndExtract(loader *Loader, st *store.Store, onProgress func(current, t
 		return nil, nil, fmt.Errorf("building SSA: %w", err)
 	}
 
-	result, err := builder.ExtractCallEdgesWithStore(st)
+	result, err := builder.ExtractCallEdgesWithStore(ctx, st)
 	if err != nil {
 		return nil, nil, fmt.Errorf("extracting call edges: %w", err)
 	}
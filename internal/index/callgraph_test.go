@@ -0,0 +1,835 @@
+package index
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestExternalCalls(t *testing.T) {
+	// The project depends on a local module through a replace directive, so
+	// no network or module cache is needed
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module depmod\n\ngo 1.21\n\nrequire example.com/payments v1.2.0\n\nreplace example.com/payments => ./payments\n",
+		"main.go": `package main
+
+import "example.com/payments"
+
+func webhook() {
+	verify()
+}
+
+func verify() {
+	payments.Verify("sig")
+	c := &payments.Client{}
+	c.Charge(100)
+}
+
+func listItems() {}
+
+func main() {}
+`,
+		"payments/go.mod": "module example.com/payments\n\ngo 1.21\n",
+		"payments/payments.go": `package payments
+
+type Client struct{}
+
+func (c *Client) Charge(amount int) error { return nil }
+
+func Verify(sig string) bool { return sig != "" }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Dependencies.Index = true
+	loader := NewLoader(cfg, tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	for _, name := range []string{"webhook", "listItems"} {
+		id, err := st.FindSymbolID(t.Context(), "depmod", name, "")
+		if err != nil {
+			t.Fatalf("finding %s: %v", name, err)
+		}
+		ep := &store.Entrypoint{Type: store.EntrypointHTTP, Label: "POST /" + name, SymbolID: id}
+		if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
+			t.Fatalf("inserting entrypoint: %v", err)
+		}
+	}
+
+	result, _, err := BuildAndExtract(t.Context(), loader, st, nil)
+	if err != nil {
+		t.Fatalf("building call graph: %v", err)
+	}
+	if result.ExternalCalls != 2 {
+		t.Errorf("expected 2 external calls, got %d", result.ExternalCalls)
+	}
+
+	deps, err := st.GetEntrypointDependencies(t.Context())
+	if err != nil {
+		t.Fatalf("getting dependencies: %v", err)
+	}
+	byLabel := make(map[string]store.EntrypointDependencies)
+	for _, d := range deps {
+		byLabel[d.Label] = d
+	}
+
+	// The webhook reaches payments through verify
+	webhook := byLabel["POST /webhook"]
+	if len(webhook.Modules) != 1 {
+		t.Fatalf("expected webhook to reach 1 module, got %+v", webhook.Modules)
+	}
+	m := webhook.Modules[0]
+	if m.Module != "example.com/payments" || m.Version != "v1.2.0" {
+		t.Errorf("unexpected module %+v", m)
+	}
+	if len(m.Funcs) != 2 || m.Funcs[0] != "example.com/payments.Client.Charge" || m.Funcs[1] != "example.com/payments.Verify" {
+		t.Errorf("unexpected funcs %v", m.Funcs)
+	}
+
+	if got := byLabel["POST /listItems"].Modules; len(got) != 0 {
+		t.Errorf("expected listItems to reach no modules, got %+v", got)
+	}
+	if scoped := store.FilterDependencies(deps, "example.com/payments"); len(scoped) != 1 || scoped[0].Label != "POST /webhook" {
+		t.Errorf("expected only the webhook to use payments, got %+v", scoped)
+	}
+}
+
+func TestProjectModules(t *testing.T) {
+	// An internal fork the project imports through a replace directive
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module forkmod\n\ngo 1.21\n\nrequire example.com/kit v1.0.0\n\nreplace example.com/kit => ./kit\n",
+		"main.go": `package main
+
+import "example.com/kit/retry"
+
+func handler() {
+	retry.Do(3)
+}
+
+func main() {
+	handler()
+}
+`,
+		"kit/go.mod": "module example.com/kit\n\ngo 1.21\n",
+		"kit/retry/retry.go": `package retry
+
+func Do(attempts int) {
+	for i := 0; i < attempts; i++ {
+		backoff(i)
+	}
+}
+
+func backoff(i int) {}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	for _, project := range []bool{false, true} {
+		cfg := config.Default()
+		if project {
+			cfg.ProjectModules = []string{"example.com/kit"}
+		}
+		loader := NewLoader(cfg, tmpDir)
+		if err := loader.Load(); err != nil {
+			t.Fatalf("loading packages: %v", err)
+		}
+		st, err := store.Open(filepath.Join(t.TempDir(), "index.db"))
+		if err != nil {
+			t.Fatalf("opening store: %v", err)
+		}
+		defer st.Close()
+		if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+			t.Fatalf("extracting symbols: %v", err)
+		}
+		if _, _, err := BuildAndExtract(t.Context(), loader, st, nil); err != nil {
+			t.Fatalf("building call graph: %v", err)
+		}
+
+		doID, err := st.FindSymbolID(t.Context(), "example.com/kit/retry", "Do", "")
+		if !project {
+			// Dependencies are boundary leaves by default
+			if err == nil {
+				t.Errorf("expected no symbol for a dependency, got %d", doID)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("finding Do: %v", err)
+		}
+
+		// The fork is traversed like project code: handler -> Do -> backoff
+		handlerID, err := st.FindSymbolID(t.Context(), "forkmod", "handler", "")
+		if err != nil {
+			t.Fatalf("finding handler: %v", err)
+		}
+		callees, err := st.GetCallees(t.Context(), handlerID)
+		if err != nil || len(callees) != 1 || callees[0].Symbol.ID != doID {
+			t.Fatalf("expected handler to call Do, got %+v (%v)", callees, err)
+		}
+		callees, err = st.GetCallees(t.Context(), doID)
+		if err != nil || len(callees) != 1 || callees[0].Symbol.Name != "backoff" {
+			t.Errorf("expected Do to call backoff, got %+v (%v)", callees, err)
+		}
+	}
+}
+
+func TestCrossRepoIndexing(t *testing.T) {
+	// A service and its client library, indexed as two repositories into
+	// one database
+	root := t.TempDir()
+	files := map[string]string{
+		"client/go.mod": "module example.com/client\n\ngo 1.21\n",
+		"client/client.go": `package client
+
+type Client struct{}
+
+func (c *Client) Fetch(id string) string { return id }
+
+func New() *Client { return &Client{} }
+`,
+		"svc/go.mod": "module example.com/svc\n\ngo 1.21\n\nrequire example.com/client v0.1.0\n\nreplace example.com/client => ../client\n",
+		"svc/main.go": `package main
+
+import "example.com/client"
+
+func handle() string {
+	return client.New().Fetch("42")
+}
+
+func main() { handle() }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	dbPath := filepath.Join(root, "org.db")
+
+	run := func(dir, repo string) *Result {
+		cfg := config.Default()
+		cfg.Repo = repo
+		cfg.Database = dbPath
+		result, err := NewIndexer(cfg, filepath.Join(root, dir)).Run(t.Context())
+		if err != nil {
+			t.Fatalf("indexing %s: %v", repo, err)
+		}
+		return result
+	}
+	crossEdges := func(st *store.Store) []store.CalleeInfo {
+		handleID, err := st.FindSymbolID(t.Context(), "example.com/svc", "handle", "")
+		if err != nil {
+			t.Fatalf("finding handle: %v", err)
+		}
+		callees, err := st.GetCallees(t.Context(), handleID)
+		if err != nil {
+			t.Fatalf("getting callees: %v", err)
+		}
+		return callees
+	}
+
+	run("client", "client")
+	if result := run("svc", "svc"); result.CrossRepoEdges != 2 {
+		t.Errorf("expected 2 cross-repo edges, got %d", result.CrossRepoEdges)
+	}
+
+	st, err := store.OpenFile(dbPath, root)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+
+	callees := crossEdges(st)
+	names := make(map[string]string)
+	for _, c := range callees {
+		names[c.Symbol.Name] = c.Symbol.Repo
+	}
+	if names["New"] != "client" || names["Fetch"] != "client" {
+		t.Errorf("expected handle to call client.New and Client.Fetch in repo client, got %+v", callees)
+	}
+
+	repos, err := st.GetRepos(t.Context())
+	if err != nil {
+		t.Fatalf("getting repos: %v", err)
+	}
+	if len(repos) != 2 || repos[0].Name != "client" || repos[0].Modules[0] != "example.com/client" ||
+		repos[1].Name != "svc" || repos[1].Dir != filepath.Join(root, "svc") {
+		t.Errorf("unexpected repos: %+v", repos)
+	}
+
+	// Re-indexing the library keeps the service and re-links its calls
+	if result := run("client", "client"); result.CrossRepoEdges != 2 {
+		t.Errorf("expected 2 cross-repo edges after re-indexing client, got %d", result.CrossRepoEdges)
+	}
+	if _, err := st.Reopen(); err != nil {
+		t.Fatalf("reopening store: %v", err)
+	}
+	if got := crossEdges(st); len(got) != 2 {
+		t.Errorf("expected cross-repo edges to survive re-indexing, got %+v", got)
+	}
+
+	// A full re-index without a repo name would wipe the other repository
+	cfg := config.Default()
+	cfg.Database = dbPath
+	if _, err := NewIndexer(cfg, filepath.Join(root, "svc")).Run(t.Context()); err == nil {
+		t.Error("expected indexing a shared database without a repo name to fail")
+	}
+	// The failed run leaves the previous index in place
+	if _, err := os.Stat(dbPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected the failed run's index copy to be removed, got %v", err)
+	}
+	if reopened, err := st.Reopen(); err != nil || reopened {
+		t.Errorf("expected the failed run not to replace the index, got %v, %v", reopened, err)
+	}
+	if got := crossEdges(st); len(got) != 2 {
+		t.Errorf("expected the previous index to survive a failed run, got %+v", got)
+	}
+}
+
+func TestUnresolvedCalls(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package main
+
+type Notifier interface{ Notify() }
+
+func target() {}
+
+func run(fn func()) { fn() }
+
+func notify(n Notifier) { n.Notify() }
+
+func init() { target() }
+
+func main() {
+	run(target)
+	notify(nil)
+	generated()
+	_ = len("builtin calls are not counted")
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module unresmod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "main_gen.go"), []byte("package main\n\nfunc generated() {}\n"), 0644); err != nil {
+		t.Fatalf("writing main_gen.go: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	result, _, err := BuildAndExtract(t.Context(), loader, st, nil)
+	if err != nil {
+		t.Fatalf("building call graph: %v", err)
+	}
+
+	// fn() in run is a parameter; n.Notify() has no implementation; the
+	// generated() is in an excluded file, so it has no symbol
+	pkgs, err := st.GetUnresolvedCalls(t.Context())
+	if err != nil {
+		t.Fatalf("getting unresolved calls: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].PkgPath != "unresmod" {
+		t.Fatalf("expected unresolved calls in unresmod only, got %+v", pkgs)
+	}
+	want := map[string]int{
+		store.UnresolvedFuncval:       1,
+		store.UnresolvedInterface:     1,
+		store.UnresolvedMissingSymbol: 1,
+	}
+	for reason, n := range want {
+		if got := pkgs[0].ByReason[reason]; got != n {
+			t.Errorf("%s: expected %d unresolved calls, got %d", reason, n, got)
+		}
+	}
+	if result.UnresolvedCalls != 3 {
+		t.Errorf("expected 3 unresolved calls in the result, got %d", result.UnresolvedCalls)
+	}
+
+	skipped, err := st.GetSkippedFunctions(t.Context(), "")
+	if err != nil {
+		t.Fatalf("getting skipped functions: %v", err)
+	}
+	if len(skipped) != 1 || skipped[0].Name != "init#1" || skipped[0].Calls != 1 || skipped[0].Line != 11 {
+		t.Errorf("expected the init function to be skipped, got %+v", skipped)
+	}
+
+	stats, err := st.GetStats(t.Context())
+	if err != nil {
+		t.Fatalf("getting stats: %v", err)
+	}
+	if stats.UnresolvedCalls[store.UnresolvedFuncval] != 1 || stats.SkippedFunctions != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestResolvedBy(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package main
+
+type Greeter interface{ Greet() }
+
+type english struct{}
+
+func (english) Greet() {}
+
+func greet(g Greeter) { g.Greet() }
+
+func main() { greet(english{}) }
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module resolvedmod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	if _, _, err := BuildAndExtract(t.Context(), loader, st, nil); err != nil {
+		t.Fatalf("building call graph: %v", err)
+	}
+
+	tests := []struct {
+		caller string
+		want   store.ResolvedBy
+	}{
+		{"main", store.ResolvedSSAStatic},
+		{"greet", store.ResolvedInterfaceHeuristic},
+	}
+	for _, tt := range tests {
+		callerID, err := st.FindSymbolID(t.Context(), "resolvedmod", tt.caller, "")
+		if err != nil {
+			t.Fatalf("finding %s: %v", tt.caller, err)
+		}
+		callees, err := st.GetCallees(t.Context(), callerID)
+		if err != nil {
+			t.Fatalf("getting callees of %s: %v", tt.caller, err)
+		}
+		if len(callees) != 1 {
+			t.Fatalf("%s: expected 1 callee, got %d", tt.caller, len(callees))
+		}
+		if got := callees[0].ResolvedBy; got != tt.want {
+			t.Errorf("%s -> %s: expected resolved_by %q, got %q", tt.caller, callees[0].Symbol.Name, tt.want, got)
+		}
+	}
+}
+
+func TestClosureSymbols(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package main
+
+type server struct{}
+
+func (s *server) start() {
+	go func() {
+		func() { listen() }()
+	}()
+}
+
+var handler = func() { serve() }
+
+func listen() {}
+
+func serve() {}
+
+func main() {
+	(&server{}).start()
+	handler()
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module closuremod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	if _, _, err := BuildAndExtract(t.Context(), loader, st, nil); err != nil {
+		t.Fatalf("building call graph: %v", err)
+	}
+
+	// Calls inside a closure belong to the closure, named as SSA names it
+	tests := []struct {
+		caller, recvType, callee string
+	}{
+		{"start", "*server", "start$1"},
+		{"start$1", "*server", "start$1$1"},
+		{"start$1$1", "*server", "listen"},
+		{"init$1", "", "serve"},
+	}
+	for _, tt := range tests {
+		callerID, err := st.FindSymbolID(t.Context(), "closuremod", tt.caller, tt.recvType)
+		if err != nil {
+			t.Fatalf("finding %s: %v", tt.caller, err)
+		}
+		callees, err := st.GetCallees(t.Context(), callerID)
+		if err != nil {
+			t.Fatalf("getting callees of %s: %v", tt.caller, err)
+		}
+		if len(callees) != 1 || callees[0].Symbol.Name != tt.callee {
+			t.Errorf("%s: expected single callee %s, got %+v", tt.caller, tt.callee, callees)
+		}
+	}
+}
+
+func TestMethodValueCalls(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package main
+
+type worker struct{}
+
+func (w *worker) Run() {}
+
+type Job interface{ Do() }
+
+type job struct{}
+
+func (job) Do() {}
+
+type server struct {
+	worker *worker
+	job    Job
+}
+
+func (s *server) handleX() {}
+
+func (s *server) start() {
+	run := s.worker.Run
+	go run()
+}
+
+func (s *server) serve() {
+	h := s.handleX
+	h()
+}
+
+func (s *server) work() {
+	do := s.job.Do
+	defer do()
+}
+
+func expr() {
+	f := (*worker).Run
+	f(&worker{})
+}
+
+func main() {
+	s := &server{worker: &worker{}, job: job{}}
+	s.start()
+	s.serve()
+	s.work()
+	expr()
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module methodvalmod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	if _, _, err := BuildAndExtract(t.Context(), loader, st, nil); err != nil {
+		t.Fatalf("building call graph: %v", err)
+	}
+
+	// Method values and expressions are called through SSA wrappers that
+	// resolve to the method they wrap
+	tests := []struct {
+		caller, recvType, callee string
+		kind                     store.CallKind
+		resolvedBy               store.ResolvedBy
+	}{
+		{"start", "*server", "Run", store.CallKindGo, store.ResolvedSSAStatic},
+		{"serve", "*server", "handleX", store.CallKindStatic, store.ResolvedSSAStatic},
+		{"work", "*server", "Do", store.CallKindDefer, store.ResolvedInterfaceHeuristic},
+		{"expr", "", "Run", store.CallKindStatic, store.ResolvedSSAStatic},
+	}
+	for _, tt := range tests {
+		callerID, err := st.FindSymbolID(t.Context(), "methodvalmod", tt.caller, tt.recvType)
+		if err != nil {
+			t.Fatalf("finding %s: %v", tt.caller, err)
+		}
+		callees, err := st.GetCallees(t.Context(), callerID)
+		if err != nil {
+			t.Fatalf("getting callees of %s: %v", tt.caller, err)
+		}
+		if len(callees) != 1 || callees[0].Symbol.Name != tt.callee {
+			t.Fatalf("%s: expected single callee %s, got %+v", tt.caller, tt.callee, callees)
+		}
+		if c := callees[0]; c.CallKind != tt.kind || c.ResolvedBy != tt.resolvedBy {
+			t.Errorf("%s -> %s: expected %s call resolved by %s, got %s by %s", tt.caller, tt.callee, tt.kind, tt.resolvedBy, c.CallKind, c.ResolvedBy)
+		}
+	}
+}
+
+func TestWrapperCalls(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package main
+
+type worker struct{}
+
+func (w *worker) Run() {}
+
+type outer struct{ *worker }
+
+type List[T any] struct{ items []T }
+
+func (l *List[T]) Push(v T) { l.items = append(l.items, v) }
+
+func Map[T, U any](in []T, f func(T) U) []U {
+	out := make([]U, 0, len(in))
+	for _, v := range in {
+		out = append(out, f(v))
+	}
+	return out
+}
+
+func double(n int) int { return n * 2 }
+
+func generic() {
+	Map([]int{1}, double)
+}
+
+func method() {
+	l := &List[int]{}
+	l.Push(1)
+}
+
+func promoted() {
+	f := (*outer).Run
+	f(&outer{worker: &worker{}})
+}
+
+func main() {
+	generic()
+	method()
+	promoted()
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module wrappermod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	for _, keep := range []bool{false, true} {
+		cfg := config.Default()
+		cfg.Wrappers.Keep = keep
+		loader := NewLoader(cfg, tmpDir)
+		if err := loader.Load(); err != nil {
+			t.Fatalf("loading packages: %v", err)
+		}
+		st, err := store.Open(filepath.Join(t.TempDir(), "index.db"))
+		if err != nil {
+			t.Fatalf("opening store: %v", err)
+		}
+		defer st.Close()
+		if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+			t.Fatalf("extracting symbols: %v", err)
+		}
+		if _, _, err := BuildAndExtract(t.Context(), loader, st, nil); err != nil {
+			t.Fatalf("building call graph: %v", err)
+		}
+		// Calls through wrappers are collapsed into the wrapped function,
+		// or, when wrappers are kept, go through a symbol for the wrapper
+		// that calls it
+		tests := []struct {
+			caller, wrapper, recvType, callee string
+		}{
+			{"generic", "Map[int int]", "", "Map"},
+			{"method", "Push[int]", "*List[...]", "Push"},
+			{"promoted", "Run$thunk", "*worker", "Run"},
+		}
+		for _, tt := range tests {
+			callerID, err := st.FindSymbolID(t.Context(), "wrappermod", tt.caller, "")
+			if err != nil {
+				t.Fatalf("finding %s: %v", tt.caller, err)
+			}
+			callees, err := st.GetCallees(t.Context(), callerID)
+			if err != nil {
+				t.Fatalf("getting callees of %s: %v", tt.caller, err)
+			}
+			if len(callees) != 1 {
+				t.Fatalf("keep=%v: expected single callee of %s, got %+v", keep, tt.caller, callees)
+			}
+			callee := callees[0].Symbol
+			if keep {
+				if callee.Name != tt.wrapper {
+					t.Fatalf("keep=%v: expected %s to call wrapper %s, got %s", keep, tt.caller, tt.wrapper, callee.Name)
+				}
+				if sym, err := st.GetSymbolByID(t.Context(), callee.ID); err != nil || sym.Doc == "" {
+					t.Errorf("keep=%v: expected wrapper %s to describe itself, got %+v (%v)", keep, tt.wrapper, sym, err)
+				}
+				if callees, err = st.GetCallees(t.Context(), callee.ID); err != nil || len(callees) != 1 {
+					t.Fatalf("keep=%v: expected wrapper %s to have a single callee, got %+v (%v)", keep, tt.wrapper, callees, err)
+				}
+				callee = callees[0].Symbol
+			}
+			if callee.Name != tt.callee || callee.RecvType != tt.recvType {
+				t.Errorf("keep=%v: expected %s to reach %s %s, got %s %s", keep, tt.caller, tt.recvType, tt.callee, callee.RecvType, callee.Name)
+			}
+		}
+	}
+}
+
+func TestCallExprs(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package main
+
+type users struct{}
+
+func (users) Create(id, name string) error { return nil }
+
+type service struct{ Users users }
+
+func cleanup() {}
+
+func notify(fn func()) {}
+
+func handle(svc *service, id, name string) {
+	defer cleanup()
+	go notify(func() {})
+	svc.Users.Create(id, name)
+}
+
+func main() { handle(&service{}, "1", "a") }
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module exprmod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	if _, _, err := BuildAndExtract(t.Context(), loader, st, nil); err != nil {
+		t.Fatalf("building call graph: %v", err)
+	}
+
+	handleID, err := st.FindSymbolID(t.Context(), "exprmod", "handle", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	callees, err := st.GetCallees(t.Context(), handleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"cleanup": "cleanup()",
+		"notify":  "notify((func() literal))",
+		"Create":  "svc.Users.Create(id, name)",
+	}
+	if len(callees) != len(want) {
+		t.Fatalf("expected %d callees, got %+v", len(want), callees)
+	}
+	for _, c := range callees {
+		if c.Expr != want[c.Symbol.Name] {
+			t.Errorf("%s: expected expr %q, got %q", c.Symbol.Name, want[c.Symbol.Name], c.Expr)
+		}
+	}
+}
+
+func TestExprText(t *testing.T) {
+	long := &ast.CallExpr{Fun: ast.NewIdent(strings.Repeat("é", maxExprLen))}
+	got := exprText(long)
+	if len(got) > maxExprLen+len("…") || !strings.HasSuffix(got, "…") || !utf8.ValidString(got) {
+		t.Errorf("expected a valid truncated expression, got %d bytes", len(got))
+	}
+}
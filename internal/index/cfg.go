@@ -1,23 +1,24 @@
 package index
 
 import (
+	"context"
 	"fmt"
+	"go/token"
 	"go/types"
 	"strings"
 
 	"golang.org/x/tools/go/ssa"
-	"golang.org/x/tools/go/ssa/ssautil"
 
-	"github.com/abramin/flowlens/internal/config"
 	"github.com/abramin/flowlens/internal/store"
 )
 
 // InstructionInfo represents a single SSA instruction in a basic block.
 type InstructionInfo struct {
 	Index    int    `json:"index"`
-	Op       string `json:"op"`        // e.g., "call", "if", "return", "store", etc.
-	Text     string `json:"text"`      // Human-readable representation
-	CalleeID *int64 `json:"callee_id"` // If this is a call, the callee symbol ID
+	Op       string `json:"op"`             // e.g., "call", "if", "return", "store", etc.
+	Text     string `json:"text"`           // Human-readable representation
+	CalleeID *int64 `json:"callee_id"`      // If this is a call, the callee symbol ID
+	Line     int    `json:"line,omitempty"` // Source line, when the instruction has a position
 }
 
 // BasicBlockInfo represents a basic block in the CFG.
@@ -29,6 +30,12 @@ type BasicBlockInfo struct {
 	IsEntry      bool              `json:"is_entry"`
 	IsExit       bool              `json:"is_exit"`
 	BranchCond   string            `json:"branch_cond,omitempty"` // "if err != nil", "return", etc.
+	File         string            `json:"file,omitempty"`        // Source file of the block's code
+	StartLine    int               `json:"start_line,omitempty"`  // First source line covered by the block
+	EndLine      int               `json:"end_line,omitempty"`    // Last source line covered by the block
+	Idom         *int              `json:"idom,omitempty"`        // Immediate dominator (nil for the entry block)
+	IsLoopHeader bool              `json:"is_loop_header,omitempty"`
+	LoopDepth    int               `json:"loop_depth,omitempty"` // Number of loops containing the block
 }
 
 // CFGInfo represents the control flow graph for a function.
@@ -39,11 +46,17 @@ type CFGInfo struct {
 	Blocks     []BasicBlockInfo `json:"blocks"`
 	EntryBlock int              `json:"entry_block"`
 	ExitBlocks []int            `json:"exit_blocks"`
+
+	DominatorEdges []CFGEdge  `json:"dominator_edges,omitempty"` // Immediate dominator -> block
+	BackEdges      []CFGEdge  `json:"back_edges,omitempty"`
+	Loops          []LoopInfo `json:"loops,omitempty"`
 }
 
 // CFGBuilder builds control flow graphs from SSA.
 type CFGBuilder struct {
-	st *store.Store
+	st         *store.Store
+	ssaCache   *SSACache // Shared SSA programs; nil rebuilds per call
+	projectDir string    // Directory the unnamed repository's program is built from
 }
 
 // NewCFGBuilder creates a new CFG builder.
@@ -53,46 +66,69 @@ func NewCFGBuilder(st *store.Store) *CFGBuilder {
 	}
 }
 
+// UseSSACache makes the builder reuse shared SSA programs instead of loading
+// packages on every call: one for projectDir, and one for each other
+// repository sharing the index, built from its recorded root. An empty
+// projectDir falls back to the project_dir recorded in the index.
+func (cb *CFGBuilder) UseSSACache(cache *SSACache, projectDir string) {
+	cb.ssaCache = cache
+	cb.projectDir = projectDir
+}
+
 // BuildCFG constructs the CFG for a given symbol.
-// This rebuilds SSA on-demand, which may take 1-2 seconds on first call.
-func (cb *CFGBuilder) BuildCFG(symbolID store.SymbolID) (*CFGInfo, error) {
+// Without an SSA cache this rebuilds SSA on-demand, which may take 1-2 seconds.
+func (cb *CFGBuilder) BuildCFG(ctx context.Context, symbolID store.SymbolID) (*CFGInfo, error) {
 	// Get symbol info
-	sym, err := cb.st.GetSymbolByID(symbolID)
+	sym, err := cb.st.GetSymbolByID(ctx, symbolID)
 	if err != nil {
 		return nil, fmt.Errorf("symbol not found: %w", err)
 	}
 
 	// Get package path
-	pkg, err := cb.st.GetPackageByPath(sym.PkgPath)
+	pkg, err := cb.st.GetPackageByPath(ctx, sym.PkgPath)
 	if err != nil {
 		return nil, fmt.Errorf("package not found: %w", err)
 	}
 
-	// Load default config
-	cfg := config.Default()
-
-	// Create loader and load packages
-	loader := NewLoader(cfg, pkg.Dir)
-	if err := loader.Load(); err != nil {
-		return nil, fmt.Errorf("failed to load package: %w", err)
+	prog, err := cb.program(ctx, pkg)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build SSA program
-	prog, _ := ssautil.AllPackages(loader.pkgs, ssa.SanityCheckFunctions)
-	prog.Build()
-
 	// Find the SSA function
-	ssaFunc := cb.findSSAFunction(prog, sym)
+	ssaFunc := findSSAFunction(prog, sym)
 	if ssaFunc == nil {
 		return nil, fmt.Errorf("SSA function not found for %s", sym.Name)
 	}
 
 	// Build the CFG
-	return cb.buildCFGFromSSA(symbolID, ssaFunc)
+	return cb.buildCFGFromSSA(ctx, symbolID, ssaFunc)
+}
+
+// program returns the SSA program containing pkg, from the shared cache
+// when one is configured. Cached programs cover the whole repository pkg
+// belongs to.
+func (cb *CFGBuilder) program(ctx context.Context, pkg *store.Package) (*ssa.Program, error) {
+	if cb.ssaCache == nil {
+		return buildProgram(pkg.Dir)
+	}
+
+	var dir string
+	if pkg.Repo == "" {
+		dir = cb.projectDir
+	}
+	if dir == "" {
+		dir = cb.st.RepoRoot(ctx, pkg.Repo)
+	}
+	if dir == "" {
+		dir = pkg.Dir
+	}
+	generation, _ := cb.st.GetMetadata(ctx, "indexed_at")
+	return cb.ssaCache.Program(dir, generation)
 }
 
 // findSSAFunction locates the SSA function for a symbol.
-func (cb *CFGBuilder) findSSAFunction(prog *ssa.Program, sym *store.Symbol) *ssa.Function {
+func findSSAFunction(prog *ssa.Program, sym *store.Symbol) *ssa.Function {
 	for _, pkg := range prog.AllPackages() {
 		if pkg.Pkg.Path() != sym.PkgPath {
 			continue
@@ -165,7 +201,7 @@ func matchesRecvType(fn *ssa.Function, recvType string) bool {
 }
 
 // buildCFGFromSSA constructs CFGInfo from an SSA function.
-func (cb *CFGBuilder) buildCFGFromSSA(symbolID store.SymbolID, fn *ssa.Function) (*CFGInfo, error) {
+func (cb *CFGBuilder) buildCFGFromSSA(ctx context.Context, symbolID store.SymbolID, fn *ssa.Function) (*CFGInfo, error) {
 	if len(fn.Blocks) == 0 {
 		return nil, fmt.Errorf("function has no basic blocks (may be external)")
 	}
@@ -202,7 +238,11 @@ func (cb *CFGBuilder) buildCFGFromSSA(symbolID store.SymbolID, fn *ssa.Function)
 
 		// Process instructions
 		for i, instr := range block.Instrs {
-			instrInfo := cb.processInstruction(instr, i)
+			instrInfo := cb.processInstruction(ctx, instr, i)
+			if pos := instrPosition(fn.Prog.Fset, instr); pos.IsValid() {
+				instrInfo.Line = pos.Line
+				blockInfo.extendRange(pos)
+			}
 			blockInfo.Instructions = append(blockInfo.Instructions, instrInfo)
 
 			// Extract branch condition from last instruction
@@ -214,11 +254,43 @@ func (cb *CFGBuilder) buildCFGFromSSA(symbolID store.SymbolID, fn *ssa.Function)
 		cfg.Blocks = append(cfg.Blocks, blockInfo)
 	}
 
+	analyzeLoops(fn, cfg)
+
 	return cfg, nil
 }
 
+// instrPosition returns the source position of an instruction. Branches
+// carry no position of their own, so an If falls back to its condition.
+func instrPosition(fset *token.FileSet, instr ssa.Instruction) token.Position {
+	pos := instr.Pos()
+	if !pos.IsValid() {
+		if ifInstr, ok := instr.(*ssa.If); ok {
+			pos = ifInstr.Cond.Pos()
+		}
+	}
+	if !pos.IsValid() {
+		return token.Position{}
+	}
+	return fset.Position(pos)
+}
+
+// extendRange widens the block's source range to include pos. Positions in
+// another file (e.g. inlined closures) are ignored.
+func (b *BasicBlockInfo) extendRange(pos token.Position) {
+	if b.File == "" {
+		b.File = pos.Filename
+		b.StartLine, b.EndLine = pos.Line, pos.Line
+		return
+	}
+	if pos.Filename != b.File {
+		return
+	}
+	b.StartLine = min(b.StartLine, pos.Line)
+	b.EndLine = max(b.EndLine, pos.Line)
+}
+
 // processInstruction converts an SSA instruction to InstructionInfo.
-func (cb *CFGBuilder) processInstruction(instr ssa.Instruction, index int) InstructionInfo {
+func (cb *CFGBuilder) processInstruction(ctx context.Context, instr ssa.Instruction, index int) InstructionInfo {
 	info := InstructionInfo{
 		Index: index,
 		Op:    instrOpName(instr),
@@ -232,7 +304,7 @@ func (cb *CFGBuilder) processInstruction(instr ssa.Instruction, index int) Instr
 		if callee := v.Call.StaticCallee(); callee != nil {
 			info.Text = formatCall(v)
 			// Try to resolve callee ID
-			if id := cb.resolveCalleeID(callee); id != nil {
+			if id := cb.resolveCalleeID(ctx, callee); id != nil {
 				info.CalleeID = id
 			}
 		} else {
@@ -474,7 +546,7 @@ func (cb *CFGBuilder) formatValue(v ssa.Value) string {
 }
 
 // resolveCalleeID tries to find the store symbol ID for an SSA function.
-func (cb *CFGBuilder) resolveCalleeID(callee *ssa.Function) *int64 {
+func (cb *CFGBuilder) resolveCalleeID(ctx context.Context, callee *ssa.Function) *int64 {
 	if callee == nil || callee.Pkg == nil {
 		return nil
 	}
@@ -494,7 +566,7 @@ func (cb *CFGBuilder) resolveCalleeID(callee *ssa.Function) *int64 {
 	}
 
 	// Try to find symbol in store
-	id, err := cb.st.FindSymbolID(pkgPath, name, recvType)
+	id, err := cb.st.FindSymbolID(ctx, pkgPath, name, recvType)
 	if err != nil {
 		return nil
 	}
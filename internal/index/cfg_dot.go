@@ -0,0 +1,118 @@
+package index
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// maxDOTInstructions caps the instructions listed in a block's DOT label so
+// large blocks stay readable; the remainder is summarized.
+const maxDOTInstructions = 8
+
+// DOT renders the CFG as a Graphviz digraph. Conditional edges are labelled
+// with the branch condition ("true: err != nil" / "false"), loop back edges
+// are dashed, and the entry and exit blocks are highlighted.
+func (c *CFGInfo) DOT() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(c.Name))
+	fmt.Fprintf(&b, "    label=%s;\n", dotQuote(c.Name+c.signatureSuffix()))
+	b.WriteString("    labelloc=t;\n")
+	b.WriteString("    node [shape=box, fontname=\"monospace\", fontsize=10];\n")
+	b.WriteString("    edge [fontname=\"monospace\", fontsize=9];\n\n")
+
+	for _, block := range c.Blocks {
+		attrs := []string{"label=" + dotQuote(block.dotLabel())}
+		switch {
+		case block.IsEntry:
+			attrs = append(attrs, "style=filled", "fillcolor=\"#d5e8d4\"")
+		case block.IsExit && block.BranchCond == "panic":
+			attrs = append(attrs, "style=filled", "fillcolor=\"#f8cecc\"")
+		case block.IsExit:
+			attrs = append(attrs, "style=filled", "fillcolor=\"#dae8fc\"")
+		}
+		if block.IsLoopHeader {
+			attrs = append(attrs, "penwidth=2")
+		}
+		fmt.Fprintf(&b, "    b%d [%s];\n", block.Index, strings.Join(attrs, ", "))
+	}
+	b.WriteString("\n")
+
+	back := make(map[CFGEdge]bool, len(c.BackEdges))
+	for _, e := range c.BackEdges {
+		back[e] = true
+	}
+	for _, block := range c.Blocks {
+		conditional := len(block.Succs) == 2 && block.BranchCond != ""
+		for i, succ := range block.Succs {
+			var attrs []string
+			if conditional {
+				// SSA orders If successors as [then, else]
+				if i == 0 {
+					attrs = append(attrs, "label="+dotQuote("true: "+block.BranchCond), "color=\"#2e7d32\"")
+				} else {
+					attrs = append(attrs, "label=\"false\"", "color=\"#c62828\"")
+				}
+			}
+			if back[CFGEdge{From: block.Index, To: succ}] {
+				attrs = append(attrs, "style=dashed", "constraint=false")
+			}
+			if len(attrs) > 0 {
+				fmt.Fprintf(&b, "    b%d -> b%d [%s];\n", block.Index, succ, strings.Join(attrs, ", "))
+			} else {
+				fmt.Fprintf(&b, "    b%d -> b%d;\n", block.Index, succ)
+			}
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (c *CFGInfo) signatureSuffix() string {
+	if c.Signature == "" {
+		return ""
+	}
+	return strings.TrimPrefix(c.Signature, "func")
+}
+
+// dotLabel builds a left-aligned label: a header with the block index and
+// source range, followed by its instructions.
+func (b *BasicBlockInfo) dotLabel() string {
+	var l strings.Builder
+	fmt.Fprintf(&l, "block %d", b.Index)
+	if b.StartLine > 0 {
+		fmt.Fprintf(&l, "  %s:%d", filepath.Base(b.File), b.StartLine)
+		if b.EndLine > b.StartLine {
+			fmt.Fprintf(&l, "-%d", b.EndLine)
+		}
+	}
+	if b.LoopDepth > 0 {
+		fmt.Fprintf(&l, "  loop depth %d", b.LoopDepth)
+	}
+	l.WriteString("\\l")
+
+	for i, instr := range b.Instructions {
+		if i == maxDOTInstructions {
+			fmt.Fprintf(&l, "... %d more\\l", len(b.Instructions)-i)
+			break
+		}
+		l.WriteString(dotEscape(instr.Text))
+		l.WriteString("\\l")
+	}
+	return l.String()
+}
+
+// dotQuote quotes s as a DOT string, escaping embedded quotes. Label
+// escapes such as \l that are already present are left intact.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// dotEscape escapes backslashes and newlines in free text so they are not
+// read as DOT label escapes.
+func dotEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
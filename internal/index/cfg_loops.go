@@ -0,0 +1,128 @@
+package index
+
+import (
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// CFGEdge is a directed edge between two basic blocks.
+type CFGEdge struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// LoopInfo describes a natural loop in the CFG.
+type LoopInfo struct {
+	Header    int       `json:"header"`           // Block that dominates the loop body
+	Blocks    []int     `json:"blocks"`           // All blocks in the loop, including the header
+	BackEdges []CFGEdge `json:"back_edges"`       // Edges from the body back to the header
+	Exits     []CFGEdge `json:"exits"`            // Edges leaving the loop
+	Guard     string    `json:"guard,omitempty"`  // Branch condition of the header, when it decides whether to continue
+	Depth     int       `json:"depth"`            // Nesting depth (1 = outermost)
+	Parent    *int      `json:"parent,omitempty"` // Header of the enclosing loop
+}
+
+// analyzeLoops fills in dominator, back-edge, and loop information for cfg
+// from the SSA function it was built from. cfg.Blocks[i] must describe
+// fn.Blocks[i].
+func analyzeLoops(fn *ssa.Function, cfg *CFGInfo) {
+	// Dominator tree
+	for i, b := range fn.Blocks {
+		if idom := b.Idom(); idom != nil {
+			parent := idom.Index
+			cfg.Blocks[i].Idom = &parent
+			cfg.DominatorEdges = append(cfg.DominatorEdges, CFGEdge{From: idom.Index, To: b.Index})
+		}
+	}
+
+	// A back edge targets a block that dominates its source; the natural
+	// loop is the header plus every block that reaches the source without
+	// passing through the header. Back edges sharing a header form one loop.
+	loops := make(map[int]*LoopInfo)
+	members := make(map[int]map[int]bool)
+	for _, b := range fn.Blocks {
+		for _, succ := range b.Succs {
+			if !succ.Dominates(b) {
+				continue
+			}
+			edge := CFGEdge{From: b.Index, To: succ.Index}
+			cfg.BackEdges = append(cfg.BackEdges, edge)
+
+			loop, ok := loops[succ.Index]
+			if !ok {
+				loop = &LoopInfo{Header: succ.Index}
+				loops[succ.Index] = loop
+				members[succ.Index] = map[int]bool{succ.Index: true}
+			}
+			loop.BackEdges = append(loop.BackEdges, edge)
+			collectLoopBody(b, members[succ.Index])
+		}
+	}
+	if len(loops) == 0 {
+		return
+	}
+
+	headers := make([]int, 0, len(loops))
+	for h := range loops {
+		headers = append(headers, h)
+	}
+	sort.Ints(headers)
+
+	for _, h := range headers {
+		loop := loops[h]
+		for idx := range members[h] {
+			loop.Blocks = append(loop.Blocks, idx)
+			cfg.Blocks[idx].LoopDepth++
+		}
+		sort.Ints(loop.Blocks)
+		cfg.Blocks[h].IsLoopHeader = true
+
+		for _, idx := range loop.Blocks {
+			for _, succ := range fn.Blocks[idx].Succs {
+				if !members[h][succ.Index] {
+					loop.Exits = append(loop.Exits, CFGEdge{From: idx, To: succ.Index})
+				}
+			}
+		}
+		if _, ok := fn.Blocks[h].Instrs[len(fn.Blocks[h].Instrs)-1].(*ssa.If); ok {
+			loop.Guard = cfg.Blocks[h].BranchCond
+		}
+	}
+
+	// Nesting: a loop's parent is the smallest other loop containing its header
+	for _, h := range headers {
+		loop := loops[h]
+		best := -1
+		for _, other := range headers {
+			if other == h || !members[other][h] {
+				continue
+			}
+			if best == -1 || len(members[other]) < len(members[best]) {
+				best = other
+			}
+		}
+		if best != -1 {
+			parent := best
+			loop.Parent = &parent
+		}
+		loop.Depth = cfg.Blocks[h].LoopDepth
+		cfg.Loops = append(cfg.Loops, *loop)
+	}
+}
+
+// collectLoopBody adds to body every block that reaches tail by walking
+// predecessors, stopping at blocks already in the body (the header is
+// seeded first, so the walk never escapes the loop).
+func collectLoopBody(tail *ssa.BasicBlock, body map[int]bool) {
+	stack := []*ssa.BasicBlock{tail}
+	for len(stack) > 0 {
+		b := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if body[b.Index] {
+			continue
+		}
+		body[b.Index] = true
+		stack = append(stack, b.Preds...)
+	}
+}
@@ -0,0 +1,180 @@
+package index
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// setupCFGProject writes a one-file module, indexes its symbols, and returns
+// the module dir, the store, and the ID of the branching check function.
+// The module also has sumPairs, a function with two nested loops.
+func setupCFGProject(t *testing.T) (string, *store.Store, store.SymbolID) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	src := `package main
+
+func check(n int) bool {
+	if n > 0 {
+		return true
+	}
+	return false
+}
+
+func main() {
+	println(check(1), sumPairs(3))
+}
+
+func sumPairs(n int) int {
+	total := 0
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			total += j
+		}
+	}
+	return total
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module testmod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		st.Close()
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	results, err := st.SearchSymbols(t.Context(), store.SearchFilter{Query: "check"})
+	if err != nil || len(results) == 0 {
+		st.Close()
+		t.Fatalf("finding symbol: %v", err)
+	}
+	return tmpDir, st, results[0].Symbol.ID
+}
+
+func TestCFGBlockSourceRanges(t *testing.T) {
+	_, st, symID := setupCFGProject(t)
+	defer st.Close()
+
+	cfg, err := NewCFGBuilder(st).BuildCFG(t.Context(), symID)
+	if err != nil {
+		t.Fatalf("building CFG: %v", err)
+	}
+
+	// Entry block holds the condition on line 4; the branches return on lines 5 and 7
+	lines := make(map[int]bool)
+	for _, b := range cfg.Blocks {
+		if b.StartLine == 0 {
+			continue
+		}
+		if filepath.Base(b.File) != "main.go" {
+			t.Errorf("block %d: expected main.go, got %q", b.Index, b.File)
+		}
+		if b.EndLine < b.StartLine {
+			t.Errorf("block %d: end line %d before start line %d", b.Index, b.EndLine, b.StartLine)
+		}
+		lines[b.StartLine] = true
+	}
+	for _, want := range []int{4, 5, 7} {
+		if !lines[want] {
+			t.Errorf("expected a block starting on line %d, got blocks %+v", want, cfg.Blocks)
+		}
+	}
+}
+
+func TestCFGLoopsAndDominators(t *testing.T) {
+	_, st, _ := setupCFGProject(t)
+	defer st.Close()
+
+	results, err := st.SearchSymbols(t.Context(), store.SearchFilter{Query: "sumPairs"})
+	if err != nil || len(results) == 0 {
+		t.Fatalf("finding sumPairs: %v", err)
+	}
+	cfg, err := NewCFGBuilder(st).BuildCFG(t.Context(), results[0].Symbol.ID)
+	if err != nil {
+		t.Fatalf("building CFG: %v", err)
+	}
+
+	if cfg.Blocks[0].Idom != nil {
+		t.Errorf("entry block should have no dominator, got %d", *cfg.Blocks[0].Idom)
+	}
+	if len(cfg.DominatorEdges) != len(cfg.Blocks)-1 {
+		t.Errorf("expected %d dominator edges, got %d", len(cfg.Blocks)-1, len(cfg.DominatorEdges))
+	}
+
+	if len(cfg.Loops) != 2 || len(cfg.BackEdges) != 2 {
+		t.Fatalf("expected 2 loops and 2 back edges, got %d and %d", len(cfg.Loops), len(cfg.BackEdges))
+	}
+	var outer, inner LoopInfo
+	for _, l := range cfg.Loops {
+		if l.Parent == nil {
+			outer = l
+		} else {
+			inner = l
+		}
+	}
+	if outer.Depth != 1 || inner.Depth != 2 {
+		t.Errorf("expected depths 1 and 2, got %d and %d", outer.Depth, inner.Depth)
+	}
+	if inner.Parent == nil || *inner.Parent != outer.Header {
+		t.Errorf("inner loop parent should be outer header %d", outer.Header)
+	}
+	if !cfg.Blocks[inner.Header].IsLoopHeader || cfg.Blocks[inner.Header].LoopDepth != 2 {
+		t.Errorf("inner header block: %+v", cfg.Blocks[inner.Header])
+	}
+	if outer.Guard == "" || len(outer.Exits) == 0 {
+		t.Errorf("outer loop should have a guard and exits, got %+v", outer)
+	}
+	if len(inner.Blocks) >= len(outer.Blocks) {
+		t.Errorf("inner loop (%v) should be smaller than outer loop (%v)", inner.Blocks, outer.Blocks)
+	}
+}
+
+func TestCFGDOT(t *testing.T) {
+	_, st, _ := setupCFGProject(t)
+	defer st.Close()
+
+	results, err := st.SearchSymbols(t.Context(), store.SearchFilter{Query: "sumPairs"})
+	if err != nil || len(results) == 0 {
+		t.Fatalf("finding sumPairs: %v", err)
+	}
+	cfg, err := NewCFGBuilder(st).BuildCFG(t.Context(), results[0].Symbol.ID)
+	if err != nil {
+		t.Fatalf("building CFG: %v", err)
+	}
+
+	dot := cfg.DOT()
+	if !strings.HasPrefix(dot, `digraph "sumPairs" {`) || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("unexpected DOT framing:\n%s", dot)
+	}
+	for _, want := range []string{
+		`label="true: `,
+		`label="false"`,
+		"style=dashed",
+		"main.go:",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+	for _, e := range cfg.BackEdges {
+		if !strings.Contains(dot, fmt.Sprintf("b%d -> b%d [", e.From, e.To)) {
+			t.Errorf("back edge %d -> %d not rendered", e.From, e.To)
+		}
+	}
+}
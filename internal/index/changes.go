@@ -0,0 +1,245 @@
+package index
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// ChangeSummary counts change log entries by kind.
+type ChangeSummary struct {
+	Added     int
+	Removed   int
+	Relocated int
+	Moved     int
+}
+
+// DiffSnapshots compares two index snapshots and returns the change log,
+// sorted by entity, change kind, and key.
+func DiffSnapshots(prev, cur *store.IndexSnapshot) []store.Change {
+	var changes []store.Change
+
+	// Symbols: identity is the symbol key; location is file:line
+	var added, removed []string
+	for key, sym := range cur.Symbols {
+		old, existed := prev.Symbols[key]
+		switch {
+		case !existed:
+			added = append(added, key)
+		case old.File != sym.File || old.Line != sym.Line:
+			changes = append(changes, store.Change{
+				Entity:      store.ChangeEntitySymbol,
+				Kind:        store.ChangeRelocated,
+				Key:         key,
+				SymbolID:    sym.ID,
+				OldLocation: old.Location(),
+				NewLocation: sym.Location(),
+			})
+		}
+	}
+	for key := range prev.Symbols {
+		if _, exists := cur.Symbols[key]; !exists {
+			removed = append(removed, key)
+		}
+	}
+
+	moved := matchMoves(prev, cur, removed, added)
+	movedFrom := make(map[string]string, len(moved))
+	for oldKey, newKey := range moved {
+		movedFrom[newKey] = oldKey
+	}
+	for _, key := range added {
+		sym := cur.Symbols[key]
+		if oldKey, ok := movedFrom[key]; ok {
+			changes = append(changes, store.Change{
+				Entity:      store.ChangeEntitySymbol,
+				Kind:        store.ChangeMoved,
+				Key:         key,
+				OldKey:      oldKey,
+				SymbolID:    sym.ID,
+				OldLocation: prev.Symbols[oldKey].Location(),
+				NewLocation: sym.Location(),
+			})
+			continue
+		}
+		changes = append(changes, store.Change{
+			Entity:      store.ChangeEntitySymbol,
+			Kind:        store.ChangeAdded,
+			Key:         key,
+			SymbolID:    sym.ID,
+			NewLocation: sym.Location(),
+		})
+	}
+	for _, key := range removed {
+		if _, ok := moved[key]; ok {
+			continue
+		}
+		changes = append(changes, store.Change{
+			Entity:      store.ChangeEntitySymbol,
+			Kind:        store.ChangeRemoved,
+			Key:         key,
+			OldLocation: prev.Symbols[key].Location(),
+		})
+	}
+
+	// Entrypoints: identity is "type label"; location is the handler symbol
+	for key, ep := range cur.Entrypoints {
+		old, existed := prev.Entrypoints[key]
+		switch {
+		case !existed:
+			changes = append(changes, store.Change{
+				Entity:      store.ChangeEntityEntrypoint,
+				Kind:        store.ChangeAdded,
+				Key:         key,
+				SymbolID:    ep.SymbolID,
+				NewLocation: ep.SymbolKey,
+			})
+		case old.SymbolKey != ep.SymbolKey:
+			changes = append(changes, store.Change{
+				Entity:      store.ChangeEntityEntrypoint,
+				Kind:        store.ChangeRelocated,
+				Key:         key,
+				SymbolID:    ep.SymbolID,
+				OldLocation: old.SymbolKey,
+				NewLocation: ep.SymbolKey,
+			})
+		}
+	}
+	for key, old := range prev.Entrypoints {
+		if _, exists := cur.Entrypoints[key]; !exists {
+			changes = append(changes, store.Change{
+				Entity:      store.ChangeEntityEntrypoint,
+				Kind:        store.ChangeRemoved,
+				Key:         key,
+				OldLocation: old.SymbolKey,
+			})
+		}
+	}
+
+	// Edges: identity is "caller -> callee"; callsite moves are not tracked,
+	// and edges of moved symbols are compared under their new keys
+	prevEdges := make(map[string]bool, len(prev.Edges))
+	for key := range prev.Edges {
+		prevEdges[movedEdgeKey(key, moved)] = true
+	}
+	for key, callerID := range cur.Edges {
+		if !prevEdges[key] {
+			changes = append(changes, store.Change{
+				Entity:   store.ChangeEntityEdge,
+				Kind:     store.ChangeAdded,
+				Key:      key,
+				SymbolID: callerID,
+			})
+		}
+	}
+	for key := range prev.Edges {
+		if _, exists := cur.Edges[movedEdgeKey(key, moved)]; !exists {
+			changes = append(changes, store.Change{
+				Entity: store.ChangeEntityEdge,
+				Kind:   store.ChangeRemoved,
+				Key:    key,
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Entity != changes[j].Entity {
+			return changes[i].Entity < changes[j].Entity
+		}
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+// moveIdentity is what a symbol keeps when it moves to another package or
+// receiver.
+type moveIdentity struct {
+	name string
+	kind store.SymbolKind
+	sig  string
+}
+
+// matchMoves pairs removed and added symbol keys that share a name, kind,
+// and signature, returning old key -> new key. Only unambiguous pairs are
+// matched: if several removed or added symbols share an identity (String
+// methods, say), none of them is considered moved.
+func matchMoves(prev, cur *store.IndexSnapshot, removed, added []string) map[string]string {
+	identity := func(key string, sym store.SnapshotSymbol) moveIdentity {
+		return moveIdentity{name: key[strings.LastIndex(key, ".")+1:], kind: sym.Kind, sig: sym.Sig}
+	}
+	oldKeys := make(map[moveIdentity][]string)
+	for _, key := range removed {
+		id := identity(key, prev.Symbols[key])
+		oldKeys[id] = append(oldKeys[id], key)
+	}
+	newKeys := make(map[moveIdentity][]string)
+	for _, key := range added {
+		id := identity(key, cur.Symbols[key])
+		newKeys[id] = append(newKeys[id], key)
+	}
+
+	moved := make(map[string]string)
+	for id, olds := range oldKeys {
+		if news := newKeys[id]; len(olds) == 1 && len(news) == 1 {
+			moved[olds[0]] = news[0]
+		}
+	}
+	return moved
+}
+
+// movedEdgeKey rewrites a previous "caller -> callee" edge key with the new
+// keys of moved symbols.
+func movedEdgeKey(key string, moved map[string]string) string {
+	caller, callee, ok := strings.Cut(key, " -> ")
+	if !ok {
+		return key
+	}
+	if newKey, ok := moved[caller]; ok {
+		caller = newKey
+	}
+	if newKey, ok := moved[callee]; ok {
+		callee = newKey
+	}
+	return caller + " -> " + callee
+}
+
+// Summarize counts changes by kind.
+func Summarize(changes []store.Change) ChangeSummary {
+	var s ChangeSummary
+	for _, c := range changes {
+		switch c.Kind {
+		case store.ChangeAdded:
+			s.Added++
+		case store.ChangeRemoved:
+			s.Removed++
+		case store.ChangeRelocated:
+			s.Relocated++
+		case store.ChangeMoved:
+			s.Moved++
+		}
+	}
+	return s
+}
+
+// recordChanges persists the change log for this run.
+func (idx *Indexer) recordChanges(ctx context.Context, st *store.Store, changes []store.Change) error {
+	batch, err := st.BeginBatch(ctx)
+	if err != nil {
+		return err
+	}
+	defer batch.Rollback()
+
+	for i := range changes {
+		if err := batch.InsertChange(ctx, &changes[i]); err != nil {
+			return err
+		}
+	}
+
+	return batch.Commit()
+}
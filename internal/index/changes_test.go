@@ -0,0 +1,93 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	prev := &store.IndexSnapshot{
+		Symbols: map[string]store.SnapshotSymbol{
+			"app.Keep":              {ID: 1, File: "a.go", Line: 10},
+			"app.Move":              {ID: 2, File: "a.go", Line: 20},
+			"app.Delete":            {ID: 3, File: "a.go", Line: 30},
+			"app/util.(*Cache).Get": {ID: 4, Kind: store.SymbolKindMethod, File: "util/cache.go", Line: 8, Sig: "func(key string) string"},
+			"app/a.String":          {ID: 5, Kind: store.SymbolKindFunc, File: "a/a.go", Line: 1},
+		},
+		Entrypoints: map[string]store.SnapshotEntrypoint{
+			"http GET /users": {SymbolID: 1, SymbolKey: "app.Keep"},
+		},
+		Edges: map[string]store.SymbolID{
+			"app.Keep -> app.Delete":            1,
+			"app.Keep -> app/util.(*Cache).Get": 1,
+		},
+	}
+	cur := &store.IndexSnapshot{
+		Symbols: map[string]store.SnapshotSymbol{
+			"app.Keep":               {ID: 11, File: "a.go", Line: 10},
+			"app.Move":               {ID: 12, File: "b.go", Line: 5},
+			"app.New":                {ID: 13, File: "a.go", Line: 30},
+			"app/cache.(*Cache).Get": {ID: 14, Kind: store.SymbolKindMethod, File: "cache/cache.go", Line: 8, Sig: "func(key string) string"},
+			// Two candidates for app/a.String: ambiguous, so not a move
+			"app/b.String": {ID: 15, Kind: store.SymbolKindFunc, File: "b/b.go", Line: 1},
+			"app/c.String": {ID: 16, Kind: store.SymbolKindFunc, File: "c/c.go", Line: 1},
+		},
+		Entrypoints: map[string]store.SnapshotEntrypoint{
+			"http GET /users": {SymbolID: 13, SymbolKey: "app.New"},
+		},
+		Edges: map[string]store.SymbolID{
+			"app.Keep -> app.New":                11,
+			"app.Keep -> app/cache.(*Cache).Get": 11,
+		},
+	}
+
+	changes := DiffSnapshots(prev, cur)
+
+	type key struct {
+		entity store.ChangeEntity
+		kind   store.ChangeKind
+		key    string
+	}
+	got := make(map[key]store.Change)
+	for _, c := range changes {
+		got[key{c.Entity, c.Kind, c.Key}] = c
+	}
+
+	want := []key{
+		{store.ChangeEntitySymbol, store.ChangeAdded, "app.New"},
+		{store.ChangeEntitySymbol, store.ChangeRemoved, "app.Delete"},
+		{store.ChangeEntitySymbol, store.ChangeRelocated, "app.Move"},
+		{store.ChangeEntitySymbol, store.ChangeMoved, "app/cache.(*Cache).Get"},
+		{store.ChangeEntitySymbol, store.ChangeAdded, "app/b.String"},
+		{store.ChangeEntitySymbol, store.ChangeAdded, "app/c.String"},
+		{store.ChangeEntitySymbol, store.ChangeRemoved, "app/a.String"},
+		{store.ChangeEntityEntrypoint, store.ChangeRelocated, "http GET /users"},
+		{store.ChangeEntityEdge, store.ChangeAdded, "app.Keep -> app.New"},
+		{store.ChangeEntityEdge, store.ChangeRemoved, "app.Keep -> app.Delete"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %d: %+v", len(want), len(changes), changes)
+	}
+	for _, k := range want {
+		if _, ok := got[k]; !ok {
+			t.Errorf("missing change %+v", k)
+		}
+	}
+
+	moved := got[key{store.ChangeEntitySymbol, store.ChangeRelocated, "app.Move"}]
+	if moved.OldLocation != "a.go:20" || moved.NewLocation != "b.go:5" {
+		t.Errorf("unexpected relocation: %s -> %s", moved.OldLocation, moved.NewLocation)
+	}
+
+	// The moved method's edge is unchanged under its new key
+	cache := got[key{store.ChangeEntitySymbol, store.ChangeMoved, "app/cache.(*Cache).Get"}]
+	if cache.OldKey != "app/util.(*Cache).Get" || cache.OldLocation != "util/cache.go:8" || cache.SymbolID != 14 {
+		t.Errorf("unexpected move: %+v", cache)
+	}
+
+	summary := Summarize(changes)
+	if summary.Added != 4 || summary.Removed != 3 || summary.Relocated != 2 || summary.Moved != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
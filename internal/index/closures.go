@@ -0,0 +1,129 @@
+package index
+
+import (
+	"go/ast"
+	"go/types"
+	"strconv"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/packages"
+)
+
+// namedClosure is a function literal, its SSA name, and the receiver of the
+// method it appears in, if any.
+type namedClosure struct {
+	lit      *ast.FuncLit
+	name     string
+	recvType string
+}
+
+// closureNames names the function literals inside decl the way go/ssa names
+// anonymous functions: "Start$1", "Start$2" in source order, and "Start$1$1"
+// for a literal nested in the first. Matching SSA's names lets the call graph
+// attribute calls made inside a closure to the closure's own symbol.
+//
+// Literals in init functions are not named: SSA numbers those functions
+// ("init#1") while their symbols are plain "init".
+func closureNames(decl *ast.FuncDecl) []namedClosure {
+	if decl.Body == nil || (decl.Recv == nil && decl.Name.Name == "init") {
+		return nil
+	}
+	recvType := ""
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		recvType = formatReceiverType(decl.Recv.List[0].Type)
+	}
+	var names []namedClosure
+	for i, lit := range funcLitsIn(decl.Body) {
+		names = appendClosure(names, lit, decl.Name.Name+"$"+strconv.Itoa(i+1), recvType)
+	}
+	return names
+}
+
+// packageClosureNames names the function literals in package-level variable
+// initializers. SSA builds these into the package's init function in
+// initialization order rather than source order, so they are numbered
+// "init$1", "init$2" following TypesInfo.InitOrder.
+func packageClosureNames(pkg *packages.Package) []namedClosure {
+	if pkg.TypesInfo == nil {
+		return nil
+	}
+	var names []namedClosure
+	n := 0
+	for _, init := range pkg.TypesInfo.InitOrder {
+		for _, lit := range funcLitsIn(init.Rhs) {
+			n++
+			names = appendClosure(names, lit, "init$"+strconv.Itoa(n), "")
+		}
+	}
+	return names
+}
+
+// appendClosure appends lit under name, followed by the literals nested in
+// it.
+func appendClosure(names []namedClosure, lit *ast.FuncLit, name, recvType string) []namedClosure {
+	names = append(names, namedClosure{lit: lit, name: name, recvType: recvType})
+	for i, nested := range funcLitsIn(lit.Body) {
+		names = appendClosure(names, nested, name+"$"+strconv.Itoa(i+1), recvType)
+	}
+	return names
+}
+
+// funcLitsIn returns the function literals in node that are not nested in
+// another literal, in source order.
+func funcLitsIn(node ast.Node) []*ast.FuncLit {
+	var lits []*ast.FuncLit
+	ast.Inspect(node, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			lits = append(lits, lit)
+			return false
+		}
+		return true
+	})
+	return lits
+}
+
+// closureIndex maps every named function literal of pkg to its name.
+func closureIndex(pkg *packages.Package) map[*ast.FuncLit]namedClosure {
+	index := make(map[*ast.FuncLit]namedClosure)
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				for _, c := range closureNames(fn) {
+					index[c.lit] = c
+				}
+			}
+		}
+	}
+	for _, c := range packageClosureNames(pkg) {
+		index[c.lit] = c
+	}
+	return index
+}
+
+// closureSymbols returns a symbol for each closure. A closure inside a method
+// is a method symbol with the method's receiver, matching how the call graph
+// looks it up.
+func (l *Loader) closureSymbols(pkg *packages.Package, closures []namedClosure) []*store.Symbol {
+	var syms []*store.Symbol
+	for _, c := range closures {
+		sym := &store.Symbol{
+			PkgPath:  pkg.PkgPath,
+			Name:     c.name,
+			Kind:     store.SymbolKindFunc,
+			RecvType: c.recvType,
+			File:     l.fset.Position(c.lit.Pos()).Filename,
+		}
+		l.setSpan(sym, c.lit.Pos(), c.lit.End())
+		if c.recvType != "" {
+			sym.Kind = store.SymbolKindMethod
+		}
+		if tv, ok := pkg.TypesInfo.Types[c.lit]; ok {
+			if sig, ok := tv.Type.(*types.Signature); ok {
+				sym.Sig = sig.String()
+				sym.Signature = signatureOf(sig)
+			}
+		}
+		syms = append(syms, sym)
+	}
+	return syms
+}
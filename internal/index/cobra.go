@@ -0,0 +1,258 @@
+package index
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// cobraCommandPath is the resolved position of a Cobra command in its
+// command tree.
+type cobraCommandPath struct {
+	Path   string // Full command path without the root, e.g. "db migrate up"
+	Parent string // Path of the parent command; empty for top-level commands
+}
+
+// cobraTree resolves parent.AddCommand(child) relationships between
+// &cobra.Command{...} literals across every loaded package, so a command
+// defined in one file and attached in another still gets its full path.
+type cobraTree struct {
+	d       *EntrypointDetector
+	uses    map[*ast.CompositeLit]string            // First word of Use
+	vars    map[types.Object]*ast.CompositeLit      // Variables bound to a command literal
+	funcs   map[types.Object]*ast.CompositeLit      // Functions returning a command literal
+	parents map[*ast.CompositeLit]*ast.CompositeLit // Child to parent
+	paths   map[*ast.CompositeLit]cobraCommandPath  // Resolved paths, filled lazily
+	adds    []cobraAddCommand
+}
+
+// cobraAddCommand is one parent.AddCommand(children...) call.
+type cobraAddCommand struct {
+	pkg      *packages.Package
+	parent   ast.Expr
+	children []ast.Expr
+}
+
+// buildCobraTree collects command literals, the variables and constructor
+// functions they flow through, and AddCommand calls from all packages.
+func (d *EntrypointDetector) buildCobraTree() *cobraTree {
+	t := &cobraTree{
+		d:       d,
+		uses:    make(map[*ast.CompositeLit]string),
+		vars:    make(map[types.Object]*ast.CompositeLit),
+		funcs:   make(map[types.Object]*ast.CompositeLit),
+		parents: make(map[*ast.CompositeLit]*ast.CompositeLit),
+		paths:   make(map[*ast.CompositeLit]cobraCommandPath),
+	}
+
+	for _, pkg := range d.loader.Packages() {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			t.collect(pkg, file)
+		}
+	}
+
+	// Variables may be bound after they are attached, so AddCommand calls
+	// are resolved once every binding is known.
+	for _, add := range t.adds {
+		parent := t.resolve(add.pkg, add.parent)
+		if parent == nil {
+			continue
+		}
+		for _, childExpr := range add.children {
+			child := t.resolve(add.pkg, childExpr)
+			if child != nil && child != parent {
+				if _, seen := t.parents[child]; !seen {
+					t.parents[child] = parent
+				}
+			}
+		}
+	}
+	return t
+}
+
+// collect records the command literals, bindings, and AddCommand calls of
+// one file.
+func (t *cobraTree) collect(pkg *packages.Package, file *ast.File) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CompositeLit:
+			if t.d.isCobraCommandType(n.Type) {
+				t.uses[n] = cobraCommandName(t.d, n)
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if i < len(n.Values) {
+					t.bind(pkg, name, n.Values[i])
+				}
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i, lhs := range n.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						t.bind(pkg, ident, n.Rhs[i])
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			t.collectConstructor(pkg, n)
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if ok && sel.Sel.Name == "AddCommand" && len(n.Args) > 0 {
+				t.adds = append(t.adds, cobraAddCommand{pkg: pkg, parent: sel.X, children: n.Args})
+			}
+		}
+		return true
+	})
+}
+
+// bind records that ident holds the command literal expr evaluates to.
+// Only the first binding of a variable is kept.
+func (t *cobraTree) bind(pkg *packages.Package, ident *ast.Ident, expr ast.Expr) {
+	lit := cobraLiteral(t.d, expr)
+	if lit == nil {
+		return
+	}
+	obj := pkg.TypesInfo.ObjectOf(ident)
+	if obj == nil {
+		return
+	}
+	if _, seen := t.vars[obj]; !seen {
+		t.vars[obj] = lit
+	}
+}
+
+// collectConstructor records functions such as newServeCmd() that return a
+// command literal, directly or through a local variable.
+func (t *cobraTree) collectConstructor(pkg *packages.Package, decl *ast.FuncDecl) {
+	if decl.Body == nil || decl.Type.Results == nil || len(decl.Type.Results.List) == 0 {
+		return
+	}
+	obj := pkg.TypesInfo.Defs[decl.Name]
+	if obj == nil {
+		return
+	}
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false // Returns inside closures belong to the closure
+		}
+		ret, ok := n.(*ast.ReturnStmt)
+		if !ok || len(ret.Results) == 0 {
+			return true
+		}
+		if _, seen := t.funcs[obj]; seen {
+			return false
+		}
+		lit := cobraLiteral(t.d, ret.Results[0])
+		if ident, ok := ret.Results[0].(*ast.Ident); ok {
+			lit = t.localCommand(pkg, decl.Body, ident)
+		}
+		if lit != nil {
+			t.funcs[obj] = lit
+		}
+		return true
+	})
+}
+
+// localCommand finds the command literal assigned to ident within body.
+func (t *cobraTree) localCommand(pkg *packages.Package, body *ast.BlockStmt, ident *ast.Ident) *ast.CompositeLit {
+	target := pkg.TypesInfo.ObjectOf(ident)
+	if target == nil {
+		return nil
+	}
+	var found *ast.CompositeLit
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != len(assign.Rhs) {
+			return true
+		}
+		for i, lhs := range assign.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok && pkg.TypesInfo.ObjectOf(id) == target {
+				found = cobraLiteral(t.d, assign.Rhs[i])
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// resolve returns the command literal an expression refers to: an inline
+// literal, a variable bound to one, or a call to a constructor returning one.
+func (t *cobraTree) resolve(pkg *packages.Package, expr ast.Expr) *ast.CompositeLit {
+	if lit := cobraLiteral(t.d, expr); lit != nil {
+		return lit
+	}
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return t.resolve(pkg, e.X)
+	case *ast.Ident:
+		return t.vars[pkg.TypesInfo.ObjectOf(e)]
+	case *ast.SelectorExpr:
+		// Package-level variable in another package, e.g. cmd.RootCmd
+		return t.vars[pkg.TypesInfo.ObjectOf(e.Sel)]
+	case *ast.CallExpr:
+		switch fn := e.Fun.(type) {
+		case *ast.Ident:
+			return t.funcs[pkg.TypesInfo.ObjectOf(fn)]
+		case *ast.SelectorExpr:
+			return t.funcs[pkg.TypesInfo.ObjectOf(fn.Sel)]
+		}
+	}
+	return nil
+}
+
+// pathOf returns the full command path of lit. The root command is the
+// binary itself, so it is left out of its descendants' paths.
+func (t *cobraTree) pathOf(lit *ast.CompositeLit) cobraCommandPath {
+	if p, ok := t.paths[lit]; ok {
+		return p
+	}
+	// Guard against AddCommand cycles while this path is being computed
+	t.paths[lit] = cobraCommandPath{Path: t.uses[lit]}
+
+	p := cobraCommandPath{Path: t.uses[lit]}
+	if parent, ok := t.parents[lit]; ok {
+		if _, isChild := t.parents[parent]; isChild {
+			p.Parent = t.pathOf(parent).Path
+			p.Path = p.Parent + " " + p.Path
+		}
+	}
+	t.paths[lit] = p
+	return p
+}
+
+// cobraLiteral returns the &cobra.Command{...} literal expr is, if any.
+func cobraLiteral(d *EntrypointDetector, expr ast.Expr) *ast.CompositeLit {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok || !d.isCobraCommandType(lit.Type) {
+		return nil
+	}
+	return lit
+}
+
+// cobraCommandName returns the first word of a command literal's Use field.
+func cobraCommandName(d *EntrypointDetector, lit *ast.CompositeLit) string {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Use" {
+			if fields := strings.Fields(d.extractStringLiteral(kv.Value)); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+	return ""
+}
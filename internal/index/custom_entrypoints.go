@@ -0,0 +1,122 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// defaultCustomLabel labels entrypoints of rules without a label template.
+const defaultCustomLabel = "{{.PkgName}}.{{if .Recv}}{{.Recv}}.{{end}}{{.Name}}"
+
+// customLabelData is the data of an entrypoints rule's label template.
+type customLabelData struct {
+	Pkg     string // Package path
+	PkgName string // Package name
+	Recv    string // Receiver type without "*", empty for functions
+	Name    string // Function or method name
+}
+
+// customRule is a compiled config.EntrypointRule.
+type customRule struct {
+	rule  config.EntrypointRule
+	name  *regexp.Regexp
+	recv  *regexp.Regexp
+	label *template.Template
+	typ   store.EntrypointType
+}
+
+// compileCustomRules compiles the entrypoints rules of cfg. Load has already
+// validated them, so errors only come from configs built in code.
+func compileCustomRules(cfg *config.Config) ([]customRule, error) {
+	var rules []customRule
+	for i, rule := range cfg.Entrypoints {
+		compiled := customRule{rule: rule, typ: store.EntrypointCustom}
+		var err error
+		if compiled.name, err = regexp.Compile(rule.Name); err != nil {
+			return nil, fmt.Errorf("entrypoints[%d]: %w", i, err)
+		}
+		if compiled.recv, err = regexp.Compile(rule.Recv); err != nil {
+			return nil, fmt.Errorf("entrypoints[%d]: %w", i, err)
+		}
+		label := rule.Label
+		if label == "" {
+			label = defaultCustomLabel
+		}
+		if compiled.label, err = template.New("label").Parse(label); err != nil {
+			return nil, fmt.Errorf("entrypoints[%d]: label: %w", i, err)
+		}
+		if rule.Type != "" {
+			compiled.typ = store.EntrypointType(rule.Type)
+		}
+		rules = append(rules, compiled)
+	}
+	return rules, nil
+}
+
+// detectCustom makes the functions and methods matching the config's
+// entrypoints rules entrypoints. A function matching several rules takes the
+// first.
+func (d *EntrypointDetector) detectCustom(ctx context.Context, batch *store.BatchTx) (int, error) {
+	if d.loader.cfg == nil || len(d.loader.cfg.Entrypoints) == 0 {
+		return 0, nil
+	}
+	rules, err := compileCustomRules(d.loader.cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, pkg := range d.loader.Packages() {
+		for i, file := range pkg.Syntax {
+			if d.loader.shouldExcludeFile(pkg.GoFiles[i]) {
+				continue
+			}
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				recvType := ""
+				if fn.Recv != nil && len(fn.Recv.List) > 0 {
+					recvType = formatReceiverType(fn.Recv.List[0].Type)
+				}
+				data := customLabelData{
+					Pkg:     pkg.PkgPath,
+					PkgName: pkg.Name,
+					Recv:    strings.TrimPrefix(recvType, "*"),
+					Name:    fn.Name.Name,
+				}
+				for _, rule := range rules {
+					if !rule.rule.MatchesPackage(pkg.PkgPath) || !rule.name.MatchString(data.Name) || !rule.recv.MatchString(data.Recv) {
+						continue
+					}
+					symbolID, err := batch.GetSymbolID(ctx, pkg.PkgPath, fn.Name.Name, recvType)
+					if err != nil {
+						break
+					}
+					var label strings.Builder
+					if err := rule.label.Execute(&label, data); err != nil {
+						return count, fmt.Errorf("labeling %s.%s: %w", pkg.PkgPath, fn.Name.Name, err)
+					}
+					ep := &store.Entrypoint{
+						Type:     rule.typ,
+						Label:    label.String(),
+						SymbolID: symbolID,
+					}
+					if err := batch.InsertEntrypoint(ctx, ep); err == nil {
+						count++
+					}
+					break
+				}
+			}
+		}
+	}
+	return count, nil
+}
@@ -1,10 +1,12 @@
 package index
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"strings"
 
 	"github.com/abramin/flowlens/internal/store"
@@ -13,8 +15,11 @@ import (
 
 // EntrypointDetector detects program entrypoints from AST.
 type EntrypointDetector struct {
-	loader *Loader
-	fset   *token.FileSet
+	loader    *Loader
+	fset      *token.FileSet
+	cobra     *cobraTree                    // Command hierarchy across all packages, built by Detect
+	closures  map[*ast.FuncLit]namedClosure // Inline handlers' closure names, built by Detect
+	funcDecls map[*types.Func]funcDecl      // Declarations for following handler factories, built by Detect
 }
 
 // NewEntrypointDetector creates a new entrypoint detector.
@@ -27,35 +32,52 @@ func NewEntrypointDetector(loader *Loader) *EntrypointDetector {
 
 // HTTPMeta holds metadata for HTTP entrypoints.
 type HTTPMeta struct {
-	Method string `json:"method"`
-	Path   string `json:"path"`
+	Method        string      `json:"method"`
+	Path          string      `json:"path"`
+	Middleware    []string    `json:"middleware,omitempty"`     // Router and wrapper middleware, outermost first
+	StatusCodes   []int       `json:"status_codes,omitempty"`   // Constant response statuses found by StatusAnalyzer
+	RequestType   string      `json:"request_type,omitempty"`   // Decoded request body type found by PayloadAnalyzer
+	ResponseTypes []string    `json:"response_types,omitempty"` // Encoded response types found by PayloadAnalyzer
+	Resilience    *Resilience `json:"resilience,omitempty"`     // Timeouts and retries found by ResilienceAnalyzer
 }
 
 // GRPCMeta holds metadata for gRPC entrypoints.
 type GRPCMeta struct {
-	Service string `json:"service"`
-	Method  string `json:"method"`
+	Service     string      `json:"service"`
+	Method      string      `json:"method"`
+	StatusCodes []int       `json:"status_codes,omitempty"` // gRPC codes found by StatusAnalyzer
+	Resilience  *Resilience `json:"resilience,omitempty"`   // Timeouts and retries found by ResilienceAnalyzer
 }
 
 // CLIMeta holds metadata for CLI entrypoints.
 type CLIMeta struct {
-	Command   string `json:"command"`
-	Parent    string `json:"parent,omitempty"`
+	Command   string `json:"command"`          // Full command path without the root, e.g. "db migrate up"
+	Parent    string `json:"parent,omitempty"` // Parent command path; empty for top-level commands
 	UsesRunE  bool   `json:"uses_run_e,omitempty"`
+	Resilience *Resilience `json:"resilience,omitempty"` // Timeouts and retries found by ResilienceAnalyzer
 }
 
 // DetectResult holds the results of entrypoint detection.
 type DetectResult struct {
-	HTTPCount  int
-	GRPCCount  int
-	CLICount   int
-	MainCount  int
-	TotalCount int
+	HTTPCount   int
+	GRPCCount   int
+	CLICount    int
+	MainCount   int
+	CustomCount int // Declared by entrypoints rules in the config
+	TotalCount  int
 }
 
 // Detect finds all entrypoints and persists them to the database.
-func (d *EntrypointDetector) Detect(batch *store.BatchTx) (*DetectResult, error) {
+func (d *EntrypointDetector) Detect(ctx context.Context, batch *store.BatchTx) (*DetectResult, error) {
 	result := &DetectResult{}
+	d.cobra = d.buildCobraTree()
+	d.funcDecls = indexFuncDecls(d.loader.Packages())
+	d.closures = make(map[*ast.FuncLit]namedClosure)
+	for _, pkg := range d.loader.Packages() {
+		for lit, c := range closureIndex(pkg) {
+			d.closures[lit] = c
+		}
+	}
 
 	for _, pkg := range d.loader.Packages() {
 		for i, file := range pkg.Syntax {
@@ -65,28 +87,28 @@ func (d *EntrypointDetector) Detect(batch *store.BatchTx) (*DetectResult, error)
 			}
 
 			// Detect HTTP entrypoints
-			httpEPs, err := d.detectHTTP(pkg, file, goFile, batch)
+			httpEPs, err := d.detectHTTP(ctx, pkg, file, goFile, batch)
 			if err != nil {
 				return nil, fmt.Errorf("detecting HTTP entrypoints in %s: %w", goFile, err)
 			}
 			result.HTTPCount += httpEPs
 
 			// Detect gRPC entrypoints
-			grpcEPs, err := d.detectGRPC(pkg, file, goFile, batch)
+			grpcEPs, err := d.detectGRPC(ctx, pkg, file, goFile, batch)
 			if err != nil {
 				return nil, fmt.Errorf("detecting gRPC entrypoints in %s: %w", goFile, err)
 			}
 			result.GRPCCount += grpcEPs
 
 			// Detect Cobra CLI entrypoints
-			cliEPs, err := d.detectCobra(pkg, file, goFile, batch)
+			cliEPs, err := d.detectCobra(ctx, pkg, file, goFile, batch)
 			if err != nil {
 				return nil, fmt.Errorf("detecting CLI entrypoints in %s: %w", goFile, err)
 			}
 			result.CLICount += cliEPs
 
 			// Detect main() entrypoints
-			mainEPs, err := d.detectMain(pkg, file, goFile, batch)
+			mainEPs, err := d.detectMain(ctx, pkg, file, goFile, batch)
 			if err != nil {
 				return nil, fmt.Errorf("detecting main entrypoints in %s: %w", goFile, err)
 			}
@@ -94,15 +116,24 @@ func (d *EntrypointDetector) Detect(batch *store.BatchTx) (*DetectResult, error)
 		}
 	}
 
-	result.TotalCount = result.HTTPCount + result.GRPCCount + result.CLICount + result.MainCount
+	customEPs, err := d.detectCustom(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("detecting configured entrypoints: %w", err)
+	}
+	result.CustomCount = customEPs
+
+	result.TotalCount = result.HTTPCount + result.GRPCCount + result.CLICount + result.MainCount + result.CustomCount
 	return result, nil
 }
 
 // detectHTTP finds HTTP route registrations (stdlib, chi, gin).
-func (d *EntrypointDetector) detectHTTP(pkg *packages.Package, file *ast.File, goFile string, batch *store.BatchTx) (int, error) {
+func (d *EntrypointDetector) detectHTTP(ctx context.Context, pkg *packages.Package, file *ast.File, goFile string, batch *store.BatchTx) (int, error) {
 	count := 0
+	scope := newMiddlewareScope(pkg)
 
 	ast.Inspect(file, func(n ast.Node) bool {
+		scope.visit(n)
+
 		call, ok := n.(*ast.CallExpr)
 		if !ok {
 			return true
@@ -111,6 +142,7 @@ func (d *EntrypointDetector) detectHTTP(pkg *packages.Package, file *ast.File, g
 		// Try to match different HTTP registration patterns
 		var method, path string
 		var handlerExpr ast.Expr
+		var middleware []string
 
 		// Check for selector expressions (e.g., mux.HandleFunc, r.Get)
 		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
@@ -149,7 +181,7 @@ func (d *EntrypointDetector) detectHTTP(pkg *packages.Package, file *ast.File, g
 				methodName == "HEAD":
 				if len(call.Args) >= 2 {
 					path = d.extractStringLiteral(call.Args[0])
-					handlerExpr = call.Args[1]
+					handlerExpr, middleware = d.splitHandlerArgs(ctx, pkg, call.Args[1:], batch)
 					method = methodName
 				}
 
@@ -157,18 +189,23 @@ func (d *EntrypointDetector) detectHTTP(pkg *packages.Package, file *ast.File, g
 			case methodName == "Any":
 				if len(call.Args) >= 2 {
 					path = d.extractStringLiteral(call.Args[0])
-					handlerExpr = call.Args[1]
+					handlerExpr, middleware = d.splitHandlerArgs(ctx, pkg, call.Args[1:], batch)
 					method = "ANY"
 				}
 			}
+
+			if handlerExpr != nil {
+				middleware = append(scope.inherited(sel.X), middleware...)
+			}
 		}
 
 		// If we found a valid route registration
 		if path != "" && handlerExpr != nil {
-			// Resolve handler to symbol
-			symbolID := d.resolveHandlerSymbol(pkg, handlerExpr, batch)
+			// Resolve handler to symbol, looking through wrapping middleware
+			inner, wrappers := unwrapHandler(pkg, handlerExpr)
+			symbolID := d.resolveHandlerSymbol(ctx, pkg, inner, batch)
 			if symbolID != 0 {
-				meta := HTTPMeta{Method: method, Path: path}
+				meta := HTTPMeta{Method: method, Path: path, Middleware: append(middleware, wrappers...)}
 				metaJSON, _ := json.Marshal(meta)
 
 				ep := &store.Entrypoint{
@@ -178,7 +215,7 @@ func (d *EntrypointDetector) detectHTTP(pkg *packages.Package, file *ast.File, g
 					MetaJSON: string(metaJSON),
 				}
 
-				if err := batch.InsertEntrypoint(ep); err == nil {
+				if err := batch.InsertEntrypoint(ctx, ep); err == nil {
 					count++
 				}
 			}
@@ -191,7 +228,7 @@ func (d *EntrypointDetector) detectHTTP(pkg *packages.Package, file *ast.File, g
 }
 
 // detectGRPC finds gRPC service registrations (RegisterXServer patterns).
-func (d *EntrypointDetector) detectGRPC(pkg *packages.Package, file *ast.File, goFile string, batch *store.BatchTx) (int, error) {
+func (d *EntrypointDetector) detectGRPC(ctx context.Context, pkg *packages.Package, file *ast.File, goFile string, batch *store.BatchTx) (int, error) {
 	count := 0
 
 	// Track registered services and their implementation types
@@ -254,10 +291,10 @@ func (d *EntrypointDetector) detectGRPC(pkg *packages.Package, file *ast.File, g
 		methods := d.findServiceMethods(pkg, implType, reg.serviceName)
 		for _, methodName := range methods {
 			// Look up the symbol for this method
-			symbolID, err := batch.GetSymbolID(pkg.PkgPath, methodName, implType)
+			symbolID, err := batch.GetSymbolID(ctx, pkg.PkgPath, methodName, implType)
 			if err != nil {
 				// Try with pointer receiver
-				symbolID, err = batch.GetSymbolID(pkg.PkgPath, methodName, "*"+implType)
+				symbolID, err = batch.GetSymbolID(ctx, pkg.PkgPath, methodName, "*"+implType)
 			}
 			if err != nil {
 				continue
@@ -273,7 +310,7 @@ func (d *EntrypointDetector) detectGRPC(pkg *packages.Package, file *ast.File, g
 				MetaJSON: string(metaJSON),
 			}
 
-			if err := batch.InsertEntrypoint(ep); err == nil {
+			if err := batch.InsertEntrypoint(ctx, ep); err == nil {
 				count++
 			}
 		}
@@ -283,7 +320,7 @@ func (d *EntrypointDetector) detectGRPC(pkg *packages.Package, file *ast.File, g
 }
 
 // detectCobra finds Cobra CLI command definitions.
-func (d *EntrypointDetector) detectCobra(pkg *packages.Package, file *ast.File, goFile string, batch *store.BatchTx) (int, error) {
+func (d *EntrypointDetector) detectCobra(ctx context.Context, pkg *packages.Package, file *ast.File, goFile string, batch *store.BatchTx) (int, error) {
 	count := 0
 
 	// Track command definitions
@@ -291,7 +328,7 @@ func (d *EntrypointDetector) detectCobra(pkg *packages.Package, file *ast.File,
 		use         string
 		runHandler  ast.Expr
 		runEHandler ast.Expr
-		parent      string
+		lit         *ast.CompositeLit
 	}
 	var commands []commandInfo
 
@@ -312,7 +349,7 @@ func (d *EntrypointDetector) detectCobra(pkg *packages.Package, file *ast.File,
 			return true
 		}
 
-		cmd := commandInfo{}
+		cmd := commandInfo{lit: compLit}
 		for _, elt := range compLit.Elts {
 			kv, ok := elt.(*ast.KeyValueExpr)
 			if !ok {
@@ -351,11 +388,16 @@ func (d *EntrypointDetector) detectCobra(pkg *packages.Package, file *ast.File,
 			handlerExpr = cmd.runHandler
 		}
 
-		symbolID := d.resolveHandlerSymbol(pkg, handlerExpr, batch)
+		symbolID := d.resolveHandlerSymbol(ctx, pkg, handlerExpr, batch)
 		if symbolID != 0 {
-			// Extract command name from Use field (first word)
+			// Full command path from AddCommand, falling back to the
+			// first word of Use
 			cmdName := strings.Fields(cmd.use)[0]
 			meta := CLIMeta{Command: cmdName, UsesRunE: usesRunE}
+			if d.cobra != nil {
+				path := d.cobra.pathOf(cmd.lit)
+				cmdName, meta.Command, meta.Parent = path.Path, path.Path, path.Parent
+			}
 			metaJSON, _ := json.Marshal(meta)
 
 			ep := &store.Entrypoint{
@@ -365,7 +407,7 @@ func (d *EntrypointDetector) detectCobra(pkg *packages.Package, file *ast.File,
 				MetaJSON: string(metaJSON),
 			}
 
-			if err := batch.InsertEntrypoint(ep); err == nil {
+			if err := batch.InsertEntrypoint(ctx, ep); err == nil {
 				count++
 			}
 		}
@@ -375,7 +417,7 @@ func (d *EntrypointDetector) detectCobra(pkg *packages.Package, file *ast.File,
 }
 
 // detectMain finds main() function entrypoints.
-func (d *EntrypointDetector) detectMain(pkg *packages.Package, file *ast.File, goFile string, batch *store.BatchTx) (int, error) {
+func (d *EntrypointDetector) detectMain(ctx context.Context, pkg *packages.Package, file *ast.File, goFile string, batch *store.BatchTx) (int, error) {
 	// Only look for main in main package
 	if pkg.Name != "main" {
 		return 0, nil
@@ -390,7 +432,7 @@ func (d *EntrypointDetector) detectMain(pkg *packages.Package, file *ast.File, g
 
 		// Check for main function (no receiver, name is "main")
 		if fn.Name.Name == "main" && fn.Recv == nil {
-			symbolID, err := batch.GetSymbolID(pkg.PkgPath, "main", "")
+			symbolID, err := batch.GetSymbolID(ctx, pkg.PkgPath, "main", "")
 			if err != nil {
 				continue
 			}
@@ -401,7 +443,7 @@ func (d *EntrypointDetector) detectMain(pkg *packages.Package, file *ast.File, g
 				SymbolID: symbolID,
 			}
 
-			if err := batch.InsertEntrypoint(ep); err == nil {
+			if err := batch.InsertEntrypoint(ctx, ep); err == nil {
 				count++
 			}
 		}
@@ -411,27 +453,36 @@ func (d *EntrypointDetector) detectMain(pkg *packages.Package, file *ast.File, g
 }
 
 // resolveHandlerSymbol attempts to resolve a handler expression to a symbol ID.
-func (d *EntrypointDetector) resolveHandlerSymbol(pkg *packages.Package, expr ast.Expr, batch *store.BatchTx) store.SymbolID {
+func (d *EntrypointDetector) resolveHandlerSymbol(ctx context.Context, pkg *packages.Package, expr ast.Expr, batch *store.BatchTx) store.SymbolID {
 	switch e := expr.(type) {
 	case *ast.Ident:
 		// Simple function reference: handler
-		symbolID, err := batch.GetSymbolID(pkg.PkgPath, e.Name, "")
+		symbolID, err := batch.GetSymbolID(ctx, pkg.PkgPath, e.Name, "")
 		if err == nil {
 			return symbolID
 		}
 
 	case *ast.SelectorExpr:
-		// Method value: obj.Method or pkg.Func
+		// Method value: obj.Method or pkg.Func, resolved through the type
+		// checker when it knows the selection, e.g. s.handleUsers on a
+		// router struct
+		if pkg.TypesInfo != nil {
+			if fn, ok := pkg.TypesInfo.ObjectOf(e.Sel).(*types.Func); ok {
+				if symbolID := d.funcSymbolID(ctx, fn, batch); symbolID != 0 {
+					return symbolID
+				}
+			}
+		}
 		methodName := e.Sel.Name
 
 		if ident, ok := e.X.(*ast.Ident); ok {
 			// Try as receiver type method
 			recvType := ident.Name
-			symbolID, err := batch.GetSymbolID(pkg.PkgPath, methodName, recvType)
+			symbolID, err := batch.GetSymbolID(ctx, pkg.PkgPath, methodName, recvType)
 			if err == nil {
 				return symbolID
 			}
-			symbolID, err = batch.GetSymbolID(pkg.PkgPath, methodName, "*"+recvType)
+			symbolID, err = batch.GetSymbolID(ctx, pkg.PkgPath, methodName, "*"+recvType)
 			if err == nil {
 				return symbolID
 			}
@@ -439,21 +490,45 @@ func (d *EntrypointDetector) resolveHandlerSymbol(pkg *packages.Package, expr as
 			// Try as package-level function from import
 			importPath := d.getImportPath(nil, ident.Name)
 			if importPath != "" {
-				symbolID, err := batch.GetSymbolID(importPath, methodName, "")
+				symbolID, err := batch.GetSymbolID(ctx, importPath, methodName, "")
 				if err == nil {
 					return symbolID
 				}
 			}
 		}
 
+	case *ast.CallExpr:
+		// Handler factory: s.handleUsers() or handleUsers(db)
+		return d.resolveFactory(ctx, pkg, e, batch, 0)
+
 	case *ast.FuncLit:
-		// Anonymous function - we can't easily track these
-		return 0
+		// Inline handler: the closure's own symbol, e.g. "newServeCmd$1"
+		if c, ok := d.closures[e]; ok {
+			symbolID, err := batch.GetSymbolID(ctx, pkg.PkgPath, c.name, c.recvType)
+			if err == nil {
+				return symbolID
+			}
+		}
 	}
 
 	return 0
 }
 
+// splitHandlerArgs separates the handler from per-route middleware in
+// gin- and echo-style registrations: gin takes the handler last
+// (r.GET(path, auth, h)), echo first (e.GET(path, h, auth)).
+func (d *EntrypointDetector) splitHandlerArgs(ctx context.Context, pkg *packages.Package, args []ast.Expr, batch *store.BatchTx) (ast.Expr, []string) {
+	handler, rest := args[0], args[1:]
+	if len(args) > 1 {
+		// Calls are gin middleware constructors (gin.Logger()), not factories
+		inner, _ := unwrapHandler(pkg, args[0])
+		if _, isCall := inner.(*ast.CallExpr); isCall || d.resolveHandlerSymbol(ctx, pkg, inner, batch) == 0 {
+			handler, rest = args[len(args)-1], args[:len(args)-1]
+		}
+	}
+	return handler, middlewareNames(rest)
+}
+
 // extractStringLiteral extracts a string value from an expression.
 func (d *EntrypointDetector) extractStringLiteral(expr ast.Expr) string {
 	switch e := expr.(type) {
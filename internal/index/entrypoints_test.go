@@ -1,6 +1,7 @@
 package index
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -46,18 +47,18 @@ func main() {
 	defer os.RemoveAll(filepath.Join(tmpDir, ".flowlens"))
 
 	// Extract symbols first
-	if err := loader.ExtractSymbols(st); err != nil {
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
 		t.Fatalf("extracting symbols: %v", err)
 	}
 
 	// Now detect entrypoints
-	batch, err := st.BeginBatch()
+	batch, err := st.BeginBatch(t.Context())
 	if err != nil {
 		t.Fatalf("starting batch: %v", err)
 	}
 
 	detector := NewEntrypointDetector(loader)
-	result, err := detector.Detect(batch)
+	result, err := detector.Detect(t.Context(), batch)
 	if err != nil {
 		batch.Rollback()
 		t.Fatalf("detecting entrypoints: %v", err)
@@ -119,17 +120,17 @@ func main() {
 	defer st.Close()
 	defer os.RemoveAll(filepath.Join(tmpDir, ".flowlens"))
 
-	if err := loader.ExtractSymbols(st); err != nil {
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
 		t.Fatalf("extracting symbols: %v", err)
 	}
 
-	batch, err := st.BeginBatch()
+	batch, err := st.BeginBatch(t.Context())
 	if err != nil {
 		t.Fatalf("starting batch: %v", err)
 	}
 
 	detector := NewEntrypointDetector(loader)
-	result, err := detector.Detect(batch)
+	result, err := detector.Detect(t.Context(), batch)
 	if err != nil {
 		batch.Rollback()
 		t.Fatalf("detecting entrypoints: %v", err)
@@ -144,6 +145,120 @@ func main() {
 	}
 }
 
+// TestEntrypointDetector_RouterStruct tests routes registered in a server
+// struct's routes method, with method values and handler factories.
+func TestEntrypointDetector_RouterStruct(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"server.go": `package main
+
+import "net/http"
+
+type server struct {
+	router *http.ServeMux
+	db     *DB
+}
+
+type DB struct{}
+
+func (s *server) routes() {
+	s.router.HandleFunc("/users", s.handleUsers())
+	s.router.HandleFunc("/health", s.handleHealth)
+	s.router.Handle("/orders", s.requireAuth(s.handleOrders(s.db)))
+}
+
+func main() {
+	s := &server{router: http.NewServeMux()}
+	s.routes()
+}
+`,
+		"handlers.go": `package main
+
+import "net/http"
+
+func (s *server) handleUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {}
+
+func (s *server) handleOrders(db *DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func (s *server) requireAuth(next http.Handler) http.Handler {
+	return next
+}
+`,
+		"go.mod": "module routermod\n\ngo 1.21\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	batch, err := st.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatalf("starting batch: %v", err)
+	}
+	if _, err := NewEntrypointDetector(loader).Detect(t.Context(), batch); err != nil {
+		batch.Rollback()
+		t.Fatalf("detecting entrypoints: %v", err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("committing batch: %v", err)
+	}
+
+	eps, err := st.GetEntrypoints(t.Context(), store.EntrypointFilter{Type: store.EntrypointHTTP})
+	if err != nil {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	got := make(map[string]store.EntrypointWithSymbol)
+	for _, ep := range eps {
+		got[ep.Label] = ep
+	}
+
+	// Factories resolve to the handler they return, not to themselves
+	want := map[string]string{
+		"ANY /users":  "handleUsers$1",
+		"ANY /health": "handleHealth",
+		"ANY /orders": "handleOrders$1",
+	}
+	for label, name := range want {
+		ep, ok := got[label]
+		if !ok {
+			t.Errorf("missing entrypoint %s", label)
+			continue
+		}
+		if ep.Symbol.Name != name || ep.Symbol.RecvType != "*server" {
+			t.Errorf("%s: expected handler (*server).%s, got (%s).%s", label, name, ep.Symbol.RecvType, ep.Symbol.Name)
+		}
+	}
+
+	var meta HTTPMeta
+	if err := json.Unmarshal([]byte(got["ANY /orders"].MetaJSON), &meta); err != nil {
+		t.Fatalf("decoding meta: %v", err)
+	}
+	if len(meta.Middleware) != 1 || meta.Middleware[0] != "s.requireAuth" {
+		t.Errorf("expected middleware [s.requireAuth], got %v", meta.Middleware)
+	}
+}
+
 // TestEntrypointDetector_Chi tests chi router detection.
 func TestEntrypointDetector_Chi(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -205,17 +320,17 @@ require github.com/go-chi/chi/v5 v5.0.10
 	defer st.Close()
 	defer os.RemoveAll(filepath.Join(tmpDir, ".flowlens"))
 
-	if err := loader.ExtractSymbols(st); err != nil {
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
 		t.Fatalf("extracting symbols: %v", err)
 	}
 
-	batch, err := st.BeginBatch()
+	batch, err := st.BeginBatch(t.Context())
 	if err != nil {
 		t.Fatalf("starting batch: %v", err)
 	}
 
 	detector := NewEntrypointDetector(loader)
-	result, err := detector.Detect(batch)
+	result, err := detector.Detect(t.Context(), batch)
 	if err != nil {
 		batch.Rollback()
 		t.Fatalf("detecting entrypoints: %v", err)
@@ -296,17 +411,17 @@ require github.com/spf13/cobra v1.8.0
 	defer st.Close()
 	defer os.RemoveAll(filepath.Join(tmpDir, ".flowlens"))
 
-	if err := loader.ExtractSymbols(st); err != nil {
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
 		t.Fatalf("extracting symbols: %v", err)
 	}
 
-	batch, err := st.BeginBatch()
+	batch, err := st.BeginBatch(t.Context())
 	if err != nil {
 		t.Fatalf("starting batch: %v", err)
 	}
 
 	detector := NewEntrypointDetector(loader)
-	result, err := detector.Detect(batch)
+	result, err := detector.Detect(t.Context(), batch)
 	if err != nil {
 		batch.Rollback()
 		t.Fatalf("detecting entrypoints: %v", err)
@@ -319,6 +434,206 @@ require github.com/spf13/cobra v1.8.0
 	}
 }
 
+// TestEntrypointDetector_CobraHierarchy tests that AddCommand calls spread
+// across files resolve to full command paths.
+func TestEntrypointDetector_CobraHierarchy(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"main.go": `package main
+
+import "github.com/spf13/cobra"
+
+var rootCmd = &cobra.Command{Use: "myapp"}
+
+func main() {
+	rootCmd.AddCommand(newDBCmd(), &cobra.Command{Use: "version", Run: runVersion})
+	rootCmd.Execute()
+}
+
+func runVersion(cmd *cobra.Command, args []string) {}
+`,
+		"db.go": `package main
+
+import "github.com/spf13/cobra"
+
+func newDBCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "db"}
+	cmd.AddCommand(migrateCmd)
+	return cmd
+}
+`,
+		"migrate.go": `package main
+
+import "github.com/spf13/cobra"
+
+var migrateCmd = &cobra.Command{Use: "migrate"}
+
+var migrateUpCmd = &cobra.Command{
+	Use:  "up [steps]",
+	RunE: runMigrateUp,
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateUpCmd)
+}
+
+func runMigrateUp(cmd *cobra.Command, args []string) error { return nil }
+`,
+		"go.mod": `module testmod
+
+go 1.21
+
+require github.com/spf13/cobra v1.8.0
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	cfg := config.Default()
+	loader := NewLoader(cfg, tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Skipf("skipping cobra test, dependency not available: %v", err)
+	}
+
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	batch, err := st.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatalf("starting batch: %v", err)
+	}
+	if _, err := NewEntrypointDetector(loader).Detect(t.Context(), batch); err != nil {
+		batch.Rollback()
+		t.Fatalf("detecting entrypoints: %v", err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("committing batch: %v", err)
+	}
+
+	eps, err := st.GetEntrypoints(t.Context(), store.EntrypointFilter{Type: store.EntrypointCLI})
+	if err != nil {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	metas := make(map[string]CLIMeta)
+	for _, ep := range eps {
+		var meta CLIMeta
+		if err := json.Unmarshal([]byte(ep.MetaJSON), &meta); err != nil {
+			t.Fatalf("decoding meta of %s: %v", ep.Label, err)
+		}
+		metas[ep.Label] = meta
+	}
+
+	up, ok := metas["db migrate up"]
+	if !ok {
+		t.Fatalf("expected entrypoint %q, got %v", "db migrate up", metas)
+	}
+	if up.Command != "db migrate up" || up.Parent != "db migrate" || !up.UsesRunE {
+		t.Errorf("db migrate up meta = %+v", up)
+	}
+	version, ok := metas["version"]
+	if !ok {
+		t.Fatalf("expected entrypoint %q, got %v", "version", metas)
+	}
+	if version.Parent != "" {
+		t.Errorf("version parent = %q, want empty for a top-level command", version.Parent)
+	}
+}
+
+// TestEntrypointDetector_Custom tests entrypoints declared by config rules.
+func TestEntrypointDetector_Custom(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"go.mod": "module custommod\n\ngo 1.21\n",
+		"plugins/billing/billing.go": `package billing
+
+type BillingPlugin struct{}
+
+func (p *BillingPlugin) Run() {}
+
+func (p *BillingPlugin) helper() {}
+`,
+		"jobs/jobs.go": `package jobs
+
+func JobNightly() {}
+
+type Runner struct{}
+
+func (Runner) JobHourly() {}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Entrypoints = []config.EntrypointRule{
+		{Package: "**/plugins/**", Recv: "Plugin$", Name: "^Run$", Label: "plugin {{.Recv}}"},
+		{Package: "custommod/jobs", Name: "^Job", Recv: "^$", Type: "cli"},
+	}
+	loader := NewLoader(cfg, tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	batch, err := st.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatalf("starting batch: %v", err)
+	}
+	result, err := NewEntrypointDetector(loader).Detect(t.Context(), batch)
+	if err != nil {
+		batch.Rollback()
+		t.Fatalf("detecting entrypoints: %v", err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("committing batch: %v", err)
+	}
+	if result.CustomCount != 2 {
+		t.Errorf("expected 2 custom entrypoints, got %d", result.CustomCount)
+	}
+
+	eps, err := st.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	got := make(map[string]store.EntrypointWithSymbol)
+	for _, ep := range eps {
+		got[ep.Label] = ep
+	}
+	if ep, ok := got["plugin BillingPlugin"]; !ok || ep.Type != store.EntrypointCustom || ep.Symbol.Name != "Run" {
+		t.Errorf("expected custom entrypoint for (*BillingPlugin).Run, got %+v", got)
+	}
+	// The default label qualifies the name with the package
+	if ep, ok := got["jobs.JobNightly"]; !ok || ep.Type != store.EntrypointCLI {
+		t.Errorf("expected cli entrypoint jobs.JobNightly, got %+v", got)
+	}
+}
+
 // TestExtractStringLiteral tests string literal extraction.
 func TestExtractStringLiteral(t *testing.T) {
 	tests := []struct {
@@ -357,17 +672,17 @@ func TestEntrypointDetectorOnFlowLens(t *testing.T) {
 	}
 	defer st.Close()
 
-	if err := loader.ExtractSymbols(st); err != nil {
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
 		t.Fatalf("extracting symbols: %v", err)
 	}
 
-	batch, err := st.BeginBatch()
+	batch, err := st.BeginBatch(t.Context())
 	if err != nil {
 		t.Fatalf("starting batch: %v", err)
 	}
 
 	detector := NewEntrypointDetector(loader)
-	result, err := detector.Detect(batch)
+	result, err := detector.Detect(t.Context(), batch)
 	if err != nil {
 		batch.Rollback()
 		t.Fatalf("detecting entrypoints: %v", err)
@@ -383,9 +698,11 @@ func TestEntrypointDetectorOnFlowLens(t *testing.T) {
 	t.Logf("  Total: %d", result.TotalCount)
 
 	// Should have at least main entrypoint
-	// Note: CLI entrypoints require named handler functions, not inline function literals
-	// FlowLens uses inline function literals for RunE, so they won't be detected as CLI entrypoints
 	if result.MainCount < 1 {
 		t.Errorf("expected at least 1 main entrypoint, got %d", result.MainCount)
 	}
+	// FlowLens's commands use inline RunE literals, resolved to closure symbols
+	if result.CLICount < 2 {
+		t.Errorf("expected at least 2 CLI entrypoints, got %d", result.CLICount)
+	}
 }
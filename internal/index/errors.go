@@ -0,0 +1,162 @@
+package index
+
+import (
+	"context"
+	"go/constant"
+	"go/types"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/ssa"
+)
+
+// wrapCalls are functions that add context to an error while keeping it
+// inspectable. fmt.Errorf counts only when its format uses %w.
+var wrapCalls = map[string]bool{
+	"fmt.Errorf":                          true,
+	"github.com/pkg/errors.Wrap":          true,
+	"github.com/pkg/errors.Wrapf":         true,
+	"github.com/pkg/errors.WithMessage":   true,
+	"github.com/pkg/errors.WithMessagef":  true,
+	"github.com/pkg/errors.WithStack":     true,
+	"github.com/cockroachdb/errors.Wrap":  true,
+	"github.com/cockroachdb/errors.Wrapf": true,
+}
+
+// statusCalls are functions that turn an error into an HTTP or gRPC status
+// seen by the client.
+var statusCalls = map[string]bool{
+	"net/http.Error":                                  true,
+	"google.golang.org/grpc/status.Error":             true,
+	"google.golang.org/grpc/status.Errorf":            true,
+	"github.com/gin-gonic/gin.Context.AbortWithError": true,
+	"github.com/labstack/echo/v4.NewHTTPError":        true,
+	"github.com/gofiber/fiber/v2.NewError":            true,
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// errorSites returns the calls in fn that wrap an error, convert one into a
+// status response, or drop a returned error, attributed to the named
+// function containing fn. An error is dropped when the result is discarded
+// or only compared against nil; fmt printing and Write methods are not
+// reported, since their errors are ignored by convention.
+func (b *CallGraphBuilder) errorSites(ctx context.Context, batch *store.BatchTx, fn *ssa.Function) []*store.ErrorSite {
+	var sites []*store.ErrorSite
+	var symbolID store.SymbolID
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			call, ok := instr.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			name := calleeName(&call.Call)
+			if name == "" {
+				continue
+			}
+			var kind string
+			switch {
+			case wrapCalls[name]:
+				if name == "fmt.Errorf" && !formatWraps(call.Call.Args) {
+					continue
+				}
+				kind = store.ErrorWrap
+			case statusCalls[name]:
+				kind = store.ErrorStatus
+			case errorDropped(call) && !ignoredErrorCall(name):
+				kind = store.ErrorSwallow
+			default:
+				continue
+			}
+			if symbolID == 0 {
+				if symbolID, _ = b.lookupSymbolID(ctx, batch, outermost(fn)); symbolID == 0 {
+					return nil
+				}
+			}
+			pos := b.loader.fset.Position(instr.Pos())
+			if !pos.IsValid() {
+				pos = b.loader.fset.Position(fn.Pos())
+			}
+			sites = append(sites, &store.ErrorSite{
+				SymbolID: symbolID,
+				Kind:     kind,
+				Call:     name,
+				File:     pos.Filename,
+				Line:     pos.Line,
+			})
+		}
+	}
+	return sites
+}
+
+// calleeName returns the qualified name of a static or interface callee, or
+// empty string for calls through function values and builtins.
+func calleeName(common *ssa.CallCommon) string {
+	if common.IsInvoke() {
+		return qualifiedFuncName(common.Method)
+	}
+	if callee := common.StaticCallee(); callee != nil {
+		return ssaFuncName(callee)
+	}
+	return ""
+}
+
+// formatWraps reports whether a fmt.Errorf call has a constant format
+// containing the %w verb.
+func formatWraps(args []ssa.Value) bool {
+	if len(args) == 0 {
+		return false
+	}
+	c, ok := args[0].(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.String {
+		return false
+	}
+	return strings.Contains(constant.StringVal(c.Value), "%w")
+}
+
+// errorDropped reports whether call returns an error as its last result and
+// that error is never used beyond comparing it against nil.
+func errorDropped(call *ssa.Call) bool {
+	results := call.Call.Signature().Results()
+	if results.Len() == 0 || !types.Identical(results.At(results.Len()-1).Type(), errorType) {
+		return false
+	}
+	if results.Len() == 1 {
+		return onlyNilChecked(call)
+	}
+	last := results.Len() - 1
+	for _, ref := range *call.Referrers() {
+		if ext, ok := ref.(*ssa.Extract); ok && ext.Index == last {
+			return onlyNilChecked(ext)
+		}
+	}
+	return true
+}
+
+// onlyNilChecked reports whether every use of v is a comparison with nil.
+func onlyNilChecked(v ssa.Value) bool {
+	for _, ref := range *v.Referrers() {
+		op, ok := ref.(*ssa.BinOp)
+		if !ok {
+			return false
+		}
+		other := op.Y
+		if other == v {
+			other = op.X
+		}
+		if c, ok := other.(*ssa.Const); !ok || !c.IsNil() {
+			return false
+		}
+	}
+	return true
+}
+
+// ignoredErrorCall reports whether a dropped error from name is
+// conventional: fmt printing and Write methods.
+func ignoredErrorCall(name string) bool {
+	if strings.HasPrefix(name, "fmt.") {
+		return true
+	}
+	method := name[strings.LastIndex(name, ".")+1:]
+	return strings.HasPrefix(method, "Write")
+}
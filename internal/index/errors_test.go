@@ -0,0 +1,129 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestExtractErrorSites(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package app
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/status"
+)
+
+func load() (string, error) { return "", errors.New("missing") }
+
+func save() error { return nil }
+
+func Wrap() error {
+	if _, err := load(); err != nil {
+		return errors.Wrap(err, "loading")
+	}
+	return nil
+}
+
+func Drop() {
+	save()
+	v, _ := load()
+	print(v)
+}
+
+func Check() bool {
+	if err := save(); err != nil {
+		return false
+	}
+	return true
+}
+
+func Status() error {
+	if err := save(); err != nil {
+		return status.Error(13, err.Error())
+	}
+	return nil
+}
+`
+	errorsSrc := `package errors
+
+type fundamental struct{ msg string }
+
+func (f *fundamental) Error() string { return f.msg }
+
+func New(msg string) error { return &fundamental{msg} }
+
+func Wrap(err error, msg string) error { return &fundamental{msg} }
+`
+	files := map[string]string{
+		"app.go": src,
+		"go.mod": "module app\n\ngo 1.21\n\nrequire (\n\tgithub.com/pkg/errors v0.0.0\n\tgoogle.golang.org/grpc v0.0.0\n)\n\n" +
+			"replace github.com/pkg/errors => ./errors\n\nreplace google.golang.org/grpc => ./grpc\n",
+		"errors/go.mod":      "module github.com/pkg/errors\n\ngo 1.21\n",
+		"errors/errors.go":   errorsSrc,
+		"grpc/go.mod":        "module google.golang.org/grpc\n\ngo 1.21\n",
+		"grpc/status/err.go": "package status\n\nimport \"github.com/pkg/errors\"\n\nfunc Error(code int, msg string) error { return errors.New(msg) }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("creating %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	result, _, err := BuildAndExtract(t.Context(), loader, st, nil)
+	if err != nil {
+		t.Fatalf("building call graph: %v", err)
+	}
+	// Wrap's errors.Wrap, Drop's two discarded errors, Check's nil-only
+	// comparison, and Status's conversion
+	if result.ErrorSites != 5 {
+		t.Errorf("ErrorSites = %d, want 5", result.ErrorSites)
+	}
+
+	want := map[string][]string{
+		"Wrap":   {store.ErrorWrap + " github.com/pkg/errors.Wrap"},
+		"Drop":   {store.ErrorSwallow + " app.save", store.ErrorSwallow + " app.load"},
+		"Check":  {store.ErrorSwallow + " app.save"},
+		"Status": {store.ErrorStatus + " google.golang.org/grpc/status.Error"},
+	}
+	for name, sites := range want {
+		id, err := st.GetSymbolID(t.Context(), "app", name, "")
+		if err != nil {
+			t.Fatalf("getting %s: %v", name, err)
+		}
+		funcs, err := st.GetReachableErrorSites(t.Context(), id)
+		if err != nil {
+			t.Fatalf("getting error sites of %s: %v", name, err)
+		}
+		if len(funcs) != 1 || funcs[0].Symbol.Name != name || funcs[0].Depth != 0 {
+			t.Fatalf("error sites of %s = %+v, want its own sites", name, funcs)
+		}
+		var got []string
+		for _, s := range funcs[0].Sites {
+			got = append(got, s.Kind+" "+s.Call)
+		}
+		if len(got) != len(sites) || got[0] != sites[0] || got[len(got)-1] != sites[len(sites)-1] {
+			t.Errorf("sites of %s = %v, want %v", name, got, sites)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package index
+
+import (
+	"context"
+	"go/ast"
+	"go/types"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/packages"
+)
+
+// maxFactoryDepth bounds how many handler factories returning other
+// factories are followed.
+const maxFactoryDepth = 4
+
+// funcDecl is a function declaration and the package it was loaded from.
+type funcDecl struct {
+	pkg  *packages.Package
+	decl *ast.FuncDecl
+}
+
+// indexFuncDecls maps the functions and methods declared in pkgs to their
+// declarations, so handler factories in other files and packages can be
+// followed.
+func indexFuncDecls(pkgs []*packages.Package) map[*types.Func]funcDecl {
+	decls := make(map[*types.Func]funcDecl)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				if fn, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func); ok {
+					decls[fn] = funcDecl{pkg: pkg, decl: fd}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// calledFunc returns the function or method a call expression calls, or nil
+// for calls through function values and conversions.
+func calledFunc(pkg *packages.Package, call *ast.CallExpr) *types.Func {
+	if pkg.TypesInfo == nil {
+		return nil
+	}
+	var ident *ast.Ident
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fn
+	case *ast.SelectorExpr:
+		ident = fn.Sel
+	default:
+		return nil
+	}
+	f, _ := pkg.TypesInfo.ObjectOf(ident).(*types.Func)
+	return f
+}
+
+// funcSymbolID looks up the symbol of a typed function or method.
+func (d *EntrypointDetector) funcSymbolID(ctx context.Context, fn *types.Func, batch *store.BatchTx) store.SymbolID {
+	if fn.Pkg() == nil {
+		return 0
+	}
+	recvType := ""
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		recvType = formatSSAReceiverType(recv.Type())
+	}
+	symbolID, err := batch.GetSymbolID(ctx, fn.Pkg().Path(), fn.Name(), recvType)
+	if err != nil {
+		return 0
+	}
+	return symbolID
+}
+
+// resolveFactory resolves a handler factory call such as s.handleUsers() or
+// handleUsers(db) to the handler it returns: the returned closure, function,
+// or method, following http.HandlerFunc conversions and nested factories.
+// A factory whose result cannot be traced resolves to itself, so the route
+// still has an entrypoint.
+func (d *EntrypointDetector) resolveFactory(ctx context.Context, pkg *packages.Package, call *ast.CallExpr, batch *store.BatchTx, depth int) store.SymbolID {
+	fn := calledFunc(pkg, call)
+	if fn == nil {
+		return 0
+	}
+	decl, ok := d.funcDecls[fn]
+	if !ok || depth >= maxFactoryDepth {
+		return d.funcSymbolID(ctx, fn, batch)
+	}
+
+	var returned ast.Expr
+	ast.Inspect(decl.decl.Body, func(n ast.Node) bool {
+		if returned != nil {
+			return false
+		}
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false // Returns inside the handler belong to the handler
+		}
+		if ret, ok := n.(*ast.ReturnStmt); ok && len(ret.Results) > 0 {
+			returned = ret.Results[0]
+		}
+		return true
+	})
+	if returned != nil {
+		returned = stripConversion(decl.pkg, returned)
+		var symbolID store.SymbolID
+		if inner, ok := returned.(*ast.CallExpr); ok {
+			symbolID = d.resolveFactory(ctx, decl.pkg, inner, batch, depth+1)
+		} else {
+			symbolID = d.resolveHandlerSymbol(ctx, decl.pkg, returned, batch)
+		}
+		if symbolID != 0 {
+			return symbolID
+		}
+	}
+	return d.funcSymbolID(ctx, fn, batch)
+}
+
+// stripConversion removes type conversions such as
+// http.HandlerFunc(func(w, r) {...}) around a handler expression.
+func stripConversion(pkg *packages.Package, expr ast.Expr) ast.Expr {
+	for {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 || pkg.TypesInfo == nil {
+			return expr
+		}
+		if tv, ok := pkg.TypesInfo.Types[call.Fun]; !ok || !tv.IsType() {
+			return expr
+		}
+		expr = call.Args[0]
+	}
+}
+
+// isHandlerTyped reports whether expr can be an HTTP handler: a function, or
+// a value with a ServeHTTP method. Untyped expressions are assumed to be
+// handlers.
+func isHandlerTyped(pkg *packages.Package, expr ast.Expr) bool {
+	if pkg == nil || pkg.TypesInfo == nil {
+		return true
+	}
+	t := pkg.TypesInfo.TypeOf(expr)
+	if t == nil {
+		return true
+	}
+	if _, ok := t.Underlying().(*types.Signature); ok {
+		return true
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "ServeHTTP")
+	_, ok := obj.(*types.Func)
+	return ok
+}
@@ -0,0 +1,73 @@
+package index
+
+import (
+	"context"
+	"go/constant"
+	"go/types"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/ssa"
+)
+
+// flagUses returns the feature-flag evaluations in fn, as configured under
+// feature_flags, attributed to the named function containing fn. The key is
+// the first string argument when it is a constant, and empty otherwise.
+func (b *CallGraphBuilder) flagUses(ctx context.Context, batch *store.BatchTx, fn *ssa.Function) []*store.FlagUse {
+	cfg := b.loader.cfg
+	if cfg == nil || len(cfg.FeatureFlags.Calls) == 0 {
+		return nil
+	}
+
+	var uses []*store.FlagUse
+	var symbolID store.SymbolID
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			call, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			common := call.Common()
+			name := calleeName(common)
+			if name == "" || !cfg.IsFlagCall(name) {
+				continue
+			}
+			if symbolID == 0 {
+				if symbolID, _ = b.lookupSymbolID(ctx, batch, outermost(fn)); symbolID == 0 {
+					return nil
+				}
+			}
+			pos := b.loader.fset.Position(instr.Pos())
+			if !pos.IsValid() {
+				pos = b.loader.fset.Position(fn.Pos())
+			}
+			uses = append(uses, &store.FlagUse{
+				SymbolID: symbolID,
+				Key:      flagKey(common),
+				Call:     name,
+				File:     pos.Filename,
+				Line:     pos.Line,
+			})
+		}
+	}
+	return uses
+}
+
+// flagKey returns the first string argument of a flag evaluation when it is
+// a constant.
+func flagKey(common *ssa.CallCommon) string {
+	args := common.Args
+	if !common.IsInvoke() && common.Signature().Recv() != nil && len(args) > 0 {
+		args = args[1:]
+	}
+	for _, arg := range args {
+		basic, ok := arg.Type().Underlying().(*types.Basic)
+		if !ok || basic.Info()&types.IsString == 0 {
+			continue
+		}
+		if c, ok := arg.(*ssa.Const); ok && c.Value != nil && c.Value.Kind() == constant.String {
+			return constant.StringVal(c.Value)
+		}
+		return ""
+	}
+	return ""
+}
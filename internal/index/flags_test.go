@@ -0,0 +1,107 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestExtractFlagUses(t *testing.T) {
+	// ld stands in for the LaunchDarkly SDK
+	root := t.TempDir()
+	src := `package main
+
+import ld "github.com/launchdarkly/go-server-sdk/v7"
+
+var client = &ld.LDClient{}
+
+func newCheckout(user string) bool {
+	on, _ := client.BoolVariation("new-checkout", user, false)
+	return on
+}
+
+func variant(flag, user string) string {
+	v, _ := client.StringVariation(flag, user, "control")
+	return v
+}
+
+func checkout(user string) {
+	if newCheckout(user) {
+		variant("checkout-copy", user)
+	}
+}
+
+func browse(user string) {}
+
+func main() {}
+`
+	files := map[string]string{
+		"ld/go.mod": "module github.com/launchdarkly/go-server-sdk/v7\n\ngo 1.21\n",
+		"ld/client.go": "package ldclient\n\ntype LDClient struct{}\n\n" +
+			"func (c *LDClient) BoolVariation(key, user string, def bool) (bool, error) { return def, nil }\n\n" +
+			"func (c *LDClient) StringVariation(key, user, def string) (string, error) { return def, nil }\n",
+		"app/go.mod": "module flagmod\n\ngo 1.21\n\nrequire github.com/launchdarkly/go-server-sdk/v7 v7.0.0\n\n" +
+			"replace github.com/launchdarkly/go-server-sdk/v7 => ../ld\n",
+		"app/main.go": src,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	tmpDir := filepath.Join(root, "app")
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	for _, name := range []string{"checkout", "browse"} {
+		id, err := st.FindSymbolID(t.Context(), "flagmod", name, "")
+		if err != nil {
+			t.Fatalf("finding %s: %v", name, err)
+		}
+		if _, err := st.InsertEntrypoint(t.Context(), &store.Entrypoint{Type: store.EntrypointCLI, Label: name, SymbolID: id}); err != nil {
+			t.Fatalf("inserting entrypoint: %v", err)
+		}
+	}
+
+	result, _, err := BuildAndExtract(t.Context(), loader, st, nil)
+	if err != nil {
+		t.Fatalf("building call graph: %v", err)
+	}
+	if result.FlagUses != 2 {
+		t.Errorf("FlagUses = %d, want 2", result.FlagUses)
+	}
+
+	flags, err := st.GetFeatureFlags(t.Context())
+	if err != nil {
+		t.Fatalf("getting feature flags: %v", err)
+	}
+	// The flag passed to variant is not a constant, so its key is empty
+	if len(flags) != 2 || flags[0].Key != "" || flags[1].Key != "new-checkout" {
+		t.Fatalf("unexpected flags: %+v", flags)
+	}
+	for _, f := range flags {
+		if len(f.Entrypoints) != 1 || f.Entrypoints[0].Label != "checkout" {
+			t.Errorf("entrypoints of %q = %+v, want checkout only", f.Key, f.Entrypoints)
+		}
+	}
+	if use := flags[1].Uses[0]; use.Function != "flagmod.newCheckout" || use.Call != "github.com/launchdarkly/go-server-sdk/v7.LDClient.BoolVariation" {
+		t.Errorf("unexpected use: %+v", use)
+	}
+}
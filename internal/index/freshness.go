@@ -0,0 +1,49 @@
+package index
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// errFoundNewer stops the walk once a newer source file is found.
+var errFoundNewer = errors.New("found newer source")
+
+// NewerSource returns the first Go source file (or go.mod/go.sum) under
+// projectDir modified after since, or "" if the index is up to date.
+// Hidden directories, vendor, testdata and node_modules are skipped, matching
+// what the loader indexes.
+func NewerSource(projectDir string, since time.Time) (string, error) {
+	var newer string
+	err := filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Unreadable entries can't be indexed either
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != projectDir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "vendor" || name == "testdata" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") && name != "go.mod" && name != "go.sum" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(since) {
+			newer = path
+			return errFoundNewer
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errFoundNewer) {
+		return "", err
+	}
+	return newer, nil
+}
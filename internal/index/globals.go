@@ -0,0 +1,94 @@
+package index
+
+import (
+	"context"
+	"go/token"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/ssa"
+)
+
+// globalWrites returns the stores in fn to package-level project variables,
+// directly or through a field, element, or pointer they hold, attributed to
+// the named function containing fn so writes in closures count for their
+// parent. Package initialization is not a write: init functions and the
+// synthetic package initializer are skipped.
+func (b *CallGraphBuilder) globalWrites(ctx context.Context, batch *store.BatchTx, fn *ssa.Function) []*store.GlobalWrite {
+	root := outermost(fn)
+	if root.Synthetic != "" || strings.HasPrefix(root.Name(), "init#") {
+		return nil
+	}
+
+	var writes []*store.GlobalWrite
+	var writerID store.SymbolID
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			var addr ssa.Value
+			switch in := instr.(type) {
+			case *ssa.Store:
+				addr = in.Addr
+			case *ssa.MapUpdate:
+				addr = in.Map
+			default:
+				continue
+			}
+			g := rootGlobal(addr)
+			if g == nil || g.Pkg == nil || !b.projectPkgs[g.Pkg.Pkg.Path()] {
+				continue
+			}
+			varID, err := batch.GetSymbolID(ctx, g.Pkg.Pkg.Path(), g.Name(), "")
+			if err != nil || varID == 0 {
+				continue // Synthetic global such as init$guard, or excluded file
+			}
+			if writerID == 0 {
+				if writerID, _ = b.lookupSymbolID(ctx, batch, root); writerID == 0 {
+					return nil
+				}
+			}
+			pos := b.loader.fset.Position(instr.Pos())
+			if !pos.IsValid() {
+				pos = b.loader.fset.Position(fn.Pos())
+			}
+			writes = append(writes, &store.GlobalWrite{
+				VarID:    varID,
+				WriterID: writerID,
+				File:     pos.Filename,
+				Line:     pos.Line,
+			})
+		}
+	}
+	return writes
+}
+
+// rootGlobal returns the package-level variable an address or map value is
+// derived from, looking through field and element addresses and pointer
+// loads, or nil when it is not derived from one.
+func rootGlobal(v ssa.Value) *ssa.Global {
+	for {
+		switch x := v.(type) {
+		case *ssa.Global:
+			return x
+		case *ssa.FieldAddr:
+			v = x.X
+		case *ssa.IndexAddr:
+			v = x.X
+		case *ssa.UnOp:
+			if x.Op != token.MUL {
+				return nil
+			}
+			v = x.X
+		default:
+			return nil
+		}
+	}
+}
+
+// outermost returns the named function an anonymous function is nested in,
+// or fn itself.
+func outermost(fn *ssa.Function) *ssa.Function {
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	return fn
+}
@@ -0,0 +1,107 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestExtractGlobalWrites(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package app
+
+type Config struct{ Debug bool }
+
+var (
+	counter int
+	cfg     = &Config{}
+	cache   = map[string]int{}
+	names   [4]string
+	local   int
+)
+
+func init() { counter = 1 }
+
+func Inc() { counter++ }
+
+func Reset() {
+	counter = 0
+	names[0] = ""
+}
+
+func SetDebug(v bool) { cfg.Debug = v }
+
+func Remember(k string) {
+	func() { cache[k] = counter }()
+}
+
+func Only() { local = 2 }
+
+func Read() int { return counter }
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "app.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing app.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module app\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	result, _, err := BuildAndExtract(t.Context(), loader, st, nil)
+	if err != nil {
+		t.Fatalf("building call graph: %v", err)
+	}
+	// Inc, Reset (x2), SetDebug, the closure in Remember, Only; not init
+	if result.GlobalWrites != 6 {
+		t.Errorf("GlobalWrites = %d, want 6", result.GlobalWrites)
+	}
+
+	vars, err := st.GetGlobalVars(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("getting global vars: %v", err)
+	}
+	writers := make(map[string][]string)
+	for _, v := range vars {
+		for _, w := range v.Writers {
+			writers[v.Symbol.Name] = append(writers[v.Symbol.Name], w.Symbol.Name)
+		}
+	}
+	want := map[string][]string{
+		"counter": {"Inc", "Reset"},
+		"names":   {"Reset"},
+		"cfg":     {"SetDebug"},
+		"cache":   {"Remember"},
+		"local":   {"Only"},
+	}
+	if len(writers) != len(want) {
+		t.Errorf("written vars = %v, want %v", writers, want)
+	}
+	for name, ws := range want {
+		if got := writers[name]; len(got) != len(ws) || got[0] != ws[0] || got[len(got)-1] != ws[len(ws)-1] {
+			t.Errorf("writers of %s = %v, want %v", name, got, ws)
+		}
+	}
+
+	// Only counter is written from more than one function, and it comes first
+	shared, err := st.GetGlobalVars(t.Context(), 2)
+	if err != nil {
+		t.Fatalf("getting shared global vars: %v", err)
+	}
+	if len(shared) != 1 || shared[0].Symbol.Name != "counter" || shared[0].PackageCount != 1 {
+		t.Errorf("shared vars = %+v, want counter written from one package", shared)
+	}
+}
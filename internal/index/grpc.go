@@ -0,0 +1,227 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// GRPCCoverageResult holds the results of gRPC coverage extraction.
+type GRPCCoverageResult struct {
+	Services      int // Generated service interfaces found
+	Unimplemented int // Service methods no registered type declares
+	Unregistered  int // Implementing types never passed to a RegisterXServer function
+}
+
+// grpcService is a service interface generated by protoc-gen-go-grpc with
+// its registration function.
+type grpcService struct {
+	name     string // e.g. "UserService"
+	pkgPath  string
+	iface    *types.Interface
+	methods  []string // Exported methods, in interface order
+	register *types.Func
+}
+
+// ExtractGRPCCoverage records, for every generated gRPC service interface
+// the project uses, which project types implement each method and whether
+// they are registered. A service is recognized by its RegisterXServer
+// function taking the XServer interface, so the generated files need not be
+// indexed. A method is implemented by a type that declares it rather than
+// inheriting it from the embedded UnimplementedXServer stub, which returns
+// codes.Unimplemented.
+func ExtractGRPCCoverage(ctx context.Context, loader *Loader, st *store.Store) (*GRPCCoverageResult, error) {
+	services := findGRPCServices(loader.Packages())
+	result := &GRPCCoverageResult{Services: len(services)}
+	if len(services) == 0 {
+		return result, nil
+	}
+
+	batch, err := st.BeginBatch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("starting batch: %w", err)
+	}
+	defer batch.Rollback()
+
+	registered := findGRPCRegistrations(loader.Packages(), services)
+	repo := loader.cfg.Repo
+	for _, svc := range services {
+		served := make(map[string]bool)
+		impls := 0
+		for _, pkg := range loader.Packages() {
+			if pkg.Types == nil {
+				continue
+			}
+			scope := pkg.Types.Scope()
+			for _, name := range scope.Names() {
+				tn, ok := scope.Lookup(name).(*types.TypeName)
+				if !ok || tn.IsAlias() || (pkg.PkgPath == svc.pkgPath && isGRPCStub(name)) {
+					continue
+				}
+				named, ok := tn.Type().(*types.Named)
+				if !ok || types.IsInterface(named) || named.TypeParams().Len() > 0 {
+					continue
+				}
+				if !types.Implements(named, svc.iface) && !types.Implements(types.NewPointer(named), svc.iface) {
+					continue
+				}
+				typeID, err := batch.GetSymbolID(ctx, pkg.PkgPath, name, "")
+				if err != nil || typeID == 0 {
+					continue // Excluded file
+				}
+				impls++
+				isRegistered := registered[svc.register][named]
+				if !isRegistered {
+					result.Unregistered++
+				}
+				for _, method := range svc.methods {
+					implemented := declaresGRPCMethod(named, method)
+					if implemented && isRegistered {
+						served[method] = true
+					}
+					if err := batch.InsertGRPCMethod(ctx, &store.GRPCMethod{
+						PkgPath: svc.pkgPath, Service: svc.name, Method: method, TypeID: typeID,
+						Registered: isRegistered, Implemented: implemented, Repo: repo,
+					}); err != nil {
+						return nil, fmt.Errorf("inserting %s/%s: %w", svc.name, method, err)
+					}
+				}
+			}
+		}
+		if impls == 0 {
+			for _, method := range svc.methods {
+				if err := batch.InsertGRPCMethod(ctx, &store.GRPCMethod{
+					PkgPath: svc.pkgPath, Service: svc.name, Method: method, Repo: repo,
+				}); err != nil {
+					return nil, fmt.Errorf("inserting %s/%s: %w", svc.name, method, err)
+				}
+			}
+		}
+		result.Unimplemented += len(svc.methods) - len(served)
+	}
+
+	if err := batch.Commit(); err != nil {
+		return nil, fmt.Errorf("committing batch: %w", err)
+	}
+	return result, nil
+}
+
+// findGRPCServices returns the service interfaces declared in the project
+// packages or the packages they import, ordered by package and name.
+func findGRPCServices(pkgs []*packages.Package) []*grpcService {
+	seen := make(map[string]bool)
+	var services []*grpcService
+	visit := func(pkg *types.Package) {
+		if pkg == nil || seen[pkg.Path()] {
+			return
+		}
+		seen[pkg.Path()] = true
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			fn, ok := scope.Lookup(name).(*types.Func)
+			if !ok || !strings.HasPrefix(name, "Register") || !strings.HasSuffix(name, "Server") {
+				continue
+			}
+			service := strings.TrimSuffix(strings.TrimPrefix(name, "Register"), "Server")
+			params := fn.Type().(*types.Signature).Params()
+			tn, ok := scope.Lookup(service + "Server").(*types.TypeName)
+			if !ok || params.Len() != 2 || !types.Identical(params.At(1).Type(), tn.Type()) {
+				continue
+			}
+			iface, ok := tn.Type().Underlying().(*types.Interface)
+			if !ok {
+				continue
+			}
+			svc := &grpcService{name: service, pkgPath: pkg.Path(), iface: iface, register: fn}
+			for i := 0; i < iface.NumMethods(); i++ {
+				if m := iface.Method(i); m.Exported() {
+					svc.methods = append(svc.methods, m.Name())
+				}
+			}
+			services = append(services, svc)
+		}
+	}
+	for _, pkg := range pkgs {
+		visit(pkg.Types)
+		for _, imp := range pkg.Imports {
+			visit(imp.Types)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].pkgPath != services[j].pkgPath {
+			return services[i].pkgPath < services[j].pkgPath
+		}
+		return services[i].name < services[j].name
+	})
+	return services
+}
+
+// findGRPCRegistrations returns, for each service registration function,
+// the concrete types passed to it anywhere in the project.
+func findGRPCRegistrations(pkgs []*packages.Package, services []*grpcService) map[*types.Func]map[*types.Named]bool {
+	registers := make(map[*types.Func]bool)
+	for _, svc := range services {
+		registers[svc.register] = true
+	}
+	registered := make(map[*types.Func]map[*types.Named]bool)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || len(call.Args) != 2 {
+					return true
+				}
+				fn, ok := typeutil.Callee(pkg.TypesInfo, call).(*types.Func)
+				if !ok || !registers[fn] {
+					return true
+				}
+				t := pkg.TypesInfo.TypeOf(call.Args[1])
+				if ptr, ok := t.(*types.Pointer); ok {
+					t = ptr.Elem()
+				}
+				if named, ok := t.(*types.Named); ok {
+					if registered[fn] == nil {
+						registered[fn] = make(map[*types.Named]bool)
+					}
+					registered[fn][named] = true
+				}
+				return true
+			})
+		}
+	}
+	return registered
+}
+
+// declaresGRPCMethod reports whether a type's method comes from the type
+// itself, or an embedded type other than the generated stub.
+func declaresGRPCMethod(named *types.Named, method string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), false, named.Obj().Pkg(), method)
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	recv := fn.Type().(*types.Signature).Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	if recvNamed, ok := recv.(*types.Named); ok {
+		return !isGRPCStub(recvNamed.Obj().Name())
+	}
+	return true
+}
+
+// isGRPCStub reports whether a generated type is a stub rather than an
+// implementation: UnimplementedXServer or UnsafeXServer.
+func isGRPCStub(name string) bool {
+	return strings.HasPrefix(name, "Unimplemented") || strings.HasPrefix(name, "Unsafe")
+}
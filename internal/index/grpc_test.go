@@ -0,0 +1,138 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestExtractGRPCCoverage(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module shop\n\ngo 1.21\n",
+		// Shaped like protoc-gen-go-grpc output, minus the grpc imports;
+		// excluded from indexing by the default *.pb.go glob
+		"pb/orders_grpc.pb.go": `package pb
+
+type ServiceRegistrar interface{}
+
+type OrdersServer interface {
+	GetOrder(id int) (int, error)
+	CancelOrder(id int) error
+	mustEmbedUnimplementedOrdersServer()
+}
+
+type UnimplementedOrdersServer struct{}
+
+func (UnimplementedOrdersServer) GetOrder(int) (int, error) { return 0, nil }
+func (UnimplementedOrdersServer) CancelOrder(int) error     { return nil }
+func (UnimplementedOrdersServer) mustEmbedUnimplementedOrdersServer() {}
+
+func RegisterOrdersServer(s ServiceRegistrar, srv OrdersServer) {}
+
+type PaymentsServer interface {
+	Charge(amount int) error
+	mustEmbedUnimplementedPaymentsServer()
+}
+
+type UnimplementedPaymentsServer struct{}
+
+func (UnimplementedPaymentsServer) Charge(int) error                       { return nil }
+func (UnimplementedPaymentsServer) mustEmbedUnimplementedPaymentsServer() {}
+
+func RegisterPaymentsServer(s ServiceRegistrar, srv PaymentsServer) {}
+`,
+		// Server implements GetOrder only and is registered; LegacyServer
+		// implements both but never is
+		"server/server.go": `package server
+
+import "shop/pb"
+
+type Server struct {
+	pb.UnimplementedOrdersServer
+}
+
+func (s *Server) GetOrder(id int) (int, error) { return id, nil }
+
+type LegacyServer struct {
+	pb.UnimplementedOrdersServer
+}
+
+func (LegacyServer) GetOrder(id int) (int, error) { return id, nil }
+func (LegacyServer) CancelOrder(id int) error     { return nil }
+
+func Register(r pb.ServiceRegistrar) {
+	pb.RegisterOrdersServer(r, &Server{})
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	result, err := ExtractGRPCCoverage(t.Context(), loader, st)
+	if err != nil {
+		t.Fatalf("extracting gRPC coverage: %v", err)
+	}
+	if *result != (GRPCCoverageResult{Services: 2, Unimplemented: 2, Unregistered: 1}) {
+		t.Errorf("unexpected result %+v", *result)
+	}
+
+	coverage, err := st.GetGRPCCoverage(t.Context())
+	if err != nil {
+		t.Fatalf("getting gRPC coverage: %v", err)
+	}
+	if len(coverage) != 2 {
+		t.Fatalf("expected 2 services, got %+v", coverage)
+	}
+
+	orders := coverage[0]
+	if orders.Service != "Orders" || orders.PkgPath != "shop/pb" {
+		t.Fatalf("expected shop/pb.Orders first, got %s.%s", orders.PkgPath, orders.Service)
+	}
+	if !reflect.DeepEqual(orders.Methods, []string{"CancelOrder", "GetOrder"}) {
+		t.Errorf("unexpected methods %v", orders.Methods)
+	}
+	// LegacyServer implements CancelOrder, but isn't registered
+	if !reflect.DeepEqual(orders.Unimplemented, []string{"CancelOrder"}) {
+		t.Errorf("expected CancelOrder to be unimplemented, got %v", orders.Unimplemented)
+	}
+	if len(orders.Implementations) != 2 {
+		t.Fatalf("expected 2 implementations, got %+v", orders.Implementations)
+	}
+	legacy, server := orders.Implementations[0], orders.Implementations[1]
+	if legacy.Type.Name != "LegacyServer" || legacy.Registered || len(legacy.Missing) != 0 {
+		t.Errorf("unexpected LegacyServer coverage %+v", legacy)
+	}
+	if server.Type.Name != "Server" || !server.Registered || !reflect.DeepEqual(server.Missing, []string{"CancelOrder"}) {
+		t.Errorf("unexpected Server coverage %+v", server)
+	}
+
+	payments := coverage[1]
+	if payments.Service != "Payments" || len(payments.Implementations) != 0 || !reflect.DeepEqual(payments.Unimplemented, []string{"Charge"}) {
+		t.Errorf("expected Payments to have no implementation, got %+v", payments)
+	}
+}
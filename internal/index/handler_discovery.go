@@ -1,6 +1,7 @@
 package index
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"go/types"
@@ -53,12 +54,12 @@ type DiscoverResult struct {
 }
 
 // Discover scans all SSA functions for HTTP handler signatures.
-func (hd *HandlerDiscovery) Discover(batch *store.BatchTx) (*DiscoverResult, error) {
+func (hd *HandlerDiscovery) Discover(ctx context.Context, batch *store.BatchTx) (*DiscoverResult, error) {
 	result := &DiscoverResult{}
 
 	// Get existing HTTP entrypoint symbol IDs to avoid duplicates
 	existingSymbols := make(map[store.SymbolID]bool)
-	existing, err := hd.getExistingHTTPEntrypoints(batch)
+	existing, err := hd.getExistingHTTPEntrypoints(ctx, batch)
 	if err != nil {
 		return nil, fmt.Errorf("getting existing entrypoints: %w", err)
 	}
@@ -93,7 +94,7 @@ func (hd *HandlerDiscovery) Discover(batch *store.BatchTx) (*DiscoverResult, err
 		}
 
 		// Look up the symbol ID
-		symbolID, err := batch.GetSymbolID(pkgPath, fn.Name(), recvType)
+		symbolID, err := batch.GetSymbolID(ctx, pkgPath, fn.Name(), recvType)
 		if err != nil {
 			continue // Symbol not found in DB
 		}
@@ -134,7 +135,7 @@ func (hd *HandlerDiscovery) Discover(batch *store.BatchTx) (*DiscoverResult, err
 			DiscoveryMethod: "signature",
 		}
 
-		if err := batch.InsertEntrypoint(ep); err != nil {
+		if err := batch.InsertEntrypoint(ctx, ep); err != nil {
 			continue // Skip on error
 		}
 
@@ -348,8 +349,8 @@ func (hd *HandlerDiscovery) isEchoContext(t types.Type) bool {
 }
 
 // getExistingHTTPEntrypoints returns the symbol IDs of existing HTTP entrypoints.
-func (hd *HandlerDiscovery) getExistingHTTPEntrypoints(batch *store.BatchTx) ([]store.SymbolID, error) {
-	return batch.GetHTTPEntrypointSymbolIDs()
+func (hd *HandlerDiscovery) getExistingHTTPEntrypoints(ctx context.Context, batch *store.BatchTx) ([]store.SymbolID, error) {
+	return batch.GetHTTPEntrypointSymbolIDs(ctx)
 }
 
 // isExported checks if a Go identifier is exported (starts with uppercase).
@@ -0,0 +1,72 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/ssa"
+)
+
+// handlerTypes are the entrypoints serving requests.
+var handlerTypes = []store.EntrypointType{store.EntrypointHTTP, store.EntrypointGRPC}
+
+// projectPackages is the set of packages loaded for the project. The
+// entrypoint analyzers summarize the functions in these packages and treat
+// calls anywhere else as opaque.
+type projectPackages map[string]bool
+
+func newProjectPackages(loader *Loader) projectPackages {
+	pkgs := make(projectPackages, len(loader.pkgs))
+	for _, pkg := range loader.pkgs {
+		pkgs[pkg.PkgPath] = true
+	}
+	return pkgs
+}
+
+// follows reports whether callee is a project function with a body, whose
+// summary an analyzer merges into its caller's.
+func (p projectPackages) follows(callee *ssa.Function) bool {
+	return callee != nil && len(callee.Blocks) > 0 && callee.Pkg != nil && p[callee.Pkg.Pkg.Path()]
+}
+
+// forEachHandler calls visit for each entrypoint of the given types (every
+// type when none are given) whose handler has an SSA body, in one batch
+// committed once all are visited. It stops without committing at the first
+// error visit returns, or when ctx ends.
+func forEachHandler(ctx context.Context, st *store.Store, prog *ssa.Program, types []store.EntrypointType,
+	visit func(batch *store.BatchTx, ep *store.EntrypointWithSymbol, fn *ssa.Function) error) error {
+	eps, err := st.GetEntrypoints(ctx, store.EntrypointFilter{})
+	if err != nil {
+		return fmt.Errorf("getting entrypoints: %w", err)
+	}
+
+	batch, err := st.BeginBatch(ctx)
+	if err != nil {
+		return fmt.Errorf("starting batch: %w", err)
+	}
+	defer batch.Rollback()
+
+	for i := range eps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ep := &eps[i]
+		if len(types) > 0 && !slices.Contains(types, ep.Type) {
+			continue
+		}
+		fn := findSSAFunction(prog, &ep.Symbol)
+		if fn == nil || len(fn.Blocks) == 0 {
+			continue
+		}
+		if err := visit(batch, ep, fn); err != nil {
+			return err
+		}
+	}
+
+	if err := batch.Commit(); err != nil {
+		return fmt.Errorf("committing batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// ChangedFiles lists the files under projectDir that differ from ref in git:
+// committed and uncommitted changes plus untracked files. Paths are absolute.
+func ChangedFiles(ctx context.Context, projectDir, ref string) ([]string, error) {
+	// --relative limits the diff to projectDir and reports paths relative to
+	// it, like ls-files does
+	diff, err := gitLines(ctx, projectDir, "diff", "--name-only", "--no-renames", "--relative", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitLines(ctx, projectDir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(diff)+len(untracked))
+	for _, name := range append(diff, untracked...) {
+		files = append(files, filepath.Join(projectDir, filepath.FromSlash(name)))
+	}
+	return files, nil
+}
+
+// gitLines runs git in dir and returns the non-empty lines of its output.
+func gitLines(ctx context.Context, dir string, args ...string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// changedPackages maps changed files to the packages that must be
+// re-extracted. A file belongs to the loaded package that contains it, or,
+// when deleted, to the loaded or previously stored package in its directory.
+// Test files are skipped since the loader does not index them.
+func changedPackages(loader *Loader, stored []store.Package, files []string) map[string]bool {
+	byDir := make(map[string]string)
+	for _, pkg := range stored {
+		if pkg.Dir != "" {
+			byDir[pkg.Dir] = pkg.PkgPath
+		}
+	}
+	for _, pkg := range loader.Packages() {
+		if dir := packageDir(pkg); dir != "" {
+			byDir[dir] = pkg.PkgPath
+		}
+	}
+
+	scope := make(map[string]bool)
+	for _, file := range files {
+		if pkg := loader.GetPackageForFile(file); pkg != nil {
+			scope[pkg.PkgPath] = true
+			continue
+		}
+		if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		if pkgPath, ok := byDir[filepath.Dir(file)]; ok {
+			scope[pkgPath] = true
+		}
+	}
+	return scope
+}
+
+// fullIndexReason explains why an incremental run must fall back to a full
+// index, or returns "" if the stored index can be reused.
+func (idx *Indexer) fullIndexReason(ctx context.Context, st *store.Store, prev *store.IndexSnapshot, changed []string) string {
+	if len(prev.Symbols) == 0 {
+		return "no previous index"
+	}
+	if status, _ := st.GetMetadata(ctx, "index_status"); status != "complete" {
+		return "previous index did not complete"
+	}
+	if v, _ := st.GetMetadata(ctx, "schema_version"); v != strconv.Itoa(store.SchemaVersion) {
+		return "index schema changed"
+	}
+	if deps, _ := st.GetMetadata(ctx, "dependencies_indexed"); deps != strconv.FormatBool(idx.cfg.Dependencies.Index) {
+		return "dependency indexing setting changed"
+	}
+	if kept, _ := st.GetMetadata(ctx, "wrappers_kept"); kept != strconv.FormatBool(idx.cfg.Wrappers.Keep) {
+		return "wrapper setting changed"
+	}
+	if modules, _ := st.GetMetadata(ctx, "project_modules"); modules != strings.Join(idx.cfg.ProjectModules, ",") {
+		return "project modules changed"
+	}
+	for _, file := range changed {
+		if name := filepath.Base(file); name == "go.mod" || name == "go.sum" {
+			return name + " changed"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package index
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestIncrementalIndex(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	write("go.mod", "module incmod\n\ngo 1.21\n")
+	write(".gitignore", ".flowlens/\n")
+	write("main.go", `package main
+
+import "incmod/svc"
+
+func main() { svc.Do() }
+`)
+	write("svc/svc.go", `package svc
+
+func Do() { helper() }
+
+func helper() {}
+`)
+	write("util/util.go", `package util
+
+func Format() string { return "" }
+`)
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	run := func(since string) *Result {
+		indexer := NewIndexer(config.Default(), dir)
+		indexer.SetSince(since)
+		result, err := indexer.Run(t.Context())
+		if err != nil {
+			t.Fatalf("indexing since %q: %v", since, err)
+		}
+		return result
+	}
+
+	// Without a previous index the first run is a full one
+	if result := run("HEAD"); result.Since != "" {
+		t.Errorf("expected a full index without a previous one, got since %q", result.Since)
+	}
+
+	st, err := store.Open(dir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	find := func(pkg, name string) store.SymbolID {
+		id, _ := st.FindSymbolID(t.Context(), pkg, name, "")
+		return id
+	}
+	doID, formatID := find("incmod/svc", "Do"), find("incmod/util", "Format")
+
+	// svc changes and gains an untracked file; main and util are reused
+	write("svc/svc.go", `package svc
+
+func Do() { extra() }
+
+func extra() {}
+`)
+	write("svc/more.go", `package svc
+
+func More() {}
+`)
+	result := run("HEAD")
+	// Each run renames a new index over the one st opened
+	if _, err := st.Reopen(); err != nil {
+		t.Fatalf("reopening store: %v", err)
+	}
+	if result.Since != "HEAD" || result.ChangedPackages != 1 {
+		t.Errorf("expected 1 package changed since HEAD, got %d since %q", result.ChangedPackages, result.Since)
+	}
+	if find("incmod/svc", "Do") != doID || find("incmod/util", "Format") != formatID {
+		t.Error("expected symbol IDs to survive an incremental run")
+	}
+	if find("incmod/svc", "helper") != 0 {
+		t.Error("expected the removed helper to be pruned")
+	}
+	if find("incmod/svc", "More") == 0 {
+		t.Error("expected the untracked file's symbols to be indexed")
+	}
+
+	callees, err := st.GetCallees(t.Context(), doID)
+	if err != nil {
+		t.Fatalf("getting callees: %v", err)
+	}
+	if len(callees) != 1 || callees[0].Symbol.Name != "extra" {
+		t.Errorf("expected Do to call only extra, got %+v", callees)
+	}
+	callers, err := st.GetCallers(t.Context(), doID)
+	if err != nil {
+		t.Fatalf("getting callers: %v", err)
+	}
+	if len(callers) != 1 || callers[0].Symbol.Name != "main" {
+		t.Errorf("expected the unchanged main to still call Do, got %+v", callers)
+	}
+
+	// A deleted package is dropped from the index
+	if err := os.RemoveAll(filepath.Join(dir, "util")); err != nil {
+		t.Fatal(err)
+	}
+	if result := run("HEAD"); result.ChangedPackages != 2 {
+		t.Errorf("expected svc and util to be re-extracted, got %d", result.ChangedPackages)
+	}
+	if _, err := st.Reopen(); err != nil {
+		t.Fatalf("reopening store: %v", err)
+	}
+	if find("incmod/util", "Format") != 0 {
+		t.Error("expected the deleted package's symbols to be removed")
+	}
+	pkgs, err := st.GetPackages(t.Context())
+	if err != nil {
+		t.Fatalf("getting packages: %v", err)
+	}
+	for _, p := range pkgs {
+		if p.PkgPath == "incmod/util" {
+			t.Error("expected the deleted package to be removed")
+		}
+	}
+
+	indexer := NewIndexer(config.Default(), dir)
+	indexer.SetSince("no-such-ref")
+	if _, err := indexer.Run(t.Context()); err == nil {
+		t.Error("expected an unknown ref to fail")
+	}
+}
@@ -1,8 +1,13 @@
 package index
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/abramin/flowlens/internal/config"
@@ -15,6 +20,9 @@ type Indexer struct {
 	projectDir string
 	store      *store.Store
 	loader     *Loader
+	since      string    // Git ref for incremental indexing; empty for a full index
+	force      bool      // Take over the index lock of another run
+	out        io.Writer // Progress output
 }
 
 // NewIndexer creates a new indexer for the given project directory.
@@ -26,9 +34,29 @@ func NewIndexer(cfg *config.Config, projectDir string) *Indexer {
 	return &Indexer{
 		cfg:        cfg,
 		projectDir: absPath,
+		out:        os.Stdout,
 	}
 }
 
+// SetSince makes the next run incremental: only packages with files changed
+// since the git ref are re-extracted, and stored data is reused for the rest.
+// Entrypoints, tags, and findings are always rebuilt. The run falls back to a
+// full index when there is no usable previous index or go.mod/go.sum changed.
+func (idx *Indexer) SetSince(ref string) {
+	idx.since = ref
+}
+
+// SetForce makes the next run take over the index even if another run
+// holds its lock, e.g. one left by a process on another host.
+func (idx *Indexer) SetForce(force bool) {
+	idx.force = force
+}
+
+// SetOutput sends progress messages to w instead of standard output.
+func (idx *Indexer) SetOutput(w io.Writer) {
+	idx.out = w
+}
+
 // Result holds the results of an indexing run.
 type Result struct {
 	PackageCount          int
@@ -38,6 +66,17 @@ type Result struct {
 	InterfaceCalls        int
 	DeferCalls            int
 	GoCalls               int
+	ExternalCalls         int // Calls into third-party modules (dependency indexing only)
+	CrossRepoEdges        int // Call edges resolved to other repositories in a shared index
+	ManualEdges           int // User-asserted call edges (see POST /api/edges)
+	Interfaces            int // Project interfaces
+	Implementations       int // Interface-type pairs where a project type satisfies an interface
+	TypeFields            int // Named struct fields recorded as type relations
+	TypeEmbeddings        int // Embedded fields and interfaces recorded as type relations
+	ConstReferences       int // Uses of project constants
+	GlobalWrites          int // Stores to package-level variables from scoped packages
+	ErrorSites            int // Calls that wrap, drop, or convert errors
+	FlagUses              int // Feature-flag evaluations
 	EntrypointCount       int
 	HTTPEntrypoints       int
 	HTTPByRouter          int // HTTP handlers discovered via router parsing
@@ -45,118 +84,516 @@ type Result struct {
 	GRPCEntrypoints       int
 	CLIEntrypoints        int
 	MainEntrypoints       int
+	CustomEntrypoints     int // Declared by entrypoints rules in the config
 	TagCount              int
 	IOTags                int
+	DerivedIOTags         int // io:<category>@N tags on callers of I/O functions
 	LayerTags             int
 	PurityTags            int
+	TaintFindings         int
+	MissingAuth           int // HTTP entrypoints with no auth middleware or check
+	UnrecoveredPanics     int // HTTP and gRPC entrypoints reaching a panic that is not recovered
+	StatusCodeEntrypoints int // HTTP and gRPC entrypoints with known response status codes
+	RequestTypes          int // HTTP entrypoints with an inferred request type
+	ResponseTypes         int // HTTP entrypoints with inferred response types
+	TimeoutEntrypoints    int // Entrypoints with a timeout along their flow
+	RetryEntrypoints      int // Entrypoints with a retried call along their flow
+	Diagnostics           int // Package loading errors (see flowlens doctor)
+	UnresolvedCalls       int // Call sites that produced no edge
+	SkippedFunctions      int // Functions without a symbol whose calls were dropped
+	Since                 string // Git ref of an incremental run; empty for a full index
+	ChangedPackages       int // Packages re-extracted by an incremental run
+	Changes               *ChangeSummary // Nil on the first run (nothing to compare against)
+	Phases                []PhaseStats   // Time and memory spent in each phase, in run order
 	Duration              time.Duration
 	DBPath                string
 }
 
 // Run executes the indexing pipeline.
-func (idx *Indexer) Run() (*Result, error) {
+// Progress is recorded in the index_status metadata key ("running",
+// "complete", or "failed") so readers such as the UI server can tell when a
+// re-index is under way.
+func (idx *Indexer) Run(ctx context.Context) (result *Result, err error) {
 	start := time.Now()
+	var phases phaseTimer
+	phases.begin("open")
+
+	// Only one run may write an index at a time
+	dbPath := idx.cfg.DatabasePath(idx.projectDir)
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("creating index directory: %w", err)
+	}
+	unlock, err := acquireLock(dbPath, idx.force)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
 	// Open (or create) the store
-	st, err := store.Open(idx.projectDir)
+	key, err := config.IndexKey()
+	if err != nil {
+		return nil, err
+	}
+	st, err := store.OpenEncrypted(dbPath, idx.projectDir, key, false)
 	if err != nil {
 		return nil, fmt.Errorf("opening store: %w", err)
 	}
 	defer st.Close()
 	idx.store = st
 
-	// Clear existing data for fresh index
-	if err := st.Clear(); err != nil {
-		return nil, fmt.Errorf("clearing store: %w", err)
+	// Snapshot the previous index so this run's changes can be reported
+	prevSnapshot, err := st.LoadSnapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading previous snapshot: %w", err)
+	}
+	prevIndexedAt, _ := st.GetMetadata(ctx, "indexed_at")
+	vcs := ReadVCSInfo(ctx, idx.projectDir)
+
+	// An incremental run needs the changed files and a reusable index
+	var changed []string
+	incremental := false
+	if idx.since != "" {
+		changed, err = ChangedFiles(ctx, idx.projectDir, idx.since)
+		if err != nil {
+			return nil, fmt.Errorf("listing changes since %s: %w", idx.since, err)
+		}
+		if reason := idx.fullIndexReason(ctx, st, prevSnapshot, changed); reason != "" {
+			fmt.Fprintf(idx.out, "Running a full index: %s\n", reason)
+		} else {
+			incremental = true
+		}
+	}
+
+	// Mark the run for readers such as the UI server
+	if err := st.SetMetadata(ctx, "index_status", "running"); err != nil {
+		return nil, fmt.Errorf("storing metadata: %w", err)
+	}
+	if err := st.SetMetadata(ctx, "index_started_at", start.Format(time.RFC3339Nano)); err != nil {
+		return nil, fmt.Errorf("storing metadata: %w", err)
+	}
+
+	// Build the new index in a copy of the current one, written in a single
+	// transaction and renamed into place only when the run succeeds, so a
+	// failed or interrupted run leaves the previous index intact and servable
+	tmpPath := st.DBPath() + ".tmp"
+	var work, run *store.Store
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Discard the partial index, then record the failure even if ctx
+		// was cancelled
+		if run != nil {
+			run.Rollback()
+		}
+		if work != nil {
+			work.Close()
+		}
+		store.RemoveFiles(tmpPath)
+		failCtx := context.WithoutCancel(ctx)
+		st.SetMetadata(failCtx, "index_status", "failed")
+		st.SetMetadata(failCtx, "index_error", err.Error())
+	}()
+	// Clean up after a run that was killed before it could
+	if err := store.RemoveFiles(tmpPath); err != nil {
+		return nil, fmt.Errorf("removing stale index copy: %w", err)
+	}
+	if err := st.CopyTo(ctx, tmpPath); err != nil {
+		return nil, err
+	}
+	work, err = store.OpenFile(tmpPath, idx.projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening index copy: %w", err)
+	}
+	run, err = work.BeginRun(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("starting run: %w", err)
+	}
+
+	// Clear existing data for fresh index; in a shared index only this
+	// repository's data is replaced. Incremental runs keep symbols and calls
+	if incremental {
+		if err := run.ClearAnalysis(ctx, idx.cfg.Repo); err != nil {
+			return nil, fmt.Errorf("clearing analysis: %w", err)
+		}
+	} else if idx.cfg.Repo != "" {
+		if err := run.ClearRepo(ctx, idx.cfg.Repo); err != nil {
+			return nil, fmt.Errorf("clearing repo %s: %w", idx.cfg.Repo, err)
+		}
+	} else {
+		// Refuse to wipe other repositories out of a shared index
+		repos, err := run.GetRepos(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing repos: %w", err)
+		}
+		for _, r := range repos {
+			if r.Name != "" {
+				return nil, fmt.Errorf("%s is shared by named repositories; set a repo name (--repo) to index into it", st.DBPath())
+			}
+		}
+		if err := run.Clear(ctx); err != nil {
+			return nil, fmt.Errorf("clearing store: %w", err)
+		}
+	}
+
+	if err := run.SetMetadata(ctx, "index_started_at", start.Format(time.RFC3339Nano)); err != nil {
+		return nil, fmt.Errorf("storing metadata: %w", err)
+	}
+	// Stored paths are relative to the repository's directory, so record it
+	// before anything is read back
+	if idx.cfg.Repo != "" {
+		if err := run.SetRepoDir(ctx, idx.cfg.Repo, idx.projectDir); err != nil {
+			return nil, fmt.Errorf("storing metadata: %w", err)
+		}
 	}
 
 	// Load packages
-	fmt.Println("Loading packages...")
+	phases.begin("load")
+	fmt.Fprintln(idx.out, "Loading packages...")
 	loader := NewLoader(idx.cfg, idx.projectDir)
+	loader.out = idx.out
 	if err := loader.Load(); err != nil {
 		return nil, fmt.Errorf("loading packages: %w", err)
 	}
 	idx.loader = loader
 
-	fmt.Printf("Loaded %d packages\n", len(loader.Packages()))
+	fmt.Fprintf(idx.out, "Loaded %d packages\n", len(loader.Packages()))
+
+	// Keep loading errors so gaps in the graph can be explained later
+	if err := idx.storeDiagnostics(ctx, loader, run); err != nil {
+		return nil, fmt.Errorf("storing diagnostics: %w", err)
+	}
+
+	changedPkgs := 0
+	if incremental {
+		stored, err := run.GetPackages(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting packages: %w", err)
+		}
+		var own []store.Package
+		for _, p := range stored {
+			if p.Repo == idx.cfg.Repo {
+				own = append(own, p)
+			}
+		}
+		scope := changedPackages(loader, own, changed)
+		loader.SetScope(scope)
+		changedPkgs = len(scope)
+		fmt.Fprintf(idx.out, "Re-extracting %d packages changed since %s\n", changedPkgs, idx.since)
+	}
 
 	// Extract and persist symbols
-	fmt.Println("Extracting symbols...")
-	if err := loader.ExtractSymbols(st); err != nil {
+	phases.begin("symbols")
+	fmt.Fprintln(idx.out, "Extracting symbols...")
+	if err := loader.ExtractSymbols(ctx, run); err != nil {
 		return nil, fmt.Errorf("extracting symbols: %w", err)
 	}
 
+	// Record interface method sets and implementations
+	phases.begin("types")
+	fmt.Fprintln(idx.out, "Matching interfaces to implementations...")
+	ifaceResult, err := ExtractInterfaces(ctx, loader, run)
+	if err != nil {
+		return nil, fmt.Errorf("extracting interfaces: %w", err)
+	}
+	fmt.Fprintf(idx.out, "Found %d interfaces with %d implementations\n", ifaceResult.InterfaceCount, ifaceResult.ImplementationCount)
+
+	// Record struct fields and embeddings
+	relResult, err := ExtractTypeRelations(ctx, loader, run)
+	if err != nil {
+		return nil, fmt.Errorf("extracting type relations: %w", err)
+	}
+	fmt.Fprintf(idx.out, "Recorded %d struct fields and %d embeddings\n", relResult.Fields, relResult.Embeddings)
+
+	// Record where constants are used
+	refResult, err := ExtractReferences(ctx, loader, run)
+	if err != nil {
+		return nil, fmt.Errorf("extracting references: %w", err)
+	}
+	fmt.Fprintf(idx.out, "Recorded %d constant references\n", refResult.References)
+
+	// Compare gRPC implementations against the generated service interfaces
+	grpcResult, err := ExtractGRPCCoverage(ctx, loader, run)
+	if err != nil {
+		return nil, fmt.Errorf("extracting gRPC coverage: %w", err)
+	}
+	if grpcResult.Services > 0 {
+		fmt.Fprintf(idx.out, "Found %d gRPC services with %d unimplemented methods and %d unregistered implementations\n",
+			grpcResult.Services, grpcResult.Unimplemented, grpcResult.Unregistered)
+	}
+
 	// Detect entrypoints
-	fmt.Println("Detecting entrypoints...")
-	epResult, err := idx.detectEntrypoints(loader, st)
+	phases.begin("entrypoints")
+	fmt.Fprintln(idx.out, "Detecting entrypoints...")
+	epResult, err := idx.detectEntrypoints(ctx, loader, run)
 	if err != nil {
 		return nil, fmt.Errorf("detecting entrypoints: %w", err)
 	}
-	fmt.Printf("Found %d entrypoints (%d http, %d grpc, %d cli, %d main)\n",
+	fmt.Fprintf(idx.out, "Found %d entrypoints (%d http, %d grpc, %d cli, %d main, %d custom)\n",
 		epResult.TotalCount, epResult.HTTPCount, epResult.GRPCCount,
-		epResult.CLICount, epResult.MainCount)
+		epResult.CLICount, epResult.MainCount, epResult.CustomCount)
 
 	// Build SSA and extract call graph
-	fmt.Println("Building call graph...")
-	cgResult, cgBuilder, err := BuildAndExtract(loader, st, func(current, total int) {
+	phases.begin("callgraph")
+	fmt.Fprintln(idx.out, "Building call graph...")
+	cgResult, cgBuilder, err := BuildAndExtract(ctx, loader, run, func(current, total int) {
+		if current == 0 {
+			fmt.Fprintf(idx.out, "Processing %d project functions...\n", total)
+		}
 		if current%500 == 0 || current == total {
-			fmt.Printf("  Processing functions: %d/%d\n", current, total)
+			fmt.Fprintf(idx.out, "  Processing functions: %d/%d\n", current, total)
 		}
 	})
 	if err != nil {
 		return nil, fmt.Errorf("building call graph: %w", err)
 	}
-	fmt.Printf("Extracted %d call edges (%d static, %d interface, %d defer, %d go)\n",
+	fmt.Fprintf(idx.out, "Extracted %d call edges (%d static, %d interface, %d defer, %d go)\n",
 		cgResult.EdgeCount, cgResult.StaticCalls, cgResult.InterfaceCalls,
 		cgResult.DeferCalls, cgResult.GoCalls)
+	fmt.Fprintf(idx.out, "Recorded %d writes to package-level variables\n", cgResult.GlobalWrites)
+	fmt.Fprintf(idx.out, "Recorded %d error wrap, drop, and status sites\n", cgResult.ErrorSites)
+	if cgResult.FlagUses > 0 {
+		fmt.Fprintf(idx.out, "Recorded %d feature-flag evaluations\n", cgResult.FlagUses)
+	}
+	if idx.cfg.Dependencies.Index {
+		fmt.Fprintf(idx.out, "Recorded %d calls into third-party modules\n", cgResult.ExternalCalls)
+	}
+
+	// Link calls between repositories sharing the index, in both directions
+	crossRepoEdges := 0
+	if idx.cfg.Repo != "" {
+		crossRepoEdges, err = run.ResolveCrossRepoEdges(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("resolving cross-repo edges: %w", err)
+		}
+		if crossRepoEdges > 0 {
+			fmt.Fprintf(idx.out, "Resolved %d call edges across repositories\n", crossRepoEdges)
+		}
+	}
+
+	// Restore user-asserted edges replaced by the re-extracted call graph
+	manualEdges, err := run.ApplyManualEdges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("applying manual edges: %w", err)
+	}
+	if manualEdges > 0 {
+		fmt.Fprintf(idx.out, "Applied %d manual call edges\n", manualEdges)
+	}
 
 	// Discover HTTP handlers by signature (complements router-based detection)
-	fmt.Println("Discovering HTTP handlers by signature...")
-	handlerResult, err := idx.discoverHandlers(loader, cgBuilder, st)
+	phases.begin("handlers")
+	fmt.Fprintln(idx.out, "Discovering HTTP handlers by signature...")
+	handlerResult, err := idx.discoverHandlers(ctx, loader, cgBuilder, run)
 	if err != nil {
 		return nil, fmt.Errorf("discovering handlers: %w", err)
 	}
 	if handlerResult.TotalCount > 0 {
-		fmt.Printf("Discovered %d additional HTTP handlers by signature\n", handlerResult.TotalCount)
+		fmt.Fprintf(idx.out, "Discovered %d additional HTTP handlers by signature\n", handlerResult.TotalCount)
 	}
 
 	// Apply tags
-	fmt.Println("Applying tags...")
-	tagger := NewTagger(idx.cfg, st)
-	tagResult, err := tagger.Tag()
+	phases.begin("tags")
+	fmt.Fprintln(idx.out, "Applying tags...")
+	tagger := NewTagger(idx.cfg, run)
+	tagResult, err := tagger.Tag(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("tagging: %w", err)
 	}
-	fmt.Printf("Applied %d tags (%d io, %d layer, %d purity)\n",
-		tagResult.TotalTags, tagResult.IOTags, tagResult.LayerTags, tagResult.PurityTags)
+	fmt.Fprintf(idx.out, "Applied %d tags (%d io, %d derived io, %d layer, %d purity)\n",
+		tagResult.TotalTags, tagResult.IOTags, tagResult.DerivedIOTags, tagResult.LayerTags, tagResult.PurityTags)
+
+	// Trace request inputs to sensitive sinks
+	phases.begin("analysis")
+	fmt.Fprintln(idx.out, "Analyzing taint flows...")
+	taintResult, err := NewTaintAnalyzer(idx.cfg, loader, cgBuilder.GetSSAProgram()).Analyze(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("analyzing taint: %w", err)
+	}
+	if taintResult.FindingCount > 0 {
+		fmt.Fprintf(idx.out, "Found %d unsanitized input-to-sink flows in %d entrypoints\n",
+			taintResult.FindingCount, taintResult.EntrypointCount)
+	}
+
+	// Flag routes that are not behind authentication
+	fmt.Fprintln(idx.out, "Checking auth coverage...")
+	authResult, err := NewAuthChecker(idx.cfg, run).Check(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("checking auth: %w", err)
+	}
+	if authResult.Missing > 0 {
+		fmt.Fprintf(idx.out, "Found %d of %d HTTP entrypoints without auth\n", authResult.Missing, authResult.Checked)
+	}
+
+	// Flag request paths where a panic would crash the service
+	fmt.Fprintln(idx.out, "Checking panic recovery...")
+	panicResult, err := NewPanicAnalyzer(idx.cfg, loader, cgBuilder.GetSSAProgram()).Analyze(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("analyzing panics: %w", err)
+	}
+	if panicResult.Unrecovered > 0 {
+		fmt.Fprintf(idx.out, "Found %d of %d entrypoints reaching an unrecovered panic\n", panicResult.Unrecovered, panicResult.Checked)
+	}
+
+	// Document the status codes each API entrypoint can respond with
+	fmt.Fprintln(idx.out, "Extracting response status codes...")
+	statusResult, err := NewStatusAnalyzer(loader, cgBuilder.GetSSAProgram()).Analyze(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("analyzing status codes: %w", err)
+	}
+	fmt.Fprintf(idx.out, "Found status codes for %d of %d entrypoints\n", statusResult.WithCodes, statusResult.Checked)
+
+	// Document the request and response payloads of HTTP entrypoints
+	fmt.Fprintln(idx.out, "Inferring request and response types...")
+	payloadResult, err := NewPayloadAnalyzer(loader, cgBuilder.GetSSAProgram()).Analyze(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("inferring payload types: %w", err)
+	}
+	fmt.Fprintf(idx.out, "Inferred request types for %d and response types for %d of %d HTTP entrypoints\n",
+		payloadResult.Requests, payloadResult.Responses, payloadResult.Checked)
+
+	// Summarize the timeouts and retries along each entrypoint's flow
+	fmt.Fprintln(idx.out, "Detecting timeouts and retries...")
+	resilienceResult, err := NewResilienceAnalyzer(loader, cgBuilder.GetSSAProgram()).Analyze(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("analyzing timeouts and retries: %w", err)
+	}
+	fmt.Fprintf(idx.out, "Found timeouts in %d and retries in %d entrypoints\n",
+		resilienceResult.WithTimeouts, resilienceResult.WithRetries)
 
 	// Store indexing metadata
-	if err := st.SetMetadata("indexed_at", time.Now().Format(time.RFC3339)); err != nil {
+	phases.begin("finalize")
+	// Nanosecond precision so back-to-back runs get distinct index generations
+	if err := run.SetMetadata(ctx, "indexed_at", time.Now().Format(time.RFC3339Nano)); err != nil {
+		return nil, fmt.Errorf("storing metadata: %w", err)
+	}
+	if err := run.SetMetadata(ctx, "project_dir", idx.projectDir); err != nil {
+		return nil, fmt.Errorf("storing metadata: %w", err)
+	}
+	if err := run.SetMetadata(ctx, "schema_version", strconv.Itoa(store.SchemaVersion)); err != nil {
+		return nil, fmt.Errorf("storing metadata: %w", err)
+	}
+	if err := run.SetMetadata(ctx, "dependencies_indexed", strconv.FormatBool(idx.cfg.Dependencies.Index)); err != nil {
+		return nil, fmt.Errorf("storing metadata: %w", err)
+	}
+	if err := run.SetMetadata(ctx, "wrappers_kept", strconv.FormatBool(idx.cfg.Wrappers.Keep)); err != nil {
+		return nil, fmt.Errorf("storing metadata: %w", err)
+	}
+	if err := run.SetMetadata(ctx, "project_modules", strings.Join(idx.cfg.ProjectModules, ",")); err != nil {
 		return nil, fmt.Errorf("storing metadata: %w", err)
 	}
-	if err := st.SetMetadata("project_dir", idx.projectDir); err != nil {
+	if err := run.SetVCSInfo(ctx, vcs); err != nil {
 		return nil, fmt.Errorf("storing metadata: %w", err)
 	}
 
+	// Record what changed since the previous run
+	var changeSummary *ChangeSummary
+	if len(prevSnapshot.Symbols) > 0 {
+		curSnapshot, err := run.LoadSnapshot(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("loading snapshot: %w", err)
+		}
+		changes := DiffSnapshots(prevSnapshot, curSnapshot)
+		if err := idx.recordChanges(ctx, run, changes); err != nil {
+			return nil, fmt.Errorf("recording changes: %w", err)
+		}
+		if err := run.SetMetadata(ctx, "previous_indexed_at", prevIndexedAt); err != nil {
+			return nil, fmt.Errorf("storing metadata: %w", err)
+		}
+		summary := Summarize(changes)
+		changeSummary = &summary
+	}
+
+	// Take over manual edges, bookmarks, views, and shares saved in the live
+	// index while the run was under way, e.g. through the UI, which the
+	// swap would otherwise discard
+	if err := run.CopyUserData(ctx, st); err != nil {
+		return nil, fmt.Errorf("copying user data: %w", err)
+	}
+	if manualEdges, err = run.ApplyManualEdges(ctx); err != nil {
+		return nil, fmt.Errorf("applying manual edges: %w", err)
+	}
+	if err := run.RebuildCallPairs(ctx); err != nil {
+		return nil, fmt.Errorf("aggregating call pairs: %w", err)
+	}
+
 	// Get stats
-	stats, err := st.GetStats()
+	stats, err := run.GetStats(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("getting stats: %w", err)
 	}
 
-	// Write index.json for UI quick boot
-	if err := st.WriteIndexJSON(); err != nil {
-		return nil, fmt.Errorf("writing index.json: %w", err)
+	if err := run.SetMetadata(ctx, "index_status", "complete"); err != nil {
+		return nil, fmt.Errorf("storing metadata: %w", err)
+	}
+	if err := run.Commit(); err != nil {
+		return nil, fmt.Errorf("committing index: %w", err)
+	}
+
+	// Swap the new index in; readers holding the old one reopen it on their
+	// next request (see store.Reopen)
+	closeErr := work.Close()
+	work = nil
+	if closeErr != nil {
+		return nil, fmt.Errorf("closing index copy: %w", closeErr)
+	}
+	if err := st.ReplaceWith(tmpPath); err != nil {
+		return nil, err
+	}
+
+	// Write index.json for UI quick boot. An encrypted index keeps no
+	// plaintext summary or snapshots next to it
+	if key == nil {
+		if err := st.WriteIndexJSON(ctx); err != nil {
+			return nil, fmt.Errorf("writing index.json: %w", err)
+		}
+	}
+
+	// Keep a copy for time-travel queries; the index itself is complete, so
+	// a failure here only warns
+	if retain := idx.cfg.Snapshots.Retain; retain > 0 {
+		if key != nil {
+			fmt.Fprintf(idx.out, "Warning: snapshots aren't retained for an encrypted index\n")
+		} else if snap, err := st.RetainSnapshot(ctx, retain); err != nil {
+			fmt.Fprintf(idx.out, "Warning: retaining snapshot: %v\n", err)
+		} else {
+			fmt.Fprintf(idx.out, "Retained snapshot %s\n", snap.Name)
+		}
+	}
+
+	phases.end()
+
+	unresolved := 0
+	for _, n := range stats.UnresolvedCalls {
+		unresolved += n
+	}
+
+	// Incremental runs only extract some edges; report the whole graph
+	edgeCount := cgResult.EdgeCount
+	since := ""
+	if incremental {
+		edgeCount = stats.CallEdgeCount
+		since = idx.since
 	}
 
 	return &Result{
 		PackageCount:          stats.PackageCount,
 		SymbolCount:           stats.SymbolCount,
-		CallEdgeCount:         cgResult.EdgeCount,
+		CallEdgeCount:         edgeCount,
 		StaticCalls:           cgResult.StaticCalls,
 		InterfaceCalls:        cgResult.InterfaceCalls,
 		DeferCalls:            cgResult.DeferCalls,
 		GoCalls:               cgResult.GoCalls,
+		ExternalCalls:         cgResult.ExternalCalls,
+		CrossRepoEdges:        crossRepoEdges,
+		ManualEdges:           manualEdges,
+		Interfaces:            ifaceResult.InterfaceCount,
+		Implementations:       ifaceResult.ImplementationCount,
+		TypeFields:            relResult.Fields,
+		TypeEmbeddings:        relResult.Embeddings,
+		ConstReferences:       refResult.References,
+		GlobalWrites:          cgResult.GlobalWrites,
+		ErrorSites:            cgResult.ErrorSites,
+		FlagUses:              cgResult.FlagUses,
 		EntrypointCount:       epResult.TotalCount + handlerResult.TotalCount,
 		HTTPEntrypoints:       epResult.HTTPCount + handlerResult.TotalCount,
 		HTTPByRouter:          epResult.HTTPCount,
@@ -164,25 +601,42 @@ func (idx *Indexer) Run() (*Result, error) {
 		GRPCEntrypoints:       epResult.GRPCCount,
 		CLIEntrypoints:        epResult.CLICount,
 		MainEntrypoints:       epResult.MainCount,
+		CustomEntrypoints:     epResult.CustomCount,
 		TagCount:              tagResult.TotalTags,
 		IOTags:                tagResult.IOTags,
+		DerivedIOTags:         tagResult.DerivedIOTags,
 		LayerTags:             tagResult.LayerTags,
 		PurityTags:            tagResult.PurityTags,
+		TaintFindings:         taintResult.FindingCount,
+		MissingAuth:           authResult.Missing,
+		UnrecoveredPanics:     panicResult.Unrecovered,
+		StatusCodeEntrypoints: statusResult.WithCodes,
+		RequestTypes:          payloadResult.Requests,
+		ResponseTypes:         payloadResult.Responses,
+		TimeoutEntrypoints:    resilienceResult.WithTimeouts,
+		RetryEntrypoints:      resilienceResult.WithRetries,
+		Diagnostics:           len(loader.Diagnostics()),
+		UnresolvedCalls:       unresolved,
+		SkippedFunctions:      stats.SkippedFunctions,
+		Since:                 since,
+		ChangedPackages:       changedPkgs,
+		Changes:               changeSummary,
+		Phases:                phases.stats,
 		Duration:              time.Since(start),
 		DBPath:                st.DBPath(),
 	}, nil
 }
 
 // detectEntrypoints runs entrypoint detection within a batch transaction.
-func (idx *Indexer) detectEntrypoints(loader *Loader, st *store.Store) (*DetectResult, error) {
-	batch, err := st.BeginBatch()
+func (idx *Indexer) detectEntrypoints(ctx context.Context, loader *Loader, st *store.Store) (*DetectResult, error) {
+	batch, err := st.BeginBatch(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("starting batch: %w", err)
 	}
 	defer batch.Rollback()
 
 	detector := NewEntrypointDetector(loader)
-	result, err := detector.Detect(batch)
+	result, err := detector.Detect(ctx, batch)
 	if err != nil {
 		return nil, err
 	}
@@ -194,16 +648,34 @@ func (idx *Indexer) detectEntrypoints(loader *Loader, st *store.Store) (*DetectR
 	return result, nil
 }
 
+// storeDiagnostics persists the loader's package errors.
+func (idx *Indexer) storeDiagnostics(ctx context.Context, loader *Loader, st *store.Store) error {
+	batch, err := st.BeginBatch(ctx)
+	if err != nil {
+		return fmt.Errorf("starting batch: %w", err)
+	}
+	defer batch.Rollback()
+
+	diags := loader.Diagnostics()
+	for i := range diags {
+		if err := batch.InsertDiagnostic(ctx, &diags[i]); err != nil {
+			return fmt.Errorf("inserting diagnostic: %w", err)
+		}
+	}
+
+	return batch.Commit()
+}
+
 // discoverHandlers runs signature-based HTTP handler discovery.
-func (idx *Indexer) discoverHandlers(loader *Loader, cgBuilder *CallGraphBuilder, st *store.Store) (*DiscoverResult, error) {
-	batch, err := st.BeginBatch()
+func (idx *Indexer) discoverHandlers(ctx context.Context, loader *Loader, cgBuilder *CallGraphBuilder, st *store.Store) (*DiscoverResult, error) {
+	batch, err := st.BeginBatch(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("starting batch: %w", err)
 	}
 	defer batch.Rollback()
 
 	discovery := NewHandlerDiscovery(loader, cgBuilder.GetSSAProgram())
-	result, err := discovery.Discover(batch)
+	result, err := discovery.Discover(ctx, batch)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,97 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// InterfaceResult holds the results of interface extraction.
+type InterfaceResult struct {
+	InterfaceCount      int // Interfaces with a method set recorded
+	ImplementationCount int // Interface-type pairs where the type satisfies the interface
+}
+
+// namedType is a package-level named type with its symbol.
+type namedType struct {
+	id    store.SymbolID
+	named *types.Named
+}
+
+// ExtractInterfaces records the method set of every project interface and
+// the project types that satisfy it. Only package-level, non-generic types
+// with a symbol are considered; constraint-only and empty interfaces get a
+// method set but no implementations, as every type would satisfy them.
+func ExtractInterfaces(ctx context.Context, loader *Loader, st *store.Store) (*InterfaceResult, error) {
+	batch, err := st.BeginBatch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("starting batch: %w", err)
+	}
+	defer batch.Rollback()
+
+	var ifaces, concrete []namedType
+	for _, pkg := range loader.Packages() {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			id, err := batch.GetSymbolID(ctx, pkg.PkgPath, name, "")
+			if err != nil || id == 0 {
+				continue // Excluded file
+			}
+			if types.IsInterface(named) {
+				ifaces = append(ifaces, namedType{id, named})
+			} else {
+				concrete = append(concrete, namedType{id, named})
+			}
+		}
+	}
+
+	result := &InterfaceResult{}
+	for _, iface := range ifaces {
+		it := iface.named.Underlying().(*types.Interface)
+		for i := 0; i < it.NumMethods(); i++ {
+			m := it.Method(i)
+			if err := batch.InsertInterfaceMethod(ctx, iface.id, &store.InterfaceMethod{
+				Name: m.Name(),
+				Sig:  m.Type().String(),
+			}); err != nil {
+				return nil, fmt.Errorf("inserting method %s of %s: %w", m.Name(), iface.named.Obj().Name(), err)
+			}
+		}
+		result.InterfaceCount++
+
+		if it.NumMethods() == 0 || !it.IsMethodSet() {
+			continue
+		}
+		for _, t := range concrete {
+			pointer := false
+			if !types.Implements(t.named, it) {
+				if !types.Implements(types.NewPointer(t.named), it) {
+					continue
+				}
+				pointer = true
+			}
+			if err := batch.InsertImplementation(ctx, iface.id, t.id, pointer); err != nil {
+				return nil, fmt.Errorf("inserting implementation of %s: %w", iface.named.Obj().Name(), err)
+			}
+			result.ImplementationCount++
+		}
+	}
+
+	if err := batch.Commit(); err != nil {
+		return nil, fmt.Errorf("committing batch: %w", err)
+	}
+	return result, nil
+}
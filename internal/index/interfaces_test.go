@@ -0,0 +1,123 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestExtractInterfaces(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package shapes
+
+type Shape interface {
+	Area() float64
+}
+
+type Solid interface {
+	Shape
+	Volume() float64
+}
+
+type Number interface{ ~int | ~float64 }
+
+type Empty interface{}
+
+type Square struct{ side float64 }
+
+func (s Square) Area() float64 { return s.side * s.side }
+
+type Cube struct{ Square }
+
+func (c *Cube) Volume() float64 { return c.Area() * c.side }
+
+type Point struct{ X, Y int }
+
+type Box[T any] struct{ v T }
+
+func (b Box[T]) Area() float64 { return 0 }
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "shapes.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing shapes.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module shapes\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	result, err := ExtractInterfaces(t.Context(), loader, st)
+	if err != nil {
+		t.Fatalf("extracting interfaces: %v", err)
+	}
+	if result.InterfaceCount != 4 {
+		t.Errorf("expected 4 interfaces, got %d", result.InterfaceCount)
+	}
+
+	ifaces, err := st.GetInterfaces(t.Context(), store.InterfaceFilter{})
+	if err != nil {
+		t.Fatalf("getting interfaces: %v", err)
+	}
+	byName := make(map[string]store.InterfaceSummary)
+	for _, is := range ifaces {
+		byName[is.Symbol.Name] = is
+	}
+	for name, want := range map[string][2]int{
+		"Shape":  {1, 2}, // Square and Cube (through the embedded Square)
+		"Solid":  {2, 1}, // Only *Cube
+		"Number": {0, 0},
+		"Empty":  {0, 0},
+	} {
+		got, ok := byName[name]
+		if !ok {
+			t.Errorf("expected interface %s", name)
+			continue
+		}
+		if got.MethodCount != want[0] || got.ImplementationCount != want[1] {
+			t.Errorf("%s: expected %d methods and %d implementations, got %d and %d",
+				name, want[0], want[1], got.MethodCount, got.ImplementationCount)
+		}
+	}
+
+	solid := byName["Solid"].Symbol.ID
+	methods, err := st.GetInterfaceMethods(t.Context(), solid)
+	if err != nil {
+		t.Fatalf("getting methods: %v", err)
+	}
+	if len(methods) != 2 || methods[0].Name != "Area" || methods[1].Name != "Volume" {
+		t.Errorf("expected Area and Volume, got %+v", methods)
+	}
+	impls, err := st.GetImplementations(t.Context(), solid)
+	if err != nil {
+		t.Fatalf("getting implementations: %v", err)
+	}
+	if len(impls) != 1 || impls[0].Symbol.Name != "Cube" || !impls[0].Pointer {
+		t.Errorf("expected only *Cube to implement Solid, got %+v", impls)
+	}
+
+	cube, err := st.GetSymbolID(t.Context(), "shapes", "Cube", "")
+	if err != nil {
+		t.Fatalf("finding Cube: %v", err)
+	}
+	implemented, err := st.GetImplementedInterfaces(t.Context(), cube)
+	if err != nil {
+		t.Fatalf("getting implemented interfaces: %v", err)
+	}
+	if len(implemented) != 2 {
+		t.Errorf("expected Cube to implement Shape and Solid, got %+v", implemented)
+	}
+}
@@ -0,0 +1,109 @@
+package index
+
+import (
+	"strings"
+
+	"github.com/abramin/flowlens/internal/config"
+	"golang.org/x/tools/go/packages"
+)
+
+// handlerImports are packages whose import marks a package as handler-ish.
+var handlerImports = []string{
+	"net/http",
+	"google.golang.org/grpc",
+	"github.com/go-chi/chi",
+	"github.com/gorilla/mux",
+	"github.com/gin-gonic/gin",
+	"github.com/labstack/echo",
+	"github.com/gofiber/fiber",
+}
+
+// InferLayers classifies project packages that no configured layer pattern
+// matches, so repos without a flowlens.yaml still get a layered view:
+//   - imports net/http, gRPC, or a router ⇒ handler
+//   - imports a db I/O package ⇒ store
+//   - imported by other project packages, imports none of them, and does
+//     no I/O ⇒ domain
+//   - imports a store package (configured or inferred) ⇒ service
+//
+// main packages are never classified. The result maps package path to layer.
+func InferLayers(pkgs []*packages.Package, cfg *config.Config) map[string]string {
+	project := make(map[string]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		project[pkg.PkgPath] = true
+	}
+
+	importedBy := make(map[string]int)
+	for _, pkg := range pkgs {
+		for path := range pkg.Imports {
+			if project[path] && path != pkg.PkgPath {
+				importedBy[path]++
+			}
+		}
+	}
+
+	layers := make(map[string]string)
+	known := make(map[string]string) // Configured and inferred layers, for the service rule
+	var undecided []*packages.Package
+
+	for _, pkg := range pkgs {
+		if layer := cfg.GetLayerForPackage(pkg.PkgPath); layer != "" {
+			known[pkg.PkgPath] = layer
+			continue
+		}
+		if pkg.Name == "main" {
+			continue
+		}
+
+		var importsProject, usesIO, usesDB, handlerish bool
+		for path := range pkg.Imports {
+			if project[path] {
+				importsProject = true
+			}
+			switch cfg.GetIOCategory(path) {
+			case "":
+			case "db":
+				usesIO, usesDB = true, true
+			default:
+				usesIO = true
+			}
+			if isHandlerImport(path) {
+				handlerish = true
+			}
+		}
+
+		switch {
+		case handlerish:
+			layers[pkg.PkgPath] = "handler"
+		case usesDB:
+			layers[pkg.PkgPath] = "store"
+		case importedBy[pkg.PkgPath] > 0 && !importsProject && !usesIO:
+			layers[pkg.PkgPath] = "domain"
+		default:
+			undecided = append(undecided, pkg)
+			continue
+		}
+		known[pkg.PkgPath] = layers[pkg.PkgPath]
+	}
+
+	for _, pkg := range undecided {
+		for path := range pkg.Imports {
+			if known[path] == "store" {
+				layers[pkg.PkgPath] = "service"
+				break
+			}
+		}
+	}
+
+	return layers
+}
+
+// isHandlerImport reports whether importing path marks a package as a handler.
+func isHandlerImport(path string) bool {
+	for _, h := range handlerImports {
+		if path == h || strings.HasPrefix(path, h+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestInferLayers(t *testing.T) {
+	pkg := func(path, name string, imports ...string) *packages.Package {
+		p := &packages.Package{PkgPath: path, Name: name, Imports: make(map[string]*packages.Package)}
+		for _, imp := range imports {
+			p.Imports[imp] = &packages.Package{PkgPath: imp}
+		}
+		return p
+	}
+
+	pkgs := []*packages.Package{
+		pkg("myapp/web", "web", "net/http", "myapp/billing"),
+		pkg("myapp/persist", "persist", "database/sql", "myapp/types"),
+		pkg("myapp/types", "types", "time"),
+		pkg("myapp/billing", "billing", "myapp/persist", "myapp/types"),
+		pkg("myapp/util", "util", "strings"),              // Imported by nobody
+		pkg("myapp/cmd/server", "main", "myapp/web"),      // main packages stay unlayered
+		pkg("myapp/internal/handlers/user", "user", "os"), // Configured layer wins
+	}
+
+	got := InferLayers(pkgs, config.Default())
+
+	want := map[string]string{
+		"myapp/web":     "handler",
+		"myapp/persist": "store",
+		"myapp/types":   "domain",
+		"myapp/billing": "service",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d inferred layers, got %v", len(want), got)
+	}
+	for path, layer := range want {
+		if got[path] != layer {
+			t.Errorf("%s: expected %q, got %q", path, layer, got[path])
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package index
 
 import (
+	"context"
 	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"io"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/abramin/flowlens/internal/config"
 	"github.com/abramin/flowlens/internal/store"
@@ -31,6 +36,9 @@ type Loader struct {
 	fset        *token.FileSet
 	pkgs        []*packages.Package
 	fileToPackage map[string]*packages.Package
+	scope       map[string]bool // Packages to re-extract; nil means all
+	diagnostics []store.Diagnostic
+	out         io.Writer // Warnings about packages that failed to load
 }
 
 // NewLoader creates a new package loader.
@@ -40,6 +48,7 @@ func NewLoader(cfg *config.Config, projectDir string) *Loader {
 		projectDir:    projectDir,
 		fset:          token.NewFileSet(),
 		fileToPackage: make(map[string]*packages.Package),
+		out:           os.Stdout,
 	}
 }
 
@@ -52,18 +61,24 @@ func (l *Loader) Load() error {
 		// Build constraints can be added here if needed
 	}
 
-	// Load all packages in the directory tree
-	pkgs, err := packages.Load(cfg, "./...")
+	// Load the selected packages (default: the whole directory tree)
+	patterns := l.cfg.Packages
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
 	if err != nil {
 		return fmt.Errorf("loading packages: %w", err)
 	}
 
 	// Filter out excluded packages and build file mapping
 	var filtered []*packages.Package
-	for _, pkg := range pkgs {
-		if l.shouldExcludePackage(pkg) {
-			continue
+	loaded := make(map[string]bool)
+	keep := func(pkg *packages.Package) {
+		if loaded[pkg.PkgPath] || l.shouldExcludePackage(pkg) {
+			return
 		}
+		loaded[pkg.PkgPath] = true
 		filtered = append(filtered, pkg)
 
 		// Build file → package mapping
@@ -74,6 +89,23 @@ func (l *Loader) Load() error {
 			l.fileToPackage[file] = pkg
 		}
 	}
+	for _, pkg := range pkgs {
+		keep(pkg)
+	}
+
+	// Dependencies in project_modules are indexed as project code, as far
+	// as the project imports them
+	if len(l.cfg.ProjectModules) > 0 {
+		packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+			modulePath := ""
+			if pkg.Module != nil {
+				modulePath = pkg.Module.Path
+			}
+			if l.cfg.IsProjectModule(modulePath, pkg.PkgPath) {
+				keep(pkg)
+			}
+		})
+	}
 
 	l.pkgs = filtered
 
@@ -82,22 +114,86 @@ func (l *Loader) Load() error {
 	packages.Visit(l.pkgs, nil, func(pkg *packages.Package) {
 		for _, err := range pkg.Errors {
 			errs = append(errs, fmt.Sprintf("%s: %s", pkg.PkgPath, err.Msg))
+			l.diagnostics = append(l.diagnostics, l.toDiagnostic(pkg, err))
 		}
 	})
 	if len(errs) > 0 {
 		// Log errors but continue - some errors are acceptable
-		fmt.Printf("Warning: %d package loading errors\n", len(errs))
+		fmt.Fprintf(l.out, "Warning: %d package loading errors (run 'flowlens doctor' for details)\n", len(errs))
 		for _, err := range errs[:min(5, len(errs))] {
-			fmt.Printf("  - %s\n", err)
+			fmt.Fprintf(l.out, "  - %s\n", err)
 		}
 		if len(errs) > 5 {
-			fmt.Printf("  ... and %d more\n", len(errs)-5)
+			fmt.Fprintf(l.out, "  ... and %d more\n", len(errs)-5)
 		}
 	}
 
 	return nil
 }
 
+// Diagnostics returns the package loading errors found by Load, including
+// those of dependencies.
+func (l *Loader) Diagnostics() []store.Diagnostic {
+	return l.diagnostics
+}
+
+// toDiagnostic converts a package loading error. List and parse errors drop
+// a package or file from the graph; type errors only leave gaps in it.
+func (l *Loader) toDiagnostic(pkg *packages.Package, err packages.Error) store.Diagnostic {
+	d := store.Diagnostic{
+		PkgPath:  pkg.PkgPath,
+		Severity: store.SeverityError,
+		Message:  err.Msg,
+		Repo:     l.cfg.Repo,
+	}
+	switch err.Kind {
+	case packages.ListError:
+		d.Kind = "list"
+	case packages.ParseError:
+		d.Kind = "parse"
+	case packages.TypeError:
+		d.Kind = "type"
+		d.Severity = store.SeverityWarning
+	default:
+		d.Kind = "unknown"
+	}
+	d.File, d.Line, d.Column = splitErrorPos(err.Pos)
+	// go list reports import errors relative to the directory it ran in
+	if d.File != "" && !filepath.IsAbs(d.File) {
+		d.File = filepath.Join(l.projectDir, d.File)
+	}
+	return d
+}
+
+// splitErrorPos splits a "file:line:col" position, any part of which may be
+// missing ("-" or "" when the error has no position).
+func splitErrorPos(pos string) (file string, line, col int) {
+	if pos == "" || pos == "-" {
+		return "", 0, 0
+	}
+	file = pos
+	var nums []int
+	for len(nums) < 2 {
+		i := strings.LastIndex(file, ":")
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(file[i+1:])
+		if err != nil {
+			break
+		}
+		nums = append(nums, n)
+		file = file[:i]
+	}
+	switch len(nums) {
+	case 1:
+		line = nums[0]
+	case 2:
+		line, col = nums[1], nums[0]
+	}
+	return file, line, col
+}
+
 // shouldExcludePackage checks if a package should be excluded based on config.
 func (l *Loader) shouldExcludePackage(pkg *packages.Package) bool {
 	// Check if package directory is excluded
@@ -112,6 +208,13 @@ func (l *Loader) shouldExcludePackage(pkg *packages.Package) bool {
 		}
 	}
 
+	// Check excluded package patterns
+	for _, pattern := range l.cfg.Exclude.Packages {
+		if matchesPackagePattern(pattern, pkg.PkgPath, packageDir(pkg), l.projectDir) {
+			return true
+		}
+	}
+
 	// Check each file against exclusion patterns
 	for _, file := range pkg.GoFiles {
 		for _, pattern := range l.cfg.Exclude.FilesGlob {
@@ -128,6 +231,27 @@ func (l *Loader) shouldExcludePackage(pkg *packages.Package) bool {
 	return false
 }
 
+// matchesPackagePattern reports whether a package matches a go-style package
+// pattern. Relative patterns (./internal/billing/...) match the package
+// directory against projectDir; others match the import path. A trailing
+// /... matches the package and everything below it.
+func matchesPackagePattern(pattern, pkgPath, pkgDir, projectDir string) bool {
+	base, recursive := strings.CutSuffix(pattern, "/...")
+	if pattern == "..." {
+		return true
+	}
+
+	if base == "." || strings.HasPrefix(base, "./") || strings.HasPrefix(base, "../") {
+		if pkgDir == "" {
+			return false
+		}
+		base = filepath.Join(projectDir, filepath.FromSlash(base))
+		return pkgDir == base || (recursive && strings.HasPrefix(pkgDir, base+string(filepath.Separator)))
+	}
+
+	return pkgPath == base || (recursive && strings.HasPrefix(pkgPath, base+"/"))
+}
+
 // matchesGlob performs a simplified glob match.
 func matchesGlob(path, pattern string) bool {
 	// Handle **/ prefix
@@ -175,68 +299,157 @@ func (l *Loader) shouldExcludeFile(file string) bool {
 	return false
 }
 
+// SetScope limits symbol and call extraction to the given package paths, for
+// incremental indexing. Stored data for other packages is left as is; scoped
+// packages that no longer load are removed from the store.
+func (l *Loader) SetScope(pkgPaths map[string]bool) {
+	l.scope = pkgPaths
+}
+
+// inScope reports whether a package's symbols and calls are (re-)extracted.
+func (l *Loader) inScope(pkgPath string) bool {
+	return l.scope == nil || l.scope[pkgPath]
+}
+
 // ExtractSymbols extracts all symbols from loaded packages and persists them.
-func (l *Loader) ExtractSymbols(st *store.Store) error {
-	batch, err := st.BeginBatch()
+// With a scope set, only scoped packages are extracted, and their symbols
+// that no longer exist are pruned.
+func (l *Loader) ExtractSymbols(ctx context.Context, st *store.Store) error {
+	batch, err := st.BeginBatch(ctx)
 	if err != nil {
 		return fmt.Errorf("starting batch: %w", err)
 	}
 	defer batch.Rollback()
 
+	inferred := InferLayers(l.pkgs, l.cfg)
+
 	for _, pkg := range l.pkgs {
 		// Insert package record
 		storePkg := &store.Package{
 			PkgPath: pkg.PkgPath,
 			Dir:     packageDir(pkg),
 			Layer:   l.cfg.GetLayerForPackage(pkg.PkgPath),
+			Repo:    l.cfg.Repo,
+		}
+		if storePkg.Layer != "" {
+			storePkg.LayerSource = store.LayerSourceConfig
+		} else if layer, ok := inferred[pkg.PkgPath]; ok {
+			storePkg.Layer = layer
+			storePkg.LayerSource = store.LayerSourceInferred
 		}
 		if pkg.Module != nil {
 			storePkg.Module = pkg.Module.Path
 		}
-		if err := batch.InsertPackage(storePkg); err != nil {
+		if err := batch.InsertPackage(ctx, storePkg); err != nil {
 			return fmt.Errorf("inserting package %s: %w", pkg.PkgPath, err)
 		}
+		if !l.inScope(pkg.PkgPath) {
+			continue
+		}
 
 		// Extract symbols from each file
+		extracted := make(map[store.SymbolID]bool)
 		for i, file := range pkg.Syntax {
 			goFile := pkg.GoFiles[i]
 			if l.shouldExcludeFile(goFile) {
 				continue
 			}
-			if err := l.extractFileSymbols(batch, pkg, file, goFile); err != nil {
+			if err := l.extractFileSymbols(ctx, batch, pkg, file, goFile, extracted); err != nil {
 				return fmt.Errorf("extracting symbols from %s: %w", goFile, err)
 			}
 		}
+		for _, closure := range l.closureSymbols(pkg, packageClosureNames(pkg)) {
+			if l.shouldExcludeFile(closure.File) {
+				continue
+			}
+			closure.Repo = l.cfg.Repo
+			id, err := batch.InsertSymbol(ctx, closure)
+			if err != nil {
+				return fmt.Errorf("inserting closure %s: %w", closure.Name, err)
+			}
+			extracted[id] = true
+		}
+		if l.scope != nil {
+			if _, err := batch.PruneSymbols(ctx, pkg.PkgPath, extracted); err != nil {
+				return fmt.Errorf("pruning symbols of %s: %w", pkg.PkgPath, err)
+			}
+		}
+	}
+
+	// Scoped packages that no longer load were deleted
+	loaded := make(map[string]bool, len(l.pkgs))
+	for _, pkg := range l.pkgs {
+		loaded[pkg.PkgPath] = true
+	}
+	for pkgPath := range l.scope {
+		if loaded[pkgPath] {
+			continue
+		}
+		if _, err := batch.PruneSymbols(ctx, pkgPath, nil); err != nil {
+			return fmt.Errorf("pruning symbols of %s: %w", pkgPath, err)
+		}
+		if err := batch.DeletePackage(ctx, pkgPath); err != nil {
+			return fmt.Errorf("deleting package %s: %w", pkgPath, err)
+		}
 	}
 
 	return batch.Commit()
 }
 
-// extractFileSymbols extracts symbols from a single AST file.
-func (l *Loader) extractFileSymbols(batch *store.BatchTx, pkg *packages.Package, file *ast.File, goFile string) error {
+// extractFileSymbols extracts symbols from a single AST file, adding their
+// IDs to extracted.
+func (l *Loader) extractFileSymbols(ctx context.Context, batch *store.BatchTx, pkg *packages.Package, file *ast.File, goFile string, extracted map[store.SymbolID]bool) error {
 	for _, decl := range file.Decls {
 		switch d := decl.(type) {
 		case *ast.FuncDecl:
 			sym := l.funcDeclToSymbol(pkg, d, goFile)
-			if _, err := batch.InsertSymbol(sym); err != nil {
+			sym.Repo = l.cfg.Repo
+			id, err := batch.InsertSymbol(ctx, sym)
+			if err != nil {
 				return err
 			}
+			extracted[id] = true
+
+			for _, closure := range l.closureSymbols(pkg, closureNames(d)) {
+				closure.Repo = l.cfg.Repo
+				id, err := batch.InsertSymbol(ctx, closure)
+				if err != nil {
+					return err
+				}
+				extracted[id] = true
+			}
 
 		case *ast.GenDecl:
 			for _, spec := range d.Specs {
 				switch s := spec.(type) {
 				case *ast.TypeSpec:
 					sym := l.typeSpecToSymbol(pkg, s, d.Tok, goFile)
-					if _, err := batch.InsertSymbol(sym); err != nil {
+					sym.Repo = l.cfg.Repo
+					if !d.Lparen.IsValid() {
+						// Ungrouped "type T ..." spans from the keyword
+						l.setSpan(sym, d.Pos(), d.End())
+					}
+					if s.Doc != nil {
+						sym.Doc = docText(s.Doc)
+					} else if len(d.Specs) == 1 {
+						// Ungrouped "type T ..." keeps its comment on the GenDecl
+						sym.Doc = docText(d.Doc)
+					}
+					id, err := batch.InsertSymbol(ctx, sym)
+					if err != nil {
 						return err
 					}
+					extracted[id] = true
 
 				case *ast.ValueSpec:
 					for _, name := range s.Names {
-						sym := l.valueSpecToSymbol(pkg, name, d.Tok, goFile)
-						if _, err := batch.InsertSymbol(sym); err != nil {
+						sym := l.valueSpecToSymbol(pkg, s, name, d.Tok, goFile)
+						sym.Repo = l.cfg.Repo
+						id, err := batch.InsertSymbol(ctx, sym)
+						if err != nil {
 							return err
 						}
+						extracted[id] = true
 					}
 				}
 			}
@@ -252,8 +465,9 @@ func (l *Loader) funcDeclToSymbol(pkg *packages.Package, decl *ast.FuncDecl, fil
 		Name:    decl.Name.Name,
 		Kind:    store.SymbolKindFunc,
 		File:    file,
-		Line:    l.fset.Position(decl.Pos()).Line,
+		Doc:     docText(decl.Doc),
 	}
+	l.setSpan(sym, decl.Pos(), decl.End())
 
 	// Check if it's a method (has receiver)
 	if decl.Recv != nil && len(decl.Recv.List) > 0 {
@@ -265,6 +479,7 @@ func (l *Loader) funcDeclToSymbol(pkg *packages.Package, decl *ast.FuncDecl, fil
 	if obj := pkg.TypesInfo.Defs[decl.Name]; obj != nil {
 		if fn, ok := obj.(*types.Func); ok {
 			sym.Sig = fn.Type().String()
+			sym.Signature = signatureOf(fn.Type().(*types.Signature))
 		}
 	}
 
@@ -273,28 +488,82 @@ func (l *Loader) funcDeclToSymbol(pkg *packages.Package, decl *ast.FuncDecl, fil
 
 // typeSpecToSymbol converts a type spec to a Symbol.
 func (l *Loader) typeSpecToSymbol(pkg *packages.Package, spec *ast.TypeSpec, tok token.Token, file string) *store.Symbol {
-	return &store.Symbol{
+	kind := store.SymbolKindType
+	if obj := pkg.TypesInfo.Defs[spec.Name]; obj != nil && types.IsInterface(obj.Type()) {
+		kind = store.SymbolKindInterface
+	}
+	sym := &store.Symbol{
 		PkgPath: pkg.PkgPath,
 		Name:    spec.Name.Name,
-		Kind:    store.SymbolKindType,
+		Kind:    kind,
 		File:    file,
-		Line:    l.fset.Position(spec.Pos()).Line,
 	}
+	l.setSpan(sym, spec.Pos(), spec.End())
+	return sym
 }
 
 // valueSpecToSymbol converts a value spec (var/const) to a Symbol.
-func (l *Loader) valueSpecToSymbol(pkg *packages.Package, name *ast.Ident, tok token.Token, file string) *store.Symbol {
-	kind := store.SymbolKindVar
-	if tok == token.CONST {
-		kind = store.SymbolKindConst
-	}
-	return &store.Symbol{
+func (l *Loader) valueSpecToSymbol(pkg *packages.Package, spec *ast.ValueSpec, name *ast.Ident, tok token.Token, file string) *store.Symbol {
+	sym := &store.Symbol{
 		PkgPath: pkg.PkgPath,
 		Name:    name.Name,
-		Kind:    kind,
+		Kind:    store.SymbolKindVar,
 		File:    file,
-		Line:    l.fset.Position(name.Pos()).Line,
 	}
+	l.setSpan(sym, name.Pos(), spec.End())
+	if tok == token.CONST {
+		sym.Kind = store.SymbolKindConst
+		if c, ok := pkg.TypesInfo.Defs[name].(*types.Const); ok {
+			sym.Value = c.Val().ExactString()
+		}
+	}
+	return sym
+}
+
+// setSpan sets the declaration span of sym to the source from start to end.
+func (l *Loader) setSpan(sym *store.Symbol, start, end token.Pos) {
+	from, to := l.fset.Position(start), l.fset.Position(end)
+	sym.Line, sym.Column = from.Line, from.Column
+	sym.EndLine, sym.EndColumn = to.Line, to.Column
+}
+
+// signatureOf returns the structured form of a function signature.
+func signatureOf(sig *types.Signature) *store.Signature {
+	return &store.Signature{
+		Params:   tupleParams(sig.Params()),
+		Results:  tupleParams(sig.Results()),
+		Variadic: sig.Variadic(),
+	}
+}
+
+// tupleParams converts a parameter or result tuple to Params.
+func tupleParams(tuple *types.Tuple) []store.Param {
+	params := make([]store.Param, tuple.Len())
+	for i := range params {
+		v := tuple.At(i)
+		params[i] = store.Param{Name: v.Name(), Type: types.TypeString(v.Type(), nil)}
+	}
+	return params
+}
+
+// maxDocLen caps the length of a stored doc comment, in bytes.
+const maxDocLen = 1024
+
+// docText returns the text of a doc comment, truncated to maxDocLen at a
+// line or word boundary.
+func docText(doc *ast.CommentGroup) string {
+	text := strings.TrimSpace(doc.Text())
+	if len(text) <= maxDocLen {
+		return text
+	}
+	cut := strings.LastIndexAny(text[:maxDocLen], " \n")
+	if cut <= 0 {
+		cut = maxDocLen
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+	}
+	return strings.TrimSpace(text[:cut]) + "…"
 }
 
 // formatReceiverType formats a receiver type expression as a string.
@@ -1,14 +1,46 @@
 package index
 
 import (
+	"go/ast"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/abramin/flowlens/internal/config"
 	"github.com/abramin/flowlens/internal/store"
 )
 
+func TestMatchesPackagePattern(t *testing.T) {
+	projectDir := filepath.FromSlash("/repo")
+	tests := []struct {
+		pattern string
+		pkgPath string
+		pkgDir  string
+		want    bool
+	}{
+		{"./cmd/legacytool/...", "example.com/repo/cmd/legacytool", "/repo/cmd/legacytool", true},
+		{"./cmd/legacytool/...", "example.com/repo/cmd/legacytool/sub", "/repo/cmd/legacytool/sub", true},
+		{"./cmd/legacytool/...", "example.com/repo/cmd/legacytoolkit", "/repo/cmd/legacytoolkit", false},
+		{"./cmd/legacytool", "example.com/repo/cmd/legacytool/sub", "/repo/cmd/legacytool/sub", false},
+		{"./...", "example.com/repo/internal/billing", "/repo/internal/billing", true},
+		{"example.com/repo/internal/...", "example.com/repo/internal/billing", "/repo/internal/billing", true},
+		{"example.com/repo/internal/billing", "example.com/repo/internal/billing/v2", "/repo/internal/billing/v2", false},
+		{"...", "anything", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.pkgPath, func(t *testing.T) {
+			got := matchesPackagePattern(tt.pattern, tt.pkgPath, filepath.FromSlash(tt.pkgDir), projectDir)
+			if got != tt.want {
+				t.Errorf("matchesPackagePattern(%q, %q) = %v, want %v", tt.pattern, tt.pkgPath, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestMatchesGlob(t *testing.T) {
 	tests := []struct {
 		path    string
@@ -129,11 +161,11 @@ func TestExtractSymbols(t *testing.T) {
 	}
 	defer st.Close()
 
-	if err := loader.ExtractSymbols(st); err != nil {
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
 		t.Fatalf("failed to extract symbols: %v", err)
 	}
 
-	stats, err := st.GetStats()
+	stats, err := st.GetStats(t.Context())
 	if err != nil {
 		t.Fatalf("failed to get stats: %v", err)
 	}
@@ -146,4 +178,190 @@ func TestExtractSymbols(t *testing.T) {
 	}
 
 	t.Logf("Extracted %d packages and %d symbols", stats.PackageCount, stats.SymbolCount)
+
+	// Doc comments are stored with functions and types
+	for _, tt := range []struct{ name, doc string }{
+		{"docText", "docText returns the text of a doc comment"},
+		{"Loader", "Loader handles loading Go packages"},
+	} {
+		id, err := st.FindSymbolID(t.Context(), "github.com/abramin/flowlens/internal/index", tt.name, "")
+		if err != nil {
+			t.Fatalf("failed to find %s: %v", tt.name, err)
+		}
+		sym, err := st.GetSymbolByID(t.Context(), id)
+		if err != nil {
+			t.Fatalf("failed to get %s: %v", tt.name, err)
+		}
+		if !strings.HasPrefix(sym.Doc, tt.doc) {
+			t.Errorf("expected %s doc to start with %q, got %q", tt.name, tt.doc, sym.Doc)
+		}
+	}
+
+	// Functions carry a structured signature
+	id, err := st.FindSymbolID(t.Context(), "github.com/abramin/flowlens/internal/index", "docText", "")
+	if err != nil {
+		t.Fatalf("failed to find docText: %v", err)
+	}
+	sym, err := st.GetSymbolByID(t.Context(), id)
+	if err != nil {
+		t.Fatalf("failed to get docText: %v", err)
+	}
+	want := &store.Signature{
+		Params:  []store.Param{{Name: "doc", Type: "*go/ast.CommentGroup"}},
+		Results: []store.Param{{Type: "string"}},
+	}
+	if !reflect.DeepEqual(sym.Signature, want) {
+		t.Errorf("expected signature %+v, got %+v", want, sym.Signature)
+	}
+}
+
+func TestDocText(t *testing.T) {
+	comment := func(text string) *ast.CommentGroup {
+		return &ast.CommentGroup{List: []*ast.Comment{{Text: "// " + text}}}
+	}
+
+	if got := docText(nil); got != "" {
+		t.Errorf("expected no doc for a nil comment, got %q", got)
+	}
+	if got := docText(comment("Get returns a user.")); got != "Get returns a user." {
+		t.Errorf("expected the comment text, got %q", got)
+	}
+
+	long := docText(comment(strings.Repeat("word ", maxDocLen)))
+	if len(long) > maxDocLen+len("…") || !strings.HasSuffix(long, "word…") {
+		t.Errorf("expected a truncated doc ending on a word, got %d bytes ending %q", len(long), long[len(long)-10:])
+	}
+	unbroken := docText(comment(strings.Repeat("é", maxDocLen)))
+	if !utf8.ValidString(unbroken) || !strings.HasSuffix(unbroken, "…") {
+		t.Errorf("expected a valid truncated doc, got %q", unbroken[len(unbroken)-10:])
+	}
+}
+
+func TestLoaderDiagnostics(t *testing.T) {
+	// One package fails to type-check, another imports a missing package
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module diagmod\n\ngo 1.21\n",
+		"typed/typed.go":   "package typed\n\nfunc F() int { return \"x\" }\n",
+		"broken/broken.go": "package broken\n\nimport \"diagmod/missing\"\n\nfunc G() { missing.H() }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+
+	byPkg := make(map[string]store.Diagnostic)
+	for _, d := range loader.Diagnostics() {
+		if _, ok := byPkg[d.PkgPath]; !ok {
+			byPkg[d.PkgPath] = d
+		}
+	}
+	typed := byPkg["diagmod/typed"]
+	if typed.Severity != store.SeverityWarning || typed.Kind != "type" ||
+		typed.File != filepath.Join(tmpDir, "typed", "typed.go") || typed.Line != 3 {
+		t.Errorf("unexpected type error diagnostic: %+v", typed)
+	}
+	// The missing package is reported at the import that needs it
+	missing := byPkg["diagmod/missing"]
+	if missing.Severity != store.SeverityError || missing.Kind != "list" ||
+		missing.File != filepath.Join(tmpDir, "broken", "broken.go") {
+		t.Errorf("unexpected missing import diagnostic: %+v", missing)
+	}
+}
+
+func TestSplitErrorPos(t *testing.T) {
+	tests := []struct {
+		pos       string
+		file      string
+		line, col int
+	}{
+		{"/src/a.go:12:5", "/src/a.go", 12, 5},
+		{"/src/a.go:12", "/src/a.go", 12, 0},
+		{"/src/a.go", "/src/a.go", 0, 0},
+		{`C:\src\a.go:3:1`, `C:\src\a.go`, 3, 1},
+		{"-", "", 0, 0},
+		{"", "", 0, 0},
+	}
+	for _, tt := range tests {
+		file, line, col := splitErrorPos(tt.pos)
+		if file != tt.file || line != tt.line || col != tt.col {
+			t.Errorf("splitErrorPos(%q) = %q, %d, %d; want %q, %d, %d", tt.pos, file, line, col, tt.file, tt.line, tt.col)
+		}
+	}
+}
+
+func TestSymbolSpans(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package main
+
+type Point struct {
+	X, Y int
+}
+
+const (
+	Origin = 0
+)
+
+func (p Point) Sum() int {
+	f := func() int {
+		return p.X + p.Y
+	}
+	return f()
+}
+
+func main() {}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module testmod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	tests := []struct {
+		name, recv                 string
+		line, col, endLine, endCol int
+	}{
+		{"Point", "", 3, 1, 5, 2},      // From the type keyword
+		{"Origin", "", 8, 2, 8, 12},    // Name and value of a grouped constant
+		{"Sum", "Point", 11, 1, 16, 2}, // Whole declaration
+		{"Sum$1", "Point", 12, 7, 14, 3},
+	}
+	for _, tt := range tests {
+		id, err := st.FindSymbolID(t.Context(), "testmod", tt.name, tt.recv)
+		if err != nil {
+			t.Fatalf("finding %s: %v", tt.name, err)
+		}
+		sym, err := st.GetSymbolByID(t.Context(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := [4]int{sym.Line, sym.Column, sym.EndLine, sym.EndColumn}
+		if want := [4]int{tt.line, tt.col, tt.endLine, tt.endCol}; got != want {
+			t.Errorf("%s: expected span %v, got %v", tt.name, want, got)
+		}
+	}
 }
@@ -0,0 +1,151 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// indexLock is the content of the lock file held while a run writes an
+// index.
+type indexLock struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+}
+
+// LockedError reports an index already being written by another run.
+type LockedError struct {
+	Path string // Lock file
+	indexLock
+}
+
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("index in progress by an unknown run (unreadable lock file %s); wait for it to finish or use --force", e.Path)
+	}
+	who := fmt.Sprintf("PID %d", e.PID)
+	if host, _ := os.Hostname(); e.Host != "" && e.Host != host {
+		who += " on " + e.Host
+	}
+	return fmt.Sprintf("index in progress by %s since %s (lock file %s); wait for it to finish or use --force",
+		who, e.Started.Format(time.RFC3339), e.Path)
+}
+
+// acquireLock takes the lock file for the index at dbPath, so two runs
+// can't write the same index at once. A lock left by a process that no
+// longer runs on this host is taken over; force takes over any lock. The
+// returned function releases it, unless another run has since taken it over.
+func acquireLock(dbPath string, force bool) (func(), error) {
+	path := dbPath + ".lock"
+	host, _ := os.Hostname()
+	lock := indexLock{PID: os.Getpid(), Host: host, Started: time.Now()}
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lock is written to a temporary file and linked into place, so
+	// other runs never see it without its content
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return nil, fmt.Errorf("creating lock file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("writing lock file: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := os.Link(tmp.Name(), path)
+		if err == nil {
+			return func() { removeLock(path, &lock) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("creating lock file: %w", err)
+		}
+
+		// Another run holds the lock, unless it died without releasing it
+		held, err := readLock(path)
+		if err != nil {
+			return nil, err
+		}
+		if held == nil {
+			continue // Released meanwhile
+		}
+		if attempt > 0 || (!force && !held.stale(host)) {
+			return nil, &LockedError{Path: path, indexLock: *held}
+		}
+		if err := removeLock(path, held); err != nil {
+			return nil, fmt.Errorf("removing stale lock file: %w", err)
+		}
+	}
+}
+
+// readLock reads a lock file, returning nil if it is gone. A lock file that
+// can't be parsed, e.g. one written by hand, is returned as held by an
+// unknown run.
+func readLock(path string) (*indexLock, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading lock file: %w", err)
+	}
+	var lock indexLock
+	if json.Unmarshal(data, &lock) != nil {
+		return &indexLock{}, nil
+	}
+	return &lock, nil
+}
+
+// removeLock removes the lock file if it still holds lock, so a run never
+// releases a lock another run has taken over.
+func removeLock(path string, lock *indexLock) error {
+	cur, err := readLock(path)
+	if err != nil || cur == nil {
+		return err
+	}
+	if cur.PID != lock.PID || cur.Host != lock.Host || !cur.Started.Equal(lock.Started) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// stale reports whether the process holding the lock is gone. Locks taken
+// on other hosts, e.g. over a network mount, can't be checked and are
+// assumed held.
+func (l *indexLock) stale(host string) bool {
+	if l.Host != host {
+		return false
+	}
+	return !ProcessAlive(l.PID)
+}
+
+// ProcessAlive reports whether a process with the given PID exists.
+func ProcessAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// On Windows FindProcess fails for processes that don't exist, and
+	// signals aren't supported
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,81 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "index.db")
+	lockPath := dbPath + ".lock"
+
+	release, err := acquireLock(dbPath, false)
+	if err != nil {
+		t.Fatalf("acquiring lock: %v", err)
+	}
+
+	// A second run fails while the first holds the lock
+	_, err = acquireLock(dbPath, false)
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.PID != os.Getpid() {
+		t.Fatalf("expected the lock to be held by PID %d, got %v", os.Getpid(), err)
+	}
+
+	// --force takes it over, and the original holder's release leaves the
+	// new holder's lock in place
+	releaseForced, err := acquireLock(dbPath, true)
+	if err != nil {
+		t.Fatalf("forcing lock: %v", err)
+	}
+	release()
+	if _, err := acquireLock(dbPath, false); !errors.As(err, &locked) {
+		t.Fatalf("expected the forced lock to survive the original release, got %v", err)
+	}
+	releaseForced()
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("expected release to remove the lock file, got %v", err)
+	}
+
+	writeLock := func(lock indexLock) {
+		data, err := json.Marshal(lock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(lockPath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	host, _ := os.Hostname()
+
+	// A lock left by a process that has exited is taken over
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("running child process: %v", err)
+	}
+	writeLock(indexLock{PID: cmd.Process.Pid, Host: host, Started: time.Now()})
+	release, err = acquireLock(dbPath, false)
+	if err != nil {
+		t.Fatalf("expected a stale lock to be taken over, got %v", err)
+	}
+	release()
+
+	// A lock file that can't be parsed, e.g. an empty one, is assumed held
+	if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acquireLock(dbPath, false); !errors.As(err, &locked) {
+		t.Errorf("expected an empty lock file to be held, got %v", err)
+	}
+	os.Remove(lockPath)
+
+	// Locks from other hosts can't be checked and are assumed held
+	writeLock(indexLock{PID: cmd.Process.Pid, Host: host + "-other", Started: time.Now()})
+	if _, err := acquireLock(dbPath, false); !errors.As(err, &locked) || locked.Host != host+"-other" {
+		t.Errorf("expected the other host's lock to be held, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package index
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// routerScopingMethods derive a sub-router that inherits its parent's
+// middleware: chi With/Group/Route, gin and echo Group, gorilla
+// PathPrefix/Subrouter.
+var routerScopingMethods = map[string]bool{
+	"With":       true,
+	"Group":      true,
+	"Route":      true,
+	"PathPrefix": true,
+	"Subrouter":  true,
+}
+
+// middlewareScope tracks the middleware registered on router values within
+// one file, so routes can be attributed the chain that wraps them. Routers
+// held in local variables are keyed by their types.Object, which keeps a
+// chi Group callback's shadowing "r" distinct from the outer one; other
+// receivers (s.router) are keyed by their source text.
+type middlewareScope struct {
+	pkg     *packages.Package
+	routers map[any][]string
+}
+
+func newMiddlewareScope(pkg *packages.Package) *middlewareScope {
+	return &middlewareScope{pkg: pkg, routers: make(map[any][]string)}
+}
+
+// key returns the map key for a router expression.
+func (m *middlewareScope) key(expr ast.Expr) any {
+	if ident, ok := expr.(*ast.Ident); ok && m.pkg.TypesInfo != nil {
+		if obj := m.pkg.TypesInfo.ObjectOf(ident); obj != nil {
+			return obj
+		}
+	}
+	return types.ExprString(expr)
+}
+
+// inherited returns the middleware applying to routes registered on expr,
+// following scoping calls such as r.With(auth) or r.Group("/api", auth).
+func (m *middlewareScope) inherited(expr ast.Expr) []string {
+	if call, ok := expr.(*ast.CallExpr); ok {
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && routerScopingMethods[sel.Sel.Name] {
+			return append(m.inherited(sel.X), middlewareNames(call.Args)...)
+		}
+	}
+	return append([]string(nil), m.routers[m.key(expr)]...)
+}
+
+// visit records middleware from r.Use(...), sub-router assignments, and
+// Group/Route callbacks. It is called for every node before routes under
+// it are detected.
+func (m *middlewareScope) visit(n ast.Node) {
+	switch node := n.(type) {
+	case *ast.CallExpr:
+		sel, ok := node.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return
+		}
+		if sel.Sel.Name == "Use" {
+			k := m.key(sel.X)
+			m.routers[k] = append(m.routers[k], middlewareNames(node.Args)...)
+			return
+		}
+		if !routerScopingMethods[sel.Sel.Name] {
+			return
+		}
+		// r.Route("/x", func(r chi.Router) { ... }): the callback's router
+		// parameter inherits r's middleware
+		for _, arg := range node.Args {
+			lit, ok := arg.(*ast.FuncLit)
+			if !ok || len(lit.Type.Params.List) == 0 || len(lit.Type.Params.List[0].Names) == 0 {
+				continue
+			}
+			param := lit.Type.Params.List[0].Names[0]
+			m.routers[m.key(param)] = m.inherited(node)
+		}
+
+	case *ast.AssignStmt:
+		// api := r.Group("/api", auth)
+		if len(node.Lhs) != len(node.Rhs) {
+			return
+		}
+		for i, rhs := range node.Rhs {
+			call, ok := rhs.(*ast.CallExpr)
+			if !ok {
+				continue
+			}
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok && routerScopingMethods[sel.Sel.Name] {
+				m.routers[m.key(node.Lhs[i])] = m.inherited(call)
+			}
+		}
+	}
+}
+
+// unwrapHandler peels middleware calls off a handler expression, e.g.
+// requireAuth(logging(h)) or alice.New(auth).Then(h), returning the inner
+// handler and the wrapper names from outermost to innermost.
+// http.HandlerFunc conversions are not middleware and are skipped, and calls
+// taking no handler argument, such as the factory s.handleUsers(db), are
+// the handler itself.
+func unwrapHandler(pkg *packages.Package, expr ast.Expr) (ast.Expr, []string) {
+	var wrappers []string
+	for {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return expr, wrappers
+		}
+		inner := lastHandlerArg(pkg, call.Args)
+		if inner == nil {
+			return expr, wrappers
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			if chain, ok := sel.X.(*ast.CallExpr); ok {
+				// chain.Then(h): the middleware are the chain's arguments
+				wrappers = append(wrappers, types.ExprString(chain.Fun))
+				wrappers = append(wrappers, middlewareNames(chain.Args)...)
+				expr = inner
+				continue
+			}
+		}
+		if name := types.ExprString(call.Fun); !strings.HasSuffix(name, "HandlerFunc") {
+			wrappers = append(wrappers, name)
+		}
+		expr = inner
+	}
+}
+
+// lastHandlerArg returns the last argument that can be a handler (not a
+// literal, arithmetic, or a value of a non-handler type), e.g. h in
+// http.StripPrefix("/static", h).
+func lastHandlerArg(pkg *packages.Package, args []ast.Expr) ast.Expr {
+	for i := len(args) - 1; i >= 0; i-- {
+		switch args[i].(type) {
+		case *ast.Ident, *ast.SelectorExpr, *ast.CallExpr, *ast.FuncLit:
+			if isHandlerTyped(pkg, args[i]) {
+				return args[i]
+			}
+		}
+	}
+	return nil
+}
+
+// middlewareNames names middleware arguments: identifiers and selectors by
+// their text, constructor calls (jwtauth.Verifier(ja)) by the called
+// function. Literals such as route prefixes are skipped.
+func middlewareNames(args []ast.Expr) []string {
+	var names []string
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+			names = append(names, types.ExprString(a))
+		case *ast.CallExpr:
+			names = append(names, types.ExprString(a.Fun))
+		}
+	}
+	return names
+}
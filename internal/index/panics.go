@@ -0,0 +1,215 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/token"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/ssa"
+)
+
+// PanicAnalyzer finds HTTP and gRPC entrypoints from which a panic is
+// reachable without a recover in between. A panic is an explicit panic()
+// in project code or a call to a configured panicking function (Must*
+// helpers, log.Panic). A function recovers when it defers a function that
+// calls recover() directly; a route is also covered by a configured
+// recovering middleware. Panics in goroutines started along the way crash
+// the service whatever the handler and its middleware do.
+//
+// The analysis descends into project functions called statically. Calls
+// through an interface or a function value are not followed, and library
+// code is only seen through the configured panicking calls.
+type PanicAnalyzer struct {
+	cfg         *config.Config
+	prog        *ssa.Program
+	projectPkgs projectPackages
+	summaries   map[*ssa.Function]*panicSummary
+}
+
+// panicSummary is the result of analyzing one function.
+type panicSummary struct {
+	escapes   *panicSite // First panic reaching the function, before its own recover
+	goroutine *panicSite // First panic in a goroutine started from the function
+	recovers  bool       // The function recovers panics raised in it
+}
+
+// panicSite is a panicking call and the functions leading to it.
+type panicSite struct {
+	call string
+	pos  token.Position
+	path []string // Functions from the analyzed function to the one panicking
+}
+
+// PanicResult holds the results of panic analysis.
+type PanicResult struct {
+	Checked     int // HTTP and gRPC entrypoints checked
+	Unrecovered int // Entrypoints with a reachable unrecovered panic
+}
+
+// NewPanicAnalyzer creates a panic analyzer for the loader's packages.
+func NewPanicAnalyzer(cfg *config.Config, loader *Loader, prog *ssa.Program) *PanicAnalyzer {
+	return &PanicAnalyzer{
+		cfg:         cfg,
+		prog:        prog,
+		projectPkgs: newProjectPackages(loader),
+		summaries:   make(map[*ssa.Function]*panicSummary),
+	}
+}
+
+// Analyze classifies every HTTP and gRPC entrypoint and records the results.
+func (a *PanicAnalyzer) Analyze(ctx context.Context, st *store.Store) (*PanicResult, error) {
+	result := &PanicResult{}
+	err := forEachHandler(ctx, st, a.prog, handlerTypes, func(batch *store.BatchTx, ep *store.EntrypointWithSymbol, fn *ssa.Function) error {
+		var meta HTTPMeta
+		if ep.Type == store.EntrypointHTTP && ep.MetaJSON != "" {
+			json.Unmarshal([]byte(ep.MetaJSON), &meta)
+		}
+
+		check := a.classify(fn, meta.Middleware)
+		check.EntrypointID = ep.ID
+		if err := batch.InsertPanicCheck(ctx, check); err != nil {
+			return fmt.Errorf("inserting panic check: %w", err)
+		}
+		result.Checked++
+		if check.Status == store.PanicUnrecovered {
+			result.Unrecovered++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// classify decides the panic status of one handler. A goroutine panic is
+// never recovered; otherwise the handler's own recover wins, then a
+// recovering middleware.
+func (a *PanicAnalyzer) classify(fn *ssa.Function, middleware []string) *store.PanicCheck {
+	sum := a.analyze(fn)
+	check := &store.PanicCheck{Status: store.PanicNone}
+
+	site := sum.escapes
+	if sum.goroutine != nil {
+		site = sum.goroutine
+		check.Goroutine = true
+	}
+	if site == nil {
+		return check
+	}
+	check.Call = site.call
+	check.File = site.pos.Filename
+	check.Line = site.pos.Line
+	check.Path = site.path
+
+	check.Status = store.PanicUnrecovered
+	if check.Goroutine {
+		return check
+	}
+	if sum.recovers {
+		check.Status = store.PanicRecovered
+		check.RecoveredBy = fn.String()
+		return check
+	}
+	for _, m := range middleware {
+		if a.cfg.IsRecoverMiddleware(m) {
+			check.Status = store.PanicRecovered
+			check.RecoveredBy = m
+			return check
+		}
+	}
+	return check
+}
+
+// analyze computes the summary of fn. Recursive calls see the in-progress
+// (initially empty) summary.
+func (a *PanicAnalyzer) analyze(fn *ssa.Function) *panicSummary {
+	if sum, ok := a.summaries[fn]; ok {
+		return sum
+	}
+	sum := &panicSummary{}
+	a.summaries[fn] = sum
+
+	label := fn.String()
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			switch v := instr.(type) {
+			case *ssa.Panic:
+				if sum.escapes == nil {
+					sum.escapes = &panicSite{call: "panic", pos: a.prog.Fset.Position(v.Pos()), path: []string{label}}
+				}
+			case *ssa.Defer:
+				if callee := v.Call.StaticCallee(); callee != nil && callsRecover(callee) {
+					sum.recovers = true
+				}
+				a.call(v, label, sum)
+			case ssa.CallInstruction:
+				a.call(v, label, sum)
+			}
+		}
+	}
+	return sum
+}
+
+// call merges the panics reachable through one call instruction of the
+// function named label into its summary.
+func (a *PanicAnalyzer) call(instr ssa.CallInstruction, label string, sum *panicSummary) {
+	callee := instr.Common().StaticCallee()
+	if callee == nil {
+		return
+	}
+
+	if !a.projectPkgs.follows(callee) {
+		name := ssaFuncName(callee)
+		if name == "" || !a.cfg.IsPanicCall(name) {
+			return
+		}
+		site := &panicSite{call: name, pos: a.prog.Fset.Position(instr.Pos()), path: []string{label}}
+		if _, ok := instr.(*ssa.Go); ok {
+			if sum.goroutine == nil {
+				sum.goroutine = site
+			}
+		} else if sum.escapes == nil {
+			sum.escapes = site
+		}
+		return
+	}
+
+	sub := a.analyze(callee)
+	prefix := func(site *panicSite) *panicSite {
+		return &panicSite{call: site.call, pos: site.pos, path: append([]string{label}, site.path...)}
+	}
+	if sum.goroutine == nil && sub.goroutine != nil {
+		sum.goroutine = prefix(sub.goroutine)
+	}
+	if sub.escapes == nil || sub.recovers {
+		return
+	}
+	if _, ok := instr.(*ssa.Go); ok {
+		if sum.goroutine == nil {
+			sum.goroutine = prefix(sub.escapes)
+		}
+	} else if sum.escapes == nil {
+		sum.escapes = prefix(sub.escapes)
+	}
+}
+
+// callsRecover reports whether fn calls the recover builtin directly, which
+// is what stops a panic when fn is deferred.
+func callsRecover(fn *ssa.Function) bool {
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			call, ok := instr.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			if b, ok := call.Call.Value.(*ssa.Builtin); ok && b.Name() == "recover" {
+				return true
+			}
+		}
+	}
+	return false
+}
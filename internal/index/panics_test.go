@@ -0,0 +1,177 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestPanicAnalyzer(t *testing.T) {
+	// A dependency-free app with its own request type; rx stands in for a
+	// library with a panicking Must helper
+	root := t.TempDir()
+	src := `package main
+
+import "example.com/rx"
+
+type ResponseWriter interface{ WriteHeader(code int) }
+
+type Request struct{ Path, Pattern string }
+
+func load(id string) string {
+	if id == "" {
+		panic("missing id")
+	}
+	return id
+}
+
+func recoverPanic() { recover() }
+
+func safeLoad(id string) string {
+	defer recoverPanic()
+	return load(id)
+}
+
+func plain(w ResponseWriter, r *Request) { w.WriteHeader(200) }
+
+func explicit(w ResponseWriter, r *Request) { load(r.Path) }
+
+func must(w ResponseWriter, r *Request) {
+	rx.MustCompile(r.Pattern)
+}
+
+func guarded(w ResponseWriter, r *Request) {
+	defer func() {
+		if err := recover(); err != nil {
+			w.WriteHeader(500)
+		}
+	}()
+	load(r.Path)
+}
+
+func wrapped(w ResponseWriter, r *Request) { load(r.Path) }
+
+func spawned(w ResponseWriter, r *Request) {
+	defer recoverPanic()
+	go load(r.Path)
+}
+
+func contained(w ResponseWriter, r *Request) { safeLoad(r.Path) }
+
+func main() {}
+`
+	files := map[string]string{
+		"rx/go.mod":   "module example.com/rx\n\ngo 1.21\n",
+		"rx/rx.go":    "package rx\n\nfunc MustCompile(s string) string { panic(s) }\n",
+		"app/go.mod":  "module panicmod\n\ngo 1.21\n\nrequire example.com/rx v0.1.0\n\nreplace example.com/rx => ../rx\n",
+		"app/main.go": src,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	tmpDir := filepath.Join(root, "app")
+
+	cfg := config.Default()
+	loader := NewLoader(cfg, tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	middleware := map[string]string{
+		"wrapped": `{"method":"GET","path":"/wrapped","middleware":["middleware.Logger","middleware.Recoverer"]}`,
+		"spawned": `{"method":"GET","path":"/spawned","middleware":["middleware.Recoverer"]}`,
+	}
+	for _, name := range []string{"plain", "explicit", "must", "guarded", "wrapped", "spawned", "contained"} {
+		id, err := st.FindSymbolID(t.Context(), "panicmod", name, "")
+		if err != nil {
+			t.Fatalf("finding %s: %v", name, err)
+		}
+		ep := &store.Entrypoint{Type: store.EntrypointHTTP, Label: "GET /" + name, SymbolID: id, MetaJSON: middleware[name]}
+		if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
+			t.Fatalf("inserting entrypoint: %v", err)
+		}
+	}
+
+	cg := NewCallGraphBuilder(loader)
+	if err := cg.Build(); err != nil {
+		t.Fatalf("building SSA: %v", err)
+	}
+	result, err := NewPanicAnalyzer(cfg, loader, cg.GetSSAProgram()).Analyze(t.Context(), st)
+	if err != nil {
+		t.Fatalf("analyzing: %v", err)
+	}
+	if result.Checked != 7 || result.Unrecovered != 3 {
+		t.Errorf("expected 7 checked and 3 unrecovered, got %+v", result)
+	}
+
+	checks, err := st.GetPanicChecks(t.Context(), "")
+	if err != nil {
+		t.Fatalf("getting panic checks: %v", err)
+	}
+	byLabel := make(map[string]store.PanicCheck)
+	for _, c := range checks {
+		byLabel[c.EntrypointLabel] = c
+	}
+	tests := []struct {
+		label, status, call, recoveredBy string
+		goroutine                        bool
+	}{
+		{"GET /plain", store.PanicNone, "", "", false},
+		{"GET /explicit", store.PanicUnrecovered, "panic", "", false},
+		{"GET /must", store.PanicUnrecovered, "example.com/rx.MustCompile", "", false},
+		{"GET /guarded", store.PanicRecovered, "panic", "panicmod.guarded", false},
+		{"GET /wrapped", store.PanicRecovered, "panic", "middleware.Recoverer", false},
+		{"GET /spawned", store.PanicUnrecovered, "panic", "", true},
+		{"GET /contained", store.PanicNone, "", "", false},
+	}
+	for _, tt := range tests {
+		c, ok := byLabel[tt.label]
+		if !ok {
+			t.Errorf("%s: no panic check", tt.label)
+			continue
+		}
+		if c.Status != tt.status || c.Call != tt.call || c.RecoveredBy != tt.recoveredBy || c.Goroutine != tt.goroutine {
+			t.Errorf("%s: got status %q call %q recovered by %q goroutine %v, want %q %q %q %v",
+				tt.label, c.Status, c.Call, c.RecoveredBy, c.Goroutine, tt.status, tt.call, tt.recoveredBy, tt.goroutine)
+		}
+	}
+
+	// The path leads from the handler to the panicking function
+	explicit := byLabel["GET /explicit"]
+	if got := strings.Join(explicit.Path, " > "); got != "panicmod.explicit > panicmod.load" {
+		t.Errorf("explicit: expected path through load, got %q", got)
+	}
+	if filepath.Base(explicit.File) != "main.go" || explicit.Line != 11 {
+		t.Errorf("explicit: expected the panic at main.go:11, got %s:%d", explicit.File, explicit.Line)
+	}
+
+	// Entrypoints carry the unrecovered flag
+	eps, err := st.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	for _, ep := range eps {
+		want := byLabel[ep.Label].Status == store.PanicUnrecovered
+		if ep.UnrecoveredPanic != want {
+			t.Errorf("%s: expected unrecovered_panic %v, got %v", ep.Label, want, ep.UnrecoveredPanic)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Calls that decode a request body or encode a response, by qualified name,
+// with the index of the payload argument (after any receiver).
+var (
+	decodeCalls = map[string]int{
+		"encoding/json.Decoder.Decode": 0,
+		"encoding/json.Unmarshal":      1,
+		"encoding/xml.Decoder.Decode":  0,
+		"encoding/xml.Unmarshal":       1,
+	}
+	encodeCalls = map[string]int{
+		"encoding/json.Encoder.Encode": 0,
+		"encoding/xml.Encoder.Encode":  0,
+	}
+)
+
+// Framework context methods (gin.Context, echo.Context) that bind the
+// request or render the response, with the index of the payload argument.
+var (
+	bindMethods = map[string]int{
+		"Bind": 0, "BindJSON": 0, "BindXML": 0,
+		"ShouldBind": 0, "ShouldBindJSON": 0, "ShouldBindXML": 0,
+	}
+	renderMethods = map[string]int{
+		"JSON": 1, "IndentedJSON": 1, "PureJSON": 1, "SecureJSON": 1, "JSONP": 1,
+		"XML": 1, "AbortWithStatusJSON": 1,
+	}
+)
+
+// PayloadAnalyzer infers the request and response types of HTTP handlers
+// from the values passed to JSON/XML decoders and encoders and to framework
+// bind and render methods. Payloads passed as an interface, as in a generic
+// decode helper, have no static type and are skipped.
+//
+// Decoders, encoders, and framework methods are recognized through
+// interfaces too, in the handler and the project functions it calls
+// statically; helpers reached through an interface or a function value are
+// not searched.
+type PayloadAnalyzer struct {
+	prog        *ssa.Program
+	projectPkgs projectPackages
+	summaries   map[*ssa.Function]*payloadSummary
+}
+
+// payloadSummary holds the payload types reachable from one function, in
+// the order they are first seen.
+type payloadSummary struct {
+	requests  []string
+	responses []string
+}
+
+// PayloadResult holds the results of payload inference.
+type PayloadResult struct {
+	Checked   int // HTTP entrypoints checked
+	Requests  int // Entrypoints with an inferred request type
+	Responses int // Entrypoints with at least one inferred response type
+}
+
+// NewPayloadAnalyzer creates a payload analyzer for the loader's packages.
+func NewPayloadAnalyzer(loader *Loader, prog *ssa.Program) *PayloadAnalyzer {
+	return &PayloadAnalyzer{
+		prog:        prog,
+		projectPkgs: newProjectPackages(loader),
+		summaries:   make(map[*ssa.Function]*payloadSummary),
+	}
+}
+
+// Analyze records the request and response types of every HTTP entrypoint
+// in its metadata.
+func (a *PayloadAnalyzer) Analyze(ctx context.Context, st *store.Store) (*PayloadResult, error) {
+	result := &PayloadResult{}
+	err := forEachHandler(ctx, st, a.prog, []store.EntrypointType{store.EntrypointHTTP}, func(batch *store.BatchTx, ep *store.EntrypointWithSymbol, fn *ssa.Function) error {
+		result.Checked++
+
+		sum := a.analyze(fn)
+		if len(sum.requests) > 0 {
+			// The first body decoded is the request; later ones are
+			// usually nested or follow-up payloads
+			if err := batch.SetEntrypointMeta(ctx, ep.ID, "request_type", sum.requests[0]); err != nil {
+				return fmt.Errorf("storing request type: %w", err)
+			}
+			result.Requests++
+		}
+		if len(sum.responses) > 0 {
+			if err := batch.SetEntrypointMeta(ctx, ep.ID, "response_types", sum.responses); err != nil {
+				return fmt.Errorf("storing response types: %w", err)
+			}
+			result.Responses++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// analyze computes the payload types reachable from fn. Recursive calls see
+// the in-progress summary.
+func (a *PayloadAnalyzer) analyze(fn *ssa.Function) *payloadSummary {
+	if sum, ok := a.summaries[fn]; ok {
+		return sum
+	}
+	sum := &payloadSummary{}
+	a.summaries[fn] = sum
+
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			call, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			common := call.Common()
+			a.record(common, sum)
+
+			callee := common.StaticCallee()
+			if !a.projectPkgs.follows(callee) {
+				continue
+			}
+			sub := a.analyze(callee)
+			for _, t := range sub.requests {
+				sum.requests = appendUnique(sum.requests, t)
+			}
+			for _, t := range sub.responses {
+				sum.responses = appendUnique(sum.responses, t)
+			}
+		}
+	}
+	return sum
+}
+
+// record adds the payload type decoded or encoded by one call, if any, to
+// sum.
+func (a *PayloadAnalyzer) record(common *ssa.CallCommon, sum *payloadSummary) {
+	name := calleeName(common)
+	if name == "" {
+		return
+	}
+	args := common.Args
+	if !common.IsInvoke() && common.Signature().Recv() != nil && len(args) > 0 {
+		args = args[1:]
+	}
+
+	dot := strings.LastIndex(name, ".")
+	recv, method := name[:dot], name[dot+1:]
+	isContext := strings.HasSuffix(recv, "Context")
+	if i, ok := decodeCalls[name]; ok {
+		sum.requests = appendPayload(sum.requests, args, i)
+	} else if i, ok := bindMethods[method]; ok && isContext {
+		sum.requests = appendPayload(sum.requests, args, i)
+	} else if i, ok := encodeCalls[name]; ok {
+		sum.responses = appendPayload(sum.responses, args, i)
+	} else if i, ok := renderMethods[method]; ok && isContext {
+		sum.responses = appendPayload(sum.responses, args, i)
+	}
+}
+
+// appendPayload adds the static type of args[i] to list, looking through
+// the conversion to interface and one pointer.
+func appendPayload(list []string, args []ssa.Value, i int) []string {
+	if i >= len(args) {
+		return list
+	}
+	mi, ok := args[i].(*ssa.MakeInterface)
+	if !ok {
+		return list
+	}
+	t := mi.X.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if types.IsInterface(t) {
+		return list
+	}
+	return appendUnique(list, types.TypeString(t, nil))
+}
+
+// appendUnique appends s to list unless it is already present.
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,128 @@
+package index
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestPayloadAnalyzer(t *testing.T) {
+	// A dependency-free app; gin stands in for the real library
+	root := t.TempDir()
+	src := `package main
+
+import "github.com/gin-gonic/gin"
+
+type CreateUser struct{ Name string }
+
+type User struct{ ID int }
+
+type Problem struct{ Detail string }
+
+func bind(c *gin.Context, v any) error { return c.ShouldBindJSON(v) }
+
+func create(c *gin.Context) {
+	var req CreateUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, Problem{Detail: err.Error()})
+		return
+	}
+	render(c, &User{ID: 1})
+}
+
+func render(c *gin.Context, u *User) { c.JSON(201, u) }
+
+func list(c *gin.Context) { c.JSON(200, []User{}) }
+
+func generic(c *gin.Context) {
+	var req CreateUser
+	bind(c, &req)
+}
+
+func main() {}
+`
+	files := map[string]string{
+		"gin/go.mod": "module github.com/gin-gonic/gin\n\ngo 1.21\n",
+		"gin/context.go": "package gin\n\ntype Context struct{}\n\n" +
+			"func (c *Context) ShouldBindJSON(obj any) error { return nil }\n\nfunc (c *Context) JSON(code int, obj any) {}\n",
+		"app/go.mod":  "module payloadmod\n\ngo 1.21\n\nrequire github.com/gin-gonic/gin v0.1.0\n\nreplace github.com/gin-gonic/gin => ../gin\n",
+		"app/main.go": src,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	tmpDir := filepath.Join(root, "app")
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	for _, name := range []string{"create", "list", "generic"} {
+		id, err := st.FindSymbolID(t.Context(), "payloadmod", name, "")
+		if err != nil {
+			t.Fatalf("finding %s: %v", name, err)
+		}
+		ep := &store.Entrypoint{Type: store.EntrypointHTTP, Label: name, SymbolID: id, MetaJSON: `{"method":"POST","path":"/` + name + `"}`}
+		if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
+			t.Fatalf("inserting entrypoint: %v", err)
+		}
+	}
+
+	cg := NewCallGraphBuilder(loader)
+	if err := cg.Build(); err != nil {
+		t.Fatalf("building SSA: %v", err)
+	}
+	result, err := NewPayloadAnalyzer(loader, cg.GetSSAProgram()).Analyze(t.Context(), st)
+	if err != nil {
+		t.Fatalf("analyzing: %v", err)
+	}
+	// generic binds through an interface parameter, so its type is unknown
+	if result.Checked != 3 || result.Requests != 1 || result.Responses != 2 {
+		t.Errorf("expected 3 checked, 1 request, and 2 responses, got %+v", result)
+	}
+
+	eps, err := st.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	tests := map[string]struct {
+		request   string
+		responses []string
+	}{
+		"create":  {"payloadmod.CreateUser", []string{"payloadmod.Problem", "payloadmod.User"}},
+		"list":    {"", []string{"[]payloadmod.User"}},
+		"generic": {"", nil},
+	}
+	for _, ep := range eps {
+		var meta HTTPMeta
+		if err := json.Unmarshal([]byte(ep.MetaJSON), &meta); err != nil {
+			t.Fatalf("decoding meta of %s: %v", ep.Label, err)
+		}
+		want := tests[ep.Label]
+		if meta.RequestType != want.request || !reflect.DeepEqual(meta.ResponseTypes, want.responses) {
+			t.Errorf("payloads of %s = %q %v, want %q %v", ep.Label, meta.RequestType, meta.ResponseTypes, want.request, want.responses)
+		}
+		if meta.Path != "/"+ep.Label {
+			t.Errorf("meta of %s lost its path: %+v", ep.Label, meta)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package index
+
+import (
+	"runtime"
+	"time"
+)
+
+// PhaseStats is the time and memory one phase of an indexing run took.
+type PhaseStats struct {
+	Name       string        `json:"name"`
+	Duration   time.Duration `json:"duration_ns"`
+	AllocBytes uint64        `json:"alloc_bytes"` // Heap allocated during the phase
+	HeapBytes  uint64        `json:"heap_bytes"`  // Heap in use when the phase ended
+}
+
+// phaseTimer splits a run into consecutive phases.
+type phaseTimer struct {
+	stats   []PhaseStats
+	current *PhaseStats
+	start   time.Time
+	alloc   uint64
+}
+
+// begin ends the current phase, if any, and starts the named one.
+func (t *phaseTimer) begin(name string) {
+	t.end()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	t.current = &PhaseStats{Name: name}
+	t.start = time.Now()
+	t.alloc = mem.TotalAlloc
+}
+
+// end records the current phase.
+func (t *phaseTimer) end() {
+	if t.current == nil {
+		return
+	}
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	t.current.Duration = time.Since(t.start)
+	t.current.AllocBytes = mem.TotalAlloc - t.alloc
+	t.current.HeapBytes = mem.HeapInuse
+	t.stats = append(t.stats, *t.current)
+	t.current = nil
+}
@@ -0,0 +1,134 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/packages"
+)
+
+// ReferenceResult holds the results of reference extraction.
+type ReferenceResult struct {
+	References int // Uses of constants recorded
+}
+
+// ExtractReferences records every use of a package-level constant that has a
+// symbol, attributed to the declaration containing it, so the places a
+// route prefix, topic name, or timeout is used can be listed.
+func ExtractReferences(ctx context.Context, loader *Loader, st *store.Store) (*ReferenceResult, error) {
+	batch, err := st.BeginBatch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("starting batch: %w", err)
+	}
+	defer batch.Rollback()
+
+	// Constant symbol IDs by object; 0 when the constant has no symbol
+	consts := make(map[*types.Const]store.SymbolID)
+	constID := func(c *types.Const) store.SymbolID {
+		if id, ok := consts[c]; ok {
+			return id
+		}
+		id, _ := batch.GetSymbolID(ctx, c.Pkg().Path(), c.Name(), "")
+		consts[c] = id
+		return id
+	}
+
+	result := &ReferenceResult{}
+	fset := loader.FileSet()
+	for _, pkg := range loader.Packages() {
+		for i, file := range pkg.Syntax {
+			goFile := pkg.GoFiles[i]
+			if loader.shouldExcludeFile(goFile) {
+				continue
+			}
+			for _, node := range declNodes(file) {
+				fromID := declSymbolID(ctx, batch, pkg, node)
+				if fromID == 0 {
+					continue
+				}
+				var insertErr error
+				ast.Inspect(node, func(n ast.Node) bool {
+					ident, ok := n.(*ast.Ident)
+					if !ok || insertErr != nil {
+						return insertErr == nil
+					}
+					c, ok := pkg.TypesInfo.Uses[ident].(*types.Const)
+					if !ok || c.Pkg() == nil || c.Parent() != c.Pkg().Scope() {
+						return true
+					}
+					id := constID(c)
+					if id == 0 {
+						return true
+					}
+					pos := fset.Position(ident.Pos())
+					insertErr = batch.InsertReference(ctx, &store.Reference{
+						SymbolID: id,
+						From:     store.Symbol{ID: fromID},
+						File:     pos.Filename,
+						Line:     pos.Line,
+						Column:   pos.Column,
+					})
+					result.References++
+					return true
+				})
+				if insertErr != nil {
+					return nil, fmt.Errorf("inserting reference in %s: %w", goFile, insertErr)
+				}
+			}
+		}
+	}
+
+	if err := batch.Commit(); err != nil {
+		return nil, fmt.Errorf("committing batch: %w", err)
+	}
+	return result, nil
+}
+
+// declNodes returns the top-level declarations of a file that can contain
+// references: functions and methods, and each spec of var, const, and type
+// declarations, so uses within a grouped declaration are attributed to the
+// spec containing them.
+func declNodes(file *ast.File) []ast.Node {
+	var nodes []ast.Node
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			nodes = append(nodes, d)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if _, ok := spec.(*ast.ImportSpec); !ok {
+					nodes = append(nodes, spec)
+				}
+			}
+		}
+	}
+	return nodes
+}
+
+// declSymbolID returns the symbol of a declaration node from declNodes: the
+// function or method, the first name of a value spec, or the type. It
+// returns 0 when there is no symbol.
+func declSymbolID(ctx context.Context, batch *store.BatchTx, pkg *packages.Package, node ast.Node) store.SymbolID {
+	var name, recvType string
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		name = n.Name.Name
+		if n.Recv != nil && len(n.Recv.List) > 0 {
+			recvType = formatReceiverType(n.Recv.List[0].Type)
+		}
+	case *ast.ValueSpec:
+		name = n.Names[0].Name
+	case *ast.TypeSpec:
+		name = n.Name.Name
+	default:
+		return 0
+	}
+	id, err := batch.GetSymbolID(ctx, pkg.PkgPath, name, recvType)
+	if err != nil {
+		return 0
+	}
+	return id
+}
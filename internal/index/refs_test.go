@@ -0,0 +1,119 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestExtractReferences(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package app
+
+import "time"
+
+const (
+	APIPrefix = "/api/v1"
+	UsersPath = APIPrefix + "/users"
+)
+
+const (
+	Low = iota + 1
+	High
+)
+
+const Timeout = 5 * time.Second
+
+var defaultTimeout = Timeout
+
+type Client struct{}
+
+func (c *Client) Do() time.Duration { return Timeout }
+
+func routes() []string {
+	return []string{APIPrefix, UsersPath}
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "app.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing app.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module app\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	// Constants carry their resolved values
+	for name, want := range map[string]string{
+		"APIPrefix": `"/api/v1"`,
+		"UsersPath": `"/api/v1/users"`,
+		"High":      "2",
+		"Timeout":   "5000000000",
+	} {
+		id, err := st.GetSymbolID(t.Context(), "app", name, "")
+		if err != nil {
+			t.Fatalf("finding %s: %v", name, err)
+		}
+		sym, err := st.GetSymbolByID(t.Context(), id)
+		if err != nil {
+			t.Fatalf("getting %s: %v", name, err)
+		}
+		if sym.Value != want {
+			t.Errorf("%s: expected value %s, got %s", name, want, sym.Value)
+		}
+	}
+
+	result, err := ExtractReferences(t.Context(), loader, st)
+	if err != nil {
+		t.Fatalf("extracting references: %v", err)
+	}
+	if result.References != 5 {
+		t.Errorf("expected 5 references, got %d", result.References)
+	}
+
+	for name, want := range map[string][]string{
+		"APIPrefix": {"UsersPath", "routes"},
+		"Timeout":   {"defaultTimeout", "Do"},
+		"Low":       nil,
+	} {
+		id, err := st.GetSymbolID(t.Context(), "app", name, "")
+		if err != nil {
+			t.Fatalf("finding %s: %v", name, err)
+		}
+		refs, err := st.GetReferences(t.Context(), id)
+		if err != nil {
+			t.Fatalf("getting references to %s: %v", name, err)
+		}
+		var from []string
+		for _, ref := range refs {
+			from = append(from, ref.From.Name)
+			if ref.File != filepath.Join(tmpDir, "app.go") || ref.Line == 0 {
+				t.Errorf("%s: unexpected position %s:%d", name, ref.File, ref.Line)
+			}
+		}
+		if len(from) != len(want) {
+			t.Errorf("%s: expected references from %v, got %v", name, want, from)
+			continue
+		}
+		for i := range want {
+			if from[i] != want[i] {
+				t.Errorf("%s: expected references from %v, got %v", name, want, from)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Resilience is the timeout and retry policy found along an entrypoint's
+// call tree, stored as "resilience" in its metadata.
+type Resilience struct {
+	Timeouts []TimeoutSite `json:"timeouts,omitempty"`
+	Retries  []RetrySite   `json:"retries,omitempty"`
+	Summary  string        `json:"summary"` // e.g. "5s timeout; retries payment.Client.Charge up to 3 times"
+}
+
+// TimeoutSite is a deadline set on a context or a client.
+type TimeoutSite struct {
+	Kind     string `json:"kind"`               // "context", "deadline", or "client"
+	Call     string `json:"call"`               // e.g. "context.WithTimeout" or "net/http.Client.Timeout"
+	Duration string `json:"duration,omitempty"` // Constant durations only, e.g. "5s"
+	Function string `json:"function"`           // Function setting the timeout
+}
+
+// RetrySite is a call into a retry library.
+type RetrySite struct {
+	Call     string `json:"call"`               // e.g. "github.com/cenkalti/backoff/v4.Retry"
+	Target   string `json:"target,omitempty"`   // First call made by the retried operation
+	Attempts int    `json:"attempts,omitempty"` // Constant attempt limit, when configured in the same function
+	Function string `json:"function"`           // Function calling the retry library
+}
+
+// retryCalls are retry library entry points, by package path without its
+// major version suffix, with the index of the retried operation argument.
+var retryCalls = map[string]int{
+	"github.com/cenkalti/backoff.Retry":               0,
+	"github.com/cenkalti/backoff.RetryNotify":         0,
+	"github.com/cenkalti/backoff.RetryWithData":       0,
+	"github.com/cenkalti/backoff.RetryNotifyWithData": 0,
+	"github.com/avast/retry-go.Do":                    0,
+	"github.com/avast/retry-go.DoWithData":            0,
+}
+
+// attemptCalls configure a retry limit, with the index of the count.
+var attemptCalls = map[string]int{
+	"github.com/cenkalti/backoff.WithMaxRetries": 1,
+	"github.com/cenkalti/backoff.WithMaxTries":   0,
+	"github.com/avast/retry-go.Attempts":         0,
+}
+
+// majorVersion matches the major version element of a module path.
+var majorVersion = regexp.MustCompile(`/v[0-9]+([./]|$)`)
+
+// ResilienceAnalyzer finds the timeouts and retries along each entrypoint's
+// call tree: context.WithTimeout and WithDeadline, constant Timeout fields
+// set on clients such as http.Client, and calls into cenkalti/backoff and
+// avast/retry-go, with the operation they retry.
+//
+// The handler and the project functions it calls statically are searched.
+// Functions reached only through an interface, a function value, or a
+// closure handed to a retry library are not, though a retried closure's
+// first call is named as the retry's target.
+type ResilienceAnalyzer struct {
+	prog        *ssa.Program
+	projectPkgs projectPackages
+	summaries   map[*ssa.Function]*Resilience
+}
+
+// ResilienceResult holds the results of resilience analysis.
+type ResilienceResult struct {
+	WithTimeouts int // Entrypoints with at least one timeout
+	WithRetries  int // Entrypoints with at least one retry
+}
+
+// NewResilienceAnalyzer creates a resilience analyzer for the loader's
+// packages.
+func NewResilienceAnalyzer(loader *Loader, prog *ssa.Program) *ResilienceAnalyzer {
+	return &ResilienceAnalyzer{
+		prog:        prog,
+		projectPkgs: newProjectPackages(loader),
+		summaries:   make(map[*ssa.Function]*Resilience),
+	}
+}
+
+// Analyze records the timeout and retry policy of every entrypoint that has
+// one in its metadata.
+func (a *ResilienceAnalyzer) Analyze(ctx context.Context, st *store.Store) (*ResilienceResult, error) {
+	result := &ResilienceResult{}
+	err := forEachHandler(ctx, st, a.prog, nil, func(batch *store.BatchTx, ep *store.EntrypointWithSymbol, fn *ssa.Function) error {
+		res := a.analyze(fn)
+		if len(res.Timeouts) == 0 && len(res.Retries) == 0 {
+			return nil
+		}
+		meta := *res
+		meta.Summary = summarizeResilience(&meta)
+		if err := batch.SetEntrypointMeta(ctx, ep.ID, "resilience", &meta); err != nil {
+			return fmt.Errorf("storing resilience: %w", err)
+		}
+		if len(res.Timeouts) > 0 {
+			result.WithTimeouts++
+		}
+		if len(res.Retries) > 0 {
+			result.WithRetries++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// analyze computes the timeouts and retries reachable from fn, in the order
+// they are first seen. Recursive calls see the in-progress summary.
+func (a *ResilienceAnalyzer) analyze(fn *ssa.Function) *Resilience {
+	if res, ok := a.summaries[fn]; ok {
+		return res
+	}
+	res := &Resilience{}
+	a.summaries[fn] = res
+
+	label := fn.String()
+	if name := ssaFuncName(fn); name != "" {
+		label = name
+	}
+	attempts := 0
+	var retries []RetrySite
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			if st, ok := instr.(*ssa.Store); ok {
+				if site := clientTimeout(st); site != nil {
+					site.Function = label
+					res.Timeouts = appendTimeout(res.Timeouts, *site)
+				}
+				continue
+			}
+			call, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			common := call.Common()
+			name := calleeName(common)
+			unversioned := majorVersion.ReplaceAllString(name, "$1")
+			if name == "context.WithTimeout" {
+				res.Timeouts = appendTimeout(res.Timeouts, TimeoutSite{
+					Kind: "context", Call: name, Duration: constDuration(common.Args, 1), Function: label,
+				})
+			} else if name == "context.WithDeadline" {
+				res.Timeouts = appendTimeout(res.Timeouts, TimeoutSite{Kind: "deadline", Call: name, Function: label})
+			} else if i, ok := retryCalls[unversioned]; ok {
+				retries = append(retries, RetrySite{Call: name, Target: retriedCall(common.Args, i), Function: label})
+			} else if i, ok := attemptCalls[unversioned]; ok && attempts == 0 {
+				attempts, _ = constInt(common.Args, i)
+			}
+
+			callee := common.StaticCallee()
+			if !a.projectPkgs.follows(callee) {
+				continue
+			}
+			sub := a.analyze(callee)
+			for _, t := range sub.Timeouts {
+				res.Timeouts = appendTimeout(res.Timeouts, t)
+			}
+			for _, r := range sub.Retries {
+				res.Retries = appendRetry(res.Retries, r)
+			}
+		}
+	}
+	for _, r := range retries {
+		r.Attempts = attempts
+		res.Retries = appendRetry(res.Retries, r)
+	}
+	return res
+}
+
+// appendTimeout appends t to list unless an identical site is present, as
+// when a helper is reached along several paths.
+func appendTimeout(list []TimeoutSite, t TimeoutSite) []TimeoutSite {
+	for _, existing := range list {
+		if existing == t {
+			return list
+		}
+	}
+	return append(list, t)
+}
+
+// appendRetry appends r to list unless an identical site is present.
+func appendRetry(list []RetrySite, r RetrySite) []RetrySite {
+	for _, existing := range list {
+		if existing == r {
+			return list
+		}
+	}
+	return append(list, r)
+}
+
+// clientTimeout returns the timeout set by a store of a constant duration
+// into a field named Timeout, as in &http.Client{Timeout: 5 * time.Second}.
+func clientTimeout(s *ssa.Store) *TimeoutSite {
+	fa, ok := s.Addr.(*ssa.FieldAddr)
+	if !ok {
+		return nil
+	}
+	st, ok := pointedStruct(fa.X.Type())
+	if !ok {
+		return nil
+	}
+	field := st.Field(fa.Field)
+	if field.Name() != "Timeout" {
+		return nil
+	}
+	duration := constDuration([]ssa.Value{s.Val}, 0)
+	if duration == "" {
+		return nil
+	}
+	call := field.Name()
+	if owner := namedTypeName(fa.X.Type()); owner != "" {
+		call = owner + "." + field.Name()
+	}
+	return &TimeoutSite{Kind: "client", Call: call, Duration: duration}
+}
+
+// pointedStruct returns the struct type a pointer type points to.
+func pointedStruct(t types.Type) (*types.Struct, bool) {
+	ptr, ok := t.Underlying().(*types.Pointer)
+	if !ok {
+		return nil, false
+	}
+	st, ok := ptr.Elem().Underlying().(*types.Struct)
+	return st, ok
+}
+
+// constDuration formats args[i] as a time.Duration when it is a constant.
+func constDuration(args []ssa.Value, i int) string {
+	n, ok := constInt(args, i)
+	if !ok || n <= 0 {
+		return ""
+	}
+	if named, ok := args[i].Type().(*types.Named); !ok || named.Obj().Name() != "Duration" {
+		return ""
+	}
+	return time.Duration(n).String()
+}
+
+// retriedCall returns the first call made by the operation passed as
+// args[i], when it is a function literal or a named function.
+func retriedCall(args []ssa.Value, i int) string {
+	if i >= len(args) {
+		return ""
+	}
+	var op *ssa.Function
+	switch v := args[i].(type) {
+	case *ssa.MakeClosure:
+		op, _ = v.Fn.(*ssa.Function)
+	case *ssa.Function:
+		op = v
+	}
+	if op == nil {
+		return ""
+	}
+	for _, block := range op.Blocks {
+		for _, instr := range block.Instrs {
+			if call, ok := instr.(ssa.CallInstruction); ok {
+				if name := calleeName(call.Common()); name != "" {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// summarizeResilience describes a policy in one line, e.g.
+// "5s timeout; retries payment.Client.Charge up to 3 times".
+func summarizeResilience(res *Resilience) string {
+	var parts []string
+	for _, t := range res.Timeouts {
+		switch {
+		case t.Kind == "deadline":
+			parts = append(parts, "deadline")
+		case t.Kind == "client" && t.Duration != "":
+			parts = append(parts, fmt.Sprintf("%s timeout on %s", t.Duration, shortFuncName(strings.TrimSuffix(t.Call, ".Timeout"))))
+		case t.Duration != "":
+			parts = append(parts, t.Duration+" timeout")
+		default:
+			parts = append(parts, "timeout")
+		}
+	}
+	for _, r := range res.Retries {
+		s := "retries"
+		if r.Target != "" {
+			s += " " + shortFuncName(r.Target)
+		}
+		if r.Attempts > 0 {
+			s += fmt.Sprintf(" up to %d times", r.Attempts)
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// shortFuncName drops the package path directories from a qualified name:
+// "example.com/payment.Client.Charge" becomes "payment.Client.Charge".
+func shortFuncName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,133 @@
+package index
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestResilienceAnalyzer(t *testing.T) {
+	// backoff stands in for github.com/cenkalti/backoff/v4
+	root := t.TempDir()
+	src := `package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+type Client struct{ Timeout time.Duration }
+
+func (c *Client) Charge(ctx context.Context) error { return nil }
+
+func newClient() *Client { return &Client{Timeout: 10 * time.Second} }
+
+func charge(ctx context.Context) error {
+	c := newClient()
+	b := backoff.WithMaxRetries(&backoff.ExponentialBackOff{}, 3)
+	return backoff.Retry(func() error { return c.Charge(ctx) }, b)
+}
+
+func pay(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return charge(ctx)
+}
+
+func plain(ctx context.Context) error { return nil }
+
+func main() {}
+`
+	files := map[string]string{
+		"backoff/go.mod": "module github.com/cenkalti/backoff/v4\n\ngo 1.21\n",
+		"backoff/backoff.go": "package backoff\n\ntype BackOff interface{ Next() int }\n\n" +
+			"type ExponentialBackOff struct{}\n\nfunc (b *ExponentialBackOff) Next() int { return 0 }\n\n" +
+			"func WithMaxRetries(b BackOff, max uint64) BackOff { return b }\n\n" +
+			"func Retry(op func() error, b BackOff) error { return op() }\n",
+		"app/go.mod": "module resmod\n\ngo 1.21\n\nrequire github.com/cenkalti/backoff/v4 v4.0.0\n\n" +
+			"replace github.com/cenkalti/backoff/v4 => ../backoff\n",
+		"app/main.go": src,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	tmpDir := filepath.Join(root, "app")
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+	for _, name := range []string{"pay", "plain"} {
+		id, err := st.FindSymbolID(t.Context(), "resmod", name, "")
+		if err != nil {
+			t.Fatalf("finding %s: %v", name, err)
+		}
+		ep := &store.Entrypoint{Type: store.EntrypointHTTP, Label: name, SymbolID: id, MetaJSON: `{"method":"POST","path":"/` + name + `"}`}
+		if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
+			t.Fatalf("inserting entrypoint: %v", err)
+		}
+	}
+
+	cg := NewCallGraphBuilder(loader)
+	if err := cg.Build(); err != nil {
+		t.Fatalf("building SSA: %v", err)
+	}
+	result, err := NewResilienceAnalyzer(loader, cg.GetSSAProgram()).Analyze(t.Context(), st)
+	if err != nil {
+		t.Fatalf("analyzing: %v", err)
+	}
+	if result.WithTimeouts != 1 || result.WithRetries != 1 {
+		t.Errorf("expected 1 entrypoint with timeouts and 1 with retries, got %+v", result)
+	}
+
+	eps, err := st.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	for _, ep := range eps {
+		var meta HTTPMeta
+		if err := json.Unmarshal([]byte(ep.MetaJSON), &meta); err != nil {
+			t.Fatalf("decoding meta of %s: %v", ep.Label, err)
+		}
+		if ep.Label == "plain" {
+			if meta.Resilience != nil {
+				t.Errorf("plain has resilience %+v, want none", meta.Resilience)
+			}
+			continue
+		}
+		res := meta.Resilience
+		if res == nil {
+			t.Fatalf("pay has no resilience: %s", ep.MetaJSON)
+		}
+		want := "5s timeout; 10s timeout on resmod.Client; retries resmod.Client.Charge up to 3 times"
+		if res.Summary != want {
+			t.Errorf("summary = %q, want %q", res.Summary, want)
+		}
+		if len(res.Retries) != 1 || res.Retries[0].Call != "github.com/cenkalti/backoff/v4.Retry" || res.Retries[0].Function != "resmod.charge" {
+			t.Errorf("retries = %+v", res.Retries)
+		}
+		if meta.Path != "/pay" {
+			t.Errorf("meta of pay lost its path: %+v", meta)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package index
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+
+	"github.com/abramin/flowlens/internal/config"
+)
+
+// SSACache lazily builds one SSA program per repository and shares it
+// across callers, so CFG requests after the first skip package loading and
+// SSA construction. A repository's program is rebuilt when the index
+// generation (indexed_at) changes. Safe for concurrent use; concurrent
+// callers wait for a single build.
+type SSACache struct {
+	mu    sync.Mutex
+	progs map[string]*cachedProgram // By repository root directory
+}
+
+// cachedProgram is an SSA program and the index generation it was built for.
+type cachedProgram struct {
+	generation string
+	prog       *ssa.Program
+}
+
+// NewSSACache creates an empty SSA cache.
+func NewSSACache() *SSACache {
+	return &SSACache{progs: make(map[string]*cachedProgram)}
+}
+
+// Program returns the SSA program for the repository rooted at dir,
+// building it if it isn't cached or was built for a different index
+// generation.
+func (c *SSACache) Program(dir, generation string) (*ssa.Program, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.progs[dir]; ok && cached.generation == generation {
+		return cached.prog, nil
+	}
+
+	prog, err := buildProgram(dir)
+	if err != nil {
+		return nil, err
+	}
+	c.progs[dir] = &cachedProgram{generation: generation, prog: prog}
+	return prog, nil
+}
+
+// buildProgram loads the packages under dir, selected by the flowlens.yaml
+// there as when indexing, and builds their SSA program.
+func buildProgram(dir string) (*ssa.Program, error) {
+	cfg, err := config.LoadFromDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	loader := NewLoader(cfg, dir)
+	if err := loader.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load package: %w", err)
+	}
+
+	prog, _ := ssautil.AllPackages(loader.pkgs, ssa.SanityCheckFunctions)
+	prog.Build()
+	return prog, nil
+}
@@ -0,0 +1,67 @@
+package index
+
+import "testing"
+
+func TestSSACacheSharesProgram(t *testing.T) {
+	tmpDir, st, symID := setupCFGProject(t)
+	defer st.Close()
+
+	cache := NewSSACache()
+	first, err := cache.Program(tmpDir, "gen1")
+	if err != nil {
+		t.Fatalf("building program: %v", err)
+	}
+	again, err := cache.Program(tmpDir, "gen1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != again {
+		t.Error("expected the same program for the same generation")
+	}
+	rebuilt, err := cache.Program(tmpDir, "gen2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt == first {
+		t.Error("expected a rebuild after the index generation changed")
+	}
+
+	// CFGs built through the cache match the on-demand path
+	builder := NewCFGBuilder(st)
+	builder.UseSSACache(cache, tmpDir)
+	cached, err := builder.BuildCFG(t.Context(), symID)
+	if err != nil {
+		t.Fatalf("building CFG with cache: %v", err)
+	}
+	direct, err := NewCFGBuilder(st).BuildCFG(t.Context(), symID)
+	if err != nil {
+		t.Fatalf("building CFG without cache: %v", err)
+	}
+	if len(cached.Blocks) != len(direct.Blocks) || len(cached.Blocks) < 2 {
+		t.Errorf("expected matching branched CFGs, got %d and %d blocks", len(cached.Blocks), len(direct.Blocks))
+	}
+}
+
+func TestSSACacheUsesRepoRoot(t *testing.T) {
+	tmpDir, st, symID := setupCFGProject(t)
+	defer st.Close()
+
+	// The symbol belongs to a named repository of a shared index served
+	// from another project
+	if _, err := st.Tx().ExecContext(t.Context(), "UPDATE packages SET repo = 'svc'"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetRepoDir(t.Context(), "svc", tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	builder := NewCFGBuilder(st)
+	builder.UseSSACache(NewSSACache(), t.TempDir())
+	cfg, err := builder.BuildCFG(t.Context(), symID)
+	if err != nil {
+		t.Fatalf("building CFG for another repository: %v", err)
+	}
+	if len(cfg.Blocks) < 2 {
+		t.Errorf("expected a branched CFG, got %d blocks", len(cfg.Blocks))
+	}
+}
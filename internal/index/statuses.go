@@ -0,0 +1,176 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"go/constant"
+	"sort"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/ssa"
+)
+
+// grpcStatusCalls are the gRPC functions whose first argument is the status
+// code returned to the client.
+var grpcStatusCalls = map[string]bool{
+	"google.golang.org/grpc/status.Error":  true,
+	"google.golang.org/grpc/status.Errorf": true,
+	"google.golang.org/grpc/status.New":    true,
+	"google.golang.org/grpc/status.Newf":   true,
+}
+
+// contextStatusMethods are framework context methods whose first argument
+// is the HTTP status code, as on gin.Context and echo.Context.
+var contextStatusMethods = map[string]bool{
+	"JSON": true, "IndentedJSON": true, "XML": true, "YAML": true, "String": true,
+	"HTML": true, "Data": true, "Blob": true, "NoContent": true, "Redirect": true,
+	"Status": true, "AbortWithStatus": true, "AbortWithStatusJSON": true,
+}
+
+// StatusAnalyzer finds the status codes each HTTP and gRPC entrypoint can
+// respond with: constant codes passed to ResponseWriter.WriteHeader,
+// http.Error, framework context methods such as c.JSON(code, ...), and gRPC
+// status constructors. Codes held in variables are not resolved.
+//
+// These calls are recognized by name, through interfaces too (WriteHeader
+// on an http.ResponseWriter), in the handler and the project functions it
+// calls statically. Helpers reached through an interface or a function
+// value are not searched.
+type StatusAnalyzer struct {
+	prog        *ssa.Program
+	projectPkgs projectPackages
+	summaries   map[*ssa.Function]*statusSummary
+}
+
+// statusSummary holds the codes reachable from one function.
+type statusSummary struct {
+	http map[int]bool
+	grpc map[int]bool
+}
+
+// StatusResult holds the results of status code analysis.
+type StatusResult struct {
+	Checked   int // HTTP and gRPC entrypoints checked
+	WithCodes int // Entrypoints with at least one known status code
+}
+
+// NewStatusAnalyzer creates a status code analyzer for the loader's packages.
+func NewStatusAnalyzer(loader *Loader, prog *ssa.Program) *StatusAnalyzer {
+	return &StatusAnalyzer{
+		prog:        prog,
+		projectPkgs: newProjectPackages(loader),
+		summaries:   make(map[*ssa.Function]*statusSummary),
+	}
+}
+
+// Analyze records the status codes of every HTTP and gRPC entrypoint in its
+// metadata.
+func (a *StatusAnalyzer) Analyze(ctx context.Context, st *store.Store) (*StatusResult, error) {
+	result := &StatusResult{}
+	err := forEachHandler(ctx, st, a.prog, handlerTypes, func(batch *store.BatchTx, ep *store.EntrypointWithSymbol, fn *ssa.Function) error {
+		result.Checked++
+
+		sum := a.analyze(fn)
+		set := sum.http
+		if ep.Type == store.EntrypointGRPC {
+			set = sum.grpc
+		}
+		if len(set) == 0 {
+			return nil
+		}
+		codes := make([]int, 0, len(set))
+		for code := range set {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		if err := batch.SetEntrypointMeta(ctx, ep.ID, "status_codes", codes); err != nil {
+			return fmt.Errorf("storing status codes: %w", err)
+		}
+		result.WithCodes++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// analyze computes the codes reachable from fn. Recursive calls see the
+// in-progress summary.
+func (a *StatusAnalyzer) analyze(fn *ssa.Function) *statusSummary {
+	if sum, ok := a.summaries[fn]; ok {
+		return sum
+	}
+	sum := &statusSummary{http: make(map[int]bool), grpc: make(map[int]bool)}
+	a.summaries[fn] = sum
+
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			call, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			common := call.Common()
+			a.record(common, sum)
+
+			callee := common.StaticCallee()
+			if !a.projectPkgs.follows(callee) {
+				continue
+			}
+			sub := a.analyze(callee)
+			for code := range sub.http {
+				sum.http[code] = true
+			}
+			for code := range sub.grpc {
+				sum.grpc[code] = true
+			}
+		}
+	}
+	return sum
+}
+
+// record adds the status code set by one call, if any, to sum.
+func (a *StatusAnalyzer) record(common *ssa.CallCommon, sum *statusSummary) {
+	name := calleeName(common)
+	if name == "" {
+		return
+	}
+	// Arguments after the receiver of a static method call
+	args := common.Args
+	if !common.IsInvoke() && common.Signature().Recv() != nil && len(args) > 0 {
+		args = args[1:]
+	}
+
+	switch {
+	case grpcStatusCalls[name]:
+		if code, ok := constInt(args, 0); ok {
+			sum.grpc[code] = true
+		}
+	case name == "net/http.Error":
+		if code, ok := constInt(args, 2); ok {
+			sum.http[code] = true
+		}
+	default:
+		dot := strings.LastIndex(name, ".")
+		recv, method := name[:dot], name[dot+1:]
+		if method == "WriteHeader" || (contextStatusMethods[method] && strings.HasSuffix(recv, "Context")) {
+			if code, ok := constInt(args, 0); ok {
+				sum.http[code] = true
+			}
+		}
+	}
+}
+
+// constInt returns args[i] when it is an integer constant.
+func constInt(args []ssa.Value, i int) (int, bool) {
+	if i >= len(args) {
+		return 0, false
+	}
+	c, ok := args[i].(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	v, exact := constant.Int64Val(c.Value)
+	return int(v), exact
+}
@@ -0,0 +1,161 @@
+package index
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestStatusAnalyzer(t *testing.T) {
+	// A dependency-free app; gin and grpc stand in for the real libraries
+	root := t.TempDir()
+	src := `package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ResponseWriter interface{ WriteHeader(code int) }
+
+type Request struct{ Path string }
+
+func notFound(w ResponseWriter) { w.WriteHeader(404) }
+
+func get(w ResponseWriter, r *Request) {
+	if r.Path == "" {
+		notFound(w)
+		return
+	}
+	w.WriteHeader(200)
+}
+
+func dynamic(w ResponseWriter, r *Request) {
+	code := 200
+	if r.Path == "" {
+		code = 400
+	}
+	w.WriteHeader(code)
+}
+
+func create(c *gin.Context) {
+	if c.Query("name") == "" {
+		c.JSON(422, nil)
+		return
+	}
+	c.JSON(201, nil)
+}
+
+type Server struct{}
+
+func (s *Server) Lookup(id string) error {
+	if id == "" {
+		return status.Error(codes.InvalidArgument, "missing id")
+	}
+	return status.Error(codes.NotFound, "no such item")
+}
+
+func main() {}
+`
+	files := map[string]string{
+		"gin/go.mod":          "module github.com/gin-gonic/gin\n\ngo 1.21\n",
+		"gin/context.go":      "package gin\n\ntype Context struct{}\n\nfunc (c *Context) Query(k string) string { return k }\n\nfunc (c *Context) JSON(code int, obj any) {}\n",
+		"grpc/go.mod":         "module google.golang.org/grpc\n\ngo 1.21\n",
+		"grpc/codes/codes.go": "package codes\n\ntype Code uint32\n\nconst (\n\tInvalidArgument Code = 3\n\tNotFound Code = 5\n)\n",
+		"grpc/status/status.go": "package status\n\nimport \"google.golang.org/grpc/codes\"\n\n" +
+			"type statusError struct{ msg string }\n\nfunc (e *statusError) Error() string { return e.msg }\n\n" +
+			"func Error(c codes.Code, msg string) error { return &statusError{msg} }\n",
+		"app/go.mod": "module statusmod\n\ngo 1.21\n\nrequire (\n\tgithub.com/gin-gonic/gin v0.1.0\n\tgoogle.golang.org/grpc v0.1.0\n)\n\n" +
+			"replace github.com/gin-gonic/gin => ../gin\n\nreplace google.golang.org/grpc => ../grpc\n",
+		"app/main.go": src,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	tmpDir := filepath.Join(root, "app")
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	entrypoints := []struct {
+		typ            store.EntrypointType
+		name, recvType string
+		meta           string
+	}{
+		{store.EntrypointHTTP, "get", "", `{"method":"GET","path":"/get","middleware":["middleware.Logger"]}`},
+		{store.EntrypointHTTP, "dynamic", "", ""},
+		{store.EntrypointHTTP, "create", "", ""},
+		{store.EntrypointGRPC, "Lookup", "*Server", ""},
+	}
+	for _, e := range entrypoints {
+		id, err := st.FindSymbolID(t.Context(), "statusmod", e.name, e.recvType)
+		if err != nil {
+			t.Fatalf("finding %s: %v", e.name, err)
+		}
+		ep := &store.Entrypoint{Type: e.typ, Label: e.name, SymbolID: id, MetaJSON: e.meta}
+		if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
+			t.Fatalf("inserting entrypoint: %v", err)
+		}
+	}
+
+	cg := NewCallGraphBuilder(loader)
+	if err := cg.Build(); err != nil {
+		t.Fatalf("building SSA: %v", err)
+	}
+	result, err := NewStatusAnalyzer(loader, cg.GetSSAProgram()).Analyze(t.Context(), st)
+	if err != nil {
+		t.Fatalf("analyzing: %v", err)
+	}
+	// dynamic's code is a variable
+	if result.Checked != 4 || result.WithCodes != 3 {
+		t.Errorf("expected 4 checked and 3 with codes, got %+v", result)
+	}
+
+	eps, err := st.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	want := map[string][]int{
+		"get":     {200, 404},
+		"dynamic": nil,
+		"create":  {201, 422},
+		"Lookup":  {3, 5},
+	}
+	for _, ep := range eps {
+		var meta HTTPMeta
+		if ep.MetaJSON != "" {
+			if err := json.Unmarshal([]byte(ep.MetaJSON), &meta); err != nil {
+				t.Fatalf("decoding meta of %s: %v", ep.Label, err)
+			}
+		}
+		if !reflect.DeepEqual(meta.StatusCodes, want[ep.Label]) {
+			t.Errorf("status codes of %s = %v, want %v", ep.Label, meta.StatusCodes, want[ep.Label])
+		}
+		// Existing metadata is kept
+		if ep.Label == "get" && (meta.Path != "/get" || len(meta.Middleware) != 1) {
+			t.Errorf("meta of get = %+v, want path and middleware kept", meta)
+		}
+	}
+}
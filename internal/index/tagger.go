@@ -1,7 +1,10 @@
 package index
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/abramin/flowlens/internal/config"
@@ -10,66 +13,109 @@ import (
 
 // Tagger applies tags to symbols based on I/O boundaries, layers, and purity heuristics.
 type Tagger struct {
-	cfg   *config.Config
-	store *store.Store
+	cfg           *config.Config
+	store         *store.Store
+	receiverRules []receiverRule
+}
+
+// receiverRule is a configured receiver tag rule ready for matching.
+type receiverRule struct {
+	suffix string         // Lowercased
+	re     *regexp.Regexp // Nil for suffix rules
+	tag    string
 }
 
 // TagResult holds the results of the tagging operation.
 type TagResult struct {
-	IOTags     int // Number of I/O boundary tags applied
-	LayerTags  int // Number of layer tags applied
-	PurityTags int // Number of purity tags applied
-	TotalTags  int // Total tags applied
+	IOTags        int // Number of I/O boundary tags applied
+	DerivedIOTags int // Number of io:<category>@N tags applied to callers of I/O functions
+	LayerTags     int // Number of layer tags applied
+	PurityTags    int // Number of purity tags applied
+	TotalTags     int // Total tags applied
 }
 
-// NewTagger creates a new tagger.
+// NewTagger creates a new tagger. Receiver rules with a malformed regex are
+// skipped; config.Load rejects them.
 func NewTagger(cfg *config.Config, st *store.Store) *Tagger {
+	var rules []receiverRule
+	for _, r := range cfg.ReceiverTags {
+		rule := receiverRule{suffix: strings.ToLower(r.Suffix), tag: r.Tag}
+		if r.Regex != "" {
+			re, err := regexp.Compile(r.Regex)
+			if err != nil {
+				continue
+			}
+			rule.re = re
+		}
+		rules = append(rules, rule)
+	}
 	return &Tagger{
-		cfg:   cfg,
-		store: st,
+		cfg:           cfg,
+		store:         st,
+		receiverRules: rules,
 	}
 }
 
 // Tag applies all tags to symbols and returns the result.
-func (t *Tagger) Tag() (*TagResult, error) {
+func (t *Tagger) Tag(ctx context.Context) (*TagResult, error) {
 	result := &TagResult{}
 
 	// Start a batch transaction
-	batch, err := t.store.BeginBatch()
+	batch, err := t.store.BeginBatch(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("starting batch: %w", err)
 	}
 	defer batch.Rollback()
 
 	// Get all symbols
-	symbols, err := t.store.GetAllSymbolsForTagging()
+	symbols, err := t.store.GetAllSymbolsForTagging(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("getting symbols: %w", err)
 	}
 
-	// Get package imports (which packages call into which other packages)
-	pkgImports, err := t.store.GetPackageImports()
-	if err != nil {
-		return nil, fmt.Errorf("getting package imports: %w", err)
+	// Build a map of package -> IO categories it imports, or of function ->
+	// IO categories it calls into, depending on the tagging mode
+	var pkgIOCategories map[string]map[string]string
+	var funcIOCategories map[store.SymbolID]map[string]ioSource
+	if t.cfg.IOTagging == config.IOTaggingPackage {
+		// Get package imports (which packages call into which other packages)
+		pkgImports, err := t.store.GetPackageImports(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting package imports: %w", err)
+		}
+		pkgIOCategories = t.buildPackageIOCategories(pkgImports)
+	} else {
+		targets, err := t.store.GetCallTargets(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting call targets: %w", err)
+		}
+		funcIOCategories = t.buildFunctionIOCategories(symbols, targets)
 	}
 
-	// Build a map of package -> IO categories it imports
-	pkgIOCategories := t.buildPackageIOCategories(pkgImports)
+	// Package layers as stored by the loader, including inferred ones
+	pkgs, err := t.store.GetPackages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting packages: %w", err)
+	}
+	pkgLayers := make(map[string]store.Package, len(pkgs))
+	for _, pkg := range pkgs {
+		pkgLayers[pkg.PkgPath] = pkg
+	}
 
 	// Apply I/O boundary tags and layer tags
 	for _, sym := range symbols {
 		// I/O boundary detection
-		ioTags := t.getIOTags(sym, pkgIOCategories)
+		ioTags := t.getIOTags(sym, pkgIOCategories, funcIOCategories)
 		for _, tag := range ioTags {
-			if err := batch.InsertTag(tag); err != nil {
+			if err := batch.InsertTag(ctx, tag); err != nil {
 				return nil, fmt.Errorf("inserting IO tag: %w", err)
 			}
 			result.IOTags++
 		}
 
 		// Layer classification
-		if layerTag := t.getLayerTag(sym); layerTag != nil {
-			if err := batch.InsertTag(layerTag); err != nil {
+		if layerTag := t.getLayerTag(sym, pkgLayers); layerTag != nil {
+			if err := batch.InsertTag(ctx, layerTag); err != nil {
 				return nil, fmt.Errorf("inserting layer tag: %w", err)
 			}
 			result.LayerTags++
@@ -81,19 +127,27 @@ func (t *Tagger) Tag() (*TagResult, error) {
 		return nil, fmt.Errorf("committing batch: %w", err)
 	}
 
-	// Start new batch for purity tags
-	batch, err = t.store.BeginBatch()
+	// Start new batch for derived I/O and purity tags
+	batch, err = t.store.BeginBatch(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("starting purity batch: %w", err)
 	}
 	defer batch.Rollback()
 
-	// Get callee relationships with their tags for purity analysis
-	calleeMap, err := t.store.GetSymbolCalleesWithTags()
+	// Get callee relationships with their tags for derived I/O tags and purity analysis
+	calleeMap, err := t.store.GetSymbolCalleesWithTags(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("getting callees with tags: %w", err)
 	}
 
+	// Derive io:<category>@N tags for callers of I/O functions
+	for _, tag := range t.getDerivedIOTags(symbols, calleeMap) {
+		if err := batch.InsertTag(ctx, tag); err != nil {
+			return nil, fmt.Errorf("inserting derived IO tag: %w", err)
+		}
+		result.DerivedIOTags++
+	}
+
 	// Build set of symbols that have callees (for purity)
 	symbolsWithCallees := make(map[store.SymbolID]bool)
 	for callerID := range calleeMap {
@@ -108,7 +162,7 @@ func (t *Tagger) Tag() (*TagResult, error) {
 		}
 
 		if purityTag := t.getPurityTag(sym, calleeMap); purityTag != nil {
-			if err := batch.InsertTag(purityTag); err != nil {
+			if err := batch.InsertTag(ctx, purityTag); err != nil {
 				return nil, fmt.Errorf("inserting purity tag: %w", err)
 			}
 			result.PurityTags++
@@ -119,7 +173,7 @@ func (t *Tagger) Tag() (*TagResult, error) {
 		return nil, fmt.Errorf("committing purity batch: %w", err)
 	}
 
-	result.TotalTags = result.IOTags + result.LayerTags + result.PurityTags
+	result.TotalTags = result.IOTags + result.DerivedIOTags + result.LayerTags + result.PurityTags
 	return result, nil
 }
 
@@ -145,8 +199,88 @@ func (t *Tagger) buildPackageIOCategories(pkgImports map[string][]string) map[st
 	return result
 }
 
-// getIOTags returns I/O boundary tags for a symbol.
-func (t *Tagger) getIOTags(sym store.SymbolForTagging, pkgIOCategories map[string]map[string]string) []*store.Tag {
+// ioSource records why a function is tagged with an I/O category.
+type ioSource struct {
+	pkg string // I/O package called
+	via string // Same-package helper reaching it, empty for direct calls
+}
+
+// buildFunctionIOCategories maps each function calling an I/O package to the
+// categories it uses. In IOTaggingCalls mode, categories also flow from
+// helpers to their callers within the same package, so a handler calling
+// its package's query helper is tagged while unrelated functions are not.
+func (t *Tagger) buildFunctionIOCategories(symbols []store.SymbolForTagging, targets map[store.SymbolID][]store.CallTarget) map[store.SymbolID]map[string]ioSource {
+	symByID := make(map[store.SymbolID]store.SymbolForTagging, len(symbols))
+	for _, sym := range symbols {
+		symByID[sym.ID] = sym
+	}
+	callers := make([]store.SymbolID, 0, len(targets))
+	for id := range targets {
+		callers = append(callers, id)
+	}
+	sort.Slice(callers, func(i, j int) bool { return callers[i] < callers[j] })
+
+	result := make(map[store.SymbolID]map[string]ioSource)
+	add := func(id store.SymbolID, category string, src ioSource) bool {
+		if _, exists := result[id][category]; exists {
+			return false
+		}
+		if result[id] == nil {
+			result[id] = make(map[string]ioSource)
+		}
+		result[id][category] = src
+		return true
+	}
+
+	for _, id := range callers {
+		pkgPath := symByID[id].PkgPath
+		for _, target := range targets[id] {
+			if target.PkgPath == pkgPath {
+				continue
+			}
+			if category := t.cfg.GetIOCategory(target.PkgPath); category != "" {
+				add(id, category, ioSource{pkg: target.PkgPath})
+			}
+		}
+	}
+	if t.cfg.IOTagging == config.IOTaggingDirect {
+		return result
+	}
+
+	// Propagate through same-package helpers until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for _, id := range callers {
+			pkgPath := symByID[id].PkgPath
+			for _, target := range targets[id] {
+				if target.CalleeID == id || target.PkgPath != pkgPath {
+					continue
+				}
+				helper := symByID[target.CalleeID]
+				via := helper.Name
+				if helper.RecvType != "" {
+					via = strings.TrimPrefix(helper.RecvType, "*") + "." + helper.Name
+				}
+				categories := make([]string, 0, len(result[target.CalleeID]))
+				for category := range result[target.CalleeID] {
+					categories = append(categories, category)
+				}
+				sort.Strings(categories)
+				for _, category := range categories {
+					src := ioSource{pkg: result[target.CalleeID][category].pkg, via: via}
+					if add(id, category, src) {
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// getIOTags returns I/O boundary tags for a symbol. Only one of
+// pkgIOCategories and funcIOCategories is set, per the tagging mode.
+func (t *Tagger) getIOTags(sym store.SymbolForTagging, pkgIOCategories map[string]map[string]string, funcIOCategories map[store.SymbolID]map[string]ioSource) []*store.Tag {
 	var tags []*store.Tag
 
 	// Only tag functions and methods
@@ -154,8 +288,21 @@ func (t *Tagger) getIOTags(sym store.SymbolForTagging, pkgIOCategories map[strin
 		return nil
 	}
 
-	// Check package-level IO imports
-	if categories := pkgIOCategories[sym.PkgPath]; categories != nil {
+	if funcIOCategories != nil {
+		// Check the function's own calls
+		for category, src := range funcIOCategories[sym.ID] {
+			reason := fmt.Sprintf("Calls %s", src.pkg)
+			if src.via != "" {
+				reason = fmt.Sprintf("Calls %s via %s", src.pkg, src.via)
+			}
+			tags = append(tags, &store.Tag{
+				SymbolID: sym.ID,
+				Tag:      "io:" + category,
+				Reason:   reason,
+			})
+		}
+	} else if categories := pkgIOCategories[sym.PkgPath]; categories != nil {
+		// Check package-level IO imports
 		for category, importedPkg := range categories {
 			tags = append(tags, &store.Tag{
 				SymbolID: sym.ID,
@@ -189,7 +336,80 @@ func (t *Tagger) getIOTags(sym store.SymbolForTagging, pkgIOCategories map[strin
 	return tags
 }
 
-// getIOTagFromReceiverType returns an I/O tag based on the receiver type name.
+// getDerivedIOTags returns io:<category>@N tags for functions whose nearest
+// io:<category> function is N-1 calls away, up to the configured distance.
+// Functions tagged io:<category> themselves get no derived tag for it.
+func (t *Tagger) getDerivedIOTags(symbols []store.SymbolForTagging, calleeMap map[store.SymbolID][]store.SymbolCallee) []*store.Tag {
+	if t.cfg.IODistance < 2 {
+		return nil
+	}
+	names := make(map[store.SymbolID]string, len(symbols))
+	for _, sym := range symbols {
+		names[sym.ID] = sym.Name
+		if sym.RecvType != "" {
+			names[sym.ID] = strings.TrimPrefix(sym.RecvType, "*") + "." + sym.Name
+		}
+	}
+	callers := make([]store.SymbolID, 0, len(calleeMap))
+	for id := range calleeMap {
+		callers = append(callers, id)
+	}
+	sort.Slice(callers, func(i, j int) bool { return callers[i] < callers[j] })
+
+	// distance[id][ioTag] is the number of calls from id to an I/O boundary;
+	// direct tags are at distance 1
+	distance := make(map[store.SymbolID]map[string]int)
+	for _, id := range callers {
+		for _, callee := range calleeMap[id] {
+			for _, tag := range callee.Tags {
+				if store.IsIOTag(tag) {
+					if distance[callee.CalleeID] == nil {
+						distance[callee.CalleeID] = make(map[string]int)
+					}
+					distance[callee.CalleeID][tag] = 1
+				}
+			}
+		}
+	}
+
+	var tags []*store.Tag
+	for d := 2; d <= t.cfg.IODistance; d++ {
+		var found []*store.Tag
+		for _, id := range callers {
+			for _, callee := range calleeMap[id] {
+				ioTags := make([]string, 0, len(distance[callee.CalleeID]))
+				for ioTag, cd := range distance[callee.CalleeID] {
+					if cd == d-1 {
+						ioTags = append(ioTags, ioTag)
+					}
+				}
+				sort.Strings(ioTags)
+				for _, ioTag := range ioTags {
+					if _, reached := distance[id][ioTag]; reached {
+						continue
+					}
+					if distance[id] == nil {
+						distance[id] = make(map[string]int)
+					}
+					distance[id][ioTag] = d
+					found = append(found, &store.Tag{
+						SymbolID: id,
+						Tag:      store.DerivedIOTag(ioTag, d),
+						Reason:   fmt.Sprintf("Reaches %s through %s", ioTag, names[callee.CalleeID]),
+					})
+				}
+			}
+		}
+		if len(found) == 0 {
+			break
+		}
+		tags = append(tags, found...)
+	}
+	return tags
+}
+
+// getIOTagFromReceiverType returns the tag of the first receiver rule (see
+// receiver_tags in flowlens.yaml) matching the receiver type name.
 func (t *Tagger) getIOTagFromReceiverType(recvType string) string {
 	// Normalize: strip pointer and package prefix
 	typeName := recvType
@@ -202,29 +422,34 @@ func (t *Tagger) getIOTagFromReceiverType(recvType string) string {
 	}
 
 	lowerName := strings.ToLower(typeName)
-
-	// Check for store/repo patterns -> io:db
-	if strings.HasSuffix(lowerName, "store") ||
-		strings.HasSuffix(lowerName, "repo") ||
-		strings.HasSuffix(lowerName, "repository") {
-		return "io:db"
-	}
-
-	// Check for client patterns -> io:net
-	if strings.HasSuffix(lowerName, "client") {
-		return "io:net"
+	for _, rule := range t.receiverRules {
+		if rule.re != nil {
+			if rule.re.MatchString(typeName) {
+				return rule.tag
+			}
+		} else if strings.HasSuffix(lowerName, rule.suffix) {
+			return rule.tag
+		}
 	}
-
 	return ""
 }
 
-// getLayerTag returns a layer tag for a symbol based on its package path.
-func (t *Tagger) getLayerTag(sym store.SymbolForTagging) *store.Tag {
+// getLayerTag returns a layer tag for a symbol based on its package's layer,
+// falling back to the configured patterns for packages not in the index.
+func (t *Tagger) getLayerTag(sym store.SymbolForTagging, pkgLayers map[string]store.Package) *store.Tag {
 	// Only tag functions and methods
 	if sym.Kind != store.SymbolKindFunc && sym.Kind != store.SymbolKindMethod {
 		return nil
 	}
 
+	if pkg, ok := pkgLayers[sym.PkgPath]; ok && pkg.LayerSource == store.LayerSourceInferred {
+		return &store.Tag{
+			SymbolID: sym.ID,
+			Tag:      "layer:" + pkg.Layer,
+			Reason:   fmt.Sprintf("Inferred %s layer from package imports (layer_source: inferred)", pkg.Layer),
+		}
+	}
+
 	layer := t.cfg.GetLayerForPackage(sym.PkgPath)
 	if layer == "" {
 		return nil
@@ -253,7 +478,7 @@ func (t *Tagger) getPurityTag(sym store.SymbolForTagging, calleeMap map[store.Sy
 	// Check if any callee has an io:* tag
 	for _, callee := range callees {
 		for _, tag := range callee.Tags {
-			if strings.HasPrefix(tag, "io:") {
+			if store.IsIOTag(tag) {
 				// Has I/O dependency, not pure
 				return nil
 			}
@@ -1,6 +1,8 @@
 package index
 
 import (
+	"database/sql"
+	"strconv"
 	"testing"
 
 	"github.com/abramin/flowlens/internal/config"
@@ -23,10 +25,10 @@ func TestTagger_IOTagFromPackageImport(t *testing.T) {
 	// Create packages
 	servicePkg := &store.Package{PkgPath: "myapp/service", Dir: "/service"}
 	dbPkg := &store.Package{PkgPath: "database/sql", Dir: "/sql"}
-	if err := st.InsertPackage(servicePkg); err != nil {
+	if err := st.InsertPackage(t.Context(), servicePkg); err != nil {
 		t.Fatal(err)
 	}
-	if err := st.InsertPackage(dbPkg); err != nil {
+	if err := st.InsertPackage(t.Context(), dbPkg); err != nil {
 		t.Fatal(err)
 	}
 
@@ -46,11 +48,11 @@ func TestTagger_IOTagFromPackageImport(t *testing.T) {
 		Line:    100,
 	}
 
-	serviceFuncID, err := st.InsertSymbol(serviceFunc)
+	serviceFuncID, err := st.InsertSymbol(t.Context(), serviceFunc)
 	if err != nil {
 		t.Fatal(err)
 	}
-	dbFuncID, err := st.InsertSymbol(dbFunc)
+	dbFuncID, err := st.InsertSymbol(t.Context(), dbFunc)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -64,14 +66,14 @@ func TestTagger_IOTagFromPackageImport(t *testing.T) {
 		CallKind:   store.CallKindStatic,
 		Count:      1,
 	}
-	if err := st.InsertCallEdge(edge); err != nil {
+	if err := st.InsertCallEdge(t.Context(), edge); err != nil {
 		t.Fatal(err)
 	}
 
 	// Run tagger
 	cfg := config.Default()
 	tagger := NewTagger(cfg, st)
-	result, err := tagger.Tag()
+	result, err := tagger.Tag(t.Context())
 	if err != nil {
 		t.Fatalf("tagging failed: %v", err)
 	}
@@ -102,7 +104,7 @@ func TestTagger_IOTagFromReceiverType(t *testing.T) {
 	defer st.Close()
 
 	pkg := &store.Package{PkgPath: "myapp/store", Dir: "/store"}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatal(err)
 	}
 
@@ -115,14 +117,14 @@ func TestTagger_IOTagFromReceiverType(t *testing.T) {
 		File:     "user_store.go",
 		Line:     20,
 	}
-	methodID, err := st.InsertSymbol(method)
+	methodID, err := st.InsertSymbol(t.Context(), method)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	cfg := config.Default()
 	tagger := NewTagger(cfg, st)
-	result, err := tagger.Tag()
+	result, err := tagger.Tag(t.Context())
 	if err != nil {
 		t.Fatalf("tagging failed: %v", err)
 	}
@@ -164,7 +166,7 @@ func TestTagger_LayerClassification(t *testing.T) {
 
 	for _, tt := range tests {
 		pkg := &store.Package{PkgPath: tt.pkgPath, Dir: "/" + tt.pkgPath}
-		if err := st.InsertPackage(pkg); err != nil {
+		if err := st.InsertPackage(t.Context(), pkg); err != nil {
 			t.Fatal(err)
 		}
 
@@ -175,13 +177,13 @@ func TestTagger_LayerClassification(t *testing.T) {
 			File:    "file.go",
 			Line:    1,
 		}
-		if _, err := st.InsertSymbol(fn); err != nil {
+		if _, err := st.InsertSymbol(t.Context(), fn); err != nil {
 			t.Fatal(err)
 		}
 	}
 
 	tagger := NewTagger(cfg, st)
-	result, err := tagger.Tag()
+	result, err := tagger.Tag(t.Context())
 	if err != nil {
 		t.Fatalf("tagging failed: %v", err)
 	}
@@ -197,7 +199,7 @@ func TestTagger_PurityNoOutgoingCalls(t *testing.T) {
 	defer st.Close()
 
 	pkg := &store.Package{PkgPath: "myapp/util", Dir: "/util"}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatal(err)
 	}
 
@@ -209,14 +211,14 @@ func TestTagger_PurityNoOutgoingCalls(t *testing.T) {
 		File:    "math.go",
 		Line:    5,
 	}
-	pureFuncID, err := st.InsertSymbol(pureFunc)
+	pureFuncID, err := st.InsertSymbol(t.Context(), pureFunc)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	cfg := config.Default()
 	tagger := NewTagger(cfg, st)
-	result, err := tagger.Tag()
+	result, err := tagger.Tag(t.Context())
 	if err != nil {
 		t.Fatalf("tagging failed: %v", err)
 	}
@@ -243,7 +245,7 @@ func TestTagger_PurityWithNonIOCalls(t *testing.T) {
 	defer st.Close()
 
 	pkg := &store.Package{PkgPath: "myapp/util", Dir: "/util"}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatal(err)
 	}
 
@@ -263,11 +265,11 @@ func TestTagger_PurityWithNonIOCalls(t *testing.T) {
 		Line:    10,
 	}
 
-	helperID, err := st.InsertSymbol(helperFunc)
+	helperID, err := st.InsertSymbol(t.Context(), helperFunc)
 	if err != nil {
 		t.Fatal(err)
 	}
-	mainID, err := st.InsertSymbol(mainFunc)
+	mainID, err := st.InsertSymbol(t.Context(), mainFunc)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -281,13 +283,13 @@ func TestTagger_PurityWithNonIOCalls(t *testing.T) {
 		CallKind:   store.CallKindStatic,
 		Count:      1,
 	}
-	if err := st.InsertCallEdge(edge); err != nil {
+	if err := st.InsertCallEdge(t.Context(), edge); err != nil {
 		t.Fatal(err)
 	}
 
 	cfg := config.Default()
 	tagger := NewTagger(cfg, st)
-	result, err := tagger.Tag()
+	result, err := tagger.Tag(t.Context())
 	if err != nil {
 		t.Fatalf("tagging failed: %v", err)
 	}
@@ -317,10 +319,10 @@ func TestTagger_NotPureWithIOCall(t *testing.T) {
 	// Create packages
 	servicePkg := &store.Package{PkgPath: "myapp/service", Dir: "/service"}
 	storePkg := &store.Package{PkgPath: "myapp/store", Dir: "/store"}
-	if err := st.InsertPackage(servicePkg); err != nil {
+	if err := st.InsertPackage(t.Context(), servicePkg); err != nil {
 		t.Fatal(err)
 	}
-	if err := st.InsertPackage(storePkg); err != nil {
+	if err := st.InsertPackage(t.Context(), storePkg); err != nil {
 		t.Fatal(err)
 	}
 
@@ -341,11 +343,11 @@ func TestTagger_NotPureWithIOCall(t *testing.T) {
 		Line:     20,
 	}
 
-	serviceFuncID, err := st.InsertSymbol(serviceFunc)
+	serviceFuncID, err := st.InsertSymbol(t.Context(), serviceFunc)
 	if err != nil {
 		t.Fatal(err)
 	}
-	storeMethodID, err := st.InsertSymbol(storeMethod)
+	storeMethodID, err := st.InsertSymbol(t.Context(), storeMethod)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -359,13 +361,13 @@ func TestTagger_NotPureWithIOCall(t *testing.T) {
 		CallKind:   store.CallKindStatic,
 		Count:      1,
 	}
-	if err := st.InsertCallEdge(edge); err != nil {
+	if err := st.InsertCallEdge(t.Context(), edge); err != nil {
 		t.Fatal(err)
 	}
 
 	cfg := config.Default()
 	tagger := NewTagger(cfg, st)
-	_, err = tagger.Tag()
+	_, err = tagger.Tag(t.Context())
 	if err != nil {
 		t.Fatalf("tagging failed: %v", err)
 	}
@@ -388,7 +390,7 @@ func TestTagger_ClientReceiverType(t *testing.T) {
 	defer st.Close()
 
 	pkg := &store.Package{PkgPath: "myapp/client", Dir: "/client"}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatal(err)
 	}
 
@@ -401,14 +403,14 @@ func TestTagger_ClientReceiverType(t *testing.T) {
 		File:     "client.go",
 		Line:     30,
 	}
-	methodID, err := st.InsertSymbol(method)
+	methodID, err := st.InsertSymbol(t.Context(), method)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	cfg := config.Default()
 	tagger := NewTagger(cfg, st)
-	_, err = tagger.Tag()
+	_, err = tagger.Tag(t.Context())
 	if err != nil {
 		t.Fatalf("tagging failed: %v", err)
 	}
@@ -428,7 +430,7 @@ func TestTagger_RepoReceiverType(t *testing.T) {
 	defer st.Close()
 
 	pkg := &store.Package{PkgPath: "myapp/repo", Dir: "/repo"}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatal(err)
 	}
 
@@ -441,14 +443,14 @@ func TestTagger_RepoReceiverType(t *testing.T) {
 		File:     "user_repo.go",
 		Line:     25,
 	}
-	methodID, err := st.InsertSymbol(method)
+	methodID, err := st.InsertSymbol(t.Context(), method)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	cfg := config.Default()
 	tagger := NewTagger(cfg, st)
-	_, err = tagger.Tag()
+	_, err = tagger.Tag(t.Context())
 	if err != nil {
 		t.Fatalf("tagging failed: %v", err)
 	}
@@ -462,3 +464,242 @@ func TestTagger_RepoReceiverType(t *testing.T) {
 		t.Fatalf("failed to query tag: %v (expected io:db for *Repo receiver)", err)
 	}
 }
+
+func TestTagger_CacheReceiverType(t *testing.T) {
+	st := setupTestStore(t)
+	defer st.Close()
+
+	pkg := &store.Package{PkgPath: "myapp/session", Dir: "/session"}
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a method on *SessionCache
+	method := &store.Symbol{
+		PkgPath:  "myapp/session",
+		Name:     "Get",
+		Kind:     store.SymbolKindMethod,
+		RecvType: "*SessionCache",
+		File:     "cache.go",
+		Line:     12,
+	}
+	methodID, err := st.InsertSymbol(t.Context(), method)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	tagger := NewTagger(cfg, st)
+	_, err = tagger.Tag(t.Context())
+	if err != nil {
+		t.Fatalf("tagging failed: %v", err)
+	}
+
+	// Verify io:cache tag for Cache type, distinct from db and net
+	var tags []string
+	rows, err := st.Tx().Query(`SELECT tag FROM tags WHERE symbol_id = ? AND tag LIKE 'io:%'`, methodID)
+	if err != nil {
+		t.Fatalf("failed to query tags: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			t.Fatal(err)
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) != 1 || tags[0] != "io:cache" {
+		t.Errorf("expected only io:cache for *Cache receiver, got %v", tags)
+	}
+}
+
+func TestTagger_ConfiguredReceiverRules(t *testing.T) {
+	st := setupTestStore(t)
+	defer st.Close()
+
+	pkg := &store.Package{PkgPath: "myapp/billing", Dir: "/billing"}
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	recvTypes := map[string]string{
+		"*PaymentGateway": "io:net",
+		"*InvoiceDAO":     "io:db",
+		"*LedgerAdapter":  "io:db",
+		"*HTTPClient":     "", // Defaults no longer apply
+	}
+	ids := make(map[string]store.SymbolID)
+	for recv := range recvTypes {
+		id, err := st.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/billing", Name: "Do", Kind: store.SymbolKindMethod, RecvType: recv, File: "billing.go", Line: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[recv] = id
+	}
+
+	cfg := config.Default()
+	cfg.ReceiverTags = []config.ReceiverTagRule{
+		{Suffix: "gateway", Tag: "io:net"},
+		{Regex: "(?i)(dao|adapter)$", Tag: "io:db"},
+	}
+	if _, err := NewTagger(cfg, st).Tag(t.Context()); err != nil {
+		t.Fatalf("tagging failed: %v", err)
+	}
+
+	for recv, want := range recvTypes {
+		var got string
+		err := st.Tx().QueryRow(`SELECT tag FROM tags WHERE symbol_id = ? AND tag LIKE 'io:%'`, ids[recv]).Scan(&got)
+		if err != nil && err != sql.ErrNoRows {
+			t.Fatalf("failed to query tag: %v", err)
+		}
+		if got != want {
+			t.Errorf("tag for %s = %q, want %q", recv, got, want)
+		}
+	}
+}
+
+func TestTagger_IOTaggingModes(t *testing.T) {
+	tests := []struct {
+		mode string
+		want map[string]string // Function -> io:db reason, absent when untagged
+	}{
+		{config.IOTaggingDirect, map[string]string{
+			"query": "Calls database/sql",
+		}},
+		{config.IOTaggingCalls, map[string]string{
+			"query":   "Calls database/sql",
+			"GetUser": "Calls database/sql via query",
+		}},
+		{config.IOTaggingPackage, map[string]string{
+			"query":      "Package imports database/sql",
+			"GetUser":    "Package imports database/sql",
+			"FormatName": "Package imports database/sql",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			st := setupTestStore(t)
+			defer st.Close()
+
+			for _, pkgPath := range []string{"myapp/users", "database/sql"} {
+				if err := st.InsertPackage(t.Context(), &store.Package{PkgPath: pkgPath}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ids := make(map[string]store.SymbolID)
+			for _, fn := range []struct{ pkg, name string }{
+				{"myapp/users", "GetUser"},
+				{"myapp/users", "query"},
+				{"myapp/users", "FormatName"},
+				{"database/sql", "Query"},
+			} {
+				id, err := st.InsertSymbol(t.Context(), &store.Symbol{
+					PkgPath: fn.pkg, Name: fn.name, Kind: store.SymbolKindFunc, File: "f.go", Line: 1,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids[fn.name] = id
+			}
+			// GetUser -> query -> sql.Query; FormatName calls nothing
+			for _, e := range [][2]string{{"GetUser", "query"}, {"query", "Query"}} {
+				edge := &store.CallEdge{
+					CallerID: ids[e[0]], CalleeID: ids[e[1]], CallerFile: "f.go", CallerLine: 2,
+					CallKind: store.CallKindStatic, Count: 1,
+				}
+				if err := st.InsertCallEdge(t.Context(), edge); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg := config.Default()
+			cfg.IOTagging = tt.mode
+			if _, err := NewTagger(cfg, st).Tag(t.Context()); err != nil {
+				t.Fatalf("tagging failed: %v", err)
+			}
+
+			for _, name := range []string{"GetUser", "query", "FormatName"} {
+				var reason string
+				err := st.Tx().QueryRow(`SELECT reason FROM tags WHERE symbol_id = ? AND tag = 'io:db'`, ids[name]).Scan(&reason)
+				if err != nil && err != sql.ErrNoRows {
+					t.Fatalf("failed to query tag: %v", err)
+				}
+				if reason != tt.want[name] {
+					t.Errorf("%s: io:db reason = %q, want %q", name, reason, tt.want[name])
+				}
+			}
+		})
+	}
+}
+
+func TestTagger_DerivedIOTags(t *testing.T) {
+	tests := []struct {
+		distance int
+		want     map[string]string // Function -> derived io:db tag
+	}{
+		{3, map[string]string{"Find": "io:db@2", "Handle": "io:db@3"}},
+		{2, map[string]string{"Find": "io:db@2"}},
+		{-1, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.distance), func(t *testing.T) {
+			st := setupTestStore(t)
+			defer st.Close()
+
+			// handlers.Handle -> service.Find -> db.Query -> sql.Query
+			ids := make(map[string]store.SymbolID)
+			for _, fn := range []struct{ pkg, name string }{
+				{"myapp/handlers", "Handle"},
+				{"myapp/service", "Find"},
+				{"myapp/db", "Query"},
+				{"database/sql", "QueryContext"},
+			} {
+				if err := st.InsertPackage(t.Context(), &store.Package{PkgPath: fn.pkg}); err != nil {
+					t.Fatal(err)
+				}
+				id, err := st.InsertSymbol(t.Context(), &store.Symbol{
+					PkgPath: fn.pkg, Name: fn.name, Kind: store.SymbolKindFunc, File: "f.go", Line: 1,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids[fn.name] = id
+			}
+			for _, e := range [][2]string{{"Handle", "Find"}, {"Find", "Query"}, {"Query", "QueryContext"}} {
+				edge := &store.CallEdge{
+					CallerID: ids[e[0]], CalleeID: ids[e[1]], CallerFile: "f.go", CallerLine: 2,
+					CallKind: store.CallKindStatic, Count: 1,
+				}
+				if err := st.InsertCallEdge(t.Context(), edge); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg := config.Default()
+			cfg.IODistance = tt.distance
+			result, err := NewTagger(cfg, st).Tag(t.Context())
+			if err != nil {
+				t.Fatalf("tagging failed: %v", err)
+			}
+			if result.DerivedIOTags != len(tt.want) {
+				t.Errorf("DerivedIOTags = %d, want %d", result.DerivedIOTags, len(tt.want))
+			}
+
+			for _, name := range []string{"Handle", "Find", "Query"} {
+				var tag string
+				err := st.Tx().QueryRow(`SELECT tag FROM tags WHERE symbol_id = ? AND tag LIKE 'io:db@%'`, ids[name]).Scan(&tag)
+				if err != nil && err != sql.ErrNoRows {
+					t.Fatalf("failed to query tag: %v", err)
+				}
+				if tag != tt.want[name] {
+					t.Errorf("%s: derived tag = %q, want %q", name, tag, tt.want[name])
+				}
+			}
+		})
+	}
+}
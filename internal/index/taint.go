@@ -0,0 +1,360 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/tools/go/ssa"
+)
+
+// TaintAnalyzer finds HTTP and gRPC entrypoints where request input can
+// reach a sensitive sink (process execution, SQL text, file paths) without
+// passing a configured sanitizer.
+//
+// The analysis is a conservative SSA data flow: every value computed from a
+// tainted operand is tainted, stores taint the address they write to, and
+// calls into non-project code return tainted results (and fill tainted
+// pointer arguments) when any input is tainted. Calls into project
+// functions are followed with per-function summaries keyed by which
+// parameters are tainted. Only string and []string arguments of a sink
+// count, so parameterized queries (db.Query(q, args...)) are not reported.
+type TaintAnalyzer struct {
+	cfg         *config.Config
+	prog        *ssa.Program
+	projectPkgs projectPackages
+	summaries   map[taintKey]*taintSummary
+}
+
+// taintKey identifies a function analyzed with a given set of tainted
+// parameters (bit i set = parameter i tainted).
+type taintKey struct {
+	fn   *ssa.Function
+	mask uint64
+}
+
+// taintSummary is the result of analyzing one function.
+type taintSummary struct {
+	returnsTainted bool
+	hits           []taintHit
+}
+
+// taintHit is a sink call reached by tainted data.
+type taintHit struct {
+	sink     string
+	category string
+	pos      token.Position
+	path     []string // Functions from the analyzed function to the one making the call
+}
+
+// TaintResult holds the results of taint analysis.
+type TaintResult struct {
+	FindingCount    int
+	EntrypointCount int // Entrypoints with at least one finding
+}
+
+// NewTaintAnalyzer creates a taint analyzer for the loader's packages.
+func NewTaintAnalyzer(cfg *config.Config, loader *Loader, prog *ssa.Program) *TaintAnalyzer {
+	return &TaintAnalyzer{
+		cfg:         cfg,
+		prog:        prog,
+		projectPkgs: newProjectPackages(loader),
+		summaries:   make(map[taintKey]*taintSummary),
+	}
+}
+
+// Analyze checks every HTTP and gRPC entrypoint and records findings.
+func (a *TaintAnalyzer) Analyze(ctx context.Context, st *store.Store) (*TaintResult, error) {
+	result := &TaintResult{}
+	err := forEachHandler(ctx, st, a.prog, handlerTypes, func(batch *store.BatchTx, ep *store.EntrypointWithSymbol, fn *ssa.Function) error {
+		mask, sources := a.sourceParams(fn, ep.Type)
+		if mask == 0 {
+			return nil
+		}
+
+		// Report each sink call once, via the first path that reaches it
+		seen := make(map[string]bool)
+		for _, hit := range a.analyze(fn, mask).hits {
+			key := hit.pos.String() + "|" + hit.sink
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			finding := &store.TaintFinding{
+				EntrypointID: ep.ID,
+				Source:       strings.Join(sources, ", "),
+				Sink:         hit.sink,
+				SinkCategory: hit.category,
+				File:         hit.pos.Filename,
+				Line:         hit.pos.Line,
+				Path:         hit.path,
+			}
+			if err := batch.InsertTaintFinding(ctx, finding); err != nil {
+				return fmt.Errorf("inserting taint finding: %w", err)
+			}
+			result.FindingCount++
+		}
+		if len(seen) > 0 {
+			result.EntrypointCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// sourceParams returns the mask of parameters carrying request input and
+// their descriptions. HTTP handlers taint parameters of a configured source
+// type; gRPC methods taint every parameter except the receiver and context.
+func (a *TaintAnalyzer) sourceParams(fn *ssa.Function, epType store.EntrypointType) (uint64, []string) {
+	var mask uint64
+	var sources []string
+	for i, p := range fn.Params {
+		if i >= 64 {
+			break
+		}
+		if i == 0 && fn.Signature.Recv() != nil {
+			continue
+		}
+		var source bool
+		switch epType {
+		case store.EntrypointHTTP:
+			source = a.cfg.IsTaintSource(namedTypeName(p.Type()))
+		case store.EntrypointGRPC:
+			source = namedTypeName(p.Type()) != "context.Context"
+		}
+		if source {
+			mask |= 1 << i
+			sources = append(sources, p.Name()+" "+types.TypeString(p.Type(), nil))
+		}
+	}
+	return mask, sources
+}
+
+// analyze computes the summary of fn with the parameters in mask tainted.
+// Recursive calls see the in-progress (initially empty) summary.
+func (a *TaintAnalyzer) analyze(fn *ssa.Function, mask uint64) *taintSummary {
+	key := taintKey{fn: fn, mask: mask}
+	if sum, ok := a.summaries[key]; ok {
+		return sum
+	}
+	sum := &taintSummary{}
+	a.summaries[key] = sum
+
+	tainted := make(map[ssa.Value]bool)
+	for i, p := range fn.Params {
+		if i < 64 && mask&(1<<i) != 0 {
+			tainted[p] = true
+		}
+	}
+
+	changed := true
+	mark := func(v ssa.Value) {
+		if v != nil && !tainted[v] {
+			tainted[v] = true
+			changed = true
+		}
+	}
+	// markAddr taints an address and the aggregates it points into
+	var markAddr func(v ssa.Value)
+	markAddr = func(v ssa.Value) {
+		mark(v)
+		switch addr := v.(type) {
+		case *ssa.FieldAddr:
+			markAddr(addr.X)
+		case *ssa.IndexAddr:
+			markAddr(addr.X)
+		}
+	}
+
+	hits := make(map[string]taintHit)
+	label := fn.String()
+
+	// Phis may refer to values defined later, so iterate to a fixed point
+	for changed {
+		changed = false
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				switch v := instr.(type) {
+				case *ssa.Store:
+					if tainted[v.Val] {
+						markAddr(v.Addr)
+					}
+				case *ssa.MapUpdate:
+					if tainted[v.Key] || tainted[v.Value] {
+						mark(v.Map)
+					}
+				case *ssa.Send:
+					if tainted[v.X] {
+						mark(v.Chan)
+					}
+				case *ssa.Return:
+					for _, r := range v.Results {
+						if tainted[r] {
+							sum.returnsTainted = true
+						}
+					}
+				case ssa.CallInstruction:
+					a.call(v, label, tainted, mark, markAddr, hits)
+				case ssa.Value:
+					for _, op := range instr.Operands(nil) {
+						if *op != nil && tainted[*op] {
+							mark(v)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(hits))
+	for k := range hits {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sum.hits = append(sum.hits, hits[k])
+	}
+	return sum
+}
+
+// call propagates taint through one call instruction of the function named
+// label, recording sink hits keyed so repeated fixed-point passes do not
+// duplicate them.
+func (a *TaintAnalyzer) call(instr ssa.CallInstruction, label string, tainted map[ssa.Value]bool,
+	mark, markAddr func(ssa.Value), hits map[string]taintHit) {
+	common := instr.Common()
+	result := instr.Value() // Nil for go and defer
+
+	callee := common.StaticCallee()
+	var name string
+	if common.IsInvoke() {
+		name = qualifiedFuncName(common.Method)
+	} else if callee != nil {
+		name = ssaFuncName(callee)
+	}
+
+	inputs := common.Args
+	if common.IsInvoke() {
+		inputs = append([]ssa.Value{common.Value}, common.Args...)
+	}
+	anyTainted := false
+	for _, in := range inputs {
+		if tainted[in] {
+			anyTainted = true
+			break
+		}
+	}
+	if !anyTainted {
+		return
+	}
+
+	if name != "" && a.cfg.IsTaintSanitizer(name) {
+		return
+	}
+
+	if category := a.cfg.GetTaintSinkCategory(name); category != "" {
+		for _, arg := range common.Args {
+			if tainted[arg] && isTaintableArg(arg.Type()) {
+				pos := a.prog.Fset.Position(instr.Pos())
+				hit := taintHit{sink: name, category: category, pos: pos, path: []string{label}}
+				hits[pos.String()+"|"+name+"|"+label] = hit
+				break
+			}
+		}
+	}
+
+	// Follow calls into project code with the tainted parameters
+	if a.projectPkgs.follows(callee) {
+		var mask uint64
+		for i, arg := range common.Args {
+			if i < 64 && tainted[arg] {
+				mask |= 1 << i
+			}
+		}
+		sum := a.analyze(callee, mask)
+		if sum.returnsTainted && result != nil {
+			mark(result)
+		}
+		for _, h := range sum.hits {
+			h.path = append([]string{label}, h.path...)
+			hits[h.pos.String()+"|"+h.sink+"|"+strings.Join(h.path, ">")] = h
+		}
+		return
+	}
+
+	// Library or dynamic call: the result derives from its inputs, and
+	// pointer arguments may be filled from them (json.Unmarshal, Decode)
+	if result != nil {
+		mark(result)
+	}
+	for _, arg := range common.Args {
+		switch arg.Type().Underlying().(type) {
+		case *types.Pointer, *types.Slice:
+			markAddr(arg)
+		}
+	}
+}
+
+// isTaintableArg reports whether a sink argument of type t can carry
+// injected text: strings and string slices.
+func isTaintableArg(t types.Type) bool {
+	if basic, ok := t.Underlying().(*types.Basic); ok {
+		return basic.Info()&types.IsString != 0
+	}
+	if slice, ok := t.Underlying().(*types.Slice); ok {
+		basic, ok := slice.Elem().Underlying().(*types.Basic)
+		return ok && basic.Info()&types.IsString != 0
+	}
+	return false
+}
+
+// ssaFuncName returns the configured-pattern name of an SSA function,
+// "pkgpath.Func" or "pkgpath.Type.Method", or empty for closures and
+// synthetic functions.
+func ssaFuncName(fn *ssa.Function) string {
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+	obj, ok := fn.Object().(*types.Func)
+	if !ok {
+		return ""
+	}
+	return qualifiedFuncName(obj)
+}
+
+// qualifiedFuncName returns "pkgpath.Func" or "pkgpath.Type.Method".
+func qualifiedFuncName(obj *types.Func) string {
+	if obj == nil || obj.Pkg() == nil {
+		return ""
+	}
+	sig, ok := obj.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return obj.Pkg().Path() + "." + obj.Name()
+	}
+	if recv := namedTypeName(sig.Recv().Type()); recv != "" {
+		return recv + "." + obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+// namedTypeName returns "pkgpath.Type" for a named type or a pointer to
+// one, or empty string otherwise.
+func namedTypeName(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	return named.Obj().Pkg().Path() + "." + named.Obj().Name()
+}
@@ -0,0 +1,133 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestTaintAnalyzer(t *testing.T) {
+	// A dependency-free module with its own request type, sinks, and
+	// sanitizer, wired up through the taint config
+	tmpDir := t.TempDir()
+	src := `package main
+
+type Request struct {
+	Query map[string]string
+	Body  string
+}
+
+func Command(name string, args ...string) {}
+
+func Query(q string, args ...any) {}
+
+func Quote(s string) string { return "'" + s + "'" }
+
+func runHandler(r *Request) {
+	runShell(r.Query["cmd"])
+}
+
+func runShell(cmd string) {
+	Command("sh", "-c", cmd)
+}
+
+func safeHandler(r *Request) {
+	Command(Quote(r.Body))
+}
+
+func queryHandler(r *Request) {
+	Query("SELECT * FROM users WHERE name = ?", r.Body)
+	Query("SELECT * FROM users WHERE name = '" + r.Body + "'")
+}
+
+func main() {}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module taintmod\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.Taint = config.TaintConfig{
+		Sources:    []string{"taintmod.Request"},
+		Sinks:      map[string][]string{"exec": {"taintmod.Command"}, "sql": {"taintmod.Query"}},
+		Sanitizers: []string{"taintmod.Quote"},
+	}
+
+	loader := NewLoader(cfg, tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	for _, name := range []string{"runHandler", "safeHandler", "queryHandler"} {
+		id, err := st.FindSymbolID(t.Context(), "taintmod", name, "")
+		if err != nil {
+			t.Fatalf("finding %s: %v", name, err)
+		}
+		ep := &store.Entrypoint{Type: store.EntrypointHTTP, Label: "POST /" + name, SymbolID: id}
+		if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
+			t.Fatalf("inserting entrypoint: %v", err)
+		}
+	}
+
+	cg := NewCallGraphBuilder(loader)
+	if err := cg.Build(); err != nil {
+		t.Fatalf("building SSA: %v", err)
+	}
+	result, err := NewTaintAnalyzer(cfg, loader, cg.GetSSAProgram()).Analyze(t.Context(), st)
+	if err != nil {
+		t.Fatalf("analyzing: %v", err)
+	}
+
+	findings, err := st.GetTaintFindings(t.Context())
+	if err != nil {
+		t.Fatalf("getting findings: %v", err)
+	}
+	if result.FindingCount != len(findings) || result.EntrypointCount != 2 {
+		t.Errorf("expected %d findings in 2 entrypoints, got %+v", len(findings), result)
+	}
+
+	byLabel := make(map[string][]store.TaintFinding)
+	for _, f := range findings {
+		byLabel[f.EntrypointLabel] = append(byLabel[f.EntrypointLabel], f)
+	}
+
+	// Tainted command reaches exec through a helper
+	run := byLabel["POST /runHandler"]
+	if len(run) != 1 {
+		t.Fatalf("expected 1 finding for runHandler, got %+v", run)
+	}
+	if run[0].Sink != "taintmod.Command" || run[0].SinkCategory != "exec" || run[0].Line != 19 {
+		t.Errorf("unexpected runHandler finding: %+v", run[0])
+	}
+	if len(run[0].Path) != 2 || run[0].Path[0] != "taintmod.runHandler" || run[0].Path[1] != "taintmod.runShell" {
+		t.Errorf("expected path runHandler -> runShell, got %v", run[0].Path)
+	}
+	if run[0].Source != "r *taintmod.Request" {
+		t.Errorf("unexpected source %q", run[0].Source)
+	}
+
+	// The sanitizer clears the taint
+	if got := byLabel["POST /safeHandler"]; len(got) != 0 {
+		t.Errorf("expected no findings for safeHandler, got %+v", got)
+	}
+
+	// Parameterized query is fine; concatenated query text is not
+	query := byLabel["POST /queryHandler"]
+	if len(query) != 1 || query[0].SinkCategory != "sql" || query[0].Line != 28 {
+		t.Errorf("expected one sql finding on line 28 for queryHandler, got %+v", query)
+	}
+}
@@ -0,0 +1,122 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// TypeRelationResult holds the results of type relation extraction.
+type TypeRelationResult struct {
+	Fields     int // Named struct fields recorded
+	Embeddings int // Embedded fields and interfaces recorded
+}
+
+// ExtractTypeRelations records the fields and embeddings of every
+// package-level project type with a symbol: struct fields, embedded
+// structs, and embedded interfaces. Each relation names the type at the core
+// of the field's type (through pointers, slices, arrays, maps, and
+// channels), so both "what does Service hold" and "who holds a *sql.DB" can
+// be answered.
+func ExtractTypeRelations(ctx context.Context, loader *Loader, st *store.Store) (*TypeRelationResult, error) {
+	batch, err := st.BeginBatch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("starting batch: %w", err)
+	}
+	defer batch.Rollback()
+
+	result := &TypeRelationResult{}
+	insert := func(rel *store.TypeRelation, t types.Type) error {
+		rel.FieldType = types.TypeString(t, nil)
+		if obj := coreTypeName(t); obj != nil {
+			rel.TargetName = obj.Name()
+			if obj.Pkg() != nil {
+				rel.TargetPkg = obj.Pkg().Path()
+			}
+		}
+		if err := batch.InsertTypeRelation(ctx, rel); err != nil {
+			return err
+		}
+		if rel.Relation == store.RelationEmbed {
+			result.Embeddings++
+		} else {
+			result.Fields++
+		}
+		return nil
+	}
+
+	for _, pkg := range loader.Packages() {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			id, err := batch.GetSymbolID(ctx, pkg.PkgPath, name, "")
+			if err != nil || id == 0 {
+				continue // Excluded file
+			}
+
+			switch u := tn.Type().Underlying().(type) {
+			case *types.Struct:
+				for i := 0; i < u.NumFields(); i++ {
+					f := u.Field(i)
+					rel := &store.TypeRelation{TypeID: id, Field: f.Name(), Relation: store.RelationField}
+					if f.Embedded() {
+						rel.Relation = store.RelationEmbed
+					}
+					if err := insert(rel, f.Type()); err != nil {
+						return nil, fmt.Errorf("inserting field %s of %s: %w", f.Name(), name, err)
+					}
+				}
+			case *types.Interface:
+				for i := 0; i < u.NumEmbeddeds(); i++ {
+					t := u.EmbeddedType(i)
+					obj := coreTypeName(t)
+					if obj == nil {
+						continue // Type set term such as ~int
+					}
+					rel := &store.TypeRelation{TypeID: id, Field: obj.Name(), Relation: store.RelationEmbed}
+					if err := insert(rel, t); err != nil {
+						return nil, fmt.Errorf("inserting embedding %s of %s: %w", obj.Name(), name, err)
+					}
+				}
+			}
+		}
+	}
+
+	if err := batch.Commit(); err != nil {
+		return nil, fmt.Errorf("committing batch: %w", err)
+	}
+	return result, nil
+}
+
+// coreTypeName returns the named type at the core of t, looking through
+// pointers, slices, arrays, map values, and channels, or nil when there is
+// none (e.g. a func or struct literal type). Instantiated generic types
+// resolve to their generic declaration.
+func coreTypeName(t types.Type) *types.TypeName {
+	for {
+		switch typ := types.Unalias(t).(type) {
+		case *types.Named:
+			return typ.Origin().Obj()
+		case *types.Pointer:
+			t = typ.Elem()
+		case *types.Slice:
+			t = typ.Elem()
+		case *types.Array:
+			t = typ.Elem()
+		case *types.Map:
+			t = typ.Elem()
+		case *types.Chan:
+			t = typ.Elem()
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+func TestExtractTypeRelations(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := `package app
+
+import "sync"
+
+type Store struct{}
+
+type Reader interface{ Read() }
+
+type ReadCloser interface {
+	Reader
+	Close()
+}
+
+type Base struct{ mu sync.Mutex }
+
+type Service struct {
+	Base
+	*Store
+	stores  map[string][]*Store
+	handler func()
+	name    string
+	err     error
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "app.go"), []byte(src), 0644); err != nil {
+		t.Fatalf("writing app.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module app\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	loader := NewLoader(config.Default(), tmpDir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(tmpDir)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	result, err := ExtractTypeRelations(t.Context(), loader, st)
+	if err != nil {
+		t.Fatalf("extracting type relations: %v", err)
+	}
+	if result.Fields != 5 || result.Embeddings != 3 {
+		t.Errorf("expected 5 fields and 3 embeddings, got %d and %d", result.Fields, result.Embeddings)
+	}
+
+	service, err := st.GetSymbolID(t.Context(), "app", "Service", "")
+	if err != nil {
+		t.Fatalf("finding Service: %v", err)
+	}
+	storeID, err := st.GetSymbolID(t.Context(), "app", "Store", "")
+	if err != nil {
+		t.Fatalf("finding Store: %v", err)
+	}
+	fields, err := st.GetTypeFields(t.Context(), service)
+	if err != nil {
+		t.Fatalf("getting fields: %v", err)
+	}
+	want := []struct {
+		field, relation, fieldType, target string
+		targetID                           store.SymbolID
+	}{
+		{"Base", store.RelationEmbed, "app.Base", "app.Base", 0},
+		{"Store", store.RelationEmbed, "*app.Store", "app.Store", storeID},
+		{"stores", store.RelationField, "map[string][]*app.Store", "app.Store", storeID},
+		{"handler", store.RelationField, "func()", ".", 0},
+		{"name", store.RelationField, "string", ".", 0},
+		{"err", store.RelationField, "error", ".error", 0},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %d fields, got %+v", len(want), fields)
+	}
+	for i, w := range want {
+		f := fields[i]
+		if f.Field != w.field || f.Relation != w.relation || f.FieldType != w.fieldType || f.TargetPkg+"."+f.TargetName != w.target {
+			t.Errorf("field %d: expected %+v, got %+v", i, w, f)
+		}
+		if w.targetID != 0 && f.TargetID != w.targetID {
+			t.Errorf("field %s: expected target ID %d, got %d", f.Field, w.targetID, f.TargetID)
+		}
+	}
+
+	// Holders are found for project and external types alike
+	holders, err := st.GetTypeHolders(t.Context(), "app", "Store")
+	if err != nil {
+		t.Fatalf("getting holders: %v", err)
+	}
+	if len(holders) != 2 || holders[0].Holder.Name != "Service" {
+		t.Errorf("expected Service to hold Store twice, got %+v", holders)
+	}
+	holders, err = st.GetTypeHolders(t.Context(), "sync", "Mutex")
+	if err != nil {
+		t.Fatalf("getting holders: %v", err)
+	}
+	if len(holders) != 1 || holders[0].Holder.Name != "Base" || holders[0].Field != "mu" {
+		t.Errorf("expected Base.mu to hold sync.Mutex, got %+v", holders)
+	}
+	holders, err = st.GetTypeHolders(t.Context(), "app", "Reader")
+	if err != nil {
+		t.Fatalf("getting holders: %v", err)
+	}
+	if len(holders) != 1 || holders[0].Holder.Name != "ReadCloser" || holders[0].Relation != store.RelationEmbed {
+		t.Errorf("expected ReadCloser to embed Reader, got %+v", holders)
+	}
+}
@@ -0,0 +1,25 @@
+package index
+
+import (
+	"context"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// ReadVCSInfo returns the git revision checked out in projectDir, or nil if
+// it isn't in a git work tree or has no commits. The tree is dirty when
+// files under projectDir differ from the commit, untracked files included;
+// the index directory is ignored, since runs write to it.
+func ReadVCSInfo(ctx context.Context, projectDir string) *store.VCSInfo {
+	commit, err := gitLines(ctx, projectDir, "rev-parse", "HEAD")
+	if err != nil || len(commit) != 1 {
+		return nil
+	}
+	info := &store.VCSInfo{Commit: commit[0]}
+	if branch, err := gitLines(ctx, projectDir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && len(branch) == 1 && branch[0] != "HEAD" {
+		info.Branch = branch[0] // "HEAD" when detached, e.g. in CI checkouts of a tag
+	}
+	status, err := gitLines(ctx, projectDir, "status", "--porcelain", "--", ".", ":(exclude).flowlens")
+	info.Dirty = err == nil && len(status) > 0
+	return info
+}
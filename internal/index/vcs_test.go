@@ -0,0 +1,58 @@
+package index
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadVCSInfo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if info := ReadVCSInfo(t.Context(), dir); info != nil {
+		t.Fatalf("expected no revision outside a work tree, got %+v", info)
+	}
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	write("main.go", "package main\n\nfunc main() {}\n")
+	git("init", "-q")
+	git("checkout", "-q", "-b", "release")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	// The index directory doesn't make the tree dirty, even when not ignored
+	write(".flowlens/index.db", "")
+	info := ReadVCSInfo(t.Context(), dir)
+	if info == nil || len(info.Commit) != 40 || info.Branch != "release" || info.Dirty {
+		t.Fatalf("expected a clean checkout of release, got %+v", info)
+	}
+
+	write("main.go", "package main\n\nfunc main() { println() }\n")
+	if info := ReadVCSInfo(t.Context(), dir); info == nil || !info.Dirty {
+		t.Errorf("expected a modified file to make the tree dirty, got %+v", info)
+	}
+
+	git("checkout", "-q", "--detach")
+	if info := ReadVCSInfo(t.Context(), dir); info == nil || info.Branch != "" {
+		t.Errorf("expected no branch on a detached HEAD, got %+v", info)
+	}
+}
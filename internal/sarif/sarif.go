@@ -0,0 +1,168 @@
+// Package sarif renders FlowLens findings as SARIF 2.1.0 logs, the format
+// consumed by GitHub code scanning and most static analysis dashboards.
+package sarif
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+const (
+	schemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+	version   = "2.1.0"
+	toolName  = "flowlens"
+	toolURI   = "https://github.com/abramin/flowlens"
+)
+
+// Rule IDs.
+const (
+	RuleMissingAuth = "FL001"
+)
+
+// Log is the top-level SARIF document.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is one invocation of the tool.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the analyzer.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver names the analyzer and the rules it can report.
+type Driver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule describes one kind of finding.
+type Rule struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	ShortDescription Message `json:"shortDescription"`
+	FullDescription  Message `json:"fullDescription"`
+	DefaultConfig    Config  `json:"defaultConfiguration"`
+}
+
+// Config is a rule's default configuration.
+type Config struct {
+	Level string `json:"level"` // "error", "warning", or "note"
+}
+
+// Message is a plain text message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is one finding.
+type Result struct {
+	RuleID     string         `json:"ruleId"`
+	Level      string         `json:"level"`
+	Message    Message        `json:"message"`
+	Locations  []Location     `json:"locations"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// Location points at a source region.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a file and region.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           Region           `json:"region"`
+}
+
+// ArtifactLocation is a file URI, relative to the source root when possible.
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// Region is a 1-based line range.
+type Region struct {
+	StartLine int `json:"startLine"`
+}
+
+var missingAuthRule = Rule{
+	ID:               RuleMissingAuth,
+	Name:             "MissingAuthMiddleware",
+	ShortDescription: Message{Text: "HTTP entrypoint without authentication"},
+	FullDescription: Message{Text: "The route is not wrapped by a configured auth middleware " +
+		"and its handler never reaches an auth check. Add auth middleware, or list the route " +
+		"under auth.public in flowlens.yaml if it is meant to be public."},
+	DefaultConfig: Config{Level: "error"},
+}
+
+// FromAuthChecks builds a log with one result per route missing auth.
+// Checks with any other status are skipped. File paths are made relative
+// to projectDir so results map onto the repository checkout.
+func FromAuthChecks(checks []store.AuthCheck, projectDir, toolVersion string) *Log {
+	results := []Result{}
+	for _, c := range checks {
+		if c.Status != store.AuthMissing {
+			continue
+		}
+		msg := fmt.Sprintf("%s is not behind an auth middleware and its handler reaches no auth check", c.EntrypointLabel)
+		if len(c.Middleware) > 0 {
+			msg += fmt.Sprintf(" (middleware: %s)", strings.Join(c.Middleware, ", "))
+		}
+		results = append(results, Result{
+			RuleID:    RuleMissingAuth,
+			Level:     missingAuthRule.DefaultConfig.Level,
+			Message:   Message{Text: msg},
+			Locations: []Location{location(c.File, c.Line, projectDir)},
+			Properties: map[string]any{
+				"entrypoint": c.EntrypointLabel,
+			},
+		})
+	}
+
+	return &Log{
+		Schema:  schemaURI,
+		Version: version,
+		Runs: []Run{{
+			Tool: Tool{Driver: Driver{
+				Name:           toolName,
+				Version:        toolVersion,
+				InformationURI: toolURI,
+				Rules:          []Rule{missingAuthRule},
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// location builds a SARIF location, relative to projectDir when the file
+// is inside it.
+func location(file string, line int, projectDir string) Location {
+	loc := ArtifactLocation{URI: filepath.ToSlash(file)}
+	if projectDir != "" && filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(projectDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			loc = ArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: "%SRCROOT%"}
+		}
+	} else if !filepath.IsAbs(file) {
+		loc.URIBaseID = "%SRCROOT%"
+	}
+	if line < 1 {
+		line = 1
+	}
+	return Location{PhysicalLocation: PhysicalLocation{
+		ArtifactLocation: loc,
+		Region:           Region{StartLine: line},
+	}}
+}
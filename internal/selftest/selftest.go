@@ -0,0 +1,272 @@
+// Package selftest indexes the FlowLens source tree and queries the result
+// through the API, as an end-to-end check of an installed binary.
+package selftest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/index"
+	"github.com/abramin/flowlens/internal/server"
+	"github.com/abramin/flowlens/internal/store"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// ModulePath is the module the self-test indexes.
+const ModulePath = "github.com/abramin/flowlens"
+
+// indexPkg is the package holding the indexer, whose Run method the
+// queries look for.
+const indexPkg = ModulePath + "/internal/index"
+
+// Options configures a self-test.
+type Options struct {
+	Dir string    // FlowLens source tree; empty to locate it (see Locate)
+	Log io.Writer // Indexer progress; nil to discard it
+}
+
+// Check is the outcome of one step of the self-test.
+type Check struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Detail   string        `json:"detail"` // What was found, or why the check failed
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Report lists the checks of a self-test, stopping at the first failure
+// that later checks depend on.
+type Report struct {
+	Dir    string  `json:"dir"`
+	Passed bool    `json:"passed"`
+	Checks []Check `json:"checks"`
+}
+
+// Run indexes the FlowLens source tree into a temporary directory and runs
+// graph, spine, and CFG queries against it through the API handlers.
+// Failed checks are reported, not returned as errors.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	dir := opts.Dir
+	if dir == "" {
+		var err error
+		if dir, err = Locate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkModule(dir); err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "flowlens-selftest-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cfg, err := config.LoadFromDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	cfg.Database = filepath.Join(tmpDir, "index.db")
+
+	report := &Report{Dir: dir}
+	start := time.Now()
+	idx := index.NewIndexer(cfg, dir)
+	if opts.Log != nil {
+		idx.SetOutput(opts.Log)
+	} else {
+		idx.SetOutput(io.Discard)
+	}
+	result, err := idx.Run(ctx)
+	check := Check{Name: "index", Duration: time.Since(start)}
+	if err != nil {
+		check.Detail = err.Error()
+		report.Checks = append(report.Checks, check)
+		return report, nil
+	}
+	check.Passed = result.SymbolCount > 0 && result.CallEdgeCount > 0
+	check.Detail = fmt.Sprintf("%d packages, %d symbols, %d call edges, %d entrypoints",
+		result.PackageCount, result.SymbolCount, result.CallEdgeCount, result.EntrypointCount)
+	report.Checks = append(report.Checks, check)
+	if !check.Passed {
+		return report, nil
+	}
+
+	srv, err := server.New(server.Config{ProjectDir: dir, DBPath: cfg.Database, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer srv.Close()
+
+	report.Checks = append(report.Checks, runChecks(ctx, srv.Handler())...)
+	report.Passed = !slices.ContainsFunc(report.Checks, func(c Check) bool { return !c.Passed })
+	return report, nil
+}
+
+// Locate finds the FlowLens source tree: the module containing the working
+// directory when it is FlowLens, otherwise the copy in the module cache
+// matching the running binary's version (as installed by go install).
+func Locate() (string, error) {
+	if wd, err := os.Getwd(); err == nil {
+		for dir := wd; ; dir = filepath.Dir(dir) {
+			if checkModule(dir) == nil {
+				return dir, nil
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" || info.Main.Version == "(devel)" {
+		return "", fmt.Errorf("cannot locate the FlowLens source; run from a checkout or pass its path")
+	}
+	out, err := exec.Command("go", "env", "GOMODCACHE").Output()
+	if err != nil {
+		return "", fmt.Errorf("finding the module cache: %w", err)
+	}
+	escaped, err := module.EscapePath(ModulePath)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(strings.TrimSpace(string(out)), filepath.FromSlash(escaped)+"@"+info.Main.Version)
+	if err := checkModule(dir); err != nil {
+		return "", fmt.Errorf("cannot locate the FlowLens source for %s; run from a checkout or pass its path", info.Main.Version)
+	}
+	return dir, nil
+}
+
+// checkModule returns an error unless dir is the root of the FlowLens module.
+func checkModule(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return err
+	}
+	if path := modfile.ModulePath(data); path != ModulePath {
+		return fmt.Errorf("%s holds module %q, not %s", dir, path, ModulePath)
+	}
+	return nil
+}
+
+// runChecks queries an index of FlowLens through the API. Each check finds
+// something the next one needs, so the first failure ends the run.
+func runChecks(ctx context.Context, h http.Handler) []Check {
+	var checks []Check
+	var entrypoint store.Entrypoint
+	var run store.Symbol
+
+	steps := []struct {
+		name string
+		fn   func() (string, error)
+	}{
+		{"health", func() (string, error) {
+			var health server.HealthResponse
+			if err := get(ctx, h, "/api/health", &health); err != nil {
+				return "", err
+			}
+			if health.SchemaVersion != health.ServerSchemaVersion {
+				return "", fmt.Errorf("index schema v%d, server expects v%d", health.SchemaVersion, health.ServerSchemaVersion)
+			}
+			return fmt.Sprintf("schema v%d", health.SchemaVersion), nil
+		}},
+		{"entrypoints", func() (string, error) {
+			var eps []store.Entrypoint
+			if err := get(ctx, h, "/api/entrypoints?type=cli", &eps); err != nil {
+				return "", err
+			}
+			i := slices.IndexFunc(eps, func(ep store.Entrypoint) bool {
+				return ep.Label == "index" || strings.HasSuffix(ep.Label, " index")
+			})
+			if i < 0 {
+				return "", fmt.Errorf("no index command among %d CLI entrypoints", len(eps))
+			}
+			entrypoint = eps[i]
+			return fmt.Sprintf("%d CLI entrypoints, including %q", len(eps), entrypoint.Label), nil
+		}},
+		{"search", func() (string, error) {
+			var results []store.SearchResult
+			if err := get(ctx, h, "/api/search?query=Indexer+Run&kind=method", &results); err != nil {
+				return "", err
+			}
+			i := slices.IndexFunc(results, func(r store.SearchResult) bool {
+				return r.Symbol.PkgPath == indexPkg && r.Symbol.Name == "Run" && r.Symbol.RecvType == "*Indexer"
+			})
+			if i < 0 {
+				return "", fmt.Errorf("(*Indexer).Run not among %d results", len(results))
+			}
+			run = results[i].Symbol
+			return fmt.Sprintf("found (*Indexer).Run at %s:%d", filepath.Base(run.File), run.Line), nil
+		}},
+		{"graph", func() (string, error) {
+			var graph server.GraphResponse
+			if err := get(ctx, h, fmt.Sprintf("/api/graph/root/%d?depth=3", entrypoint.SymbolID), &graph); err != nil {
+				return "", err
+			}
+			if !slices.ContainsFunc(graph.Nodes, func(n server.GraphNode) bool { return n.ID == run.ID }) {
+				return "", fmt.Errorf("(*Indexer).Run not reached from %q (%d nodes)", entrypoint.Label, len(graph.Nodes))
+			}
+			return fmt.Sprintf("%d nodes and %d edges from %q", len(graph.Nodes), len(graph.Edges), entrypoint.Label), nil
+		}},
+		{"spine", func() (string, error) {
+			var spine server.SpineResponse
+			if err := get(ctx, h, fmt.Sprintf("/api/spine/%d", run.ID), &spine); err != nil {
+				return "", err
+			}
+			if len(spine.MainPath) < 2 {
+				return "", fmt.Errorf("main path of (*Indexer).Run has %d nodes", len(spine.MainPath))
+			}
+			return fmt.Sprintf("main path of %d nodes", len(spine.MainPath)), nil
+		}},
+		{"cfg", func() (string, error) {
+			var cfg index.CFGInfo
+			if err := get(ctx, h, fmt.Sprintf("/api/cfg/%d", run.ID), &cfg); err != nil {
+				return "", err
+			}
+			if len(cfg.Blocks) < 2 || len(cfg.ExitBlocks) == 0 {
+				return "", fmt.Errorf("(*Indexer).Run has %d blocks and %d exits", len(cfg.Blocks), len(cfg.ExitBlocks))
+			}
+			return fmt.Sprintf("%d blocks, %d loops", len(cfg.Blocks), len(cfg.Loops)), nil
+		}},
+	}
+
+	for _, step := range steps {
+		start := time.Now()
+		detail, err := step.fn()
+		check := Check{Name: step.name, Passed: err == nil, Detail: detail, Duration: time.Since(start)}
+		if err != nil {
+			check.Detail = err.Error()
+		}
+		checks = append(checks, check)
+		if err != nil {
+			break
+		}
+	}
+	return checks
+}
+
+// get serves a GET request in process and decodes the JSON response.
+func get(ctx context.Context, h http.Handler, target string, v any) error {
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return fmt.Errorf("GET %s: %d %s", req.URL.Path, w.Code, strings.TrimSpace(w.Body.String()))
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		return fmt.Errorf("GET %s: %w", req.URL.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package selftest
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/index"
+	"github.com/abramin/flowlens/internal/server"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// writeFixture writes a miniature FlowLens module: a main calling
+// (*Indexer).Run, which branches into a helper.
+func writeFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module " + ModulePath + "\n\ngo 1.21\n",
+		"cmd/flowlens/main.go": `package main
+
+import "` + indexPkg + `"
+
+func main() {
+	idx := &index.Indexer{}
+	idx.Run()
+}
+`,
+		"internal/index/indexer.go": `package index
+
+type Indexer struct {
+	n int
+}
+
+func (idx *Indexer) Run() error {
+	if idx.n > 0 {
+		return idx.load()
+	}
+	return nil
+}
+
+func (idx *Indexer) load() error {
+	return nil
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// indexFixture stores the fixture's symbols, its call edges, and an index
+// command entrypoint; the CLI detector needs cobra, which the fixture
+// doesn't import.
+func indexFixture(t *testing.T, dir string) {
+	t.Helper()
+	loader := index.NewLoader(config.Default(), dir)
+	if err := loader.Load(); err != nil {
+		t.Fatalf("loading packages: %v", err)
+	}
+	st, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	if err := loader.ExtractSymbols(t.Context(), st); err != nil {
+		t.Fatalf("extracting symbols: %v", err)
+	}
+
+	ids := make(map[string]store.SymbolID)
+	for _, name := range []string{"main", "Run", "load"} {
+		results, err := st.SearchSymbols(t.Context(), store.SearchFilter{Query: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range results {
+			if r.Symbol.Name == name {
+				ids[name] = r.Symbol.ID
+			}
+		}
+		if ids[name] == 0 {
+			t.Fatalf("symbol %s not extracted", name)
+		}
+	}
+	for _, e := range [][2]string{{"main", "Run"}, {"Run", "load"}} {
+		edge := &store.CallEdge{CallerID: ids[e[0]], CalleeID: ids[e[1]], CallKind: store.CallKindStatic, Count: 1}
+		if err := st.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ep := &store.Entrypoint{Type: store.EntrypointCLI, Label: "flowlens index", SymbolID: ids["main"]}
+	if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetMetadata(t.Context(), "schema_version", strconv.Itoa(store.SchemaVersion)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunChecks(t *testing.T) {
+	dir := writeFixture(t)
+	indexFixture(t, dir)
+
+	srv, err := server.New(server.Config{ProjectDir: dir, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	checks := runChecks(t.Context(), srv.Handler())
+	var names []string
+	for _, c := range checks {
+		names = append(names, c.Name)
+		if !c.Passed {
+			t.Errorf("check %s failed: %s", c.Name, c.Detail)
+		}
+	}
+	if got := strings.Join(names, ","); got != "health,entrypoints,search,graph,spine,cfg" {
+		t.Errorf("expected every check to run, got %s", got)
+	}
+}
+
+func TestRunChecksStopsAtFirstFailure(t *testing.T) {
+	dir := t.TempDir()
+	st, err := store.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetMetadata(t.Context(), "schema_version", strconv.Itoa(store.SchemaVersion)); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	srv, err := server.New(server.Config{ProjectDir: dir, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	// An empty index has no index command
+	checks := runChecks(t.Context(), srv.Handler())
+	if len(checks) != 2 || !checks[0].Passed || checks[1].Passed {
+		t.Fatalf("expected health to pass and entrypoints to fail, got %+v", checks)
+	}
+	if !strings.Contains(checks[1].Detail, "no index command") {
+		t.Errorf("unexpected failure detail %q", checks[1].Detail)
+	}
+}
+
+func TestCheckModule(t *testing.T) {
+	if err := checkModule(writeFixture(t)); err != nil {
+		t.Errorf("expected the fixture to pass as FlowLens: %v", err)
+	}
+
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "go.mod"), []byte("module example.com/other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkModule(other); err == nil || !strings.Contains(err.Error(), "example.com/other") {
+		t.Errorf("expected a module mismatch error, got %v", err)
+	}
+	if err := checkModule(t.TempDir()); err == nil {
+		t.Error("expected an error without go.mod")
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/config"
+)
+
+// Badge colors, matching shields.io's defaults.
+const (
+	badgeLabelColor = "#555"
+	badgeBlue       = "#007ec6"
+	badgeGreen      = "#4c1"
+	badgeRed        = "#e05d44"
+)
+
+// badgeCharWidth approximates the width of one character of 11px Verdana.
+const badgeCharWidth = 7
+
+// handleBadge handles GET /api/badge.svg?metric=entrypoints|symbols|violations
+// Renders a shields.io-style SVG badge so index stats can be embedded in READMEs.
+func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		metric = "entrypoints"
+	}
+
+	var value int
+	color := badgeBlue
+	switch metric {
+	case "entrypoints", "symbols":
+		stats, err := s.store.GetStats(ctx)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get stats: %v", err))
+			return
+		}
+		if metric == "entrypoints" {
+			value = stats.EntrypointCount
+		} else {
+			value = stats.SymbolCount
+		}
+	case "violations":
+		counts, err := s.store.GetCrossLayerEdgeCounts(ctx)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to count violations: %v", err))
+			return
+		}
+		for _, c := range counts {
+			if config.IsLayerViolation(c.CallerLayer, c.CalleeLayer) {
+				value += c.Count
+			}
+		}
+		color = badgeGreen
+		if value > 0 {
+			color = badgeRed
+		}
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown metric %q (expected entrypoints, symbols, or violations)", metric))
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	// Badges are embedded by third-party pages; don't let them go stale in caches
+	w.Header().Set("Cache-Control", "no-cache, max-age=0")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(renderBadge(metric, strconv.Itoa(value), color)))
+}
+
+// renderBadge renders a flat two-part badge: a gray label and a colored value.
+func renderBadge(label, value, color string) string {
+	labelWidth := len(label)*badgeCharWidth + 10
+	valueWidth := len(value)*badgeCharWidth + 10
+	total := labelWidth + valueWidth
+
+	label = html.EscapeString(label)
+	value = html.EscapeString(value)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, total, label, value)
+	fmt.Fprintf(&b, `<title>%s: %s</title>`, label, value)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, total)
+	b.WriteString(`<g clip-path="url(#r)">`)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="%s"/>`, labelWidth, badgeLabelColor)
+	fmt.Fprintf(&b, `<rect x="%d" width="%d" height="20" fill="%s"/>`, labelWidth, valueWidth, color)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="url(#s)"/>`, total)
+	b.WriteString(`</g>`)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text>`, labelWidth/2, label)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, labelWidth/2, label)
+	fmt.Fprintf(&b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text>`, labelWidth+valueWidth/2, value)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, labelWidth+valueWidth/2, value)
+	b.WriteString(`</g></svg>`)
+	return b.String()
+}
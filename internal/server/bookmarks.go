@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// maxViewStateSize caps the size of a saved view state.
+const maxViewStateSize = 64 << 10
+
+// BookmarkRequest is the body of POST /api/bookmarks.
+type BookmarkRequest struct {
+	Kind string `json:"kind"` // "symbol" or "entrypoint"
+	ID   int64  `json:"id"`   // Symbol or entrypoint ID
+	Note string `json:"note,omitempty"`
+}
+
+// BookmarksResponse lists pinned symbols and starred entrypoints.
+type BookmarksResponse struct {
+	Bookmarks []store.Bookmark `json:"bookmarks"`
+}
+
+// ViewRequest is the body of POST /api/views.
+type ViewRequest struct {
+	Name  string          `json:"name"`
+	State json.RawMessage `json:"state"`
+}
+
+// ViewsResponse lists saved views.
+type ViewsResponse struct {
+	Views []store.View `json:"views"`
+}
+
+// handleBookmarks handles /api/bookmarks:
+//
+//	GET    lists bookmarks
+//	POST   pins a symbol or stars an entrypoint (body: BookmarkRequest)
+//	DELETE removes one (?kind=&key=)
+//
+// Bookmarks are shared by everyone using the server and kept across
+// re-indexes.
+func (s *Server) handleBookmarks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		bookmarks, err := s.store.GetBookmarks(ctx)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get bookmarks: %v", err))
+			return
+		}
+		if bookmarks == nil {
+			bookmarks = []store.Bookmark{}
+		}
+		writeJSON(w, http.StatusOK, &BookmarksResponse{Bookmarks: bookmarks})
+
+	case http.MethodPost:
+		if s.rejectReadOnly(w) {
+			return
+		}
+		var req BookmarkRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		var b *store.Bookmark
+		var err error
+		switch req.Kind {
+		case store.BookmarkSymbol:
+			b, err = s.store.PinSymbol(ctx, store.SymbolID(req.ID), req.Note)
+		case store.BookmarkEntrypoint:
+			b, err = s.store.StarEntrypoint(ctx, store.EntrypointID(req.ID), req.Note)
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid kind %q (want symbol or entrypoint)", req.Kind))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("%s not found: %v", req.Kind, err))
+			return
+		}
+		writeJSON(w, http.StatusCreated, b)
+
+	case http.MethodDelete:
+		if s.rejectReadOnly(w) {
+			return
+		}
+		q := r.URL.Query()
+		kind, key := q.Get("kind"), q.Get("key")
+		if kind != store.BookmarkSymbol && kind != store.BookmarkEntrypoint {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid kind %q (want symbol or entrypoint)", kind))
+			return
+		}
+		deleted, err := s.store.DeleteBookmark(ctx, kind, key)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete bookmark: %v", err))
+			return
+		}
+		if !deleted {
+			writeError(w, http.StatusNotFound, "bookmark not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleViews handles /api/views:
+//
+//	GET    lists saved views
+//	POST   saves a view, replacing one with the same name (body: ViewRequest)
+//	DELETE removes one (?name=)
+func (s *Server) handleViews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		views, err := s.store.GetViews(ctx)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get views: %v", err))
+			return
+		}
+		if views == nil {
+			views = []store.View{}
+		}
+		writeJSON(w, http.StatusOK, &ViewsResponse{Views: views})
+
+	case http.MethodPost:
+		if s.rejectReadOnly(w) {
+			return
+		}
+		var req ViewRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxViewStateSize)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			writeError(w, http.StatusBadRequest, "view name is required")
+			return
+		}
+		if !isJSONObject(req.State) {
+			writeError(w, http.StatusBadRequest, "view state must be a JSON object")
+			return
+		}
+		view, created, err := s.store.SaveView(ctx, req.Name, req.State)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save view: %v", err))
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, view)
+
+	case http.MethodDelete:
+		if s.rejectReadOnly(w) {
+			return
+		}
+		deleted, err := s.store.DeleteView(ctx, r.URL.Query().Get("name"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete view: %v", err))
+			return
+		}
+		if !deleted {
+			writeError(w, http.StatusNotFound, "view not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// isJSONObject reports whether raw holds a JSON object.
+func isJSONObject(raw json.RawMessage) bool {
+	var obj map[string]json.RawMessage
+	return json.Unmarshal(raw, &obj) == nil && obj != nil
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// defaultCacheSize is the number of graph/spine responses kept in memory.
+const defaultCacheSize = 256
+
+// responseCache is an LRU cache of built graph, spine, and CFG responses.
+// Entries belong to one index generation (the index's indexed_at); the
+// whole cache is dropped as soon as a request sees a newer generation, so
+// a re-index never serves stale graphs. A nil cache is a no-op.
+type responseCache struct {
+	mu         sync.Mutex
+	capacity   int
+	generation string
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key   string
+	value any
+}
+
+// newResponseCache creates a cache holding up to capacity responses.
+func newResponseCache(capacity int) *responseCache {
+	if capacity <= 0 {
+		capacity = defaultCacheSize
+	}
+	return &responseCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached response for key in the given generation.
+func (c *responseCache) Get(generation, key string) (any, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetIfStale(generation)
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).value, true
+}
+
+// Put stores a response for key in the given generation, evicting the
+// least recently used entry when full.
+func (c *responseCache) Put(generation, key string, value any) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetIfStale(generation)
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*cacheEntry).value = value
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, value: value})
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Len returns the number of cached responses.
+func (c *responseCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// resetIfStale drops all entries when the index generation changes.
+// Must be called with mu held.
+func (c *responseCache) resetIfStale(generation string) {
+	if generation == c.generation {
+		return
+	}
+	c.generation = generation
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// filterHash returns a short stable hash of a graph filter for cache keys.
+func filterHash(filter GraphFilter) string {
+	data, _ := json.Marshal(filter)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// graphCacheKey returns the cache key of a graph response.
+func graphCacheKey(action string, symbolID store.SymbolID, depth int, filter GraphFilter, layout LayoutAlgorithm) string {
+	return fmt.Sprintf("graph|%s|%d|%d|%s|%s", action, symbolID, depth, filterHash(filter), layout)
+}
+
+// indexGeneration identifies the current index contents. It changes on every
+// re-index, including those run by a separate `flowlens index` process, and
+// whenever manual edges are added or removed.
+func (s *Server) indexGeneration(ctx context.Context) string {
+	generation, _ := s.store.GetMetadata(ctx, "indexed_at")
+	if edited, err := s.store.GetMetadata(ctx, "manual_edges_at"); err == nil {
+		generation += "|" + edited
+	}
+	return generation
+}
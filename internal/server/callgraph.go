@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// DefaultMemoryGraphMaxCalls is the largest index, in call edges, whose
+// call graph 'flowlens ui' holds in memory.
+const DefaultMemoryGraphMaxCalls = 2_000_000
+
+// callGraphs holds the call graph of the current index generation in
+// memory, shared read-only by every graph, spine, and symbol request so
+// they traverse maps instead of querying the index. A new generation is
+// loaded in the background; requests query the store until it is ready,
+// and always for indexes over maxCalls.
+type callGraphs struct {
+	store    *store.Store
+	ctx      context.Context // Canceled on shutdown, stopping a load
+	maxCalls int             // Call edges of the largest index loaded; negative loads none
+	loads    sync.WaitGroup
+
+	mu         sync.Mutex
+	started    bool             // A generation was asked for
+	generation string           // Generation of graph, or of the load under way
+	graph      *store.CallGraph // nil while loading, or if the load failed
+}
+
+func newCallGraphs(ctx context.Context, st *store.Store, maxCalls int) *callGraphs {
+	if maxCalls == 0 {
+		maxCalls = DefaultMemoryGraphMaxCalls
+	}
+	return &callGraphs{store: st, ctx: ctx, maxCalls: maxCalls}
+}
+
+// get returns the call graph of an index generation, or nil if it isn't
+// loaded; a load is started the first time a generation is asked for. A nil
+// callGraphs, or one that loads none, always returns nil.
+func (cg *callGraphs) get(generation string) *store.CallGraph {
+	if cg == nil || cg.maxCalls < 0 {
+		return nil
+	}
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+	if cg.started && generation == cg.generation {
+		return cg.graph
+	}
+	cg.started, cg.generation, cg.graph = true, generation, nil
+	cg.loads.Add(1)
+	go cg.load(generation)
+	return nil
+}
+
+// load reads the call graph of a generation, unless the index is over
+// maxCalls or a newer generation is asked for in the meantime.
+func (cg *callGraphs) load(generation string) {
+	defer cg.loads.Done()
+	start := time.Now()
+	calls, err := cg.store.CountCallEdges(cg.ctx)
+	if err == nil && calls > cg.maxCalls {
+		log.Printf("Index has %d calls, over the %d held in memory; queries read the index", calls, cg.maxCalls)
+		return
+	}
+	graph, err := cg.store.LoadCallGraph(cg.ctx)
+	if err != nil {
+		if cg.ctx.Err() == nil {
+			log.Printf("Error loading call graph, querying the index instead: %v", err)
+		}
+		return
+	}
+
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+	if generation != cg.generation {
+		return // Superseded by a new index
+	}
+	cg.graph = graph
+	log.Printf("Loaded call graph (%d symbols, %d calls) in %s",
+		len(graph.Symbols), graph.Edges, time.Since(start).Round(time.Millisecond))
+}
+
+// wait blocks until the loads started so far have finished.
+func (cg *callGraphs) wait() {
+	if cg != nil {
+		cg.loads.Wait()
+	}
+}
+
+// preloadCallGraph starts loading the call graph of the current index, so
+// the first requests after startup or a reindex find it in memory.
+func (s *Server) preloadCallGraph() {
+	s.graphs.get(s.indexGeneration(s.background))
+}
+
+// callGraph returns the call graph of an index generation if it is loaded.
+func (s *Server) callGraph(generation string) *store.CallGraph {
+	return s.graphs.get(generation)
+}
+
+// newGraphBuilder returns a graph builder under the server's limits,
+// reading the call graph in memory when the generation's is loaded.
+func (s *Server) newGraphBuilder(generation string, filter GraphFilter) *GraphBuilder {
+	builder := NewGraphBuilder(s.store, filter)
+	builder.SetLimits(s.limits)
+	builder.SetCallGraph(s.callGraph(generation))
+	return builder
+}
+
+// newSpineBuilder returns a spine builder reading the call graph in memory
+// when the generation's is loaded.
+func (s *Server) newSpineBuilder(generation string, filter GraphFilter) *SpineBuilder {
+	builder := NewSpineBuilder(s.store, filter)
+	builder.SetCallGraph(s.callGraph(generation))
+	return builder
+}
+
+// symbolCalls returns the tags, callees, and callers of a symbol for the
+// symbol details, from the call graph in memory when it is loaded.
+func (s *Server) symbolCalls(ctx context.Context, id store.SymbolID) ([]store.Tag, []store.CalleeInfo, []store.CallerInfo) {
+	if graph := s.callGraph(s.indexGeneration(ctx)); graph != nil && graph.Symbols[id] != nil {
+		return graph.Tags[id], graph.Calls[id], graph.Callers[id]
+	}
+	tags, err := s.store.GetSymbolTags(ctx, id)
+	if err != nil {
+		tags = []store.Tag{} // Don't fail if tags can't be fetched
+	}
+	callees, err := s.store.GetCallees(ctx, id)
+	if err != nil {
+		callees = []store.CalleeInfo{}
+	}
+	callers, err := s.store.GetCallers(ctx, id)
+	if err != nil {
+		callers = []store.CallerInfo{}
+	}
+	return tags, callees, callers
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// CleanupCall is a deferred call shown in the cleanup lane.
+type CleanupCall struct {
+	ID         store.SymbolID `json:"id"`
+	Name       string         `json:"name"`
+	PkgPath    string         `json:"pkg_path"`
+	RecvType   string         `json:"recv_type,omitempty"`
+	CallerFile string         `json:"caller_file"` // Location of the defer statement
+	CallerLine int            `json:"caller_line"`
+	Expr       string         `json:"expr,omitempty"` // Source of the deferred call
+}
+
+// CleanupSection lists the deferred calls of one function, such as Close,
+// Rollback, or Unlock, so teardown can be reviewed apart from the main flow.
+type CleanupSection struct {
+	FunctionID store.SymbolID `json:"function_id"`
+	Calls      []CleanupCall  `json:"calls"`
+}
+
+// isCleanupCall reports whether a callee belongs in the cleanup lane rather
+// than the main flow.
+func isCleanupCall(filter GraphFilter, c *store.CalleeInfo) bool {
+	return filter.CleanupLane && c.CallKind == store.CallKindDefer
+}
+
+// newCleanupCall describes a deferred callee for the cleanup lane.
+func newCleanupCall(c *store.CalleeInfo) CleanupCall {
+	return CleanupCall{
+		ID:         c.Symbol.ID,
+		Name:       c.Symbol.Name,
+		PkgPath:    c.Symbol.PkgPath,
+		RecvType:   c.Symbol.RecvType,
+		CallerFile: c.CallerFile,
+		CallerLine: c.CallerLine,
+		Expr:       c.Expr,
+	}
+}
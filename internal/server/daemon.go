@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/abramin/flowlens/internal/index"
+)
+
+// PIDFile is the content of the file a background 'flowlens ui --daemon'
+// server writes once it listens, so editor plugins can find and stop it.
+type PIDFile struct {
+	PID        int       `json:"pid"`
+	Port       int       `json:"port"`
+	URL        string    `json:"url"`
+	ProjectDir string    `json:"project_dir"`
+	DBPath     string    `json:"db_path"`
+	Started    time.Time `json:"started"`
+}
+
+// PIDFilePath returns the pidfile of the server for the index at dbPath.
+func PIDFilePath(dbPath string) string {
+	return dbPath + ".ui.pid"
+}
+
+// ReadPIDFile reads the pidfile at path, returning nil if there is none or
+// the process that wrote it no longer runs.
+func ReadPIDFile(path string) (*PIDFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading pidfile: %w", err)
+	}
+	var pf PIDFile
+	if err := json.Unmarshal(data, &pf); err != nil {
+		return nil, fmt.Errorf("parsing pidfile %s: %w", path, err)
+	}
+	if pf.PID == 0 || !index.ProcessAlive(pf.PID) {
+		return nil, nil
+	}
+	return &pf, nil
+}
+
+// writePIDFile records the running server in its pidfile. The file is
+// written to a temporary file and renamed into place, so readers never see
+// it without its content.
+func (s *Server) writePIDFile(port int) error {
+	pf := PIDFile{
+		PID:        os.Getpid(),
+		Port:       port,
+		URL:        fmt.Sprintf("http://localhost:%d", port),
+		ProjectDir: s.projectDir,
+		DBPath:     s.store.DBPath(),
+		Started:    time.Now(),
+	}
+	data, err := json.MarshalIndent(pf, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.pidFile), filepath.Base(s.pidFile)+".*")
+	if err != nil {
+		return fmt.Errorf("creating pidfile: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.pidFile)
+	}
+	if err != nil {
+		return fmt.Errorf("writing pidfile: %w", err)
+	}
+	return nil
+}
+
+// removePIDFile removes the server's pidfile, unless another server has
+// since replaced it.
+func (s *Server) removePIDFile() {
+	data, err := os.ReadFile(s.pidFile)
+	if err != nil {
+		return
+	}
+	var pf PIDFile
+	if json.Unmarshal(data, &pf) == nil && pf.PID != os.Getpid() {
+		return
+	}
+	os.Remove(s.pidFile)
+}
+
+// idleTracker records when the server last handled a request, for shutting
+// it down after --idle-timeout without any.
+type idleTracker struct {
+	timeout    time.Duration // 0 = never idle
+	inFlight   atomic.Int64
+	lastActive atomic.Int64 // Unix nanoseconds the last request started or finished
+}
+
+func newIdleTracker(timeout time.Duration) *idleTracker {
+	t := &idleTracker{timeout: timeout}
+	t.lastActive.Store(time.Now().UnixNano())
+	return t
+}
+
+// middleware records the requests handled by next.
+func (t *idleTracker) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.inFlight.Add(1)
+		t.lastActive.Store(time.Now().UnixNano())
+		defer func() {
+			t.lastActive.Store(time.Now().UnixNano())
+			t.inFlight.Add(-1)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// idle reports whether no request has been handled for the timeout at now.
+// Requests still running, such as long graph streams, keep the server busy.
+func (t *idleTracker) idle(now time.Time) bool {
+	if t == nil || t.timeout <= 0 || t.inFlight.Load() > 0 {
+		return false
+	}
+	return now.Sub(time.Unix(0, t.lastActive.Load())) >= t.timeout
+}
+
+// wait returns a channel closed once the server has been idle for the
+// timeout, or one never closed if it has none.
+func (t *idleTracker) wait(stop <-chan struct{}) <-chan struct{} {
+	idle := make(chan struct{})
+	if t == nil || t.timeout <= 0 {
+		return idle
+	}
+	interval := min(max(t.timeout/10, 10*time.Millisecond), time.Minute)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				if t.idle(now) {
+					close(idle)
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return idle
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// DiagnosticsResponse lists the package loading errors of the last index.
+type DiagnosticsResponse struct {
+	Diagnostics []store.Diagnostic `json:"diagnostics"`
+	BySeverity  map[string]int     `json:"by_severity"`
+}
+
+// handleDiagnostics handles GET /api/diagnostics?severity=&package=
+// Diagnostics explain why packages, files, or calls are missing from the graph.
+func (s *Server) handleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	filter := store.DiagnosticFilter{
+		Severity: q.Get("severity"),
+		PkgPath:  q.Get("package"),
+	}
+	switch filter.Severity {
+	case "", store.SeverityError, store.SeverityWarning:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid severity %q", filter.Severity))
+		return
+	}
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := "diagnostics|" + filter.Severity + "|" + filter.PkgPath
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	diags, err := s.store.GetDiagnostics(ctx, filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get diagnostics: %v", err))
+		return
+	}
+
+	resp := &DiagnosticsResponse{
+		Diagnostics: diags,
+		BySeverity: map[string]int{
+			store.SeverityError:   0,
+			store.SeverityWarning: 0,
+		},
+	}
+	if resp.Diagnostics == nil {
+		resp.Diagnostics = []store.Diagnostic{}
+	}
+	for _, d := range diags {
+		resp.BySeverity[d.Severity]++
+	}
+	s.cache.Put(generation, cacheKey, resp)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// UnresolvedResponse reports the call sites and functions missing from the
+// call graph.
+type UnresolvedResponse struct {
+	ByReason         map[string]int            `json:"by_reason"`
+	Packages         []store.PackageUnresolved `json:"packages"` // Most unresolved calls first
+	SkippedFunctions []store.SkippedFunction   `json:"skipped_functions"`
+}
+
+// handleUnresolved handles GET /api/stats/unresolved?package=
+// Per-package unresolved call counts and the functions whose calls were
+// dropped, for judging how complete the graph is.
+func (s *Server) handleUnresolved(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	pkgPath := r.URL.Query().Get("package")
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := "unresolved|" + pkgPath
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	pkgs, err := s.store.GetUnresolvedCalls(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get unresolved calls: %v", err))
+		return
+	}
+	skipped, err := s.store.GetSkippedFunctions(ctx, pkgPath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get skipped functions: %v", err))
+		return
+	}
+
+	resp := &UnresolvedResponse{
+		ByReason:         make(map[string]int, len(store.UnresolvedReasons)),
+		Packages:         []store.PackageUnresolved{},
+		SkippedFunctions: skipped,
+	}
+	for _, reason := range store.UnresolvedReasons {
+		resp.ByReason[reason] = 0
+	}
+	for _, p := range pkgs {
+		if pkgPath != "" && p.PkgPath != pkgPath {
+			continue
+		}
+		resp.Packages = append(resp.Packages, p)
+		for reason, n := range p.ByReason {
+			resp.ByReason[reason] += n
+		}
+	}
+	if resp.SkippedFunctions == nil {
+		resp.SkippedFunctions = []store.SkippedFunction{}
+	}
+	s.cache.Put(generation, cacheKey, resp)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// ManualEdgeRequest is the body of POST /api/edges.
+type ManualEdgeRequest struct {
+	CallerID store.SymbolID `json:"caller_id"`
+	CalleeID store.SymbolID `json:"callee_id"`
+	CallKind store.CallKind `json:"call_kind,omitempty"` // Default "static"
+	Note     string         `json:"note,omitempty"`      // Why the call exists, e.g. "dispatched by reflection"
+}
+
+// ManualEdgesResponse lists the user-asserted call edges.
+type ManualEdgesResponse struct {
+	Edges []store.ManualEdge `json:"edges"`
+}
+
+// manualCallKinds are the call kinds a manual edge may have.
+var manualCallKinds = map[store.CallKind]bool{
+	store.CallKindStatic:    true,
+	store.CallKindInterface: true,
+	store.CallKindFuncval:   true,
+	store.CallKindDefer:     true,
+	store.CallKindGo:        true,
+}
+
+// handleEdges handles /api/edges:
+//
+//	GET    lists manual edges
+//	POST   adds a manual edge (body: ManualEdgeRequest)
+//	DELETE removes one (?caller_id=&callee_id=)
+//
+// Manual edges fill in calls the call graph cannot see, such as reflective
+// dispatch. They appear in graphs with resolved_by "manual" and are kept
+// across re-indexes.
+func (s *Server) handleEdges(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listManualEdges(w, r)
+	case http.MethodPost:
+		if !s.rejectReadOnly(w) {
+			s.addManualEdge(w, r)
+		}
+	case http.MethodDelete:
+		if !s.rejectReadOnly(w) {
+			s.deleteManualEdge(w, r)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) listManualEdges(w http.ResponseWriter, r *http.Request) {
+	edges, err := s.store.GetManualEdges(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get manual edges: %v", err))
+		return
+	}
+	if edges == nil {
+		edges = []store.ManualEdge{}
+	}
+	writeJSON(w, http.StatusOK, &ManualEdgesResponse{Edges: edges})
+}
+
+func (s *Server) addManualEdge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ManualEdgeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if req.CallKind == "" {
+		req.CallKind = store.CallKindStatic
+	}
+	if !manualCallKinds[req.CallKind] {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid call_kind %q", req.CallKind))
+		return
+	}
+	if !s.symbolsExist(w, r, req.CallerID, req.CalleeID) {
+		return
+	}
+
+	edge, err := s.store.AddManualEdge(ctx, req.CallerID, req.CalleeID, req.CallKind, req.Note)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to add manual edge: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, edge)
+}
+
+func (s *Server) deleteManualEdge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	callerID, err := strconv.ParseInt(q.Get("caller_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid caller_id")
+		return
+	}
+	calleeID, err := strconv.ParseInt(q.Get("callee_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid callee_id")
+		return
+	}
+	if !s.symbolsExist(w, r, store.SymbolID(callerID), store.SymbolID(calleeID)) {
+		return
+	}
+
+	deleted, err := s.store.DeleteManualEdge(ctx, store.SymbolID(callerID), store.SymbolID(calleeID))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete manual edge: %v", err))
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "manual edge not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// symbolsExist writes a 404 and returns false unless every symbol is in
+// the index.
+func (s *Server) symbolsExist(w http.ResponseWriter, r *http.Request, ids ...store.SymbolID) bool {
+	for _, id := range ids {
+		if _, err := s.store.GetSymbolByID(r.Context(), id); err != nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("symbol %d not found: %v", id, err))
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/index"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// EntrypointGroup is a node of the entrypoint tree: an HTTP path prefix, a
+// gRPC service, or a Cobra command with its subcommands.
+type EntrypointGroup struct {
+	Name        string                       `json:"name"`                  // Path segment, service, or command name
+	Path        string                       `json:"path"`                  // Full prefix, e.g. "/api/users" or "db migrate"
+	Count       int                          `json:"count"`                 // Entrypoints in this group and its subgroups
+	Entrypoints []store.EntrypointWithSymbol `json:"entrypoints,omitempty"` // Entrypoints at exactly this path
+	Groups      []*EntrypointGroup           `json:"groups,omitempty"`      // Subgroups, in the order of the entrypoint list
+}
+
+// EntrypointTree is the response of GET /api/entrypoints?view=tree.
+type EntrypointTree struct {
+	Groups []*EntrypointGroup `json:"groups"` // One per entrypoint type, named after it
+	Count  int                `json:"count"`
+}
+
+// entrypointTypeOrder is the order of the top-level groups.
+var entrypointTypeOrder = []store.EntrypointType{
+	store.EntrypointHTTP, store.EntrypointGRPC, store.EntrypointCLI, store.EntrypointMain, store.EntrypointCustom,
+}
+
+// groupEntrypoints arranges entrypoints into a tree: HTTP routes by path
+// segment, gRPC methods by service, and CLI commands by command path.
+// Chains of groups holding a single subgroup and no entrypoints are merged,
+// so "/api/v1/users" is one level rather than three.
+func groupEntrypoints(eps []store.EntrypointWithSymbol) *EntrypointTree {
+	roots := make(map[store.EntrypointType]*EntrypointGroup)
+	for _, ep := range eps {
+		root, ok := roots[ep.Type]
+		if !ok {
+			root = &EntrypointGroup{Name: string(ep.Type)}
+			roots[ep.Type] = root
+		}
+		segments := entrypointGroupPath(&ep)
+		group := root
+		for i, seg := range segments {
+			group = group.child(seg, sepFor(ep.Type), segments[:i+1])
+		}
+		group.Entrypoints = append(group.Entrypoints, ep)
+	}
+
+	tree := &EntrypointTree{Groups: []*EntrypointGroup{}, Count: len(eps)}
+	for _, typ := range entrypointTypeOrder {
+		if root, ok := roots[typ]; ok {
+			tree.Groups = append(tree.Groups, root.compact(sepFor(typ)))
+			delete(roots, typ)
+		}
+	}
+	for _, ep := range eps {
+		// Types not listed above, in first-seen order
+		if root, ok := roots[ep.Type]; ok {
+			tree.Groups = append(tree.Groups, root.compact(sepFor(ep.Type)))
+			delete(roots, ep.Type)
+		}
+	}
+	return tree
+}
+
+// entrypointGroupPath returns the groups an entrypoint belongs under, from
+// outermost.
+func entrypointGroupPath(ep *store.EntrypointWithSymbol) []string {
+	switch ep.Type {
+	case store.EntrypointHTTP:
+		var meta index.HTTPMeta
+		path := ""
+		if json.Unmarshal([]byte(ep.MetaJSON), &meta) == nil {
+			path = meta.Path
+		} else if _, p, ok := strings.Cut(ep.Label, " "); ok {
+			path = p
+		}
+		var segments []string
+		for _, seg := range strings.Split(path, "/") {
+			if seg != "" {
+				segments = append(segments, seg)
+			}
+		}
+		return segments
+	case store.EntrypointGRPC:
+		var meta index.GRPCMeta
+		if json.Unmarshal([]byte(ep.MetaJSON), &meta) == nil && meta.Service != "" {
+			return []string{meta.Service}
+		}
+		if service, _, ok := strings.Cut(strings.TrimPrefix(ep.Label, "/"), "/"); ok {
+			return []string{service}
+		}
+		return nil
+	case store.EntrypointCLI:
+		var meta index.CLIMeta
+		command := ep.Label
+		if json.Unmarshal([]byte(ep.MetaJSON), &meta) == nil && meta.Command != "" {
+			command = meta.Command
+			if meta.Parent != "" && !strings.Contains(command, " ") {
+				command = meta.Parent + " " + command
+			}
+		}
+		return strings.Fields(command)
+	}
+	return nil
+}
+
+// sepFor returns the path separator of an entrypoint type's groups.
+func sepFor(typ store.EntrypointType) string {
+	if typ == store.EntrypointHTTP {
+		return "/"
+	}
+	return " "
+}
+
+// child returns the subgroup named seg, creating it if needed.
+func (g *EntrypointGroup) child(seg, sep string, path []string) *EntrypointGroup {
+	for _, c := range g.Groups {
+		if c.Name == seg {
+			return c
+		}
+	}
+	c := &EntrypointGroup{Name: seg, Path: strings.Join(path, sep)}
+	if sep == "/" {
+		c.Path = "/" + c.Path
+	}
+	g.Groups = append(g.Groups, c)
+	return c
+}
+
+// compact merges single-subgroup chains below g and fills in counts.
+func (g *EntrypointGroup) compact(sep string) *EntrypointGroup {
+	g.Count = len(g.Entrypoints)
+	for i, c := range g.Groups {
+		for len(c.Entrypoints) == 0 && len(c.Groups) == 1 {
+			only := c.Groups[0]
+			only.Name = c.Name + sep + only.Name
+			c = only
+		}
+		g.Groups[i] = c.compact(sep)
+		g.Count += g.Groups[i].Count
+	}
+	return g
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// ErrorChainResponse shows where errors raised along an entrypoint's call
+// tree are wrapped, swallowed, or turned into HTTP or gRPC statuses, for
+// auditing the errors clients end up seeing.
+type ErrorChainResponse struct {
+	Entrypoint *store.EntrypointWithSymbol `json:"entrypoint"`
+	Functions  []store.FunctionErrors      `json:"functions"` // Nearest to the handler first
+	ByLayer    map[string]map[string]int   `json:"by_layer"`  // Site counts per layer tag and kind; "" is untagged
+}
+
+// handleEntrypointErrors handles GET /api/entrypoints/:id/errors.
+func (s *Server) handleEntrypointErrors(w http.ResponseWriter, r *http.Request, id store.EntrypointID) {
+	ctx := r.Context()
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := "errors|" + strconv.FormatInt(int64(id), 10)
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	ep, err := s.store.GetEntrypointByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "entrypoint not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get entrypoint: %v", err))
+		return
+	}
+
+	funcs, err := s.store.GetReachableErrorSites(ctx, ep.SymbolID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get error sites: %v", err))
+		return
+	}
+
+	resp := &ErrorChainResponse{
+		Entrypoint: ep,
+		Functions:  funcs,
+		ByLayer:    make(map[string]map[string]int),
+	}
+	if resp.Functions == nil {
+		resp.Functions = []store.FunctionErrors{}
+	}
+	for _, f := range funcs {
+		counts := resp.ByLayer[f.Layer]
+		if counts == nil {
+			counts = make(map[string]int)
+			resp.ByLayer[f.Layer] = counts
+		}
+		for _, site := range f.Sites {
+			counts[site.Kind]++
+		}
+	}
+	s.cache.Put(generation, cacheKey, resp)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, resp)
+}
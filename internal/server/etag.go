@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// etagState memoizes the index ETag for one index generation, so stats are
+// only recounted after a re-index.
+type etagState struct {
+	mu         sync.Mutex
+	generation string
+	etag       string
+}
+
+// indexETag returns a weak ETag identifying the current index contents,
+// derived from indexed_at and the index stats. It is weak because responses
+// built from the same index are equivalent but not byte-identical (e.g.,
+// graph node order).
+func (s *Server) indexETag(ctx context.Context) (string, error) {
+	generation := s.indexGeneration(ctx)
+
+	s.etags.mu.Lock()
+	defer s.etags.mu.Unlock()
+	if s.etags.etag != "" && s.etags.generation == generation {
+		return s.etags.etag, nil
+	}
+
+	stats, err := s.store.GetStats(ctx)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%d|%d|%d",
+		generation, stats.PackageCount, stats.SymbolCount, stats.CallEdgeCount,
+		stats.EntrypointCount, stats.TagCount)))
+
+	s.etags.generation = generation
+	s.etags.etag = `W/"` + hex.EncodeToString(sum[:12]) + `"`
+	return s.etags.etag, nil
+}
+
+// notModified sets the ETag header and reports whether the request's
+// If-None-Match already matches it, in which case a 304 has been written
+// and the handler should return without a body.
+func (s *Server) notModified(w http.ResponseWriter, r *http.Request) bool {
+	etag, err := s.indexETag(r.Context())
+	if err != nil {
+		return false // Serve the full response rather than fail
+	}
+	w.Header().Set("ETag", etag)
+
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header matches etag,
+// using weak comparison as required for GET.
+func etagMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
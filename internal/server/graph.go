@@ -1,6 +1,9 @@
 package server
 
 import (
+	"context"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/abramin/flowlens/internal/store"
@@ -9,15 +12,24 @@ import (
 // GraphFilter specifies filters for graph traversal.
 type GraphFilter struct {
 	HideStdlib          bool     `json:"hideStdlib"`
+	StdlibAllow         []string `json:"stdlibAllow"` // Standard library packages hideStdlib keeps, as for noisePackages (default: stdlib_allow in flowlens.yaml)
 	HideVendors         bool     `json:"hideVendors"`
 	StopAtIO            bool     `json:"stopAtIO"`
+	StopAtIODistance    int      `json:"stopAtIODistance"` // Also stop at nodes within this many calls of I/O (io:<category>@N tags)
 	StopAtPackagePrefix []string `json:"stopAtPackagePrefix"`
 	MaxDepth            int      `json:"maxDepth"`
 	NoisePackages       []string `json:"noisePackages"`
-	CollapseWiring      bool     `json:"collapseWiring"` // Collapse New*, setup*, init*, load*, FromEnv* functions
+	CollapseWiring      bool     `json:"collapseWiring"` // Fold New*, Provide*, setup*, init*, wire.* chains into their first function
 	HideCmdMain         bool     `json:"hideCmdMain"`    // Hide nodes in cmd/* packages (except root)
+	CleanupLane         bool     `json:"cleanupLane"`    // Move deferred calls out of the flow into a cleanup section
+	CollapseNoise       bool     `json:"collapseNoise"`  // Fold each function's noise-package calls into one "N observability calls" node instead of hiding them
+	OrderBy             string   `json:"orderBy"`        // Order of each function's calls: source order (default) or OrderByCount
 }
 
+// OrderByCount orders each function's calls by call count, highest first,
+// instead of source order.
+const OrderByCount = "count"
+
 // DefaultGraphFilter returns sensible defaults for graph filtering.
 func DefaultGraphFilter() GraphFilter {
 	return GraphFilter{
@@ -31,6 +43,14 @@ func DefaultGraphFilter() GraphFilter {
 	}
 }
 
+// defaultGraphFilter returns DefaultGraphFilter with the server's
+// configured stdlib allowlist; the filters of a request override it.
+func (s *Server) defaultGraphFilter() GraphFilter {
+	filter := DefaultGraphFilter()
+	filter.StdlibAllow = s.stdlibAllow
+	return filter
+}
+
 // GraphNode represents a node in the graph response.
 type GraphNode struct {
 	ID       store.SymbolID   `json:"id"`
@@ -44,35 +64,73 @@ type GraphNode struct {
 	Tags     []string         `json:"tags"`
 	Expanded bool             `json:"expanded"`
 	Depth    int              `json:"depth"`
+	Position *NodePosition    `json:"position,omitempty"` // Set when a server-side layout is requested
+	Noise    *NoiseSummary    `json:"noise,omitempty"`    // Set on pseudo-nodes for collapsed noise calls, whose ID is the negated caller ID
+	Wiring   *WiringSummary   `json:"wiring,omitempty"`   // Wiring functions folded into this one, with the collapseWiring filter
 }
 
 // GraphEdge represents an edge in the graph response.
 type GraphEdge struct {
-	SourceID      store.SymbolID `json:"source_id"`
-	TargetID      store.SymbolID `json:"target_id"`
-	CallKind      store.CallKind `json:"call_kind"`
-	CallsiteCount int            `json:"callsite_count"`
-	CallerFile    string         `json:"caller_file,omitempty"`
-	CallerLine    int            `json:"caller_line,omitempty"`
+	SourceID      store.SymbolID   `json:"source_id"`
+	TargetID      store.SymbolID   `json:"target_id"`
+	CallKind      store.CallKind   `json:"call_kind"`
+	ResolvedBy    store.ResolvedBy `json:"resolved_by"` // How the callee was determined
+	CallsiteCount int              `json:"callsite_count"`
+	CallerFile    string           `json:"caller_file,omitempty"`
+	CallerLine    int              `json:"caller_line,omitempty"`
+	Expr          string           `json:"expr,omitempty"`      // Source of the call at CallerFile:CallerLine
+	Callsites     []store.Callsite `json:"callsites,omitempty"` // Every site where the source calls the target
+	Order         int              `json:"order,omitempty"`     // Position of the call among the source's calls in source order, from 1
 }
 
 // GraphResponse is the response format for graph endpoints.
 type GraphResponse struct {
-	Nodes    []GraphNode `json:"nodes"`
-	Edges    []GraphEdge `json:"edges"`
-	RootID   store.SymbolID `json:"root_id"`
-	MaxDepth int            `json:"max_depth"`
-	Filtered int            `json:"filtered_count"`
+	Nodes    []GraphNode      `json:"nodes"`
+	Edges    []GraphEdge      `json:"edges"`
+	RootID   store.SymbolID   `json:"root_id"`
+	MaxDepth int              `json:"max_depth"`
+	Filtered int              `json:"filtered_count"`
+	Layout   string           `json:"layout,omitempty"`  // Layout algorithm used for node positions
+	Cleanup  []CleanupSection `json:"cleanup,omitempty"` // Deferred calls per function, with the cleanupLane filter
 }
 
 // GraphBuilder builds graphs from the store with filtering.
 type GraphBuilder struct {
-	store   *store.Store
-	filter  GraphFilter
-	nodes   map[store.SymbolID]*GraphNode
-	edges   []GraphEdge
-	visited map[store.SymbolID]bool
+	store    *store.Store
+	filter   GraphFilter
+	nodes    map[store.SymbolID]*GraphNode
+	order    []store.SymbolID // Nodes in the order they were added
+	edges    []GraphEdge
+	visited  map[store.SymbolID]bool
 	filtered int
+	emit     func(GraphStreamEvent) // Called as nodes and edges are discovered; nil when not streaming
+	limits   GraphLimits
+	depth    int // Requested depth of the current build, for limit suggestions
+	cleanup  []CleanupSection
+	pairs    *bool                                // Whether the index has aggregated call pairs; checked on first use
+	tree     *store.CallTree                      // Callees prefetched for the current build; nil falls back to a query per node
+	graph    *store.CallGraph                     // The whole call graph in memory, shared with other builds; nil queries the store
+	symbols  map[store.SymbolID]*store.CalleeInfo // Symbols and tags seen as callees, saving a lookup per node
+}
+
+// GraphStreamEvent is one line of the NDJSON graph stream.
+type GraphStreamEvent struct {
+	Type    string              `json:"type"` // "node", "edge", "cleanup", "done", or "error"
+	Node    *GraphNode          `json:"node,omitempty"`
+	Edge    *GraphEdge          `json:"edge,omitempty"`
+	Done    *GraphStreamSummary `json:"done,omitempty"`
+	Error   string              `json:"error,omitempty"`
+	Limit   *GraphLimitError    `json:"limit,omitempty"`   // Set when an error event was caused by a size guard
+	Cleanup *CleanupSection     `json:"cleanup,omitempty"` // Deferred calls of one function, on "cleanup" events
+}
+
+// GraphStreamSummary closes a graph stream.
+type GraphStreamSummary struct {
+	RootID    store.SymbolID `json:"root_id"`
+	MaxDepth  int            `json:"max_depth"`
+	Filtered  int            `json:"filtered_count"`
+	NodeCount int            `json:"node_count"`
+	EdgeCount int            `json:"edge_count"`
 }
 
 // NewGraphBuilder creates a new graph builder.
@@ -86,20 +144,41 @@ func NewGraphBuilder(s *store.Store, filter GraphFilter) *GraphBuilder {
 	}
 }
 
+// OnEvent registers a callback that receives each node and edge as the
+// traversal discovers it, so callers can stream partial graphs.
+func (gb *GraphBuilder) OnEvent(fn func(GraphStreamEvent)) {
+	gb.emit = fn
+}
+
+// SetCallGraph makes the builder read symbols, tags, and callees from a
+// call graph loaded in memory instead of querying the store for them.
+func (gb *GraphBuilder) SetCallGraph(g *store.CallGraph) {
+	gb.graph = g
+}
+
+// SetLimits bounds the size and duration of subsequent builds.
+func (gb *GraphBuilder) SetLimits(limits GraphLimits) {
+	gb.limits = limits
+}
+
 // BuildFromRoot builds a graph starting from a root symbol.
-func (gb *GraphBuilder) BuildFromRoot(rootID store.SymbolID, depth int) (*GraphResponse, error) {
+func (gb *GraphBuilder) BuildFromRoot(ctx context.Context, rootID store.SymbolID, depth int) (*GraphResponse, error) {
 	// Clamp depth to maxDepth
 	if gb.filter.MaxDepth > 0 && depth > gb.filter.MaxDepth {
 		depth = gb.filter.MaxDepth
 	}
 
-	// Add the root node
-	if err := gb.addNode(rootID, 0, true); err != nil {
-		return nil, err
-	}
+	err := gb.withLimits(ctx, depth, func(ctx context.Context) error {
+		// Add the root node
+		if err := gb.addNode(ctx, rootID, 0, true); err != nil {
+			return err
+		}
 
-	// Recursively expand
-	if err := gb.expand(rootID, depth, 0); err != nil {
+		// Recursively expand
+		gb.prefetch(ctx, rootID, depth)
+		return gb.expand(ctx, rootID, depth, 0)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -107,29 +186,65 @@ func (gb *GraphBuilder) BuildFromRoot(rootID store.SymbolID, depth int) (*GraphR
 }
 
 // Expand expands a single node by the given depth.
-func (gb *GraphBuilder) Expand(symbolID store.SymbolID, depth int) (*GraphResponse, error) {
-	// Add the node if not already present
-	if _, exists := gb.nodes[symbolID]; !exists {
-		if err := gb.addNode(symbolID, 0, true); err != nil {
-			return nil, err
+func (gb *GraphBuilder) Expand(ctx context.Context, symbolID store.SymbolID, depth int) (*GraphResponse, error) {
+	err := gb.withLimits(ctx, depth, func(ctx context.Context) error {
+		// Add the node if not already present
+		if _, exists := gb.nodes[symbolID]; !exists {
+			if err := gb.addNode(ctx, symbolID, 0, true); err != nil {
+				return err
+			}
 		}
-	}
 
-	// Expand from this node
-	if err := gb.expand(symbolID, depth, 0); err != nil {
+		// Expand from this node
+		gb.prefetch(ctx, symbolID, depth)
+		return gb.expand(ctx, symbolID, depth, 0)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return gb.buildResponse(symbolID, depth), nil
 }
 
+// withLimits runs a build under the configured timeout, converting a
+// timeout into a GraphLimitError. Cancellation by the caller is returned
+// unchanged.
+func (gb *GraphBuilder) withLimits(ctx context.Context, depth int, build func(ctx context.Context) error) error {
+	gb.depth = depth
+
+	buildCtx := ctx
+	if gb.limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		buildCtx, cancel = context.WithTimeout(ctx, gb.limits.Timeout)
+		defer cancel()
+	}
+
+	err := build(buildCtx)
+	if buildCtx.Err() != nil && ctx.Err() == nil {
+		// Store lookups may swallow the deadline and return a partial graph
+		return newGraphLimitError("time", gb.limits.Timeout.String(), gb.filter, depth)
+	}
+	return err
+}
+
+// checkLimits returns a GraphLimitError once the graph outgrows its limits.
+func (gb *GraphBuilder) checkLimits() error {
+	if gb.limits.MaxNodes > 0 && len(gb.nodes) > gb.limits.MaxNodes {
+		return newGraphLimitError("nodes", strconv.Itoa(gb.limits.MaxNodes), gb.filter, gb.depth)
+	}
+	if gb.limits.MaxEdges > 0 && len(gb.edges) > gb.limits.MaxEdges {
+		return newGraphLimitError("edges", strconv.Itoa(gb.limits.MaxEdges), gb.filter, gb.depth)
+	}
+	return nil
+}
+
 // addNode adds a node to the graph if it passes filters.
-func (gb *GraphBuilder) addNode(id store.SymbolID, depth int, expanded bool) error {
+func (gb *GraphBuilder) addNode(ctx context.Context, id store.SymbolID, depth int, expanded bool) error {
 	if _, exists := gb.nodes[id]; exists {
 		return nil
 	}
 
-	sym, err := gb.store.GetSymbolByID(id)
+	sym, tags, err := gb.symbol(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -140,12 +255,12 @@ func (gb *GraphBuilder) addNode(id store.SymbolID, depth int, expanded bool) err
 		return nil
 	}
 
-	tags, _ := gb.store.GetSymbolTags(id)
 	tagStrs := make([]string, len(tags))
 	for i, t := range tags {
 		tagStrs[i] = t.Tag
 	}
 
+	gb.order = append(gb.order, id)
 	gb.nodes[id] = &GraphNode{
 		ID:       sym.ID,
 		Name:     sym.Name,
@@ -159,6 +274,16 @@ func (gb *GraphBuilder) addNode(id store.SymbolID, depth int, expanded bool) err
 		Expanded: expanded,
 		Depth:    depth,
 	}
+	if gb.filter.CollapseWiring && isWiringSymbol(sym) {
+		gb.nodes[id].Wiring = gb.foldWiring(ctx, id)
+	}
+	if err := gb.checkLimits(); err != nil {
+		return err
+	}
+	if gb.emit != nil {
+		node := *gb.nodes[id]
+		gb.emit(GraphStreamEvent{Type: "node", Node: &node})
+	}
 
 	return nil
 }
@@ -166,7 +291,7 @@ func (gb *GraphBuilder) addNode(id store.SymbolID, depth int, expanded bool) err
 // shouldFilter returns true if the symbol should be filtered out.
 func (gb *GraphBuilder) shouldFilter(sym *store.Symbol) bool {
 	// Filter stdlib
-	if gb.filter.HideStdlib && isStdlib(sym.PkgPath) {
+	if hidesStdlib(gb.filter, sym.PkgPath) {
 		return true
 	}
 
@@ -181,10 +306,8 @@ func (gb *GraphBuilder) shouldFilter(sym *store.Symbol) bool {
 	}
 
 	// Filter noise packages
-	for _, noise := range gb.filter.NoisePackages {
-		if matchPackagePattern(noise, sym.PkgPath) {
-			return true
-		}
+	if isNoisePackage(gb.filter, sym.PkgPath) {
+		return true
 	}
 
 	return false
@@ -195,14 +318,23 @@ func (gb *GraphBuilder) shouldStopExpansion(sym *store.Symbol, tags []store.Tag)
 	// Stop at I/O if configured
 	if gb.filter.StopAtIO {
 		for _, t := range tags {
-			if strings.HasPrefix(t.Tag, "io:") {
+			if store.IsIOTag(t.Tag) {
+				return true
+			}
+		}
+	}
+
+	// Stop at the first node reaching I/O within the configured distance
+	if gb.filter.StopAtIODistance > 0 {
+		for _, t := range tags {
+			if _, d, ok := store.ParseDerivedIOTag(t.Tag); ok && d <= gb.filter.StopAtIODistance {
 				return true
 			}
 		}
 	}
 
-	// Stop at wiring functions (don't expand into their callees)
-	if gb.filter.CollapseWiring && isWiringFunction(sym.Name) {
+	// Stop at wiring functions (their wiring callees are folded into them)
+	if gb.filter.CollapseWiring && isWiringSymbol(sym) {
 		return true
 	}
 
@@ -217,10 +349,13 @@ func (gb *GraphBuilder) shouldStopExpansion(sym *store.Symbol, tags []store.Tag)
 }
 
 // expand recursively expands the graph from a symbol.
-func (gb *GraphBuilder) expand(symbolID store.SymbolID, maxDepth int, currentDepth int) error {
+func (gb *GraphBuilder) expand(ctx context.Context, symbolID store.SymbolID, maxDepth int, currentDepth int) error {
 	if currentDepth >= maxDepth {
 		return nil
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	if gb.visited[symbolID] {
 		return nil
@@ -228,57 +363,129 @@ func (gb *GraphBuilder) expand(symbolID store.SymbolID, maxDepth int, currentDep
 	gb.visited[symbolID] = true
 
 	// Get symbol for stop-at checks
-	sym, err := gb.store.GetSymbolByID(symbolID)
+	sym, tags, err := gb.symbol(ctx, symbolID)
 	if err != nil {
 		return nil // Symbol not found, skip
 	}
 
-	tags, _ := gb.store.GetSymbolTags(symbolID)
-
 	// Check if we should stop expansion
 	if gb.shouldStopExpansion(sym, tags) {
 		return nil
 	}
 
 	// Get callees
-	callees, err := gb.store.GetCallees(symbolID)
+	callees, err := gb.callees(ctx, symbolID)
 	if err != nil {
 		return err
 	}
 
-	// Aggregate edges by callee (sum up call counts)
+	// Aggregate edges by callee (sum up call counts), in order of each
+	// callee's first call site
 	calleeEdges := make(map[store.SymbolID]*GraphEdge)
+	var ordered []*GraphEdge
+	var cleanup []CleanupCall
+	var noise *GraphEdge // To the pseudo-node for collapsed noise calls
 	for _, c := range callees {
+		if isCollapsedNoise(gb.filter, &c.Symbol) {
+			if noise == nil {
+				noise = &GraphEdge{
+					SourceID:   symbolID,
+					TargetID:   -symbolID,
+					CallKind:   c.CallKind,
+					ResolvedBy: c.ResolvedBy,
+					CallerFile: c.CallerFile,
+					CallerLine: c.CallerLine,
+				}
+				gb.nodes[noise.TargetID] = &GraphNode{ID: noise.TargetID, Tags: []string{}, Depth: currentDepth + 1, Noise: &NoiseSummary{}}
+				gb.order = append(gb.order, noise.TargetID)
+			}
+			node := gb.nodes[noise.TargetID]
+			addNoiseCall(node.Noise, &c)
+			node.Name = noiseNodeName(node.Noise)
+			noise.CallsiteCount = node.Noise.Count
+			continue
+		}
 		if gb.shouldFilterCallee(&c.Symbol) {
 			gb.filtered++
 			continue
 		}
+		if isCleanupCall(gb.filter, &c) {
+			cleanup = append(cleanup, newCleanupCall(&c))
+			continue
+		}
 
 		if existing, ok := calleeEdges[c.Symbol.ID]; ok {
 			existing.CallsiteCount += c.Count
+			existing.Callsites = append(existing.Callsites, callsites(&c)...)
 		} else {
-			calleeEdges[c.Symbol.ID] = &GraphEdge{
+			edge := &GraphEdge{
 				SourceID:      symbolID,
 				TargetID:      c.Symbol.ID,
 				CallKind:      c.CallKind,
+				ResolvedBy:    c.ResolvedBy,
 				CallsiteCount: c.Count,
 				CallerFile:    c.CallerFile,
 				CallerLine:    c.CallerLine,
+				Expr:          c.Expr,
+				Callsites:     slices.Clip(callsites(&c)), // Appended to; the sites may be shared
+				Order:         len(ordered) + 1,
 			}
+			calleeEdges[c.Symbol.ID] = edge
+			ordered = append(ordered, edge)
+		}
+	}
+	if gb.filter.OrderBy == OrderByCount {
+		slices.SortStableFunc(ordered, func(a, b *GraphEdge) int {
+			return b.CallsiteCount - a.CallsiteCount
+		})
+	}
+
+	if len(cleanup) > 0 {
+		section := CleanupSection{FunctionID: symbolID, Calls: cleanup}
+		gb.cleanup = append(gb.cleanup, section)
+		if gb.emit != nil {
+			gb.emit(GraphStreamEvent{Type: "cleanup", Cleanup: &section})
+		}
+	}
+
+	// Add collapsed noise calls
+	if noise != nil {
+		gb.edges = append(gb.edges, *noise)
+		if err := gb.checkLimits(); err != nil {
+			return err
+		}
+		if gb.emit != nil {
+			node, e := *gb.nodes[noise.TargetID], *noise
+			gb.emit(GraphStreamEvent{Type: "node", Node: &node})
+			gb.emit(GraphStreamEvent{Type: "edge", Edge: &e})
 		}
 	}
 
 	// Add edges and nodes
-	for calleeID, edge := range calleeEdges {
+	for _, edge := range ordered {
+		calleeID := edge.TargetID
 		gb.edges = append(gb.edges, *edge)
+		if err := gb.checkLimits(); err != nil {
+			return err
+		}
 
 		// Add callee node
-		if err := gb.addNode(calleeID, currentDepth+1, false); err != nil {
+		if err := gb.addNode(ctx, calleeID, currentDepth+1, false); err != nil {
+			if isFatalBuildError(ctx, err) {
+				return err
+			}
 			continue
 		}
+		if gb.emit != nil {
+			e := *edge
+			gb.emit(GraphStreamEvent{Type: "edge", Edge: &e})
+		}
 
 		// Recursively expand
-		if err := gb.expand(calleeID, maxDepth, currentDepth+1); err != nil {
+		if err := gb.expand(ctx, calleeID, maxDepth, currentDepth+1); err != nil {
+			if isFatalBuildError(ctx, err) {
+				return err
+			}
 			continue
 		}
 	}
@@ -291,6 +498,114 @@ func (gb *GraphBuilder) expand(symbolID store.SymbolID, maxDepth int, currentDep
 	return nil
 }
 
+// prefetch loads the callees of every symbol the traversal from root can
+// expand in one recursive query, with the filters that stop expansion
+// pushed into SQL. The traversal still applies all its filters, and falls
+// back to a query per node if the prefetch fails. Builds reading a call
+// graph in memory need no prefetch.
+func (gb *GraphBuilder) prefetch(ctx context.Context, root store.SymbolID, depth int) {
+	if gb.graph != nil {
+		return
+	}
+	q := store.ReachQuery{
+		MaxDepth:     depth,
+		StopPrefixes: gb.filter.StopAtPackagePrefix,
+		StopAtIO:     gb.filter.StopAtIO,
+		SkipStdlib:   gb.filter.HideStdlib,
+		KeepStdlib:   gb.filter.StdlibAllow,
+		SkipVendor:   gb.filter.HideVendors,
+	}
+	tree, err := gb.store.GetReachableCallees(ctx, root, q)
+	if err != nil {
+		gb.tree = nil
+		return
+	}
+	gb.tree = tree
+}
+
+// symbol returns a symbol and its tags, from the callees seen so far when
+// possible.
+func (gb *GraphBuilder) symbol(ctx context.Context, id store.SymbolID) (*store.Symbol, []store.Tag, error) {
+	if c, ok := gb.symbols[id]; ok {
+		return &c.Symbol, c.Tags, nil
+	}
+	if gb.graph != nil {
+		if sym, tags := gb.graph.SymbolAndTags(id); sym != nil {
+			return sym, tags, nil
+		}
+	}
+	sym, err := gb.store.GetSymbolByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, _ := gb.store.GetSymbolTags(ctx, id)
+	return sym, tags, nil
+}
+
+// callees returns the callees of a symbol, one per callee from the
+// aggregated call pairs when the index has them. With the cleanup lane on,
+// a callee both deferred and called directly needs its sites separated, so
+// such symbols are read from the call edges instead.
+func (gb *GraphBuilder) callees(ctx context.Context, symbolID store.SymbolID) ([]store.CalleeInfo, error) {
+	callees, err := gb.loadCallees(ctx, symbolID)
+	if err != nil {
+		return nil, err
+	}
+	if gb.symbols == nil {
+		gb.symbols = make(map[store.SymbolID]*store.CalleeInfo)
+	}
+	for i := range callees {
+		gb.symbols[callees[i].Symbol.ID] = &callees[i]
+	}
+	return callees, nil
+}
+
+// loadCallees reads the callees of a symbol for callees.
+func (gb *GraphBuilder) loadCallees(ctx context.Context, symbolID store.SymbolID) ([]store.CalleeInfo, error) {
+	if gb.pairs == nil {
+		var has bool
+		if gb.graph != nil {
+			has = gb.graph.Pairs
+		} else {
+			has = gb.store.HasCallPairs(ctx)
+		}
+		gb.pairs = &has
+	}
+	var pairs []store.CalleeInfo
+	prefetched := false
+	if gb.graph != nil {
+		pairs, prefetched = gb.graph.Callees[symbolID], true
+	} else if gb.tree != nil {
+		pairs, prefetched = gb.tree.Callees[symbolID]
+	}
+	if !prefetched {
+		if !*gb.pairs {
+			return gb.store.GetCallees(ctx, symbolID)
+		}
+		var err error
+		if pairs, err = gb.store.GetCallPairs(ctx, symbolID); err != nil {
+			return nil, err
+		}
+	}
+	if *gb.pairs && gb.filter.CleanupLane {
+		for _, p := range pairs {
+			if len(p.Kinds) > 1 && slices.Contains(p.Kinds, store.CallKindDefer) {
+				return gb.store.GetCallees(ctx, symbolID)
+			}
+		}
+	}
+	return pairs, nil
+}
+
+// callsites returns the call sites of a callee: every site of a call pair,
+// or the single site of a call edge.
+func callsites(c *store.CalleeInfo) []store.Callsite {
+	if len(c.Sites) > 0 {
+		return c.Sites
+	}
+	return []store.Callsite{{File: c.CallerFile, Line: c.CallerLine}}
+}
+
 // shouldFilterCallee applies filters to a callee symbol.
 func (gb *GraphBuilder) shouldFilterCallee(sym *store.Symbol) bool {
 	return gb.shouldFilter(sym)
@@ -299,8 +614,8 @@ func (gb *GraphBuilder) shouldFilterCallee(sym *store.Symbol) bool {
 // buildResponse constructs the final response.
 func (gb *GraphBuilder) buildResponse(rootID store.SymbolID, maxDepth int) *GraphResponse {
 	nodes := make([]GraphNode, 0, len(gb.nodes))
-	for _, node := range gb.nodes {
-		nodes = append(nodes, *node)
+	for _, id := range gb.order {
+		nodes = append(nodes, *gb.nodes[id])
 	}
 
 	return &GraphResponse{
@@ -309,6 +624,7 @@ func (gb *GraphBuilder) buildResponse(rootID store.SymbolID, maxDepth int) *Grap
 		RootID:   rootID,
 		MaxDepth: maxDepth,
 		Filtered: gb.filtered,
+		Cleanup:  gb.cleanup,
 	}
 }
 
@@ -327,6 +643,20 @@ func isStdlib(pkgPath string) bool {
 	return !strings.Contains(firstSegment, ".")
 }
 
+// hidesStdlib reports whether the filter hides a package: a standard
+// library package, with hideStdlib on, that is not in the allowlist.
+func hidesStdlib(filter GraphFilter, pkgPath string) bool {
+	if !filter.HideStdlib || !isStdlib(pkgPath) {
+		return false
+	}
+	for _, allowed := range filter.StdlibAllow {
+		if matchPackagePattern(allowed, pkgPath) {
+			return false
+		}
+	}
+	return true
+}
+
 // isVendor checks if a package path is from a vendor directory.
 func isVendor(pkgPath string) bool {
 	return strings.Contains(pkgPath, "/vendor/") || strings.HasPrefix(pkgPath, "vendor/")
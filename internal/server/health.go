@@ -0,0 +1,90 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abramin/flowlens/internal/index"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// HealthResponse reports server liveness and index freshness.
+type HealthResponse struct {
+	Status               string         `json:"status"`
+	SchemaVersion        int            `json:"schema_version"`        // Schema the index was written with (0 = unknown)
+	ServerSchemaVersion  int            `json:"server_schema_version"` // Schema this server expects
+	IndexedAt            string         `json:"indexed_at,omitempty"`
+	VCS                  *store.VCSInfo `json:"vcs,omitempty"` // Revision the index was built from
+	DBSizeBytes          int64          `json:"db_size_bytes"`
+	SourceNewerThanIndex bool           `json:"source_newer_than_index"`
+	NewerSourceFile      string         `json:"newer_source_file,omitempty"` // First source file found modified after indexed_at
+	Reindex              ReindexStatus  `json:"reindex"`
+	Queue                QueueStats     `json:"queue"` // Load on the graph, spine, and CFG workers
+}
+
+// ReindexStatus describes the most recent indexing run.
+type ReindexStatus struct {
+	Status    string `json:"status"` // "idle", "running", or "failed"
+	StartedAt string `json:"started_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// handleHealth handles GET /api/health
+// Always 200 while the server is up; dashboards alert on the freshness fields.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	resp := HealthResponse{
+		Status:              "ok",
+		ServerSchemaVersion: store.SchemaVersion,
+		Reindex:             ReindexStatus{Status: "idle"},
+		Queue:               s.pool.stats(),
+	}
+
+	if v, err := s.store.GetMetadata(ctx, "schema_version"); err == nil {
+		resp.SchemaVersion, _ = strconv.Atoi(v)
+	}
+	if size, err := s.store.Size(); err == nil {
+		resp.DBSizeBytes = size
+	}
+
+	switch status, _ := s.store.GetMetadata(ctx, "index_status"); status {
+	case "running":
+		resp.Reindex.Status = "running"
+		resp.Reindex.StartedAt, _ = s.store.GetMetadata(ctx, "index_started_at")
+	case "failed":
+		resp.Reindex.Status = "failed"
+		resp.Reindex.StartedAt, _ = s.store.GetMetadata(ctx, "index_started_at")
+		resp.Reindex.Error, _ = s.store.GetMetadata(ctx, "index_error")
+	}
+
+	indexedAt, err := s.store.GetMetadata(ctx, "indexed_at")
+	if err != nil || indexedAt == "" {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	resp.IndexedAt = indexedAt
+	resp.VCS = s.store.GetVCSInfo(ctx)
+
+	projectDir := s.projectDir
+	if projectDir == "" {
+		projectDir = s.store.RepoRoot(ctx, "")
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, indexedAt); err == nil && projectDir != "" {
+		newer, err := index.NewerSource(projectDir, ts)
+		if err != nil {
+			log.Printf("Error checking source freshness: %v", err)
+		}
+		resp.SourceNewerThanIndex = newer != ""
+		resp.NewerSourceFile = newer
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// InterfacesResponse lists project interfaces.
+type InterfacesResponse struct {
+	Interfaces []store.InterfaceSummary `json:"interfaces"`
+}
+
+// InterfaceResponse describes one interface: its method set and the
+// project types that satisfy it.
+type InterfaceResponse struct {
+	Interface       *store.Symbol           `json:"interface"`
+	Methods         []store.InterfaceMethod `json:"methods"`
+	Implementations []store.Implementation  `json:"implementations"`
+}
+
+// handleInterfaces handles GET /api/interfaces?package=&repo=
+func (s *Server) handleInterfaces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	q := r.URL.Query()
+	filter := store.InterfaceFilter{
+		PkgPath: q.Get("package"),
+		Repo:    q.Get("repo"),
+	}
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := "interfaces|" + filter.PkgPath + "|" + filter.Repo
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	ifaces, err := s.store.GetInterfaces(ctx, filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get interfaces: %v", err))
+		return
+	}
+	resp := &InterfacesResponse{Interfaces: ifaces}
+	if resp.Interfaces == nil {
+		resp.Interfaces = []store.InterfaceSummary{}
+	}
+	s.cache.Put(generation, cacheKey, resp)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleInterfaceByID handles GET /api/interfaces/:id
+func (s *Server) handleInterfaceByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/interfaces/"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid interface ID")
+		return
+	}
+
+	sym, err := s.store.GetSymbolByID(ctx, store.SymbolID(id))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && sym.Kind != store.SymbolKindInterface) {
+		writeError(w, http.StatusNotFound, "interface not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get interface: %v", err))
+		return
+	}
+
+	methods, err := s.store.GetInterfaceMethods(ctx, sym.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get methods: %v", err))
+		return
+	}
+	impls, err := s.store.GetImplementations(ctx, sym.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get implementations: %v", err))
+		return
+	}
+
+	resp := &InterfaceResponse{
+		Interface:       sym,
+		Methods:         methods,
+		Implementations: impls,
+	}
+	if resp.Methods == nil {
+		resp.Methods = []store.InterfaceMethod{}
+	}
+	if resp.Implementations == nil {
+		resp.Implementations = []store.Implementation{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
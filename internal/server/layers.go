@@ -0,0 +1,103 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/abramin/flowlens/internal/config"
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// LayerSummary describes one layer for the architecture dashboard.
+type LayerSummary struct {
+	Layer      string `json:"layer"`
+	Packages   int    `json:"packages"`
+	Symbols    int    `json:"symbols"`
+	Inbound    int    `json:"inbound"`    // Call edges into the layer from other layers
+	Outbound   int    `json:"outbound"`   // Call edges from the layer into other layers
+	Violations int    `json:"violations"` // Outbound edges flowing outward (see config.IsLayerViolation)
+}
+
+// LayersResponse is the response of GET /api/layers.
+type LayersResponse struct {
+	Layers []LayerSummary `json:"layers"` // Default layers outermost first, then custom layers by name
+	Edges  []LayerEdge    `json:"edges"`  // Cross-layer edge counts by caller and callee layer
+}
+
+// LayerEdge counts the call edges from one layer to another.
+type LayerEdge struct {
+	store.LayerEdgeCount
+	Violation bool `json:"violation"`
+}
+
+// handleLayers handles GET /api/layers, summarizing each layer's size and
+// the calls crossing into and out of it.
+func (s *Server) handleLayers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	ctx := r.Context()
+
+	sizes, err := s.store.GetLayerSizes(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get layers: %v", err))
+		return
+	}
+	counts, err := s.store.GetCrossLayerEdgeCounts(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to count cross-layer edges: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, summarizeLayers(sizes, counts))
+}
+
+// summarizeLayers combines layer sizes with cross-layer edge counts. Layers
+// only seen in edge counts are included with zero size.
+func summarizeLayers(sizes []store.LayerSize, counts []store.LayerEdgeCount) *LayersResponse {
+	byLayer := make(map[string]*LayerSummary)
+	layer := func(name string) *LayerSummary {
+		if l, ok := byLayer[name]; ok {
+			return l
+		}
+		l := &LayerSummary{Layer: name}
+		byLayer[name] = l
+		return l
+	}
+	for _, size := range sizes {
+		l := layer(size.Layer)
+		l.Packages, l.Symbols = size.Packages, size.Symbols
+	}
+
+	resp := &LayersResponse{Layers: []LayerSummary{}, Edges: []LayerEdge{}}
+	for _, c := range counts {
+		violation := config.IsLayerViolation(c.CallerLayer, c.CalleeLayer)
+		caller := layer(c.CallerLayer)
+		caller.Outbound += c.Count
+		if violation {
+			caller.Violations += c.Count
+		}
+		layer(c.CalleeLayer).Inbound += c.Count
+		resp.Edges = append(resp.Edges, LayerEdge{LayerEdgeCount: c, Violation: violation})
+	}
+
+	for _, l := range byLayer {
+		resp.Layers = append(resp.Layers, *l)
+	}
+	slices.SortFunc(resp.Layers, func(a, b LayerSummary) int {
+		rankA, okA := config.LayerOrder[a.Layer]
+		rankB, okB := config.LayerOrder[b.Layer]
+		switch {
+		case okA && okB:
+			return cmp.Compare(rankA, rankB)
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return cmp.Compare(a.Layer, b.Layer)
+	})
+	return resp
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// LayoutAlgorithm names a server-side graph layout strategy.
+type LayoutAlgorithm string
+
+const (
+	LayoutNone    LayoutAlgorithm = ""        // No coordinates computed
+	LayoutLayered LayoutAlgorithm = "layered" // Ranks by BFS distance from the root
+	LayoutDagre   LayoutAlgorithm = "dagre"   // Ranks by longest path, like dagre's default ranker
+)
+
+// Layout spacing, matching the client-side layout in the UI.
+const (
+	layoutNodeSpacing = 200.0
+	layoutRankSpacing = 120.0
+	layoutSweeps      = 4
+)
+
+// NodePosition is the top-left coordinate of a node in a computed layout.
+type NodePosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// ParseLayoutAlgorithm validates a layout query parameter.
+func ParseLayoutAlgorithm(s string) (LayoutAlgorithm, error) {
+	switch LayoutAlgorithm(s) {
+	case LayoutNone, LayoutLayered, LayoutDagre:
+		return LayoutAlgorithm(s), nil
+	default:
+		return LayoutNone, fmt.Errorf("unknown layout %q (expected dagre or layered)", s)
+	}
+}
+
+// ApplyLayout computes x/y coordinates for every node in the response.
+// Nodes are assigned to ranks (rows), ordered within each rank with the
+// barycenter heuristic to reduce edge crossings, then centered around x=0.
+func ApplyLayout(resp *GraphResponse, algo LayoutAlgorithm) {
+	if algo == LayoutNone || len(resp.Nodes) == 0 {
+		return
+	}
+
+	index := make(map[store.SymbolID]int, len(resp.Nodes))
+	for i, n := range resp.Nodes {
+		index[n.ID] = i
+	}
+
+	// Build adjacency restricted to nodes present in the response
+	succs := make(map[store.SymbolID][]store.SymbolID)
+	preds := make(map[store.SymbolID][]store.SymbolID)
+	for _, e := range resp.Edges {
+		if _, ok := index[e.SourceID]; !ok {
+			continue
+		}
+		if _, ok := index[e.TargetID]; !ok {
+			continue
+		}
+		if e.SourceID == e.TargetID {
+			continue
+		}
+		succs[e.SourceID] = append(succs[e.SourceID], e.TargetID)
+		preds[e.TargetID] = append(preds[e.TargetID], e.SourceID)
+	}
+
+	var ranks map[store.SymbolID]int
+	if algo == LayoutDagre {
+		ranks = longestPathRanks(resp, succs)
+	} else {
+		ranks = bfsRanks(resp, succs)
+	}
+
+	layers := orderLayers(resp, ranks, succs, preds)
+
+	for rank, layer := range layers {
+		offset := float64(len(layer)-1) / 2
+		for pos, id := range layer {
+			resp.Nodes[index[id]].Position = &NodePosition{
+				X: (float64(pos) - offset) * layoutNodeSpacing,
+				Y: float64(rank) * layoutRankSpacing,
+			}
+		}
+	}
+	resp.Layout = string(algo)
+}
+
+// bfsRanks assigns each node its shortest distance from the root.
+// Nodes unreachable from the root fall back to their traversal depth.
+func bfsRanks(resp *GraphResponse, succs map[store.SymbolID][]store.SymbolID) map[store.SymbolID]int {
+	ranks := make(map[store.SymbolID]int, len(resp.Nodes))
+	var queue []store.SymbolID
+	if containsNode(resp, resp.RootID) {
+		queue = append(queue, resp.RootID)
+		ranks[resp.RootID] = 0
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range succs[id] {
+			if _, seen := ranks[next]; !seen {
+				ranks[next] = ranks[id] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	for _, n := range resp.Nodes {
+		if _, ok := ranks[n.ID]; !ok {
+			ranks[n.ID] = n.Depth
+		}
+	}
+	return ranks
+}
+
+// longestPathRanks places each node one rank below its deepest predecessor,
+// ignoring back edges so cycles (recursion) don't loop forever.
+func longestPathRanks(resp *GraphResponse, succs map[store.SymbolID][]store.SymbolID) map[store.SymbolID]int {
+	// Topological order via DFS from the root, then from any leftover nodes
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[store.SymbolID]int, len(resp.Nodes))
+	dagSuccs := make(map[store.SymbolID][]store.SymbolID)
+	var order []store.SymbolID
+
+	var visit func(id store.SymbolID)
+	visit = func(id store.SymbolID) {
+		state[id] = inProgress
+		for _, next := range succs[id] {
+			switch state[next] {
+			case unvisited:
+				dagSuccs[id] = append(dagSuccs[id], next)
+				visit(next)
+			case done:
+				dagSuccs[id] = append(dagSuccs[id], next)
+			}
+			// inProgress means a back edge; drop it
+		}
+		state[id] = done
+		order = append(order, id)
+	}
+
+	if containsNode(resp, resp.RootID) {
+		visit(resp.RootID)
+	}
+	for _, n := range sortedNodeIDs(resp) {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+
+	ranks := make(map[store.SymbolID]int, len(resp.Nodes))
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		for _, next := range dagSuccs[id] {
+			if ranks[id]+1 > ranks[next] {
+				ranks[next] = ranks[id] + 1
+			}
+		}
+	}
+	return ranks
+}
+
+// orderLayers groups nodes by rank and orders each rank with barycenter sweeps.
+func orderLayers(
+	resp *GraphResponse,
+	ranks map[store.SymbolID]int,
+	succs, preds map[store.SymbolID][]store.SymbolID,
+) [][]store.SymbolID {
+	maxRank := 0
+	for _, r := range ranks {
+		if r > maxRank {
+			maxRank = r
+		}
+	}
+
+	layers := make([][]store.SymbolID, maxRank+1)
+	for _, id := range sortedNodeIDs(resp) {
+		r := ranks[id]
+		layers[r] = append(layers[r], id)
+	}
+
+	positions := make(map[store.SymbolID]int, len(resp.Nodes))
+	record := func(layer []store.SymbolID) {
+		for i, id := range layer {
+			positions[id] = i
+		}
+	}
+	for _, layer := range layers {
+		record(layer)
+	}
+
+	for sweep := 0; sweep < layoutSweeps; sweep++ {
+		if sweep%2 == 0 {
+			for r := 1; r < len(layers); r++ {
+				sortByBarycenter(layers[r], preds, positions)
+				record(layers[r])
+			}
+		} else {
+			for r := len(layers) - 2; r >= 0; r-- {
+				sortByBarycenter(layers[r], succs, positions)
+				record(layers[r])
+			}
+		}
+	}
+
+	return layers
+}
+
+// sortByBarycenter reorders a layer by the mean position of each node's neighbors
+// in the adjacent layer. Nodes without neighbors keep their current position.
+func sortByBarycenter(layer []store.SymbolID, neighbors map[store.SymbolID][]store.SymbolID, positions map[store.SymbolID]int) {
+	bary := make(map[store.SymbolID]float64, len(layer))
+	for i, id := range layer {
+		ns := neighbors[id]
+		if len(ns) == 0 {
+			bary[id] = float64(i)
+			continue
+		}
+		sum := 0
+		for _, n := range ns {
+			sum += positions[n]
+		}
+		bary[id] = float64(sum) / float64(len(ns))
+	}
+	sort.SliceStable(layer, func(i, j int) bool {
+		return bary[layer[i]] < bary[layer[j]]
+	})
+}
+
+// sortedNodeIDs returns node IDs ordered by name then ID for deterministic layouts.
+func sortedNodeIDs(resp *GraphResponse) []store.SymbolID {
+	nodes := make([]GraphNode, len(resp.Nodes))
+	copy(nodes, resp.Nodes)
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Name != nodes[j].Name {
+			return nodes[i].Name < nodes[j].Name
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	ids := make([]store.SymbolID, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+// containsNode reports whether the response includes a node with the given ID.
+func containsNode(resp *GraphResponse, id store.SymbolID) bool {
+	for _, n := range resp.Nodes {
+		if n.ID == id {
+			return true
+		}
+	}
+	return false
+}
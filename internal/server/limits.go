@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Default graph size limits for `flowlens ui`, sized so the UI stays responsive.
+const (
+	DefaultMaxGraphNodes = 2000
+	DefaultMaxGraphEdges = 5000
+	DefaultGraphTimeout  = 8 * time.Second
+)
+
+// GraphLimits bounds a single graph build. Zero values disable a limit.
+type GraphLimits struct {
+	MaxNodes int
+	MaxEdges int
+	Timeout  time.Duration
+}
+
+// GraphLimitError reports that a graph build was stopped by a limit.
+type GraphLimitError struct {
+	Limit       string   `json:"limit"` // "nodes", "edges", or "time"
+	Max         string   `json:"max"`
+	Message     string   `json:"error"`
+	Suggestions []string `json:"suggestions"`
+}
+
+func (e *GraphLimitError) Error() string {
+	return e.Message
+}
+
+// newGraphLimitError builds a limit error with suggestions derived from the
+// filter and depth that produced the oversized graph.
+func newGraphLimitError(limit, max string, filter GraphFilter, depth int) *GraphLimitError {
+	return &GraphLimitError{
+		Limit:       limit,
+		Max:         max,
+		Message:     fmt.Sprintf("graph exceeds %s limit (%s)", limit, max),
+		Suggestions: suggestFilters(filter, depth),
+	}
+}
+
+// suggestFilters lists filter changes that would shrink the graph.
+func suggestFilters(filter GraphFilter, depth int) []string {
+	var suggestions []string
+	if depth > 1 {
+		suggestions = append(suggestions, fmt.Sprintf("reduce depth (currently %d)", depth))
+	}
+	if !filter.HideStdlib {
+		suggestions = append(suggestions, "enable hideStdlib")
+	}
+	if !filter.HideVendors {
+		suggestions = append(suggestions, "enable hideVendors")
+	}
+	if !filter.StopAtIO {
+		suggestions = append(suggestions, "enable stopAtIO")
+	}
+	if !filter.CollapseWiring {
+		suggestions = append(suggestions, "enable collapseWiring")
+	}
+	if len(filter.NoisePackages) == 0 {
+		suggestions = append(suggestions, "add noisePackages for logging/metrics packages")
+	}
+	return suggestions
+}
+
+// writeLimitError writes a 422 with the structured limit payload if err is a
+// GraphLimitError, and reports whether it did.
+func writeLimitError(w http.ResponseWriter, err error) bool {
+	var limitErr *GraphLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, limitErr)
+	return true
+}
+
+// isFatalBuildError reports whether a traversal error must abort the whole
+// build rather than skip one branch.
+func isFatalBuildError(ctx context.Context, err error) bool {
+	var limitErr *GraphLimitError
+	return errors.As(err, &limitErr) || ctx.Err() != nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// NoiseSummary describes calls into noise packages (logging, metrics,
+// tracing) collapsed with the collapseNoise filter, so the flow stays
+// readable while still showing that instrumentation exists.
+type NoiseSummary struct {
+	Count    int      `json:"count"`    // Calls collapsed, counting repeated call sites
+	Packages []string `json:"packages"` // Noise packages called, sorted
+	Labels   []string `json:"labels"`   // Called functions, in call site order
+}
+
+// isNoisePackage reports whether pkgPath matches one of the filter's noise
+// packages.
+func isNoisePackage(filter GraphFilter, pkgPath string) bool {
+	for _, noise := range filter.NoisePackages {
+		if matchPackagePattern(noise, pkgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCollapsedNoise reports whether a callee is folded into a noise summary
+// rather than shown or hidden.
+func isCollapsedNoise(filter GraphFilter, sym *store.Symbol) bool {
+	return filter.CollapseNoise && isNoisePackage(filter, sym.PkgPath)
+}
+
+// addNoiseCall folds a noise callee into summary.
+func addNoiseCall(summary *NoiseSummary, c *store.CalleeInfo) {
+	count := c.Count
+	if count == 0 {
+		count = 1
+	}
+	summary.Count += count
+	label := c.Symbol.Name
+	if c.Symbol.RecvType != "" {
+		label = "(" + c.Symbol.RecvType + ")." + label
+	}
+	summary.Labels = appendUniqueString(summary.Labels, label)
+	summary.Packages = appendUniqueString(summary.Packages, c.Symbol.PkgPath)
+	sort.Strings(summary.Packages)
+}
+
+// noiseNodeName is the display name of a collapsed noise pseudo-node.
+func noiseNodeName(summary *NoiseSummary) string {
+	if summary.Count == 1 {
+		return "1 observability call"
+	}
+	return fmt.Sprintf("%d observability calls", summary.Count)
+}
+
+// appendUniqueString appends s to list unless already present.
+func appendUniqueString(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for sharing one 'flowlens ui' server between several users.
+const (
+	DefaultWorkers       = 4
+	DefaultQueueSize     = 32
+	DefaultQueueWait     = 10 * time.Second
+	DefaultRequestBudget = 12 * time.Second // Below the server's 15s write timeout
+)
+
+var (
+	errQueueFull = errors.New("server busy: too many graph requests waiting")
+	errQueueWait = errors.New("server busy: no worker was free in time")
+)
+
+// requestPool bounds the expensive requests (graph, spine, and CFG builds)
+// running at once, so a few large graphs can't starve everyone else sharing
+// the server of index connections and CPU. Requests beyond the workers wait
+// in a bounded queue and are turned away with a 503 when it is full or they
+// waited too long. A nil pool, or one without workers, runs every request
+// at once.
+type requestPool struct {
+	slots    chan struct{} // One per worker; nil when unlimited
+	maxQueue int           // Requests waiting for a worker (0 = unbounded)
+	maxWait  time.Duration // Longest wait for a worker (0 = as long as the request lasts)
+
+	active         atomic.Int64
+	queued         atomic.Int64
+	served         atomic.Int64 // Requests that got a worker
+	rejected       atomic.Int64 // Turned away because the queue was full
+	timedOut       atomic.Int64 // Turned away after waiting maxWait
+	budgetExceeded atomic.Int64 // Requests stopped by their query budget
+	waitNanos      atomic.Int64 // Total queue wait of served requests
+	maxWaitNanos   atomic.Int64
+}
+
+func newRequestPool(workers, queueSize int, queueWait time.Duration) *requestPool {
+	p := &requestPool{maxQueue: queueSize, maxWait: queueWait}
+	if workers > 0 {
+		p.slots = make(chan struct{}, workers)
+	}
+	return p
+}
+
+// acquire waits for a worker and returns the function that frees it, or an
+// error if the queue is full, the wait exceeds maxWait, or ctx ends first.
+func (p *requestPool) acquire(ctx context.Context) (func(), error) {
+	if p == nil || p.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return p.start(0), nil
+	default:
+	}
+
+	if n := p.queued.Add(1); p.maxQueue > 0 && n > int64(p.maxQueue) {
+		p.queued.Add(-1)
+		p.rejected.Add(1)
+		return nil, errQueueFull
+	}
+	defer p.queued.Add(-1)
+
+	var timeout <-chan time.Time
+	if p.maxWait > 0 {
+		timer := time.NewTimer(p.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	start := time.Now()
+	select {
+	case p.slots <- struct{}{}:
+		return p.start(time.Since(start)), nil
+	case <-timeout:
+		p.timedOut.Add(1)
+		return nil, errQueueWait
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// start records a request that got a worker after waiting wait, and
+// returns the function that frees the worker.
+func (p *requestPool) start(wait time.Duration) func() {
+	p.active.Add(1)
+	p.served.Add(1)
+	p.waitNanos.Add(int64(wait))
+	for {
+		max := p.maxWaitNanos.Load()
+		if int64(wait) <= max || p.maxWaitNanos.CompareAndSwap(max, int64(wait)) {
+			break
+		}
+	}
+	return func() {
+		p.active.Add(-1)
+		<-p.slots
+	}
+}
+
+// QueueStats reports the load on the expensive-request pool, for
+// /api/health.
+type QueueStats struct {
+	Workers        int     `json:"workers"`    // 0 = unlimited
+	QueueSize      int     `json:"queue_size"` // 0 = unbounded
+	Active         int64   `json:"active"`
+	Queued         int64   `json:"queued"`
+	Served         int64   `json:"served"`
+	Rejected       int64   `json:"rejected"`  // Queue full
+	TimedOut       int64   `json:"timed_out"` // Waited too long for a worker
+	BudgetExceeded int64   `json:"budget_exceeded"`
+	AvgWaitMs      float64 `json:"avg_wait_ms"`
+	MaxWaitMs      float64 `json:"max_wait_ms"`
+}
+
+// stats returns the pool's current load and counters since startup.
+func (p *requestPool) stats() QueueStats {
+	if p == nil {
+		return QueueStats{}
+	}
+	st := QueueStats{
+		Workers:        cap(p.slots),
+		QueueSize:      p.maxQueue,
+		Active:         p.active.Load(),
+		Queued:         p.queued.Load(),
+		Served:         p.served.Load(),
+		Rejected:       p.rejected.Load(),
+		TimedOut:       p.timedOut.Load(),
+		BudgetExceeded: p.budgetExceeded.Load(),
+		MaxWaitMs:      float64(p.maxWaitNanos.Load()) / float64(time.Millisecond),
+	}
+	if st.Served > 0 {
+		st.AvgWaitMs = float64(p.waitNanos.Load()) / float64(st.Served) / float64(time.Millisecond)
+	}
+	return st
+}
+
+// acquireWorker waits for a worker of the expensive-request pool for r. If
+// none is available it writes a 503 and returns false; otherwise the caller
+// must call the returned function when done.
+func (s *Server) acquireWorker(w http.ResponseWriter, r *http.Request) (func(), bool) {
+	release, err := s.pool.acquire(r.Context())
+	if err == nil {
+		return release, true
+	}
+	if s.writeBudgetError(w, r.Context()) {
+		return nil, false
+	}
+	w.Header().Set("Retry-After", "1")
+	writeError(w, http.StatusServiceUnavailable, err.Error())
+	return nil, false
+}
+
+// budgetMiddleware bounds the index queries of each API request by the
+// server's per-request budget, on top of the per-query timeout.
+func (s *Server) budgetMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.budget <= 0 || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), s.budget)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// writeBudgetError writes a 503 and returns true if the request ran out of
+// its query budget. Expensive handlers call it when a build fails.
+func (s *Server) writeBudgetError(w http.ResponseWriter, ctx context.Context) bool {
+	if s.budget <= 0 || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return false
+	}
+	if s.pool != nil {
+		s.pool.budgetExceeded.Add(1)
+	}
+	writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("request exceeded its %s query budget; try a smaller depth or more filters", s.budget))
+	return true
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// ReferencesResponse lists the uses of a symbol. Uses are recorded for
+// constants, so a constant's value and everywhere it is used come back
+// together.
+type ReferencesResponse struct {
+	Symbol     *store.Symbol     `json:"symbol"`
+	References []store.Reference `json:"references"`
+}
+
+// handleSymbolReferences handles GET /api/symbol/:id/references.
+func (s *Server) handleSymbolReferences(w http.ResponseWriter, r *http.Request, id store.SymbolID) {
+	ctx := r.Context()
+
+	sym, err := s.store.GetSymbolByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "symbol not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get symbol: %v", err))
+		return
+	}
+
+	refs, err := s.store.GetReferences(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get references: %v", err))
+		return
+	}
+	if refs == nil {
+		refs = []store.Reference{}
+	}
+	writeJSON(w, http.StatusOK, &ReferencesResponse{Symbol: sym, References: refs})
+}
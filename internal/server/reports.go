@@ -0,0 +1,616 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/sarif"
+	"github.com/abramin/flowlens/internal/store"
+	"github.com/abramin/flowlens/internal/version"
+)
+
+// TaintReport groups taint findings by entrypoint.
+type TaintReport struct {
+	Entrypoints  []TaintReportEntry `json:"entrypoints"`
+	FindingCount int                `json:"finding_count"`
+	ByCategory   map[string]int     `json:"by_category"` // Sink category -> finding count
+}
+
+// TaintReportEntry lists the unsanitized input-to-sink flows of one entrypoint.
+type TaintReportEntry struct {
+	EntrypointID store.EntrypointID   `json:"entrypoint_id"`
+	Label        string               `json:"label"`
+	Type         store.EntrypointType `json:"type"`
+	Findings     []store.TaintFinding `json:"findings"`
+}
+
+// handleTaintReport handles GET /api/reports/taint
+// Findings are computed at index time; this groups them by entrypoint.
+func (s *Server) handleTaintReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := "report|taint"
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	findings, err := s.store.GetTaintFindings(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get taint findings: %v", err))
+		return
+	}
+
+	report := &TaintReport{
+		Entrypoints:  []TaintReportEntry{},
+		FindingCount: len(findings),
+		ByCategory:   make(map[string]int),
+	}
+	// Findings arrive ordered by entrypoint
+	for _, f := range findings {
+		report.ByCategory[f.SinkCategory]++
+		n := len(report.Entrypoints)
+		if n == 0 || report.Entrypoints[n-1].EntrypointID != f.EntrypointID {
+			report.Entrypoints = append(report.Entrypoints, TaintReportEntry{
+				EntrypointID: f.EntrypointID,
+				Label:        f.EntrypointLabel,
+				Type:         f.EntrypointType,
+			})
+			n++
+		}
+		report.Entrypoints[n-1].Findings = append(report.Entrypoints[n-1].Findings, f)
+	}
+	s.cache.Put(generation, cacheKey, report)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// AuthReport lists the auth status of HTTP entrypoints.
+type AuthReport struct {
+	Checks       []store.AuthCheck `json:"checks"`
+	MissingCount int               `json:"missing_count"`
+	ByStatus     map[string]int    `json:"by_status"` // Status -> entrypoint count
+}
+
+// handleAuthReport handles GET /api/reports/auth
+// Query params: status (middleware, call, public, missing; default all),
+// format (json or sarif; default json). SARIF output contains only routes
+// missing auth.
+func (s *Server) handleAuthReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	status := q.Get("status")
+	switch status {
+	case "", store.AuthMiddleware, store.AuthCall, store.AuthPublic, store.AuthMissing:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid status %q", status))
+		return
+	}
+	format := q.Get("format")
+	switch format {
+	case "":
+		format = "json"
+	case "json", "sarif":
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid format %q", format))
+		return
+	}
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := "report|auth|" + status + "|" + format
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	checks, err := s.store.GetAuthChecks(ctx, status)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get auth checks: %v", err))
+		return
+	}
+
+	var resp interface{}
+	if format == "sarif" {
+		resp = sarif.FromAuthChecks(checks, s.projectDir, version.Get().Version)
+	} else {
+		report := &AuthReport{
+			Checks:   checks,
+			ByStatus: make(map[string]int),
+		}
+		if report.Checks == nil {
+			report.Checks = []store.AuthCheck{}
+		}
+		for _, c := range checks {
+			report.ByStatus[c.Status]++
+		}
+		report.MissingCount = report.ByStatus[store.AuthMissing]
+		resp = report
+	}
+	s.cache.Put(generation, cacheKey, resp)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DependencyReport maps entrypoints to the third-party modules they reach.
+type DependencyReport struct {
+	Indexed     bool                           `json:"indexed"` // False when the index was built without dependency indexing
+	Entrypoints []store.EntrypointDependencies `json:"entrypoints"`
+	ByModule    map[string][]string            `json:"by_module"` // Module -> labels of entrypoints reaching it
+}
+
+// handleDependencyReport handles GET /api/reports/dependencies
+// Query params: module (only entrypoints reaching this module path).
+// Requires an index built with dependencies.index (or index --deps).
+func (s *Server) handleDependencyReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	module := r.URL.Query().Get("module")
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := "report|dependencies|" + module
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	deps, err := s.store.GetEntrypointDependencies(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get dependencies: %v", err))
+		return
+	}
+	indexed, _ := s.store.GetMetadata(ctx, "dependencies_indexed")
+
+	report := &DependencyReport{
+		Indexed:     indexed == "true",
+		Entrypoints: []store.EntrypointDependencies{},
+		ByModule:    make(map[string][]string),
+	}
+	if module != "" {
+		deps = store.FilterDependencies(deps, module)
+	}
+	for _, ep := range deps {
+		for _, m := range ep.Modules {
+			report.ByModule[m.Module] = append(report.ByModule[m.Module], ep.Label)
+		}
+		report.Entrypoints = append(report.Entrypoints, ep)
+	}
+	s.cache.Put(generation, cacheKey, report)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// PanicReport lists the panic check results of HTTP and gRPC entrypoints.
+type PanicReport struct {
+	Checks           []store.PanicCheck `json:"checks"`
+	UnrecoveredCount int                `json:"unrecovered_count"`
+	ByStatus         map[string]int     `json:"by_status"` // Status -> entrypoint count
+}
+
+// handlePanicReport handles GET /api/reports/panics
+// Query params: status (none, recovered, unrecovered; default all).
+func (s *Server) handlePanicReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", store.PanicNone, store.PanicRecovered, store.PanicUnrecovered:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid status %q", status))
+		return
+	}
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := "report|panics|" + status
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	checks, err := s.store.GetPanicChecks(ctx, status)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get panic checks: %v", err))
+		return
+	}
+
+	report := &PanicReport{
+		Checks:   checks,
+		ByStatus: make(map[string]int),
+	}
+	if report.Checks == nil {
+		report.Checks = []store.PanicCheck{}
+	}
+	for _, c := range checks {
+		report.ByStatus[c.Status]++
+	}
+	report.UnrecoveredCount = report.ByStatus[store.PanicUnrecovered]
+	s.cache.Put(generation, cacheKey, report)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GlobalStateReport lists package-level variables written from several
+// functions, the hidden shared state behind otherwise separate flows.
+type GlobalStateReport struct {
+	Vars         []store.GlobalVar `json:"vars"`
+	Count        int               `json:"count"`
+	CrossPackage int               `json:"cross_package"` // Vars written from more than one package
+}
+
+// handleGlobalStateReport handles GET /api/reports/globals
+// Query params: min_writers (distinct writing functions; default 2).
+// Writes in init functions and package-level initializers are not counted.
+func (s *Server) handleGlobalStateReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	minWriters := 2
+	if v := r.URL.Query().Get("min_writers"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "min_writers must be a positive integer")
+			return
+		}
+		minWriters = n
+	}
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := fmt.Sprintf("report|globals|%d", minWriters)
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	vars, err := s.store.GetGlobalVars(ctx, minWriters)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get global writes: %v", err))
+		return
+	}
+
+	report := &GlobalStateReport{Vars: vars, Count: len(vars)}
+	if report.Vars == nil {
+		report.Vars = []store.GlobalVar{}
+	}
+	for _, v := range vars {
+		if v.PackageCount > 1 {
+			report.CrossPackage++
+		}
+	}
+	s.cache.Put(generation, cacheKey, report)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// FeatureFlagReport maps feature flags to the entrypoints whose flows
+// evaluate them.
+type FeatureFlagReport struct {
+	Flags     []store.FeatureFlag `json:"flags"`
+	Count     int                 `json:"count"`
+	Unreached int                 `json:"unreached"` // Flags no entrypoint reaches
+	Dynamic   int                 `json:"dynamic"`   // Evaluations whose key is not a constant
+}
+
+// handleFeatureFlagReport handles GET /api/reports/feature-flags
+// Query params: key (a single flag). Evaluation calls are configured under
+// feature_flags in flowlens.yaml.
+func (s *Server) handleFeatureFlagReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	key := r.URL.Query().Get("key")
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := "report|feature-flags|" + key
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	flags, err := s.store.GetFeatureFlags(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get feature flags: %v", err))
+		return
+	}
+
+	report := &FeatureFlagReport{Flags: []store.FeatureFlag{}}
+	for _, f := range flags {
+		if key != "" && f.Key != key {
+			continue
+		}
+		report.Flags = append(report.Flags, f)
+		if len(f.Entrypoints) == 0 {
+			report.Unreached++
+		}
+		if f.Key == "" {
+			report.Dynamic += len(f.Uses)
+		}
+	}
+	report.Count = len(report.Flags)
+	s.cache.Put(generation, cacheKey, report)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// SimilarEntrypointsReport lists pairs of entrypoints with overlapping call
+// trees: copy-pasted handlers and candidates for consolidation.
+type SimilarEntrypointsReport struct {
+	Pairs []store.SimilarEntrypoints `json:"pairs"`
+	Count int                        `json:"count"`
+}
+
+// handleSimilarEntrypointsReport handles GET /api/reports/similar-entrypoints
+// Query params: min_similarity (Jaccard index of the reachable symbol sets,
+// 0 to 1; default 0.8), min_reach (entrypoints reaching fewer symbols are
+// skipped; default 3).
+func (s *Server) handleSimilarEntrypointsReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	minSimilarity, minReach := 0.8, 3
+	if v := r.URL.Query().Get("min_similarity"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			writeError(w, http.StatusBadRequest, "min_similarity must be between 0 and 1")
+			return
+		}
+		minSimilarity = f
+	}
+	if v := r.URL.Query().Get("min_reach"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "min_reach must be a positive integer")
+			return
+		}
+		minReach = n
+	}
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := fmt.Sprintf("report|similar-entrypoints|%g|%d", minSimilarity, minReach)
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	pairs, err := s.store.GetSimilarEntrypoints(ctx, minSimilarity, minReach)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to compare entrypoints: %v", err))
+		return
+	}
+
+	report := &SimilarEntrypointsReport{Pairs: pairs, Count: len(pairs)}
+	if report.Pairs == nil {
+		report.Pairs = []store.SimilarEntrypoints{}
+	}
+	s.cache.Put(generation, cacheKey, report)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// OrphanedHandlersReport lists functions with HTTP handler signatures that
+// no router registration or other entrypoint reaches.
+type OrphanedHandlersReport struct {
+	Handlers []store.EntrypointWithSymbol `json:"handlers"`
+	Count    int                          `json:"count"`
+}
+
+// handleOrphanedHandlersReport handles GET /api/reports/orphaned-handlers
+func (s *Server) handleOrphanedHandlersReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	generation := s.indexGeneration(ctx)
+	cacheKey := "report|orphaned-handlers"
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	handlers, err := s.store.GetOrphanedHandlers(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to find orphaned handlers: %v", err))
+		return
+	}
+
+	report := &OrphanedHandlersReport{Handlers: handlers, Count: len(handlers)}
+	if report.Handlers == nil {
+		report.Handlers = []store.EntrypointWithSymbol{}
+	}
+	s.cache.Put(generation, cacheKey, report)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GRPCCoverageReport compares the generated gRPC service interfaces with
+// the project types implementing and registering them.
+type GRPCCoverageReport struct {
+	Services      []store.GRPCServiceCoverage `json:"services"`
+	Unimplemented int                         `json:"unimplemented"` // Service methods that return codes.Unimplemented
+	Unregistered  int                         `json:"unregistered"`  // Implementations never passed to RegisterXServer
+}
+
+// handleGRPCCoverageReport handles GET /api/reports/grpc
+func (s *Server) handleGRPCCoverageReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	generation := s.indexGeneration(ctx)
+	cacheKey := "report|grpc"
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	services, err := s.store.GetGRPCCoverage(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get gRPC coverage: %v", err))
+		return
+	}
+
+	report := &GRPCCoverageReport{Services: services}
+	for _, svc := range services {
+		report.Unimplemented += len(svc.Unimplemented)
+		for _, impl := range svc.Implementations {
+			if !impl.Registered {
+				report.Unregistered++
+			}
+		}
+	}
+	s.cache.Put(generation, cacheKey, report)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// HeatmapNode is one directory of the heatmap tree. Value counts the calls
+// into the directory's own symbols, so summing it over a subtree (as a
+// treemap layout does) gives Calls.
+type HeatmapNode struct {
+	Name        string         `json:"name"` // Last path element; "" for the root
+	Path        string         `json:"path"`
+	Value       int            `json:"value"`
+	Calls       int            `json:"calls"`
+	Symbols     int            `json:"symbols"`
+	Entrypoints int            `json:"entrypoints"`
+	Children    []*HeatmapNode `json:"children,omitempty"`
+}
+
+// HeatmapReport shows where entrypoint traffic concentrates in the source
+// tree.
+type HeatmapReport struct {
+	Root        *HeatmapNode `json:"root"`
+	Directories int          `json:"directories"` // Nodes in the tree, the root included
+}
+
+// handleHeatmapReport handles GET /api/reports/heatmap
+// Query params: type (entrypoint type; default all), depth (directory levels
+// below the root to return, deeper directories folding into their ancestor;
+// default 0 = all).
+func (s *Server) handleHeatmapReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	epType := store.EntrypointType(r.URL.Query().Get("type"))
+	depth := 0
+	if v := r.URL.Query().Get("depth"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "depth must be a non-negative integer")
+			return
+		}
+		depth = n
+	}
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := fmt.Sprintf("report|heatmap|%s|%d", epType, depth)
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	heat, err := s.store.GetDirectoryHeat(ctx, epType)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to compute heatmap: %v", err))
+		return
+	}
+
+	report := buildHeatmap(heat, depth)
+	s.cache.Put(generation, cacheKey, report)
+
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// buildHeatmap nests the directories of heat, sorted by path, into a tree.
+// Directories more than depth levels below the root (when depth > 0) are
+// left out, their calls counted in the Value of their ancestor at depth.
+func buildHeatmap(heat []store.DirectoryHeat, depth int) *HeatmapReport {
+	report := &HeatmapReport{Root: &HeatmapNode{}}
+	nodes := map[string]*HeatmapNode{"": report.Root}
+	for _, h := range heat {
+		levels := 0
+		if h.Dir != "" {
+			levels = strings.Count(h.Dir, "/") + 1
+		}
+		if depth > 0 && levels > depth {
+			continue
+		}
+		node := nodes[h.Dir]
+		if node == nil {
+			node = &HeatmapNode{Name: path.Base(h.Dir), Path: h.Dir}
+			// Ancestors sort first, so the parent is already in the tree
+			parent := path.Dir(h.Dir)
+			if parent == "." {
+				parent = ""
+			}
+			nodes[parent].Children = append(nodes[parent].Children, node)
+			nodes[h.Dir] = node
+		}
+		node.Value = h.DirectCalls
+		if depth > 0 && levels == depth {
+			node.Value = h.Calls // Folds in the subdirectories left out
+		}
+		node.Calls = h.Calls
+		node.Symbols = h.Symbols
+		node.Entrypoints = h.Entrypoints
+		report.Directories++
+	}
+	return report
+}
@@ -3,44 +3,102 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/abramin/flowlens/internal/index"
 	"github.com/abramin/flowlens/internal/store"
+	"github.com/abramin/flowlens/internal/version"
 )
 
 // Server is the FlowLens HTTP server.
 type Server struct {
-	store      *store.Store
-	httpServer *http.Server
-	port       int
+	store          *store.Store
+	httpServer     *http.Server
+	port           int
+	cache          *responseCache // Graph and spine responses; nil disables caching
+	etags          etagState
+	limits         GraphLimits
+	projectDir     string
+	ssa            *index.SSACache  // Shared SSA program for CFG requests; nil rebuilds per request
+	stdlibAllow    []string         // Default GraphFilter.StdlibAllow
+	snapshots      *snapshotServers // Servers for ?as_of= requests
+	asOf           string           // Name of the retained snapshot served, if not the current index
+	warmGraphs     int              // Entrypoint graphs warmCaches builds
+	warming        atomic.Bool      // A warm-up is running
+	graphs         *callGraphs      // Call graph of the current index, shared by graph builds
+	pool           *requestPool     // Workers for graph, spine, and CFG builds
+	budget         time.Duration    // Deadline for the index queries of each API request (0 = none)
+	idle           *idleTracker     // Requests handled, for shutting down when idle
+	pidFile        string           // Written once listening and removed on shutdown, if set
+	background     context.Context  // Canceled on shutdown, stopping warm-ups and call graph loads
+	stopBackground context.CancelFunc
 }
 
 // Config holds server configuration.
 type Config struct {
-	Port       int
-	ProjectDir string
+	Port                int
+	ProjectDir          string
+	DBPath              string        // Index database (default: <ProjectDir>/.flowlens/index.db)
+	ReadOnly            bool          // Open the index read-only; requests that would change it are rejected
+	IndexKey            []byte        // Key the index is encrypted with at rest (nil = unencrypted; see store.OpenEncrypted)
+	QueryTimeout        time.Duration // Per-query store timeout (0 = none)
+	CacheSize           int           // Max cached graph/spine responses (0 = default)
+	GraphLimits         GraphLimits   // Per-request graph size and time limits (zero fields = unlimited)
+	StdlibAllow         []string      // Standard library packages hideStdlib keeps unless a request overrides them
+	WarmGraphs          int           // Entrypoint graphs built in the background at startup and after a reindex (0 = none)
+	MemoryGraphMaxCalls int           // Largest index, in call edges, whose call graph is held in memory (0 = default, negative = none)
+	Workers             int           // Graph, spine, and CFG builds run at once; more wait for a worker (0 = unlimited)
+	QueueSize           int           // Builds waiting for a worker before more are turned away with 503 (0 = unbounded)
+	QueueWait           time.Duration // Longest wait for a worker before a 503 (0 = as long as the request lasts)
+	RequestBudget       time.Duration // Deadline for the index queries of each API request (0 = none)
+	IdleTimeout         time.Duration // Shut down after this long without requests (0 = never)
+	PIDFile             string        // Pidfile recording the running server, e.g. for 'flowlens ui --stop' (empty = none)
+
+	asOf string // Retained snapshot at DBPath, for servers opened by asOfMiddleware
 }
 
 // New creates a new server instance.
 func New(cfg Config) (*Server, error) {
-	st, err := store.Open(cfg.ProjectDir)
+	dbPath := cfg.DBPath
+	if dbPath == "" {
+		dbPath = filepath.Join(cfg.ProjectDir, ".flowlens", "index.db")
+	}
+	st, err := store.OpenEncrypted(dbPath, cfg.ProjectDir, cfg.IndexKey, cfg.ReadOnly)
 	if err != nil {
 		return nil, fmt.Errorf("opening store: %w", err)
 	}
+	st.SetQueryTimeout(cfg.QueryTimeout)
 
 	s := &Server{
-		store: st,
-		port:  cfg.Port,
-	}
+		store:       st,
+		port:        cfg.Port,
+		cache:       newResponseCache(cfg.CacheSize),
+		limits:      cfg.GraphLimits,
+		projectDir:  cfg.ProjectDir,
+		ssa:         index.NewSSACache(),
+		stdlibAllow: cfg.StdlibAllow,
+		snapshots:   newSnapshotServers(cfg),
+		asOf:        cfg.asOf,
+		warmGraphs:  cfg.WarmGraphs,
+		pool:        newRequestPool(cfg.Workers, cfg.QueueSize, cfg.QueueWait),
+		budget:      cfg.RequestBudget,
+		idle:        newIdleTracker(cfg.IdleTimeout),
+		pidFile:     cfg.PIDFile,
+	}
+	s.background, s.stopBackground = context.WithCancel(context.Background())
+	s.graphs = newCallGraphs(s.background, st, cfg.MemoryGraphMaxCalls)
 
 	mux := http.NewServeMux()
 
@@ -49,20 +107,49 @@ func New(cfg Config) (*Server, error) {
 	mux.HandleFunc("/api/entrypoints/", s.corsMiddleware(s.handleEntrypointByID))
 	mux.HandleFunc("/api/symbol/", s.corsMiddleware(s.handleSymbol))
 	mux.HandleFunc("/api/search", s.corsMiddleware(s.handleSearch))
+	mux.HandleFunc("/api/tags", s.corsMiddleware(s.handleTags))
+	mux.HandleFunc("/api/tags/", s.corsMiddleware(s.handleTagSymbols))
+	mux.HandleFunc("/api/layers", s.corsMiddleware(s.handleLayers))
+	mux.HandleFunc("/api/interfaces", s.corsMiddleware(s.handleInterfaces))
+	mux.HandleFunc("/api/interfaces/", s.corsMiddleware(s.handleInterfaceByID))
+	mux.HandleFunc("/api/types/", s.corsMiddleware(s.handleTypes))
 	mux.HandleFunc("/api/graph/", s.corsMiddleware(s.handleGraph))
+	mux.HandleFunc("/api/graph/stream/", s.corsMiddleware(s.handleGraphStream))
 	mux.HandleFunc("/api/spine/", s.corsMiddleware(s.handleSpine))
 	mux.HandleFunc("/api/cfg/", s.corsMiddleware(s.handleCFG))
 	mux.HandleFunc("/api/stats", s.corsMiddleware(s.handleStats))
+	mux.HandleFunc("/api/stats/unresolved", s.corsMiddleware(s.handleUnresolved))
+	mux.HandleFunc("/api/changes", s.corsMiddleware(s.handleChanges))
+	mux.HandleFunc("/api/snapshots", s.corsMiddleware(s.handleSnapshots))
+	mux.HandleFunc("/api/repos", s.corsMiddleware(s.handleRepos))
+	mux.HandleFunc("/api/diagnostics", s.corsMiddleware(s.handleDiagnostics))
+	mux.HandleFunc("/api/edges", s.corsMiddleware(s.handleEdges))
+	mux.HandleFunc("/api/bookmarks", s.corsMiddleware(s.handleBookmarks))
+	mux.HandleFunc("/api/views", s.corsMiddleware(s.handleViews))
+	mux.HandleFunc("/api/share", s.corsMiddleware(s.handleShare))
+	mux.HandleFunc("/api/share/", s.corsMiddleware(s.handleShareByID))
+	mux.HandleFunc("/api/badge.svg", s.corsMiddleware(s.handleBadge))
+	mux.HandleFunc("/api/reports/taint", s.corsMiddleware(s.handleTaintReport))
+	mux.HandleFunc("/api/reports/auth", s.corsMiddleware(s.handleAuthReport))
+	mux.HandleFunc("/api/reports/dependencies", s.corsMiddleware(s.handleDependencyReport))
+	mux.HandleFunc("/api/reports/panics", s.corsMiddleware(s.handlePanicReport))
+	mux.HandleFunc("/api/reports/globals", s.corsMiddleware(s.handleGlobalStateReport))
+	mux.HandleFunc("/api/reports/feature-flags", s.corsMiddleware(s.handleFeatureFlagReport))
+	mux.HandleFunc("/api/reports/similar-entrypoints", s.corsMiddleware(s.handleSimilarEntrypointsReport))
+	mux.HandleFunc("/api/reports/orphaned-handlers", s.corsMiddleware(s.handleOrphanedHandlersReport))
+	mux.HandleFunc("/api/reports/grpc", s.corsMiddleware(s.handleGRPCCoverageReport))
+	mux.HandleFunc("/api/reports/heatmap", s.corsMiddleware(s.handleHeatmapReport))
 
 	// Health check
 	mux.HandleFunc("/api/health", s.corsMiddleware(s.handleHealth))
+	mux.HandleFunc("/api/version", s.corsMiddleware(s.handleVersion))
 
 	// Serve React UI
 	mux.Handle("/", UIHandler())
 
 	s.httpServer = &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      mux,
+		Handler:      s.idle.middleware(s.asOfMiddleware(compressMiddleware(s.reopenMiddleware(s.budgetMiddleware(mux))))),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -71,20 +158,57 @@ func New(cfg Config) (*Server, error) {
 	return s, nil
 }
 
-// Start starts the server and blocks until shutdown.
+// Handler returns the server's HTTP handler, for serving requests in
+// process, e.g. in flowlens selftest.
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// Close releases the index of a server that was never started; Start
+// releases it on shutdown.
+func (s *Server) Close() error {
+	s.stopBackground()
+	s.graphs.wait()
+	s.snapshots.close()
+	return s.store.Close()
+}
+
+// Start starts the server and blocks until shutdown: on an interrupt or
+// SIGTERM, or once the server has been idle for its idle timeout.
 func (s *Server) Start() error {
 	// Setup graceful shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		s.Close()
+		return fmt.Errorf("listening on port %d: %w", s.port, err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	if s.pidFile != "" {
+		if err := s.writePIDFile(port); err != nil {
+			ln.Close()
+			s.Close()
+			return err
+		}
+		defer s.removePIDFile()
+	}
+
+	s.preloadCallGraph()
+	s.warmCaches()
 	go func() {
-		log.Printf("Server starting on http://localhost:%d", s.port)
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Server starting on http://localhost:%d", port)
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server error: %v", err)
 		}
 	}()
 
-	<-stop
+	select {
+	case <-stop:
+	case <-s.idle.wait(s.background.Done()):
+		log.Printf("No requests for %s", s.idle.timeout)
+	}
 	log.Println("Shutting down server...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -94,6 +218,9 @@ func (s *Server) Start() error {
 		return fmt.Errorf("shutdown error: %w", err)
 	}
 
+	s.stopBackground()
+	s.graphs.wait()
+	s.snapshots.close()
 	if err := s.store.Close(); err != nil {
 		return fmt.Errorf("closing store: %w", err)
 	}
@@ -111,8 +238,9 @@ func (s *Server) Port() int {
 func (s *Server) corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -123,6 +251,32 @@ func (s *Server) corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// rejectReadOnly writes a 403 and returns true if the index is served
+// read-only. Handlers saving bookmarks, views, shares, or manual edges call
+// it before writing, since those are stored in the index.
+func (s *Server) rejectReadOnly(w http.ResponseWriter) bool {
+	if !s.store.ReadOnly() {
+		return false
+	}
+	writeError(w, http.StatusForbidden, "the index is served read-only")
+	return true
+}
+
+// reopenMiddleware reopens the store when 'flowlens index' has renamed a new
+// index over the one the server opened, so requests see it without a restart.
+func (s *Server) reopenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reopened, err := s.store.Reopen(); err != nil {
+			log.Printf("Error reopening index: %v", err)
+		} else if reopened {
+			log.Printf("Reopened replaced index %s", s.store.DBPath())
+			s.preloadCallGraph()
+			s.warmCaches()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // writeJSON writes a JSON response.
 func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -138,13 +292,13 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	writeJSON(w, status, map[string]string{"error": message})
 }
 
-// handleHealth returns server health status.
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+// handleVersion returns build information for the running binary.
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	writeJSON(w, http.StatusOK, version.Get())
 }
 
 // handleStats returns index statistics.
@@ -154,7 +308,11 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats, err := s.store.GetStats()
+	if s.notModified(w, r) {
+		return
+	}
+
+	stats, err := s.store.GetStats(r.Context())
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get stats: %v", err))
 		return
@@ -163,7 +321,61 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, stats)
 }
 
-// handleEntrypoints handles GET /api/entrypoints
+// handleChanges handles GET /api/changes
+// Returns what was added, removed, relocated, or moved by the latest indexing run.
+// Query params: entity (symbol|entrypoint|edge), change (added|removed|relocated|moved), limit.
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	filter := store.ChangeFilter{
+		Entity: store.ChangeEntity(r.URL.Query().Get("entity")),
+		Kind:   store.ChangeKind(r.URL.Query().Get("change")),
+	}
+
+	changes, err := s.store.GetChanges(ctx, filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get changes: %v", err))
+		return
+	}
+
+	// Summary counts cover every matching change, not just the returned page
+	summary := map[store.ChangeKind]int{
+		store.ChangeAdded:     0,
+		store.ChangeRemoved:   0,
+		store.ChangeRelocated: 0,
+		store.ChangeMoved:     0,
+	}
+	for _, c := range changes {
+		summary[c.Kind]++
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit < len(changes) {
+			changes = changes[:limit]
+		}
+	}
+	if changes == nil {
+		changes = []store.Change{}
+	}
+
+	previousIndexedAt, _ := s.store.GetMetadata(ctx, "previous_indexed_at")
+	indexedAt, _ := s.store.GetMetadata(ctx, "indexed_at")
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"previous_indexed_at": previousIndexedAt,
+		"indexed_at":          indexedAt,
+		"summary":             summary,
+		"changes":             changes,
+	})
+}
+
+// handleEntrypoints handles GET /api/entrypoints; ?view=tree groups them by
+// path prefix, gRPC service, and command (see groupEntrypoints).
 func (s *Server) handleEntrypoints(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -180,31 +392,50 @@ func (s *Server) handleEntrypoints(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	entrypoints, err := s.store.GetEntrypoints(filter)
+	view := r.URL.Query().Get("view")
+	if view != "" && view != "list" && view != "tree" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid view %q (expected list or tree)", view))
+		return
+	}
+
+	if s.notModified(w, r) {
+		return
+	}
+
+	entrypoints, err := s.store.GetEntrypoints(r.Context(), filter)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get entrypoints: %v", err))
 		return
 	}
 
+	if view == "tree" {
+		writeJSON(w, http.StatusOK, groupEntrypoints(entrypoints))
+		return
+	}
 	writeJSON(w, http.StatusOK, entrypoints)
 }
 
-// handleEntrypointByID handles GET /api/entrypoints/:id
+// handleEntrypointByID handles GET /api/entrypoints/:id and GET /api/entrypoints/:id/errors
 func (s *Server) handleEntrypointByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	// Extract ID from path: /api/entrypoints/123
+	// Extract ID from path: /api/entrypoints/123 or /api/entrypoints/123/errors
 	path := strings.TrimPrefix(r.URL.Path, "/api/entrypoints/")
+	path, errorChain := strings.CutSuffix(path, "/errors")
 	id, err := strconv.ParseInt(path, 10, 64)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid entrypoint ID")
 		return
 	}
+	if errorChain {
+		s.handleEntrypointErrors(w, r, store.EntrypointID(id))
+		return
+	}
 
-	ep, err := s.store.GetEntrypointByID(store.EntrypointID(id))
+	ep, err := s.store.GetEntrypointByID(r.Context(), store.EntrypointID(id))
 	if err != nil {
 		writeError(w, http.StatusNotFound, fmt.Sprintf("entrypoint not found: %v", err))
 		return
@@ -213,85 +444,116 @@ func (s *Server) handleEntrypointByID(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, ep)
 }
 
-// handleSymbol handles GET /api/symbol/:id
+// handleSymbol handles GET /api/symbol/:id and GET /api/symbol/:id/references
 func (s *Server) handleSymbol(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	// Extract ID from path: /api/symbol/123
+	ctx := r.Context()
+
+	// Extract ID from path: /api/symbol/123 or /api/symbol/123/references
 	path := strings.TrimPrefix(r.URL.Path, "/api/symbol/")
+	path, references := strings.CutSuffix(path, "/references")
 	id, err := strconv.ParseInt(path, 10, 64)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid symbol ID")
 		return
 	}
+	if references {
+		s.handleSymbolReferences(w, r, store.SymbolID(id))
+		return
+	}
 
-	sym, err := s.store.GetSymbolByID(store.SymbolID(id))
+	sym, err := s.store.GetSymbolByID(ctx, store.SymbolID(id))
 	if err != nil {
 		writeError(w, http.StatusNotFound, fmt.Sprintf("symbol not found: %v", err))
 		return
 	}
 
-	tags, err := s.store.GetSymbolTags(store.SymbolID(id))
-	if err != nil {
-		tags = []store.Tag{} // Don't fail if tags can't be fetched
-	}
+	tags, callees, callers := s.symbolCalls(ctx, store.SymbolID(id))
 
 	// Get package info
-	pkg, _ := s.store.GetPackageByPath(sym.PkgPath)
+	pkg, _ := s.store.GetPackageByPath(ctx, sym.PkgPath)
 
-	// Get callees (functions this symbol calls)
-	callees, err := s.store.GetCallees(store.SymbolID(id))
-	if err != nil {
-		callees = []store.CalleeInfo{}
-	}
-
-	// Get callers (functions that call this symbol)
-	callers, err := s.store.GetCallers(store.SymbolID(id))
-	if err != nil {
-		callers = []store.CallerInfo{}
+	// Interfaces a type satisfies
+	var implements []store.Implementation
+	if sym.Kind == store.SymbolKindType {
+		implements, _ = s.store.GetImplementedInterfaces(ctx, sym.ID)
 	}
 
 	response := struct {
 		*store.Symbol
-		Tags    []store.Tag        `json:"tags"`
-		Package *store.Package     `json:"package,omitempty"`
-		Callees []store.CalleeInfo `json:"callees"`
-		Callers []store.CallerInfo `json:"callers"`
+		Tags       []store.Tag            `json:"tags"`
+		Package    *store.Package         `json:"package,omitempty"`
+		Callees    []store.CalleeInfo     `json:"callees"`
+		Callers    []store.CallerInfo     `json:"callers"`
+		Implements []store.Implementation `json:"implements,omitempty"`
 	}{
-		Symbol:  sym,
-		Tags:    tags,
-		Package: pkg,
-		Callees: callees,
-		Callers: callers,
+		Symbol:     sym,
+		Tags:       tags,
+		Package:    pkg,
+		Callees:    callees,
+		Callers:    callers,
+		Implements: implements,
 	}
 
 	writeJSON(w, http.StatusOK, response)
 }
 
-// handleSearch handles GET /api/search?query=xxx
+// handleRepos handles GET /api/repos
+// Lists the repositories in a shared index with their modules and sizes.
+func (s *Server) handleRepos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	repos, err := s.store.GetRepos(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get repos: %v", err))
+		return
+	}
+	if repos == nil {
+		repos = []store.RepoInfo{}
+	}
+	writeJSON(w, http.StatusOK, repos)
+}
+
+// handleSearch handles GET /api/search?query=xxx, with optional tag, layer,
+// file, kind, sig_contains, param_type, result_type, and repo filters.
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	query := r.URL.Query().Get("query")
-	if query == "" {
-		writeError(w, http.StatusBadRequest, "query parameter required")
+	q := r.URL.Query()
+	filter := store.SearchFilter{
+		Query:       q.Get("query"),
+		Tag:         q.Get("tag"),
+		Layer:       q.Get("layer"),
+		File:        q.Get("file"),
+		Kind:        store.SymbolKind(q.Get("kind")),
+		SigContains: q.Get("sig_contains"),
+		ParamType:   q.Get("param_type"),
+		ResultType:  q.Get("result_type"),
+		Repo:        q.Get("repo"),
+		Limit:       50,
+	}
+	if filter.IsEmpty() {
+		writeError(w, http.StatusBadRequest, "query or at least one of tag, layer, file, kind, sig_contains, param_type, result_type, repo required")
 		return
 	}
 
-	limit := 50
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+	if limitStr := q.Get("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
+			filter.Limit = l
 		}
 	}
 
-	results, err := s.store.SearchSymbols(query, limit)
+	results, err := s.store.SearchSymbols(r.Context(), filter)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("search failed: %v", err))
 		return
@@ -301,14 +563,16 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleGraph handles graph-related endpoints
-// GET /api/graph/root/:symbolId?depth=N&filters={...} - get graph starting from symbol
-// GET /api/graph/expand/:symbolId?depth=N&filters={...} - expand a node
+// GET /api/graph/root/:symbolId?depth=N&filters={...}&layout=dagre|layered - get graph starting from symbol
+// GET /api/graph/expand/:symbolId?depth=N&filters={...}&layout=dagre|layered - expand a node
 func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
+	ctx := r.Context()
+
 	path := strings.TrimPrefix(r.URL.Path, "/api/graph/")
 	parts := strings.SplitN(path, "/", 2)
 	if len(parts) != 2 {
@@ -337,7 +601,7 @@ func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse filters from query parameter (URL-encoded JSON)
-	filter := DefaultGraphFilter()
+	filter := s.defaultGraphFilter()
 	if filtersStr := r.URL.Query().Get("filters"); filtersStr != "" {
 		if err := json.Unmarshal([]byte(filtersStr), &filter); err != nil {
 			writeError(w, http.StatusBadRequest, "invalid filters JSON")
@@ -345,42 +609,173 @@ func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Parse optional server-side layout
+	layout, err := ParseLayoutAlgorithm(r.URL.Query().Get("layout"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if s.notModified(w, r) {
+		return
+	}
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := graphCacheKey(action, symbolID, depth, filter, layout)
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	release, ok := s.acquireWorker(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
 	// Verify symbol exists
-	if _, err := s.store.GetSymbolByID(symbolID); err != nil {
+	if _, err := s.store.GetSymbolByID(ctx, symbolID); err != nil {
+		if s.writeBudgetError(w, ctx) {
+			return
+		}
 		writeError(w, http.StatusNotFound, fmt.Sprintf("symbol not found: %v", err))
 		return
 	}
 
 	// Build the graph
-	builder := NewGraphBuilder(s.store, filter)
+	builder := s.newGraphBuilder(generation, filter)
 
 	var response *GraphResponse
 	switch action {
 	case "root":
-		response, err = builder.BuildFromRoot(symbolID, depth)
+		response, err = builder.BuildFromRoot(ctx, symbolID, depth)
 	case "expand":
-		response, err = builder.Expand(symbolID, depth)
+		response, err = builder.Expand(ctx, symbolID, depth)
 	default:
 		writeError(w, http.StatusBadRequest, "invalid graph action")
 		return
 	}
 
 	if err != nil {
+		if writeLimitError(w, err) || s.writeBudgetError(w, ctx) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to build graph: %v", err))
 		return
 	}
 
+	ApplyLayout(response, layout)
+	s.cache.Put(generation, cacheKey, response)
+
+	w.Header().Set("X-Cache", "MISS")
 	writeJSON(w, http.StatusOK, response)
 }
 
-// handleSpine handles GET /api/spine/:symbolId?depth=N&filters={...}
+// handleGraphStream handles GET /api/graph/stream/:symbolId?depth=N&filters={...}
+// Streams the graph from a root symbol as NDJSON while the traversal runs:
+// one "node" or "edge" event per line, then a final "done" (or "error") event.
+// An edge is only sent after its target node.
+func (s *Server) handleGraphStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/graph/stream/")
+	id, err := strconv.ParseInt(path, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid symbol ID")
+		return
+	}
+
+	symbolID := store.SymbolID(id)
+
+	// Parse depth parameter (default: 3, as for /api/graph/root)
+	depth := 3
+	if depthStr := r.URL.Query().Get("depth"); depthStr != "" {
+		if d, err := strconv.Atoi(depthStr); err == nil && d > 0 {
+			depth = d
+		}
+	}
+
+	filter := s.defaultGraphFilter()
+	if filtersStr := r.URL.Query().Get("filters"); filtersStr != "" {
+		if err := json.Unmarshal([]byte(filtersStr), &filter); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid filters JSON")
+			return
+		}
+	}
+
+	release, ok := s.acquireWorker(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Verify symbol exists before committing to a streamed 200
+	if _, err := s.store.GetSymbolByID(ctx, symbolID); err != nil {
+		if s.writeBudgetError(w, ctx) {
+			return
+		}
+		writeError(w, http.StatusNotFound, fmt.Sprintf("symbol not found: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	send := func(ev GraphStreamEvent) {
+		if err := enc.Encode(ev); err != nil {
+			log.Printf("Error encoding stream event: %v", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	builder := s.newGraphBuilder(s.indexGeneration(ctx), filter)
+	builder.OnEvent(send)
+
+	response, err := builder.BuildFromRoot(ctx, symbolID, depth)
+	if err != nil {
+		var limitErr *GraphLimitError
+		if errors.As(err, &limitErr) {
+			send(GraphStreamEvent{Type: "error", Error: limitErr.Message, Limit: limitErr})
+			return
+		}
+		send(GraphStreamEvent{Type: "error", Error: fmt.Sprintf("failed to build graph: %v", err)})
+		return
+	}
+
+	send(GraphStreamEvent{Type: "done", Done: &GraphStreamSummary{
+		RootID:    response.RootID,
+		MaxDepth:  response.MaxDepth,
+		Filtered:  response.Filtered,
+		NodeCount: len(response.Nodes),
+		EdgeCount: len(response.Edges),
+	}})
+}
+
+// handleSpine handles GET /api/spine/:symbolId?depth=N&filters={...}&seedPath=id,id&via=id,id
 // Returns a call spine visualization with main path and collapsed branches.
+// Equal-scored callees are picked by call site order, then name; seedPath
+// pins the main path to a route for as long as the call graph follows it,
+// and via reroutes it through symbols picked by the user.
 func (s *Server) handleSpine(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
+	ctx := r.Context()
+
 	// Extract symbol ID from path: /api/spine/123
 	path := strings.TrimPrefix(r.URL.Path, "/api/spine/")
 	id, err := strconv.ParseInt(path, 10, 64)
@@ -400,7 +795,7 @@ func (s *Server) handleSpine(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse filters from query parameter (URL-encoded JSON)
-	filter := DefaultGraphFilter()
+	filter := s.defaultGraphFilter()
 	if filtersStr := r.URL.Query().Get("filters"); filtersStr != "" {
 		if err := json.Unmarshal([]byte(filtersStr), &filter); err != nil {
 			writeError(w, http.StatusBadRequest, "invalid filters JSON")
@@ -408,23 +803,81 @@ func (s *Server) handleSpine(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Verify symbol exists
-	if _, err := s.store.GetSymbolByID(symbolID); err != nil {
-		writeError(w, http.StatusNotFound, fmt.Sprintf("symbol not found: %v", err))
+	// Parse the preferred route and the symbols to route through
+	seedStr, viaStr := r.URL.Query().Get("seedPath"), r.URL.Query().Get("via")
+	seed, err := parseSymbolIDs(seedStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid seedPath")
+		return
+	}
+	via, err := parseSymbolIDs(viaStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid via")
+		return
+	}
+
+	generation := s.indexGeneration(ctx)
+	cacheKey := fmt.Sprintf("spine|%d|%d|%s|%s|%s", symbolID, depth, filterHash(filter), seedStr, viaStr)
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
 		return
 	}
 
+	release, ok := s.acquireWorker(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Verify symbol exists
+	graph := s.callGraph(generation)
+	if graph == nil || graph.Symbols[symbolID] == nil {
+		if _, err := s.store.GetSymbolByID(ctx, symbolID); err != nil {
+			if s.writeBudgetError(w, ctx) {
+				return
+			}
+			writeError(w, http.StatusNotFound, fmt.Sprintf("symbol not found: %v", err))
+			return
+		}
+	}
+
 	// Build the spine
 	builder := NewSpineBuilder(s.store, filter)
-	response, err := builder.BuildSpine(symbolID, depth)
+	builder.SetCallGraph(graph)
+	builder.SetSeedPath(seed)
+	builder.SetVia(via)
+	response, err := builder.BuildSpine(ctx, symbolID, depth)
 	if err != nil {
+		if s.writeBudgetError(w, ctx) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to build spine: %v", err))
 		return
 	}
+	s.cache.Put(generation, cacheKey, response)
 
+	w.Header().Set("X-Cache", "MISS")
 	writeJSON(w, http.StatusOK, response)
 }
 
+// parseSymbolIDs parses a comma-separated list of symbol IDs; an empty
+// string is an empty list.
+func parseSymbolIDs(list string) ([]store.SymbolID, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var ids []store.SymbolID
+	for _, part := range strings.Split(list, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, store.SymbolID(id))
+	}
+	return ids, nil
+}
+
 // handleCFG handles GET /api/cfg/:symbolId
 // Returns the control flow graph for a function.
 func (s *Server) handleCFG(w http.ResponseWriter, r *http.Request) {
@@ -433,8 +886,11 @@ func (s *Server) handleCFG(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract symbol ID from path: /api/cfg/123
+	ctx := r.Context()
+
+	// Extract symbol ID from path: /api/cfg/123 or /api/cfg/123/dot
 	path := strings.TrimPrefix(r.URL.Path, "/api/cfg/")
+	path, dot := strings.CutSuffix(path, "/dot")
 	id, err := strconv.ParseInt(path, 10, 64)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid symbol ID")
@@ -444,19 +900,51 @@ func (s *Server) handleCFG(w http.ResponseWriter, r *http.Request) {
 	symbolID := store.SymbolID(id)
 
 	// Verify symbol exists
-	if _, err := s.store.GetSymbolByID(symbolID); err != nil {
+	if _, err := s.store.GetSymbolByID(ctx, symbolID); err != nil {
 		writeError(w, http.StatusNotFound, fmt.Sprintf("symbol not found: %v", err))
 		return
 	}
 
-	// Build the CFG (this rebuilds SSA on-demand)
+	generation := s.indexGeneration(ctx)
+	cacheKey := fmt.Sprintf("cfg|%d", symbolID)
+	if cached, ok := s.cache.Get(generation, cacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeCFG(w, cached.(*index.CFGInfo), dot)
+		return
+	}
+
+	release, ok := s.acquireWorker(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Build the CFG; the SSA program is built once and shared across requests
 	builder := index.NewCFGBuilder(s.store)
-	cfg, err := builder.BuildCFG(symbolID)
+	if s.ssa != nil {
+		builder.UseSSACache(s.ssa, s.projectDir)
+	}
+	cfg, err := builder.BuildCFG(ctx, symbolID)
 	if err != nil {
+		if s.writeBudgetError(w, ctx) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to build CFG: %v", err))
 		return
 	}
+	s.cache.Put(generation, cacheKey, cfg)
 
-	writeJSON(w, http.StatusOK, cfg)
+	w.Header().Set("X-Cache", "MISS")
+	writeCFG(w, cfg, dot)
 }
 
+// writeCFG writes a CFG as JSON, or as a Graphviz document when dot is set.
+func writeCFG(w http.ResponseWriter, cfg *index.CFGInfo, dot bool) {
+	if !dot {
+		writeJSON(w, http.StatusOK, cfg)
+		return
+	}
+	w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(cfg.DOT()))
+}
@@ -1,12 +1,26 @@
 package server
 
 import (
+	"compress/flate"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/abramin/flowlens/internal/sarif"
 	"github.com/abramin/flowlens/internal/store"
+	"github.com/abramin/flowlens/internal/version"
 )
 
 func setupTestServer(t *testing.T) *Server {
@@ -18,7 +32,7 @@ func setupTestServer(t *testing.T) *Server {
 
 	// Insert test data
 	pkg := &store.Package{PkgPath: "myapp/handlers", Dir: "/handlers", Layer: "handler"}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatal(err)
 	}
 
@@ -29,8 +43,13 @@ func setupTestServer(t *testing.T) *Server {
 		File:    "user.go",
 		Line:    10,
 		Sig:     "func(w http.ResponseWriter, r *http.Request)",
+		Doc:     "GetUser returns the user with the requested ID.",
+		Signature: &store.Signature{Params: []store.Param{
+			{Name: "w", Type: "net/http.ResponseWriter"},
+			{Name: "r", Type: "*net/http.Request"},
+		}},
 	}
-	symID, err := st.InsertSymbol(sym)
+	symID, err := st.InsertSymbol(t.Context(), sym)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -41,11 +60,11 @@ func setupTestServer(t *testing.T) *Server {
 		SymbolID: symID,
 		MetaJSON: `{"method":"GET","path":"/api/users"}`,
 	}
-	if _, err := st.InsertEntrypoint(ep); err != nil {
+	if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
 		t.Fatal(err)
 	}
 
-	if err := st.InsertTag(&store.Tag{SymbolID: symID, Tag: "layer:handler", Reason: "Package path matches handler layer"}); err != nil {
+	if err := st.InsertTag(t.Context(), &store.Tag{SymbolID: symID, Tag: "layer:handler", Reason: "Package path matches handler layer"}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -70,12 +89,82 @@ func TestHandleHealth(t *testing.T) {
 		t.Errorf("expected status 200, got %d", w.Code)
 	}
 
-	var resp map[string]string
+	var resp HealthResponse
 	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
-	if resp["status"] != "ok" {
-		t.Errorf("expected status 'ok', got '%s'", resp["status"])
+	if resp.Status != "ok" {
+		t.Errorf("expected status 'ok', got '%s'", resp.Status)
+	}
+	if resp.ServerSchemaVersion != store.SchemaVersion {
+		t.Errorf("expected server schema version %d, got %d", store.SchemaVersion, resp.ServerSchemaVersion)
+	}
+	if resp.DBSizeBytes == 0 {
+		t.Error("expected non-zero DB size")
+	}
+	if resp.Reindex.Status != "idle" {
+		t.Errorf("expected idle reindex status, got %q", resp.Reindex.Status)
+	}
+}
+
+func TestHandleHealthFreshness(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	projectDir := t.TempDir()
+	src := filepath.Join(projectDir, "main.go")
+	if err := os.WriteFile(src, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.projectDir = projectDir
+
+	get := func() HealthResponse {
+		w := httptest.NewRecorder()
+		s.handleHealth(w, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+		var resp HealthResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	meta := map[string]string{
+		"indexed_at":       time.Now().Add(time.Hour).Format(time.RFC3339Nano),
+		"schema_version":   strconv.Itoa(store.SchemaVersion),
+		"index_status":     "running",
+		"index_started_at": "2026-01-01T00:00:00Z",
+	}
+	for k, v := range meta {
+		if err := s.store.SetMetadata(t.Context(), k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vcs := &store.VCSInfo{Commit: "0123456789abcdef0123456789abcdef01234567", Branch: "main", Dirty: true}
+	if err := s.store.SetVCSInfo(t.Context(), vcs); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := get()
+	if resp.VCS == nil || *resp.VCS != *vcs {
+		t.Errorf("expected revision %+v, got %+v", vcs, resp.VCS)
+	}
+	if resp.SourceNewerThanIndex {
+		t.Errorf("expected fresh index, found newer %s", resp.NewerSourceFile)
+	}
+	if resp.SchemaVersion != store.SchemaVersion {
+		t.Errorf("expected schema version %d, got %d", store.SchemaVersion, resp.SchemaVersion)
+	}
+	if resp.Reindex.Status != "running" || resp.Reindex.StartedAt == "" {
+		t.Errorf("expected running reindex with start time, got %+v", resp.Reindex)
+	}
+
+	// Source edited after the index was built
+	if err := s.store.SetMetadata(t.Context(), "indexed_at", time.Now().Add(-time.Hour).Format(time.RFC3339Nano)); err != nil {
+		t.Fatal(err)
+	}
+	resp = get()
+	if !resp.SourceNewerThanIndex || resp.NewerSourceFile != src {
+		t.Errorf("expected %s to be reported newer, got %+v", src, resp)
 	}
 }
 
@@ -177,6 +266,68 @@ func TestHandleEntrypointsWithFilter(t *testing.T) {
 	}
 }
 
+func TestHandleEntrypointsTree(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// Setup has GET /api/users
+	for _, ep := range []store.Entrypoint{
+		{Type: store.EntrypointHTTP, Label: "POST /api/users", MetaJSON: `{"method":"POST","path":"/api/users"}`},
+		{Type: store.EntrypointHTTP, Label: "GET /api/users/{id}", MetaJSON: `{"method":"GET","path":"/api/users/{id}"}`},
+		{Type: store.EntrypointHTTP, Label: "GET /healthz", MetaJSON: `{"method":"GET","path":"/healthz"}`},
+		{Type: store.EntrypointGRPC, Label: "users.UserService/Get", MetaJSON: `{"service":"users.UserService","method":"Get"}`},
+		{Type: store.EntrypointCLI, Label: "db migrate up", MetaJSON: `{"command":"db migrate up"}`},
+		{Type: store.EntrypointCLI, Label: "db migrate down", MetaJSON: `{"command":"db migrate down"}`},
+		{Type: store.EntrypointCLI, Label: "serve", MetaJSON: `{"command":"serve"}`},
+	} {
+		ep.SymbolID = 1
+		if _, err := s.store.InsertEntrypoint(t.Context(), &ep); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleEntrypoints(w, httptest.NewRequest(http.MethodGet, "/api/entrypoints?view=tree", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var tree EntrypointTree
+	if err := json.NewDecoder(w.Body).Decode(&tree); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	// describe renders a group as "name(count)[children]"
+	var describe func(g *EntrypointGroup) string
+	describe = func(g *EntrypointGroup) string {
+		out := fmt.Sprintf("%s(%d)", g.Name, g.Count)
+		if len(g.Groups) > 0 {
+			var children []string
+			for _, c := range g.Groups {
+				children = append(children, describe(c))
+			}
+			out += "[" + strings.Join(children, " ") + "]"
+		}
+		return out
+	}
+	var got []string
+	for _, g := range tree.Groups {
+		got = append(got, describe(g))
+	}
+	want := "http(4)[api/users(3)[{id}(1)] healthz(1)] grpc(1)[users.UserService(1)] cli(3)[db migrate(2)[down(1) up(1)] serve(1)]"
+	if strings.Join(got, " ") != want || tree.Count != 8 {
+		t.Errorf("got tree %q (count %d), want %q", strings.Join(got, " "), tree.Count, want)
+	}
+	if path := tree.Groups[0].Groups[0].Groups[0].Path; path != "/api/users/{id}" {
+		t.Errorf("expected path /api/users/{id}, got %q", path)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleEntrypoints(w, httptest.NewRequest(http.MethodGet, "/api/entrypoints?view=forest", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown view, got %d", w.Code)
+	}
+}
+
 func TestHandleSymbol(t *testing.T) {
 	s := setupTestServer(t)
 	defer s.store.Close()
@@ -201,6 +352,12 @@ func TestHandleSymbol(t *testing.T) {
 	if resp.Name != "GetUser" {
 		t.Errorf("expected name 'GetUser', got '%s'", resp.Name)
 	}
+	if resp.Doc != "GetUser returns the user with the requested ID." {
+		t.Errorf("expected the doc comment, got %q", resp.Doc)
+	}
+	if resp.Signature == nil || len(resp.Signature.Params) != 2 || resp.Signature.Params[1].Type != "*net/http.Request" {
+		t.Errorf("expected the structured signature, got %+v", resp.Signature)
+	}
 	if len(resp.Tags) != 1 {
 		t.Errorf("expected 1 tag, got %d", len(resp.Tags))
 	}
@@ -228,6 +385,50 @@ func TestHandleSymbolNotFound(t *testing.T) {
 	}
 }
 
+func TestHandleRepos(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// The fixture's package has no repo name; add a named repository
+	if err := s.store.InsertPackage(t.Context(), &store.Package{PkgPath: "example.com/lib/users", Module: "example.com/lib", Repo: "lib"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "example.com/lib/users", Name: "Find", Kind: store.SymbolKindFunc, File: "users/repo.go", Line: 3, Repo: "lib",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	libDir := filepath.Join(t.TempDir(), "lib")
+	if err := s.store.SetRepoDir(t.Context(), "lib", libDir); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleRepos(w, httptest.NewRequest(http.MethodGet, "/api/repos", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var repos []store.RepoInfo
+	if err := json.NewDecoder(w.Body).Decode(&repos); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repos, got %+v", repos)
+	}
+	if repos[0].Name != "" || repos[0].PackageCount != 1 || repos[0].SymbolCount != 1 {
+		t.Errorf("unexpected unnamed repo %+v", repos[0])
+	}
+	if repos[1].Name != "lib" || repos[1].Dir != libDir || len(repos[1].Modules) != 1 || repos[1].Modules[0] != "example.com/lib" {
+		t.Errorf("unexpected lib repo %+v", repos[1])
+	}
+
+	w = httptest.NewRecorder()
+	s.handleRepos(w, httptest.NewRequest(http.MethodPost, "/api/repos", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
+
 func TestHandleSearch(t *testing.T) {
 	s := setupTestServer(t)
 	defer s.store.Close()
@@ -253,6 +454,43 @@ func TestHandleSearch(t *testing.T) {
 	}
 }
 
+func TestHandleSearchStructured(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{"/api/search?tag=layer:handler", 1},
+		{"/api/search?layer=handler&kind=func", 1},
+		{"/api/search?file=user.go&sig_contains=ResponseWriter", 1},
+		{"/api/search?param_type=*net/http.Request", 1},
+		{"/api/search?param_type=ResponseWriter&result_type=error", 0},
+		{"/api/search?query=GetUser&tag=io:db", 0},
+		{"/api/search?layer=store", 0},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		w := httptest.NewRecorder()
+
+		s.handleSearch(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", tt.url, w.Code)
+		}
+
+		var results []store.SearchResult
+		if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tt.url, err)
+		}
+		if len(results) != tt.want {
+			t.Errorf("%s: expected %d results, got %d", tt.url, tt.want, len(results))
+		}
+	}
+}
+
 func TestHandleSearchNoQuery(t *testing.T) {
 	s := setupTestServer(t)
 	defer s.store.Close()
@@ -346,6 +584,216 @@ func TestHandleGraphWithFilters(t *testing.T) {
 	}
 }
 
+func TestHandleGraphStdlibAllow(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+	s.stdlibAllow = []string{"database/sql"}
+
+	// Handle -> net/http.Error, fmt.Sprintf, and (*sql.DB).Query -> driver.Open;
+	// the handler's module path has a dot, unlike the fixture's
+	ids := make(map[string]store.SymbolID)
+	for _, sym := range []*store.Symbol{
+		{PkgPath: "example.com/app", Name: "Handle", Kind: store.SymbolKindFunc},
+		{PkgPath: "net/http", Name: "Error", Kind: store.SymbolKindFunc},
+		{PkgPath: "fmt", Name: "Sprintf", Kind: store.SymbolKindFunc},
+		{PkgPath: "database/sql", Name: "Query", Kind: store.SymbolKindMethod, RecvType: "*DB"},
+		{PkgPath: "database/sql/driver", Name: "Open", Kind: store.SymbolKindFunc},
+	} {
+		if err := s.store.InsertPackage(t.Context(), &store.Package{PkgPath: sym.PkgPath}); err != nil {
+			t.Fatal(err)
+		}
+		id, err := s.store.InsertSymbol(t.Context(), sym)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[sym.Name] = id
+	}
+	for i, e := range [][2]string{{"Handle", "Error"}, {"Handle", "Sprintf"}, {"Handle", "Query"}, {"Query", "Open"}} {
+		edge := &store.CallEdge{CallerID: ids[e[0]], CalleeID: ids[e[1]], CallerFile: "f.go", CallerLine: i + 1, CallKind: store.CallKindStatic, Count: 1}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nodes := func(filters string) []string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleGraph(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/graph/root/%d?filters=", ids["Handle"])+url.QueryEscape(filters), nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp GraphResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		var names []string
+		for _, n := range resp.Nodes {
+			names = append(names, n.PkgPath+"."+n.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// The configured allowlist keeps database/sql, but not its subpackages
+	if got := nodes(`{"hideStdlib":true}`); !reflect.DeepEqual(got, []string{"database/sql.Query", "example.com/app.Handle"}) {
+		t.Errorf("expected only database/sql to be kept, got %v", got)
+	}
+	// A request's allowlist replaces it
+	if got := nodes(`{"hideStdlib":true,"stdlibAllow":["net/*"]}`); !reflect.DeepEqual(got, []string{"example.com/app.Handle", "net/http.Error"}) {
+		t.Errorf("expected only net/http to be kept, got %v", got)
+	}
+	if got := nodes(`{"hideStdlib":false}`); len(got) != 5 {
+		t.Errorf("expected every node without hideStdlib, got %v", got)
+	}
+}
+
+func TestGraphFromCallGraph(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// Handle -> Place (twice) -> NewRepo (wiring), Save -> database/sql.Exec
+	ids := make(map[string]store.SymbolID)
+	for _, sym := range []*store.Symbol{
+		{PkgPath: "example.com/shop/api", Name: "Handle", Kind: store.SymbolKindFunc},
+		{PkgPath: "example.com/shop/service", Name: "Place", Kind: store.SymbolKindFunc},
+		{PkgPath: "example.com/shop/repo", Name: "NewRepo", Kind: store.SymbolKindFunc},
+		{PkgPath: "example.com/shop/repo", Name: "Save", Kind: store.SymbolKindFunc},
+		{PkgPath: "database/sql", Name: "Exec", Kind: store.SymbolKindFunc},
+	} {
+		if err := s.store.InsertPackage(t.Context(), &store.Package{PkgPath: sym.PkgPath}); err != nil {
+			t.Fatal(err)
+		}
+		id, err := s.store.InsertSymbol(t.Context(), sym)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[sym.Name] = id
+	}
+	for i, e := range [][2]string{{"Handle", "Place"}, {"Handle", "Place"}, {"Place", "NewRepo"}, {"Place", "Save"}, {"Save", "Exec"}} {
+		edge := &store.CallEdge{CallerID: ids[e[0]], CalleeID: ids[e[1]], CallerFile: "f.go", CallerLine: i + 1, CallKind: store.CallKindStatic, Count: 1}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.store.InsertTag(t.Context(), &store.Tag{SymbolID: ids["Save"], Tag: "io:db"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.RebuildCallPairs(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	build := func(graph *store.CallGraph) *GraphResponse {
+		t.Helper()
+		builder := NewGraphBuilder(s.store, DefaultGraphFilter())
+		builder.SetCallGraph(graph)
+		resp, err := builder.BuildFromRoot(t.Context(), ids["Handle"], 5)
+		if err != nil {
+			t.Fatalf("BuildFromRoot failed: %v", err)
+		}
+		sort.Slice(resp.Nodes, func(i, j int) bool { return resp.Nodes[i].ID < resp.Nodes[j].ID })
+		sort.Slice(resp.Edges, func(i, j int) bool { return resp.Edges[i].TargetID < resp.Edges[j].TargetID })
+		return resp
+	}
+	spine := func(graph *store.CallGraph) *SpineResponse {
+		t.Helper()
+		builder := NewSpineBuilder(s.store, DefaultGraphFilter())
+		builder.SetCallGraph(graph)
+		resp, err := builder.BuildSpine(t.Context(), ids["Handle"], 5)
+		if err != nil {
+			t.Fatalf("BuildSpine failed: %v", err)
+		}
+		return resp
+	}
+	want, wantSpine := build(nil), spine(nil)
+
+	// Indexes over the limit are left to the store
+	s.graphs = newCallGraphs(t.Context(), s.store, 3)
+	s.graphs.get("small")
+	s.graphs.wait()
+	if s.graphs.get("small") != nil {
+		t.Fatal("expected no call graph for an index over the limit")
+	}
+
+	s.graphs = newCallGraphs(t.Context(), s.store, 0)
+	generation := s.indexGeneration(t.Context())
+	if s.graphs.get(generation) != nil {
+		t.Fatal("expected the call graph to load in the background")
+	}
+	s.graphs.wait()
+	graph := s.graphs.get(generation)
+	if graph == nil || graph.Edges != 4 || !graph.Pairs {
+		t.Fatalf("expected the call graph loaded with 4 call pairs, got %+v", graph)
+	}
+
+	// Builds from the call graph don't touch the index
+	s.store.Close()
+	if got := build(graph); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the same graph from memory as from the index:\n got %+v\nwant %+v", got, want)
+	}
+	if got := spine(graph); !reflect.DeepEqual(got, wantSpine) {
+		t.Errorf("expected the same spine from memory as from the index:\n got %+v\nwant %+v", got, wantSpine)
+	}
+
+	if s.graphs.get(generation+"|new") != nil {
+		t.Error("expected a new generation to drop the loaded graph")
+	}
+	s.graphs.wait()
+}
+
+func TestHandleGraphStopAtIODistance(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser -> service.Find (io:db@2) -> db.Query (io:db)
+	ids := map[string]store.SymbolID{"GetUser": 1}
+	for _, pkgPath := range []string{"myapp/service", "myapp/db"} {
+		if err := s.store.InsertPackage(t.Context(), &store.Package{PkgPath: pkgPath}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, sym := range []*store.Symbol{
+		{PkgPath: "myapp/service", Name: "Find", Kind: store.SymbolKindFunc, File: "service.go", Line: 5},
+		{PkgPath: "myapp/db", Name: "Query", Kind: store.SymbolKindFunc, File: "db.go", Line: 5},
+	} {
+		id, err := s.store.InsertSymbol(t.Context(), sym)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[sym.Name] = id
+	}
+	for _, e := range [][2]string{{"GetUser", "Find"}, {"Find", "Query"}} {
+		edge := &store.CallEdge{CallerID: ids[e[0]], CalleeID: ids[e[1]], CallerFile: "f.go", CallerLine: 1, CallKind: store.CallKindStatic, Count: 1}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, tag := range map[string]string{"Find": "io:db@2", "Query": "io:db"} {
+		if err := s.store.InsertTag(t.Context(), &store.Tag{SymbolID: ids[name], Tag: tag}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for filters, want := range map[string]int{
+		`{"maxDepth":5}`:                      3,
+		`{"maxDepth":5,"stopAtIO":true}`:      3,
+		`{"maxDepth":5,"stopAtIODistance":2}`: 2,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/graph/root/1?filters="+url.QueryEscape(filters), nil)
+		w := httptest.NewRecorder()
+		s.handleGraph(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", filters, w.Code, w.Body.String())
+		}
+		var resp GraphResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Nodes) != want {
+			t.Errorf("%s: got %d nodes, want %d", filters, len(resp.Nodes), want)
+		}
+	}
+}
+
 func TestCorsMiddleware(t *testing.T) {
 	s := setupTestServer(t)
 	defer s.store.Close()
@@ -366,6 +814,38 @@ func TestCorsMiddleware(t *testing.T) {
 	}
 }
 
+func TestReadOnlyServer(t *testing.T) {
+	s := setupTestServer(t)
+	dbPath := s.store.DBPath()
+	s.store.Close()
+
+	st, err := store.OpenReadOnly(dbPath, "")
+	if err != nil {
+		t.Fatalf("opening read-only store: %v", err)
+	}
+	defer st.Close()
+	s.store = st
+
+	w := httptest.NewRecorder()
+	s.corsMiddleware(s.handleBookmarks)(w, httptest.NewRequest(http.MethodPost, "/api/bookmarks",
+		strings.NewReader(`{"kind":"symbol","key":"myapp/handlers.GetUser"}`)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a write to a read-only index, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.corsMiddleware(s.handleEntrypoints)(w, httptest.NewRequest(http.MethodGet, "/api/entrypoints", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected reads to work on a read-only index, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	s.corsMiddleware(s.handleEntrypoints)(w, httptest.NewRequest(http.MethodPost, "/api/entrypoints", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for a POST to a read-only endpoint, got %d", w.Code)
+	}
+}
+
 func TestMethodNotAllowed(t *testing.T) {
 	s := setupTestServer(t)
 	defer s.store.Close()
@@ -380,3 +860,2745 @@ func TestMethodNotAllowed(t *testing.T) {
 		t.Errorf("expected status 405, got %d", w.Code)
 	}
 }
+
+func TestApplyLayout(t *testing.T) {
+	// Diamond with a shortcut: 1 -> 2 -> 3 -> 4 and 1 -> 4
+	newResp := func() *GraphResponse {
+		return &GraphResponse{
+			RootID: 1,
+			Nodes: []GraphNode{
+				{ID: 1, Name: "Root"},
+				{ID: 2, Name: "Service", Depth: 1},
+				{ID: 3, Name: "Store", Depth: 2},
+				{ID: 4, Name: "Query", Depth: 1},
+			},
+			Edges: []GraphEdge{
+				{SourceID: 1, TargetID: 2},
+				{SourceID: 2, TargetID: 3},
+				{SourceID: 3, TargetID: 4},
+				{SourceID: 1, TargetID: 4},
+			},
+		}
+	}
+
+	tests := []struct {
+		algo      LayoutAlgorithm
+		wantQuery float64 // Y coordinate of node 4
+	}{
+		{LayoutLayered, 1 * layoutRankSpacing},
+		{LayoutDagre, 3 * layoutRankSpacing},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.algo), func(t *testing.T) {
+			resp := newResp()
+			ApplyLayout(resp, tt.algo)
+
+			if resp.Layout != string(tt.algo) {
+				t.Errorf("expected layout %q, got %q", tt.algo, resp.Layout)
+			}
+			for _, n := range resp.Nodes {
+				if n.Position == nil {
+					t.Fatalf("node %d has no position", n.ID)
+				}
+			}
+			if resp.Nodes[0].Position.Y != 0 {
+				t.Errorf("expected root at y=0, got %v", resp.Nodes[0].Position.Y)
+			}
+			if got := resp.Nodes[3].Position.Y; got != tt.wantQuery {
+				t.Errorf("expected node 4 at y=%v, got %v", tt.wantQuery, got)
+			}
+		})
+	}
+}
+
+func TestHandleGraphWithLayout(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/graph/root/1?layout=layered", nil)
+	w := httptest.NewRecorder()
+
+	s.handleGraph(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp GraphResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Layout != "layered" {
+		t.Errorf("expected layout 'layered', got %q", resp.Layout)
+	}
+	if len(resp.Nodes) != 1 || resp.Nodes[0].Position == nil {
+		t.Fatal("expected root node with a position")
+	}
+
+	// Unknown layouts are rejected
+	req = httptest.NewRequest(http.MethodGet, "/api/graph/root/1?layout=circular", nil)
+	w = httptest.NewRecorder()
+
+	s.handleGraph(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHandleChanges(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := []store.Change{
+		{Entity: store.ChangeEntitySymbol, Kind: store.ChangeAdded, Key: "myapp/handlers.GetUser", SymbolID: 1, NewLocation: "user.go:10"},
+		{Entity: store.ChangeEntitySymbol, Kind: store.ChangeRemoved, Key: "myapp/handlers.DeleteUser", OldLocation: "user.go:40"},
+		{Entity: store.ChangeEntitySymbol, Kind: store.ChangeMoved, Key: "myapp/handlers.ListUsers", OldKey: "myapp/legacy.ListUsers", SymbolID: 1},
+		{Entity: store.ChangeEntityEntrypoint, Kind: store.ChangeAdded, Key: "http GET /api/users", SymbolID: 1},
+	}
+	for i := range changes {
+		if err := batch.InsertChange(t.Context(), &changes[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/changes?entity=symbol", nil)
+	w := httptest.NewRecorder()
+
+	s.handleChanges(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Summary map[string]int `json:"summary"`
+		Changes []store.Change `json:"changes"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Changes) != 3 {
+		t.Fatalf("expected 3 symbol changes, got %d", len(resp.Changes))
+	}
+	if resp.Summary["added"] != 1 || resp.Summary["removed"] != 1 || resp.Summary["moved"] != 1 {
+		t.Errorf("unexpected summary: %v", resp.Summary)
+	}
+	for _, c := range resp.Changes {
+		if c.Kind == store.ChangeMoved && c.OldKey != "myapp/legacy.ListUsers" {
+			t.Errorf("expected the moved symbol's old key, got %q", c.OldKey)
+		}
+	}
+
+	// Limit trims the list but not the summary
+	req = httptest.NewRequest(http.MethodGet, "/api/changes?limit=1", nil)
+	w = httptest.NewRecorder()
+	s.handleChanges(w, req)
+
+	resp.Changes = nil
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Changes) != 1 {
+		t.Errorf("expected 1 change with limit, got %d", len(resp.Changes))
+	}
+	if resp.Summary["added"] != 2 {
+		t.Errorf("expected summary to count 2 additions, got %d", resp.Summary["added"])
+	}
+}
+
+func TestAsOfSnapshot(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+	s.snapshots = newSnapshotServers(Config{})
+	defer s.snapshots.close()
+
+	if err := s.store.SetMetadata(t.Context(), "indexed_at", "2026-03-01T09:00:00.5Z"); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := s.store.RetainSnapshot(t.Context(), 3)
+	if err != nil {
+		t.Fatalf("RetainSnapshot failed: %v", err)
+	}
+	if snap.Name != "20260301T090000Z" {
+		t.Errorf("expected snapshot named by index time, got %q", snap.Name)
+	}
+
+	// The current index gains an entrypoint the snapshot doesn't have
+	eps, err := s.store.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.store.InsertEntrypoint(t.Context(), &store.Entrypoint{
+		Type: store.EntrypointHTTP, Label: "POST /api/users", SymbolID: eps[0].SymbolID,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := s.asOfMiddleware(http.HandlerFunc(s.handleEntrypoints))
+	for _, tc := range []struct {
+		asOf   string
+		status int
+		count  int
+	}{
+		{"", http.StatusOK, 2},
+		{"20260301T090000Z", http.StatusOK, 1},
+		{"2026-03-01T10:00:00Z", http.StatusOK, 1}, // Newest snapshot at or before
+		{"2026-03-01", http.StatusOK, 1},
+		{"2026-02-28", http.StatusNotFound, 0},
+		{"20250101T000000Z", http.StatusNotFound, 0},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/entrypoints?as_of="+url.QueryEscape(tc.asOf), nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("as_of=%q: expected status %d, got %d: %s", tc.asOf, tc.status, w.Code, w.Body.String())
+			continue
+		}
+		if tc.status != http.StatusOK {
+			continue
+		}
+		var got []store.EntrypointWithSymbol
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(got) != tc.count {
+			t.Errorf("as_of=%q: expected %d entrypoints, got %d", tc.asOf, tc.count, len(got))
+		}
+		if snapshot := w.Header().Get("X-FlowLens-Snapshot"); tc.asOf != "" && snapshot != snap.Name {
+			t.Errorf("as_of=%q: expected X-FlowLens-Snapshot %q, got %q", tc.asOf, snap.Name, snapshot)
+		}
+	}
+
+	// Snapshots are read-only
+	req := httptest.NewRequest(http.MethodPost, "/api/bookmarks?as_of="+snap.Name, strings.NewReader(`{"symbol_id":1}`))
+	w := httptest.NewRecorder()
+	s.asOfMiddleware(http.HandlerFunc(s.handleBookmarks)).ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected writes to a snapshot to be rejected, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/snapshots", nil)
+	w = httptest.NewRecorder()
+	s.handleSnapshots(w, req)
+	var list struct {
+		Snapshots []store.RetainedSnapshot `json:"snapshots"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list.Snapshots) != 1 || list.Snapshots[0].Name != snap.Name || list.Snapshots[0].Size == 0 {
+		t.Errorf("unexpected snapshots %+v", list.Snapshots)
+	}
+}
+
+func TestSnapshotServersEviction(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+	ss := newSnapshotServers(Config{})
+	ss.limit = 2
+	defer ss.close()
+
+	var snaps []*store.RetainedSnapshot
+	for _, at := range []string{"2026-03-01T09:00:00Z", "2026-03-02T09:00:00Z", "2026-03-03T09:00:00Z"} {
+		if err := s.store.SetMetadata(t.Context(), "indexed_at", at); err != nil {
+			t.Fatal(err)
+		}
+		snap, err := s.store.RetainSnapshot(t.Context(), 3)
+		if err != nil {
+			t.Fatalf("RetainSnapshot failed: %v", err)
+		}
+		snaps = append(snaps, snap)
+	}
+	closed := func(srv *Server) bool {
+		_, err := srv.store.GetMetadata(t.Context(), "indexed_at")
+		return err != nil
+	}
+
+	first, releaseFirst, err := ss.get(snaps[0])
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	second, release, err := ss.get(snaps[1])
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	release()
+
+	// The least recently used server is evicted, but stays open while in use
+	if _, release, err = ss.get(snaps[2]); err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	release()
+	if _, ok := ss.byName[snaps[0].Name]; ok || len(ss.byName) != 2 {
+		t.Errorf("expected the oldest snapshot server to be evicted, got %v", ss.byName)
+	}
+	if closed(first) {
+		t.Error("expected an evicted server in use to stay open")
+	}
+	releaseFirst()
+	if !closed(first) {
+		t.Error("expected an evicted server to be closed once released")
+	}
+
+	// Servers of pruned snapshots are closed
+	if err := store.RemoveFiles(snaps[1].Path); err != nil {
+		t.Fatal(err)
+	}
+	if _, release, err = ss.get(snaps[2]); err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	release()
+	if _, ok := ss.byName[snaps[1].Name]; ok || !closed(second) {
+		t.Error("expected the server of a removed snapshot to be closed")
+	}
+}
+
+func TestHandleBadge(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/badge.svg?metric=symbols", nil)
+	w := httptest.NewRecorder()
+
+	s.handleBadge(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("expected image/svg+xml, got %s", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "<title>symbols: 1</title>") {
+		t.Errorf("expected badge to show 1 symbol, got %s", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/badge.svg?metric=bogus", nil)
+	w = httptest.NewRecorder()
+	s.handleBadge(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown metric, got %d", w.Code)
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	c := newResponseCache(2)
+
+	c.Put("gen1", "a", 1)
+	c.Put("gen1", "b", 2)
+	if _, ok := c.Get("gen1", "a"); !ok {
+		t.Fatal("expected hit for a")
+	}
+
+	// b is now least recently used and gets evicted
+	c.Put("gen1", "c", 3)
+	if _, ok := c.Get("gen1", "b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if v, ok := c.Get("gen1", "c"); !ok || v.(int) != 3 {
+		t.Errorf("expected hit for c, got %v %v", v, ok)
+	}
+
+	// A new index generation invalidates everything
+	if _, ok := c.Get("gen2", "a"); ok {
+		t.Error("expected miss after generation change")
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected empty cache after generation change, got %d", c.Len())
+	}
+
+	// A nil cache is a no-op
+	var nilCache *responseCache
+	nilCache.Put("gen", "k", 1)
+	if _, ok := nilCache.Get("gen", "k"); ok {
+		t.Error("expected nil cache to miss")
+	}
+}
+
+func TestHandleGraphCached(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+	s.cache = newResponseCache(0)
+
+	get := func() string {
+		req := httptest.NewRequest(http.MethodGet, "/api/graph/root/1", nil)
+		w := httptest.NewRecorder()
+		s.handleGraph(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		return w.Header().Get("X-Cache")
+	}
+
+	if got := get(); got != "MISS" {
+		t.Errorf("expected first request to miss, got %q", got)
+	}
+	if got := get(); got != "HIT" {
+		t.Errorf("expected second request to hit, got %q", got)
+	}
+
+	// Re-indexing bumps indexed_at and invalidates the cache
+	if err := s.store.SetMetadata(t.Context(), "indexed_at", "2026-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(); got != "MISS" {
+		t.Errorf("expected miss after re-index, got %q", got)
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/entrypoints", nil)
+	w := httptest.NewRecorder()
+	s.handleEntrypoints(w, req)
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/entrypoints", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	s.handleEntrypoints(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for matching ETag, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Error("expected empty body for 304")
+	}
+
+	// Re-indexing changes the ETag
+	if err := s.store.SetMetadata(t.Context(), "indexed_at", "2026-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	s.handleStats(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 after re-index, got %d", w.Code)
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("expected ETag to change after re-index")
+	}
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"name":"GetUser"},`, 200)
+	handler := compressMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("small") != "" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(large))
+	}))
+
+	tests := []struct {
+		name           string
+		url            string
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{"gzip", "/", "gzip, deflate", "gzip"},
+		{"deflate only", "/", "deflate", "deflate"},
+		{"gzip refused", "/", "gzip;q=0, deflate", "deflate"},
+		{"no encoding", "/", "", ""},
+		{"below threshold", "/?small=1", "gzip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tt.wantEncoding, got)
+			}
+
+			var body io.Reader = w.Body
+			switch tt.wantEncoding {
+			case "gzip":
+				gz, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = gz
+			case "deflate":
+				body = flate.NewReader(w.Body)
+			}
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := large
+			if tt.url != "/" {
+				want = `{}`
+			}
+			if string(data) != want {
+				t.Errorf("body mismatch: got %d bytes, want %d", len(data), len(want))
+			}
+		})
+	}
+}
+
+func TestHandleGraphStream(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/graph/stream/1?depth=3", nil)
+	w := httptest.NewRecorder()
+	s.handleGraphStream(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expected NDJSON content type, got %q", ct)
+	}
+
+	var events []GraphStreamEvent
+	dec := json.NewDecoder(w.Body)
+	for dec.More() {
+		var ev GraphStreamEvent
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("decoding event: %v", err)
+		}
+		events = append(events, ev)
+	}
+	if len(events) == 0 {
+		t.Fatal("expected events")
+	}
+
+	// Every edge must follow its target node, and the stream ends with done
+	seen := make(map[store.SymbolID]bool)
+	nodes, edges := 0, 0
+	for _, ev := range events[:len(events)-1] {
+		switch ev.Type {
+		case "node":
+			seen[ev.Node.ID] = true
+			nodes++
+		case "edge":
+			if !seen[ev.Edge.SourceID] || !seen[ev.Edge.TargetID] {
+				t.Errorf("edge %d->%d sent before its nodes", ev.Edge.SourceID, ev.Edge.TargetID)
+			}
+			edges++
+		default:
+			t.Errorf("unexpected event type %q mid-stream", ev.Type)
+		}
+	}
+	last := events[len(events)-1]
+	if last.Type != "done" || last.Done == nil {
+		t.Fatalf("expected final done event, got %+v", last)
+	}
+	if last.Done.NodeCount != nodes || last.Done.EdgeCount != edges {
+		t.Errorf("summary %d nodes/%d edges, streamed %d/%d", last.Done.NodeCount, last.Done.EdgeCount, nodes, edges)
+	}
+	if nodes == 0 {
+		t.Error("expected the root node")
+	}
+
+	// Unknown symbols fail before the stream starts
+	req = httptest.NewRequest(http.MethodGet, "/api/graph/stream/99999", nil)
+	w = httptest.NewRecorder()
+	s.handleGraphStream(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestHandleGraphSizeGuard(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// Give GetUser (ID 1) a few callees
+	for _, name := range []string{"LoadUser", "CheckAuth", "Render"} {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: name, Kind: store.SymbolKindFunc, File: "user.go", Line: 20,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.store.InsertCallEdge(t.Context(), &store.CallEdge{
+			CallerID: 1, CalleeID: id, CallerFile: "user.go", CallerLine: 12, CallKind: store.CallKindStatic, Count: 1,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.limits = GraphLimits{MaxNodes: 2}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=3", nil)
+	w := httptest.NewRecorder()
+	s.handleGraph(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d", w.Code)
+	}
+	var resp GraphLimitError
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Limit != "nodes" || resp.Max != "2" {
+		t.Errorf("expected nodes limit of 2, got %q of %q", resp.Limit, resp.Max)
+	}
+	if resp.Message == "" {
+		t.Error("expected error message")
+	}
+	if len(resp.Suggestions) == 0 || resp.Suggestions[0] != "reduce depth (currently 3)" {
+		t.Errorf("expected depth suggestion first, got %v", resp.Suggestions)
+	}
+
+	// Within limits the graph builds normally
+	s.limits = GraphLimits{MaxNodes: 10}
+	w = httptest.NewRecorder()
+	s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=3", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 within limits, got %d", w.Code)
+	}
+
+	// Streams report the limit in their error event
+	s.limits = GraphLimits{MaxEdges: 1}
+	w = httptest.NewRecorder()
+	s.handleGraphStream(w, httptest.NewRequest(http.MethodGet, "/api/graph/stream/1", nil))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	var last GraphStreamEvent
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last.Type != "error" || last.Limit == nil || last.Limit.Limit != "edges" {
+		t.Errorf("expected edges limit error event, got %+v", last)
+	}
+}
+
+func TestRequestPool(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// Hold the only worker
+	s.pool = newRequestPool(1, 1, 20*time.Millisecond)
+	release, err := s.pool.acquire(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A request that waits too long for a worker is turned away
+	w := httptest.NewRecorder()
+	s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503 after the queue wait, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+
+	// With the queue full, further requests are turned away at once
+	s.pool.maxWait = 0
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		s.handleSpine(w, httptest.NewRequest(http.MethodGet, "/api/spine/1", nil))
+		done <- w.Code
+	}()
+	for s.pool.stats().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	w = httptest.NewRecorder()
+	s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503 with the queue full, got %d", w.Code)
+	}
+
+	// The queued request runs once the worker is free
+	release()
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expected queued request to succeed, got %d", code)
+	}
+
+	// Requests over their query budget are stopped
+	s.budget = time.Nanosecond
+	w = httptest.NewRecorder()
+	s.budgetMiddleware(http.HandlerFunc(s.handleGraph)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "budget") {
+		t.Errorf("expected budget 503, got %d: %s", w.Code, w.Body.String())
+	}
+
+	st := s.pool.stats()
+	if st.Workers != 1 || st.Active != 0 || st.Queued != 0 {
+		t.Errorf("expected 1 idle worker, got %+v", st)
+	}
+	if st.Served != 3 || st.Rejected != 1 || st.TimedOut != 1 || st.BudgetExceeded != 1 {
+		t.Errorf("unexpected counters: %+v", st)
+	}
+	if st.MaxWaitMs <= 0 {
+		t.Errorf("expected the queued request's wait to be recorded, got %+v", st)
+	}
+}
+
+func TestIdleShutdown(t *testing.T) {
+	idle := newIdleTracker(time.Hour)
+	if idle.idle(time.Now()) {
+		t.Error("expected a new server not to be idle")
+	}
+	if !idle.idle(time.Now().Add(time.Hour)) {
+		t.Error("expected idle after the timeout without requests")
+	}
+
+	// A running request keeps the server busy, and resets the timeout
+	started, finish := make(chan struct{}), make(chan struct{})
+	handler := idle.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+	}))
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	<-started
+	if idle.idle(time.Now().Add(2 * time.Hour)) {
+		t.Error("expected not idle while a request runs")
+	}
+	close(finish)
+	for idle.inFlight.Load() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if idle.idle(time.Now().Add(time.Minute)) || !idle.idle(time.Now().Add(time.Hour)) {
+		t.Error("expected the timeout to restart when the request finished")
+	}
+
+	// Without a timeout the server is never idle
+	if newIdleTracker(0).idle(time.Now().Add(24 * time.Hour)) {
+		t.Error("expected no idle shutdown without a timeout")
+	}
+	short := newIdleTracker(20 * time.Millisecond)
+	select {
+	case <-short.wait(t.Context().Done()):
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the idle channel to close")
+	}
+}
+
+func TestPIDFile(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	s.pidFile = PIDFilePath(s.store.DBPath())
+	if pf, err := ReadPIDFile(s.pidFile); err != nil || pf != nil {
+		t.Fatalf("expected no pidfile, got %+v, %v", pf, err)
+	}
+	if err := s.writePIDFile(4242); err != nil {
+		t.Fatal(err)
+	}
+	pf, err := ReadPIDFile(s.pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf == nil || pf.PID != os.Getpid() || pf.Port != 4242 || pf.URL != "http://localhost:4242" {
+		t.Fatalf("unexpected pidfile %+v", pf)
+	}
+
+	s.removePIDFile()
+	if _, err := os.Stat(s.pidFile); !os.IsNotExist(err) {
+		t.Errorf("expected the pidfile to be removed, got %v", err)
+	}
+}
+
+func TestHandleGraphResolvedBy(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser (ID 1) calls one function directly and one through an interface
+	edges := map[string]*store.CallEdge{
+		"LoadUser": {CallKind: store.CallKindStatic},
+		"Render":   {CallKind: store.CallKindInterface, ResolvedBy: store.ResolvedInterfaceHeuristic},
+	}
+	want := make(map[store.SymbolID]store.ResolvedBy)
+	for name, edge := range edges {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: name, Kind: store.SymbolKindFunc, File: "user.go", Line: 20,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		edge.CallerID, edge.CalleeID, edge.CallerFile, edge.CallerLine, edge.Count = 1, id, "user.go", 12, 1
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+		want[id] = edge.ResolvedBy
+		if want[id] == "" {
+			want[id] = store.ResolvedSSAStatic // Unset provenance defaults to SSA
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp GraphResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Edges) != len(want) {
+		t.Fatalf("expected %d edges, got %d", len(want), len(resp.Edges))
+	}
+	for _, e := range resp.Edges {
+		if e.ResolvedBy != want[e.TargetID] {
+			t.Errorf("edge to %d: expected resolved_by %q, got %q", e.TargetID, want[e.TargetID], e.ResolvedBy)
+		}
+	}
+}
+
+func TestHandleGraphCleanupLane(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser (ID 1) loads the user and defers closing the transaction
+	edges := map[string]store.CallKind{"LoadUser": store.CallKindStatic, "Close": store.CallKindDefer}
+	ids := make(map[string]store.SymbolID)
+	for name, kind := range edges {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: name, Kind: store.SymbolKindFunc, File: "user.go", Line: 20,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = id
+		edge := &store.CallEdge{CallerID: 1, CalleeID: id, CallKind: kind, CallerFile: "user.go", CallerLine: 12, Count: 1}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkCleanup := func(t *testing.T, cleanup []CleanupSection) {
+		t.Helper()
+		if len(cleanup) != 1 || cleanup[0].FunctionID != 1 || len(cleanup[0].Calls) != 1 || cleanup[0].Calls[0].ID != ids["Close"] {
+			t.Errorf("expected Close in GetUser's cleanup section, got %+v", cleanup)
+		}
+	}
+	filters := `{"cleanupLane":true}`
+
+	w := httptest.NewRecorder()
+	s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=1&filters="+filters, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var graph GraphResponse
+	if err := json.NewDecoder(w.Body).Decode(&graph); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(graph.Edges) != 1 || graph.Edges[0].TargetID != ids["LoadUser"] {
+		t.Errorf("expected only the LoadUser edge in the flow, got %+v", graph.Edges)
+	}
+	for _, n := range graph.Nodes {
+		if n.ID == ids["Close"] {
+			t.Errorf("expected no node for the deferred call")
+		}
+	}
+	checkCleanup(t, graph.Cleanup)
+
+	w = httptest.NewRecorder()
+	s.handleSpine(w, httptest.NewRequest(http.MethodGet, "/api/spine/1?filters="+filters, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var spine SpineResponse
+	if err := json.NewDecoder(w.Body).Decode(&spine); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(spine.MainPath) != 2 || spine.MainPath[1] != int64(ids["LoadUser"]) || spine.CollapsedCount != 0 {
+		t.Errorf("expected a GetUser -> LoadUser spine with nothing collapsed, got %+v", spine)
+	}
+	checkCleanup(t, spine.Cleanup)
+
+	// Without the option deferred calls stay in the flow
+	w = httptest.NewRecorder()
+	s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=1", nil))
+	graph = GraphResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&graph); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(graph.Edges) != 2 || graph.Cleanup != nil {
+		t.Errorf("expected both edges and no cleanup section, got %+v", graph)
+	}
+}
+
+func TestHandleSpineRouting(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser calls A and B on the same line with equal scores; A -> D -> E, B -> C
+	ids := map[string]store.SymbolID{"GetUser": 1}
+	if err := s.store.InsertPackage(t.Context(), &store.Package{PkgPath: "example.com/app/svc"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"B", "A", "C", "D", "E"} {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{PkgPath: "example.com/app/svc", Name: name, Kind: store.SymbolKindFunc, File: "svc.go", Line: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = id
+	}
+	for _, e := range [][2]string{{"GetUser", "B"}, {"GetUser", "A"}, {"A", "D"}, {"B", "C"}, {"D", "E"}} {
+		edge := &store.CallEdge{CallerID: ids[e[0]], CalleeID: ids[e[1]], CallerFile: "f.go", CallerLine: 3, CallKind: store.CallKindStatic, Count: 1}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spine := func(query string) SpineResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleSpine(w, httptest.NewRequest(http.MethodGet, "/api/spine/1"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var spine SpineResponse
+		if err := json.NewDecoder(w.Body).Decode(&spine); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return spine
+	}
+	mainPath := func(query string) []int64 {
+		t.Helper()
+		return spine(query).MainPath
+	}
+	path := func(names ...string) []int64 {
+		out := []int64{1}
+		for _, name := range names {
+			out = append(out, int64(ids[name]))
+		}
+		return out
+	}
+
+	// Ties are broken by name, whatever order the calls were indexed in
+	for range 5 {
+		if got, want := mainPath(""), path("A", "D", "E"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected main path %v, got %v", want, got)
+		}
+	}
+
+	seed := fmt.Sprintf("?seedPath=%d", ids["B"])
+	if got, want := mainPath(seed), path("B", "C"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the seed to pin main path %v, got %v", want, got)
+	}
+
+	// Scoring takes over where the seed leaves the call graph
+	seed = fmt.Sprintf("?seedPath=1,%d,%d", ids["B"], ids["D"])
+	if got, want := mainPath(seed), path("B", "C"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected main path %v, got %v", want, got)
+	}
+
+	// via reroutes the main path through a symbol below another branch
+	if got, want := mainPath(fmt.Sprintf("?via=%d", ids["C"])), path("B", "C"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected main path %v routed through C, got %v", want, got)
+	}
+	if got, want := mainPath(fmt.Sprintf("?via=%d", ids["D"])), path("A", "D", "E"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected scoring to continue past D to %v, got %v", want, got)
+	}
+	got := spine(fmt.Sprintf("?via=%d,%d", ids["C"], ids["E"]))
+	if want := path("B", "C"); !reflect.DeepEqual(got.MainPath, want) || !reflect.DeepEqual(got.SkippedVia, []int64{int64(ids["E"])}) {
+		t.Errorf("expected main path %v skipping unreachable E, got %v skipping %v", want, got.MainPath, got.SkippedVia)
+	}
+
+	for _, query := range []string{"?seedPath=B", "?via=1,x"} {
+		w := httptest.NewRecorder()
+		s.handleSpine(w, httptest.NewRequest(http.MethodGet, "/api/spine/1"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestHandleGraphCollapseNoise(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser (ID 1) logs twice around loading the user
+	if err := s.store.InsertPackage(t.Context(), &store.Package{PkgPath: "log/slog"}); err != nil {
+		t.Fatal(err)
+	}
+	calls := []struct {
+		pkg, name string
+		line      int
+	}{
+		{"log/slog", "Info", 11},
+		{"myapp/handlers", "LoadUser", 12},
+		{"log/slog", "Debug", 13},
+	}
+	ids := make(map[string]store.SymbolID)
+	for _, c := range calls {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: c.pkg, Name: c.name, Kind: store.SymbolKindFunc, File: "f.go", Line: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[c.name] = id
+		edge := &store.CallEdge{CallerID: 1, CalleeID: id, CallKind: store.CallKindStatic, CallerFile: "user.go", CallerLine: c.line, Count: 1}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filters := url.QueryEscape(`{"noisePackages":["log/slog"],"collapseNoise":true}`)
+
+	w := httptest.NewRecorder()
+	s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=1&filters="+filters, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var graph GraphResponse
+	if err := json.NewDecoder(w.Body).Decode(&graph); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	var noise *GraphNode
+	for i, n := range graph.Nodes {
+		if n.ID == ids["Info"] || n.ID == ids["Debug"] {
+			t.Errorf("expected no node for noise call %s", n.Name)
+		}
+		if n.Noise != nil {
+			noise = &graph.Nodes[i]
+		}
+	}
+	if noise == nil || noise.ID != -1 || noise.Name != "2 observability calls" ||
+		len(noise.Noise.Packages) != 1 || len(noise.Noise.Labels) != 2 {
+		t.Fatalf("expected a pseudo-node for both log calls, got %+v", noise)
+	}
+	if len(graph.Edges) != 2 || graph.Filtered != 0 {
+		t.Errorf("expected edges to LoadUser and the noise node, got %+v", graph)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleSpine(w, httptest.NewRequest(http.MethodGet, "/api/spine/1?filters="+filters, nil))
+	var spine SpineResponse
+	if err := json.NewDecoder(w.Body).Decode(&spine); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(spine.Nodes) == 0 || spine.Nodes[0].Noise == nil || spine.Nodes[0].Noise.Count != 2 {
+		t.Errorf("expected two noise calls on the spine root, got %+v", spine.Nodes)
+	}
+
+	// Without collapsing, noise calls are hidden
+	w = httptest.NewRecorder()
+	filters = url.QueryEscape(`{"noisePackages":["log/slog"]}`)
+	s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=1&filters="+filters, nil))
+	graph = GraphResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&graph); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(graph.Nodes) != 2 || graph.Filtered != 2 {
+		t.Errorf("expected noise calls to be filtered, got %+v", graph)
+	}
+}
+
+func TestHandleGraphCallOrder(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser (ID 1) calls Validate, then Load, then Audit three times in a loop
+	calls := []struct {
+		name        string
+		line, count int
+	}{
+		{"Load", 12, 1},
+		{"Audit", 13, 3},
+		{"Validate", 11, 1},
+	}
+	ids := make(map[string]store.SymbolID)
+	for _, c := range calls {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: c.name, Kind: store.SymbolKindFunc, File: "f.go", Line: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[c.name] = id
+		edge := &store.CallEdge{CallerID: 1, CalleeID: id, CallKind: store.CallKindStatic, CallerFile: "user.go", CallerLine: c.line, Count: c.count}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	graph := func(filters string) GraphResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=1&filters="+url.QueryEscape(filters), nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var graph GraphResponse
+		if err := json.NewDecoder(w.Body).Decode(&graph); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return graph
+	}
+	targets := func(graph GraphResponse) (names []string, order []int) {
+		for _, e := range graph.Edges {
+			for name, id := range ids {
+				if e.TargetID == id {
+					names = append(names, name)
+				}
+			}
+			order = append(order, e.Order)
+		}
+		return names, order
+	}
+
+	// Edges and nodes come in source order, every time
+	for range 5 {
+		g := graph(`{}`)
+		if names, order := targets(g); !reflect.DeepEqual(names, []string{"Validate", "Load", "Audit"}) || !reflect.DeepEqual(order, []int{1, 2, 3}) {
+			t.Fatalf("expected edges in source order, got %v %v", names, order)
+		}
+		if len(g.Nodes) != 4 || g.Nodes[0].ID != 1 || g.Nodes[1].ID != ids["Validate"] || g.Nodes[3].ID != ids["Audit"] {
+			t.Fatalf("expected the root then its callees in source order, got %+v", g.Nodes)
+		}
+	}
+
+	// By count, keeping each call's source position
+	if names, order := targets(graph(`{"orderBy":"count"}`)); !reflect.DeepEqual(names, []string{"Audit", "Validate", "Load"}) || !reflect.DeepEqual(order, []int{3, 1, 2}) {
+		t.Errorf("expected edges by call count, got %v %v", names, order)
+	}
+
+	// Spine branches follow the same order
+	badge := func(filters string) []int64 {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleSpine(w, httptest.NewRequest(http.MethodGet, "/api/spine/1?filters="+url.QueryEscape(filters), nil))
+		var spine SpineResponse
+		if err := json.NewDecoder(w.Body).Decode(&spine); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(spine.Nodes) == 0 || spine.Nodes[0].BranchBadge == nil {
+			t.Fatalf("expected a branch badge on the spine root, got %+v", spine.Nodes)
+		}
+		return spine.Nodes[0].BranchBadge.CollapsedIDs
+	}
+	if got, want := badge(`{}`), []int64{int64(ids["Load"]), int64(ids["Audit"])}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected branches %v in source order, got %v", want, got)
+	}
+	if got, want := badge(`{"orderBy":"count"}`), []int64{int64(ids["Audit"]), int64(ids["Load"])}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected branches %v by call count, got %v", want, got)
+	}
+}
+
+func TestHandleGraphCollapseWiring(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser (ID 1) -> NewService -> NewRepo -> wire.Build, and NewService -> Lookup
+	if err := s.store.InsertPackage(t.Context(), &store.Package{PkgPath: wirePackage}); err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]store.SymbolID{"GetUser": 1}
+	for _, sym := range []*store.Symbol{
+		{PkgPath: "myapp/handlers", Name: "NewService", Kind: store.SymbolKindFunc, File: "wire.go", Line: 1},
+		{PkgPath: "myapp/handlers", Name: "NewRepo", Kind: store.SymbolKindFunc, File: "wire.go", Line: 5},
+		{PkgPath: "myapp/handlers", Name: "Lookup", Kind: store.SymbolKindFunc, File: "wire.go", Line: 9},
+		{PkgPath: wirePackage, Name: "Build", Kind: store.SymbolKindFunc, File: "wire.go", Line: 1},
+	} {
+		id, err := s.store.InsertSymbol(t.Context(), sym)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[sym.Name] = id
+	}
+	for _, e := range [][2]string{{"GetUser", "NewService"}, {"NewService", "NewRepo"}, {"NewRepo", "Build"}, {"NewService", "Lookup"}} {
+		edge := &store.CallEdge{CallerID: ids[e[0]], CalleeID: ids[e[1]], CallKind: store.CallKindStatic, CallerFile: "wire.go", CallerLine: 2, Count: 1}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	graph := func(filters string) GraphResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=5&filters="+url.QueryEscape(filters), nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp GraphResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	resp := graph(`{"collapseWiring":true}`)
+	if len(resp.Nodes) != 2 {
+		t.Fatalf("expected GetUser and the folded NewService, got %+v", resp.Nodes)
+	}
+	for _, n := range resp.Nodes {
+		if n.ID != ids["NewService"] {
+			continue
+		}
+		if n.Wiring == nil || n.Wiring.Count != 2 || n.Wiring.Labels[0] != "NewRepo" || n.Wiring.Labels[1] != "wire.Build" {
+			t.Errorf("expected NewRepo and wire.Build folded into NewService, got %+v", n.Wiring)
+		}
+	}
+
+	if resp := graph(`{"collapseWiring":false}`); len(resp.Nodes) != 5 {
+		t.Errorf("expected the whole chain without collapsing, got %d nodes", len(resp.Nodes))
+	}
+}
+
+func TestHandleEdges(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	calleeID, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "AuditUser", Kind: store.SymbolKindFunc, File: "audit.go", Line: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	graphEdges := func() []GraphEdge {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=1", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		var resp GraphResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp.Edges
+	}
+	if edges := graphEdges(); len(edges) != 0 {
+		t.Fatalf("expected no edges before asserting one, got %d", len(edges))
+	}
+
+	body := fmt.Sprintf(`{"caller_id":1,"callee_id":%d,"note":"called via reflection"}`, calleeID)
+	w := httptest.NewRecorder()
+	s.handleEdges(w, httptest.NewRequest(http.MethodPost, "/api/edges", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created store.ManualEdge
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.CallerKey != "myapp/handlers.GetUser" || created.CallKind != store.CallKindStatic {
+		t.Errorf("unexpected manual edge %+v", created)
+	}
+
+	// The cached graph is rebuilt with the manual edge
+	edges := graphEdges()
+	if len(edges) != 1 || edges[0].TargetID != calleeID || edges[0].ResolvedBy != store.ResolvedManual {
+		t.Fatalf("expected a manual edge to %d, got %+v", calleeID, edges)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleEdges(w, httptest.NewRequest(http.MethodGet, "/api/edges", nil))
+	var list ManualEdgesResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list.Edges) != 1 || list.Edges[0].Note != "called via reflection" {
+		t.Errorf("expected the manual edge to be listed, got %+v", list.Edges)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"invalid call kind", http.MethodPost, "/api/edges", `{"caller_id":1,"callee_id":1,"call_kind":"magic"}`, http.StatusBadRequest},
+		{"unknown symbol", http.MethodPost, "/api/edges", `{"caller_id":1,"callee_id":999}`, http.StatusNotFound},
+		{"delete missing ID", http.MethodDelete, "/api/edges?caller_id=1", "", http.StatusBadRequest},
+		{"delete", http.MethodDelete, fmt.Sprintf("/api/edges?caller_id=1&callee_id=%d", calleeID), "", http.StatusNoContent},
+		{"delete again", http.MethodDelete, fmt.Sprintf("/api/edges?caller_id=1&callee_id=%d", calleeID), "", http.StatusNotFound},
+		{"method not allowed", http.MethodPut, "/api/edges", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleEdges(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if edges := graphEdges(); len(edges) != 0 {
+		t.Errorf("expected the manual edge to be gone, got %+v", edges)
+	}
+}
+
+func TestHandleBookmarks(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	eps, err := s.store.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil || len(eps) == 0 {
+		t.Fatalf("expected entrypoints, got %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.handleBookmarks(w, httptest.NewRequest(http.MethodPost, "/api/bookmarks", strings.NewReader(body)))
+		return w
+	}
+	if w := post(`{"kind":"symbol","id":1,"note":"start here"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201 pinning a symbol, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post(fmt.Sprintf(`{"kind":"entrypoint","id":%d}`, eps[0].ID)); w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201 starring an entrypoint, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post(`{"kind":"symbol","id":999}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown symbol, got %d", w.Code)
+	}
+	if w := post(`{"kind":"package","id":1}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid kind, got %d", w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleBookmarks(w, httptest.NewRequest(http.MethodGet, "/api/bookmarks", nil))
+	var resp BookmarksResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := []store.Bookmark{
+		{Kind: store.BookmarkSymbol, Key: "myapp/handlers.GetUser", ID: 1, Note: "start here"},
+		{Kind: store.BookmarkEntrypoint, Key: "http GET /api/users", ID: int64(eps[0].ID)},
+	}
+	if len(resp.Bookmarks) != len(want) {
+		t.Fatalf("expected %d bookmarks, got %+v", len(want), resp.Bookmarks)
+	}
+	for i, b := range resp.Bookmarks {
+		b.CreatedAt = ""
+		if b != want[i] {
+			t.Errorf("bookmark %d: expected %+v, got %+v", i, want[i], b)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	s.handleBookmarks(w, httptest.NewRequest(http.MethodDelete, "/api/bookmarks?kind=symbol&key=myapp/handlers.GetUser", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	s.handleBookmarks(w, httptest.NewRequest(http.MethodDelete, "/api/bookmarks?kind=symbol&key=myapp/handlers.GetUser", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 deleting twice, got %d", w.Code)
+	}
+}
+
+func TestHandleViews(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"create", `{"name":"login flow","state":{"entrypointId":1,"expandedNodeIds":[1]}}`, http.StatusCreated},
+		{"replace", `{"name":"login flow","state":{"entrypointId":1,"expandedNodeIds":[1,2]}}`, http.StatusOK},
+		{"missing name", `{"name":" ","state":{}}`, http.StatusBadRequest},
+		{"state not an object", `{"name":"bad","state":[1,2]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleViews(w, httptest.NewRequest(http.MethodPost, "/api/views", strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	s.handleViews(w, httptest.NewRequest(http.MethodGet, "/api/views", nil))
+	var resp ViewsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Views) != 1 || resp.Views[0].Name != "login flow" {
+		t.Fatalf("expected one saved view, got %+v", resp.Views)
+	}
+	if got := string(resp.Views[0].State); got != `{"entrypointId":1,"expandedNodeIds":[1,2]}` {
+		t.Errorf("expected the replaced state, got %s", got)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleViews(w, httptest.NewRequest(http.MethodDelete, "/api/views?name=login+flow", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+}
+
+func TestHandleShare(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	share := func(body string) (*httptest.ResponseRecorder, store.Share) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleShare(w, httptest.NewRequest(http.MethodPost, "/api/share", strings.NewReader(body)))
+		var resp store.Share
+		if w.Code == http.StatusCreated {
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return w, resp
+	}
+
+	w, first := share(`{"state": {"entrypointId": 1, "expandedNodeIds": [1, 2, 3]}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(first.ID) != 8 {
+		t.Errorf("expected an 8 character ID, got %q", first.ID)
+	}
+
+	// The same state, formatted differently, shares the same link
+	if _, again := share(`{"state":{"entrypointId":1,"expandedNodeIds":[1,2,3]}}`); again.ID != first.ID {
+		t.Errorf("expected the same ID for the same state, got %q and %q", first.ID, again.ID)
+	}
+	if _, other := share(`{"state":{"entrypointId":2}}`); other.ID == first.ID {
+		t.Error("expected a different ID for a different state")
+	}
+	if w, _ := share(`{"state":"not an object"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a non-object state, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleShareByID(w, httptest.NewRequest(http.MethodGet, "/api/share/"+first.ID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var got store.Share
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if string(got.State) != `{"entrypointId":1,"expandedNodeIds":[1,2,3]}` {
+		t.Errorf("unexpected restored state %s", got.State)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleShareByID(w, httptest.NewRequest(http.MethodGet, "/api/share/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHandleVersion(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	w := httptest.NewRecorder()
+	s.handleVersion(w, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp version.Info
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Version == "" || resp.Commit == "" || resp.GoVersion == "" {
+		t.Errorf("expected populated build info, got %+v", resp)
+	}
+	if resp.SchemaVersion != store.SchemaVersion {
+		t.Errorf("expected schema version %d, got %d", store.SchemaVersion, resp.SchemaVersion)
+	}
+}
+
+func TestHandleTaintReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	eps, err := s.store.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil || len(eps) == 0 {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []store.TaintFinding{
+		{EntrypointID: eps[0].ID, Source: "r *net/http.Request", Sink: "os/exec.Command", SinkCategory: store.SinkExec, File: "user.go", Line: 14, Path: []string{"myapp/handlers.GetUser"}},
+		{EntrypointID: eps[0].ID, Source: "r *net/http.Request", Sink: "database/sql.DB.Query", SinkCategory: store.SinkSQL, File: "user.go", Line: 20, Path: []string{"myapp/handlers.GetUser", "myapp/store.Find"}},
+	} {
+		if err := batch.InsertTaintFinding(t.Context(), &f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleTaintReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/taint", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var report TaintReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.FindingCount != 2 || report.ByCategory["exec"] != 1 || report.ByCategory["sql"] != 1 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+	if len(report.Entrypoints) != 1 || report.Entrypoints[0].Label != "GET /api/users" {
+		t.Fatalf("expected findings grouped under GET /api/users, got %+v", report.Entrypoints)
+	}
+	if findings := report.Entrypoints[0].Findings; len(findings) != 2 || len(findings[1].Path) != 2 {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestHandleAuthReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+	// Stored paths resolve against the project the index belongs to
+	s.projectDir = filepath.Dir(filepath.Dir(s.store.DBPath()))
+
+	eps, err := s.store.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil || len(eps) == 0 {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := &store.AuthCheck{EntrypointID: eps[0].ID, Status: store.AuthMissing, Middleware: []string{"middleware.Logger"}}
+	if err := batch.InsertAuthCheck(t.Context(), check); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleAuthReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/auth?status=missing", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report AuthReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.MissingCount != 1 || len(report.Checks) != 1 || report.Checks[0].EntrypointLabel != "GET /api/users" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	// SARIF carries one result at the handler's location
+	w = httptest.NewRecorder()
+	s.handleAuthReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/auth?format=sarif", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var sarifLog sarif.Log
+	if err := json.NewDecoder(w.Body).Decode(&sarifLog); err != nil {
+		t.Fatalf("failed to decode SARIF: %v", err)
+	}
+	if sarifLog.Version != "2.1.0" || len(sarifLog.Runs) != 1 || len(sarifLog.Runs[0].Results) != 1 {
+		t.Fatalf("unexpected SARIF log: %+v", sarifLog)
+	}
+	result := sarifLog.Runs[0].Results[0]
+	loc := result.Locations[0].PhysicalLocation
+	if result.RuleID != sarif.RuleMissingAuth || loc.ArtifactLocation.URI != "user.go" || loc.Region.StartLine != eps[0].Symbol.Line {
+		t.Errorf("unexpected SARIF result: %+v", result)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleAuthReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/auth?status=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid status, got %d", w.Code)
+	}
+}
+
+func TestHandleDependencyReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	eps, err := s.store.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil || len(eps) == 0 {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	if err := s.store.SetMetadata(t.Context(), "dependencies_indexed", "true"); err != nil {
+		t.Fatal(err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []store.ExternalCall{
+		{CallerID: eps[0].SymbolID, Module: "github.com/stripe/stripe-go/v76", Version: "v76.1.0", PkgPath: "github.com/stripe/stripe-go/v76/charge", Func: "github.com/stripe/stripe-go/v76/charge.New", CallerFile: "user.go", CallerLine: 12},
+		{CallerID: eps[0].SymbolID, Module: "github.com/google/uuid", Version: "v1.6.0", PkgPath: "github.com/google/uuid", Func: "github.com/google/uuid.New", CallerFile: "user.go", CallerLine: 11},
+	} {
+		if err := batch.InsertExternalCall(t.Context(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleDependencyReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/dependencies", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report DependencyReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !report.Indexed || len(report.Entrypoints) != 1 || len(report.Entrypoints[0].Modules) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if labels := report.ByModule["github.com/google/uuid"]; len(labels) != 1 || labels[0] != "GET /api/users" {
+		t.Errorf("unexpected by_module: %+v", report.ByModule)
+	}
+
+	// Scoping to one module drops the others
+	w = httptest.NewRecorder()
+	s.handleDependencyReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/dependencies?module=github.com/google/uuid", nil))
+	report = DependencyReport{}
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(report.Entrypoints) != 1 || len(report.Entrypoints[0].Modules) != 1 || len(report.ByModule) != 1 {
+		t.Errorf("expected one module after scoping, got %+v", report)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleDependencyReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/dependencies?module=example.com/unused", nil))
+	report = DependencyReport{}
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(report.Entrypoints) != 0 {
+		t.Errorf("expected no entrypoints for an unused module, got %+v", report.Entrypoints)
+	}
+}
+
+func TestHandleDiagnostics(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []store.Diagnostic{
+		{PkgPath: "myapp/handlers", File: "user.go", Line: 3, Column: 2, Kind: "type", Severity: store.SeverityWarning, Message: "undefined: db"},
+		{PkgPath: "myapp/legacy", File: "old.go", Line: 1, Kind: "parse", Severity: store.SeverityError, Message: "expected 'package', found 'EOF'"},
+	} {
+		if err := batch.InsertDiagnostic(t.Context(), &d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleDiagnostics(w, httptest.NewRequest(http.MethodGet, "/api/diagnostics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp DiagnosticsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	// Errors come first
+	if len(resp.Diagnostics) != 2 || resp.Diagnostics[0].PkgPath != "myapp/legacy" {
+		t.Fatalf("unexpected diagnostics: %+v", resp.Diagnostics)
+	}
+	if resp.BySeverity[store.SeverityError] != 1 || resp.BySeverity[store.SeverityWarning] != 1 {
+		t.Errorf("unexpected by_severity: %+v", resp.BySeverity)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleDiagnostics(w, httptest.NewRequest(http.MethodGet, "/api/diagnostics?severity=warning&package=myapp/handlers", nil))
+	resp = DiagnosticsResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Message != "undefined: db" {
+		t.Errorf("expected the handlers warning only, got %+v", resp.Diagnostics)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleDiagnostics(w, httptest.NewRequest(http.MethodGet, "/api/diagnostics?severity=fatal", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid severity, got %d", w.Code)
+	}
+}
+
+func TestHandleUnresolved(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []struct {
+		pkg, reason string
+		n           int
+	}{
+		{"myapp/handlers", store.UnresolvedFuncval, 2},
+		{"myapp/handlers", store.UnresolvedInterface, 1},
+		{"myapp/service", store.UnresolvedFuncval, 5},
+	} {
+		if err := batch.AddUnresolvedCalls(t.Context(), u.pkg, u.reason, u.n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	skipped := &store.SkippedFunction{PkgPath: "myapp/handlers", Name: "GetUser$1", File: "user.go", Line: 12, Calls: 3}
+	if err := batch.InsertSkippedFunction(t.Context(), skipped); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleUnresolved(w, httptest.NewRequest(http.MethodGet, "/api/stats/unresolved", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp UnresolvedResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	// Packages with the most unresolved calls come first
+	if len(resp.Packages) != 2 || resp.Packages[0].PkgPath != "myapp/service" || resp.Packages[1].Total != 3 {
+		t.Errorf("unexpected packages: %+v", resp.Packages)
+	}
+	if resp.ByReason[store.UnresolvedFuncval] != 7 || resp.ByReason[store.UnresolvedMissingSymbol] != 0 {
+		t.Errorf("unexpected by_reason: %+v", resp.ByReason)
+	}
+	if len(resp.SkippedFunctions) != 1 || resp.SkippedFunctions[0].Name != "GetUser$1" {
+		t.Errorf("unexpected skipped functions: %+v", resp.SkippedFunctions)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleUnresolved(w, httptest.NewRequest(http.MethodGet, "/api/stats/unresolved?package=myapp/service", nil))
+	resp = UnresolvedResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Packages) != 1 || resp.ByReason[store.UnresolvedInterface] != 0 || len(resp.SkippedFunctions) != 0 {
+		t.Errorf("expected only myapp/service, got %+v", resp)
+	}
+
+	// The totals also appear in /api/stats
+	w = httptest.NewRecorder()
+	s.handleStats(w, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	var stats store.Stats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+	if stats.UnresolvedCalls[store.UnresolvedFuncval] != 7 || stats.SkippedFunctions != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestHandleInterfaces(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	ifaceID, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "UserGetter", Kind: store.SymbolKindInterface, File: "user.go", Line: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeID, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "UserHandler", Kind: store.SymbolKindType, File: "user.go", Line: 7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.InsertInterfaceMethod(t.Context(), ifaceID, &store.InterfaceMethod{Name: "GetUser", Sig: "func(id string) error"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.InsertImplementation(t.Context(), ifaceID, typeID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleInterfaces(w, httptest.NewRequest(http.MethodGet, "/api/interfaces?package=myapp/handlers", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var list InterfacesResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list.Interfaces) != 1 || list.Interfaces[0].MethodCount != 1 || list.Interfaces[0].ImplementationCount != 1 {
+		t.Errorf("expected UserGetter with 1 method and 1 implementation, got %+v", list.Interfaces)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleInterfaceByID(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/interfaces/%d", ifaceID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var detail InterfaceResponse
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if detail.Interface.Name != "UserGetter" || len(detail.Methods) != 1 {
+		t.Errorf("unexpected interface detail %+v", detail)
+	}
+	if len(detail.Implementations) != 1 || detail.Implementations[0].Symbol.Name != "UserHandler" || !detail.Implementations[0].Pointer {
+		t.Errorf("expected *UserHandler as the implementation, got %+v", detail.Implementations)
+	}
+
+	// A type's symbol details list the interfaces it satisfies
+	w = httptest.NewRecorder()
+	s.handleSymbol(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/symbol/%d", typeID), nil))
+	var sym struct {
+		Implements []store.Implementation `json:"implements"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&sym); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(sym.Implements) != 1 || sym.Implements[0].Symbol.Name != "UserGetter" {
+		t.Errorf("expected UserHandler to implement UserGetter, got %+v", sym.Implements)
+	}
+
+	// Only interfaces are found
+	w = httptest.NewRecorder()
+	s.handleInterfaceByID(w, httptest.NewRequest(http.MethodGet, "/api/interfaces/1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a function, got %d", w.Code)
+	}
+}
+
+func TestHandleTypeRelations(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	serviceID, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "UserService", Kind: store.SymbolKindType, File: "user.go", Line: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlerID, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "UserHandler", Kind: store.SymbolKindType, File: "user.go", Line: 7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range []*store.TypeRelation{
+		{TypeID: serviceID, Field: "db", Relation: store.RelationField, FieldType: "*database/sql.DB", TargetPkg: "database/sql", TargetName: "DB"},
+		{TypeID: handlerID, Field: "UserService", Relation: store.RelationEmbed, FieldType: "*myapp/handlers.UserService", TargetPkg: "myapp/handlers", TargetName: "UserService"},
+	} {
+		if err := batch.InsertTypeRelation(t.Context(), rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(url string, wantStatus int) TypeRelationsResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleTypes(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != wantStatus {
+			t.Fatalf("%s: expected status %d, got %d: %s", url, wantStatus, w.Code, w.Body.String())
+		}
+		var resp TypeRelationsResponse
+		if wantStatus == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("%s: failed to decode response: %v", url, err)
+			}
+		}
+		return resp
+	}
+
+	resp := get(fmt.Sprintf("/api/types/%d/relations", serviceID), http.StatusOK)
+	if resp.Type == nil || resp.Type.Name != "UserService" {
+		t.Errorf("expected UserService, got %+v", resp.Type)
+	}
+	if len(resp.Fields) != 1 || resp.Fields[0].FieldType != "*database/sql.DB" {
+		t.Errorf("expected the db field, got %+v", resp.Fields)
+	}
+	if len(resp.HeldBy) != 1 || resp.HeldBy[0].Holder.Name != "UserHandler" || resp.HeldBy[0].Relation != store.RelationEmbed {
+		t.Errorf("expected UserHandler to embed UserService, got %+v", resp.HeldBy)
+	}
+
+	// Holders of a type outside the index
+	resp = get("/api/types/relations?target=database/sql.DB", http.StatusOK)
+	if resp.Type != nil || len(resp.HeldBy) != 1 || resp.HeldBy[0].TypeID != serviceID {
+		t.Errorf("expected UserService to hold *sql.DB, got %+v", resp)
+	}
+
+	get("/api/types/1/relations", http.StatusNotFound) // A function
+	get("/api/types/relations?target=DB", http.StatusBadRequest)
+	get("/api/types/abc/relations", http.StatusBadRequest)
+}
+
+func TestHandleSymbolReferences(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	constID, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "UsersPath", Kind: store.SymbolKindConst, File: "routes.go", Line: 3, Value: `"/api/users"`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.InsertReference(t.Context(), &store.Reference{
+		SymbolID: constID, From: store.Symbol{ID: 1}, File: "user.go", Line: 12, Column: 9,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleSymbol(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/symbol/%d/references", constID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ReferencesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Symbol.Value != `"/api/users"` {
+		t.Errorf("expected the constant's value, got %q", resp.Symbol.Value)
+	}
+	if len(resp.References) != 1 || resp.References[0].From.Name != "GetUser" || resp.References[0].Line != 12 {
+		t.Errorf("expected a reference from GetUser at line 12, got %+v", resp.References)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleSymbol(w, httptest.NewRequest(http.MethodGet, "/api/symbol/999/references", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHandleGlobalStateReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	varID, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "requestCount", Kind: store.SymbolKindVar, File: "user.go", Line: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.InsertPackage(t.Context(), &store.Package{PkgPath: "myapp/metrics", Dir: "/metrics"}); err != nil {
+		t.Fatal(err)
+	}
+	writerID, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/metrics", Name: "Reset", Kind: store.SymbolKindFunc, File: "metrics.go", Line: 8,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, gw := range []*store.GlobalWrite{
+		{VarID: varID, WriterID: 1, File: "user.go", Line: 14},
+		{VarID: varID, WriterID: 1, File: "user.go", Line: 11},
+		{VarID: varID, WriterID: writerID, File: "metrics.go", Line: 9},
+	} {
+		if err := batch.InsertGlobalWrite(t.Context(), gw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleGlobalStateReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/globals", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report GlobalStateReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Count != 1 || report.CrossPackage != 1 || report.Vars[0].Symbol.Name != "requestCount" {
+		t.Fatalf("expected requestCount written across packages, got %+v", report)
+	}
+	writers := report.Vars[0].Writers
+	if len(writers) != 2 || writers[0].Symbol.Name != "GetUser" || writers[0].Line != 11 || writers[0].Writes != 2 {
+		t.Errorf("expected GetUser's first of 2 writes at line 11, got %+v", writers)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleGlobalStateReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/globals?min_writers=3", nil))
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Count != 0 || report.Vars == nil {
+		t.Errorf("expected an empty list for min_writers=3, got %+v", report)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleGlobalStateReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/globals?min_writers=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHandlePanicReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	eps, err := s.store.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil || len(eps) == 0 {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := &store.PanicCheck{
+		EntrypointID: eps[0].ID, Status: store.PanicUnrecovered, Call: "panic",
+		File: "user.go", Line: 14, Path: []string{"myapp/handlers.GetUser"},
+	}
+	if err := batch.InsertPanicCheck(t.Context(), check); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handlePanicReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/panics?status=unrecovered", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report PanicReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.UnrecoveredCount != 1 || len(report.Checks) != 1 || report.Checks[0].EntrypointLabel != "GET /api/users" || report.Checks[0].Line != 14 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	// The entrypoint listing flags the route
+	w = httptest.NewRecorder()
+	s.handleEntrypoints(w, httptest.NewRequest(http.MethodGet, "/api/entrypoints", nil))
+	var listed []store.EntrypointWithSymbol
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("failed to decode entrypoints: %v", err)
+	}
+	if len(listed) == 0 || !listed[0].UnrecoveredPanic {
+		t.Errorf("expected the entrypoint to be flagged, got %+v", listed)
+	}
+
+	w = httptest.NewRecorder()
+	s.handlePanicReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/panics?status=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHandleEntrypointErrors(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser (ID 1) converts errors from LoadUser, which wraps them; an
+	// unreachable function's sites are left out
+	ids := make(map[string]store.SymbolID)
+	for _, name := range []string{"LoadUser", "Unused"} {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: name, Kind: store.SymbolKindFunc, File: "user.go", Line: 20,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = id
+	}
+	if err := s.store.InsertCallEdge(t.Context(), &store.CallEdge{
+		CallerID: 1, CalleeID: ids["LoadUser"], CallerFile: "user.go", CallerLine: 12, CallKind: store.CallKindStatic, Count: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sites := []*store.ErrorSite{
+		{SymbolID: 1, Kind: store.ErrorStatus, Call: "net/http.Error", File: "user.go", Line: 14},
+		{SymbolID: ids["LoadUser"], Kind: store.ErrorWrap, Call: "fmt.Errorf", File: "user.go", Line: 22},
+		{SymbolID: ids["LoadUser"], Kind: store.ErrorSwallow, Call: "myapp/handlers.audit", File: "user.go", Line: 24},
+		{SymbolID: ids["Unused"], Kind: store.ErrorSwallow, Call: "myapp/handlers.audit", File: "user.go", Line: 30},
+	}
+	for _, site := range sites {
+		if err := batch.InsertErrorSite(t.Context(), site); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	eps, err := s.store.GetEntrypoints(t.Context(), store.EntrypointFilter{})
+	if err != nil || len(eps) == 0 {
+		t.Fatalf("getting entrypoints: %v", err)
+	}
+	url := fmt.Sprintf("/api/entrypoints/%d/errors", eps[0].ID)
+	w := httptest.NewRecorder()
+	s.handleEntrypointByID(w, httptest.NewRequest(http.MethodGet, url, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ErrorChainResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Functions) != 2 || resp.Functions[0].Symbol.Name != "GetUser" || resp.Functions[1].Symbol.Name != "LoadUser" {
+		t.Fatalf("unexpected functions: %+v", resp.Functions)
+	}
+	if resp.Functions[0].Layer != "handler" || resp.Functions[1].Depth != 1 || len(resp.Functions[1].Sites) != 2 {
+		t.Errorf("unexpected function details: %+v", resp.Functions)
+	}
+	if resp.ByLayer["handler"][store.ErrorStatus] != 1 || resp.ByLayer[""][store.ErrorWrap] != 1 || resp.ByLayer[""][store.ErrorSwallow] != 1 {
+		t.Errorf("unexpected layer counts: %v", resp.ByLayer)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleEntrypointByID(w, httptest.NewRequest(http.MethodGet, "/api/entrypoints/999/errors", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHandleFeatureFlagReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser (ID 1) evaluates one flag; another is evaluated off every flow
+	unused, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "Legacy", Kind: store.SymbolKindFunc, File: "user.go", Line: 40,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	uses := []*store.FlagUse{
+		{SymbolID: 1, Key: "new-profile", Call: "github.com/launchdarkly/go-server-sdk/v7.LDClient.BoolVariation", File: "user.go", Line: 13},
+		{SymbolID: unused, Key: "old-profile", Call: "github.com/launchdarkly/go-server-sdk/v7.LDClient.BoolVariation", File: "user.go", Line: 41},
+	}
+	for _, u := range uses {
+		if err := batch.InsertFlagUse(t.Context(), u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleFeatureFlagReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/feature-flags", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report FeatureFlagReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Count != 2 || report.Unreached != 1 || report.Flags[0].Key != "new-profile" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if eps := report.Flags[0].Entrypoints; len(eps) != 1 || eps[0].Label != "GET /api/users" {
+		t.Errorf("unexpected entrypoints for new-profile: %+v", eps)
+	}
+	if fn := report.Flags[0].Uses[0].Function; fn != "myapp/handlers.GetUser" {
+		t.Errorf("expected use in myapp/handlers.GetUser, got %q", fn)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleFeatureFlagReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/feature-flags?key=old-profile", nil))
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Count != 1 || report.Flags[0].Key != "old-profile" || len(report.Flags[0].Entrypoints) != 0 {
+		t.Errorf("unexpected filtered report: %+v", report)
+	}
+}
+
+func TestHandleGraphCallsites(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser (ID 1) calls LoadUser from two lines
+	id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "LoadUser", Kind: store.SymbolKindFunc, File: "user.go", Line: 20,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []int{15, 12} {
+		edge := &store.CallEdge{CallerID: 1, CalleeID: id, CallKind: store.CallKindStatic, CallerFile: "user.go", CallerLine: line, Count: 1}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(t *testing.T) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/graph/root/1?depth=1", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp GraphResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Edges) != 1 {
+			t.Fatalf("expected 1 edge, got %+v", resp.Edges)
+		}
+		e := resp.Edges[0]
+		if e.CallsiteCount != 2 || e.CallerLine != 12 {
+			t.Errorf("expected 2 calls first made on line 12, got %+v", e)
+		}
+		if len(e.Callsites) != 2 || e.Callsites[0].Line != 12 || e.Callsites[1].Line != 15 || filepath.Base(e.Callsites[0].File) != "user.go" {
+			t.Errorf("expected both call sites, got %+v", e.Callsites)
+		}
+	}
+
+	// From call edges, and from the aggregated call pairs
+	check(t)
+	if err := s.store.RebuildCallPairs(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.SetMetadata(t.Context(), "indexed_at", "later"); err != nil {
+		t.Fatal(err)
+	}
+	check(t)
+}
+
+func TestWarmCaches(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+	s.cache = newResponseCache(0)
+	s.warmGraphs = 1
+
+	// A second entrypoint, starred, is warmed ahead of GET /api/users
+	id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+		PkgPath: "myapp/handlers", Name: "ListOrders", Kind: store.SymbolKindFunc, File: "orders.go", Line: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	epID, err := s.store.InsertEntrypoint(t.Context(), &store.Entrypoint{Type: store.EntrypointHTTP, Label: "GET /api/orders", SymbolID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.store.StarEntrypoint(t.Context(), epID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	built, err := s.warm(t.Context())
+	if err != nil {
+		t.Fatalf("warm failed: %v", err)
+	}
+	if built != 1 {
+		t.Errorf("expected 1 graph built, got %d", built)
+	}
+
+	get := func(handler http.HandlerFunc, target string) string {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		return w.Header().Get("X-Cache")
+	}
+	if cache := get(s.handleGraph, fmt.Sprintf("/api/graph/root/%d", id)); cache != "HIT" {
+		t.Errorf("expected the starred entrypoint's graph to be warm, got X-Cache %q", cache)
+	}
+	eps, err := s.store.GetEntrypoints(t.Context(), store.EntrypointFilter{Query: "users"})
+	if err != nil || len(eps) != 1 {
+		t.Fatalf("expected GET /api/users, got %+v (%v)", eps, err)
+	}
+	if cache := get(s.handleGraph, fmt.Sprintf("/api/graph/root/%d", eps[0].SymbolID)); cache != "MISS" {
+		t.Errorf("expected graphs past --warm-graphs to be built on request, got X-Cache %q", cache)
+	}
+	if cache := get(s.handleTags, "/api/tags"); cache != "HIT" {
+		t.Errorf("expected tag counts to be warm, got X-Cache %q", cache)
+	}
+}
+
+func TestHandleTags(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// Three more symbols tagged io:db@2, one of them also io:bus
+	for i, name := range []string{"SaveUser", "LoadUser", "DeleteUser"} {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: name, Kind: store.SymbolKindFunc, File: "user.go", Line: 20 + i,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.store.InsertTag(t.Context(), &store.Tag{SymbolID: id, Tag: "io:db@2", Reason: "calls the store"}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if err := s.store.InsertTag(t.Context(), &store.Tag{SymbolID: id, Tag: "io:bus"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleTags(w, httptest.NewRequest(http.MethodGet, "/api/tags", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var tags TagsResponse
+	if err := json.NewDecoder(w.Body).Decode(&tags); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(tags.Tags) != 3 || tags.Tags[0] != (store.TagCount{Tag: "io:db@2", Count: 3}) {
+		t.Errorf("expected io:db@2 first of 3 tags, got %+v", tags.Tags)
+	}
+
+	page := func(target string) TagSymbolsResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleTagSymbols(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		var resp TagSymbolsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	first := page("/api/tags/io:db%402/symbols?limit=2")
+	if first.Tag != "io:db@2" || first.Total != 3 || len(first.Symbols) != 2 {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+	if first.Symbols[0].Symbol.Name != "DeleteUser" || first.Symbols[0].Reason != "calls the store" {
+		t.Errorf("expected symbols by name with their reason, got %+v", first.Symbols[0])
+	}
+	second := page("/api/tags/io:db%402/symbols?limit=2&offset=2")
+	if len(second.Symbols) != 1 || second.Symbols[0].Symbol.Name != "SaveUser" {
+		t.Errorf("unexpected second page %+v", second)
+	}
+	if none := page("/api/tags/missing/symbols"); none.Total != 0 || none.Symbols == nil {
+		t.Errorf("expected an empty page for an unknown tag, got %+v", none)
+	}
+
+	for _, target := range []string{"/api/tags/io:bus/symbols?limit=0", "/api/tags/io:bus/symbols?offset=-1"} {
+		w := httptest.NewRecorder()
+		s.handleTagSymbols(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: expected status 400, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestHandleLayers(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	// GetUser (handler, ID 1) calls the service, which calls the store; the
+	// store calls back into the handler layer, and a custom layer calls the
+	// service
+	ids := map[string]store.SymbolID{"GetUser": 1}
+	for _, sym := range []struct{ pkg, layer, name string }{
+		{"myapp/users", "service", "Find"},
+		{"myapp/db", "store", "Query"},
+		{"myapp/jobs", "worker", "Sync"},
+	} {
+		if err := s.store.InsertPackage(t.Context(), &store.Package{PkgPath: sym.pkg, Dir: "/" + sym.layer, Layer: sym.layer}); err != nil {
+			t.Fatal(err)
+		}
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{PkgPath: sym.pkg, Name: sym.name, Kind: store.SymbolKindFunc, File: "x.go", Line: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[sym.name] = id
+	}
+	for i, e := range [][2]string{{"GetUser", "Find"}, {"Find", "Query"}, {"Query", "GetUser"}, {"Sync", "Find"}} {
+		edge := &store.CallEdge{CallerID: ids[e[0]], CalleeID: ids[e[1]], CallKind: store.CallKindStatic, CallerFile: "x.go", CallerLine: i + 1, Count: 1}
+		if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleLayers(w, httptest.NewRequest(http.MethodGet, "/api/layers", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp LayersResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	want := []LayerSummary{
+		{Layer: "handler", Packages: 1, Symbols: 1, Inbound: 1, Outbound: 1},
+		{Layer: "service", Packages: 1, Symbols: 1, Inbound: 2, Outbound: 1},
+		{Layer: "store", Packages: 1, Symbols: 1, Inbound: 1, Outbound: 1, Violations: 1},
+		{Layer: "worker", Packages: 1, Symbols: 1, Outbound: 1},
+	}
+	if !reflect.DeepEqual(resp.Layers, want) {
+		t.Errorf("expected layers %+v, got %+v", want, resp.Layers)
+	}
+	violations := 0
+	for _, e := range resp.Edges {
+		if e.Violation {
+			violations++
+			if e.CallerLayer != "store" || e.CalleeLayer != "handler" {
+				t.Errorf("unexpected violation %+v", e)
+			}
+		}
+	}
+	if len(resp.Edges) != 4 || violations != 1 {
+		t.Errorf("expected 4 edges with 1 violation, got %+v", resp.Edges)
+	}
+}
+
+func TestHandleSimilarEntrypointsReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	ids := map[string]store.SymbolID{"GetUser": 1}
+	for i, name := range []string{"UpdateUser", "CreateUser", "DeleteUser", "load", "check", "render", "audit", "purge"} {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: name, Kind: store.SymbolKindFunc, File: "user.go", Line: 20 + i,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = id
+	}
+	// UpdateUser copies GetUser's flow, CreateUser skips the audit, and
+	// DeleteUser reaches too little to compare; HEAD shares GetUser's handler
+	calls := map[string][]string{
+		"GetUser":    {"load", "check", "render", "audit"},
+		"UpdateUser": {"load", "check", "render", "audit"},
+		"CreateUser": {"load", "check", "render"},
+		"DeleteUser": {"purge"},
+	}
+	for caller, callees := range calls {
+		for i, callee := range callees {
+			edge := &store.CallEdge{CallerID: ids[caller], CalleeID: ids[callee], CallKind: store.CallKindStatic, CallerFile: "user.go", CallerLine: i + 1, Count: 1}
+			if err := s.store.InsertCallEdge(t.Context(), edge); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, ep := range []struct{ label, handler string }{
+		{"PUT /api/users", "UpdateUser"},
+		{"POST /api/users", "CreateUser"},
+		{"DELETE /api/users", "DeleteUser"},
+		{"HEAD /api/users", "GetUser"},
+	} {
+		if _, err := s.store.InsertEntrypoint(t.Context(), &store.Entrypoint{Type: store.EntrypointHTTP, Label: ep.label, SymbolID: ids[ep.handler]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(target string) SimilarEntrypointsReport {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleSimilarEntrypointsReport(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		var report SimilarEntrypointsReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return report
+	}
+
+	// GET and HEAD each copy PUT; the pair sharing a handler is left out
+	report := get("/api/reports/similar-entrypoints")
+	if report.Count != 2 {
+		t.Fatalf("expected 2 pairs, got %+v", report.Pairs)
+	}
+	for _, p := range report.Pairs {
+		if (p.A.Label != "PUT /api/users" && p.B.Label != "PUT /api/users") || p.Similarity != 1 || p.Shared != 4 {
+			t.Errorf("unexpected pair %+v", p)
+		}
+	}
+
+	// POST shares 3 of the 4 symbols with each of them
+	report = get("/api/reports/similar-entrypoints?min_similarity=0.7")
+	if report.Count != 5 {
+		t.Fatalf("expected 5 pairs, got %+v", report.Pairs)
+	}
+	if last := report.Pairs[4]; last.Similarity != 0.75 || last.Shared != 3 {
+		t.Errorf("expected the least similar pair last, got %+v", last)
+	}
+
+	for _, target := range []string{"/api/reports/similar-entrypoints?min_similarity=2", "/api/reports/similar-entrypoints?min_reach=0"} {
+		w := httptest.NewRecorder()
+		s.handleSimilarEntrypointsReport(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: expected status 400, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestHandleOrphanedHandlersReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	ids := map[string]store.SymbolID{"GetUser": 1}
+	for i, name := range []string{"Routes", "ListUsers", "ExportUsers", "LegacyUsers"} {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: name, Kind: store.SymbolKindFunc, File: "user.go", Line: 20 + i,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = id
+	}
+	// GET /api/users calls Routes, which passes ListUsers to a router the
+	// indexer didn't recognize; ExportUsers is called from ListUsers, and
+	// nothing reaches LegacyUsers
+	if err := s.store.InsertCallEdge(t.Context(), &store.CallEdge{CallerID: ids["GetUser"], CalleeID: ids["Routes"], CallKind: store.CallKindStatic, Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.InsertCallEdge(t.Context(), &store.CallEdge{CallerID: ids["ListUsers"], CalleeID: ids["ExportUsers"], CallKind: store.CallKindStatic, Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.InsertReference(t.Context(), &store.Reference{
+		SymbolID: ids["ListUsers"], From: store.Symbol{ID: ids["Routes"]}, File: "routes.go", Line: 5, Column: 20,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ListUsers", "ExportUsers", "LegacyUsers"} {
+		ep := &store.Entrypoint{Type: store.EntrypointHTTP, Label: name, SymbolID: ids[name], DiscoveryMethod: "signature"}
+		if _, err := s.store.InsertEntrypoint(t.Context(), ep); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleOrphanedHandlersReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/orphaned-handlers", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report OrphanedHandlersReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Count != 1 || report.Handlers[0].Label != "LegacyUsers" {
+		t.Fatalf("expected only LegacyUsers to be orphaned, got %+v", report.Handlers)
+	}
+	if filepath.Base(report.Handlers[0].Symbol.File) != "user.go" || report.Handlers[0].Symbol.Line != 23 {
+		t.Errorf("expected the handler's location, got %s:%d", report.Handlers[0].Symbol.File, report.Handlers[0].Symbol.Line)
+	}
+}
+
+func TestHandleGRPCCoverageReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	ids := make(map[string]store.SymbolID)
+	for _, name := range []string{"UserServer", "LegacyUserServer"} {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: name, Kind: store.SymbolKindType, File: "grpc.go", Line: 5,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = id
+	}
+	// UserServer is registered but leaves DeleteUser to the Unimplemented
+	// stub; LegacyUserServer implements both but is never registered
+	batch, err := s.store.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []store.GRPCMethod{
+		{Method: "GetUser", TypeID: ids["UserServer"], Registered: true, Implemented: true},
+		{Method: "DeleteUser", TypeID: ids["UserServer"], Registered: true},
+		{Method: "GetUser", TypeID: ids["LegacyUserServer"], Implemented: true},
+		{Method: "DeleteUser", TypeID: ids["LegacyUserServer"], Implemented: true},
+	} {
+		m.PkgPath, m.Service = "myapp/pb", "UserService"
+		if err := batch.InsertGRPCMethod(t.Context(), &m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleGRPCCoverageReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/grpc", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report GRPCCoverageReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if report.Unimplemented != 1 || report.Unregistered != 1 || len(report.Services) != 1 {
+		t.Fatalf("expected 1 unimplemented method and 1 unregistered type, got %+v", report)
+	}
+	svc := report.Services[0]
+	if svc.Service != "UserService" || len(svc.Methods) != 2 || len(svc.Unimplemented) != 1 || svc.Unimplemented[0] != "DeleteUser" {
+		t.Errorf("unexpected service %+v", svc)
+	}
+	if len(svc.Implementations) != 2 || svc.Implementations[0].Type.Name != "LegacyUserServer" || svc.Implementations[0].Registered ||
+		svc.Implementations[1].Type.Name != "UserServer" || len(svc.Implementations[1].Missing) != 1 {
+		t.Errorf("unexpected implementations %+v", svc.Implementations)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleGRPCCoverageReport(w, httptest.NewRequest(http.MethodPost, "/api/reports/grpc", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
+
+func TestHandleHeatmapReport(t *testing.T) {
+	s := setupTestServer(t)
+	defer s.store.Close()
+
+	ids := map[string]store.SymbolID{"GetUser": 1}
+	for _, sym := range []struct{ name, file string }{
+		{"CreateUser", "api/grpc.go"},
+		{"FindUser", "internal/users/repo.go"},
+		{"Query", "internal/db/db.go"},
+		{"Println", "/go/pkg/mod/fmt/print.go"},
+	} {
+		id, err := s.store.InsertSymbol(t.Context(), &store.Symbol{
+			PkgPath: "myapp/handlers", Name: sym.name, Kind: store.SymbolKindFunc, File: sym.file, Line: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[sym.name] = id
+	}
+	// Both entrypoints look the user up; GetUser also logs, outside the project
+	for _, e := range [][2]string{
+		{"GetUser", "FindUser"}, {"GetUser", "Println"}, {"CreateUser", "FindUser"}, {"FindUser", "Query"},
+	} {
+		if err := s.store.InsertCallEdge(t.Context(), &store.CallEdge{CallerID: ids[e[0]], CalleeID: ids[e[1]], CallKind: store.CallKindStatic, Count: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.store.InsertEntrypoint(t.Context(), &store.Entrypoint{Type: store.EntrypointGRPC, Label: "users.Create", SymbolID: ids["CreateUser"]}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(url string) HeatmapReport {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleHeatmapReport(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var report HeatmapReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return report
+	}
+	find := func(n *HeatmapNode, path string) *HeatmapNode {
+		for n != nil && n.Path != path {
+			var next *HeatmapNode
+			for _, c := range n.Children {
+				if c.Path == path || strings.HasPrefix(path, c.Path+"/") {
+					next = c
+				}
+			}
+			n = next
+		}
+		return n
+	}
+
+	// Each entrypoint enters its handler and calls FindUser, which calls Query
+	report := get("/api/reports/heatmap")
+	if report.Directories != 5 {
+		t.Errorf("expected 5 directories, got %d", report.Directories)
+	}
+	if report.Root.Calls != 6 || report.Root.Value != 1 || report.Root.Entrypoints != 2 || report.Root.Symbols != 4 {
+		t.Errorf("unexpected root %+v", report.Root)
+	}
+	internal := find(report.Root, "internal")
+	if internal == nil || internal.Name != "internal" || internal.Calls != 4 || internal.Value != 0 || internal.Entrypoints != 2 || len(internal.Children) != 2 {
+		t.Fatalf("unexpected internal directory %+v", internal)
+	}
+	if users := find(report.Root, "internal/users"); users == nil || users.Name != "users" || users.Calls != 2 || users.Symbols != 1 {
+		t.Errorf("unexpected internal/users directory %+v", users)
+	}
+	if api := find(report.Root, "api"); api == nil || api.Calls != 1 || api.Entrypoints != 1 {
+		t.Errorf("unexpected api directory %+v", api)
+	}
+
+	// Deeper directories fold into their ancestor at depth
+	report = get("/api/reports/heatmap?depth=1")
+	if internal := find(report.Root, "internal"); internal == nil || internal.Value != 4 || len(internal.Children) != 0 {
+		t.Errorf("expected internal to fold in its subdirectories, got %+v", internal)
+	}
+
+	// Only the gRPC entrypoint
+	report = get("/api/reports/heatmap?type=grpc")
+	if report.Root.Calls != 3 || report.Root.Entrypoints != 1 || find(report.Root, "api") == nil {
+		t.Errorf("unexpected gRPC root %+v", report.Root)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleHeatmapReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/heatmap?depth=-1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a negative depth, got %d", w.Code)
+	}
+}
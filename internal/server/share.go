@@ -0,0 +1,74 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ShareRequest is the body of POST /api/share.
+type ShareRequest struct {
+	State json.RawMessage `json:"state"` // View state: entrypoint, filters, expanded nodes
+}
+
+// handleShare handles POST /api/share, storing a view state under a short
+// ID. Links carry the ID instead of the encoded state, which grows to
+// several kilobytes for large graphs.
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.rejectReadOnly(w) {
+		return
+	}
+
+	ctx := r.Context()
+
+	var req ShareRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxViewStateSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if !isJSONObject(req.State) {
+		writeError(w, http.StatusBadRequest, "state must be a JSON object")
+		return
+	}
+
+	share, err := s.store.SaveShare(ctx, req.State)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save share: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, share)
+}
+
+// handleShareByID handles GET /api/share/{id}, returning a shared view state.
+func (s *Server) handleShareByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/share/")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "share ID is required")
+		return
+	}
+
+	share, err := s.store.GetShare(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "share not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get share: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, share)
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"container/list"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// maxOpenSnapshots is the number of snapshot servers kept open at once.
+const maxOpenSnapshots = 4
+
+// snapshotServers serves requests with ?as_of= from retained snapshots of
+// the index, each through a read-only Server of its own opened on first use.
+// The least recently used servers are closed beyond maxOpenSnapshots, and
+// servers whose snapshot has since been pruned are closed on the next use.
+// A server still serving requests is closed once the last one is done.
+type snapshotServers struct {
+	cfg    Config // Configuration the snapshot servers inherit
+	limit  int
+	mu     sync.Mutex
+	ll     *list.List // *openSnapshot, most recently used first
+	byName map[string]*list.Element
+}
+
+// openSnapshot is a snapshot server and the requests it is serving.
+type openSnapshot struct {
+	name    string
+	srv     *Server
+	users   int
+	evicted bool
+}
+
+func newSnapshotServers(cfg Config) *snapshotServers {
+	return &snapshotServers{cfg: cfg, limit: maxOpenSnapshots, ll: list.New(), byName: make(map[string]*list.Element)}
+}
+
+// get returns the server for a retained snapshot, opening it if needed,
+// and a function to call once done with it.
+func (ss *snapshotServers) get(snap *store.RetainedSnapshot) (*Server, func(), error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	for elem := ss.ll.Front(); elem != nil; {
+		next := elem.Next()
+		if o := elem.Value.(*openSnapshot); o.name != snap.Name {
+			if _, err := os.Stat(o.srv.store.DBPath()); os.IsNotExist(err) {
+				ss.evict(elem)
+			}
+		}
+		elem = next
+	}
+
+	elem, ok := ss.byName[snap.Name]
+	if ok {
+		ss.ll.MoveToFront(elem)
+	} else {
+		cfg := ss.cfg
+		cfg.DBPath = snap.Path
+		cfg.ReadOnly = true
+		cfg.IndexKey = nil // Snapshots aren't retained for encrypted indexes
+		cfg.asOf = snap.Name
+		srv, err := New(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		elem = ss.ll.PushFront(&openSnapshot{name: snap.Name, srv: srv})
+		ss.byName[snap.Name] = elem
+		for ss.ll.Len() > ss.limit {
+			ss.evict(ss.ll.Back())
+		}
+	}
+
+	o := elem.Value.(*openSnapshot)
+	o.users++
+	release := func() {
+		ss.mu.Lock()
+		defer ss.mu.Unlock()
+		o.users--
+		if o.evicted && o.users == 0 {
+			closeSnapshot(o)
+		}
+	}
+	return o.srv, release, nil
+}
+
+// evict drops an open snapshot, closing it unless requests are using it.
+// Callers hold ss.mu.
+func (ss *snapshotServers) evict(elem *list.Element) {
+	o := ss.ll.Remove(elem).(*openSnapshot)
+	delete(ss.byName, o.name)
+	o.evicted = true
+	if o.users == 0 {
+		closeSnapshot(o)
+	}
+}
+
+func closeSnapshot(o *openSnapshot) {
+	if err := o.srv.Close(); err != nil {
+		log.Printf("Error closing snapshot %s: %v", o.name, err)
+	}
+}
+
+// close releases the snapshots opened so far.
+func (ss *snapshotServers) close() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for ss.ll.Len() > 0 {
+		ss.evict(ss.ll.Front())
+	}
+}
+
+// asOfMiddleware answers requests with ?as_of=<snapshot> from that retained
+// snapshot instead of the current index. as_of is a snapshot name or a time,
+// picking the newest snapshot indexed at or before it; the snapshot used is
+// returned in the X-FlowLens-Snapshot header. Snapshots are read-only, so
+// requests that would change the index are rejected.
+func (s *Server) asOfMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asOf := r.URL.Query().Get("as_of")
+		if asOf == "" || s.asOf != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		snap, err := store.FindSnapshot(s.store.DBPath(), asOf)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		srv, release, err := s.snapshots.get(snap)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("opening snapshot %s: %v", snap.Name, err))
+			return
+		}
+		defer release()
+		w.Header().Set("X-FlowLens-Snapshot", snap.Name)
+		srv.Handler().ServeHTTP(w, r)
+	})
+}
+
+// handleSnapshots handles GET /api/snapshots, listing the retained snapshots
+// ?as_of= accepts, oldest first.
+func (s *Server) handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	snapshots, err := store.ListSnapshots(s.store.DBPath())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list snapshots: %v", err))
+		return
+	}
+	if snapshots == nil {
+		snapshots = []store.RetainedSnapshot{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"snapshots": snapshots})
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"slices"
 	"sort"
 	"strings"
 
@@ -20,6 +22,7 @@ type SpineNode struct {
 	IsMainPath  bool           `json:"is_main_path"`
 	BranchBadge *BranchBadge   `json:"branch_badge,omitempty"`
 	Layer       string         `json:"layer,omitempty"` // handler, service, store, domain
+	Noise       *NoiseSummary  `json:"noise,omitempty"` // Noise-package calls, with the collapseNoise filter
 }
 
 // BranchBadge summarizes collapsed branch calls from a spine node.
@@ -35,22 +38,69 @@ type SpineResponse struct {
 	MainPath      []int64     `json:"main_path"`       // Ordered node IDs forming spine
 	TotalNodes    int         `json:"total_nodes"`     // Including collapsed
 	CollapsedCount int        `json:"collapsed_count"`
+	Cleanup       []CleanupSection `json:"cleanup,omitempty"` // Deferred calls of main path nodes, with the cleanupLane filter
+	SkippedVia    []int64     `json:"skipped_via,omitempty"` // Via symbols the main path couldn't be routed through
 }
 
 // SpineBuilder builds a call spine from the call graph.
 type SpineBuilder struct {
-	store  *store.Store
-	filter GraphFilter
+	store   *store.Store
+	filter  GraphFilter
+	cleanup map[store.SymbolID][]CleanupCall // Deferred calls by caller, with the cleanupLane filter
+	noise   map[store.SymbolID]*NoiseSummary // Noise-package calls by caller, with the collapseNoise filter
+	graph   *store.CallGraph                 // The whole call graph in memory, shared with other builds; nil queries the store
+	seed    []store.SymbolID                 // Preferred main path below the root, followed while it is reachable
+	via     []store.SymbolID                 // Symbols the main path is routed through, in order
 }
 
 // NewSpineBuilder creates a new spine builder.
 func NewSpineBuilder(st *store.Store, filter GraphFilter) *SpineBuilder {
 	return &SpineBuilder{
-		store:  st,
-		filter: filter,
+		store:   st,
+		filter:  filter,
+		cleanup: make(map[store.SymbolID][]CleanupCall),
+		noise:   make(map[store.SymbolID]*NoiseSummary),
 	}
 }
 
+// SetCallGraph makes the builder read symbols, tags, and callees from a
+// call graph loaded in memory instead of querying the store for them.
+func (sb *SpineBuilder) SetCallGraph(g *store.CallGraph) {
+	sb.graph = g
+}
+
+// SetSeedPath pins the main path to a route: from the root, each symbol of
+// seed is picked over higher-scored callees as long as it is an unvisited
+// callee of the previous one. Scoring picks the rest of the path. A seed
+// starting with the root is accepted too.
+func (sb *SpineBuilder) SetSeedPath(seed []store.SymbolID) {
+	sb.seed = seed
+}
+
+// SetVia forces the main path through symbols picked by the user, in
+// order: after the seed path, it takes the shortest route to each one it
+// can reach, and scoring picks the rest of the path from the last. Symbols
+// it can't reach are listed in the response's SkippedVia.
+func (sb *SpineBuilder) SetVia(via []store.SymbolID) {
+	sb.via = via
+}
+
+// symbol returns a symbol and its tags, from the call graph in memory when
+// it holds the symbol.
+func (sb *SpineBuilder) symbol(ctx context.Context, id store.SymbolID) (*store.Symbol, []store.Tag, error) {
+	if sb.graph != nil {
+		if sym, tags := sb.graph.SymbolAndTags(id); sym != nil {
+			return sym, tags, nil
+		}
+	}
+	sym, err := sb.store.GetSymbolByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, _ := sb.store.GetSymbolTags(ctx, id)
+	return sym, tags, nil
+}
+
 // ScoredCallee represents a callee with a score for main path selection.
 type ScoredCallee struct {
 	ID       store.SymbolID
@@ -58,10 +108,11 @@ type ScoredCallee struct {
 	CallKind store.CallKind
 	Score    int
 	Tags     []string
+	Line     int // Line of the call site, breaking ties between equal scores
 }
 
 // BuildSpine constructs the call spine from a root symbol.
-func (sb *SpineBuilder) BuildSpine(rootID store.SymbolID, maxDepth int) (*SpineResponse, error) {
+func (sb *SpineBuilder) BuildSpine(ctx context.Context, rootID store.SymbolID, maxDepth int) (*SpineResponse, error) {
 	if maxDepth <= 0 {
 		maxDepth = 10
 	}
@@ -70,12 +121,12 @@ func (sb *SpineBuilder) BuildSpine(rootID store.SymbolID, maxDepth int) (*SpineR
 	allCallees := make(map[store.SymbolID][]store.CalleeInfo)
 	visited := make(map[store.SymbolID]bool)
 
-	if err := sb.loadCalleesRecursive(rootID, maxDepth, 0, allCallees, visited); err != nil {
+	if err := sb.loadCalleesRecursive(ctx, rootID, maxDepth, 0, allCallees, visited); err != nil {
 		return nil, err
 	}
 
 	// Determine main path using scoring heuristics
-	mainPath := sb.determineMainPath(rootID, allCallees, maxDepth)
+	mainPath, skippedVia := sb.determineMainPath(ctx, rootID, allCallees, maxDepth)
 
 	// Build spine nodes with branch badges for non-main-path calls
 	mainPathSet := make(map[store.SymbolID]bool)
@@ -84,17 +135,17 @@ func (sb *SpineBuilder) BuildSpine(rootID store.SymbolID, maxDepth int) (*SpineR
 	}
 
 	var nodes []SpineNode
+	var cleanup []CleanupSection
 	totalNodes := 0
 	collapsedCount := 0
 
 	for i, id := range mainPath {
 		symID := store.SymbolID(id)
-		sym, err := sb.store.GetSymbolByID(symID)
+		sym, tags, err := sb.symbol(ctx, symID)
 		if err != nil {
 			continue
 		}
 
-		tags, _ := sb.store.GetSymbolTags(symID)
 		tagStrs := make([]string, len(tags))
 		for j, t := range tags {
 			tagStrs[j] = t.Tag
@@ -111,10 +162,15 @@ func (sb *SpineBuilder) BuildSpine(rootID store.SymbolID, maxDepth int) (*SpineR
 			Depth:      i,
 			IsMainPath: true,
 			Layer:      extractLayer(tagStrs),
+			Noise:      sb.noise[symID],
 		}
 
-		// Build branch badge for non-main-path callees
+		// Build branch badge for non-main-path callees, in source order or
+		// by call count
 		callees := allCallees[symID]
+		if sb.filter.OrderBy == OrderByCount {
+			callees = orderByCount(callees)
+		}
 		var collapsedIDs []int64
 		var collapsedLabels []string
 
@@ -140,6 +196,9 @@ func (sb *SpineBuilder) BuildSpine(rootID store.SymbolID, maxDepth int) (*SpineR
 		}
 
 		nodes = append(nodes, node)
+		if calls := sb.cleanup[symID]; len(calls) > 0 {
+			cleanup = append(cleanup, CleanupSection{FunctionID: symID, Calls: calls})
+		}
 	}
 
 	return &SpineResponse{
@@ -147,11 +206,27 @@ func (sb *SpineBuilder) BuildSpine(rootID store.SymbolID, maxDepth int) (*SpineR
 		MainPath:       mainPath,
 		TotalNodes:     totalNodes + len(mainPath),
 		CollapsedCount: collapsedCount,
+		Cleanup:        cleanup,
+		SkippedVia:     skippedVia,
 	}, nil
 }
 
+// orderByCount returns calls sorted by the total call count of their
+// callees, highest first, keeping source order between equal counts.
+func orderByCount(calls []store.CalleeInfo) []store.CalleeInfo {
+	totals := make(map[store.SymbolID]int)
+	for _, c := range calls {
+		totals[c.Symbol.ID] += c.Count
+	}
+	sorted := slices.Clone(calls)
+	slices.SortStableFunc(sorted, func(a, b store.CalleeInfo) int {
+		return totals[b.Symbol.ID] - totals[a.Symbol.ID]
+	})
+	return sorted
+}
+
 // loadCalleesRecursive loads callees recursively up to maxDepth.
-func (sb *SpineBuilder) loadCalleesRecursive(
+func (sb *SpineBuilder) loadCalleesRecursive(ctx context.Context, 
 	symbolID store.SymbolID,
 	maxDepth int,
 	currentDepth int,
@@ -167,24 +242,41 @@ func (sb *SpineBuilder) loadCalleesRecursive(
 	}
 	visited[symbolID] = true
 
-	callees, err := sb.store.GetCallees(symbolID)
-	if err != nil {
-		return nil // Ignore errors, just skip
+	var callees []store.CalleeInfo
+	if sb.graph != nil {
+		callees = sb.graph.Calls[symbolID]
+	} else {
+		var err error
+		if callees, err = sb.store.GetCallees(ctx, symbolID); err != nil {
+			return nil // Ignore errors, just skip
+		}
 	}
 
 	// Filter callees
 	var filteredCallees []store.CalleeInfo
 	for _, c := range callees {
-		if !sb.shouldFilterCallee(&c.Symbol) {
-			filteredCallees = append(filteredCallees, c)
+		if isCollapsedNoise(sb.filter, &c.Symbol) {
+			if sb.noise[symbolID] == nil {
+				sb.noise[symbolID] = &NoiseSummary{}
+			}
+			addNoiseCall(sb.noise[symbolID], &c)
+			continue
+		}
+		if sb.shouldFilterCallee(&c.Symbol) {
+			continue
+		}
+		if isCleanupCall(sb.filter, &c) {
+			sb.cleanup[symbolID] = append(sb.cleanup[symbolID], newCleanupCall(&c))
+			continue
 		}
+		filteredCallees = append(filteredCallees, c)
 	}
 
 	allCallees[symbolID] = filteredCallees
 
 	// Recurse into callees
 	for _, c := range filteredCallees {
-		if err := sb.loadCalleesRecursive(c.Symbol.ID, maxDepth, currentDepth+1, allCallees, visited); err != nil {
+		if err := sb.loadCalleesRecursive(ctx, c.Symbol.ID, maxDepth, currentDepth+1, allCallees, visited); err != nil {
 			return err
 		}
 	}
@@ -195,7 +287,7 @@ func (sb *SpineBuilder) loadCalleesRecursive(
 // shouldFilterCallee checks if a callee should be filtered out.
 func (sb *SpineBuilder) shouldFilterCallee(sym *store.Symbol) bool {
 	// Filter stdlib
-	if sb.filter.HideStdlib && isStdlib(sym.PkgPath) {
+	if hidesStdlib(sb.filter, sym.PkgPath) {
 		return true
 	}
 
@@ -210,70 +302,137 @@ func (sb *SpineBuilder) shouldFilterCallee(sym *store.Symbol) bool {
 	}
 
 	// Filter noise packages
-	for _, noise := range sb.filter.NoisePackages {
-		if matchPackagePattern(noise, sym.PkgPath) {
-			return true
-		}
+	if isNoisePackage(sb.filter, sym.PkgPath) {
+		return true
 	}
 
 	return false
 }
 
-// determineMainPath uses scoring heuristics to find the "happy path".
-func (sb *SpineBuilder) determineMainPath(
+// determineMainPath uses scoring heuristics to find the "happy path". The
+// path follows the seed path first, then is routed through the via symbols,
+// and scoring picks the rest. It also returns the via symbols it couldn't
+// route through.
+func (sb *SpineBuilder) determineMainPath(ctx context.Context,
 	rootID store.SymbolID,
 	allCallees map[store.SymbolID][]store.CalleeInfo,
 	maxDepth int,
-) []int64 {
+) ([]int64, []int64) {
 	// Get root symbol for package context
-	rootSym, err := sb.store.GetSymbolByID(rootID)
+	rootSym, _, err := sb.symbol(ctx, rootID)
 	if err != nil {
-		return []int64{int64(rootID)}
+		return []int64{int64(rootID)}, nil
 	}
 	rootPkg := rootSym.PkgPath
 
-	// Greedy path selection with scoring
 	path := []int64{int64(rootID)}
 	current := rootID
 	visited := make(map[store.SymbolID]bool)
 	visited[rootID] = true
+	step := func(id store.SymbolID) {
+		visited[id] = true
+		path = append(path, int64(id))
+		current = id
+	}
 
-	for len(path) < maxDepth {
-		callees := allCallees[current]
-		if len(callees) == 0 {
+	// Follow the seed path while it goes on from the previous symbol
+	seed := sb.seed
+	if len(seed) > 0 && seed[0] == rootID {
+		seed = seed[1:]
+	}
+	for _, id := range seed {
+		if len(path) >= maxDepth || !slices.ContainsFunc(allCallees[current], func(c store.CalleeInfo) bool {
+			return c.Symbol.ID == id && !visited[id]
+		}) {
 			break
 		}
+		step(id)
+	}
 
-		// Score each callee
-		scored := sb.scoreCallees(current, callees, rootPkg, visited)
+	// Route through the via symbols in order
+	var skipped []int64
+	for _, id := range sb.via {
+		if visited[id] {
+			continue
+		}
+		route := sb.route(current, id, allCallees, rootPkg, visited)
+		if route == nil {
+			skipped = append(skipped, int64(id))
+			continue
+		}
+		for _, hop := range route {
+			step(hop)
+		}
+	}
+
+	// Greedy path selection with scoring
+	for len(path) < maxDepth {
+		scored := sb.rankCallees(current, allCallees[current], rootPkg, visited)
 		if len(scored) == 0 {
 			break
 		}
+		step(scored[0].ID)
+	}
 
-		// Sort by score descending
-		sort.Slice(scored, func(i, j int) bool {
-			return scored[i].Score > scored[j].Score
-		})
+	return path, skipped
+}
 
-		// Pick the best unvisited callee
-		var best *ScoredCallee
-		for i := range scored {
-			if !visited[scored[i].ID] {
-				best = &scored[i]
-				break
+// route returns the shortest route of unvisited callees from one symbol to
+// another, excluding from, or nil if there is none. Among equally short
+// routes the one through the best-ranked callees wins.
+func (sb *SpineBuilder) route(from, to store.SymbolID,
+	allCallees map[store.SymbolID][]store.CalleeInfo,
+	rootPkg string,
+	visited map[store.SymbolID]bool,
+) []store.SymbolID {
+	parent := map[store.SymbolID]store.SymbolID{from: from}
+	queue := []store.SymbolID{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, c := range sb.rankCallees(id, allCallees[id], rootPkg, visited) {
+			if _, seen := parent[c.ID]; seen {
+				continue
 			}
+			parent[c.ID] = id
+			if c.ID == to {
+				var route []store.SymbolID
+				for hop := to; hop != from; hop = parent[hop] {
+					route = append(route, hop)
+				}
+				slices.Reverse(route)
+				return route
+			}
+			queue = append(queue, c.ID)
 		}
-
-		if best == nil {
-			break
-		}
-
-		visited[best.ID] = true
-		path = append(path, int64(best.ID))
-		current = best.ID
 	}
+	return nil
+}
 
-	return path
+// rankCallees scores the unvisited callees of a symbol, best first. Ties
+// are broken by call site order and name so the same index always yields
+// the same spine.
+func (sb *SpineBuilder) rankCallees(
+	callerID store.SymbolID,
+	callees []store.CalleeInfo,
+	rootPkg string,
+	visited map[store.SymbolID]bool,
+) []ScoredCallee {
+	scored := sb.scoreCallees(callerID, callees, rootPkg, visited)
+	sort.Slice(scored, func(i, j int) bool {
+		a, b := &scored[i], &scored[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Symbol.Name != b.Symbol.Name {
+			return a.Symbol.Name < b.Symbol.Name
+		}
+		return a.ID < b.ID
+	})
+	return scored
 }
 
 // scoreCallees assigns scores to callees for main path selection.
@@ -323,7 +482,7 @@ func (sb *SpineBuilder) scoreCallees(
 		}
 
 		// Heuristic 4: Wiring function penalty
-		if sb.filter.CollapseWiring && isWiringFunction(c.Symbol.Name) {
+		if sb.filter.CollapseWiring && isWiringSymbol(&c.Symbol) {
 			score -= 10
 		}
 
@@ -348,6 +507,7 @@ func (sb *SpineBuilder) scoreCallees(
 			CallKind: c.CallKind,
 			Score:    score,
 			Tags:     tagStrs,
+			Line:     c.CallerLine,
 		})
 	}
 
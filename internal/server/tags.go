@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// Page sizes of GET /api/tags/:tag/symbols.
+const (
+	defaultTagPageSize = 100
+	maxTagPageSize     = 1000
+)
+
+// tagsCacheKey caches the response of GET /api/tags.
+const tagsCacheKey = "tags"
+
+// TagsResponse lists every tag in the index.
+type TagsResponse struct {
+	Tags []store.TagCount `json:"tags"` // Most used first
+}
+
+// TagSymbolsResponse is a page of the symbols carrying a tag.
+type TagSymbolsResponse struct {
+	Tag     string               `json:"tag"`
+	Total   int                  `json:"total"` // Symbols carrying the tag, across all pages
+	Limit   int                  `json:"limit"`
+	Offset  int                  `json:"offset"`
+	Symbols []store.TaggedSymbol `json:"symbols"`
+}
+
+// handleTags handles GET /api/tags, listing the distinct tags with the
+// number of symbols carrying each.
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	generation := s.indexGeneration(r.Context())
+	if cached, ok := s.cache.Get(generation, tagsCacheKey); ok {
+		w.Header().Set("X-Cache", "HIT")
+		writeJSON(w, http.StatusOK, cached)
+		return
+	}
+
+	resp, err := s.tags(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get tags: %v", err))
+		return
+	}
+	s.cache.Put(generation, tagsCacheKey, resp)
+	w.Header().Set("X-Cache", "MISS")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// tags counts the symbols carrying each tag.
+func (s *Server) tags(ctx context.Context) (*TagsResponse, error) {
+	counts, err := s.store.GetTagCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if counts == nil {
+		counts = []store.TagCount{}
+	}
+	return &TagsResponse{Tags: counts}, nil
+}
+
+// handleTagSymbols handles GET /api/tags/:tag/symbols?limit=&offset=,
+// listing the symbols carrying a tag a page at a time. The tag is path
+// escaped, e.g. /api/tags/io:db%402/symbols for io:db@2.
+func (s *Server) handleTagSymbols(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	tag, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/tags/"), "/symbols")
+	if !ok || tag == "" {
+		writeError(w, http.StatusNotFound, "expected /api/tags/:tag/symbols")
+		return
+	}
+
+	limit, offset := defaultTagPageSize, 0
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", v))
+			return
+		}
+		limit = min(n, maxTagPageSize)
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid offset %q", v))
+			return
+		}
+		offset = n
+	}
+
+	symbols, total, err := s.store.GetTaggedSymbols(r.Context(), tag, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get tagged symbols: %v", err))
+		return
+	}
+	if symbols == nil {
+		symbols = []store.TaggedSymbol{}
+	}
+	writeJSON(w, http.StatusOK, &TagSymbolsResponse{Tag: tag, Total: total, Limit: limit, Offset: offset, Symbols: symbols})
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// TypeRelationsResponse describes the composition relationships of a type:
+// the types it holds or embeds, and the types holding or embedding it.
+type TypeRelationsResponse struct {
+	Type   *store.Symbol        `json:"type,omitempty"` // Absent for a ?target= type that is not indexed
+	Fields []store.TypeRelation `json:"fields"`         // Fields and embeddings of the type
+	HeldBy []store.TypeRelation `json:"held_by"`        // Fields and embeddings of other types referring to it
+}
+
+// handleTypes handles type relation endpoints:
+//
+//	GET /api/types/:id/relations                   - relations of an indexed type
+//	GET /api/types/relations?target=pkg/path.Name - holders of any named type, e.g. database/sql.DB
+func (s *Server) handleTypes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	path := strings.TrimPrefix(r.URL.Path, "/api/types/")
+
+	var id int64
+	var pkgPath, name string
+	if path == "relations" {
+		target := r.URL.Query().Get("target")
+		dot := strings.LastIndex(target, ".")
+		if dot <= strings.LastIndex(target, "/") || dot == len(target)-1 {
+			writeError(w, http.StatusBadRequest, "target must be a qualified type name, e.g. database/sql.DB")
+			return
+		}
+		pkgPath, name = target[:dot], target[dot+1:]
+		if symID, err := s.store.GetSymbolID(ctx, pkgPath, name, ""); err == nil {
+			id = int64(symID)
+		}
+	} else {
+		idStr, ok := strings.CutSuffix(path, "/relations")
+		var err error
+		if id, err = strconv.ParseInt(idStr, 10, 64); !ok || err != nil || id <= 0 {
+			writeError(w, http.StatusBadRequest, "expected /api/types/:id/relations")
+			return
+		}
+	}
+
+	resp := &TypeRelationsResponse{}
+	if id != 0 {
+		sym, err := s.store.GetSymbolByID(ctx, store.SymbolID(id))
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && sym.Kind != store.SymbolKindType && sym.Kind != store.SymbolKindInterface) {
+			writeError(w, http.StatusNotFound, "type not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get type: %v", err))
+			return
+		}
+		resp.Type = sym
+		pkgPath, name = sym.PkgPath, sym.Name
+
+		if resp.Fields, err = s.store.GetTypeFields(ctx, sym.ID); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get fields: %v", err))
+			return
+		}
+	}
+
+	holders, err := s.store.GetTypeHolders(ctx, pkgPath, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get holders: %v", err))
+		return
+	}
+	resp.HeldBy = holders
+
+	if resp.Fields == nil {
+		resp.Fields = []store.TypeRelation{}
+	}
+	if resp.HeldBy == nil {
+		resp.HeldBy = []store.TypeRelation{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
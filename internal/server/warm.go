@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// DefaultWarmGraphs is the number of entrypoint graphs 'flowlens ui' builds
+// ahead of the first request.
+const DefaultWarmGraphs = 20
+
+// warmRootDepth is the depth of the graphs the UI requests when an
+// entrypoint is opened (see handleGraph).
+const warmRootDepth = 3
+
+// warmCaches fills the response cache in the background with what users
+// ask for first: the tag counts and the root graphs, as the UI requests them
+// by default, of up to warmGraphs entrypoints, starred ones first and then
+// in the order they're listed. It is called at startup and whenever the
+// server sees a new index; a call while a warm-up is running is ignored.
+func (s *Server) warmCaches() {
+	if s.warmGraphs <= 0 || s.cache == nil || !s.warming.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer s.warming.Store(false)
+		start := time.Now()
+		n, err := s.warm(s.background)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("Error warming caches: %v", err)
+			return
+		}
+		log.Printf("Warmed %d entrypoint graphs in %s", n, time.Since(start).Round(time.Millisecond))
+	}()
+}
+
+// warm builds the responses warmCaches caches and returns the number of
+// graphs built. Graphs over the size or time limits are skipped.
+func (s *Server) warm(ctx context.Context) (int, error) {
+	generation := s.indexGeneration(ctx)
+	tags, err := s.tags(ctx)
+	if err != nil {
+		return 0, err
+	}
+	s.cache.Put(generation, tagsCacheKey, tags)
+
+	roots, err := s.warmRoots(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// Build from the call graph in memory once it's loaded
+	s.graphs.get(generation)
+	s.graphs.wait()
+	filter := s.defaultGraphFilter()
+	built := 0
+	for _, id := range roots {
+		key := graphCacheKey("root", id, warmRootDepth, filter, LayoutNone)
+		if _, ok := s.cache.Get(generation, key); ok {
+			continue
+		}
+		builder := s.newGraphBuilder(generation, filter)
+		response, err := builder.BuildFromRoot(ctx, id, warmRootDepth)
+		if err != nil {
+			if ctx.Err() != nil {
+				return built, ctx.Err()
+			}
+			continue // Over the limits; it would be rejected on request too
+		}
+		s.cache.Put(generation, key, response)
+		built++
+	}
+	return built, nil
+}
+
+// warmRoots returns the handler symbols of the entrypoints to warm.
+func (s *Server) warmRoots(ctx context.Context) ([]store.SymbolID, error) {
+	eps, err := s.store.GetEntrypoints(ctx, store.EntrypointFilter{})
+	if err != nil {
+		return nil, err
+	}
+	bookmarks, err := s.store.GetBookmarks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolOf := make(map[store.EntrypointID]store.SymbolID, len(eps))
+	for _, ep := range eps {
+		symbolOf[ep.ID] = ep.SymbolID
+	}
+	var roots []store.SymbolID
+	seen := make(map[store.SymbolID]bool)
+	add := func(id store.SymbolID) {
+		if id != 0 && !seen[id] && len(roots) < s.warmGraphs {
+			seen[id] = true
+			roots = append(roots, id)
+		}
+	}
+	for _, b := range bookmarks {
+		if b.Kind == store.BookmarkEntrypoint {
+			add(symbolOf[store.EntrypointID(b.ID)])
+		}
+	}
+	for _, ep := range eps {
+		add(ep.SymbolID)
+	}
+	return roots, nil
+}
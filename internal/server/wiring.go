@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// maxWiringFold bounds the functions folded into one wiring node, so a
+// large dependency injection graph cannot stall a request.
+const maxWiringFold = 100
+
+// WiringSummary lists the constructor and dependency injection functions
+// reached from a wiring node through other wiring calls, which the
+// collapseWiring filter folds into it rather than showing as a chain.
+type WiringSummary struct {
+	Count  int      `json:"count"`  // Wiring functions folded
+	Labels []string `json:"labels"` // Folded functions, nearest first
+}
+
+// wirePackage is Google Wire, whose calls (wire.Build, wire.NewSet,
+// wire.Bind) only declare the dependency graph.
+const wirePackage = "github.com/google/wire"
+
+// isWiringSymbol reports whether a function is constructor or bootstrap
+// code: a wiring name such as NewX or ProvideX, or a Wire declaration.
+func isWiringSymbol(sym *store.Symbol) bool {
+	return sym.PkgPath == wirePackage || isWiringFunction(sym.Name)
+}
+
+// foldWiring walks the wiring functions reachable from id through wiring
+// calls only, and summarizes them. It returns nil when there are none.
+func (gb *GraphBuilder) foldWiring(ctx context.Context, id store.SymbolID) *WiringSummary {
+	summary := &WiringSummary{}
+	seen := map[store.SymbolID]bool{id: true}
+	queue := []store.SymbolID{id}
+	for len(queue) > 0 && summary.Count < maxWiringFold {
+		callees, err := gb.wiringCallees(ctx, queue[0])
+		queue = queue[1:]
+		if err != nil {
+			break
+		}
+		for _, c := range callees {
+			if summary.Count >= maxWiringFold {
+				break
+			}
+			if seen[c.Symbol.ID] || !isWiringSymbol(&c.Symbol) || gb.shouldFilterCallee(&c.Symbol) {
+				continue
+			}
+			seen[c.Symbol.ID] = true
+			queue = append(queue, c.Symbol.ID)
+			label := c.Symbol.Name
+			if c.Symbol.PkgPath == wirePackage {
+				label = "wire." + label
+			} else if c.Symbol.RecvType != "" {
+				label = "(" + c.Symbol.RecvType + ")." + label
+			}
+			summary.Labels = append(summary.Labels, label)
+			summary.Count++
+		}
+	}
+	if summary.Count == 0 {
+		return nil
+	}
+	return summary
+}
+
+// wiringCallees returns the callees foldWiring follows from a function.
+func (gb *GraphBuilder) wiringCallees(ctx context.Context, id store.SymbolID) ([]store.CalleeInfo, error) {
+	if gb.graph != nil {
+		return gb.graph.Callees[id], nil
+	}
+	return gb.store.GetCallees(ctx, id)
+}
@@ -0,0 +1,299 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Annotations are what users add to an index by hand: pinned symbols,
+// starred entrypoints, manual edges, and saved views, with their notes.
+// Symbols are referred to by identity rather than ID, so annotations
+// exported from one index can be committed to the repository and imported
+// into a fresh index on any machine.
+type Annotations struct {
+	Pinned      []PinnedSymbol      `yaml:"pinned,omitempty" json:"pinned,omitempty"`
+	Starred     []StarredEntrypoint `yaml:"starred,omitempty" json:"starred,omitempty"`
+	ManualEdges []AnnotatedEdge     `yaml:"manual_edges,omitempty" json:"manual_edges,omitempty"`
+	Views       []AnnotatedView     `yaml:"views,omitempty" json:"views,omitempty"`
+}
+
+// SymbolRef identifies a symbol across indexes.
+type SymbolRef struct {
+	Pkg  string `yaml:"pkg" json:"pkg"`
+	Name string `yaml:"name" json:"name"`
+	Recv string `yaml:"recv,omitempty" json:"recv,omitempty"` // Receiver type of a method, e.g. "*UserService"
+}
+
+// PinnedSymbol is an exported pinned symbol.
+type PinnedSymbol struct {
+	Symbol  SymbolRef `yaml:"symbol" json:"symbol"`
+	Note    string    `yaml:"note,omitempty" json:"note,omitempty"`
+	Created string    `yaml:"created,omitempty" json:"created,omitempty"`
+}
+
+// StarredEntrypoint is an exported starred entrypoint.
+type StarredEntrypoint struct {
+	Type    EntrypointType `yaml:"type" json:"type"`
+	Label   string         `yaml:"label" json:"label"`
+	Note    string         `yaml:"note,omitempty" json:"note,omitempty"`
+	Created string         `yaml:"created,omitempty" json:"created,omitempty"`
+}
+
+// AnnotatedEdge is an exported manual edge.
+type AnnotatedEdge struct {
+	Caller  SymbolRef `yaml:"caller" json:"caller"`
+	Callee  SymbolRef `yaml:"callee" json:"callee"`
+	Kind    CallKind  `yaml:"kind,omitempty" json:"kind,omitempty"` // Default static
+	Note    string    `yaml:"note,omitempty" json:"note,omitempty"`
+	Created string    `yaml:"created,omitempty" json:"created,omitempty"`
+}
+
+// AnnotatedView is an exported saved view. State is the view's JSON.
+type AnnotatedView struct {
+	Name    string `yaml:"name" json:"name"`
+	State   string `yaml:"state" json:"state"`
+	Created string `yaml:"created,omitempty" json:"created,omitempty"`
+	Updated string `yaml:"updated,omitempty" json:"updated,omitempty"`
+}
+
+// AnnotationCounts reports what ImportAnnotations wrote.
+type AnnotationCounts struct {
+	Pinned      int `json:"pinned"`
+	Starred     int `json:"starred"`
+	ManualEdges int `json:"manual_edges"`
+	Applied     int `json:"applied"` // Manual edges between symbols in the index, added to the call graph
+	Views       int `json:"views"`
+}
+
+// ExportAnnotations returns the annotations of the index, oldest first
+// (views by name), including those whose symbols or entrypoints are no
+// longer indexed.
+func (s *Store) ExportAnnotations(ctx context.Context) (*Annotations, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	a := &Annotations{}
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT pkg_path, name, recv_type, note, created_at FROM pinned_symbols ORDER BY created_at, key
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("reading pinned symbols: %w", err)
+	}
+	for rows.Next() {
+		var p PinnedSymbol
+		if err := rows.Scan(&p.Symbol.Pkg, &p.Symbol.Name, &p.Symbol.Recv, &p.Note, &p.Created); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("reading pinned symbols: %w", err)
+		}
+		a.Pinned = append(a.Pinned, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading pinned symbols: %w", err)
+	}
+
+	rows, err = s.readDB.QueryContext(ctx, `
+		SELECT type, label, note, created_at FROM starred_entrypoints ORDER BY created_at, key
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("reading starred entrypoints: %w", err)
+	}
+	for rows.Next() {
+		var st StarredEntrypoint
+		if err := rows.Scan(&st.Type, &st.Label, &st.Note, &st.Created); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("reading starred entrypoints: %w", err)
+		}
+		a.Starred = append(a.Starred, st)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading starred entrypoints: %w", err)
+	}
+
+	rows, err = s.readDB.QueryContext(ctx, `
+		SELECT caller_pkg, caller_name, caller_recv, callee_pkg, callee_name, callee_recv, call_kind, note, created_at
+		FROM manual_edges
+		ORDER BY created_at, rowid
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("reading manual edges: %w", err)
+	}
+	for rows.Next() {
+		var e AnnotatedEdge
+		if err := rows.Scan(&e.Caller.Pkg, &e.Caller.Name, &e.Caller.Recv, &e.Callee.Pkg, &e.Callee.Name, &e.Callee.Recv,
+			&e.Kind, &e.Note, &e.Created); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("reading manual edges: %w", err)
+		}
+		a.ManualEdges = append(a.ManualEdges, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading manual edges: %w", err)
+	}
+
+	views, err := s.GetViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading views: %w", err)
+	}
+	for _, v := range views {
+		a.Views = append(a.Views, AnnotatedView{Name: v.Name, State: string(v.State), Created: v.CreatedAt, Updated: v.UpdatedAt})
+	}
+	return a, nil
+}
+
+// validate checks that every annotation identifies what it annotates.
+func (a *Annotations) validate() error {
+	for i, p := range a.Pinned {
+		if p.Symbol.Pkg == "" || p.Symbol.Name == "" {
+			return fmt.Errorf("pinned symbol %d: pkg and name are required", i+1)
+		}
+	}
+	for i, st := range a.Starred {
+		if st.Type == "" || st.Label == "" {
+			return fmt.Errorf("starred entrypoint %d: type and label are required", i+1)
+		}
+	}
+	for i, e := range a.ManualEdges {
+		if e.Caller.Pkg == "" || e.Caller.Name == "" || e.Callee.Pkg == "" || e.Callee.Name == "" {
+			return fmt.Errorf("manual edge %d: caller and callee pkg and name are required", i+1)
+		}
+	}
+	for _, v := range a.Views {
+		if v.Name == "" {
+			return fmt.Errorf("view without a name")
+		}
+		if !json.Valid([]byte(v.State)) {
+			return fmt.Errorf("view %q: state is not valid JSON", v.Name)
+		}
+	}
+	return nil
+}
+
+// ImportAnnotations merges annotations into the index in one transaction:
+// new ones are added, and existing ones (same symbol, entrypoint, edge, or
+// view name) take the imported note, call kind, or state. Manual edges
+// between symbols in the index are added to the call graph; the rest are
+// kept for a later index, as ApplyManualEdges does.
+func (s *Store) ImportAnnotations(ctx context.Context, a *Annotations) (*AnnotationCounts, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.pools.get().write.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Format(time.RFC3339)
+	orNow := func(t string) string {
+		if t == "" {
+			return now
+		}
+		return t
+	}
+	counts := &AnnotationCounts{}
+
+	for _, p := range a.Pinned {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO pinned_symbols (key, pkg_path, name, recv_type, note, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(key) DO UPDATE SET note = excluded.note
+		`, SymbolKey(p.Symbol.Pkg, p.Symbol.Name, p.Symbol.Recv), p.Symbol.Pkg, p.Symbol.Name, p.Symbol.Recv,
+			p.Note, orNow(p.Created)); err != nil {
+			return nil, fmt.Errorf("importing pinned symbol: %w", err)
+		}
+		counts.Pinned++
+	}
+
+	for _, st := range a.Starred {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO starred_entrypoints (key, type, label, note, created_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(key) DO UPDATE SET note = excluded.note
+		`, string(st.Type)+" "+st.Label, st.Type, st.Label, st.Note, orNow(st.Created)); err != nil {
+			return nil, fmt.Errorf("importing starred entrypoint: %w", err)
+		}
+		counts.Starred++
+	}
+
+	for _, e := range a.ManualEdges {
+		kind := e.Kind
+		if kind == "" {
+			kind = CallKindStatic
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO manual_edges (caller_pkg, caller_name, caller_recv, callee_pkg, callee_name, callee_recv, call_kind, note, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(caller_pkg, caller_name, caller_recv, callee_pkg, callee_name, callee_recv) DO UPDATE SET
+				call_kind = excluded.call_kind,
+				note = excluded.note
+		`, e.Caller.Pkg, e.Caller.Name, e.Caller.Recv, e.Callee.Pkg, e.Callee.Name, e.Callee.Recv,
+			kind, e.Note, orNow(e.Created)); err != nil {
+			return nil, fmt.Errorf("importing manual edge: %w", err)
+		}
+		counts.ManualEdges++
+
+		var callerID, calleeID SymbolID
+		err := tx.QueryRowContext(ctx, `
+			SELECT c.id, d.id FROM symbols c, symbols d
+			WHERE c.pkg_path = ? AND c.name = ? AND COALESCE(c.recv_type, '') = ?
+			  AND d.pkg_path = ? AND d.name = ? AND COALESCE(d.recv_type, '') = ?
+		`, e.Caller.Pkg, e.Caller.Name, e.Caller.Recv, e.Callee.Pkg, e.Callee.Name, e.Callee.Recv).Scan(&callerID, &calleeID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue // Not in this index
+		}
+		if err != nil {
+			return nil, fmt.Errorf("resolving manual edge: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO call_edges (caller_id, callee_id, caller_file, caller_line, call_kind, count, resolved_by)
+			SELECT id, ?, file, 0, ?, 1, ? FROM symbols WHERE id = ?
+			ON CONFLICT(caller_id, callee_id, caller_file, caller_line) DO UPDATE SET
+				call_kind = excluded.call_kind
+		`, calleeID, kind, ResolvedManual, callerID); err != nil {
+			return nil, fmt.Errorf("inserting call edge: %w", err)
+		}
+		if err := refreshCallPair(ctx, tx, callerID, calleeID); err != nil {
+			return nil, err
+		}
+		counts.Applied++
+	}
+	if counts.ManualEdges > 0 {
+		if err := touchManualEdges(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range a.Views {
+		created := orNow(v.Created)
+		updated := v.Updated
+		if updated == "" {
+			updated = created
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO views (name, state_json, created_at, updated_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET
+				state_json = excluded.state_json,
+				updated_at = excluded.updated_at
+		`, v.Name, v.State, created, updated); err != nil {
+			return nil, fmt.Errorf("importing view %q: %w", v.Name, err)
+		}
+		counts.Views++
+	}
+
+	return counts, tx.Commit()
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Auth check statuses.
+const (
+	AuthMiddleware = "middleware" // An auth middleware wraps the route
+	AuthCall       = "call"       // The handler reaches an auth check through its calls
+	AuthPublic     = "public"     // The route is configured as public
+	AuthMissing    = "missing"    // No auth middleware or check was found
+)
+
+// AuthCheck records whether an HTTP entrypoint is authenticated.
+type AuthCheck struct {
+	EntrypointID    EntrypointID `json:"entrypoint_id"`
+	EntrypointLabel string       `json:"entrypoint_label,omitempty"` // Filled in on read
+	Status          string       `json:"status"`                     // AuthMiddleware, AuthCall, AuthPublic, or AuthMissing
+	Via             []string     `json:"via,omitempty"`              // Matching middleware, or the call path to the auth check
+	Middleware      []string     `json:"middleware,omitempty"`       // The route's full middleware chain
+	File            string       `json:"file,omitempty"`             // Handler location, filled in on read
+	Line            int          `json:"line,omitempty"`
+}
+
+// InsertAuthCheck records an auth check result within the batch.
+func (b *BatchTx) InsertAuthCheck(ctx context.Context, c *AuthCheck) error {
+	via, err := json.Marshal(c.Via)
+	if err != nil {
+		return fmt.Errorf("encoding via: %w", err)
+	}
+	middleware, err := json.Marshal(c.Middleware)
+	if err != nil {
+		return fmt.Errorf("encoding middleware: %w", err)
+	}
+	_, err = b.tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO auth_checks (entrypoint_id, status, via_json, middleware_json)
+		VALUES (?, ?, ?, ?)
+	`, c.EntrypointID, c.Status, string(via), string(middleware))
+	return err
+}
+
+// GetAuthChecks retrieves auth check results, optionally only those with
+// the given status, ordered by entrypoint label.
+func (s *Store) GetAuthChecks(ctx context.Context, status string) ([]AuthCheck, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT a.entrypoint_id, e.label, a.status, COALESCE(a.via_json, 'null'),
+		       COALESCE(a.middleware_json, 'null'), s.file, s.line, s.repo
+		FROM auth_checks a
+		JOIN entrypoints e ON a.entrypoint_id = e.id
+		JOIN symbols s ON e.symbol_id = s.id
+	`
+	var args []interface{}
+	if status != "" {
+		query += " WHERE a.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY e.label, a.entrypoint_id"
+
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []AuthCheck
+	for rows.Next() {
+		var c AuthCheck
+		var via, middleware, repo string
+		if err := rows.Scan(&c.EntrypointID, &c.EntrypointLabel, &c.Status, &via, &middleware, &c.File, &c.Line, &repo); err != nil {
+			return nil, err
+		}
+		c.File = s.absPath(ctx, repo, c.File)
+		if err := json.Unmarshal([]byte(via), &c.Via); err != nil {
+			return nil, fmt.Errorf("decoding via: %w", err)
+		}
+		if err := json.Unmarshal([]byte(middleware), &c.Middleware); err != nil {
+			return nil, fmt.Errorf("decoding middleware: %w", err)
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Bookmark kinds.
+const (
+	BookmarkSymbol     = "symbol"     // Pinned symbol
+	BookmarkEntrypoint = "entrypoint" // Starred entrypoint
+)
+
+// Bookmark is a pinned symbol or starred entrypoint. Bookmarks are keyed by
+// identity rather than ID so they survive re-indexing.
+type Bookmark struct {
+	Kind      string `json:"kind"`
+	Key       string `json:"key"`          // Symbol key, or "type label" for entrypoints
+	ID        int64  `json:"id,omitempty"` // Current symbol or entrypoint ID; absent when no longer indexed
+	Note      string `json:"note,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// View is a named, saved exploration state. The state is opaque to the
+// server; the UI decides what it holds (entrypoint, filters, expanded nodes).
+type View struct {
+	Name      string          `json:"name"`
+	State     json.RawMessage `json:"state"`
+	CreatedAt string          `json:"created_at"`
+	UpdatedAt string          `json:"updated_at"`
+}
+
+// PinSymbol bookmarks a symbol. Pinning it again updates the note.
+func (s *Store) PinSymbol(ctx context.Context, id SymbolID, note string) (*Bookmark, error) {
+	sym, err := s.GetSymbolByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	b := &Bookmark{
+		Kind:      BookmarkSymbol,
+		Key:       SymbolKey(sym.PkgPath, sym.Name, sym.RecvType),
+		ID:        int64(id),
+		Note:      note,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO pinned_symbols (key, pkg_path, name, recv_type, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET note = excluded.note
+	`, b.Key, sym.PkgPath, sym.Name, sym.RecvType, note, b.CreatedAt)
+	return b, err
+}
+
+// StarEntrypoint bookmarks an entrypoint. Starring it again updates the note.
+func (s *Store) StarEntrypoint(ctx context.Context, id EntrypointID, note string) (*Bookmark, error) {
+	ep, err := s.GetEntrypointByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	b := &Bookmark{
+		Kind:      BookmarkEntrypoint,
+		Key:       string(ep.Type) + " " + ep.Label,
+		ID:        int64(id),
+		Note:      note,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO starred_entrypoints (key, type, label, note, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET note = excluded.note
+	`, b.Key, ep.Type, ep.Label, note, b.CreatedAt)
+	return b, err
+}
+
+// DeleteBookmark removes a bookmark by kind and key, reporting whether it
+// existed.
+func (s *Store) DeleteBookmark(ctx context.Context, kind, key string) (bool, error) {
+	var table string
+	switch kind {
+	case BookmarkSymbol:
+		table = "pinned_symbols"
+	case BookmarkEntrypoint:
+		table = "starred_entrypoints"
+	default:
+		return false, fmt.Errorf("unknown bookmark kind %q", kind)
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM "+table+" WHERE key = ?", key)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetBookmarks returns pinned symbols followed by starred entrypoints,
+// oldest first, with their current IDs.
+func (s *Store) GetBookmarks(ctx context.Context) ([]Bookmark, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT ?, p.key, COALESCE(s.id, 0), p.note, p.created_at
+		FROM pinned_symbols p
+		LEFT JOIN symbols s ON s.pkg_path = p.pkg_path AND s.name = p.name AND COALESCE(s.recv_type, '') = p.recv_type
+		UNION ALL
+		SELECT ?, st.key, COALESCE(MIN(e.id), 0), st.note, st.created_at
+		FROM starred_entrypoints st
+		LEFT JOIN entrypoints e ON e.type = st.type AND e.label = st.label
+		GROUP BY st.key
+		ORDER BY 1 DESC, 5, 2
+	`, BookmarkSymbol, BookmarkEntrypoint)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookmarks []Bookmark
+	for rows.Next() {
+		var b Bookmark
+		if err := rows.Scan(&b.Kind, &b.Key, &b.ID, &b.Note, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
+
+// SaveView stores a named view, replacing the state of an existing view
+// with the same name. It reports whether the view was created.
+func (s *Store) SaveView(ctx context.Context, name string, state json.RawMessage) (*View, bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().Format(time.RFC3339)
+	var createdAt string
+	err := s.db.QueryRowContext(ctx, "SELECT created_at FROM views WHERE name = ?", name).Scan(&createdAt)
+	created := err == sql.ErrNoRows
+	if err != nil && !created {
+		return nil, false, err
+	}
+	if created {
+		createdAt = now
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO views (name, state_json, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			state_json = excluded.state_json,
+			updated_at = excluded.updated_at
+	`, name, string(state), createdAt, now)
+	if err != nil {
+		return nil, false, err
+	}
+	return &View{Name: name, State: state, CreatedAt: createdAt, UpdatedAt: now}, created, nil
+}
+
+// GetViews returns the saved views ordered by name.
+func (s *Store) GetViews(ctx context.Context) ([]View, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT name, state_json, created_at, updated_at FROM views ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []View
+	for rows.Next() {
+		var v View
+		var state string
+		if err := rows.Scan(&v.Name, &state, &v.CreatedAt, &v.UpdatedAt); err != nil {
+			return nil, err
+		}
+		v.State = json.RawMessage(state)
+		views = append(views, v)
+	}
+	return views, rows.Err()
+}
+
+// DeleteView removes a saved view, reporting whether it existed.
+func (s *Store) DeleteView(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM views WHERE name = ?", name)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CallGraph is the whole call graph of an index held in memory, so graph
+// traversals need no queries. It is read-only once loaded and may be shared
+// by concurrent readers, which must not modify what it returns.
+type CallGraph struct {
+	Symbols map[SymbolID]*Symbol      // Every symbol, without its doc comment, span, or structured signature
+	Tags    map[SymbolID][]Tag        // Tags of the symbols carrying any
+	Callees map[SymbolID][]CalleeInfo // Callees of every symbol with any, as GetCallPairs lists them (or GetCallees, without call pairs)
+	Calls   map[SymbolID][]CalleeInfo // Callees of every symbol with any, one per call site, as GetCallees lists them
+	Callers map[SymbolID][]CallerInfo // Callers of every symbol with any, as GetCallers lists them
+	Edges   int                       // Entries in Callees
+	Pairs   bool                      // Callees come from call pairs, one per callee, rather than call edges
+}
+
+// SymbolAndTags returns a symbol and its tags, or nil if the symbol isn't
+// in the graph.
+func (g *CallGraph) SymbolAndTags(id SymbolID) (*Symbol, []Tag) {
+	return g.Symbols[id], g.Tags[id]
+}
+
+// CountCallEdges returns the number of call edges in the index, the size
+// LoadCallGraph's memory grows with.
+func (s *Store) CountCallEdges(ctx context.Context) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var n int
+	err := s.readDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM call_edges").Scan(&n)
+	return n, err
+}
+
+// LoadCallGraph reads every symbol, tag, call edge, and call pair into
+// memory. Being one bulk read, it isn't bounded by the query timeout;
+// cancel ctx to stop it.
+func (s *Store) LoadCallGraph(ctx context.Context) (*CallGraph, error) {
+	g := &CallGraph{
+		Symbols: make(map[SymbolID]*Symbol),
+		Tags:    make(map[SymbolID][]Tag),
+		Calls:   make(map[SymbolID][]CalleeInfo),
+		Callers: make(map[SymbolID][]CallerInfo),
+	}
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT id, pkg_path, name, kind, COALESCE(recv_type, ''), file, line, COALESCE(sig, ''), repo, value
+		FROM symbols
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("loading symbols: %w", err)
+	}
+	for rows.Next() {
+		sym := &Symbol{}
+		if err := rows.Scan(&sym.ID, &sym.PkgPath, &sym.Name, &sym.Kind, &sym.RecvType, &sym.File, &sym.Line, &sym.Sig, &sym.Repo, &sym.Value); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("loading symbols: %w", err)
+		}
+		sym.File = s.absPath(ctx, sym.Repo, sym.File)
+		g.Symbols[sym.ID] = sym
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("loading symbols: %w", err)
+	}
+
+	rows, err = s.readDB.QueryContext(ctx, `SELECT symbol_id, tag, COALESCE(reason, '') FROM tags ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("loading tags: %w", err)
+	}
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.SymbolID, &t.Tag, &t.Reason); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("loading tags: %w", err)
+		}
+		g.Tags[t.SymbolID] = append(g.Tags[t.SymbolID], t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("loading tags: %w", err)
+	}
+
+	if err := s.loadCallEdges(ctx, g); err != nil {
+		return nil, err
+	}
+	if g.Pairs = s.HasCallPairs(ctx); !g.Pairs {
+		g.Callees = g.Calls
+		for _, calls := range g.Calls {
+			g.Edges += len(calls)
+		}
+		return g, nil
+	}
+	if err := s.loadCallPairs(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// loadCallEdges fills the Calls and Callers of a graph whose symbols and
+// tags are loaded.
+func (s *Store) loadCallEdges(ctx context.Context, g *CallGraph) error {
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT caller_id, callee_id, call_kind, resolved_by, caller_file, caller_line, count, expr
+		FROM call_edges
+		ORDER BY caller_id, caller_line, rowid
+	`)
+	if err != nil {
+		return fmt.Errorf("loading calls: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var callerID SymbolID
+		var c CalleeInfo
+		if err := rows.Scan(&callerID, &c.Symbol.ID, &c.CallKind, &c.ResolvedBy, &c.CallerFile, &c.CallerLine, &c.Count, &c.Expr); err != nil {
+			return fmt.Errorf("loading calls: %w", err)
+		}
+		caller, callee := g.Symbols[callerID], g.Symbols[c.Symbol.ID]
+		if caller == nil || callee == nil {
+			continue
+		}
+		c.Symbol = *callee
+		c.Tags = g.Tags[callee.ID]
+		c.CallerFile = s.absPath(ctx, caller.Repo, c.CallerFile)
+		g.Calls[callerID] = append(g.Calls[callerID], c)
+		g.Callers[callee.ID] = append(g.Callers[callee.ID], CallerInfo{
+			Symbol:     *caller,
+			CallKind:   c.CallKind,
+			ResolvedBy: c.ResolvedBy,
+			CallerFile: c.CallerFile,
+			CallerLine: c.CallerLine,
+			Count:      c.Count,
+			Expr:       c.Expr,
+			Tags:       g.Tags[callerID],
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("loading calls: %w", err)
+	}
+	for _, callers := range g.Callers {
+		sort.SliceStable(callers, func(i, j int) bool {
+			if callers[i].Symbol.PkgPath != callers[j].Symbol.PkgPath {
+				return callers[i].Symbol.PkgPath < callers[j].Symbol.PkgPath
+			}
+			return callers[i].Symbol.Name < callers[j].Symbol.Name
+		})
+	}
+	return nil
+}
+
+// loadCallPairs fills the Callees of a graph from the call pairs.
+func (s *Store) loadCallPairs(ctx context.Context, g *CallGraph) error {
+	g.Callees = make(map[SymbolID][]CalleeInfo)
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT caller_id, callee_id, call_kind, kinds, resolved_by, caller_file, caller_line, count, callsites, expr, sites
+		FROM call_pairs
+		ORDER BY caller_id, caller_line, callee_id
+	`)
+	if err != nil {
+		return fmt.Errorf("loading call pairs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var caller SymbolID
+		var c CalleeInfo
+		var kinds, sites string
+		if err := rows.Scan(&caller, &c.Symbol.ID, &c.CallKind, &kinds, &c.ResolvedBy, &c.CallerFile, &c.CallerLine,
+			&c.Count, &c.Callsites, &c.Expr, &sites); err != nil {
+			return fmt.Errorf("loading call pairs: %w", err)
+		}
+		callerSym, callee := g.Symbols[caller], g.Symbols[c.Symbol.ID]
+		if callerSym == nil || callee == nil {
+			continue
+		}
+		c.Symbol = *callee
+		c.Tags = g.Tags[callee.ID]
+		if kinds != "" {
+			for _, k := range strings.Split(kinds, ",") {
+				c.Kinds = append(c.Kinds, CallKind(k))
+			}
+		}
+		if c.Sites, err = s.decodeSites(ctx, callerSym.Repo, sites); err != nil {
+			return err
+		}
+		c.CallerFile = s.absPath(ctx, callerSym.Repo, c.CallerFile)
+		g.Callees[caller] = append(g.Callees[caller], c)
+		g.Edges++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("loading call pairs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// SymbolKey returns the stable identity of a symbol across indexing runs,
+// e.g. "myapp/service.(*UserService).GetUser" or "myapp/service.NewUserService".
+func SymbolKey(pkgPath, name, recvType string) string {
+	if recvType != "" {
+		return fmt.Sprintf("%s.(%s).%s", pkgPath, recvType, name)
+	}
+	return pkgPath + "." + name
+}
+
+// SnapshotSymbol is the location of a symbol in an index snapshot.
+type SnapshotSymbol struct {
+	ID   SymbolID
+	Kind SymbolKind
+	File string
+	Line int
+	Sig  string
+}
+
+// Location returns the symbol's position as file:line.
+func (s SnapshotSymbol) Location() string {
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// SnapshotEntrypoint is the handler of an entrypoint in an index snapshot.
+type SnapshotEntrypoint struct {
+	SymbolID  SymbolID
+	SymbolKey string
+}
+
+// IndexSnapshot is a lightweight, identity-keyed view of an index used to
+// compute what changed between two indexing runs.
+type IndexSnapshot struct {
+	Symbols     map[string]SnapshotSymbol     // Symbol key -> location
+	Entrypoints map[string]SnapshotEntrypoint // "type label" -> handler
+	Edges       map[string]SymbolID           // "caller -> callee" -> caller ID
+}
+
+// LoadSnapshot reads the identity-keyed view of the current index contents.
+func (s *Store) LoadSnapshot(ctx context.Context) (*IndexSnapshot, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	snap := &IndexSnapshot{
+		Symbols:     make(map[string]SnapshotSymbol),
+		Entrypoints: make(map[string]SnapshotEntrypoint),
+		Edges:       make(map[string]SymbolID),
+	}
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT id, pkg_path, name, COALESCE(recv_type, ''), kind, file, line, COALESCE(sig, ''), repo
+		FROM symbols
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying symbols: %w", err)
+	}
+	for rows.Next() {
+		var pkgPath, name, recvType, repo string
+		var sym SnapshotSymbol
+		if err := rows.Scan(&sym.ID, &pkgPath, &name, &recvType, &sym.Kind, &sym.File, &sym.Line, &sym.Sig, &repo); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sym.File = s.absPath(ctx, repo, sym.File)
+		snap.Symbols[SymbolKey(pkgPath, name, recvType)] = sym
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.readDB.QueryContext(ctx, `
+		SELECT e.type, e.label, s.id, s.pkg_path, s.name, COALESCE(s.recv_type, '')
+		FROM entrypoints e
+		JOIN symbols s ON e.symbol_id = s.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying entrypoints: %w", err)
+	}
+	for rows.Next() {
+		var epType, label, pkgPath, name, recvType string
+		var ep SnapshotEntrypoint
+		if err := rows.Scan(&epType, &label, &ep.SymbolID, &pkgPath, &name, &recvType); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ep.SymbolKey = SymbolKey(pkgPath, name, recvType)
+		snap.Entrypoints[epType+" "+label] = ep
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.readDB.QueryContext(ctx, `
+		SELECT DISTINCT s1.id, s1.pkg_path, s1.name, COALESCE(s1.recv_type, ''),
+		       s2.pkg_path, s2.name, COALESCE(s2.recv_type, '')
+		FROM call_edges ce
+		JOIN symbols s1 ON ce.caller_id = s1.id
+		JOIN symbols s2 ON ce.callee_id = s2.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying call edges: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var callerID SymbolID
+		var callerPkg, callerName, callerRecv, calleePkg, calleeName, calleeRecv string
+		if err := rows.Scan(&callerID, &callerPkg, &callerName, &callerRecv, &calleePkg, &calleeName, &calleeRecv); err != nil {
+			return nil, err
+		}
+		key := SymbolKey(callerPkg, callerName, callerRecv) + " -> " + SymbolKey(calleePkg, calleeName, calleeRecv)
+		snap.Edges[key] = callerID
+	}
+	return snap, rows.Err()
+}
+
+// InsertChange records a change log entry within the batch.
+func (b *BatchTx) InsertChange(ctx context.Context, c *Change) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT INTO changes (entity, change, key, symbol_id, old_location, new_location, old_key)
+		VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''))
+		ON CONFLICT(entity, key) DO UPDATE SET
+			change = excluded.change,
+			symbol_id = excluded.symbol_id,
+			old_location = excluded.old_location,
+			new_location = excluded.new_location,
+			old_key = excluded.old_key
+	`, c.Entity, c.Kind, c.Key, nullableSymbolID(c.SymbolID), c.OldLocation, c.NewLocation, c.OldKey)
+	return err
+}
+
+// ChangeFilter specifies filtering options for GetChanges.
+type ChangeFilter struct {
+	Entity ChangeEntity // Filter by entity (empty = all)
+	Kind   ChangeKind   // Filter by change kind (empty = all)
+	Limit  int          // Max results (0 = no limit)
+}
+
+// GetChanges retrieves the change log of the latest indexing run.
+func (s *Store) GetChanges(ctx context.Context, filter ChangeFilter) ([]Change, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT entity, change, key, COALESCE(symbol_id, 0),
+		       COALESCE(old_location, ''), COALESCE(new_location, ''), COALESCE(old_key, '')
+		FROM changes
+		WHERE 1=1
+	`
+	var args []interface{}
+
+	if filter.Entity != "" {
+		query += " AND entity = ?"
+		args = append(args, filter.Entity)
+	}
+	if filter.Kind != "" {
+		query += " AND change = ?"
+		args = append(args, filter.Kind)
+	}
+
+	query += " ORDER BY entity, change, key"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []Change
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.Entity, &c.Kind, &c.Key, &c.SymbolID, &c.OldLocation, &c.NewLocation, &c.OldKey); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// nullableSymbolID stores zero IDs as NULL.
+func nullableSymbolID(id SymbolID) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"sort"
+)
+
+// ExternalCall is a call from a project function into a third-party module.
+type ExternalCall struct {
+	CallerID   SymbolID `json:"caller_id"`
+	Module     string   `json:"module"`            // Module path, e.g. github.com/stripe/stripe-go/v76
+	Version    string   `json:"version,omitempty"` // Module version from go.mod
+	PkgPath    string   `json:"pkg_path"`
+	Func       string   `json:"func"`                // "pkgpath.Func" or "pkgpath.Type.Method"
+	Name       string   `json:"name"`                // Function or method name
+	RecvType   string   `json:"recv_type,omitempty"` // Receiver as symbols record it, e.g. "*Client"
+	CallKind   CallKind `json:"call_kind"`
+	CallerFile string   `json:"caller_file"`
+	CallerLine int      `json:"caller_line"`
+}
+
+// EntrypointDependencies lists the third-party modules reachable from an
+// entrypoint through the project call graph.
+type EntrypointDependencies struct {
+	EntrypointID EntrypointID      `json:"entrypoint_id"`
+	Label        string            `json:"label"`
+	Type         EntrypointType    `json:"type"`
+	Modules      []ReachableModule `json:"modules"`
+}
+
+// ReachableModule is a third-party module and the functions of it that an
+// entrypoint can call.
+type ReachableModule struct {
+	Module  string   `json:"module"`
+	Version string   `json:"version,omitempty"`
+	Funcs   []string `json:"funcs"`
+}
+
+// InsertExternalCall records a call into a third-party module within the batch.
+func (b *BatchTx) InsertExternalCall(ctx context.Context, c *ExternalCall) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO external_calls (caller_id, module, version, pkg_path, func, name, recv_type, call_kind, caller_file, caller_line)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.CallerID, c.Module, c.Version, c.PkgPath, c.Func, c.Name, c.RecvType, c.CallKind, relPath(b.baseDir, c.CallerFile), c.CallerLine)
+	return err
+}
+
+// GetExternalCalls returns all recorded third-party calls keyed by caller.
+func (s *Store) GetExternalCalls(ctx context.Context) (map[SymbolID][]ExternalCall, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT ec.caller_id, ec.module, COALESCE(ec.version, ''), ec.pkg_path, ec.func, ec.name, ec.recv_type, ec.call_kind,
+		       ec.caller_file, ec.caller_line, COALESCE(s.repo, '')
+		FROM external_calls ec
+		LEFT JOIN symbols s ON s.id = ec.caller_id
+		ORDER BY ec.caller_id, ec.module, ec.func
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	calls := make(map[SymbolID][]ExternalCall)
+	for rows.Next() {
+		var c ExternalCall
+		var repo string
+		if err := rows.Scan(&c.CallerID, &c.Module, &c.Version, &c.PkgPath, &c.Func, &c.Name, &c.RecvType, &c.CallKind, &c.CallerFile, &c.CallerLine, &repo); err != nil {
+			return nil, err
+		}
+		c.CallerFile = s.absPath(ctx, repo, c.CallerFile)
+		calls[c.CallerID] = append(calls[c.CallerID], c)
+	}
+	return calls, rows.Err()
+}
+
+// GetEntrypointDependencies walks the call graph from every entrypoint and
+// collects the third-party modules its reachable functions call into.
+// Entrypoints that reach no third-party code are included with no modules.
+func (s *Store) GetEntrypointDependencies(ctx context.Context) ([]EntrypointDependencies, error) {
+	eps, err := s.GetEntrypoints(ctx, EntrypointFilter{})
+	if err != nil {
+		return nil, err
+	}
+	external, err := s.GetExternalCalls(ctx)
+	if err != nil {
+		return nil, err
+	}
+	callees, err := s.getCalleeAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]EntrypointDependencies, 0, len(eps))
+	for _, ep := range eps {
+		modules := make(map[string]*ReachableModule)
+		funcs := make(map[string]map[string]bool)
+
+		seen := map[SymbolID]bool{ep.SymbolID: true}
+		queue := []SymbolID{ep.SymbolID}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			for _, c := range external[id] {
+				m, ok := modules[c.Module]
+				if !ok {
+					m = &ReachableModule{Module: c.Module, Version: c.Version}
+					modules[c.Module] = m
+					funcs[c.Module] = make(map[string]bool)
+				}
+				if !funcs[c.Module][c.Func] {
+					funcs[c.Module][c.Func] = true
+					m.Funcs = append(m.Funcs, c.Func)
+				}
+			}
+			for _, next := range callees[id] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		deps := EntrypointDependencies{
+			EntrypointID: ep.ID,
+			Label:        ep.Label,
+			Type:         ep.Type,
+			Modules:      []ReachableModule{},
+		}
+		for _, m := range modules {
+			sort.Strings(m.Funcs)
+			deps.Modules = append(deps.Modules, *m)
+		}
+		sort.Slice(deps.Modules, func(i, j int) bool { return deps.Modules[i].Module < deps.Modules[j].Module })
+		result = append(result, deps)
+	}
+	return result, nil
+}
+
+// FilterDependencies keeps the entrypoints reaching module, each with only
+// that module listed.
+func FilterDependencies(deps []EntrypointDependencies, module string) []EntrypointDependencies {
+	var kept []EntrypointDependencies
+	for _, ep := range deps {
+		for _, m := range ep.Modules {
+			if m.Module == module {
+				ep.Modules = []ReachableModule{m}
+				kept = append(kept, ep)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// getCalleeAdjacency returns the distinct callees of every caller.
+func (s *Store) getCalleeAdjacency(ctx context.Context) (map[SymbolID][]SymbolID, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `SELECT DISTINCT caller_id, callee_id FROM call_edges`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adj := make(map[SymbolID][]SymbolID)
+	for rows.Next() {
+		var caller, callee SymbolID
+		if err := rows.Scan(&caller, &callee); err != nil {
+			return nil, err
+		}
+		adj[caller] = append(adj[caller], callee)
+	}
+	return adj, rows.Err()
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+)
+
+// Diagnostic severities.
+const (
+	// SeverityError marks problems that leave a package or file out of the
+	// graph, such as unparsable source or an unresolvable import.
+	SeverityError = "error"
+	// SeverityWarning marks problems that leave a package in the graph with
+	// gaps, such as type errors that hide some calls.
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a problem reported while loading packages.
+type Diagnostic struct {
+	PkgPath  string `json:"pkg_path"`
+	File     string `json:"file,omitempty"` // Empty when the error has no position
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Kind     string `json:"kind"` // "list", "parse", "type", or "unknown"
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Repo     string `json:"repo,omitempty"`
+}
+
+// DiagnosticFilter specifies criteria for listing diagnostics.
+type DiagnosticFilter struct {
+	Severity string // Filter by severity (empty = all)
+	PkgPath  string // Filter by package (empty = all)
+}
+
+// InsertDiagnostic records a loader diagnostic within the batch. Errors
+// reported through several importing packages are stored once.
+func (b *BatchTx) InsertDiagnostic(ctx context.Context, d *Diagnostic) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO diagnostics (pkg_path, file, line, col, kind, severity, message, repo)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.PkgPath, relPath(b.baseDir, d.File), d.Line, d.Column, d.Kind, d.Severity, d.Message, d.Repo)
+	return err
+}
+
+// GetDiagnostics retrieves loader diagnostics, errors first, then by package
+// and position.
+func (s *Store) GetDiagnostics(ctx context.Context, filter DiagnosticFilter) ([]Diagnostic, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT pkg_path, file, line, col, kind, severity, message, repo
+		FROM diagnostics
+		WHERE 1=1
+	`
+	var args []interface{}
+
+	if filter.Severity != "" {
+		query += " AND severity = ?"
+		args = append(args, filter.Severity)
+	}
+	if filter.PkgPath != "" {
+		query += " AND pkg_path = ?"
+		args = append(args, filter.PkgPath)
+	}
+
+	query += " ORDER BY severity = 'error' DESC, pkg_path, file, line, col"
+
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var diags []Diagnostic
+	for rows.Next() {
+		var d Diagnostic
+		if err := rows.Scan(&d.PkgPath, &d.File, &d.Line, &d.Column, &d.Kind, &d.Severity, &d.Message, &d.Repo); err != nil {
+			return nil, err
+		}
+		d.File = s.absPath(ctx, d.Repo, d.File)
+		diags = append(diags, d)
+	}
+	return diags, rows.Err()
+}
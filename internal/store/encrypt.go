@@ -0,0 +1,299 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// An encrypted index is kept at rest in EncryptedPath(dbPath): encMagic, a
+// random salt, then the database in chunks of encChunkSize sealed with
+// AES-256-GCM under a key derived from the index key and the salt. Each
+// chunk's nonce is its number, and the last chunk is marked in its
+// additional data, so chunks can't be reordered or cut off unnoticed.
+const (
+	encMagic     = "FLENC\x00\x00\x01"
+	encSaltSize  = 32
+	encChunkSize = 1 << 20
+)
+
+// ErrEncrypted is returned for an encrypted index opened without its key.
+var ErrEncrypted = errors.New("index is encrypted, and no key was given")
+
+// EncryptedPath returns where the index at dbPath is kept when encrypted.
+func EncryptedPath(dbPath string) string {
+	return dbPath + ".enc"
+}
+
+// Exists reports whether there is an index at dbPath, decrypted or not.
+func Exists(dbPath string) bool {
+	for _, path := range []string{dbPath, EncryptedPath(dbPath)} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenEncrypted opens the index at dbPath like OpenFile or, if readOnly,
+// OpenReadOnly, keeping it encrypted with key at rest. While open, the
+// index is decrypted to dbPath, readable only by its owner; closing the
+// store encrypts it again and removes the decrypted copy, unless another
+// connection still has it open, in which case the last one to close it
+// does. A decrypted index already at dbPath, e.g. one indexed before
+// encryption was turned on or left by a process that crashed, is used as
+// is and encrypted on close. A nil key opens dbPath unencrypted, failing
+// with ErrEncrypted if only its encrypted copy exists.
+func OpenEncrypted(dbPath, projectDir string, key []byte, readOnly bool) (*Store, error) {
+	open := OpenFile
+	if readOnly {
+		open = OpenReadOnly
+	}
+	if key == nil {
+		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+			if _, err := os.Stat(EncryptedPath(dbPath)); err == nil {
+				return nil, fmt.Errorf("%s: %w", EncryptedPath(dbPath), ErrEncrypted)
+			}
+		}
+		return open(dbPath, projectDir)
+	}
+	if readOnly && !Exists(dbPath) {
+		return open(dbPath, projectDir)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("creating index directory: %w", err)
+	}
+	if err := decryptIndex(dbPath, key); err != nil {
+		return nil, err
+	}
+	st, err := open(dbPath, projectDir)
+	if err != nil {
+		sealIndex(dbPath, key)
+		return nil, err
+	}
+	st.key = key
+	return st, nil
+}
+
+// decryptIndex makes the decrypted index available at dbPath, readable
+// only by its owner. An index that doesn't exist yet is created empty with
+// those permissions, which SQLite gives its WAL and shared-memory files too.
+func decryptIndex(dbPath string, key []byte) error {
+	if _, err := os.Stat(dbPath); err == nil {
+		for _, f := range []string{dbPath, dbPath + "-wal", dbPath + "-shm"} {
+			if err := os.Chmod(f, 0600); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dbPath), filepath.Base(dbPath)+".*")
+	if err != nil {
+		return fmt.Errorf("decrypting index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	err = decryptFile(EncryptedPath(dbPath), tmp, key)
+	if os.IsNotExist(err) {
+		err = nil // A new index
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("decrypting index: %w", err)
+	}
+	// Another process may have decrypted it meanwhile; either copy will do
+	if err := os.Link(tmp.Name(), dbPath); err != nil && !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("decrypting index: %w", err)
+	}
+	return nil
+}
+
+// sealIndex encrypts the index at dbPath to EncryptedPath(dbPath) and
+// removes it, unless another connection has it open or it is already gone,
+// e.g. encrypted by another process after replacing the file this one had
+// open. Leaving WAL mode needs the only connection to the database, so it
+// tells whether there are others; the exclusive transaction then keeps new
+// ones from reading or writing the index while it is encrypted.
+func sealIndex(dbPath string, key []byte) error {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil
+	}
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=rw&_pragma=busy_timeout(0)")
+	if err != nil {
+		return fmt.Errorf("encrypting index: %w", err)
+	}
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("encrypting index: %w", err)
+	}
+	defer conn.Close()
+
+	var mode string
+	err = conn.QueryRowContext(ctx, "PRAGMA journal_mode = DELETE").Scan(&mode)
+	var serr *sqlite.Error
+	if errors.As(err, &serr) && serr.Code()&0xff == sqlite3.SQLITE_BUSY {
+		return nil // Still open elsewhere
+	}
+	if err != nil {
+		return fmt.Errorf("encrypting index: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN EXCLUSIVE"); err != nil {
+		if errors.As(err, &serr) && serr.Code()&0xff == sqlite3.SQLITE_BUSY {
+			return nil
+		}
+		return fmt.Errorf("encrypting index: %w", err)
+	}
+
+	enc := EncryptedPath(dbPath)
+	tmp, err := os.CreateTemp(filepath.Dir(enc), filepath.Base(enc)+".*")
+	if err != nil {
+		conn.ExecContext(ctx, "ROLLBACK")
+		return fmt.Errorf("encrypting index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	err = encryptFile(dbPath, tmp, key)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), enc)
+	}
+	conn.ExecContext(ctx, "ROLLBACK")
+	if err != nil {
+		return fmt.Errorf("encrypting index: %w", err)
+	}
+
+	// Windows can't remove files that are still open
+	conn.Close()
+	db.Close()
+	return RemoveFiles(dbPath)
+}
+
+// encryptFile writes the file at src, encrypted with key, to dst.
+func encryptFile(src string, dst io.Writer, key []byte) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	salt := make([]byte, encSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := fileCipher(key, salt)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(dst)
+	w.WriteString(encMagic)
+	w.Write(salt)
+
+	buf := make([]byte, encChunkSize)
+	sealed := make([]byte, 0, encChunkSize+aead.Overhead())
+	for n := uint64(0); ; n++ {
+		size, err := io.ReadFull(in, buf)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return err
+		}
+		sealed = aead.Seal(sealed[:0], chunkNonce(aead, n), buf[:size], chunkAD(last))
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if last {
+			return w.Flush()
+		}
+	}
+}
+
+// decryptFile writes the file encryptFile wrote at src, decrypted with
+// key, to dst.
+func decryptFile(src string, dst io.Writer, key []byte) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	r := bufio.NewReader(in)
+
+	header := make([]byte, len(encMagic)+encSaltSize)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(encMagic)]) != encMagic {
+		return fmt.Errorf("%s is not an encrypted index", src)
+	}
+	aead, err := fileCipher(key, header[len(encMagic):])
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, encChunkSize+aead.Overhead())
+	plain := make([]byte, 0, encChunkSize)
+	for n := uint64(0); ; n++ {
+		size, err := io.ReadFull(r, buf)
+		last := err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			if err == io.EOF {
+				return fmt.Errorf("%s is truncated", src)
+			}
+			return err
+		}
+		plain, err = aead.Open(plain[:0], chunkNonce(aead, n), buf[:size], chunkAD(last))
+		if err != nil {
+			return fmt.Errorf("%s: wrong key, or the file is damaged", src)
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// fileCipher returns the AES-256-GCM cipher for the encrypted file with the
+// given salt.
+func fileCipher(key, salt []byte) (cipher.AEAD, error) {
+	fileKey, err := hkdf.Key(sha256.New, key, salt, "flowlens index", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce of chunk n.
+func chunkNonce(aead cipher.AEAD, n uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], n)
+	return nonce
+}
+
+// chunkAD returns the additional data of a chunk, marking the last one.
+func chunkAD(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
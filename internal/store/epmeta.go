@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SetEntrypointMeta sets one key of an entrypoint's metadata to the JSON
+// encoding of value, keeping the other keys. Analyzers use it to annotate
+// entrypoints found earlier in the run, e.g. with "status_codes".
+func (b *BatchTx) SetEntrypointMeta(ctx context.Context, id EntrypointID, key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", key, err)
+	}
+	_, err = b.tx.ExecContext(ctx, `
+		UPDATE entrypoints
+		SET meta_json = json_set(COALESCE(NULLIF(meta_json, ''), '{}'), '$.' || ?, json(?))
+		WHERE id = ?
+	`, key, string(encoded), id)
+	return err
+}
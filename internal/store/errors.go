@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"sort"
+)
+
+// Error site kinds.
+const (
+	ErrorWrap    = "wrap"    // The error is wrapped with context (fmt.Errorf("%w"), errors.Wrap)
+	ErrorSwallow = "swallow" // A returned error is discarded or only compared against nil
+	ErrorStatus  = "status"  // The error is turned into an HTTP or gRPC status response
+)
+
+// ErrorSite is a call where a function wraps, drops, or converts an error.
+type ErrorSite struct {
+	SymbolID SymbolID `json:"symbol_id"` // Function containing the call
+	Kind     string   `json:"kind"`      // ErrorWrap, ErrorSwallow, or ErrorStatus
+	Call     string   `json:"call"`      // Called function, e.g. "fmt.Errorf"
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+}
+
+// FunctionErrors lists the error sites of one function reachable from an
+// entrypoint.
+type FunctionErrors struct {
+	Symbol Symbol      `json:"symbol"`
+	Layer  string      `json:"layer,omitempty"` // From the function's layer tag
+	Depth  int         `json:"depth"`           // Calls from the entrypoint handler
+	Sites  []ErrorSite `json:"sites"`
+}
+
+// InsertErrorSite records an error site within the batch.
+func (b *BatchTx) InsertErrorSite(ctx context.Context, site *ErrorSite) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO error_sites (symbol_id, kind, call, file, line)
+		VALUES (?, ?, ?, ?, ?)
+	`, site.SymbolID, site.Kind, site.Call, relPath(b.baseDir, site.File), site.Line)
+	return err
+}
+
+// GetReachableErrorSites walks the call graph from rootID and returns the
+// reachable functions that have error sites, nearest first, each with its
+// sites in source order.
+func (s *Store) GetReachableErrorSites(ctx context.Context, rootID SymbolID) ([]FunctionErrors, error) {
+	callees, err := s.getCalleeAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	depth := map[SymbolID]int{rootID: 0}
+	queue := []SymbolID{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range callees[id] {
+			if _, seen := depth[next]; !seen {
+				depth[next] = depth[id] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT e.symbol_id, e.kind, e.call, e.file, e.line,
+		       s.pkg_path, s.name, s.kind, COALESCE(s.recv_type, ''), s.file, s.line, s.repo,
+		       COALESCE((SELECT substr(t.tag, 7) FROM tags t WHERE t.symbol_id = s.id AND t.tag LIKE 'layer:%' LIMIT 1), '')
+		FROM error_sites e
+		JOIN symbols s ON s.id = e.symbol_id
+		ORDER BY e.symbol_id, e.file, e.line
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var funcs []FunctionErrors
+	for rows.Next() {
+		var site ErrorSite
+		var sym Symbol
+		var layer string
+		if err := rows.Scan(&site.SymbolID, &site.Kind, &site.Call, &site.File, &site.Line,
+			&sym.PkgPath, &sym.Name, &sym.Kind, &sym.RecvType, &sym.File, &sym.Line, &sym.Repo, &layer); err != nil {
+			return nil, err
+		}
+		d, ok := depth[site.SymbolID]
+		if !ok {
+			continue
+		}
+		site.File = s.absPath(ctx, sym.Repo, site.File)
+		if len(funcs) == 0 || funcs[len(funcs)-1].Symbol.ID != site.SymbolID {
+			sym.ID = site.SymbolID
+			sym.File = s.absPath(ctx, sym.Repo, sym.File)
+			funcs = append(funcs, FunctionErrors{Symbol: sym, Layer: layer, Depth: d})
+		}
+		cur := &funcs[len(funcs)-1]
+		cur.Sites = append(cur.Sites, site)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(funcs, func(i, j int) bool {
+		if funcs[i].Depth != funcs[j].Depth {
+			return funcs[i].Depth < funcs[j].Depth
+		}
+		a, b := funcs[i].Symbol, funcs[j].Symbol
+		return SymbolKey(a.PkgPath, a.Name, a.RecvType) < SymbolKey(b.PkgPath, b.Name, b.RecvType)
+	})
+	return funcs, nil
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// FlagUse is a feature-flag evaluation in a project function.
+type FlagUse struct {
+	SymbolID SymbolID `json:"symbol_id"`          // Function evaluating the flag
+	Function string   `json:"function,omitempty"` // "pkgpath.Func" or "pkgpath.Type.Method", filled in on read
+	Key      string   `json:"key"`                // Empty when the key is not a constant
+	Call     string   `json:"call"`               // Evaluating function, e.g. "...LDClient.BoolVariation"
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+}
+
+// FeatureFlag is a flag key with its evaluation sites and the entrypoints
+// reaching them.
+type FeatureFlag struct {
+	Key         string          `json:"key"` // Empty for evaluations with a non-constant key
+	Uses        []FlagUse       `json:"uses"`
+	Entrypoints []EntrypointRef `json:"entrypoints"`
+}
+
+// EntrypointRef identifies an entrypoint in reports.
+type EntrypointRef struct {
+	ID    EntrypointID   `json:"id"`
+	Label string         `json:"label"`
+	Type  EntrypointType `json:"type"`
+}
+
+// InsertFlagUse records a flag evaluation within the batch.
+func (b *BatchTx) InsertFlagUse(ctx context.Context, u *FlagUse) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO flag_uses (symbol_id, flag_key, call, file, line)
+		VALUES (?, ?, ?, ?, ?)
+	`, u.SymbolID, u.Key, u.Call, relPath(b.baseDir, u.File), u.Line)
+	return err
+}
+
+// GetFeatureFlags returns every evaluated flag, ordered by key, with the
+// entrypoints whose call trees reach an evaluation of it.
+func (s *Store) GetFeatureFlags(ctx context.Context) ([]FeatureFlag, error) {
+	uses, err := s.getFlagUses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	eps, err := s.GetEntrypoints(ctx, EntrypointFilter{})
+	if err != nil {
+		return nil, err
+	}
+	callees, err := s.getCalleeAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]*FeatureFlag)
+	for _, list := range uses {
+		for _, u := range list {
+			f, ok := flags[u.Key]
+			if !ok {
+				f = &FeatureFlag{Key: u.Key, Entrypoints: []EntrypointRef{}}
+				flags[u.Key] = f
+			}
+			f.Uses = append(f.Uses, u)
+		}
+	}
+
+	for _, ep := range eps {
+		reached := make(map[string]bool)
+		seen := map[SymbolID]bool{ep.SymbolID: true}
+		queue := []SymbolID{ep.SymbolID}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			for _, u := range uses[id] {
+				reached[u.Key] = true
+			}
+			for _, next := range callees[id] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		for key := range reached {
+			flags[key].Entrypoints = append(flags[key].Entrypoints, EntrypointRef{ID: ep.ID, Label: ep.Label, Type: ep.Type})
+		}
+	}
+
+	result := make([]FeatureFlag, 0, len(flags))
+	for _, f := range flags {
+		sort.Slice(f.Uses, func(i, j int) bool {
+			if f.Uses[i].File != f.Uses[j].File {
+				return f.Uses[i].File < f.Uses[j].File
+			}
+			return f.Uses[i].Line < f.Uses[j].Line
+		})
+		result = append(result, *f)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// getFlagUses returns all recorded flag evaluations keyed by function.
+func (s *Store) getFlagUses(ctx context.Context) (map[SymbolID][]FlagUse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT f.symbol_id, s.pkg_path, s.name, COALESCE(s.recv_type, ''), f.flag_key, f.call, f.file, f.line, s.repo
+		FROM flag_uses f
+		JOIN symbols s ON s.id = f.symbol_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uses := make(map[SymbolID][]FlagUse)
+	for rows.Next() {
+		var u FlagUse
+		var pkgPath, name, recvType, repo string
+		if err := rows.Scan(&u.SymbolID, &pkgPath, &name, &recvType, &u.Key, &u.Call, &u.File, &u.Line, &repo); err != nil {
+			return nil, err
+		}
+		u.Function = pkgPath + "." + name
+		if recvType != "" {
+			u.Function = pkgPath + "." + strings.TrimPrefix(recvType, "*") + "." + name
+		}
+		u.File = s.absPath(ctx, repo, u.File)
+		uses[u.SymbolID] = append(uses[u.SymbolID], u)
+	}
+	return uses, rows.Err()
+}
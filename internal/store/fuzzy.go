@@ -0,0 +1,199 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Field weights for fuzzy matching. A token matching the symbol name counts
+// more than one matching the receiver type, which counts more than the package.
+const (
+	fuzzyNameWeight = 1.0
+	fuzzyRecvWeight = 0.9
+	fuzzyPkgWeight  = 0.5
+
+	// trigramThreshold is the minimum trigram similarity considered a match
+	// when the token is not a subsequence of the field (e.g., transposed letters).
+	trigramThreshold = 0.3
+	trigramWeight    = 0.7
+)
+
+// fuzzyScore scores a symbol against a whitespace-separated query.
+// Every query token must match at least one of name, receiver type, or
+// package path; otherwise the score is 0. Higher is better.
+func fuzzyScore(query string, sym *Symbol) float64 {
+	tokens := strings.Fields(strings.ToLower(query))
+	if len(tokens) == 0 {
+		return 0
+	}
+
+	recv := strings.TrimPrefix(sym.RecvType, "*")
+	pkgBase := sym.PkgPath
+	if idx := strings.LastIndex(pkgBase, "/"); idx != -1 {
+		pkgBase = pkgBase[idx+1:]
+	}
+
+	total := 0.0
+	for _, tok := range tokens {
+		best := fuzzyNameWeight * fieldScore(tok, sym.Name)
+		if recv != "" {
+			best = max(best, fuzzyRecvWeight*fieldScore(tok, recv))
+		}
+		best = max(best, fuzzyPkgWeight*fieldScore(tok, pkgBase))
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	score := total / float64(len(tokens))
+
+	// Whole-query bonuses for exact and prefix name matches
+	lowerQuery := strings.ToLower(strings.TrimSpace(query))
+	lowerName := strings.ToLower(sym.Name)
+	switch {
+	case lowerName == lowerQuery:
+		score += 1.0
+	case strings.HasPrefix(lowerName, lowerQuery):
+		score += 0.5
+	}
+
+	return score
+}
+
+// fieldScore returns the best of subsequence and trigram similarity of a
+// lowercase token against a field, in the range [0, 1].
+func fieldScore(token, field string) float64 {
+	if field == "" {
+		return 0
+	}
+	score := subsequenceScore(token, field)
+	if tri := trigramSimilarity(token, strings.ToLower(field)); tri >= trigramThreshold {
+		score = max(score, trigramWeight*tri)
+	}
+	return score
+}
+
+// subsequenceScore scores how well token matches field as an in-order
+// subsequence, rewarding consecutive runs and matches at word boundaries
+// (start, camelCase humps, after '.', '_' or '/'). Returns 0 if token is
+// not a subsequence of field.
+func subsequenceScore(token, field string) float64 {
+	runes := []rune(field)
+	lower := []rune(strings.ToLower(field))
+	query := []rune(token)
+	if len(query) == 0 || len(query) > len(runes) {
+		return 0
+	}
+
+	const (
+		matchPoints       = 1
+		consecutiveBonus  = 2
+		boundaryBonus     = 3
+		maxPointsPerMatch = matchPoints + consecutiveBonus + boundaryBonus
+	)
+
+	points := 0
+	prev := -2
+	j := 0
+	for _, q := range query {
+		for j < len(lower) && lower[j] != q {
+			j++
+		}
+		if j == len(lower) {
+			return 0
+		}
+		p := matchPoints
+		if j == prev+1 {
+			p += consecutiveBonus
+		}
+		if isWordBoundary(runes, j) {
+			p += boundaryBonus
+		}
+		points += p
+		prev = j
+		j++
+	}
+
+	score := float64(points) / float64(len(query)*maxPointsPerMatch)
+	// Prefer tighter fields: "get" should rank GetUser above GetUserPreferencesByID
+	coverage := float64(len(query)) / float64(len(runes))
+	return score * (0.75 + 0.25*coverage)
+}
+
+// isWordBoundary reports whether position i in s starts a new word.
+func isWordBoundary(s []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := s[i-1], s[i]
+	switch prev {
+	case '.', '_', '/', '-', '*', '(', ')':
+		return true
+	}
+	return unicode.IsUpper(cur) && unicode.IsLower(prev)
+}
+
+// trigramSimilarity returns the Jaccard similarity of the trigram sets of a and b.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the set of 3-rune substrings of s.
+// Strings shorter than three runes yield themselves as a single gram.
+func trigrams(s string) map[string]bool {
+	runes := []rune(s)
+	set := make(map[string]bool)
+	if len(runes) < 3 {
+		if len(runes) > 0 {
+			set[s] = true
+		}
+		return set
+	}
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
+
+// fuzzyPrefilter returns a SQL condition on symbols s that every symbol
+// fieldScore could match against token passes: a subsequence match needs
+// the token's first rune in a field, and a trigram match needs one of its
+// trigrams. LIKE only folds ASCII case, so other tokens get no condition.
+func fuzzyPrefilter(token string) (string, []any) {
+	for _, r := range token {
+		if r > unicode.MaxASCII {
+			return "", nil
+		}
+	}
+	const haystack = "(s.name || ' ' || COALESCE(s.recv_type, '') || ' ' || s.pkg_path)"
+
+	needles := []string{token[:1]}
+	for gram := range trigrams(token) {
+		if len(gram) == 3 {
+			needles = append(needles, gram)
+		}
+	}
+	sort.Strings(needles[1:]) // Stable SQL text for the statement cache
+	conds := make([]string, len(needles))
+	args := make([]any, len(needles))
+	for i, n := range needles {
+		conds[i] = haystack + ` LIKE ? ESCAPE '\'`
+		args[i] = "%" + likeEscaper.Replace(n) + "%"
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
+
+// likeEscaper escapes LIKE wildcards, which are common in identifiers
+// ("_"), for use with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+)
+
+// GlobalWrite is a store to a package-level variable, attributed to the
+// named function containing it.
+type GlobalWrite struct {
+	VarID    SymbolID
+	WriterID SymbolID
+	File     string
+	Line     int
+}
+
+// GlobalWriter is a function that writes a package-level variable.
+type GlobalWriter struct {
+	Symbol Symbol `json:"symbol"`
+	File   string `json:"file"`   // File of the first write
+	Line   int    `json:"line"`   // Line of the first write
+	Writes int    `json:"writes"` // Write sites within the function
+}
+
+// GlobalVar is a package-level variable with the functions writing it.
+type GlobalVar struct {
+	Symbol       Symbol         `json:"symbol"`
+	Writers      []GlobalWriter `json:"writers"`
+	PackageCount int            `json:"package_count"` // Distinct packages of the writers
+}
+
+// InsertGlobalWrite records a write to a package-level variable within the
+// batch.
+func (b *BatchTx) InsertGlobalWrite(ctx context.Context, w *GlobalWrite) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO global_writes (var_id, writer_id, file, line)
+		VALUES (?, ?, ?, ?)
+	`, w.VarID, w.WriterID, relPath(b.baseDir, w.File), w.Line)
+	return err
+}
+
+// GetGlobalVars returns the package-level variables written from at least
+// minWriters distinct functions, most written first, each with its writers
+// ordered by package and name.
+func (s *Store) GetGlobalVars(ctx context.Context, minWriters int) ([]GlobalVar, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		WITH shared AS (
+			SELECT var_id, COUNT(DISTINCT writer_id) AS writers
+			FROM global_writes
+			GROUP BY var_id
+			HAVING COUNT(DISTINCT writer_id) >= ?
+		)
+		SELECT v.id, v.pkg_path, v.name, v.kind, v.file, v.line, v.repo,
+		       f.id, f.pkg_path, f.name, f.kind, COALESCE(f.recv_type, ''), f.file, f.line, f.repo,
+		       g.file, MIN(g.line), COUNT(*)
+		FROM shared
+		JOIN symbols v ON v.id = shared.var_id
+		JOIN global_writes g ON g.var_id = shared.var_id
+		JOIN symbols f ON f.id = g.writer_id
+		GROUP BY g.var_id, g.writer_id
+		ORDER BY shared.writers DESC, v.pkg_path, v.name, f.pkg_path, f.name, COALESCE(f.recv_type, '')
+	`, minWriters)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vars []GlobalVar
+	var pkgs map[string]bool
+	for rows.Next() {
+		var v Symbol
+		var w GlobalWriter
+		f := &w.Symbol
+		if err := rows.Scan(&v.ID, &v.PkgPath, &v.Name, &v.Kind, &v.File, &v.Line, &v.Repo,
+			&f.ID, &f.PkgPath, &f.Name, &f.Kind, &f.RecvType, &f.File, &f.Line, &f.Repo,
+			&w.File, &w.Line, &w.Writes); err != nil {
+			return nil, err
+		}
+		if len(vars) == 0 || vars[len(vars)-1].Symbol.ID != v.ID {
+			v.File = s.absPath(ctx, v.Repo, v.File)
+			vars = append(vars, GlobalVar{Symbol: v})
+			pkgs = make(map[string]bool)
+		}
+		w.File = s.absPath(ctx, f.Repo, w.File)
+		f.File = s.absPath(ctx, f.Repo, f.File)
+		cur := &vars[len(vars)-1]
+		cur.Writers = append(cur.Writers, w)
+		if !pkgs[f.PkgPath] {
+			pkgs[f.PkgPath] = true
+			cur.PackageCount++
+		}
+	}
+	return vars, rows.Err()
+}
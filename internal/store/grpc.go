@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+)
+
+// GRPCMethod is one method of a generated gRPC service interface as served
+// by one implementing type, or by none.
+type GRPCMethod struct {
+	PkgPath     string // Package of the generated XServer interface
+	Service     string // e.g. "UserService"
+	Method      string
+	TypeID      SymbolID // Implementing type; 0 when the service has none
+	Registered  bool     // The type is passed to RegisterXServer
+	Implemented bool     // The type declares the method instead of inheriting the Unimplemented stub
+	Repo        string
+}
+
+// GRPCServiceCoverage is a generated gRPC service with the project types
+// implementing it.
+type GRPCServiceCoverage struct {
+	PkgPath         string               `json:"pkg_path"`
+	Service         string               `json:"service"`
+	Methods         []string             `json:"methods"`
+	Unimplemented   []string             `json:"unimplemented"` // Methods no registered type declares; they return codes.Unimplemented
+	Implementations []GRPCImplementation `json:"implementations"`
+}
+
+// GRPCImplementation is a project type satisfying a service interface.
+type GRPCImplementation struct {
+	Type       Symbol   `json:"type"`
+	Registered bool     `json:"registered"`        // Passed to RegisterXServer somewhere in the project
+	Missing    []string `json:"missing,omitempty"` // Methods inherited from the Unimplemented stub
+}
+
+// InsertGRPCMethod records a service method within the batch.
+func (b *BatchTx) InsertGRPCMethod(ctx context.Context, m *GRPCMethod) error {
+	var typeID sql.NullInt64
+	if m.TypeID != 0 {
+		typeID = sql.NullInt64{Int64: int64(m.TypeID), Valid: true}
+	}
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT INTO grpc_methods (pkg_path, service, method, type_id, registered, implemented, repo)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, m.PkgPath, m.Service, m.Method, typeID, m.Registered, m.Implemented, m.Repo)
+	return err
+}
+
+// GetGRPCCoverage returns the generated gRPC services the project uses,
+// ordered by package and name, with their methods in interface order and
+// their implementations ordered by package and name.
+func (s *Store) GetGRPCCoverage(ctx context.Context) ([]GRPCServiceCoverage, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT g.pkg_path, g.service, g.method, g.registered, g.implemented,
+		       COALESCE(s.id, 0), COALESCE(s.pkg_path, ''), COALESCE(s.name, ''), COALESCE(s.kind, ''),
+		       COALESCE(s.file, ''), COALESCE(s.line, 0), COALESCE(s.repo, '')
+		FROM grpc_methods g
+		LEFT JOIN symbols s ON s.id = g.type_id
+		ORDER BY g.rowid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type serviceKey struct{ pkgPath, service string }
+	byService := make(map[serviceKey]*GRPCServiceCoverage)
+	var services []*GRPCServiceCoverage
+	served := make(map[serviceKey]map[string]bool)
+	for rows.Next() {
+		var key serviceKey
+		var method string
+		var registered, implemented bool
+		var sym Symbol
+		if err := rows.Scan(&key.pkgPath, &key.service, &method, &registered, &implemented,
+			&sym.ID, &sym.PkgPath, &sym.Name, &sym.Kind, &sym.File, &sym.Line, &sym.Repo); err != nil {
+			return nil, err
+		}
+		svc := byService[key]
+		if svc == nil {
+			svc = &GRPCServiceCoverage{PkgPath: key.pkgPath, Service: key.service, Implementations: []GRPCImplementation{}}
+			byService[key] = svc
+			services = append(services, svc)
+			served[key] = make(map[string]bool)
+		}
+		if !slices.Contains(svc.Methods, method) {
+			svc.Methods = append(svc.Methods, method)
+		}
+		if sym.ID == 0 {
+			continue
+		}
+		if registered && implemented {
+			served[key][method] = true
+		}
+		i := slices.IndexFunc(svc.Implementations, func(impl GRPCImplementation) bool { return impl.Type.ID == sym.ID })
+		if i < 0 {
+			sym.File = s.absPath(ctx, sym.Repo, sym.File)
+			svc.Implementations = append(svc.Implementations, GRPCImplementation{Type: sym, Registered: registered})
+			i = len(svc.Implementations) - 1
+		}
+		if !implemented {
+			svc.Implementations[i].Missing = append(svc.Implementations[i].Missing, method)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	coverage := make([]GRPCServiceCoverage, 0, len(services))
+	for _, svc := range services {
+		svc.Unimplemented = []string{}
+		for _, method := range svc.Methods {
+			if !served[serviceKey{svc.PkgPath, svc.Service}][method] {
+				svc.Unimplemented = append(svc.Unimplemented, method)
+			}
+		}
+		sort.Slice(svc.Implementations, func(i, j int) bool {
+			a, b := svc.Implementations[i].Type, svc.Implementations[j].Type
+			if a.PkgPath != b.PkgPath {
+				return a.PkgPath < b.PkgPath
+			}
+			return a.Name < b.Name
+		})
+		coverage = append(coverage, *svc)
+	}
+	sort.Slice(coverage, func(i, j int) bool {
+		if coverage[i].PkgPath != coverage[j].PkgPath {
+			return coverage[i].PkgPath < coverage[j].PkgPath
+		}
+		return coverage[i].Service < coverage[j].Service
+	})
+	return coverage, nil
+}
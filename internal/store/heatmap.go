@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DirectoryHeat is how much of the entrypoints' call trees lands in one
+// source directory and its subdirectories.
+type DirectoryHeat struct {
+	Dir         string `json:"dir"`          // Slash-separated, relative to the repository root and prefixed by the repository in a shared index; "" for the root
+	Calls       int    `json:"calls"`        // Calls into the directory's symbols, summed over every entrypoint's call tree
+	DirectCalls int    `json:"direct_calls"` // Calls into symbols in the directory itself, not its subdirectories
+	Symbols     int    `json:"symbols"`      // Symbols reached by any entrypoint
+	Entrypoints int    `json:"entrypoints"`  // Entrypoints whose call tree reaches the directory
+}
+
+// GetDirectoryHeat walks the call tree of every entrypoint of type epType
+// (empty for all) and counts, per source directory, the call edges it
+// traverses into the directory's symbols, plus one for entering each
+// handler. A call reached from several entrypoints counts once for each, so
+// shared code used by many flows runs hot. Every directory holding or
+// containing a reached symbol is returned, the root included, sorted by
+// path; symbols in files outside the project, such as the module cache, are
+// left out.
+func (s *Store) GetDirectoryHeat(ctx context.Context, epType EntrypointType) ([]DirectoryHeat, error) {
+	eps, err := s.GetEntrypoints(ctx, EntrypointFilter{Type: epType})
+	if err != nil {
+		return nil, err
+	}
+	callees, err := s.getCalleeAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := s.symbolDirs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	heat := make(map[string]*DirectoryHeat)
+	at := func(dir string) *DirectoryHeat {
+		h, ok := heat[dir]
+		if !ok {
+			h = &DirectoryHeat{Dir: dir}
+			heat[dir] = h
+		}
+		return h
+	}
+	call := func(id SymbolID, touched map[string]bool) {
+		dir, ok := dirs[id]
+		if !ok {
+			return
+		}
+		at(dir).DirectCalls++
+		for _, d := range dirAncestors(dir) {
+			at(d).Calls++
+			touched[d] = true
+		}
+	}
+
+	reached := make(map[SymbolID]bool)
+	for _, ep := range eps {
+		touched := make(map[string]bool)
+		seen := map[SymbolID]bool{ep.SymbolID: true}
+		queue := []SymbolID{ep.SymbolID}
+		call(ep.SymbolID, touched)
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			for _, next := range callees[id] {
+				call(next, touched)
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		for d := range touched {
+			heat[d].Entrypoints++
+		}
+		for id := range seen {
+			reached[id] = true
+		}
+	}
+	for id := range reached {
+		if dir, ok := dirs[id]; ok {
+			for _, d := range dirAncestors(dir) {
+				at(d).Symbols++
+			}
+		}
+	}
+
+	result := make([]DirectoryHeat, 0, len(heat))
+	for _, h := range heat {
+		result = append(result, *h)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Dir < result[j].Dir })
+	return result, nil
+}
+
+// symbolDirs returns the directory of every symbol in a project file, as
+// DirectoryHeat reports it.
+func (s *Store) symbolDirs(ctx context.Context) (map[SymbolID]string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `SELECT id, file, repo FROM symbols`)
+	if err != nil {
+		return nil, fmt.Errorf("listing symbol files: %w", err)
+	}
+	defer rows.Close()
+
+	dirs := make(map[SymbolID]string)
+	for rows.Next() {
+		var id SymbolID
+		var file, repo string
+		if err := rows.Scan(&id, &file, &repo); err != nil {
+			return nil, err
+		}
+		file = strings.ReplaceAll(file, `\`, "/")
+		if file == "" || isAbsPath(file) {
+			continue // Outside the project
+		}
+		dir := path.Dir(file)
+		if dir == "." {
+			dir = ""
+		}
+		if repo != "" {
+			dir = strings.TrimSuffix(repo+"/"+dir, "/")
+		}
+		dirs[id] = dir
+	}
+	return dirs, rows.Err()
+}
+
+// dirAncestors returns a directory and every directory containing it, up
+// to the root "".
+func dirAncestors(dir string) []string {
+	out := []string{dir}
+	for dir != "" {
+		i := strings.LastIndex(dir, "/")
+		if i < 0 {
+			dir = ""
+		} else {
+			dir = dir[:i]
+		}
+		out = append(out, dir)
+	}
+	return out
+}
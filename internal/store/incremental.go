@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// ClearAnalysis removes the entrypoints, tags, interface and type relations,
+// references, gRPC coverage, findings, and diagnostics of one repository
+// (the unnamed one for ""), which every indexing run rebuilds from scratch. Symbols and call
+// edges are kept so unchanged packages need not be re-extracted. For an
+// unnamed index the change log is cleared too, as Clear does.
+func (s *Store) ClearAnalysis(ctx context.Context, repo string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const repoSymbols = "SELECT id FROM symbols WHERE repo = ?"
+	const repoEntrypoints = "SELECT id FROM entrypoints WHERE symbol_id IN (" + repoSymbols + ")"
+	statements := []struct {
+		table, query string
+	}{
+		{"diagnostics", "DELETE FROM diagnostics WHERE repo = ?"},
+		{"auth_checks", "DELETE FROM auth_checks WHERE entrypoint_id IN (" + repoEntrypoints + ")"},
+		{"panic_checks", "DELETE FROM panic_checks WHERE entrypoint_id IN (" + repoEntrypoints + ")"},
+		{"taint_findings", "DELETE FROM taint_findings WHERE entrypoint_id IN (" + repoEntrypoints + ")"},
+		{"grpc_methods", "DELETE FROM grpc_methods WHERE repo = ?"},
+		{"tags", "DELETE FROM tags WHERE symbol_id IN (" + repoSymbols + ")"},
+		{"entrypoints", "DELETE FROM entrypoints WHERE symbol_id IN (" + repoSymbols + ")"},
+		{"implementations", "DELETE FROM implementations WHERE interface_id IN (" + repoSymbols + ")"},
+		{"implementations", "DELETE FROM implementations WHERE type_id IN (" + repoSymbols + ")"},
+		{"interface_methods", "DELETE FROM interface_methods WHERE interface_id IN (" + repoSymbols + ")"},
+		{"type_relations", "DELETE FROM type_relations WHERE type_id IN (" + repoSymbols + ")"},
+		{"symbol_refs", "DELETE FROM symbol_refs WHERE symbol_id IN (" + repoSymbols + ")"},
+		{"symbol_refs", "DELETE FROM symbol_refs WHERE from_id IN (" + repoSymbols + ")"},
+	}
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt.query, repo); err != nil {
+			return fmt.Errorf("clearing table %s: %w", stmt.table, err)
+		}
+	}
+	if repo == "" {
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM changes"); err != nil {
+			return fmt.Errorf("clearing table changes: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteCallsFrom removes the call edges, external calls, global writes,
+// error sites, and flag uses of a package's symbols, and its unresolved call
+// statistics, within the batch, ahead of re-extracting them.
+func (b *BatchTx) DeleteCallsFrom(ctx context.Context, pkgPath string) error {
+	const pkgSymbols = "SELECT id FROM symbols WHERE pkg_path = ?"
+	if _, err := b.tx.ExecContext(ctx, "DELETE FROM call_edges WHERE caller_id IN ("+pkgSymbols+")", pkgPath); err != nil {
+		return fmt.Errorf("deleting call edges: %w", err)
+	}
+	if _, err := b.tx.ExecContext(ctx, "DELETE FROM external_calls WHERE caller_id IN ("+pkgSymbols+")", pkgPath); err != nil {
+		return fmt.Errorf("deleting external calls: %w", err)
+	}
+	if _, err := b.tx.ExecContext(ctx, "DELETE FROM global_writes WHERE writer_id IN ("+pkgSymbols+")", pkgPath); err != nil {
+		return fmt.Errorf("deleting global writes: %w", err)
+	}
+	if _, err := b.tx.ExecContext(ctx, "DELETE FROM error_sites WHERE symbol_id IN ("+pkgSymbols+")", pkgPath); err != nil {
+		return fmt.Errorf("deleting error sites: %w", err)
+	}
+	if _, err := b.tx.ExecContext(ctx, "DELETE FROM flag_uses WHERE symbol_id IN ("+pkgSymbols+")", pkgPath); err != nil {
+		return fmt.Errorf("deleting flag uses: %w", err)
+	}
+	for _, table := range []string{"unresolved_calls", "skipped_functions"} {
+		if _, err := b.tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE pkg_path = ?", pkgPath); err != nil {
+			return fmt.Errorf("deleting %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// PruneSymbols deletes a package's symbols that are not in keep within the
+// batch, together with everything referring to them: call edges in either
+// direction, external calls, tags, and entrypoints with their findings. It
+// returns the number of symbols removed.
+func (b *BatchTx) PruneSymbols(ctx context.Context, pkgPath string, keep map[SymbolID]bool) (int, error) {
+	rows, err := b.tx.QueryContext(ctx, "SELECT id FROM symbols WHERE pkg_path = ?", pkgPath)
+	if err != nil {
+		return 0, err
+	}
+	var stale []SymbolID
+	for rows.Next() {
+		var id SymbolID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if !keep[id] {
+			stale = append(stale, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, err
+	}
+	rows.Close()
+
+	const symbolEntrypoints = "SELECT id FROM entrypoints WHERE symbol_id = ?"
+	statements := []struct {
+		table, query string
+		args         int
+	}{
+		{"auth_checks", "DELETE FROM auth_checks WHERE entrypoint_id IN (" + symbolEntrypoints + ")", 1},
+		{"panic_checks", "DELETE FROM panic_checks WHERE entrypoint_id IN (" + symbolEntrypoints + ")", 1},
+		{"taint_findings", "DELETE FROM taint_findings WHERE entrypoint_id IN (" + symbolEntrypoints + ")", 1},
+		{"entrypoints", "DELETE FROM entrypoints WHERE symbol_id = ?", 1},
+		{"tags", "DELETE FROM tags WHERE symbol_id = ?", 1},
+		{"implementations", "DELETE FROM implementations WHERE interface_id = ? OR type_id = ?", 2},
+		{"interface_methods", "DELETE FROM interface_methods WHERE interface_id = ?", 1},
+		{"type_relations", "DELETE FROM type_relations WHERE type_id = ?", 1},
+		{"symbol_refs", "DELETE FROM symbol_refs WHERE symbol_id = ? OR from_id = ?", 2},
+		{"external_calls", "DELETE FROM external_calls WHERE caller_id = ?", 1},
+		{"global_writes", "DELETE FROM global_writes WHERE var_id = ? OR writer_id = ?", 2},
+		{"error_sites", "DELETE FROM error_sites WHERE symbol_id = ?", 1},
+		{"flag_uses", "DELETE FROM flag_uses WHERE symbol_id = ?", 1},
+		{"call_pairs", "DELETE FROM call_pairs WHERE caller_id = ? OR callee_id = ?", 2},
+		{"call_edges", "DELETE FROM call_edges WHERE caller_id = ? OR callee_id = ?", 2},
+		{"symbols", "DELETE FROM symbols WHERE id = ?", 1},
+	}
+	for _, id := range stale {
+		for _, stmt := range statements {
+			args := make([]interface{}, stmt.args)
+			for i := range args {
+				args[i] = id
+			}
+			if _, err := b.tx.ExecContext(ctx, stmt.query, args...); err != nil {
+				return 0, fmt.Errorf("pruning %s: %w", stmt.table, err)
+			}
+		}
+	}
+	return len(stale), nil
+}
+
+// DeletePackage removes a package record within the batch. Its symbols must
+// already be gone (see PruneSymbols).
+func (b *BatchTx) DeletePackage(ctx context.Context, pkgPath string) error {
+	_, err := b.tx.ExecContext(ctx, "DELETE FROM packages WHERE pkg_path = ?", pkgPath)
+	return err
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+)
+
+// InterfaceMethod is a method in an interface's method set.
+type InterfaceMethod struct {
+	Name string `json:"name"`
+	Sig  string `json:"sig"`
+}
+
+// Implementation is a project type that satisfies an interface, or an
+// interface a type satisfies.
+type Implementation struct {
+	Symbol  Symbol `json:"symbol"`
+	Pointer bool   `json:"pointer,omitempty"` // Only the pointer type *T satisfies the interface
+}
+
+// InterfaceSummary is an interface with the sizes of its method set and
+// implementation list.
+type InterfaceSummary struct {
+	Symbol              Symbol `json:"symbol"`
+	MethodCount         int    `json:"method_count"`
+	ImplementationCount int    `json:"implementation_count"`
+}
+
+// InterfaceFilter specifies criteria for listing interfaces.
+type InterfaceFilter struct {
+	PkgPath string // Filter by package (empty = all)
+	Repo    string // Filter by repository, in a shared multi-repo index
+}
+
+// InsertInterfaceMethod records a method of an interface within the batch.
+func (b *BatchTx) InsertInterfaceMethod(ctx context.Context, ifaceID SymbolID, m *InterfaceMethod) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO interface_methods (interface_id, name, sig)
+		VALUES (?, ?, ?)
+	`, ifaceID, m.Name, m.Sig)
+	return err
+}
+
+// InsertImplementation records that a type satisfies an interface within the
+// batch; pointer is set when only *T does.
+func (b *BatchTx) InsertImplementation(ctx context.Context, ifaceID, typeID SymbolID, pointer bool) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO implementations (interface_id, type_id, pointer)
+		VALUES (?, ?, ?)
+	`, ifaceID, typeID, pointer)
+	return err
+}
+
+// GetInterfaces lists interfaces ordered by package and name, with the
+// number of methods and implementations of each.
+func (s *Store) GetInterfaces(ctx context.Context, filter InterfaceFilter) ([]InterfaceSummary, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT s.id, s.pkg_path, s.name, s.kind, s.file, s.line, s.repo,
+		       (SELECT COUNT(*) FROM interface_methods m WHERE m.interface_id = s.id),
+		       (SELECT COUNT(*) FROM implementations i WHERE i.interface_id = s.id)
+		FROM symbols s
+		WHERE s.kind = ?
+	`
+	args := []interface{}{SymbolKindInterface}
+	if filter.PkgPath != "" {
+		query += " AND s.pkg_path = ?"
+		args = append(args, filter.PkgPath)
+	}
+	if filter.Repo != "" {
+		query += " AND s.repo = ?"
+		args = append(args, filter.Repo)
+	}
+	query += " ORDER BY s.pkg_path, s.name"
+
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ifaces []InterfaceSummary
+	for rows.Next() {
+		var is InterfaceSummary
+		sym := &is.Symbol
+		if err := rows.Scan(&sym.ID, &sym.PkgPath, &sym.Name, &sym.Kind, &sym.File, &sym.Line, &sym.Repo,
+			&is.MethodCount, &is.ImplementationCount); err != nil {
+			return nil, err
+		}
+		sym.File = s.absPath(ctx, sym.Repo, sym.File)
+		ifaces = append(ifaces, is)
+	}
+	return ifaces, rows.Err()
+}
+
+// GetInterfaceMethods returns an interface's method set ordered by name.
+func (s *Store) GetInterfaceMethods(ctx context.Context, ifaceID SymbolID) ([]InterfaceMethod, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT name, sig FROM interface_methods WHERE interface_id = ? ORDER BY name
+	`, ifaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var methods []InterfaceMethod
+	for rows.Next() {
+		var m InterfaceMethod
+		if err := rows.Scan(&m.Name, &m.Sig); err != nil {
+			return nil, err
+		}
+		methods = append(methods, m)
+	}
+	return methods, rows.Err()
+}
+
+// GetImplementations returns the types that satisfy an interface.
+func (s *Store) GetImplementations(ctx context.Context, ifaceID SymbolID) ([]Implementation, error) {
+	return s.getImplementations(ctx, "i.type_id", "i.interface_id", ifaceID)
+}
+
+// GetImplementedInterfaces returns the interfaces a type satisfies.
+func (s *Store) GetImplementedInterfaces(ctx context.Context, typeID SymbolID) ([]Implementation, error) {
+	return s.getImplementations(ctx, "i.interface_id", "i.type_id", typeID)
+}
+
+// getImplementations returns the symbols on one side of the implementations
+// matching id on the other, ordered by package and name.
+func (s *Store) getImplementations(ctx context.Context, symbolCol, matchCol string, id SymbolID) ([]Implementation, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT s.id, s.pkg_path, s.name, s.kind, s.file, s.line, s.repo, i.pointer
+		FROM implementations i
+		JOIN symbols s ON s.id = `+symbolCol+`
+		WHERE `+matchCol+` = ?
+		ORDER BY s.pkg_path, s.name
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var impls []Implementation
+	for rows.Next() {
+		var impl Implementation
+		sym := &impl.Symbol
+		if err := rows.Scan(&sym.ID, &sym.PkgPath, &sym.Name, &sym.Kind, &sym.File, &sym.Line, &sym.Repo, &impl.Pointer); err != nil {
+			return nil, err
+		}
+		sym.File = s.absPath(ctx, sym.Repo, sym.File)
+		impls = append(impls, impl)
+	}
+	return impls, rows.Err()
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"strconv"
+	"strings"
+)
+
+// IsIOTag reports whether tag marks a direct I/O boundary, such as "io:db",
+// as opposed to a derived tag such as "io:db@2".
+func IsIOTag(tag string) bool {
+	return strings.HasPrefix(tag, "io:") && !strings.Contains(tag, "@")
+}
+
+// DerivedIOTag returns the tag of a function reaching an I/O boundary
+// through distance-1 intermediate calls, e.g. "io:db@2" for a caller of an
+// io:db function.
+func DerivedIOTag(ioTag string, distance int) string {
+	return ioTag + "@" + strconv.Itoa(distance)
+}
+
+// ParseDerivedIOTag splits a derived tag such as "io:db@2" into its I/O tag
+// and distance. Direct I/O tags have distance 1.
+func ParseDerivedIOTag(tag string) (ioTag string, distance int, ok bool) {
+	if !strings.HasPrefix(tag, "io:") {
+		return "", 0, false
+	}
+	base, d, found := strings.Cut(tag, "@")
+	if !found {
+		return tag, 1, true
+	}
+	distance, err := strconv.Atoi(d)
+	if err != nil || distance < 2 {
+		return "", 0, false
+	}
+	return base, distance, true
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// LayerCall is a call from a symbol in one layer to a symbol in another.
+type LayerCall struct {
+	CallerID    SymbolID `json:"caller_id"`
+	Caller      string   `json:"caller"` // pkg.Name or pkg.(Recv).Name
+	CallerLayer string   `json:"caller_layer"`
+	CalleeID    SymbolID `json:"callee_id"`
+	Callee      string   `json:"callee"`
+	CalleeLayer string   `json:"callee_layer"`
+}
+
+// EntrypointLayers describes how deep an entrypoint's call tree goes and
+// which layers it passes through.
+type EntrypointLayers struct {
+	Entrypoint  EntrypointRef `json:"entrypoint"`
+	MaxDepth    int           `json:"max_depth"`   // Calls from the handler to the farthest symbol it reaches, each taken at its shortest distance
+	Transitions int           `json:"transitions"` // Most layer changes along one of those shortest paths
+	Path        []string      `json:"path"`        // The layers along that path, e.g. handler, service, store
+	Crossings   []LayerCall   `json:"crossings"`   // Every call between two layers in the call tree
+}
+
+// GetEntrypointLayers walks the call tree of every entrypoint and reports
+// its depth, the most calls it takes to reach any symbol in it, and the
+// layer transitions along the way, with every call between layered
+// packages the tree makes. Paths are shortest paths from the handler, so
+// recursion and cycles don't lengthen them. Entrypoints are
+// ordered by label; unlayered symbols don't count as transitions.
+func (s *Store) GetEntrypointLayers(ctx context.Context) ([]EntrypointLayers, error) {
+	eps, err := s.GetEntrypoints(ctx, EntrypointFilter{})
+	if err != nil {
+		return nil, err
+	}
+	callees, err := s.getCalleeAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	syms, err := s.symbolLayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]EntrypointLayers, 0, len(eps))
+	for _, ep := range eps {
+		e := EntrypointLayers{
+			Entrypoint: EntrypointRef{ID: ep.ID, Label: ep.Label, Type: ep.Type},
+			Path:       []string{},
+			Crossings:  []LayerCall{},
+		}
+
+		// step is how the traversal first reached a symbol; last is the layer
+		// of the nearest layered symbol on the way, the symbol included
+		type step struct {
+			parent      SymbolID
+			depth       int
+			transitions int
+			last        string
+		}
+		root := ep.SymbolID
+		steps := map[SymbolID]step{root: {last: syms[root].layer}}
+		layered := root
+		queue := []SymbolID{root}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			from := steps[id]
+			for _, next := range callees[id] {
+				layer := syms[next].layer
+				if caller := syms[id].layer; caller != "" && layer != "" && caller != layer {
+					e.Crossings = append(e.Crossings, LayerCall{
+						CallerID: id, Caller: syms[id].name, CallerLayer: caller,
+						CalleeID: next, Callee: syms[next].name, CalleeLayer: layer,
+					})
+				}
+				if _, ok := steps[next]; ok {
+					continue
+				}
+				to := step{parent: id, depth: from.depth + 1, transitions: from.transitions, last: from.last}
+				if layer != "" {
+					if from.last != "" && layer != from.last {
+						to.transitions++
+					}
+					to.last = layer
+				}
+				steps[next] = to
+				queue = append(queue, next)
+				e.MaxDepth = max(e.MaxDepth, to.depth)
+				if best := steps[layered]; to.transitions > best.transitions {
+					layered = next
+				}
+			}
+		}
+
+		e.Transitions = steps[layered].transitions
+		for id := layered; ; id = steps[id].parent {
+			if layer := syms[id].layer; layer != "" && (len(e.Path) == 0 || e.Path[0] != layer) {
+				e.Path = append([]string{layer}, e.Path...)
+			}
+			if id == root {
+				break
+			}
+		}
+		result = append(result, e)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Entrypoint.Label < result[j].Entrypoint.Label })
+	return result, nil
+}
+
+// symbolLayer is a symbol's qualified name and the layer of its package.
+type symbolLayer struct {
+	name  string
+	layer string
+}
+
+// symbolLayers returns the name and layer of every symbol.
+func (s *Store) symbolLayers(ctx context.Context) (map[SymbolID]symbolLayer, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT s.id, s.pkg_path, s.name, COALESCE(s.recv_type, ''), COALESCE(p.layer, '')
+		FROM symbols s
+		LEFT JOIN packages p ON s.pkg_path = p.pkg_path
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying symbol layers: %w", err)
+	}
+	defer rows.Close()
+
+	syms := make(map[SymbolID]symbolLayer)
+	for rows.Next() {
+		var id SymbolID
+		var pkg, name, recv, layer string
+		if err := rows.Scan(&id, &pkg, &name, &recv, &layer); err != nil {
+			return nil, err
+		}
+		if recv != "" {
+			name = fmt.Sprintf("%s.(%s).%s", pkg, recv, name)
+		} else {
+			name = pkg + "." + name
+		}
+		syms[id] = symbolLayer{name: name, layer: layer}
+	}
+	return syms, rows.Err()
+}
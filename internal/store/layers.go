@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// LayerEdgeCount is the number of call edges from one layer to another.
+type LayerEdgeCount struct {
+	CallerLayer string `json:"caller_layer"`
+	CalleeLayer string `json:"callee_layer"`
+	Count       int    `json:"count"`
+}
+
+// GetCrossLayerEdgeCounts counts call edges between packages of different
+// layers, grouped by caller and callee layer. Unlayered packages are ignored.
+func (s *Store) GetCrossLayerEdgeCounts(ctx context.Context) ([]LayerEdgeCount, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT p1.layer, p2.layer, COUNT(*)
+		FROM call_edges ce
+		JOIN symbols s1 ON ce.caller_id = s1.id
+		JOIN symbols s2 ON ce.callee_id = s2.id
+		JOIN packages p1 ON s1.pkg_path = p1.pkg_path
+		JOIN packages p2 ON s2.pkg_path = p2.pkg_path
+		WHERE p1.layer IS NOT NULL AND p1.layer != ''
+		  AND p2.layer IS NOT NULL AND p2.layer != ''
+		  AND p1.layer != p2.layer
+		GROUP BY p1.layer, p2.layer
+		ORDER BY p1.layer, p2.layer
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying cross-layer edges: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []LayerEdgeCount
+	for rows.Next() {
+		var c LayerEdgeCount
+		if err := rows.Scan(&c.CallerLayer, &c.CalleeLayer, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// LayerSize is the number of packages and symbols in a layer.
+type LayerSize struct {
+	Layer    string `json:"layer"`
+	Packages int    `json:"packages"`
+	Symbols  int    `json:"symbols"`
+}
+
+// GetLayerSizes counts the packages and symbols of each layer, ordered by
+// layer name. Unlayered packages are ignored.
+func (s *Store) GetLayerSizes(ctx context.Context) ([]LayerSize, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT p.layer, COUNT(*), COALESCE(SUM(
+			(SELECT COUNT(*) FROM symbols s WHERE s.pkg_path = p.pkg_path)
+		), 0)
+		FROM packages p
+		WHERE p.layer IS NOT NULL AND p.layer != ''
+		GROUP BY p.layer
+		ORDER BY p.layer
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying layer sizes: %w", err)
+	}
+	defer rows.Close()
+
+	var sizes []LayerSize
+	for rows.Next() {
+		var l LayerSize
+		if err := rows.Scan(&l.Layer, &l.Packages, &l.Symbols); err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, l)
+	}
+	return sizes, rows.Err()
+}
@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ManualEdge is a call edge asserted by the user, such as reflective
+// dispatch the call graph cannot see. Its endpoints are stored by symbol
+// identity rather than ID, so the edge survives re-indexing.
+type ManualEdge struct {
+	CallerKey string   `json:"caller_key"` // Stable identity, e.g. "myapp/svc.(*UserService).GetUser"
+	CalleeKey string   `json:"callee_key"`
+	CallerID  SymbolID `json:"caller_id,omitempty"` // Symbol in the current index; absent when it no longer exists
+	CalleeID  SymbolID `json:"callee_id,omitempty"`
+	CallKind  CallKind `json:"call_kind"`
+	Note      string   `json:"note,omitempty"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// AddManualEdge records a user-asserted call from caller to callee and adds
+// it to the call graph with resolved_by "manual". Asserting an existing
+// manual edge again updates its call kind and note.
+func (s *Store) AddManualEdge(ctx context.Context, callerID, calleeID SymbolID, kind CallKind, note string) (*ManualEdge, error) {
+	caller, err := s.GetSymbolByID(ctx, callerID)
+	if err != nil {
+		return nil, fmt.Errorf("caller %d: %w", callerID, err)
+	}
+	callee, err := s.GetSymbolByID(ctx, calleeID)
+	if err != nil {
+		return nil, fmt.Errorf("callee %d: %w", calleeID, err)
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.pools.get().write.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	edge := &ManualEdge{
+		CallerKey: SymbolKey(caller.PkgPath, caller.Name, caller.RecvType),
+		CalleeKey: SymbolKey(callee.PkgPath, callee.Name, callee.RecvType),
+		CallerID:  callerID,
+		CalleeID:  calleeID,
+		CallKind:  kind,
+		Note:      note,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO manual_edges (caller_pkg, caller_name, caller_recv, callee_pkg, callee_name, callee_recv, call_kind, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(caller_pkg, caller_name, caller_recv, callee_pkg, callee_name, callee_recv) DO UPDATE SET
+			call_kind = excluded.call_kind,
+			note = excluded.note
+	`, caller.PkgPath, caller.Name, caller.RecvType, callee.PkgPath, callee.Name, callee.RecvType,
+		kind, note, edge.CreatedAt); err != nil {
+		return nil, fmt.Errorf("inserting manual edge: %w", err)
+	}
+	// A manual edge has no call site; it is attributed to the caller's declaration
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO call_edges (caller_id, callee_id, caller_file, caller_line, call_kind, count, resolved_by)
+		SELECT id, ?, file, 0, ?, 1, ? FROM symbols WHERE id = ?
+		ON CONFLICT(caller_id, callee_id, caller_file, caller_line) DO UPDATE SET
+			call_kind = excluded.call_kind
+	`, calleeID, kind, ResolvedManual, callerID); err != nil {
+		return nil, fmt.Errorf("inserting call edge: %w", err)
+	}
+	if err := refreshCallPair(ctx, tx, callerID, calleeID); err != nil {
+		return nil, err
+	}
+	if err := touchManualEdges(ctx, tx); err != nil {
+		return nil, err
+	}
+	return edge, tx.Commit()
+}
+
+// DeleteManualEdge removes a user-asserted call edge and its call graph
+// edge. It reports whether a manual edge existed.
+func (s *Store) DeleteManualEdge(ctx context.Context, callerID, calleeID SymbolID) (bool, error) {
+	caller, err := s.GetSymbolByID(ctx, callerID)
+	if err != nil {
+		return false, fmt.Errorf("caller %d: %w", callerID, err)
+	}
+	callee, err := s.GetSymbolByID(ctx, calleeID)
+	if err != nil {
+		return false, fmt.Errorf("callee %d: %w", calleeID, err)
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.pools.get().write.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM manual_edges
+		WHERE caller_pkg = ? AND caller_name = ? AND caller_recv = ?
+		  AND callee_pkg = ? AND callee_name = ? AND callee_recv = ?
+	`, caller.PkgPath, caller.Name, caller.RecvType, callee.PkgPath, callee.Name, callee.RecvType)
+	if err != nil {
+		return false, fmt.Errorf("deleting manual edge: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM call_edges WHERE caller_id = ? AND callee_id = ? AND resolved_by = ?
+	`, callerID, calleeID, ResolvedManual); err != nil {
+		return false, fmt.Errorf("deleting call edge: %w", err)
+	}
+	if err := refreshCallPair(ctx, tx, callerID, calleeID); err != nil {
+		return false, err
+	}
+	if err := touchManualEdges(ctx, tx); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// touchManualEdges records when manual edges last changed in the
+// manual_edges_at metadata key, so readers caching graphs can tell they
+// are stale without a re-index.
+func touchManualEdges(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO metadata (key, value)
+		VALUES ('manual_edges_at', ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, time.Now().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("storing metadata: %w", err)
+	}
+	return nil
+}
+
+// GetManualEdges returns the user-asserted call edges, oldest first, with
+// the current IDs of the symbols they connect.
+func (s *Store) GetManualEdges(ctx context.Context) ([]ManualEdge, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT me.caller_pkg, me.caller_name, me.caller_recv, me.callee_pkg, me.callee_name, me.callee_recv,
+		       COALESCE(c.id, 0), COALESCE(d.id, 0), me.call_kind, me.note, me.created_at
+		FROM manual_edges me
+		LEFT JOIN symbols c ON c.pkg_path = me.caller_pkg AND c.name = me.caller_name AND COALESCE(c.recv_type, '') = me.caller_recv
+		LEFT JOIN symbols d ON d.pkg_path = me.callee_pkg AND d.name = me.callee_name AND COALESCE(d.recv_type, '') = me.callee_recv
+		ORDER BY me.created_at, me.rowid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []ManualEdge
+	for rows.Next() {
+		var e ManualEdge
+		var callerPkg, callerName, callerRecv, calleePkg, calleeName, calleeRecv string
+		if err := rows.Scan(&callerPkg, &callerName, &callerRecv, &calleePkg, &calleeName, &calleeRecv,
+			&e.CallerID, &e.CalleeID, &e.CallKind, &e.Note, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.CallerKey = SymbolKey(callerPkg, callerName, callerRecv)
+		e.CalleeKey = SymbolKey(calleePkg, calleeName, calleeRecv)
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
+
+// ApplyManualEdges adds the user-asserted call edges whose symbols are in
+// the index to the call graph, after a re-index replaced the extracted
+// edges. Edges between symbols that no longer exist are kept but skipped.
+// It returns the number of manual edges in the graph.
+func (s *Store) ApplyManualEdges(ctx context.Context) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO call_edges (caller_id, callee_id, caller_file, caller_line, call_kind, count, resolved_by)
+		SELECT c.id, d.id, c.file, 0, me.call_kind, 1, ?
+		FROM manual_edges me
+		JOIN symbols c ON c.pkg_path = me.caller_pkg AND c.name = me.caller_name AND COALESCE(c.recv_type, '') = me.caller_recv
+		JOIN symbols d ON d.pkg_path = me.callee_pkg AND d.name = me.callee_name AND COALESCE(d.recv_type, '') = me.callee_recv
+	`, ResolvedManual); err != nil {
+		return 0, err
+	}
+
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM call_edges WHERE resolved_by = ?", ResolvedManual).Scan(&n)
+	return n, err
+}
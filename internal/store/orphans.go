@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"sort"
+)
+
+// GetOrphanedHandlers returns the HTTP handlers found by signature that
+// nothing wires up: no router-registered or other entrypoint reaches them,
+// either by calling them or by referencing them (as a method value passed to
+// a router, say) from a function it reaches. Orphans are likely dead
+// endpoints or forgotten registrations. They are ordered by package and
+// label.
+func (s *Store) GetOrphanedHandlers(ctx context.Context) ([]EntrypointWithSymbol, error) {
+	eps, err := s.GetEntrypoints(ctx, EntrypointFilter{})
+	if err != nil {
+		return nil, err
+	}
+	callees, err := s.getCalleeAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	referenced, err := s.getReferenceAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []EntrypointWithSymbol
+	seen := make(map[SymbolID]bool)
+	var queue []SymbolID
+	for _, ep := range eps {
+		if ep.DiscoveryMethod == "signature" {
+			candidates = append(candidates, ep)
+			continue
+		}
+		if !seen[ep.SymbolID] {
+			seen[ep.SymbolID] = true
+			queue = append(queue, ep.SymbolID)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, adj := range [][]SymbolID{callees[id], referenced[id]} {
+			for _, next := range adj {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+
+	var orphans []EntrypointWithSymbol
+	for _, ep := range candidates {
+		if !seen[ep.SymbolID] {
+			orphans = append(orphans, ep)
+		}
+	}
+	sort.SliceStable(orphans, func(i, j int) bool {
+		if orphans[i].Symbol.PkgPath != orphans[j].Symbol.PkgPath {
+			return orphans[i].Symbol.PkgPath < orphans[j].Symbol.PkgPath
+		}
+		return orphans[i].Label < orphans[j].Label
+	})
+	return orphans, nil
+}
+
+// getReferenceAdjacency maps each symbol to the symbols it references.
+func (s *Store) getReferenceAdjacency(ctx context.Context) (map[SymbolID][]SymbolID, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `SELECT DISTINCT from_id, symbol_id FROM symbol_refs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adj := make(map[SymbolID][]SymbolID)
+	for rows.Next() {
+		var from, to SymbolID
+		if err := rows.Scan(&from, &to); err != nil {
+			return nil, err
+		}
+		adj[from] = append(adj[from], to)
+	}
+	return adj, rows.Err()
+}
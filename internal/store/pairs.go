@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Call pairs aggregate call_edges, which hold one row per call site, into
+// one row per caller and callee. Hot pairs called from many sites then cost
+// graph queries one row instead of thousands. The table is rebuilt at the
+// end of each indexing run (RebuildCallPairs) and kept in step with manual
+// edges; indexes written before it existed fall back to call_edges.
+
+// callPairsKey is the metadata key recording that call_pairs is populated.
+const callPairsKey = "call_pairs_at"
+
+// aggregatePairs selects call_pairs rows from the call_edges matching the
+// condition appended to it. The representative site is the first by line,
+// preferring sites that aren't deferred, so a pair only counts as deferred
+// when every site is.
+const aggregatePairs = `
+	WITH sites AS (
+		SELECT caller_id, callee_id, caller_file, caller_line, call_kind, resolved_by, expr,
+		       ROW_NUMBER() OVER (PARTITION BY caller_id, callee_id
+		                          ORDER BY call_kind = 'defer', caller_line, caller_file) AS site,
+		       SUM(count) OVER (PARTITION BY caller_id, callee_id) AS total,
+		       COUNT(*) OVER (PARTITION BY caller_id, callee_id) AS callsites
+		FROM call_edges
+		WHERE %s
+	)
+	SELECT caller_id, callee_id, total, callsites,
+	       (SELECT group_concat(call_kind, ',') FROM (
+	            SELECT DISTINCT call_kind FROM call_edges e
+	            WHERE e.caller_id = sites.caller_id AND e.callee_id = sites.callee_id
+	            ORDER BY call_kind)),
+	       call_kind, resolved_by, caller_file, caller_line, expr,
+	       (SELECT json_group_array(json_object('file', caller_file, 'line', caller_line)) FROM (
+	            SELECT caller_file, caller_line FROM call_edges e
+	            WHERE e.caller_id = sites.caller_id AND e.callee_id = sites.callee_id
+	            ORDER BY caller_file, caller_line))
+	FROM sites
+	WHERE site = 1
+`
+
+// RebuildCallPairs recomputes call_pairs from call_edges. Indexing runs
+// call it once the call graph is complete.
+func (s *Store) RebuildCallPairs(ctx context.Context) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM call_pairs"); err != nil {
+		return fmt.Errorf("clearing call pairs: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO call_pairs (caller_id, callee_id, count, callsites, kinds, call_kind, resolved_by, caller_file, caller_line, expr, sites)
+	`+fmt.Sprintf(aggregatePairs, "1=1")); err != nil {
+		return fmt.Errorf("aggregating call pairs: %w", err)
+	}
+	return s.SetMetadata(ctx, callPairsKey, "1")
+}
+
+// refreshCallPair recomputes the call_pairs row of one caller and callee
+// after their call edges changed.
+func refreshCallPair(ctx context.Context, q querier, callerID, calleeID SymbolID) error {
+	if _, err := q.ExecContext(ctx, "DELETE FROM call_pairs WHERE caller_id = ? AND callee_id = ?", callerID, calleeID); err != nil {
+		return fmt.Errorf("clearing call pair: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO call_pairs (caller_id, callee_id, count, callsites, kinds, call_kind, resolved_by, caller_file, caller_line, expr, sites)
+	`+fmt.Sprintf(aggregatePairs, "caller_id = ? AND callee_id = ?"), callerID, calleeID); err != nil {
+		return fmt.Errorf("aggregating call pair: %w", err)
+	}
+	return nil
+}
+
+// decodeSites parses the sites column of a call pair, resolving its paths
+// against the caller's repository. Pairs aggregated before the column
+// existed have no sites.
+func (s *Store) decodeSites(ctx context.Context, repo, sites string) ([]Callsite, error) {
+	var out []Callsite
+	if err := json.Unmarshal([]byte(sites), &out); err != nil {
+		return nil, fmt.Errorf("decoding call sites: %w", err)
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	for i := range out {
+		out[i].File = s.absPath(ctx, repo, out[i].File)
+	}
+	return out, nil
+}
+
+// HasCallPairs reports whether call_pairs is populated, i.e. the index was
+// written by a run that aggregates call edges.
+func (s *Store) HasCallPairs(ctx context.Context) bool {
+	v, err := s.GetMetadata(ctx, callPairsKey)
+	return err == nil && v != ""
+}
+
+// GetCallPairs returns the callees of a symbol with one entry per callee,
+// in order of their first call site. Count sums the call counts of every
+// site, Callsites counts the sites, and the call site fields describe the
+// first one. It needs call_pairs to be populated (see HasCallPairs).
+func (s *Store) GetCallPairs(ctx context.Context, callerID SymbolID) ([]CalleeInfo, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT s.id, s.pkg_path, s.name, s.kind, COALESCE(s.recv_type, '') as recv_type,
+		       s.file, s.line, COALESCE(s.sig, '') as sig, s.repo,
+		       cp.call_kind, cp.kinds, cp.resolved_by, cp.caller_file, cp.caller_line, cp.count, cp.expr, cp.callsites, cp.sites, cs.repo
+		FROM call_pairs cp
+		JOIN symbols s ON cp.callee_id = s.id
+		JOIN symbols cs ON cp.caller_id = cs.id
+		WHERE cp.caller_id = ?
+		ORDER BY cp.caller_line, s.id
+	`, callerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []CalleeInfo
+	for rows.Next() {
+		var c CalleeInfo
+		var kinds, sites, callerRepo string
+		err := rows.Scan(
+			&c.Symbol.ID, &c.Symbol.PkgPath, &c.Symbol.Name, &c.Symbol.Kind,
+			&c.Symbol.RecvType, &c.Symbol.File, &c.Symbol.Line, &c.Symbol.Sig, &c.Symbol.Repo,
+			&c.CallKind, &kinds, &c.ResolvedBy, &c.CallerFile, &c.CallerLine, &c.Count, &c.Expr, &c.Callsites, &sites, &callerRepo,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range strings.Split(kinds, ",") {
+			c.Kinds = append(c.Kinds, CallKind(k))
+		}
+		if c.Sites, err = s.decodeSites(ctx, callerRepo, sites); err != nil {
+			return nil, err
+		}
+		c.Symbol.File = s.absPath(ctx, c.Symbol.Repo, c.Symbol.File)
+		c.CallerFile = s.absPath(ctx, callerRepo, c.CallerFile)
+		results = append(results, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		tags, err := s.GetSymbolTags(ctx, results[i].Symbol.ID)
+		if err != nil {
+			return nil, err
+		}
+		results[i].Tags = tags
+	}
+	return results, nil
+}
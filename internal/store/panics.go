@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Panic check statuses.
+const (
+	PanicNone        = "none"        // No panic is reachable from the handler
+	PanicRecovered   = "recovered"   // A reachable panic is recovered by the handler or a middleware
+	PanicUnrecovered = "unrecovered" // A reachable panic would crash the service
+)
+
+// PanicCheck records whether a panic reachable from an HTTP or gRPC
+// entrypoint is recovered before it crashes the service.
+type PanicCheck struct {
+	EntrypointID    EntrypointID   `json:"entrypoint_id"`
+	EntrypointLabel string         `json:"entrypoint_label,omitempty"` // Filled in on read
+	EntrypointType  EntrypointType `json:"entrypoint_type,omitempty"`  // Filled in on read
+	Status          string         `json:"status"`                     // PanicNone, PanicRecovered, or PanicUnrecovered
+	Call            string         `json:"call,omitempty"`             // "panic" or the panicking function, e.g. "regexp.MustCompile"
+	File            string         `json:"file,omitempty"`             // Location of the panicking call
+	Line            int            `json:"line,omitempty"`
+	Path            []string       `json:"path,omitempty"`         // Functions from the handler to the one panicking
+	RecoveredBy     string         `json:"recovered_by,omitempty"` // Recovering middleware or function
+	Goroutine       bool           `json:"goroutine,omitempty"`    // The panic is in a goroutine, which no caller can recover
+}
+
+// InsertPanicCheck records a panic check result within the batch.
+func (b *BatchTx) InsertPanicCheck(ctx context.Context, c *PanicCheck) error {
+	path, err := json.Marshal(c.Path)
+	if err != nil {
+		return fmt.Errorf("encoding path: %w", err)
+	}
+	_, err = b.tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO panic_checks (entrypoint_id, status, call, file, line, path_json, recovered_by, goroutine)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.EntrypointID, c.Status, c.Call, relPath(b.baseDir, c.File), c.Line, string(path), c.RecoveredBy, c.Goroutine)
+	return err
+}
+
+// GetPanicChecks retrieves panic check results, optionally only those with
+// the given status, ordered by entrypoint type and label.
+func (s *Store) GetPanicChecks(ctx context.Context, status string) ([]PanicCheck, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT p.entrypoint_id, e.label, e.type, p.status, p.call, p.file, p.line,
+		       COALESCE(p.path_json, 'null'), p.recovered_by, p.goroutine, s.repo
+		FROM panic_checks p
+		JOIN entrypoints e ON p.entrypoint_id = e.id
+		JOIN symbols s ON e.symbol_id = s.id
+	`
+	var args []interface{}
+	if status != "" {
+		query += " WHERE p.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY e.type, e.label, p.entrypoint_id"
+
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []PanicCheck
+	for rows.Next() {
+		var c PanicCheck
+		var path, repo string
+		if err := rows.Scan(&c.EntrypointID, &c.EntrypointLabel, &c.EntrypointType, &c.Status, &c.Call,
+			&c.File, &c.Line, &path, &c.RecoveredBy, &c.Goroutine, &repo); err != nil {
+			return nil, err
+		}
+		c.File = s.absPath(ctx, repo, c.File)
+		if err := json.Unmarshal([]byte(path), &c.Path); err != nil {
+			return nil, fmt.Errorf("decoding path: %w", err)
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+)
+
+// File paths are stored relative to the project root, slash-separated, so an
+// index built on one machine (e.g. in CI) works on another. Paths outside the
+// root, such as files in the module cache, are stored as they are, also
+// slash-separated. Paths are made absolute again on read, against the root
+// of the repository they belong to, and converted to this OS's separators;
+// backslashes, which indexes built on Windows by older versions stored, are
+// read as separators on every OS.
+
+// relPath returns the stored form of path: relative to baseDir when it is
+// inside it, unchanged otherwise, and slash-separated.
+func relPath(baseDir, path string) string {
+	if path == "" || baseDir == "" || !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// absPath resolves a stored path against the root of repo ("" for an
+// unnamed project). Absolute paths, including those of another OS, are
+// returned unchanged but for their separators.
+func (s *Store) absPath(ctx context.Context, repo, path string) string {
+	if path == "" || isAbsPath(path) {
+		return localPath(path)
+	}
+	root := s.repoRoot(ctx, repo)
+	if root == "" {
+		return localPath(path)
+	}
+	return filepath.Join(root, localPath(path))
+}
+
+// localPath converts a stored path to this OS's separators, reading both
+// slashes and backslashes as separators.
+func localPath(path string) string {
+	return filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+}
+
+// isAbsPath reports whether a stored path is absolute on any OS: rooted
+// (/home/ci/..., or //host/share/... on Windows) or starting with a drive
+// letter (C:/...). An index built on Windows thus keeps its module cache
+// paths on Linux, and the other way around, instead of joining them to the
+// repository root.
+func isAbsPath(path string) bool {
+	p := strings.ReplaceAll(path, `\`, "/")
+	if strings.HasPrefix(p, "/") {
+		return true
+	}
+	if len(p) < 3 || p[1] != ':' || p[2] != '/' {
+		return false
+	}
+	c := p[0] | 0x20 // Lower case
+	return c >= 'a' && c <= 'z'
+}
+
+// RepoRoot returns the root directory of repo ("" for an unnamed project)
+// in a shared index, as stored paths are resolved against it.
+func (s *Store) RepoRoot(ctx context.Context, repo string) string {
+	return s.repoRoot(ctx, repo)
+}
+
+// repoRoot returns the directory paths of repo are relative to: the
+// directory its latest indexing run recorded for a named repository
+// (resolved against the database's directory), and otherwise the store's
+// project directory or the recorded project_dir. Looked-up directories are
+// cached; SetRepoDir updates the cache.
+func (s *Store) repoRoot(ctx context.Context, repo string) string {
+	if dir, ok := s.repoDirs.Load(repo); ok {
+		return dir.(string)
+	}
+	var dir string
+	if repo != "" {
+		dir, _ = s.GetMetadata(ctx, repoDirKey(repo))
+		if dir != "" && !isAbsPath(dir) {
+			if dbDir, err := filepath.Abs(filepath.Dir(s.dbPath)); err == nil {
+				dir = filepath.Join(dbDir, localPath(dir))
+			}
+		} else {
+			dir = localPath(dir)
+		}
+	}
+	if dir == "" && s.baseDir != "" {
+		return s.baseDir
+	}
+	if dir == "" {
+		dir, _ = s.GetMetadata(ctx, "project_dir")
+		dir = localPath(dir)
+	}
+	if dir != "" {
+		s.repoDirs.Store(repo, dir)
+	}
+	return dir
+}
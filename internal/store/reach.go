@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// ReachQuery bounds a call graph traversal run in SQL by GetReachableCallees.
+// Its predicates only prune: callers should still apply their own filters to
+// the result, which may hold symbols they would not have expanded.
+type ReachQuery struct {
+	MaxDepth     int      // Symbols MaxDepth or more calls from the root are not expanded
+	StopPrefixes []string // Symbols in packages with these path prefixes are not expanded
+	StopAtIO     bool     // Symbols with a direct I/O tag are not expanded
+	SkipStdlib   bool     // Standard library callees are not expanded
+	KeepStdlib   []string // Except those in these packages; a trailing "*" matches any suffix
+	SkipVendor   bool     // Vendored callees are not expanded
+}
+
+// CallTree holds the callees of every symbol expanded by a traversal.
+type CallTree struct {
+	Callees map[SymbolID][]CalleeInfo // One entry, possibly empty, per expanded symbol
+}
+
+// GetReachableCallees walks the call graph from root in a single recursive
+// query and returns the callees of every symbol it expands, in the order
+// GetCallPairs (or GetCallees, for indexes without call pairs) lists them.
+func (s *Store) GetReachableCallees(ctx context.Context, root SymbolID, q ReachQuery) (*CallTree, error) {
+	tree := &CallTree{Callees: make(map[SymbolID][]CalleeInfo)}
+	if q.MaxDepth <= 0 {
+		return tree, nil
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	edges := `SELECT caller_id, callee_id, call_kind, '' AS kinds, resolved_by, caller_file, caller_line, count, 0 AS callsites, expr, '[]' AS sites FROM call_edges`
+	if s.HasCallPairs(ctx) {
+		edges = `SELECT caller_id, callee_id, call_kind, kinds, resolved_by, caller_file, caller_line, count, callsites, expr, sites FROM call_pairs`
+	}
+
+	// expandable holds for the symbols whose callees the traversal follows;
+	// the filters on callees don't apply to the root
+	expandable := []string{"1=1"}
+	var args []any
+	for _, prefix := range q.StopPrefixes {
+		expandable = append(expandable, "substr(s.pkg_path, 1, length(?)) != ?")
+		args = append(args, prefix, prefix)
+	}
+	if q.StopAtIO {
+		expandable = append(expandable, "NOT EXISTS (SELECT 1 FROM tags t WHERE t.symbol_id = s.id AND substr(t.tag, 1, 3) = 'io:' AND instr(t.tag, '@') = 0)")
+	}
+	if q.SkipStdlib {
+		keep := ""
+		for _, pattern := range q.KeepStdlib {
+			if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+				keep += " OR substr(s.pkg_path, 1, length(?)) = ?"
+				args = append(args, prefix, prefix)
+			} else {
+				keep += " OR s.pkg_path = ?"
+				args = append(args, pattern)
+			}
+		}
+		expandable = append(expandable, `(r.depth = 0 OR s.pkg_path = '' OR instr(substr(s.pkg_path, 1,
+			CASE instr(s.pkg_path, '/') WHEN 0 THEN length(s.pkg_path) ELSE instr(s.pkg_path, '/') - 1 END), '.') > 0`+keep+`)`)
+	}
+	if q.SkipVendor {
+		expandable = append(expandable, "(r.depth = 0 OR (instr(s.pkg_path, '/vendor/') = 0 AND substr(s.pkg_path, 1, 7) != 'vendor/'))")
+	}
+	cond := strings.Join(expandable, " AND ")
+
+	query := `
+		WITH RECURSIVE
+		edges AS (` + edges + `),
+		reach(id, depth) AS (
+			SELECT ?, 0
+			UNION
+			SELECT e.callee_id, r.depth + 1
+			FROM reach r
+			JOIN symbols s ON s.id = r.id
+			JOIN edges e ON e.caller_id = r.id
+			WHERE r.depth + 1 < ? AND ` + cond + `
+		),
+		open AS (
+			SELECT DISTINCT r.id
+			FROM reach r
+			JOIN symbols s ON s.id = r.id
+			WHERE ` + cond + `
+		)
+		SELECT o.id, COALESCE(c.id, 0), COALESCE(c.pkg_path, ''), COALESCE(c.name, ''), COALESCE(c.kind, ''),
+		       COALESCE(c.recv_type, ''), COALESCE(c.file, ''), COALESCE(c.line, 0), COALESCE(c.sig, ''), COALESCE(c.repo, ''),
+		       COALESCE(c.call_kind, ''), COALESCE(c.kinds, ''), COALESCE(c.resolved_by, ''), COALESCE(c.caller_file, ''),
+		       COALESCE(c.caller_line, 0), COALESCE(c.count, 0), COALESCE(c.callsites, 0), COALESCE(c.expr, ''), COALESCE(c.sites, '[]'), COALESCE(c.caller_repo, ''),
+		       COALESCE(c.tags, '[]')
+		FROM open o
+		LEFT JOIN (
+			SELECT e.caller_id, s.id, s.pkg_path, s.name, s.kind, s.recv_type, s.file, s.line, s.sig, s.repo,
+			       e.call_kind, e.kinds, e.resolved_by, e.caller_file, e.caller_line, e.count, e.callsites, e.expr, e.sites,
+			       cs.repo AS caller_repo,
+			       (SELECT json_group_array(json_object('symbol_id', t.symbol_id, 'tag', t.tag, 'reason', COALESCE(t.reason, '')))
+			        FROM tags t WHERE t.symbol_id = s.id) AS tags
+			FROM edges e
+			JOIN symbols s ON s.id = e.callee_id
+			JOIN symbols cs ON cs.id = e.caller_id
+		) c ON c.caller_id = o.id
+		ORDER BY o.id, c.caller_line, c.id
+	`
+	// The condition appears in both the recursive step and the open set
+	all := append([]any{root, q.MaxDepth}, args...)
+	all = append(all, args...)
+	rows, err := s.readDB.QueryContext(ctx, query, all...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var caller SymbolID
+		var c CalleeInfo
+		var kinds, sites, callerRepo, tags string
+		err := rows.Scan(&caller,
+			&c.Symbol.ID, &c.Symbol.PkgPath, &c.Symbol.Name, &c.Symbol.Kind,
+			&c.Symbol.RecvType, &c.Symbol.File, &c.Symbol.Line, &c.Symbol.Sig, &c.Symbol.Repo,
+			&c.CallKind, &kinds, &c.ResolvedBy, &c.CallerFile, &c.CallerLine, &c.Count, &c.Callsites, &c.Expr, &sites, &callerRepo,
+			&tags,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if c.Symbol.ID == 0 {
+			// Expanded symbol without callees
+			tree.Callees[caller] = nil
+			continue
+		}
+		if kinds != "" {
+			for _, k := range strings.Split(kinds, ",") {
+				c.Kinds = append(c.Kinds, CallKind(k))
+			}
+		}
+		if c.Sites, err = s.decodeSites(ctx, callerRepo, sites); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(tags), &c.Tags); err != nil {
+			return nil, err
+		}
+		if len(c.Tags) == 0 {
+			c.Tags = nil
+		}
+		c.Symbol.File = s.absPath(ctx, c.Symbol.Repo, c.Symbol.File)
+		c.CallerFile = s.absPath(ctx, callerRepo, c.CallerFile)
+		tree.Callees[caller] = append(tree.Callees[caller], c)
+	}
+	return tree, rows.Err()
+}
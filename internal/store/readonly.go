@@ -0,0 +1,97 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// ErrReadOnly is returned for writes to a store opened with OpenReadOnly.
+var ErrReadOnly = errors.New("index is opened read-only")
+
+// OpenReadOnly opens the index database at dbPath without write access, so
+// a server can't change the index and can serve one on a read-only
+// filesystem or shared mount. The schema is neither created nor migrated.
+func OpenReadOnly(dbPath, projectDir string) (*Store, error) {
+	p, err := openReadOnlyPools(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if projectDir != "" {
+		if abs, err := filepath.Abs(projectDir); err == nil {
+			projectDir = abs
+		}
+	}
+
+	live := &livePools{p: p}
+	return &Store{
+		pools:    live,
+		readOnly: true,
+		db:       writeQuerier{live},
+		readDB:   readQuerier{live},
+		dbPath:   dbPath,
+		baseDir:  projectDir,
+		repoDirs: &sync.Map{},
+	}, nil
+}
+
+// ReadOnly reports whether the store was opened with OpenReadOnly.
+func (s *Store) ReadOnly() bool {
+	return s.readOnly
+}
+
+// openReadOnlyPools opens one read-only pool on dbPath. Reading a WAL
+// database needs write access to its shared-memory file; only where that
+// can't be had, e.g. on a read-only filesystem, is the database opened as
+// immutable instead, which ignores changes still in its WAL.
+func openReadOnlyPools(dbPath string) (*pools, error) {
+	file, err := os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	mode := "mode=ro"
+	if err := probeShm(dbPath); err != nil {
+		if !errors.Is(err, syscall.EROFS) && !errors.Is(err, fs.ErrPermission) {
+			return nil, fmt.Errorf("opening database: %w", err)
+		}
+		log.Printf("Can't write %s-shm (%v); serving the index immutable, ignoring its WAL", dbPath, err)
+		mode = "mode=ro&immutable=1"
+	}
+
+	common := fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)&_pragma=cache_size(-64000)", busyTimeoutMs)
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?"+mode+"&"+common)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	if _, err := db.Exec("SELECT COUNT(*) FROM metadata"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening database read-only: %w", err)
+	}
+	return &pools{write: db, read: db, file: file}, nil
+}
+
+// probeShm checks that SQLite can open dbPath's shared-memory file for
+// writing, creating it if needed.
+func probeShm(dbPath string) error {
+	f, err := os.OpenFile(dbPath+"-shm", os.O_RDWR, 0)
+	if err == nil {
+		return f.Close()
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	probe, err := os.CreateTemp(filepath.Dir(dbPath), ".flowlens-probe-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+)
+
+// Reference is a use of a symbol in source.
+type Reference struct {
+	SymbolID SymbolID `json:"symbol_id"`
+	From     Symbol   `json:"from"` // Declaration containing the use
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+}
+
+// InsertReference records a use of a symbol within the batch.
+func (b *BatchTx) InsertReference(ctx context.Context, ref *Reference) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO symbol_refs (symbol_id, from_id, file, line, col)
+		VALUES (?, ?, ?, ?, ?)
+	`, ref.SymbolID, ref.From.ID, relPath(b.baseDir, ref.File), ref.Line, ref.Column)
+	return err
+}
+
+// GetReferences returns the uses of a symbol ordered by position.
+func (s *Store) GetReferences(ctx context.Context, id SymbolID) ([]Reference, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT r.symbol_id, r.file, r.line, r.col,
+		       f.id, f.pkg_path, f.name, f.kind, COALESCE(f.recv_type, ''), f.file, f.line, f.repo
+		FROM symbol_refs r
+		JOIN symbols f ON f.id = r.from_id
+		WHERE r.symbol_id = ?
+		ORDER BY f.pkg_path, r.file, r.line, r.col
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []Reference
+	for rows.Next() {
+		var ref Reference
+		from := &ref.From
+		if err := rows.Scan(&ref.SymbolID, &ref.File, &ref.Line, &ref.Column,
+			&from.ID, &from.PkgPath, &from.Name, &from.Kind, &from.RecvType, &from.File, &from.Line, &from.Repo); err != nil {
+			return nil, err
+		}
+		ref.File = s.absPath(ctx, from.Repo, ref.File)
+		from.File = s.absPath(ctx, from.Repo, from.File)
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
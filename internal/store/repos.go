@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// RepoInfo summarizes one repository in a shared index.
+type RepoInfo struct {
+	Name         string   `json:"name"`
+	Dir          string   `json:"dir,omitempty"` // Project directory of its latest indexing run
+	Modules      []string `json:"modules"`
+	PackageCount int      `json:"package_count"`
+	SymbolCount  int      `json:"symbol_count"`
+}
+
+// repoDirKey is the metadata key holding a repository's project directory.
+func repoDirKey(repo string) string {
+	return "repo_dir:" + repo
+}
+
+// ClearRepo removes one repository's data from a shared index, leaving
+// other repositories in place. Call edges into the repository from other
+// repositories are removed too; ResolveCrossRepoEdges restores them from
+// the callers' recorded external calls once the repository is re-indexed.
+func (s *Store) ClearRepo(ctx context.Context, repo string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	const repoSymbols = "SELECT id FROM symbols WHERE repo = ?"
+	const repoEntrypoints = "SELECT id FROM entrypoints WHERE symbol_id IN (" + repoSymbols + ")"
+	const repoPackages = "SELECT pkg_path FROM packages WHERE repo = ?"
+	statements := []struct {
+		table, query string
+		args         int
+	}{
+		{"diagnostics", "DELETE FROM diagnostics WHERE repo = ?", 1},
+		{"unresolved_calls", "DELETE FROM unresolved_calls WHERE pkg_path IN (" + repoPackages + ")", 1},
+		{"skipped_functions", "DELETE FROM skipped_functions WHERE pkg_path IN (" + repoPackages + ")", 1},
+		{"external_calls", "DELETE FROM external_calls WHERE caller_id IN (" + repoSymbols + ")", 1},
+		{"global_writes", "DELETE FROM global_writes WHERE var_id IN (" + repoSymbols + ") OR writer_id IN (" + repoSymbols + ")", 2},
+		{"error_sites", "DELETE FROM error_sites WHERE symbol_id IN (" + repoSymbols + ")", 1},
+		{"flag_uses", "DELETE FROM flag_uses WHERE symbol_id IN (" + repoSymbols + ")", 1},
+		{"auth_checks", "DELETE FROM auth_checks WHERE entrypoint_id IN (" + repoEntrypoints + ")", 1},
+		{"panic_checks", "DELETE FROM panic_checks WHERE entrypoint_id IN (" + repoEntrypoints + ")", 1},
+		{"taint_findings", "DELETE FROM taint_findings WHERE entrypoint_id IN (" + repoEntrypoints + ")", 1},
+		{"grpc_methods", "DELETE FROM grpc_methods WHERE repo = ?", 1},
+		{"tags", "DELETE FROM tags WHERE symbol_id IN (" + repoSymbols + ")", 1},
+		{"entrypoints", "DELETE FROM entrypoints WHERE symbol_id IN (" + repoSymbols + ")", 1},
+		{"implementations", "DELETE FROM implementations WHERE interface_id IN (" + repoSymbols + ") OR type_id IN (" + repoSymbols + ")", 2},
+		{"interface_methods", "DELETE FROM interface_methods WHERE interface_id IN (" + repoSymbols + ")", 1},
+		{"type_relations", "DELETE FROM type_relations WHERE type_id IN (" + repoSymbols + ")", 1},
+		{"symbol_refs", "DELETE FROM symbol_refs WHERE symbol_id IN (" + repoSymbols + ") OR from_id IN (" + repoSymbols + ")", 2},
+		{"call_pairs", "DELETE FROM call_pairs WHERE caller_id IN (" + repoSymbols + ") OR callee_id IN (" + repoSymbols + ")", 2},
+		{"call_edges", "DELETE FROM call_edges WHERE caller_id IN (" + repoSymbols + ") OR callee_id IN (" + repoSymbols + ")", 2},
+		{"symbols", "DELETE FROM symbols WHERE repo = ?", 1},
+		{"packages", "DELETE FROM packages WHERE repo = ?", 1},
+	}
+	for _, stmt := range statements {
+		args := make([]interface{}, stmt.args)
+		for i := range args {
+			args[i] = repo
+		}
+		if _, err := s.db.ExecContext(ctx, stmt.query, args...); err != nil {
+			return fmt.Errorf("clearing table %s: %w", stmt.table, err)
+		}
+	}
+	return nil
+}
+
+// SetRepoDir records the project directory a repository was indexed from.
+// It is stored relative to the database's directory when possible, so a
+// shared index copied along with its repositories (e.g. from CI) resolves
+// them at their new location.
+func (s *Store) SetRepoDir(ctx context.Context, repo, dir string) error {
+	stored := filepath.ToSlash(dir)
+	if dbDir, err := filepath.Abs(filepath.Dir(s.dbPath)); err == nil {
+		if rel, err := filepath.Rel(dbDir, dir); err == nil {
+			stored = filepath.ToSlash(rel)
+		}
+	}
+	if err := s.SetMetadata(ctx, repoDirKey(repo), stored); err != nil {
+		return err
+	}
+	s.repoDirs.Store(repo, dir)
+	return nil
+}
+
+// rebaseRepoDirs rewrites the repository directories recorded relative to
+// fromDir, the directory of the database this one was copied from, to be
+// relative to this database's directory, so they keep naming the same
+// repositories.
+func (s *Store) rebaseRepoDirs(ctx context.Context, fromDir string) error {
+	dirs, err := func() (map[string]string, error) {
+		ctx, cancel := s.withTimeout(ctx)
+		defer cancel()
+
+		rows, err := s.readDB.QueryContext(ctx, "SELECT key, value FROM metadata WHERE key LIKE 'repo_dir:%'")
+		if err != nil {
+			return nil, fmt.Errorf("listing repository directories: %w", err)
+		}
+		defer rows.Close()
+
+		dirs := make(map[string]string)
+		for rows.Next() {
+			var key, dir string
+			if err := rows.Scan(&key, &dir); err != nil {
+				return nil, err
+			}
+			if dir != "" && !isAbsPath(dir) {
+				dirs[strings.TrimPrefix(key, repoDirKey(""))] = filepath.Join(fromDir, localPath(dir))
+			}
+		}
+		return dirs, rows.Err()
+	}()
+	if err != nil {
+		return err
+	}
+	for repo, dir := range dirs {
+		if err := s.SetRepoDir(ctx, repo, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveCrossRepoEdges turns recorded external calls into call edges when
+// the callee belongs to another repository in the same index, matching the
+// callee's module path, package, name, and receiver. Calls through an
+// interface are matched by method name, so they count as heuristic. It
+// returns the number of edges added.
+func (s *Store) ResolveCrossRepoEdges(ctx context.Context) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO call_edges (caller_id, callee_id, caller_file, caller_line, call_kind, count, resolved_by)
+		SELECT e.caller_id, s.id, e.caller_file, e.caller_line, e.call_kind, 1,
+		       CASE e.call_kind WHEN 'interface' THEN 'interface-heuristic' ELSE 'ssa-static' END
+		FROM external_calls e
+		JOIN packages p ON p.pkg_path = e.pkg_path AND p.module = e.module
+		JOIN symbols s ON s.pkg_path = e.pkg_path AND s.name = e.name
+		     AND COALESCE(s.recv_type, '') = e.recv_type
+		JOIN symbols caller ON caller.id = e.caller_id
+		WHERE s.repo != caller.repo
+	`)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// GetRepos lists the repositories in the index. A single-repository index
+// built without a repo name reports one entry with an empty name.
+func (s *Store) GetRepos(ctx context.Context) ([]RepoInfo, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT p.repo, COALESCE(p.module, ''), COUNT(*),
+		       SUM((SELECT COUNT(*) FROM symbols s WHERE s.pkg_path = p.pkg_path))
+		FROM packages p
+		GROUP BY p.repo, COALESCE(p.module, '')
+		ORDER BY p.repo, COALESCE(p.module, '')
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repos []RepoInfo
+	for rows.Next() {
+		var name, module string
+		var pkgs, syms int
+		if err := rows.Scan(&name, &module, &pkgs, &syms); err != nil {
+			return nil, err
+		}
+		if n := len(repos); n == 0 || repos[n-1].Name != name {
+			repos = append(repos, RepoInfo{Name: name, Modules: []string{}})
+		}
+		r := &repos[len(repos)-1]
+		if module != "" {
+			r.Modules = append(r.Modules, module)
+		}
+		r.PackageCount += pkgs
+		r.SymbolCount += syms
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range repos {
+		if repos[i].Name != "" {
+			repos[i].Dir = s.repoRoot(ctx, repos[i].Name)
+		}
+	}
+	return repos, nil
+}
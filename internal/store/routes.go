@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// RouteConflictKind classifies a RouteConflict.
+type RouteConflictKind string
+
+const (
+	// RouteDuplicate is a method and path registered twice, patterns that
+	// differ only in parameter names included. Routers panic on these at
+	// startup or silently keep one of the handlers.
+	RouteDuplicate RouteConflictKind = "duplicate"
+	// RouteOverlap is two different patterns that match a common request,
+	// such as /users/{id} and /users/me. Which handler serves it depends on
+	// the router's precedence rules or registration order.
+	RouteOverlap RouteConflictKind = "overlap"
+)
+
+// RouteConflict is a pair of HTTP routes that can match the same request.
+type RouteConflict struct {
+	Kind   RouteConflictKind `json:"kind"`
+	Method string            `json:"method"` // Method both routes accept; "ANY" when neither restricts it
+	A      EntrypointRef     `json:"a"`
+	B      EntrypointRef     `json:"b"`
+}
+
+// GetRouteConflicts compares the method and path of every pair of HTTP
+// entrypoints found by router parsing and returns those that can match the
+// same request, duplicates first. Routes registered on different routers
+// (an admin and a public server, say) are compared like any other. Routes
+// for two different hosts never conflict, and a route without a host only
+// overlaps one with a host. A path ending in a slash matches its whole
+// subtree, as in ServeMux (/static/ overlaps /static/app.js), except for
+// the root /, which would otherwise overlap every route.
+func (s *Store) GetRouteConflicts(ctx context.Context) ([]RouteConflict, error) {
+	eps, err := s.GetEntrypoints(ctx, EntrypointFilter{Type: EntrypointHTTP})
+	if err != nil {
+		return nil, err
+	}
+
+	type route struct {
+		ref      EntrypointRef
+		method   string
+		host     string
+		segments []RouteSegment
+	}
+	var routes []route
+	for _, ep := range eps {
+		if ep.DiscoveryMethod == "signature" {
+			continue
+		}
+		var meta struct {
+			Method string `json:"method"`
+			Path   string `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(ep.MetaJSON), &meta); err != nil || meta.Path == "" {
+			continue
+		}
+		method, host, path := SplitRoutePattern(meta.Method, meta.Path)
+		routes = append(routes, route{
+			ref:      EntrypointRef{ID: ep.ID, Label: ep.Label, Type: ep.Type},
+			method:   method,
+			host:     host,
+			segments: subtreeSegments(ParseRoutePath(path)),
+		})
+	}
+
+	var conflicts []RouteConflict
+	for i := range routes {
+		for j := i + 1; j < len(routes); j++ {
+			a, b := routes[i], routes[j]
+			method := a.method
+			switch {
+			case a.method == b.method:
+			case a.method == "ANY":
+				method = b.method
+			case b.method == "ANY":
+			default:
+				continue
+			}
+			if a.host != b.host && a.host != "" && b.host != "" {
+				continue
+			}
+			kind := RouteOverlap
+			if a.host == b.host && routesEqual(a.segments, b.segments) {
+				kind = RouteDuplicate
+			} else if !routesOverlap(a.segments, b.segments) {
+				continue
+			}
+			if a.ref.ID > b.ref.ID {
+				a, b = b, a
+			}
+			conflicts = append(conflicts, RouteConflict{Kind: kind, Method: method, A: a.ref, B: b.ref})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Kind != conflicts[j].Kind {
+			return conflicts[i].Kind == RouteDuplicate
+		}
+		if conflicts[i].A.Label != conflicts[j].A.Label {
+			return conflicts[i].A.Label < conflicts[j].A.Label
+		}
+		return conflicts[i].B.Label < conflicts[j].B.Label
+	})
+	return conflicts, nil
+}
+
+// SplitRoutePattern moves the method of a Go 1.22 ServeMux pattern
+// ("GET example.com/users/{id}") out of its path, and the host prefix, if
+// any, into host.
+func SplitRoutePattern(method, pattern string) (string, string, string) {
+	var host string
+	path := pattern
+	if m, p, ok := strings.Cut(path, " "); ok && method == "ANY" {
+		method, path = strings.ToUpper(m), strings.TrimSpace(p)
+	}
+	if i := strings.Index(path, "/"); i > 0 {
+		host, path = path[:i], path[i:]
+	}
+	return method, host, path
+}
+
+// RouteSegment is one element of a route path.
+type RouteSegment struct {
+	Literal  string // Fixed text; empty for parameters and a trailing slash
+	Param    bool   // Matches any one segment: {id}, :id
+	CatchAll bool   // Matches the rest of the path: {path...}, *path, *
+	Name     string // Parameter name, if any
+	Pattern  string // Regexp constraint of a chi or gorilla/mux parameter, e.g. [0-9]+
+}
+
+// ParseRoutePath splits a path in the stdlib, chi, gorilla/mux, gin, or echo
+// syntax into segments. A trailing slash is kept as an empty segment.
+func ParseRoutePath(path string) []RouteSegment {
+	var segments []RouteSegment
+	for _, part := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		switch {
+		case strings.HasPrefix(part, "*"):
+			segments = append(segments, RouteSegment{CatchAll: true, Name: part[1:]})
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "...}"):
+			segments = append(segments, RouteSegment{CatchAll: true, Name: part[1 : len(part)-4]})
+		case strings.HasPrefix(part, ":"):
+			segments = append(segments, RouteSegment{Param: true, Name: part[1:]})
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") && part != "{$}":
+			name, pattern, _ := strings.Cut(part[1:len(part)-1], ":")
+			segments = append(segments, RouteSegment{Param: true, Name: name, Pattern: pattern})
+		default:
+			segments = append(segments, RouteSegment{Literal: part})
+		}
+	}
+	return segments
+}
+
+// subtreeSegments turns the empty last segment of a path ending in a slash,
+// other than the root, into a catch-all, since such a ServeMux pattern
+// matches every path below it. A trailing {$}, which matches the slash
+// alone, becomes the empty segment.
+func subtreeSegments(segments []RouteSegment) []RouteSegment {
+	n := len(segments)
+	switch {
+	case n > 1 && segments[n-1] == RouteSegment{}:
+		segments[n-1] = RouteSegment{CatchAll: true}
+	case n > 0 && segments[n-1].Literal == "{$}":
+		segments[n-1] = RouteSegment{}
+	}
+	return segments
+}
+
+// routesEqual reports whether two parsed paths match exactly the same
+// requests. Parameter names and regexp constraints are ignored, so routes
+// that differ only in them compare equal.
+func routesEqual(a, b []RouteSegment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Literal != b[i].Literal || a[i].Param != b[i].Param || a[i].CatchAll != b[i].CatchAll {
+			return false
+		}
+	}
+	return true
+}
+
+// routesOverlap reports whether some request path matches both parsed paths.
+func routesOverlap(a, b []RouteSegment) bool {
+	switch {
+	case len(a) > 0 && a[0].CatchAll, len(b) > 0 && b[0].CatchAll:
+		return true
+	case len(a) == 0 || len(b) == 0:
+		return len(a) == len(b)
+	case a[0].Param && b[0].Param:
+	case a[0].Param:
+		return b[0].Literal != "" && routesOverlap(a[1:], b[1:])
+	case b[0].Param:
+		return a[0].Literal != "" && routesOverlap(a[1:], b[1:])
+	case a[0].Literal != b[0].Literal:
+		return false
+	}
+	return routesOverlap(a[1:], b[1:])
+}
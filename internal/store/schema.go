@@ -1,5 +1,34 @@
 package store
 
+// SchemaVersion identifies the layout of the tables below. Bump it whenever
+// the schema changes so stale indexes can be detected.
+const SchemaVersion = 28
+
+// migrations add columns introduced after a table was first created.
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so each
+// entry is applied when the column is missing.
+var migrations = []struct {
+	table, column, definition string
+}{
+	{"packages", "layer_source", "TEXT"},
+	{"packages", "repo", "TEXT NOT NULL DEFAULT ''"},
+	{"symbols", "repo", "TEXT NOT NULL DEFAULT ''"},
+	{"external_calls", "name", "TEXT NOT NULL DEFAULT ''"},
+	{"external_calls", "recv_type", "TEXT NOT NULL DEFAULT ''"},
+	{"external_calls", "call_kind", "TEXT NOT NULL DEFAULT 'static'"},
+	{"call_edges", "resolved_by", "TEXT NOT NULL DEFAULT 'ssa-static'"},
+	{"symbols", "doc", "TEXT NOT NULL DEFAULT ''"},
+	{"symbols", "sig_json", "TEXT"},
+	{"symbols", "value", "TEXT NOT NULL DEFAULT ''"},
+	{"changes", "old_key", "TEXT"},
+	{"symbols", "col", "INTEGER NOT NULL DEFAULT 0"},
+	{"symbols", "end_line", "INTEGER NOT NULL DEFAULT 0"},
+	{"symbols", "end_col", "INTEGER NOT NULL DEFAULT 0"},
+	{"call_edges", "expr", "TEXT NOT NULL DEFAULT ''"},
+	{"call_pairs", "expr", "TEXT NOT NULL DEFAULT ''"},
+	{"call_pairs", "sites", "TEXT NOT NULL DEFAULT '[]'"},
+}
+
 // schema contains the SQL statements to create the FlowLens database schema.
 const schema = `
 -- Packages table
@@ -7,7 +36,9 @@ CREATE TABLE IF NOT EXISTS packages (
     pkg_path TEXT PRIMARY KEY,
     module   TEXT,
     dir      TEXT NOT NULL,
-    layer    TEXT
+    layer    TEXT,
+    layer_source TEXT, -- "config" or "inferred"; NULL when layer is empty
+    repo     TEXT NOT NULL DEFAULT '' -- Repository name when several share one index
 );
 
 CREATE INDEX IF NOT EXISTS idx_packages_module ON packages(module);
@@ -23,6 +54,13 @@ CREATE TABLE IF NOT EXISTS symbols (
     file      TEXT NOT NULL,
     line      INTEGER NOT NULL,
     sig       TEXT,
+    repo      TEXT NOT NULL DEFAULT '',
+    doc       TEXT NOT NULL DEFAULT '', -- Leading doc comment, truncated
+    sig_json  TEXT, -- Structured signature (params/results) of functions and methods
+    value     TEXT NOT NULL DEFAULT '', -- Resolved value of a constant
+    col       INTEGER NOT NULL DEFAULT 0, -- Column of line; with end_line and end_col, the declaration span (0 = unknown)
+    end_line  INTEGER NOT NULL DEFAULT 0,
+    end_col   INTEGER NOT NULL DEFAULT 0,
     FOREIGN KEY (pkg_path) REFERENCES packages(pkg_path)
 );
 
@@ -40,6 +78,8 @@ CREATE TABLE IF NOT EXISTS call_edges (
     caller_line INTEGER NOT NULL,
     call_kind   TEXT NOT NULL,
     count       INTEGER DEFAULT 1,
+    resolved_by TEXT NOT NULL DEFAULT 'ssa-static', -- ssa-static, interface-heuristic, closure-trace, or manual
+    expr        TEXT NOT NULL DEFAULT '', -- Source of the call expression, truncated; empty when unknown
     PRIMARY KEY (caller_id, callee_id, caller_file, caller_line),
     FOREIGN KEY (caller_id) REFERENCES symbols(id),
     FOREIGN KEY (callee_id) REFERENCES symbols(id)
@@ -49,6 +89,24 @@ CREATE INDEX IF NOT EXISTS idx_call_edges_caller ON call_edges(caller_id);
 CREATE INDEX IF NOT EXISTS idx_call_edges_callee ON call_edges(callee_id);
 CREATE INDEX IF NOT EXISTS idx_call_edges_kind ON call_edges(call_kind);
 
+-- Call pairs: call_edges aggregated to one row per caller and callee
+CREATE TABLE IF NOT EXISTS call_pairs (
+    caller_id   INTEGER NOT NULL,
+    callee_id   INTEGER NOT NULL,
+    count       INTEGER NOT NULL,  -- Sum of the call counts of every site
+    callsites   INTEGER NOT NULL,  -- Number of call sites
+    kinds       TEXT NOT NULL,     -- Distinct call kinds, comma-separated
+    call_kind   TEXT NOT NULL,     -- Representative (first) site
+    resolved_by TEXT NOT NULL,
+    caller_file TEXT NOT NULL,
+    caller_line INTEGER NOT NULL,
+    expr        TEXT NOT NULL DEFAULT '',
+    sites       TEXT NOT NULL DEFAULT '[]', -- Every site as a JSON array of {file, line}, by file and line
+    PRIMARY KEY (caller_id, callee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_call_pairs_callee ON call_pairs(callee_id);
+
 -- Entrypoints table
 CREATE TABLE IF NOT EXISTS entrypoints (
     id               INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -75,6 +133,271 @@ CREATE TABLE IF NOT EXISTS tags (
 
 CREATE INDEX IF NOT EXISTS idx_tags_tag ON tags(tag);
 
+-- Interface methods: the method set of each interface symbol, including
+-- embedded interfaces' methods
+CREATE TABLE IF NOT EXISTS interface_methods (
+    interface_id INTEGER NOT NULL,
+    name         TEXT NOT NULL,
+    sig          TEXT NOT NULL,
+    PRIMARY KEY (interface_id, name),
+    FOREIGN KEY (interface_id) REFERENCES symbols(id)
+);
+
+-- Implementations: project types whose method set satisfies an interface
+CREATE TABLE IF NOT EXISTS implementations (
+    interface_id INTEGER NOT NULL,
+    type_id      INTEGER NOT NULL,
+    pointer      INTEGER NOT NULL DEFAULT 0, -- 1 when only *T satisfies the interface
+    PRIMARY KEY (interface_id, type_id),
+    FOREIGN KEY (interface_id) REFERENCES symbols(id),
+    FOREIGN KEY (type_id) REFERENCES symbols(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_implementations_type ON implementations(type_id);
+
+-- Type relations: struct fields and embeddings (and embedded interfaces),
+-- linking a type to the named type at the core of each field's type
+CREATE TABLE IF NOT EXISTS type_relations (
+    type_id     INTEGER NOT NULL,
+    field       TEXT NOT NULL,
+    relation    TEXT NOT NULL, -- "field" or "embed"
+    field_type  TEXT NOT NULL,
+    target_pkg  TEXT NOT NULL DEFAULT '',
+    target_name TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (type_id, field),
+    FOREIGN KEY (type_id) REFERENCES symbols(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_type_relations_target ON type_relations(target_pkg, target_name);
+
+-- Symbol references: where constants are used, by the declaration
+-- (function, method, var, const, or type) containing the use
+CREATE TABLE IF NOT EXISTS symbol_refs (
+    symbol_id INTEGER NOT NULL,
+    from_id   INTEGER NOT NULL,
+    file      TEXT NOT NULL,
+    line      INTEGER NOT NULL,
+    col       INTEGER NOT NULL,
+    PRIMARY KEY (symbol_id, file, line, col),
+    FOREIGN KEY (symbol_id) REFERENCES symbols(id),
+    FOREIGN KEY (from_id) REFERENCES symbols(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_symbol_refs_from ON symbol_refs(from_id);
+
+-- Global writes: functions storing to package-level variables (directly or
+-- through a field, element, or pointer), by the named function containing
+-- the store
+CREATE TABLE IF NOT EXISTS global_writes (
+    var_id    INTEGER NOT NULL,
+    writer_id INTEGER NOT NULL,
+    file      TEXT NOT NULL,
+    line      INTEGER NOT NULL,
+    PRIMARY KEY (var_id, writer_id, file, line),
+    FOREIGN KEY (var_id) REFERENCES symbols(id),
+    FOREIGN KEY (writer_id) REFERENCES symbols(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_global_writes_writer ON global_writes(writer_id);
+
+-- Error sites: where functions wrap errors, drop them, or turn them into
+-- HTTP/gRPC status responses, by the named function containing the call
+CREATE TABLE IF NOT EXISTS error_sites (
+    symbol_id INTEGER NOT NULL,
+    kind      TEXT NOT NULL,   -- "wrap", "swallow", or "status"
+    call      TEXT NOT NULL,
+    file      TEXT NOT NULL,
+    line      INTEGER NOT NULL,
+    PRIMARY KEY (symbol_id, file, line, kind, call),
+    FOREIGN KEY (symbol_id) REFERENCES symbols(id)
+);
+
+-- Flag uses: feature-flag evaluations (see feature_flags in flowlens.yaml),
+-- by the named function containing the call; flag_key is empty when the
+-- key is not a constant
+CREATE TABLE IF NOT EXISTS flag_uses (
+    symbol_id INTEGER NOT NULL,
+    flag_key  TEXT NOT NULL,
+    call      TEXT NOT NULL,
+    file      TEXT NOT NULL,
+    line      INTEGER NOT NULL,
+    PRIMARY KEY (symbol_id, file, line, call),
+    FOREIGN KEY (symbol_id) REFERENCES symbols(id)
+);
+
+-- Changes table: what the latest indexing run added, removed, relocated,
+-- or moved
+CREATE TABLE IF NOT EXISTS changes (
+    entity       TEXT NOT NULL,
+    change       TEXT NOT NULL,
+    key          TEXT NOT NULL,
+    symbol_id    INTEGER,
+    old_location TEXT,
+    new_location TEXT,
+    old_key      TEXT, -- Previous key of a moved symbol
+    PRIMARY KEY (entity, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_changes_change ON changes(change);
+
+-- Taint findings: request inputs reaching sensitive sinks unsanitized
+CREATE TABLE IF NOT EXISTS taint_findings (
+    entrypoint_id INTEGER NOT NULL,
+    source        TEXT NOT NULL,
+    sink          TEXT NOT NULL,
+    sink_category TEXT NOT NULL,
+    file          TEXT NOT NULL,
+    line          INTEGER NOT NULL,
+    path_json     TEXT,
+    PRIMARY KEY (entrypoint_id, file, line, sink),
+    FOREIGN KEY (entrypoint_id) REFERENCES entrypoints(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_taint_findings_category ON taint_findings(sink_category);
+
+-- Auth checks: whether each HTTP entrypoint is behind auth middleware
+CREATE TABLE IF NOT EXISTS auth_checks (
+    entrypoint_id   INTEGER PRIMARY KEY,
+    status          TEXT NOT NULL,
+    via_json        TEXT,
+    middleware_json TEXT,
+    FOREIGN KEY (entrypoint_id) REFERENCES entrypoints(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_checks_status ON auth_checks(status);
+
+-- Panic checks: whether a panic reachable from each HTTP or gRPC entrypoint
+-- is recovered by the handler or a middleware
+CREATE TABLE IF NOT EXISTS panic_checks (
+    entrypoint_id INTEGER PRIMARY KEY,
+    status        TEXT NOT NULL,
+    call          TEXT NOT NULL DEFAULT '',
+    file          TEXT NOT NULL DEFAULT '',
+    line          INTEGER NOT NULL DEFAULT 0,
+    path_json     TEXT,
+    recovered_by  TEXT NOT NULL DEFAULT '',
+    goroutine     INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (entrypoint_id) REFERENCES entrypoints(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_panic_checks_status ON panic_checks(status);
+
+-- gRPC methods: each method of a generated gRPC service interface with the
+-- project types implementing the service
+CREATE TABLE IF NOT EXISTS grpc_methods (
+    pkg_path    TEXT NOT NULL,              -- Package of the generated interface
+    service     TEXT NOT NULL,              -- e.g. "UserService" for UserServiceServer
+    method      TEXT NOT NULL,
+    type_id     INTEGER,                    -- Implementing type; NULL when the service has none
+    registered  INTEGER NOT NULL DEFAULT 0, -- The type is passed to RegisterXServer
+    implemented INTEGER NOT NULL DEFAULT 0, -- The type declares the method instead of inheriting the Unimplemented stub
+    repo        TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (type_id) REFERENCES symbols(id)
+);
+
+-- External calls: project functions calling into third-party modules
+-- (recorded when dependency indexing or a repo name is set; calls into
+-- other repositories in the same index become call edges)
+CREATE TABLE IF NOT EXISTS external_calls (
+    caller_id   INTEGER NOT NULL,
+    module      TEXT NOT NULL,
+    version     TEXT,
+    pkg_path    TEXT NOT NULL,
+    func        TEXT NOT NULL,
+    name        TEXT NOT NULL DEFAULT '',
+    recv_type   TEXT NOT NULL DEFAULT '',
+    call_kind   TEXT NOT NULL DEFAULT 'static',
+    caller_file TEXT NOT NULL,
+    caller_line INTEGER NOT NULL,
+    PRIMARY KEY (caller_id, func, caller_file, caller_line),
+    FOREIGN KEY (caller_id) REFERENCES symbols(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_external_calls_module ON external_calls(module);
+
+-- Diagnostics: package loading errors, so gaps in the graph can be explained
+CREATE TABLE IF NOT EXISTS diagnostics (
+    pkg_path TEXT NOT NULL,
+    file     TEXT NOT NULL DEFAULT '',
+    line     INTEGER NOT NULL DEFAULT 0,
+    col      INTEGER NOT NULL DEFAULT 0,
+    kind     TEXT NOT NULL,
+    severity TEXT NOT NULL,  -- "error" or "warning"
+    message  TEXT NOT NULL,
+    repo     TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (pkg_path, file, line, col, message)
+);
+
+CREATE INDEX IF NOT EXISTS idx_diagnostics_severity ON diagnostics(severity);
+
+-- Unresolved calls: per-package counts of call sites that produced no edge
+CREATE TABLE IF NOT EXISTS unresolved_calls (
+    pkg_path TEXT NOT NULL,
+    reason   TEXT NOT NULL, -- "funcval", "interface", or "missing_symbol"
+    count    INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (pkg_path, reason)
+);
+
+-- Skipped functions: project functions without a symbol, whose calls are dropped
+CREATE TABLE IF NOT EXISTS skipped_functions (
+    pkg_path TEXT NOT NULL,
+    name     TEXT NOT NULL,
+    file     TEXT NOT NULL,
+    line     INTEGER NOT NULL,
+    calls    INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (pkg_path, name)
+);
+
+-- Manual edges: user-asserted calls (e.g. reflective dispatch), keyed by
+-- symbol identity so they survive re-indexing; applied to call_edges with
+-- resolved_by = 'manual'
+CREATE TABLE IF NOT EXISTS manual_edges (
+    caller_pkg  TEXT NOT NULL,
+    caller_name TEXT NOT NULL,
+    caller_recv TEXT NOT NULL DEFAULT '',
+    callee_pkg  TEXT NOT NULL,
+    callee_name TEXT NOT NULL,
+    callee_recv TEXT NOT NULL DEFAULT '',
+    call_kind   TEXT NOT NULL DEFAULT 'static',
+    note        TEXT NOT NULL DEFAULT '',
+    created_at  TEXT NOT NULL,
+    PRIMARY KEY (caller_pkg, caller_name, caller_recv, callee_pkg, callee_name, callee_recv)
+);
+
+-- Bookmarks: pinned symbols and starred entrypoints, keyed by identity so
+-- they survive re-indexing
+CREATE TABLE IF NOT EXISTS pinned_symbols (
+    key        TEXT PRIMARY KEY, -- e.g. "myapp/svc.(*UserService).GetUser"
+    pkg_path   TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    recv_type  TEXT NOT NULL DEFAULT '',
+    note       TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS starred_entrypoints (
+    key        TEXT PRIMARY KEY, -- "type label", e.g. "http GET /api/users"
+    type       TEXT NOT NULL,
+    label      TEXT NOT NULL,
+    note       TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL
+);
+
+-- Saved views: named exploration states stored for the UI
+CREATE TABLE IF NOT EXISTS views (
+    name       TEXT PRIMARY KEY,
+    state_json TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+-- Shares: view states stored under short IDs for shareable links
+CREATE TABLE IF NOT EXISTS shares (
+    id         TEXT PRIMARY KEY,
+    state_json TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+
 -- Metadata table for index info
 CREATE TABLE IF NOT EXISTS metadata (
     key   TEXT PRIMARY KEY,
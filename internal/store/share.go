@@ -0,0 +1,80 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// shareIDBytes is the number of state hash bytes in a share ID (8 base64
+// characters); IDs are lengthened in the unlikely case of a collision.
+const shareIDBytes = 6
+
+// Share is a view state stored under a short ID for sharing as a link.
+type Share struct {
+	ID        string          `json:"id"`
+	State     json.RawMessage `json:"state"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// SaveShare stores a view state and returns its share ID. IDs are derived
+// from the state, so sharing the same state twice yields the same link.
+func (s *Store) SaveShare(ctx context.Context, state json.RawMessage) (*Share, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, state); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(compact.Bytes())
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	for n := shareIDBytes; n <= len(sum); n += 3 {
+		share := &Share{
+			ID:        base64.RawURLEncoding.EncodeToString(sum[:n]),
+			State:     compact.Bytes(),
+			CreatedAt: time.Now().Format(time.RFC3339),
+		}
+
+		var existing, createdAt string
+		err := s.db.QueryRowContext(ctx, "SELECT state_json, created_at FROM shares WHERE id = ?", share.ID).Scan(&existing, &createdAt)
+		switch {
+		case err == sql.ErrNoRows:
+			_, err := s.db.ExecContext(ctx, `
+				INSERT INTO shares (id, state_json, created_at) VALUES (?, ?, ?)
+			`, share.ID, compact.String(), share.CreatedAt)
+			if err != nil {
+				return nil, err
+			}
+			return share, nil
+		case err != nil:
+			return nil, err
+		case existing == compact.String():
+			share.CreatedAt = createdAt
+			return share, nil
+		}
+		// Another state has this ID; try a longer one
+	}
+	return nil, fmt.Errorf("no free share ID for state")
+}
+
+// GetShare returns the view state stored under a share ID, or
+// sql.ErrNoRows if there is none.
+func (s *Store) GetShare(ctx context.Context, id string) (*Share, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	share := &Share{ID: id}
+	var state string
+	err := s.readDB.QueryRowContext(ctx, "SELECT state_json, created_at FROM shares WHERE id = ?", id).Scan(&state, &share.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	share.State = json.RawMessage(state)
+	return share, nil
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Signature is the structured form of a function signature. Types are
+// written with full package paths, as in Symbol.Sig.
+type Signature struct {
+	Params   []Param `json:"params"`
+	Results  []Param `json:"results"`
+	Variadic bool    `json:"variadic,omitempty"` // The last parameter is ...T (its type is []T)
+}
+
+// Param is a parameter or result of a Signature.
+type Param struct {
+	Name string `json:"name,omitempty"` // Empty for unnamed parameters and results
+	Type string `json:"type"`
+}
+
+// encodeSignature returns the sig_json value for sig, NULL when sig is nil.
+func encodeSignature(sig *Signature) (sql.NullString, error) {
+	if sig == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(sig)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// decodeSignature parses a sig_json value, returning nil for NULL or
+// malformed values.
+func decodeSignature(data sql.NullString) *Signature {
+	if !data.Valid {
+		return nil
+	}
+	var sig Signature
+	if err := json.Unmarshal([]byte(data.String), &sig); err != nil {
+		return nil
+	}
+	return &sig
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"sort"
+)
+
+// SimilarEntrypoints is a pair of entrypoints whose call trees overlap.
+type SimilarEntrypoints struct {
+	A          EntrypointRef `json:"a"`
+	B          EntrypointRef `json:"b"`
+	ReachA     int           `json:"reach_a"` // Symbols reachable from A, excluding its handler
+	ReachB     int           `json:"reach_b"`
+	Shared     int           `json:"shared"`     // Symbols reachable from both
+	Similarity float64       `json:"similarity"` // Shared over the union of both sets (Jaccard index)
+}
+
+// GetSimilarEntrypoints compares the symbols reachable from each pair of
+// entrypoints, not counting their own handlers, and returns the pairs whose
+// Jaccard similarity is at least minSimilarity, most similar first.
+// Entrypoints reaching fewer than minReach symbols are skipped, as are
+// pairs sharing one handler.
+func (s *Store) GetSimilarEntrypoints(ctx context.Context, minSimilarity float64, minReach int) ([]SimilarEntrypoints, error) {
+	eps, err := s.GetEntrypoints(ctx, EntrypointFilter{})
+	if err != nil {
+		return nil, err
+	}
+	callees, err := s.getCalleeAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// reachers inverts the reachable sets: symbol -> indexes into eps
+	reach := make([]int, len(eps))
+	reachers := make(map[SymbolID][]int)
+	for i, ep := range eps {
+		seen := map[SymbolID]bool{ep.SymbolID: true}
+		queue := []SymbolID{ep.SymbolID}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			for _, next := range callees[id] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		delete(seen, ep.SymbolID)
+		if len(seen) < max(minReach, 1) {
+			continue
+		}
+		reach[i] = len(seen)
+		for id := range seen {
+			reachers[id] = append(reachers[id], i)
+		}
+	}
+
+	type pair struct{ a, b int }
+	shared := make(map[pair]int)
+	for _, list := range reachers {
+		for x := range list {
+			for y := x + 1; y < len(list); y++ {
+				shared[pair{list[x], list[y]}]++
+			}
+		}
+	}
+
+	var results []SimilarEntrypoints
+	for p, n := range shared {
+		a, b := eps[p.a], eps[p.b]
+		if a.SymbolID == b.SymbolID {
+			continue
+		}
+		similarity := float64(n) / float64(reach[p.a]+reach[p.b]-n)
+		if similarity < minSimilarity {
+			continue
+		}
+		results = append(results, SimilarEntrypoints{
+			A:          EntrypointRef{ID: a.ID, Label: a.Label, Type: a.Type},
+			B:          EntrypointRef{ID: b.ID, Label: b.Label, Type: b.Type},
+			ReachA:     reach[p.a],
+			ReachB:     reach[p.b],
+			Shared:     n,
+			Similarity: similarity,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Similarity != results[j].Similarity {
+			return results[i].Similarity > results[j].Similarity
+		}
+		if results[i].Shared != results[j].Shared {
+			return results[i].Shared > results[j].Shared
+		}
+		if results[i].A.ID != results[j].A.ID {
+			return results[i].A.ID < results[j].A.ID
+		}
+		return results[i].B.ID < results[j].B.ID
+	})
+	return results, nil
+}
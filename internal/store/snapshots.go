@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotTimeFormat names retained snapshots by the UTC time their index
+// was built, so they sort chronologically.
+const snapshotTimeFormat = "20060102T150405Z"
+
+// RetainedSnapshot is a copy of a past index kept for time-travel queries.
+type RetainedSnapshot struct {
+	Name      string    `json:"name"` // e.g. "20260314T091500Z"
+	IndexedAt time.Time `json:"indexed_at"`
+	Size      int64     `json:"size"`
+	Path      string    `json:"-"`
+}
+
+// SnapshotDir returns the directory holding the retained snapshots of the
+// index at dbPath.
+func SnapshotDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "snapshots")
+}
+
+// RetainSnapshot copies the current index into the snapshot directory,
+// named by its indexed_at time, and removes all but the newest retain
+// snapshots.
+func (s *Store) RetainSnapshot(ctx context.Context, retain int) (*RetainedSnapshot, error) {
+	indexedAt, err := s.GetMetadata(ctx, "indexed_at")
+	if err != nil {
+		return nil, fmt.Errorf("reading metadata: %w", err)
+	}
+	at, err := time.Parse(time.RFC3339Nano, indexedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parsing indexed_at %q: %w", indexedAt, err)
+	}
+
+	dir := SnapshotDir(s.dbPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", dir, err)
+	}
+	name := at.UTC().Format(snapshotTimeFormat)
+	path := filepath.Join(dir, name+".db")
+	if err := RemoveFiles(path); err != nil {
+		return nil, err
+	}
+	if err := s.CopyTo(ctx, path); err != nil {
+		return nil, err
+	}
+	if err := s.rebaseSnapshot(ctx, path); err != nil {
+		RemoveFiles(path)
+		return nil, fmt.Errorf("rebasing snapshot: %w", err)
+	}
+
+	snapshots, err := ListSnapshots(s.dbPath)
+	if err != nil {
+		return nil, err
+	}
+	for len(snapshots) > retain {
+		if err := RemoveFiles(snapshots[0].Path); err != nil {
+			return nil, fmt.Errorf("removing snapshot %s: %w", snapshots[0].Name, err)
+		}
+		snapshots = snapshots[1:]
+	}
+	for i := range snapshots {
+		if snapshots[i].Name == name {
+			return &snapshots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("snapshot %s was not retained", name)
+}
+
+// rebaseSnapshot points the repository directories recorded in the
+// snapshot at path, one directory below the index, back at the
+// repositories.
+func (s *Store) rebaseSnapshot(ctx context.Context, path string) error {
+	dbDir, err := filepath.Abs(filepath.Dir(s.dbPath))
+	if err != nil {
+		return err
+	}
+	snap, err := OpenFile(path, s.baseDir)
+	if err != nil {
+		return err
+	}
+	if err := snap.rebaseRepoDirs(ctx, dbDir); err != nil {
+		snap.Close()
+		return err
+	}
+	return snap.Close()
+}
+
+// ListSnapshots returns the retained snapshots of the index at dbPath,
+// oldest first.
+func ListSnapshots(dbPath string) ([]RetainedSnapshot, error) {
+	entries, err := os.ReadDir(SnapshotDir(dbPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshots: %w", err)
+	}
+
+	var snapshots []RetainedSnapshot
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".db")
+		if !ok || e.IsDir() {
+			continue
+		}
+		at, err := time.Parse(snapshotTimeFormat, name)
+		if err != nil {
+			continue // Not a snapshot
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // Removed since the directory was read
+		}
+		snapshots = append(snapshots, RetainedSnapshot{
+			Name:      name,
+			IndexedAt: at,
+			Size:      info.Size(),
+			Path:      filepath.Join(SnapshotDir(dbPath), e.Name()),
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].IndexedAt.Before(snapshots[j].IndexedAt) })
+	return snapshots, nil
+}
+
+// FindSnapshot returns the retained snapshot named asOf or, given an
+// RFC 3339 time or date, the newest snapshot indexed at or before it.
+func FindSnapshot(dbPath, asOf string) (*RetainedSnapshot, error) {
+	snapshots, err := ListSnapshots(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshots {
+		if snapshots[i].Name == asOf {
+			return &snapshots[i], nil
+		}
+	}
+
+	at, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		if at, err = time.Parse(time.DateOnly, asOf); err != nil {
+			return nil, fmt.Errorf("no snapshot %q", asOf)
+		}
+		at = at.Add(24*time.Hour - time.Second) // End of the day
+	}
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if !snapshots[i].IndexedAt.After(at) {
+			return &snapshots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no snapshot at or before %s", asOf)
+}
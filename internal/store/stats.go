@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// PackageStats is the number of symbols and outgoing call edges of a package.
+type PackageStats struct {
+	PkgPath     string `json:"pkg_path"`
+	Layer       string `json:"layer,omitempty"`
+	SymbolCount int    `json:"symbol_count"`
+	EdgeCount   int    `json:"edge_count"` // Call edges whose caller is in the package
+}
+
+// TagCount is the number of symbols carrying a tag.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// FanIn is a symbol and the number of distinct functions calling it.
+type FanIn struct {
+	Symbol  Symbol `json:"symbol"`
+	Callers int    `json:"callers"`
+}
+
+// GetPackageStats returns the symbol and call edge counts of every package,
+// ordered by package path.
+func (s *Store) GetPackageStats(ctx context.Context) ([]PackageStats, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT p.pkg_path, COALESCE(p.layer, ''),
+		       (SELECT COUNT(*) FROM symbols s WHERE s.pkg_path = p.pkg_path),
+		       (SELECT COUNT(*) FROM call_edges ce
+		        JOIN symbols s ON ce.caller_id = s.id
+		        WHERE s.pkg_path = p.pkg_path)
+		FROM packages p
+		ORDER BY p.pkg_path
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying package stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []PackageStats
+	for rows.Next() {
+		var p PackageStats
+		if err := rows.Scan(&p.PkgPath, &p.Layer, &p.SymbolCount, &p.EdgeCount); err != nil {
+			return nil, err
+		}
+		stats = append(stats, p)
+	}
+	return stats, rows.Err()
+}
+
+// GetTagCounts returns how many symbols carry each tag, most used first.
+func (s *Store) GetTagCounts(ctx context.Context) ([]TagCount, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT tag, COUNT(*) AS n
+		FROM tags
+		GROUP BY tag
+		ORDER BY n DESC, tag
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying tag counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []TagCount
+	for rows.Next() {
+		var c TagCount
+		if err := rows.Scan(&c.Tag, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// TaggedSymbol is a symbol carrying a tag, with the reason it was applied.
+type TaggedSymbol struct {
+	Symbol Symbol `json:"symbol"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// GetTaggedSymbols returns a page of the symbols carrying tag, ordered by
+// package and name, and how many carry it in all.
+func (s *Store) GetTaggedSymbols(ctx context.Context, tag string, limit, offset int) ([]TaggedSymbol, int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var total int
+	if err := s.readDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM tags WHERE tag = ?", tag).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting tagged symbols: %w", err)
+	}
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT s.id, s.pkg_path, s.name, s.kind, COALESCE(s.recv_type, ''),
+		       s.file, s.line, COALESCE(s.sig, ''), s.repo, COALESCE(t.reason, '')
+		FROM tags t
+		JOIN symbols s ON t.symbol_id = s.id
+		WHERE t.tag = ?
+		ORDER BY s.pkg_path, s.name, s.recv_type
+		LIMIT ? OFFSET ?
+	`, tag, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("querying tagged symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var results []TaggedSymbol
+	for rows.Next() {
+		var t TaggedSymbol
+		err := rows.Scan(
+			&t.Symbol.ID, &t.Symbol.PkgPath, &t.Symbol.Name, &t.Symbol.Kind, &t.Symbol.RecvType,
+			&t.Symbol.File, &t.Symbol.Line, &t.Symbol.Sig, &t.Symbol.Repo, &t.Reason,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		t.Symbol.File = s.absPath(ctx, t.Symbol.Repo, t.Symbol.File)
+		results = append(results, t)
+	}
+	return results, total, rows.Err()
+}
+
+// GetTopFanIn returns the limit symbols called from the most distinct
+// functions, most called first.
+func (s *Store) GetTopFanIn(ctx context.Context, limit int) ([]FanIn, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT s.id, s.pkg_path, s.name, s.kind, COALESCE(s.recv_type, ''),
+		       s.file, s.line, COALESCE(s.sig, ''), s.repo, f.callers
+		FROM (
+			SELECT callee_id, COUNT(DISTINCT caller_id) AS callers
+			FROM call_edges
+			GROUP BY callee_id
+		) f
+		JOIN symbols s ON f.callee_id = s.id
+		ORDER BY f.callers DESC, s.pkg_path, s.name
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying fan-in: %w", err)
+	}
+	defer rows.Close()
+
+	var results []FanIn
+	for rows.Next() {
+		var f FanIn
+		err := rows.Scan(
+			&f.Symbol.ID, &f.Symbol.PkgPath, &f.Symbol.Name, &f.Symbol.Kind, &f.Symbol.RecvType,
+			&f.Symbol.File, &f.Symbol.Line, &f.Symbol.Sig, &f.Symbol.Repo, &f.Callers,
+		)
+		if err != nil {
+			return nil, err
+		}
+		f.Symbol.File = s.absPath(ctx, f.Symbol.Repo, f.Symbol.File)
+		results = append(results, f)
+	}
+	return results, rows.Err()
+}
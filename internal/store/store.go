@@ -1,67 +1,228 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// busyTimeoutMs is how long a connection waits on a locked database
+// before failing with SQLITE_BUSY.
+const busyTimeoutMs = 5000
+
+// querier is the subset of *sql.DB and *sql.Tx the store's queries use.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 // Store handles persistence of indexed data to SQLite.
+// Writes go through a single connection so they are serialized; reads use a
+// separate read-only pool so the UI stays responsive while indexing runs.
 type Store struct {
-	db      *sql.DB
-	dbPath  string
-	baseDir string // Project root directory
+	pools        *livePools // Connections to the database file, reopened when it is replaced
+	readOnly     bool       // Opened with OpenReadOnly
+	db           querier    // Writes: the write connection, or the run transaction
+	readDB       querier    // Reads: the read pool, or the run transaction
+	run          *sql.Tx    // Transaction of a store returned by BeginRun
+	dbPath       string
+	baseDir      string        // Project root directory; stored paths are relative to it
+	queryTimeout time.Duration // Per-query timeout (0 = none)
+	repoDirs     *sync.Map     // Repository name -> root directory, for resolving stored paths
+	key          []byte        // Key the index is encrypted with on Close, if opened with OpenEncrypted
 }
 
 // Open creates or opens a FlowLens index database.
 // By default, stores at .flowlens/index.db relative to the given project directory.
 func Open(projectDir string) (*Store, error) {
-	flowlensDir := filepath.Join(projectDir, ".flowlens")
-	if err := os.MkdirAll(flowlensDir, 0755); err != nil {
-		return nil, fmt.Errorf("creating .flowlens directory: %w", err)
+	return OpenFile(filepath.Join(projectDir, ".flowlens", "index.db"), projectDir)
+}
+
+// OpenFile creates or opens the index database at dbPath, e.g. one shared
+// by several repositories, for the project in projectDir.
+func OpenFile(dbPath, projectDir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("creating index directory: %w", err)
 	}
 
-	dbPath := filepath.Join(flowlensDir, "index.db")
-	db, err := sql.Open("sqlite", dbPath)
+	p, err := openPools(dbPath, false)
 	if err != nil {
-		return nil, fmt.Errorf("opening database: %w", err)
+		return nil, err
 	}
 
-	// Enable foreign keys and WAL mode for better performance
-	pragmas := []string{
-		"PRAGMA foreign_keys = ON",
-		"PRAGMA journal_mode = WAL",
-		"PRAGMA synchronous = NORMAL",
-		"PRAGMA cache_size = -64000", // 64MB cache
-	}
-	for _, pragma := range pragmas {
-		if _, err := db.Exec(pragma); err != nil {
-			db.Close()
-			return nil, fmt.Errorf("setting pragma: %w", err)
+	if projectDir != "" {
+		if abs, err := filepath.Abs(projectDir); err == nil {
+			projectDir = abs
 		}
 	}
 
+	live := &livePools{p: p}
+	return &Store{
+		pools:    live,
+		db:       writeQuerier{live},
+		readDB:   readQuerier{live},
+		dbPath:   dbPath,
+		baseDir:  projectDir,
+		repoDirs: &sync.Map{},
+	}, nil
+}
+
+// openPools opens the write connection and read pool on dbPath, creating or
+// migrating the schema. Read-only pools share one read-only pool for both.
+func openPools(dbPath string, readOnly bool) (*pools, error) {
+	if readOnly {
+		return openReadOnlyPools(dbPath)
+	}
+
+	// Pragmas in the DSN apply to every connection the pool opens
+	common := fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)&_pragma=synchronous(NORMAL)&_pragma=cache_size(-64000)", busyTimeoutMs)
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?"+common+"&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	// WAL lets readers proceed while a write transaction is open
+	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("setting pragma: %w", err)
+	}
+
 	// Create schema
 	if _, err := db.Exec(schema); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("creating schema: %w", err)
 	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating schema: %w", err)
+	}
+
+	readDB, err := sql.Open("sqlite", "file:"+dbPath+"?"+common+"&_pragma=query_only(1)")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening read connection: %w", err)
+	}
+
+	file, err := os.Stat(dbPath)
+	if err != nil {
+		readDB.Close()
+		db.Close()
+		return nil, fmt.Errorf("stat %s: %w", dbPath, err)
+	}
+	return &pools{write: db, read: readDB, file: file}, nil
+}
+
+// Close closes the database connections.
+// An index opened with OpenEncrypted is encrypted again once no other
+// connection has it open.
+func (s *Store) Close() error {
+	if err := s.pools.get().close(); err != nil {
+		return err
+	}
+	if s.key != nil {
+		return sealIndex(s.dbPath, s.key)
+	}
+	return nil
+}
 
+// BeginRun starts a transaction spanning a whole indexing run. The returned
+// Store reads and writes through it, so the run sees its own writes while
+// other readers keep seeing the previous index until Commit. Batches begun on
+// it join the run's transaction: their Commit and Rollback do nothing, and a
+// failed run is undone as a whole with Rollback.
+//
+// The write connection is held until Commit or Rollback, so writes through s
+// block meanwhile. The run store must not be closed.
+func (s *Store) BeginRun(ctx context.Context) (*Store, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+	if s.run != nil {
+		return nil, fmt.Errorf("run already in progress")
+	}
+	tx, err := s.pools.get().write.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
 	return &Store{
-		db:      db,
-		dbPath:  dbPath,
-		baseDir: projectDir,
+		pools:        s.pools,
+		db:           tx,
+		readDB:       tx,
+		run:          tx,
+		dbPath:       s.dbPath,
+		baseDir:      s.baseDir,
+		queryTimeout: s.queryTimeout,
+		repoDirs:     s.repoDirs,
 	}, nil
 }
 
-// Close closes the database connection.
-func (s *Store) Close() error {
-	return s.db.Close()
+// Commit commits the transaction of a store returned by BeginRun.
+func (s *Store) Commit() error {
+	if s.run == nil {
+		return fmt.Errorf("no run in progress")
+	}
+	return s.run.Commit()
+}
+
+// Rollback discards everything written through a store returned by
+// BeginRun. It is a no-op after Commit.
+func (s *Store) Rollback() error {
+	if s.run == nil {
+		return fmt.Errorf("no run in progress")
+	}
+	if err := s.run.Rollback(); err != nil && err != sql.ErrTxDone {
+		return err
+	}
+	return nil
+}
+
+// SetQueryTimeout bounds every Store query by d, in addition to any deadline
+// on the caller's context, so a locked database can't hang callers forever.
+// Zero disables the timeout. Batch statements are bounded only by the context
+// passed to BeginBatch.
+func (s *Store) SetQueryTimeout(d time.Duration) {
+	s.queryTimeout = d
+}
+
+// withTimeout derives a context bounded by the store's query timeout.
+func (s *Store) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+// migrate adds columns missing from tables created by older versions.
+func migrate(db *sql.DB) error {
+	for _, m := range migrations {
+		var exists bool
+		err := db.QueryRow(
+			"SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?", m.table, m.column,
+		).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("inspecting %s: %w", m.table, err)
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("adding %s.%s: %w", m.table, m.column, err)
+		}
+	}
+	return nil
 }
 
 // DBPath returns the path to the database file.
@@ -69,11 +230,31 @@ func (s *Store) DBPath() string {
 	return s.dbPath
 }
 
+// Size returns the on-disk size of the database in bytes, including the
+// write-ahead log.
+func (s *Store) Size() (int64, error) {
+	var total int64
+	for _, path := range []string{s.dbPath, s.dbPath + "-wal"} {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("stat %s: %w", path, err)
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
 // Clear removes all data from the database (for re-indexing).
-func (s *Store) Clear() error {
-	tables := []string{"tags", "entrypoints", "call_edges", "symbols", "packages", "metadata"}
+func (s *Store) Clear(ctx context.Context) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tables := []string{"diagnostics", "unresolved_calls", "skipped_functions", "external_calls", "global_writes", "error_sites", "flag_uses", "auth_checks", "panic_checks", "taint_findings", "grpc_methods", "tags", "entrypoints", "implementations", "interface_methods", "type_relations", "symbol_refs", "call_pairs", "call_edges", "symbols", "packages", "changes", "metadata"}
 	for _, table := range tables {
-		if _, err := s.db.Exec("DELETE FROM " + table); err != nil {
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM "+table); err != nil {
 			return fmt.Errorf("clearing table %s: %w", table, err)
 		}
 	}
@@ -81,29 +262,49 @@ func (s *Store) Clear() error {
 }
 
 // InsertPackage inserts or updates a package.
-func (s *Store) InsertPackage(pkg *Package) error {
-	_, err := s.db.Exec(`
-		INSERT INTO packages (pkg_path, module, dir, layer)
-		VALUES (?, ?, ?, ?)
+func (s *Store) InsertPackage(ctx context.Context, pkg *Package) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO packages (pkg_path, module, dir, layer, layer_source, repo)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?)
 		ON CONFLICT(pkg_path) DO UPDATE SET
 			module = excluded.module,
 			dir = excluded.dir,
-			layer = excluded.layer
-	`, pkg.PkgPath, pkg.Module, pkg.Dir, pkg.Layer)
+			layer = excluded.layer,
+			layer_source = excluded.layer_source,
+			repo = excluded.repo
+	`, pkg.PkgPath, pkg.Module, relPath(s.baseDir, pkg.Dir), pkg.Layer, pkg.LayerSource, pkg.Repo)
 	return err
 }
 
 // InsertSymbol inserts a symbol and returns its ID.
-func (s *Store) InsertSymbol(sym *Symbol) (SymbolID, error) {
-	result, err := s.db.Exec(`
-		INSERT INTO symbols (pkg_path, name, kind, recv_type, file, line, sig)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+func (s *Store) InsertSymbol(ctx context.Context, sym *Symbol) (SymbolID, error) {
+	sigJSON, err := encodeSignature(sym.Signature)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO symbols (pkg_path, name, kind, recv_type, file, line, sig, repo, doc, sig_json, value, col, end_line, end_col)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(pkg_path, name, recv_type) DO UPDATE SET
 			kind = excluded.kind,
 			file = excluded.file,
 			line = excluded.line,
-			sig = excluded.sig
-	`, sym.PkgPath, sym.Name, sym.Kind, sym.RecvType, sym.File, sym.Line, sym.Sig)
+			sig = excluded.sig,
+			repo = excluded.repo,
+			doc = excluded.doc,
+			sig_json = excluded.sig_json,
+			value = excluded.value,
+			col = excluded.col,
+			end_line = excluded.end_line,
+			end_col = excluded.end_col
+	`, sym.PkgPath, sym.Name, sym.Kind, sym.RecvType, relPath(s.baseDir, sym.File), sym.Line, sym.Sig, sym.Repo, sym.Doc, sigJSON, sym.Value, sym.Column, sym.EndLine, sym.EndColumn)
 	if err != nil {
 		return 0, err
 	}
@@ -111,15 +312,18 @@ func (s *Store) InsertSymbol(sym *Symbol) (SymbolID, error) {
 	id, err := result.LastInsertId()
 	if err != nil {
 		// If LastInsertId fails (e.g., on conflict update), look it up
-		return s.GetSymbolID(sym.PkgPath, sym.Name, sym.RecvType)
+		return s.GetSymbolID(ctx, sym.PkgPath, sym.Name, sym.RecvType)
 	}
 	return SymbolID(id), nil
 }
 
 // GetSymbolID looks up a symbol's ID by its unique key.
-func (s *Store) GetSymbolID(pkgPath, name, recvType string) (SymbolID, error) {
+func (s *Store) GetSymbolID(ctx context.Context, pkgPath, name, recvType string) (SymbolID, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	var id int64
-	err := s.db.QueryRow(`
+	err := s.readDB.QueryRowContext(ctx, `
 		SELECT id FROM symbols
 		WHERE pkg_path = ? AND name = ? AND (recv_type = ? OR (recv_type IS NULL AND ? = ''))
 	`, pkgPath, name, recvType, recvType).Scan(&id)
@@ -130,23 +334,29 @@ func (s *Store) GetSymbolID(pkgPath, name, recvType string) (SymbolID, error) {
 }
 
 // InsertCallEdge inserts a call edge.
-func (s *Store) InsertCallEdge(edge *CallEdge) error {
-	_, err := s.db.Exec(`
-		INSERT INTO call_edges (caller_id, callee_id, caller_file, caller_line, call_kind, count)
-		VALUES (?, ?, ?, ?, ?, ?)
+func (s *Store) InsertCallEdge(ctx context.Context, edge *CallEdge) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO call_edges (caller_id, callee_id, caller_file, caller_line, call_kind, count, resolved_by, expr)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(caller_id, callee_id, caller_file, caller_line) DO UPDATE SET
 			count = call_edges.count + excluded.count
-	`, edge.CallerID, edge.CalleeID, edge.CallerFile, edge.CallerLine, edge.CallKind, edge.Count)
+	`, edge.CallerID, edge.CalleeID, relPath(s.baseDir, edge.CallerFile), edge.CallerLine, edge.CallKind, edge.Count, edge.resolvedBy(), edge.Expr)
 	return err
 }
 
 // InsertEntrypoint inserts an entrypoint and returns its ID.
-func (s *Store) InsertEntrypoint(ep *Entrypoint) (EntrypointID, error) {
+func (s *Store) InsertEntrypoint(ctx context.Context, ep *Entrypoint) (EntrypointID, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	discoveryMethod := ep.DiscoveryMethod
 	if discoveryMethod == "" {
 		discoveryMethod = "router"
 	}
-	result, err := s.db.Exec(`
+	result, err := s.db.ExecContext(ctx, `
 		INSERT INTO entrypoints (type, label, symbol_id, meta_json, discovery_method)
 		VALUES (?, ?, ?, ?, ?)
 	`, ep.Type, ep.Label, ep.SymbolID, ep.MetaJSON, discoveryMethod)
@@ -158,8 +368,11 @@ func (s *Store) InsertEntrypoint(ep *Entrypoint) (EntrypointID, error) {
 }
 
 // InsertTag inserts a tag on a symbol.
-func (s *Store) InsertTag(tag *Tag) error {
-	_, err := s.db.Exec(`
+func (s *Store) InsertTag(ctx context.Context, tag *Tag) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `
 		INSERT INTO tags (symbol_id, tag, reason)
 		VALUES (?, ?, ?)
 		ON CONFLICT(symbol_id, tag) DO UPDATE SET
@@ -169,8 +382,11 @@ func (s *Store) InsertTag(tag *Tag) error {
 }
 
 // SetMetadata stores a key-value pair in the metadata table.
-func (s *Store) SetMetadata(key, value string) error {
-	_, err := s.db.Exec(`
+func (s *Store) SetMetadata(ctx context.Context, key, value string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `
 		INSERT INTO metadata (key, value)
 		VALUES (?, ?)
 		ON CONFLICT(key) DO UPDATE SET value = excluded.value
@@ -179,24 +395,72 @@ func (s *Store) SetMetadata(key, value string) error {
 }
 
 // GetMetadata retrieves a value from the metadata table.
-func (s *Store) GetMetadata(key string) (string, error) {
+func (s *Store) GetMetadata(ctx context.Context, key string) (string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	var value string
-	err := s.db.QueryRow("SELECT value FROM metadata WHERE key = ?", key).Scan(&value)
+	err := s.readDB.QueryRowContext(ctx, "SELECT value FROM metadata WHERE key = ?", key).Scan(&value)
 	return value, err
 }
 
 // Stats holds statistics about the indexed data.
 type Stats struct {
-	PackageCount    int       `json:"package_count"`
-	SymbolCount     int       `json:"symbol_count"`
-	CallEdgeCount   int       `json:"call_edge_count"`
-	EntrypointCount int       `json:"entrypoint_count"`
-	TagCount        int       `json:"tag_count"`
-	IndexedAt       time.Time `json:"indexed_at"`
+	PackageCount     int            `json:"package_count"`
+	SymbolCount      int            `json:"symbol_count"`
+	CallEdgeCount    int            `json:"call_edge_count"`
+	EntrypointCount  int            `json:"entrypoint_count"`
+	TagCount         int            `json:"tag_count"`
+	IndexedAt        time.Time      `json:"indexed_at"`
+	UnresolvedCalls  map[string]int `json:"unresolved_calls"`  // Call sites with no edge, by reason
+	SkippedFunctions int            `json:"skipped_functions"` // Functions whose calls were dropped
+	VCS              *VCSInfo       `json:"vcs,omitempty"`     // Revision indexed; nil outside a git work tree
+}
+
+// VCSInfo is the git revision an index was built from.
+type VCSInfo struct {
+	Commit string `json:"commit"`
+	Branch string `json:"branch,omitempty"` // Empty on a detached HEAD
+	Dirty  bool   `json:"dirty"`            // Uncommitted changes were indexed too
+}
+
+// SetVCSInfo records the revision being indexed, or clears it when info is
+// nil.
+func (s *Store) SetVCSInfo(ctx context.Context, info *VCSInfo) error {
+	if info == nil {
+		info = &VCSInfo{}
+	}
+	for key, value := range map[string]string{
+		"git_commit": info.Commit,
+		"git_branch": info.Branch,
+		"git_dirty":  strconv.FormatBool(info.Dirty),
+	} {
+		if err := s.SetMetadata(ctx, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetVCSInfo returns the revision recorded by SetVCSInfo, or nil if none
+// was.
+func (s *Store) GetVCSInfo(ctx context.Context) *VCSInfo {
+	commit, err := s.GetMetadata(ctx, "git_commit")
+	if err != nil || commit == "" {
+		return nil
+	}
+	info := &VCSInfo{Commit: commit}
+	info.Branch, _ = s.GetMetadata(ctx, "git_branch")
+	dirty, _ := s.GetMetadata(ctx, "git_dirty")
+	info.Dirty, _ = strconv.ParseBool(dirty)
+	return info
 }
 
 // GetStats returns statistics about the indexed data.
-func (s *Store) GetStats() (*Stats, error) {
+func (s *Store) GetStats(ctx context.Context) (*Stats, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	stats := &Stats{}
 
 	rows := []struct {
@@ -211,16 +475,23 @@ func (s *Store) GetStats() (*Stats, error) {
 	}
 
 	for _, r := range rows {
-		err := s.db.QueryRow("SELECT COUNT(*) FROM " + r.table).Scan(r.dest)
+		err := s.readDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM " + r.table).Scan(r.dest)
 		if err != nil {
 			return nil, fmt.Errorf("counting %s: %w", r.table, err)
 		}
 	}
 
+	var err error
+	stats.UnresolvedCalls, stats.SkippedFunctions, err = s.getUnresolvedTotals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("counting unresolved calls: %w", err)
+	}
+
 	// Get indexed timestamp from metadata
-	if ts, err := s.GetMetadata("indexed_at"); err == nil {
+	if ts, err := s.GetMetadata(ctx, "indexed_at"); err == nil {
 		stats.IndexedAt, _ = time.Parse(time.RFC3339, ts)
 	}
+	stats.VCS = s.GetVCSInfo(ctx)
 
 	return stats, nil
 }
@@ -234,17 +505,21 @@ type IndexMetadata struct {
 	SymbolCount     int       `json:"symbol_count"`
 	EntrypointCount int       `json:"entrypoint_count"`
 	Packages        []string  `json:"packages"` // List of package paths
+	VCS             *VCSInfo  `json:"vcs,omitempty"`
 }
 
 // WriteIndexJSON writes index.json for quick UI boot.
-func (s *Store) WriteIndexJSON() error {
-	stats, err := s.GetStats()
+func (s *Store) WriteIndexJSON(ctx context.Context) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	stats, err := s.GetStats(ctx)
 	if err != nil {
 		return fmt.Errorf("getting stats: %w", err)
 	}
 
 	// Get list of packages
-	rows, err := s.db.Query("SELECT pkg_path FROM packages ORDER BY pkg_path")
+	rows, err := s.readDB.QueryContext(ctx, "SELECT pkg_path FROM packages ORDER BY pkg_path")
 	if err != nil {
 		return fmt.Errorf("querying packages: %w", err)
 	}
@@ -267,6 +542,7 @@ func (s *Store) WriteIndexJSON() error {
 		SymbolCount:     stats.SymbolCount,
 		EntrypointCount: stats.EntrypointCount,
 		Packages:        packages,
+		VCS:             stats.VCS,
 	}
 
 	data, err := json.MarshalIndent(meta, "", "  ")
@@ -285,63 +561,91 @@ func (s *Store) WriteIndexJSON() error {
 // Tx returns the underlying database for advanced queries.
 // Use with caution - prefer adding methods to Store instead.
 func (s *Store) Tx() *sql.DB {
-	return s.db
+	return s.pools.get().write
 }
 
 // BeginBatch starts a transaction for batch inserts.
-// Call Commit() when done, or Rollback() on error.
-func (s *Store) BeginBatch() (*BatchTx, error) {
-	tx, err := s.db.Begin()
+// Call Commit() when done, or Rollback() on error. The transaction is
+// rolled back if ctx is canceled before Commit. On a store returned by
+// BeginRun the batch is part of the run's transaction.
+func (s *Store) BeginBatch(ctx context.Context) (*BatchTx, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+	if s.run != nil {
+		return &BatchTx{tx: s.run, baseDir: s.baseDir, inRun: true}, nil
+	}
+	tx, err := s.pools.get().write.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &BatchTx{tx: tx}, nil
+	return &BatchTx{tx: tx, baseDir: s.baseDir}, nil
 }
 
 // BatchTx wraps a transaction for batch operations.
 type BatchTx struct {
-	tx *sql.Tx
+	tx      *sql.Tx
+	baseDir string // Project root that stored paths are relative to
+	inRun   bool   // Part of a run's transaction, committed with the run
 }
 
 // Commit commits the batch transaction.
 func (b *BatchTx) Commit() error {
+	if b.inRun {
+		return nil
+	}
 	return b.tx.Commit()
 }
 
 // Rollback rolls back the batch transaction.
 func (b *BatchTx) Rollback() error {
+	if b.inRun {
+		return nil
+	}
 	return b.tx.Rollback()
 }
 
 // InsertPackage inserts a package within the batch.
-func (b *BatchTx) InsertPackage(pkg *Package) error {
-	_, err := b.tx.Exec(`
-		INSERT INTO packages (pkg_path, module, dir, layer)
-		VALUES (?, ?, ?, ?)
+func (b *BatchTx) InsertPackage(ctx context.Context, pkg *Package) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT INTO packages (pkg_path, module, dir, layer, layer_source, repo)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?)
 		ON CONFLICT(pkg_path) DO UPDATE SET
 			module = excluded.module,
 			dir = excluded.dir,
-			layer = excluded.layer
-	`, pkg.PkgPath, pkg.Module, pkg.Dir, pkg.Layer)
+			layer = excluded.layer,
+			layer_source = excluded.layer_source,
+			repo = excluded.repo
+	`, pkg.PkgPath, pkg.Module, relPath(b.baseDir, pkg.Dir), pkg.Layer, pkg.LayerSource, pkg.Repo)
 	return err
 }
 
-// InsertSymbol inserts a symbol within the batch and returns its ID.
-func (b *BatchTx) InsertSymbol(sym *Symbol) (SymbolID, error) {
-	result, err := b.tx.Exec(`
-		INSERT INTO symbols (pkg_path, name, kind, recv_type, file, line, sig)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(pkg_path, name, recv_type) DO UPDATE SET
-			kind = excluded.kind,
-			file = excluded.file,
-			line = excluded.line,
-			sig = excluded.sig
-	`, sym.PkgPath, sym.Name, sym.Kind, sym.RecvType, sym.File, sym.Line, sym.Sig)
+// InsertSymbol inserts a symbol within the batch and returns its ID. An
+// existing symbol keeps its ID, so call edges into it stay valid.
+func (b *BatchTx) InsertSymbol(ctx context.Context, sym *Symbol) (SymbolID, error) {
+	sigJSON, err := encodeSignature(sym.Signature)
 	if err != nil {
 		return 0, err
 	}
 
-	id, err := result.LastInsertId()
+	var id int64
+	err = b.tx.QueryRowContext(ctx, `
+		INSERT INTO symbols (pkg_path, name, kind, recv_type, file, line, sig, repo, doc, sig_json, value, col, end_line, end_col)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(pkg_path, name, recv_type) DO UPDATE SET
+			kind = excluded.kind,
+			file = excluded.file,
+			line = excluded.line,
+			sig = excluded.sig,
+			repo = excluded.repo,
+			doc = excluded.doc,
+			sig_json = excluded.sig_json,
+			value = excluded.value,
+			col = excluded.col,
+			end_line = excluded.end_line,
+			end_col = excluded.end_col
+		RETURNING id
+	`, sym.PkgPath, sym.Name, sym.Kind, sym.RecvType, relPath(b.baseDir, sym.File), sym.Line, sym.Sig, sym.Repo, sym.Doc, sigJSON, sym.Value, sym.Column, sym.EndLine, sym.EndColumn).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
@@ -349,20 +653,20 @@ func (b *BatchTx) InsertSymbol(sym *Symbol) (SymbolID, error) {
 }
 
 // InsertCallEdge inserts a call edge within the batch.
-func (b *BatchTx) InsertCallEdge(edge *CallEdge) error {
-	_, err := b.tx.Exec(`
-		INSERT INTO call_edges (caller_id, callee_id, caller_file, caller_line, call_kind, count)
-		VALUES (?, ?, ?, ?, ?, ?)
+func (b *BatchTx) InsertCallEdge(ctx context.Context, edge *CallEdge) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT INTO call_edges (caller_id, callee_id, caller_file, caller_line, call_kind, count, resolved_by, expr)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(caller_id, callee_id, caller_file, caller_line) DO UPDATE SET
 			count = call_edges.count + excluded.count
-	`, edge.CallerID, edge.CalleeID, edge.CallerFile, edge.CallerLine, edge.CallKind, edge.Count)
+	`, edge.CallerID, edge.CalleeID, relPath(b.baseDir, edge.CallerFile), edge.CallerLine, edge.CallKind, edge.Count, edge.resolvedBy(), edge.Expr)
 	return err
 }
 
 // GetSymbolID looks up a symbol's ID by its unique key within the batch.
-func (b *BatchTx) GetSymbolID(pkgPath, name, recvType string) (SymbolID, error) {
+func (b *BatchTx) GetSymbolID(ctx context.Context, pkgPath, name, recvType string) (SymbolID, error) {
 	var id int64
-	err := b.tx.QueryRow(`
+	err := b.tx.QueryRowContext(ctx, `
 		SELECT id FROM symbols
 		WHERE pkg_path = ? AND name = ? AND (recv_type = ? OR (recv_type IS NULL AND ? = ''))
 	`, pkgPath, name, recvType, recvType).Scan(&id)
@@ -373,12 +677,12 @@ func (b *BatchTx) GetSymbolID(pkgPath, name, recvType string) (SymbolID, error)
 }
 
 // InsertEntrypoint inserts an entrypoint within the batch and returns its ID.
-func (b *BatchTx) InsertEntrypoint(ep *Entrypoint) error {
+func (b *BatchTx) InsertEntrypoint(ctx context.Context, ep *Entrypoint) error {
 	discoveryMethod := ep.DiscoveryMethod
 	if discoveryMethod == "" {
 		discoveryMethod = "router"
 	}
-	_, err := b.tx.Exec(`
+	_, err := b.tx.ExecContext(ctx, `
 		INSERT INTO entrypoints (type, label, symbol_id, meta_json, discovery_method)
 		VALUES (?, ?, ?, ?, ?)
 	`, ep.Type, ep.Label, ep.SymbolID, ep.MetaJSON, discoveryMethod)
@@ -386,8 +690,8 @@ func (b *BatchTx) InsertEntrypoint(ep *Entrypoint) error {
 }
 
 // GetHTTPEntrypointSymbolIDs returns all symbol IDs that are already HTTP entrypoints.
-func (b *BatchTx) GetHTTPEntrypointSymbolIDs() ([]SymbolID, error) {
-	rows, err := b.tx.Query(`
+func (b *BatchTx) GetHTTPEntrypointSymbolIDs(ctx context.Context) ([]SymbolID, error) {
+	rows, err := b.tx.QueryContext(ctx, `
 		SELECT symbol_id FROM entrypoints WHERE type = ?
 	`, EntrypointHTTP)
 	if err != nil {
@@ -407,8 +711,8 @@ func (b *BatchTx) GetHTTPEntrypointSymbolIDs() ([]SymbolID, error) {
 }
 
 // InsertTag inserts a tag on a symbol within the batch.
-func (b *BatchTx) InsertTag(tag *Tag) error {
-	_, err := b.tx.Exec(`
+func (b *BatchTx) InsertTag(ctx context.Context, tag *Tag) error {
+	_, err := b.tx.ExecContext(ctx, `
 		INSERT INTO tags (symbol_id, tag, reason)
 		VALUES (?, ?, ?)
 		ON CONFLICT(symbol_id, tag) DO UPDATE SET
@@ -427,8 +731,11 @@ type SymbolForTagging struct {
 }
 
 // GetAllSymbolsForTagging returns all symbols with the data needed for tagging.
-func (s *Store) GetAllSymbolsForTagging() ([]SymbolForTagging, error) {
-	rows, err := s.db.Query(`
+func (s *Store) GetAllSymbolsForTagging(ctx context.Context) ([]SymbolForTagging, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
 		SELECT id, pkg_path, name, kind, COALESCE(recv_type, '') as recv_type
 		FROM symbols
 	`)
@@ -456,8 +763,11 @@ type PackageImport struct {
 
 // GetPackageImports returns all package import relationships from call edges.
 // A package is considered to import another if it has any call edges to symbols in that package.
-func (s *Store) GetPackageImports() (map[string][]string, error) {
-	rows, err := s.db.Query(`
+func (s *Store) GetPackageImports(ctx context.Context) (map[string][]string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
 		SELECT DISTINCT s1.pkg_path as caller_pkg, s2.pkg_path as callee_pkg
 		FROM call_edges ce
 		JOIN symbols s1 ON ce.caller_id = s1.id
@@ -480,6 +790,41 @@ func (s *Store) GetPackageImports() (map[string][]string, error) {
 	return imports, rows.Err()
 }
 
+// CallTarget is a callee of a call edge with the package it belongs to.
+type CallTarget struct {
+	CalleeID SymbolID
+	PkgPath  string
+}
+
+// GetCallTargets returns the distinct callees of every caller, with their
+// packages. Used for per-function I/O tagging.
+func (s *Store) GetCallTargets(ctx context.Context) (map[SymbolID][]CallTarget, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT DISTINCT ce.caller_id, ce.callee_id, s.pkg_path
+		FROM call_edges ce
+		JOIN symbols s ON ce.callee_id = s.id
+		ORDER BY ce.caller_id, ce.callee_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	targets := make(map[SymbolID][]CallTarget)
+	for rows.Next() {
+		var callerID SymbolID
+		var t CallTarget
+		if err := rows.Scan(&callerID, &t.CalleeID, &t.PkgPath); err != nil {
+			return nil, err
+		}
+		targets[callerID] = append(targets[callerID], t)
+	}
+	return targets, rows.Err()
+}
+
 // SymbolCallee represents a callee symbol with its tags.
 type SymbolCallee struct {
 	CallerID SymbolID
@@ -489,8 +834,11 @@ type SymbolCallee struct {
 
 // GetSymbolCalleesWithTags returns all caller-callee relationships with callee tags.
 // Used for purity analysis.
-func (s *Store) GetSymbolCalleesWithTags() (map[SymbolID][]SymbolCallee, error) {
-	rows, err := s.db.Query(`
+func (s *Store) GetSymbolCalleesWithTags(ctx context.Context) (map[SymbolID][]SymbolCallee, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
 		SELECT ce.caller_id, ce.callee_id, COALESCE(GROUP_CONCAT(t.tag), '') as tags
 		FROM call_edges ce
 		LEFT JOIN tags t ON ce.callee_id = t.symbol_id
@@ -547,34 +895,44 @@ func splitTags(s string) []string {
 // ============================================================================
 
 // GetSymbolByID retrieves a symbol by its ID with full details.
-func (s *Store) GetSymbolByID(id SymbolID) (*Symbol, error) {
+func (s *Store) GetSymbolByID(ctx context.Context, id SymbolID) (*Symbol, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	sym := &Symbol{}
-	var recvType sql.NullString
-	err := s.db.QueryRow(`
-		SELECT id, pkg_path, name, kind, recv_type, file, line, COALESCE(sig, '') as sig
+	var recvType, sigJSON sql.NullString
+	err := s.readDB.QueryRowContext(ctx, `
+		SELECT id, pkg_path, name, kind, recv_type, file, line, COALESCE(sig, '') as sig, repo, doc, sig_json, value,
+		       col, end_line, end_col
 		FROM symbols WHERE id = ?
-	`, id).Scan(&sym.ID, &sym.PkgPath, &sym.Name, &sym.Kind, &recvType, &sym.File, &sym.Line, &sym.Sig)
+	`, id).Scan(&sym.ID, &sym.PkgPath, &sym.Name, &sym.Kind, &recvType, &sym.File, &sym.Line, &sym.Sig, &sym.Repo, &sym.Doc, &sigJSON, &sym.Value,
+		&sym.Column, &sym.EndLine, &sym.EndColumn)
 	if err != nil {
 		return nil, err
 	}
+	sym.Signature = decodeSignature(sigJSON)
 	if recvType.Valid {
 		sym.RecvType = recvType.String
 	}
+	sym.File = s.absPath(ctx, sym.Repo, sym.File)
 	return sym, nil
 }
 
 // FindSymbolID finds a symbol ID by package path, name, and optional receiver type.
-func (s *Store) FindSymbolID(pkgPath, name, recvType string) (SymbolID, error) {
+func (s *Store) FindSymbolID(ctx context.Context, pkgPath, name, recvType string) (SymbolID, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	var id SymbolID
 	var err error
 
 	if recvType == "" {
-		err = s.db.QueryRow(`
+		err = s.readDB.QueryRowContext(ctx, `
 			SELECT id FROM symbols
 			WHERE pkg_path = ? AND name = ? AND (recv_type IS NULL OR recv_type = '')
 		`, pkgPath, name).Scan(&id)
 	} else {
-		err = s.db.QueryRow(`
+		err = s.readDB.QueryRowContext(ctx, `
 			SELECT id FROM symbols
 			WHERE pkg_path = ? AND name = ? AND recv_type = ?
 		`, pkgPath, name, recvType).Scan(&id)
@@ -587,8 +945,11 @@ func (s *Store) FindSymbolID(pkgPath, name, recvType string) (SymbolID, error) {
 }
 
 // GetSymbolTags retrieves all tags for a symbol.
-func (s *Store) GetSymbolTags(id SymbolID) ([]Tag, error) {
-	rows, err := s.db.Query(`
+func (s *Store) GetSymbolTags(ctx context.Context, id SymbolID) ([]Tag, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
 		SELECT symbol_id, tag, COALESCE(reason, '') as reason
 		FROM tags WHERE symbol_id = ?
 	`, id)
@@ -618,16 +979,21 @@ type EntrypointFilter struct {
 // EntrypointWithSymbol combines entrypoint with its symbol details.
 type EntrypointWithSymbol struct {
 	Entrypoint
-	Symbol Symbol `json:"symbol"`
+	Symbol           Symbol `json:"symbol"`
+	UnrecoveredPanic bool   `json:"unrecovered_panic,omitempty"` // A reachable panic is not recovered (see PanicCheck)
 }
 
 // GetEntrypoints retrieves entrypoints with optional filtering.
-func (s *Store) GetEntrypoints(filter EntrypointFilter) ([]EntrypointWithSymbol, error) {
+func (s *Store) GetEntrypoints(ctx context.Context, filter EntrypointFilter) ([]EntrypointWithSymbol, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT e.id, e.type, e.label, e.symbol_id, COALESCE(e.meta_json, '') as meta_json,
 		       COALESCE(e.discovery_method, 'router') as discovery_method,
 		       s.id, s.pkg_path, s.name, s.kind, COALESCE(s.recv_type, '') as recv_type,
-		       s.file, s.line, COALESCE(s.sig, '') as sig
+		       s.file, s.line, COALESCE(s.sig, '') as sig, s.repo,
+		       EXISTS (SELECT 1 FROM panic_checks p WHERE p.entrypoint_id = e.id AND p.status = 'unrecovered')
 		FROM entrypoints e
 		JOIN symbols s ON e.symbol_id = s.id
 		WHERE 1=1
@@ -650,7 +1016,7 @@ func (s *Store) GetEntrypoints(filter EntrypointFilter) ([]EntrypointWithSymbol,
 		args = append(args, filter.Limit)
 	}
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -662,86 +1028,189 @@ func (s *Store) GetEntrypoints(filter EntrypointFilter) ([]EntrypointWithSymbol,
 		err := rows.Scan(
 			&ep.ID, &ep.Type, &ep.Label, &ep.SymbolID, &ep.MetaJSON, &ep.DiscoveryMethod,
 			&ep.Symbol.ID, &ep.Symbol.PkgPath, &ep.Symbol.Name, &ep.Symbol.Kind,
-			&ep.Symbol.RecvType, &ep.Symbol.File, &ep.Symbol.Line, &ep.Symbol.Sig,
+			&ep.Symbol.RecvType, &ep.Symbol.File, &ep.Symbol.Line, &ep.Symbol.Sig, &ep.Symbol.Repo,
+			&ep.UnrecoveredPanic,
 		)
 		if err != nil {
 			return nil, err
 		}
+		ep.Symbol.File = s.absPath(ctx, ep.Symbol.Repo, ep.Symbol.File)
 		results = append(results, ep)
 	}
 	return results, rows.Err()
 }
 
 // GetEntrypointByID retrieves a single entrypoint with its symbol.
-func (s *Store) GetEntrypointByID(id EntrypointID) (*EntrypointWithSymbol, error) {
+func (s *Store) GetEntrypointByID(ctx context.Context, id EntrypointID) (*EntrypointWithSymbol, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	ep := &EntrypointWithSymbol{}
-	err := s.db.QueryRow(`
+	err := s.readDB.QueryRowContext(ctx, `
 		SELECT e.id, e.type, e.label, e.symbol_id, COALESCE(e.meta_json, '') as meta_json,
 		       COALESCE(e.discovery_method, 'router') as discovery_method,
 		       s.id, s.pkg_path, s.name, s.kind, COALESCE(s.recv_type, '') as recv_type,
-		       s.file, s.line, COALESCE(s.sig, '') as sig
+		       s.file, s.line, COALESCE(s.sig, '') as sig, s.repo,
+		       EXISTS (SELECT 1 FROM panic_checks p WHERE p.entrypoint_id = e.id AND p.status = 'unrecovered')
 		FROM entrypoints e
 		JOIN symbols s ON e.symbol_id = s.id
 		WHERE e.id = ?
 	`, id).Scan(
 		&ep.ID, &ep.Type, &ep.Label, &ep.SymbolID, &ep.MetaJSON, &ep.DiscoveryMethod,
 		&ep.Symbol.ID, &ep.Symbol.PkgPath, &ep.Symbol.Name, &ep.Symbol.Kind,
-		&ep.Symbol.RecvType, &ep.Symbol.File, &ep.Symbol.Line, &ep.Symbol.Sig,
+		&ep.Symbol.RecvType, &ep.Symbol.File, &ep.Symbol.Line, &ep.Symbol.Sig, &ep.Symbol.Repo,
+		&ep.UnrecoveredPanic,
 	)
 	if err != nil {
 		return nil, err
 	}
+	ep.Symbol.File = s.absPath(ctx, ep.Symbol.Repo, ep.Symbol.File)
 	return ep, nil
 }
 
 // SearchResult represents a symbol search result.
 type SearchResult struct {
-	Symbol Symbol `json:"symbol"`
-	Tags   []Tag  `json:"tags,omitempty"`
+	Symbol Symbol  `json:"symbol"`
+	Tags   []Tag   `json:"tags,omitempty"`
+	Score  float64 `json:"score"` // Fuzzy match score, higher is better
+}
+
+// SearchFilter specifies structured criteria for SearchSymbols.
+// All non-empty fields must match.
+type SearchFilter struct {
+	Query       string     // Fuzzy query against name, receiver, and package (empty = all)
+	Tag         string     // Exact tag, e.g. "io:db"
+	Layer       string     // Package layer, e.g. "service"
+	File        string     // Substring of the file path
+	Kind        SymbolKind // Symbol kind
+	SigContains string     // Substring of the signature
+	ParamType   string     // Substring of a parameter type, e.g. "*net/http.Request"
+	ResultType  string     // Substring of a result type, e.g. "error"
+	Repo        string     // Repository name, in a shared multi-repo index
+	Limit       int        // Max results (0 = 50)
+}
+
+// IsEmpty reports whether the filter has no criteria.
+func (f SearchFilter) IsEmpty() bool {
+	return strings.TrimSpace(f.Query) == "" && f.Tag == "" && f.Layer == "" &&
+		f.File == "" && f.Kind == "" && f.SigContains == "" && f.ParamType == "" &&
+		f.ResultType == "" && f.Repo == ""
 }
 
-// SearchSymbols performs a fuzzy search on symbol names.
-func (s *Store) SearchSymbols(query string, limit int) ([]SearchResult, error) {
+// SearchSymbols searches symbols by structured criteria, ranking by fuzzy score
+// when a query is given. Each whitespace-separated query token must match as a
+// subsequence or by trigram similarity, so "usrsvc get" finds (*UserService).GetUser.
+func (s *Store) SearchSymbols(ctx context.Context, filter SearchFilter) ([]SearchResult, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	limit := filter.Limit
 	if limit <= 0 {
 		limit = 50
 	}
+	if filter.IsEmpty() {
+		return nil, nil
+	}
 
-	// Search by name containing the query (case-insensitive)
-	rows, err := s.db.Query(`
-		SELECT id, pkg_path, name, kind, COALESCE(recv_type, '') as recv_type,
-		       file, line, COALESCE(sig, '') as sig
-		FROM symbols
-		WHERE name LIKE ? OR pkg_path LIKE ?
-		ORDER BY
-			CASE WHEN name = ? THEN 0
-			     WHEN name LIKE ? THEN 1
-			     ELSE 2
-			END,
-			name
-		LIMIT ?
-	`, "%"+query+"%", "%"+query+"%", query, query+"%", limit)
+	query := `
+		SELECT s.id, s.pkg_path, s.name, s.kind, COALESCE(s.recv_type, '') as recv_type,
+		       s.file, s.line, COALESCE(s.sig, '') as sig, s.repo, s.sig_json
+		FROM symbols s
+		WHERE 1=1
+	`
+	var args []interface{}
+
+	if filter.Tag != "" {
+		query += " AND EXISTS (SELECT 1 FROM tags t WHERE t.symbol_id = s.id AND t.tag = ?)"
+		args = append(args, filter.Tag)
+	}
+	if filter.Layer != "" {
+		query += " AND EXISTS (SELECT 1 FROM packages p WHERE p.pkg_path = s.pkg_path AND p.layer = ?)"
+		args = append(args, filter.Layer)
+	}
+	if filter.File != "" {
+		query += ` AND REPLACE(s.file, '\', '/') LIKE ?`
+		args = append(args, "%"+relPath(s.baseDir, filter.File)+"%")
+	}
+	if filter.Kind != "" {
+		query += " AND s.kind = ?"
+		args = append(args, filter.Kind)
+	}
+	if filter.SigContains != "" {
+		query += " AND s.sig LIKE ?"
+		args = append(args, "%"+filter.SigContains+"%")
+	}
+	if filter.ParamType != "" {
+		query += " AND EXISTS (SELECT 1 FROM json_each(s.sig_json, '$.params') p WHERE json_extract(p.value, '$.type') LIKE ?)"
+		args = append(args, "%"+filter.ParamType+"%")
+	}
+	if filter.ResultType != "" {
+		query += " AND EXISTS (SELECT 1 FROM json_each(s.sig_json, '$.results') p WHERE json_extract(p.value, '$.type') LIKE ?)"
+		args = append(args, "%"+filter.ResultType+"%")
+	}
+	if filter.Repo != "" {
+		query += " AND s.repo = ?"
+		args = append(args, filter.Repo)
+	}
+	// Skip rows no query token can fuzzy-match before scoring them in Go
+	for _, tok := range strings.Fields(strings.ToLower(filter.Query)) {
+		if clause, tokArgs := fuzzyPrefilter(tok); clause != "" {
+			query += " AND " + clause
+			args = append(args, tokArgs...)
+		}
+	}
+
+	query += " ORDER BY s.pkg_path, s.name, s.id"
+
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	hasQuery := strings.TrimSpace(filter.Query) != ""
 	var results []SearchResult
 	for rows.Next() {
 		var sym Symbol
+		var sigJSON sql.NullString
 		err := rows.Scan(&sym.ID, &sym.PkgPath, &sym.Name, &sym.Kind,
-			&sym.RecvType, &sym.File, &sym.Line, &sym.Sig)
+			&sym.RecvType, &sym.File, &sym.Line, &sym.Sig, &sym.Repo, &sigJSON)
 		if err != nil {
 			return nil, err
 		}
-		results = append(results, SearchResult{Symbol: sym})
+		sym.Signature = decodeSignature(sigJSON)
+		sym.File = s.absPath(ctx, sym.Repo, sym.File)
+		if !hasQuery {
+			results = append(results, SearchResult{Symbol: sym})
+			continue
+		}
+		if score := fuzzyScore(filter.Query, &sym); score > 0 {
+			results = append(results, SearchResult{Symbol: sym, Score: score})
+		}
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
+	// Without a query, rows are already in package/name order
+	if hasQuery {
+		sort.SliceStable(results, func(i, j int) bool {
+			if results[i].Score != results[j].Score {
+				return results[i].Score > results[j].Score
+			}
+			if results[i].Symbol.Name != results[j].Symbol.Name {
+				return results[i].Symbol.Name < results[j].Symbol.Name
+			}
+			return results[i].Symbol.ID < results[j].Symbol.ID
+		})
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
 	// Fetch tags for each result
 	for i := range results {
-		tags, err := s.GetSymbolTags(results[i].Symbol.ID)
+		tags, err := s.GetSymbolTags(ctx, results[i].Symbol.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -753,22 +1222,37 @@ func (s *Store) SearchSymbols(query string, limit int) ([]SearchResult, error) {
 
 // CalleeInfo represents a callee with call site information.
 type CalleeInfo struct {
-	Symbol     Symbol   `json:"symbol"`
-	CallKind   CallKind `json:"call_kind"`
-	CallerFile string   `json:"caller_file"`
-	CallerLine int      `json:"caller_line"`
-	Count      int      `json:"count"`
-	Tags       []Tag    `json:"tags,omitempty"`
+	Symbol     Symbol     `json:"symbol"`
+	CallKind   CallKind   `json:"call_kind"`
+	ResolvedBy ResolvedBy `json:"resolved_by"`
+	CallerFile string     `json:"caller_file"`
+	CallerLine int        `json:"caller_line"`
+	Count      int        `json:"count"`
+	Expr       string     `json:"expr,omitempty"`      // Source of the call expression
+	Callsites  int        `json:"callsites,omitempty"` // Call sites aggregated into this entry (GetCallPairs)
+	Kinds      []CallKind `json:"kinds,omitempty"`     // Distinct call kinds of those sites (GetCallPairs)
+	Sites      []Callsite `json:"sites,omitempty"`     // Every one of those sites, by file and line (GetCallPairs)
+	Tags       []Tag      `json:"tags,omitempty"`
+}
+
+// Callsite is the location of one call.
+type Callsite struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
 }
 
 // GetCallees retrieves all symbols called by the given symbol.
-func (s *Store) GetCallees(callerID SymbolID) ([]CalleeInfo, error) {
-	rows, err := s.db.Query(`
+func (s *Store) GetCallees(ctx context.Context, callerID SymbolID) ([]CalleeInfo, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
 		SELECT s.id, s.pkg_path, s.name, s.kind, COALESCE(s.recv_type, '') as recv_type,
-		       s.file, s.line, COALESCE(s.sig, '') as sig,
-		       ce.call_kind, ce.caller_file, ce.caller_line, ce.count
+		       s.file, s.line, COALESCE(s.sig, '') as sig, s.repo,
+		       ce.call_kind, ce.resolved_by, ce.caller_file, ce.caller_line, ce.count, ce.expr, cs.repo
 		FROM call_edges ce
 		JOIN symbols s ON ce.callee_id = s.id
+		JOIN symbols cs ON ce.caller_id = cs.id
 		WHERE ce.caller_id = ?
 		ORDER BY ce.caller_line
 	`, callerID)
@@ -780,14 +1264,17 @@ func (s *Store) GetCallees(callerID SymbolID) ([]CalleeInfo, error) {
 	var results []CalleeInfo
 	for rows.Next() {
 		var c CalleeInfo
+		var callerRepo string
 		err := rows.Scan(
 			&c.Symbol.ID, &c.Symbol.PkgPath, &c.Symbol.Name, &c.Symbol.Kind,
-			&c.Symbol.RecvType, &c.Symbol.File, &c.Symbol.Line, &c.Symbol.Sig,
-			&c.CallKind, &c.CallerFile, &c.CallerLine, &c.Count,
+			&c.Symbol.RecvType, &c.Symbol.File, &c.Symbol.Line, &c.Symbol.Sig, &c.Symbol.Repo,
+			&c.CallKind, &c.ResolvedBy, &c.CallerFile, &c.CallerLine, &c.Count, &c.Expr, &callerRepo,
 		)
 		if err != nil {
 			return nil, err
 		}
+		c.Symbol.File = s.absPath(ctx, c.Symbol.Repo, c.Symbol.File)
+		c.CallerFile = s.absPath(ctx, callerRepo, c.CallerFile)
 		results = append(results, c)
 	}
 	if err := rows.Err(); err != nil {
@@ -796,7 +1283,7 @@ func (s *Store) GetCallees(callerID SymbolID) ([]CalleeInfo, error) {
 
 	// Fetch tags for each callee
 	for i := range results {
-		tags, err := s.GetSymbolTags(results[i].Symbol.ID)
+		tags, err := s.GetSymbolTags(ctx, results[i].Symbol.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -808,20 +1295,25 @@ func (s *Store) GetCallees(callerID SymbolID) ([]CalleeInfo, error) {
 
 // CallerInfo represents a caller with call site information.
 type CallerInfo struct {
-	Symbol     Symbol   `json:"symbol"`
-	CallKind   CallKind `json:"call_kind"`
-	CallerFile string   `json:"caller_file"`
-	CallerLine int      `json:"caller_line"`
-	Count      int      `json:"count"`
-	Tags       []Tag    `json:"tags,omitempty"`
+	Symbol     Symbol     `json:"symbol"`
+	CallKind   CallKind   `json:"call_kind"`
+	ResolvedBy ResolvedBy `json:"resolved_by"`
+	CallerFile string     `json:"caller_file"`
+	CallerLine int        `json:"caller_line"`
+	Count      int        `json:"count"`
+	Expr       string     `json:"expr,omitempty"` // Source of the call expression
+	Tags       []Tag      `json:"tags,omitempty"`
 }
 
 // GetCallers retrieves all symbols that call the given symbol.
-func (s *Store) GetCallers(calleeID SymbolID) ([]CallerInfo, error) {
-	rows, err := s.db.Query(`
+func (s *Store) GetCallers(ctx context.Context, calleeID SymbolID) ([]CallerInfo, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
 		SELECT s.id, s.pkg_path, s.name, s.kind, COALESCE(s.recv_type, '') as recv_type,
-		       s.file, s.line, COALESCE(s.sig, '') as sig,
-		       ce.call_kind, ce.caller_file, ce.caller_line, ce.count
+		       s.file, s.line, COALESCE(s.sig, '') as sig, s.repo,
+		       ce.call_kind, ce.resolved_by, ce.caller_file, ce.caller_line, ce.count, ce.expr
 		FROM call_edges ce
 		JOIN symbols s ON ce.caller_id = s.id
 		WHERE ce.callee_id = ?
@@ -837,12 +1329,14 @@ func (s *Store) GetCallers(calleeID SymbolID) ([]CallerInfo, error) {
 		var c CallerInfo
 		err := rows.Scan(
 			&c.Symbol.ID, &c.Symbol.PkgPath, &c.Symbol.Name, &c.Symbol.Kind,
-			&c.Symbol.RecvType, &c.Symbol.File, &c.Symbol.Line, &c.Symbol.Sig,
-			&c.CallKind, &c.CallerFile, &c.CallerLine, &c.Count,
+			&c.Symbol.RecvType, &c.Symbol.File, &c.Symbol.Line, &c.Symbol.Sig, &c.Symbol.Repo,
+			&c.CallKind, &c.ResolvedBy, &c.CallerFile, &c.CallerLine, &c.Count, &c.Expr,
 		)
 		if err != nil {
 			return nil, err
 		}
+		c.Symbol.File = s.absPath(ctx, c.Symbol.Repo, c.Symbol.File)
+		c.CallerFile = s.absPath(ctx, c.Symbol.Repo, c.CallerFile)
 		results = append(results, c)
 	}
 	if err := rows.Err(); err != nil {
@@ -851,7 +1345,7 @@ func (s *Store) GetCallers(calleeID SymbolID) ([]CallerInfo, error) {
 
 	// Fetch tags for each caller
 	for i := range results {
-		tags, err := s.GetSymbolTags(results[i].Symbol.ID)
+		tags, err := s.GetSymbolTags(ctx, results[i].Symbol.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -862,20 +1356,92 @@ func (s *Store) GetCallers(calleeID SymbolID) ([]CallerInfo, error) {
 }
 
 // GetPackageByPath retrieves a package by its path.
-func (s *Store) GetPackageByPath(pkgPath string) (*Package, error) {
+func (s *Store) GetPackageByPath(ctx context.Context, pkgPath string) (*Package, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	pkg := &Package{}
-	var module, layer sql.NullString
-	err := s.db.QueryRow(`
-		SELECT pkg_path, module, dir, layer FROM packages WHERE pkg_path = ?
-	`, pkgPath).Scan(&pkg.PkgPath, &module, &pkg.Dir, &layer)
+	var module, layer, layerSource sql.NullString
+	err := s.readDB.QueryRowContext(ctx, `
+		SELECT pkg_path, module, dir, layer, layer_source, repo FROM packages WHERE pkg_path = ?
+	`, pkgPath).Scan(&pkg.PkgPath, &module, &pkg.Dir, &layer, &layerSource, &pkg.Repo)
 	if err != nil {
 		return nil, err
 	}
+	pkg.Dir = s.absPath(ctx, pkg.Repo, pkg.Dir)
 	if module.Valid {
 		pkg.Module = module.String
 	}
 	if layer.Valid {
 		pkg.Layer = layer.String
 	}
+	if layerSource.Valid {
+		pkg.LayerSource = layerSource.String
+	}
 	return pkg, nil
 }
+
+// GetPackages retrieves all packages ordered by path.
+func (s *Store) GetPackages(ctx context.Context) ([]Package, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT pkg_path, COALESCE(module, ''), dir, COALESCE(layer, ''), COALESCE(layer_source, ''), repo
+		FROM packages
+		ORDER BY pkg_path
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pkgs []Package
+	for rows.Next() {
+		var pkg Package
+		if err := rows.Scan(&pkg.PkgPath, &pkg.Module, &pkg.Dir, &pkg.Layer, &pkg.LayerSource, &pkg.Repo); err != nil {
+			return nil, err
+		}
+		pkg.Dir = s.absPath(ctx, pkg.Repo, pkg.Dir)
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, rows.Err()
+}
+
+// PackageDependency is an aggregated call relationship between two packages.
+type PackageDependency struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"` // Number of call edges from From to To
+}
+
+// GetPackageDependencies aggregates call edges into package-to-package
+// dependencies, excluding calls within the same package.
+func (s *Store) GetPackageDependencies(ctx context.Context) ([]PackageDependency, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT s1.pkg_path, s2.pkg_path, COUNT(*)
+		FROM call_edges ce
+		JOIN symbols s1 ON ce.caller_id = s1.id
+		JOIN symbols s2 ON ce.callee_id = s2.id
+		WHERE s1.pkg_path != s2.pkg_path
+		GROUP BY s1.pkg_path, s2.pkg_path
+		ORDER BY s1.pkg_path, s2.pkg_path
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deps []PackageDependency
+	for rows.Next() {
+		var d PackageDependency
+		if err := rows.Scan(&d.From, &d.To, &d.Count); err != nil {
+			return nil, err
+		}
+		deps = append(deps, d)
+	}
+	return deps, rows.Err()
+}
@@ -1,9 +1,18 @@
 package store
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestOpenAndClose(t *testing.T) {
@@ -46,7 +55,7 @@ func TestInsertAndRetrievePackage(t *testing.T) {
 		Layer:   "service",
 	}
 
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatalf("failed to insert package: %v", err)
 	}
 
@@ -74,7 +83,7 @@ func TestInsertAndRetrieveSymbol(t *testing.T) {
 		PkgPath: "github.com/test/pkg",
 		Dir:     "/path/to/pkg",
 	}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatalf("failed to insert package: %v", err)
 	}
 
@@ -88,7 +97,7 @@ func TestInsertAndRetrieveSymbol(t *testing.T) {
 		Sig:      "func() error",
 	}
 
-	id, err := st.InsertSymbol(sym)
+	id, err := st.InsertSymbol(t.Context(), sym)
 	if err != nil {
 		t.Fatalf("failed to insert symbol: %v", err)
 	}
@@ -97,7 +106,7 @@ func TestInsertAndRetrieveSymbol(t *testing.T) {
 	}
 
 	// Retrieve by ID
-	lookupID, err := st.GetSymbolID(sym.PkgPath, sym.Name, sym.RecvType)
+	lookupID, err := st.GetSymbolID(t.Context(), sym.PkgPath, sym.Name, sym.RecvType)
 	if err != nil {
 		t.Fatalf("failed to get symbol ID: %v", err)
 	}
@@ -118,7 +127,7 @@ func TestInsertMethod(t *testing.T) {
 		PkgPath: "github.com/test/pkg",
 		Dir:     "/path/to/pkg",
 	}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatalf("failed to insert package: %v", err)
 	}
 
@@ -133,7 +142,7 @@ func TestInsertMethod(t *testing.T) {
 		Sig:      "func(*Handler) Process() error",
 	}
 
-	id, err := st.InsertSymbol(sym)
+	id, err := st.InsertSymbol(t.Context(), sym)
 	if err != nil {
 		t.Fatalf("failed to insert method: %v", err)
 	}
@@ -142,7 +151,7 @@ func TestInsertMethod(t *testing.T) {
 	}
 
 	// Should be able to retrieve with receiver type
-	lookupID, err := st.GetSymbolID(sym.PkgPath, sym.Name, sym.RecvType)
+	lookupID, err := st.GetSymbolID(t.Context(), sym.PkgPath, sym.Name, sym.RecvType)
 	if err != nil {
 		t.Fatalf("failed to get method ID: %v", err)
 	}
@@ -159,7 +168,7 @@ func TestBatchInsert(t *testing.T) {
 	}
 	defer st.Close()
 
-	batch, err := st.BeginBatch()
+	batch, err := st.BeginBatch(t.Context())
 	if err != nil {
 		t.Fatalf("failed to begin batch: %v", err)
 	}
@@ -170,7 +179,7 @@ func TestBatchInsert(t *testing.T) {
 			PkgPath: "github.com/test/pkg" + string(rune('a'+i)),
 			Dir:     "/path/to/pkg",
 		}
-		if err := batch.InsertPackage(pkg); err != nil {
+		if err := batch.InsertPackage(t.Context(), pkg); err != nil {
 			batch.Rollback()
 			t.Fatalf("failed to insert package: %v", err)
 		}
@@ -181,7 +190,7 @@ func TestBatchInsert(t *testing.T) {
 	}
 
 	// Verify count
-	stats, err := st.GetStats()
+	stats, err := st.GetStats(t.Context())
 	if err != nil {
 		t.Fatalf("failed to get stats: %v", err)
 	}
@@ -200,22 +209,22 @@ func TestClear(t *testing.T) {
 
 	// Insert some data
 	pkg := &Package{PkgPath: "github.com/test/pkg", Dir: "/path"}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatalf("failed to insert package: %v", err)
 	}
 
 	sym := &Symbol{PkgPath: "github.com/test/pkg", Name: "Func", Kind: SymbolKindFunc, File: "f.go", Line: 1}
-	if _, err := st.InsertSymbol(sym); err != nil {
+	if _, err := st.InsertSymbol(t.Context(), sym); err != nil {
 		t.Fatalf("failed to insert symbol: %v", err)
 	}
 
 	// Clear
-	if err := st.Clear(); err != nil {
+	if err := st.Clear(t.Context()); err != nil {
 		t.Fatalf("failed to clear: %v", err)
 	}
 
 	// Verify empty
-	stats, err := st.GetStats()
+	stats, err := st.GetStats(t.Context())
 	if err != nil {
 		t.Fatalf("failed to get stats: %v", err)
 	}
@@ -232,11 +241,11 @@ func TestMetadata(t *testing.T) {
 	}
 	defer st.Close()
 
-	if err := st.SetMetadata("version", "1.0"); err != nil {
+	if err := st.SetMetadata(t.Context(), "version", "1.0"); err != nil {
 		t.Fatalf("failed to set metadata: %v", err)
 	}
 
-	val, err := st.GetMetadata("version")
+	val, err := st.GetMetadata(t.Context(), "version")
 	if err != nil {
 		t.Fatalf("failed to get metadata: %v", err)
 	}
@@ -245,11 +254,11 @@ func TestMetadata(t *testing.T) {
 	}
 
 	// Update existing key
-	if err := st.SetMetadata("version", "2.0"); err != nil {
+	if err := st.SetMetadata(t.Context(), "version", "2.0"); err != nil {
 		t.Fatalf("failed to update metadata: %v", err)
 	}
 
-	val, err = st.GetMetadata("version")
+	val, err = st.GetMetadata(t.Context(), "version")
 	if err != nil {
 		t.Fatalf("failed to get updated metadata: %v", err)
 	}
@@ -268,15 +277,15 @@ func TestWriteIndexJSON(t *testing.T) {
 
 	// Insert some data
 	pkg := &Package{PkgPath: "github.com/test/pkg", Dir: "/path"}
-	if err := st.InsertPackage(pkg); err != nil {
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
 		t.Fatalf("failed to insert package: %v", err)
 	}
 
-	if err := st.SetMetadata("indexed_at", "2024-01-01T00:00:00Z"); err != nil {
+	if err := st.SetMetadata(t.Context(), "indexed_at", "2024-01-01T00:00:00Z"); err != nil {
 		t.Fatalf("failed to set metadata: %v", err)
 	}
 
-	if err := st.WriteIndexJSON(); err != nil {
+	if err := st.WriteIndexJSON(t.Context()); err != nil {
 		t.Fatalf("failed to write index.json: %v", err)
 	}
 
@@ -286,3 +295,1623 @@ func TestWriteIndexJSON(t *testing.T) {
 		t.Error("index.json was not created")
 	}
 }
+
+func TestSearchSymbolsFuzzy(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/service", Dir: "/service"}); err != nil {
+		t.Fatal(err)
+	}
+
+	symbols := []*Symbol{
+		{PkgPath: "myapp/service", Name: "GetUser", Kind: SymbolKindMethod, RecvType: "*UserService", File: "user.go", Line: 10},
+		{PkgPath: "myapp/service", Name: "GetUser", Kind: SymbolKindMethod, RecvType: "*AdminHandler", File: "admin.go", Line: 20},
+		{PkgPath: "myapp/service", Name: "DeleteUser", Kind: SymbolKindMethod, RecvType: "*UserService", File: "user.go", Line: 30},
+		{PkgPath: "myapp/service", Name: "Register", Kind: SymbolKindFunc, File: "service.go", Line: 40},
+	}
+	for _, sym := range symbols {
+		if _, err := st.InsertSymbol(t.Context(), sym); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := st.SearchSymbols(t.Context(), SearchFilter{Query: "usrsvc get", Limit: 10})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("expected results for 'usrsvc get'")
+	}
+	top := results[0].Symbol
+	if top.Name != "GetUser" || top.RecvType != "*UserService" {
+		t.Errorf("expected (*UserService).GetUser first, got (%s).%s", top.RecvType, top.Name)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Errorf("results not sorted by score at %d", i)
+		}
+	}
+
+	// Tokens that match nothing exclude the symbol
+	results, err = st.SearchSymbols(t.Context(), SearchFilter{Query: "zzz", Limit: 10})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no results for 'zzz', got %d", len(results))
+	}
+
+	// The SQL prefilter keeps every symbol the fuzzy scorer matches,
+	// including trigram-only matches and LIKE wildcards in the query
+	if _, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/service", Name: "load_user_cfg", Kind: SymbolKindFunc, File: "cfg.go", Line: 1}); err != nil {
+		t.Fatal(err)
+	}
+	symbols = append(symbols, &Symbol{PkgPath: "myapp/service", Name: "load_user_cfg"})
+	for _, q := range []string{"xuser", "user_cfg", "usre", "svc del", "%", "r"} {
+		want := 0
+		for _, sym := range symbols {
+			if fuzzyScore(q, sym) > 0 {
+				want++
+			}
+		}
+		results, err := st.SearchSymbols(t.Context(), SearchFilter{Query: q, Limit: 10})
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		if len(results) != want {
+			t.Errorf("query %q: expected %d results, got %d", q, want, len(results))
+		}
+	}
+}
+
+func TestGetCrossLayerEdgeCounts(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	for _, pkg := range []*Package{
+		{PkgPath: "myapp/handlers", Dir: "/handlers", Layer: "handler"},
+		{PkgPath: "myapp/service", Dir: "/service", Layer: "service"},
+		{PkgPath: "myapp/store", Dir: "/store", Layer: "store"},
+	} {
+		if err := st.InsertPackage(t.Context(), pkg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler, _ := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/handlers", Name: "GetUser", Kind: SymbolKindFunc, File: "h.go", Line: 1})
+	service, _ := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/service", Name: "GetUser", Kind: SymbolKindFunc, File: "s.go", Line: 1})
+	repo, _ := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/store", Name: "GetUser", Kind: SymbolKindFunc, File: "r.go", Line: 1})
+
+	edges := []*CallEdge{
+		{CallerID: handler, CalleeID: service, CallerFile: "h.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+		{CallerID: service, CalleeID: repo, CallerFile: "s.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+		{CallerID: repo, CalleeID: handler, CallerFile: "r.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+	}
+	for _, e := range edges {
+		if err := st.InsertCallEdge(t.Context(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counts, err := st.GetCrossLayerEdgeCounts(t.Context())
+	if err != nil {
+		t.Fatalf("GetCrossLayerEdgeCounts failed: %v", err)
+	}
+	if len(counts) != 3 {
+		t.Fatalf("expected 3 layer pairs, got %d: %+v", len(counts), counts)
+	}
+	for _, c := range counts {
+		if c.Count != 1 {
+			t.Errorf("expected 1 edge for %s -> %s, got %d", c.CallerLayer, c.CalleeLayer, c.Count)
+		}
+	}
+}
+
+func TestGetPackageStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	for _, pkg := range []*Package{
+		{PkgPath: "myapp/api", Dir: "/api", Layer: "handler"},
+		{PkgPath: "myapp/util", Dir: "/util"},
+	} {
+		if err := st.InsertPackage(t.Context(), pkg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, _ := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/api", Name: "A", Kind: SymbolKindFunc, File: "a.go", Line: 1})
+	b, _ := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/api", Name: "B", Kind: SymbolKindFunc, File: "a.go", Line: 5})
+	helper, _ := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/util", Name: "Helper", Kind: SymbolKindFunc, File: "u.go", Line: 1})
+
+	edges := []*CallEdge{
+		{CallerID: a, CalleeID: helper, CallerFile: "a.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+		{CallerID: a, CalleeID: helper, CallerFile: "a.go", CallerLine: 3, CallKind: CallKindStatic, Count: 1},
+		{CallerID: b, CalleeID: helper, CallerFile: "a.go", CallerLine: 6, CallKind: CallKindStatic, Count: 1},
+		{CallerID: b, CalleeID: a, CallerFile: "a.go", CallerLine: 7, CallKind: CallKindStatic, Count: 1},
+	}
+	for _, e := range edges {
+		if err := st.InsertCallEdge(t.Context(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tag := range []*Tag{
+		{SymbolID: a, Tag: "io:db"},
+		{SymbolID: b, Tag: "io:db"},
+		{SymbolID: helper, Tag: "pure"},
+	} {
+		if err := st.InsertTag(t.Context(), tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := st.GetPackageStats(t.Context())
+	if err != nil {
+		t.Fatalf("GetPackageStats failed: %v", err)
+	}
+	want := []PackageStats{
+		{PkgPath: "myapp/api", Layer: "handler", SymbolCount: 2, EdgeCount: 4},
+		{PkgPath: "myapp/util", SymbolCount: 1, EdgeCount: 0},
+	}
+	if len(pkgs) != len(want) {
+		t.Fatalf("expected %d packages, got %+v", len(want), pkgs)
+	}
+	for i := range want {
+		if pkgs[i] != want[i] {
+			t.Errorf("package %d: expected %+v, got %+v", i, want[i], pkgs[i])
+		}
+	}
+
+	tags, err := st.GetTagCounts(t.Context())
+	if err != nil {
+		t.Fatalf("GetTagCounts failed: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != (TagCount{Tag: "io:db", Count: 2}) || tags[1] != (TagCount{Tag: "pure", Count: 1}) {
+		t.Errorf("unexpected tag counts: %+v", tags)
+	}
+
+	// Two call sites from A count as one caller
+	top, err := st.GetTopFanIn(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("GetTopFanIn failed: %v", err)
+	}
+	if len(top) != 1 || top[0].Symbol.ID != helper || top[0].Callers != 2 {
+		t.Errorf("expected Helper with 2 callers, got %+v", top)
+	}
+}
+
+func TestGetPackageDependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	for _, pkg := range []*Package{
+		{PkgPath: "myapp/b", Dir: "/b"},
+		{PkgPath: "myapp/a", Dir: "/a", Module: "myapp", Layer: "handler"},
+	} {
+		if err := st.InsertPackage(t.Context(), pkg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a1, _ := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/a", Name: "One", Kind: SymbolKindFunc, File: "a.go", Line: 1})
+	a2, _ := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/a", Name: "Two", Kind: SymbolKindFunc, File: "a.go", Line: 5})
+	b1, _ := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/b", Name: "Three", Kind: SymbolKindFunc, File: "b.go", Line: 1})
+
+	for _, e := range []*CallEdge{
+		{CallerID: a1, CalleeID: a2, CallerFile: "a.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+		{CallerID: a1, CalleeID: b1, CallerFile: "a.go", CallerLine: 3, CallKind: CallKindStatic, Count: 1},
+		{CallerID: a2, CalleeID: b1, CallerFile: "a.go", CallerLine: 6, CallKind: CallKindStatic, Count: 1},
+	} {
+		if err := st.InsertCallEdge(t.Context(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := st.GetPackages(t.Context())
+	if err != nil {
+		t.Fatalf("GetPackages failed: %v", err)
+	}
+	if len(pkgs) != 2 || pkgs[0].PkgPath != "myapp/a" || pkgs[0].Layer != "handler" {
+		t.Errorf("unexpected packages: %+v", pkgs)
+	}
+
+	deps, err := st.GetPackageDependencies(t.Context())
+	if err != nil {
+		t.Fatalf("GetPackageDependencies failed: %v", err)
+	}
+	if len(deps) != 1 {
+		t.Fatalf("expected 1 dependency (same-package calls excluded), got %d", len(deps))
+	}
+	if deps[0].From != "myapp/a" || deps[0].To != "myapp/b" || deps[0].Count != 2 {
+		t.Errorf("unexpected dependency: %+v", deps[0])
+	}
+}
+
+func TestRunTransaction(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/old", Dir: "/old"}); err != nil {
+		t.Fatal(err)
+	}
+	countPackages := func(s *Store) int {
+		t.Helper()
+		stats, err := s.GetStats(t.Context())
+		if err != nil {
+			t.Fatalf("GetStats failed: %v", err)
+		}
+		return stats.PackageCount
+	}
+
+	// A failed run leaves the previous index untouched
+	run, err := st.BeginRun(t.Context())
+	if err != nil {
+		t.Fatalf("BeginRun failed: %v", err)
+	}
+	if err := run.Clear(t.Context()); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if n := countPackages(run); n != 0 {
+		t.Errorf("expected the run to see its own clear, got %d packages", n)
+	}
+	if n := countPackages(st); n != 1 {
+		t.Errorf("expected readers to see the previous index during the run, got %d packages", n)
+	}
+	if err := run.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if n := countPackages(st); n != 1 {
+		t.Errorf("expected the rolled back run to keep the previous index, got %d packages", n)
+	}
+
+	// Batches join the run and are only visible once it commits
+	run, err = st.BeginRun(t.Context())
+	if err != nil {
+		t.Fatalf("BeginRun failed: %v", err)
+	}
+	if err := run.Clear(t.Context()); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	batch, err := run.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatalf("BeginBatch failed: %v", err)
+	}
+	for _, path := range []string{"myapp/a", "myapp/b"} {
+		if err := batch.InsertPackage(t.Context(), &Package{PkgPath: path, Dir: "/" + path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("batch Commit failed: %v", err)
+	}
+	if n := countPackages(st); n != 1 {
+		t.Errorf("expected committed batches to stay invisible until the run commits, got %d packages", n)
+	}
+	if err := run.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := run.Rollback(); err != nil {
+		t.Errorf("expected Rollback after Commit to be a no-op, got %v", err)
+	}
+	if n := countPackages(st); n != 2 {
+		t.Errorf("expected 2 packages after the run, got %d", n)
+	}
+}
+
+func TestReplaceWith(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/a", Dir: "/a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process serving the index, like the UI server
+	reader, err := OpenFile(st.DBPath(), tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open reader: %v", err)
+	}
+	defer reader.Close()
+
+	tmpPath := st.DBPath() + ".tmp"
+	if err := st.CopyTo(t.Context(), tmpPath); err != nil {
+		t.Fatalf("CopyTo failed: %v", err)
+	}
+	work, err := OpenFile(tmpPath, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open copy: %v", err)
+	}
+	if err := work.InsertPackage(t.Context(), &Package{PkgPath: "myapp/b", Dir: "/b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := work.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.ReplaceWith(tmpPath); err != nil {
+		t.Fatalf("ReplaceWith failed: %v", err)
+	}
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be renamed away, got %v", tmpPath, err)
+	}
+	pkgs, err := st.GetPackages(t.Context())
+	if err != nil {
+		t.Fatalf("GetPackages failed: %v", err)
+	}
+	if len(pkgs) != 2 {
+		t.Errorf("expected the replaced store to see 2 packages, got %d", len(pkgs))
+	}
+
+	reopened, err := reader.Reopen()
+	if err != nil || !reopened {
+		t.Fatalf("expected the reader to reopen the replaced file, got %v, %v", reopened, err)
+	}
+	if pkgs, err := reader.GetPackages(t.Context()); err != nil || len(pkgs) != 2 {
+		t.Errorf("expected the reopened reader to see 2 packages, got %d (%v)", len(pkgs), err)
+	}
+	if reopened, err := reader.Reopen(); err != nil || reopened {
+		t.Errorf("expected no reopen for an unchanged file, got %v, %v", reopened, err)
+	}
+}
+
+func TestCopyUserData(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	tmpPath := st.DBPath() + ".tmp"
+	if err := st.CopyTo(t.Context(), tmpPath); err != nil {
+		t.Fatalf("CopyTo failed: %v", err)
+	}
+
+	// Saved by the UI server while the run builds its copy
+	if _, _, err := st.SaveView(t.Context(), "checkout", json.RawMessage(`{"root":1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	work, err := OpenFile(tmpPath, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open copy: %v", err)
+	}
+	run, err := work.BeginRun(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run.CopyUserData(t.Context(), st); err != nil {
+		t.Fatalf("CopyUserData failed: %v", err)
+	}
+	if err := run.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := work.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.ReplaceWith(tmpPath); err != nil {
+		t.Fatalf("ReplaceWith failed: %v", err)
+	}
+	views, err := st.GetViews(t.Context())
+	if err != nil {
+		t.Fatalf("GetViews failed: %v", err)
+	}
+	if len(views) != 1 || views[0].Name != "checkout" {
+		t.Errorf("expected the view saved during the run to survive the swap, got %+v", views)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/a", Dir: "/a"}); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := st.DBPath()
+	st.Close()
+
+	ro, err := OpenReadOnly(dbPath, tmpDir)
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed: %v", err)
+	}
+	defer ro.Close()
+	if !ro.ReadOnly() {
+		t.Error("expected ReadOnly to be true")
+	}
+	pkgs, err := ro.GetPackages(t.Context())
+	if err != nil || len(pkgs) != 1 {
+		t.Fatalf("expected 1 package, got %d (%v)", len(pkgs), err)
+	}
+	if err := ro.InsertPackage(t.Context(), &Package{PkgPath: "myapp/b", Dir: "/b"}); err == nil {
+		t.Error("expected a write to a read-only store to fail")
+	}
+	if _, err := ro.BeginBatch(t.Context()); err != ErrReadOnly {
+		t.Errorf("expected ErrReadOnly from BeginBatch, got %v", err)
+	}
+
+	if _, err := OpenReadOnly(filepath.Join(tmpDir, "missing.db"), tmpDir); err == nil {
+		t.Error("expected opening a missing index read-only to fail")
+	}
+}
+
+func TestQueryHonorsContext(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := st.GetStats(ctx); err == nil {
+		t.Error("expected error for canceled context")
+	}
+
+	st.SetQueryTimeout(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, err := st.GetEntrypoints(t.Context(), EntrypointFilter{}); err == nil {
+		t.Error("expected error when the query timeout has elapsed")
+	}
+
+	st.SetQueryTimeout(0)
+	if _, err := st.GetEntrypoints(t.Context(), EntrypointFilter{}); err != nil {
+		t.Errorf("expected query to succeed without a timeout: %v", err)
+	}
+}
+
+func TestReadsDuringWriteBatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/a", Dir: "/a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	batch, err := st.BeginBatch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer batch.Rollback()
+	if _, err := batch.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/a", Name: "Pending", Kind: SymbolKindFunc, File: "a.go", Line: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reads must not block on the open write transaction, and must not see it
+	stats, err := st.GetStats(t.Context())
+	if err != nil {
+		t.Fatalf("read during write batch failed: %v", err)
+	}
+	if stats.PackageCount != 1 || stats.SymbolCount != 0 {
+		t.Errorf("expected committed state only, got %+v", stats)
+	}
+
+	if _, err := st.readDB.ExecContext(t.Context(), "DELETE FROM packages"); err == nil {
+		t.Error("expected read connection to reject writes")
+	}
+}
+
+func TestPackageLayerSource(t *testing.T) {
+	st, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	pkgs := []*Package{
+		{PkgPath: "myapp/handlers", Dir: "/handlers", Layer: "handler", LayerSource: LayerSourceConfig},
+		{PkgPath: "myapp/persist", Dir: "/persist", Layer: "store", LayerSource: LayerSourceInferred},
+		{PkgPath: "myapp/util", Dir: "/util"},
+	}
+	for _, pkg := range pkgs {
+		if err := st.InsertPackage(t.Context(), pkg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range pkgs {
+		got, err := st.GetPackageByPath(t.Context(), want.PkgPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Layer != want.Layer || got.LayerSource != want.LayerSource {
+			t.Errorf("%s: expected %q/%q, got %q/%q", want.PkgPath, want.Layer, want.LayerSource, got.Layer, got.LayerSource)
+		}
+	}
+}
+
+func TestMigrateAddsMissingColumns(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".flowlens"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A packages table as created before layer_source existed
+	db, err := sql.Open("sqlite", filepath.Join(dir, ".flowlens", "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("CREATE TABLE packages (pkg_path TEXT PRIMARY KEY, module TEXT, dir TEXT NOT NULL, layer TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	st, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open old index: %v", err)
+	}
+	defer st.Close()
+
+	pkg := &Package{PkgPath: "myapp/persist", Dir: "/persist", Layer: "store", LayerSource: LayerSourceInferred}
+	if err := st.InsertPackage(t.Context(), pkg); err != nil {
+		t.Fatalf("insert after migration failed: %v", err)
+	}
+}
+
+func TestManualEdgesSurviveClear(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	insert := func() (SymbolID, SymbolID) {
+		t.Helper()
+		if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/jobs", Dir: "/path"}); err != nil {
+			t.Fatalf("failed to insert package: %v", err)
+		}
+		caller, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/jobs", Name: "Dispatch", Kind: SymbolKindFunc, File: "jobs.go", Line: 10})
+		if err != nil {
+			t.Fatalf("failed to insert symbol: %v", err)
+		}
+		callee, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/jobs", Name: "Run", Kind: SymbolKindMethod, RecvType: "*Cleanup", File: "jobs.go", Line: 30})
+		if err != nil {
+			t.Fatalf("failed to insert symbol: %v", err)
+		}
+		return caller, callee
+	}
+
+	caller, callee := insert()
+	edge, err := st.AddManualEdge(t.Context(), caller, callee, CallKindStatic, "dispatched by reflection")
+	if err != nil {
+		t.Fatalf("failed to add manual edge: %v", err)
+	}
+	if edge.CalleeKey != "myapp/jobs.(*Cleanup).Run" {
+		t.Errorf("unexpected callee key %q", edge.CalleeKey)
+	}
+
+	// A re-index clears the graph and recreates the symbols with new IDs
+	if err := st.Clear(t.Context()); err != nil {
+		t.Fatalf("failed to clear: %v", err)
+	}
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/other", Dir: "/other"}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+	if _, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/other", Name: "Shift", Kind: SymbolKindFunc, File: "o.go", Line: 1}); err != nil {
+		t.Fatalf("failed to insert symbol: %v", err)
+	}
+	caller, callee = insert()
+
+	n, err := st.ApplyManualEdges(t.Context())
+	if err != nil {
+		t.Fatalf("failed to apply manual edges: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 manual edge applied, got %d", n)
+	}
+	callees, err := st.GetCallees(t.Context(), caller)
+	if err != nil {
+		t.Fatalf("failed to get callees: %v", err)
+	}
+	if len(callees) != 1 || callees[0].Symbol.ID != callee || callees[0].ResolvedBy != ResolvedManual {
+		t.Errorf("expected a manual edge to the new callee ID %d, got %+v", callee, callees)
+	}
+
+	edges, err := st.GetManualEdges(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get manual edges: %v", err)
+	}
+	if len(edges) != 1 || edges[0].CallerID != caller || edges[0].Note != "dispatched by reflection" {
+		t.Errorf("unexpected manual edges %+v", edges)
+	}
+
+	deleted, err := st.DeleteManualEdge(t.Context(), caller, callee)
+	if err != nil || !deleted {
+		t.Fatalf("expected manual edge to be deleted, got %v, %v", deleted, err)
+	}
+	callees, err = st.GetCallees(t.Context(), caller)
+	if err != nil {
+		t.Fatalf("failed to get callees: %v", err)
+	}
+	if len(callees) != 0 {
+		t.Errorf("expected no callees after delete, got %d", len(callees))
+	}
+}
+
+func TestAnnotationsRoundTrip(t *testing.T) {
+	// Two indexes of the same project, with symbols under different IDs
+	open := func(extra bool) (*Store, SymbolID, SymbolID) {
+		t.Helper()
+		st, err := Open(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		t.Cleanup(func() { st.Close() })
+		if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/jobs", Dir: "/path"}); err != nil {
+			t.Fatalf("failed to insert package: %v", err)
+		}
+		if extra {
+			if _, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/jobs", Name: "Shift", Kind: SymbolKindFunc, File: "jobs.go", Line: 1}); err != nil {
+				t.Fatalf("failed to insert symbol: %v", err)
+			}
+		}
+		caller, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/jobs", Name: "Dispatch", Kind: SymbolKindFunc, File: "jobs.go", Line: 10})
+		if err != nil {
+			t.Fatalf("failed to insert symbol: %v", err)
+		}
+		callee, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/jobs", Name: "Run", Kind: SymbolKindMethod, RecvType: "*Cleanup", File: "jobs.go", Line: 30})
+		if err != nil {
+			t.Fatalf("failed to insert symbol: %v", err)
+		}
+		return st, caller, callee
+	}
+
+	src, caller, callee := open(false)
+	epID, err := src.InsertEntrypoint(t.Context(), &Entrypoint{Type: EntrypointHTTP, Label: "POST /jobs", SymbolID: caller})
+	if err != nil {
+		t.Fatalf("failed to insert entrypoint: %v", err)
+	}
+	if _, err := src.PinSymbol(t.Context(), callee, "runs every job"); err != nil {
+		t.Fatalf("failed to pin: %v", err)
+	}
+	if _, err := src.StarEntrypoint(t.Context(), epID, "main flow"); err != nil {
+		t.Fatalf("failed to star: %v", err)
+	}
+	if _, err := src.AddManualEdge(t.Context(), caller, callee, CallKindGo, "dispatched by reflection"); err != nil {
+		t.Fatalf("failed to add manual edge: %v", err)
+	}
+	if _, _, err := src.SaveView(t.Context(), "jobs", json.RawMessage(`{"root":1}`)); err != nil {
+		t.Fatalf("failed to save view: %v", err)
+	}
+	// Kept for an index that has the symbols again
+	if _, err := src.db.ExecContext(t.Context(), `
+		INSERT INTO manual_edges (caller_pkg, caller_name, callee_pkg, callee_name, created_at)
+		VALUES ('myapp/gone', 'Old', 'myapp/jobs', 'Dispatch', '2030-01-01T00:00:00Z')
+	`); err != nil {
+		t.Fatalf("failed to insert manual edge: %v", err)
+	}
+
+	exported, err := src.ExportAnnotations(t.Context())
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if len(exported.Pinned) != 1 || exported.Pinned[0].Symbol != (SymbolRef{Pkg: "myapp/jobs", Name: "Run", Recv: "*Cleanup"}) ||
+		exported.Pinned[0].Note != "runs every job" {
+		t.Errorf("unexpected pinned symbols %+v", exported.Pinned)
+	}
+	if len(exported.Starred) != 1 || exported.Starred[0].Label != "POST /jobs" {
+		t.Errorf("unexpected starred entrypoints %+v", exported.Starred)
+	}
+	if len(exported.ManualEdges) != 2 || exported.ManualEdges[0].Kind != CallKindGo || exported.ManualEdges[1].Caller.Pkg != "myapp/gone" {
+		t.Errorf("unexpected manual edges %+v", exported.ManualEdges)
+	}
+	if len(exported.Views) != 1 || exported.Views[0].State != `{"root":1}` {
+		t.Errorf("unexpected views %+v", exported.Views)
+	}
+
+	dst, caller, callee := open(true)
+	counts, err := dst.ImportAnnotations(t.Context(), exported)
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if *counts != (AnnotationCounts{Pinned: 1, Starred: 1, ManualEdges: 2, Applied: 1, Views: 1}) {
+		t.Errorf("unexpected counts %+v", counts)
+	}
+	callees, err := dst.GetCallees(t.Context(), caller)
+	if err != nil {
+		t.Fatalf("failed to get callees: %v", err)
+	}
+	if len(callees) != 1 || callees[0].Symbol.ID != callee || callees[0].ResolvedBy != ResolvedManual || callees[0].CallKind != CallKindGo {
+		t.Errorf("expected a manual go edge to the new callee ID %d, got %+v", callee, callees)
+	}
+	bookmarks, err := dst.GetBookmarks(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get bookmarks: %v", err)
+	}
+	if len(bookmarks) != 2 || bookmarks[0].ID != int64(callee) {
+		t.Errorf("expected the pin to resolve to the new callee ID %d, got %+v", callee, bookmarks)
+	}
+
+	// Importing again changes nothing, and exports the same annotations
+	if _, err := dst.ImportAnnotations(t.Context(), exported); err != nil {
+		t.Fatalf("failed to import again: %v", err)
+	}
+	reexported, err := dst.ExportAnnotations(t.Context())
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if !reflect.DeepEqual(exported, reexported) {
+		t.Errorf("round trip changed annotations:\n%+v\n%+v", exported, reexported)
+	}
+
+	bad := &Annotations{Views: []AnnotatedView{{Name: "broken", State: "{"}}}
+	if _, err := dst.ImportAnnotations(t.Context(), bad); err == nil {
+		t.Error("expected invalid view state to be rejected")
+	}
+}
+
+func TestPathsRelativeToProject(t *testing.T) {
+	buildDir := t.TempDir()
+	st, err := Open(buildDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/svc", Dir: filepath.Join(buildDir, "svc")}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+	inside, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/svc", Name: "Get", Kind: SymbolKindFunc, File: filepath.Join(buildDir, "svc", "get.go"), Line: 3})
+	if err != nil {
+		t.Fatalf("failed to insert symbol: %v", err)
+	}
+	outsidePath := filepath.Join(string(filepath.Separator), "gomodcache", "lib", "lib.go")
+	outside, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/svc", Name: "Lib", Kind: SymbolKindFunc, File: outsidePath, Line: 1})
+	if err != nil {
+		t.Fatalf("failed to insert symbol: %v", err)
+	}
+
+	var stored string
+	if err := st.pools.get().read.QueryRow("SELECT file FROM symbols WHERE id = ?", inside).Scan(&stored); err != nil {
+		t.Fatalf("failed to read stored path: %v", err)
+	}
+	if stored != "svc/get.go" {
+		t.Errorf("expected the stored path to be relative, got %q", stored)
+	}
+	st.Close()
+
+	// Move the index to another checkout
+	localDir := t.TempDir()
+	if err := os.CopyFS(filepath.Join(localDir, ".flowlens"), os.DirFS(filepath.Join(buildDir, ".flowlens"))); err != nil {
+		t.Fatalf("failed to copy index: %v", err)
+	}
+	st, err = Open(localDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	sym, err := st.GetSymbolByID(t.Context(), inside)
+	if err != nil {
+		t.Fatalf("failed to get symbol: %v", err)
+	}
+	if want := filepath.Join(localDir, "svc", "get.go"); sym.File != want {
+		t.Errorf("expected %s, got %s", want, sym.File)
+	}
+	sym, err = st.GetSymbolByID(t.Context(), outside)
+	if err != nil {
+		t.Fatalf("failed to get symbol: %v", err)
+	}
+	if sym.File != outsidePath {
+		t.Errorf("expected a path outside the project to stay %s, got %s", outsidePath, sym.File)
+	}
+	pkg, err := st.GetPackageByPath(t.Context(), "myapp/svc")
+	if err != nil {
+		t.Fatalf("failed to get package: %v", err)
+	}
+	if want := filepath.Join(localDir, "svc"); pkg.Dir != want {
+		t.Errorf("expected package dir %s, got %s", want, pkg.Dir)
+	}
+
+	// A named repository in a shared index moves with the index
+	buildRoot := t.TempDir()
+	repoDir := filepath.Join(buildRoot, "billing")
+	shared, err := OpenFile(filepath.Join(buildRoot, "index.db"), repoDir)
+	if err != nil {
+		t.Fatalf("failed to open shared store: %v", err)
+	}
+	if err := shared.SetRepoDir(t.Context(), "billing", repoDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := shared.InsertPackage(t.Context(), &Package{PkgPath: "billing/api", Dir: filepath.Join(repoDir, "api"), Repo: "billing"}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+	shared.Close()
+
+	localRoot := t.TempDir()
+	if err := os.CopyFS(localRoot, os.DirFS(buildRoot)); err != nil {
+		t.Fatalf("failed to copy index: %v", err)
+	}
+	shared, err = OpenFile(filepath.Join(localRoot, "index.db"), localDir)
+	if err != nil {
+		t.Fatalf("failed to open shared store: %v", err)
+	}
+	defer shared.Close()
+	pkg, err = shared.GetPackageByPath(t.Context(), "billing/api")
+	if err != nil {
+		t.Fatalf("failed to get package: %v", err)
+	}
+	if want := filepath.Join(localRoot, "billing", "api"); pkg.Dir != want {
+		t.Errorf("expected the named repository's package dir %s, got %s", want, pkg.Dir)
+	}
+}
+
+func TestPathsFromWindowsIndex(t *testing.T) {
+	dir := t.TempDir()
+	st, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/svc", Dir: filepath.Join(dir, "svc")}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+	inside, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/svc", Name: "Get", Kind: SymbolKindFunc, File: "get.go", Line: 3})
+	if err != nil {
+		t.Fatalf("failed to insert symbol: %v", err)
+	}
+	outside, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/svc", Name: "Lib", Kind: SymbolKindFunc, File: "lib.go", Line: 1})
+	if err != nil {
+		t.Fatalf("failed to insert symbol: %v", err)
+	}
+
+	// Paths as an index built on Windows stored them
+	for id, file := range map[SymbolID]string{
+		inside:  `svc\get.go`,
+		outside: `C:\Users\ci\go\pkg\mod\lib@v1.0.0\lib.go`,
+	} {
+		if _, err := st.db.ExecContext(t.Context(), "UPDATE symbols SET file = ? WHERE id = ?", file, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sym, err := st.GetSymbolByID(t.Context(), inside)
+	if err != nil {
+		t.Fatalf("failed to get symbol: %v", err)
+	}
+	if want := filepath.Join(dir, "svc", "get.go"); sym.File != want {
+		t.Errorf("expected %s, got %s", want, sym.File)
+	}
+	sym, err = st.GetSymbolByID(t.Context(), outside)
+	if err != nil {
+		t.Fatalf("failed to get symbol: %v", err)
+	}
+	if want := filepath.FromSlash("C:/Users/ci/go/pkg/mod/lib@v1.0.0/lib.go"); sym.File != want {
+		t.Errorf("expected a Windows path outside the project to stay %s, got %s", want, sym.File)
+	}
+
+	// File filters match either separator
+	results, err := st.SearchSymbols(t.Context(), SearchFilter{File: filepath.Join("svc", "get"), Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Symbol.ID != inside {
+		t.Errorf("expected the file filter to find Get, got %+v", results)
+	}
+
+	for path, want := range map[string]bool{
+		"/home/ci/go/pkg/mod/lib.go": true,
+		`C:\src\app\main.go`:         true,
+		"c:/src/app/main.go":         true,
+		`\\host\share\main.go`:       true,
+		"svc/get.go":                 false,
+		`svc\get.go`:                 false,
+		"1:/x.go":                    false,
+	} {
+		if got := isAbsPath(path); got != want {
+			t.Errorf("isAbsPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestCallPairs(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/orders", Dir: "/path"}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+	caller, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/orders", Name: "Checkout", Kind: SymbolKindFunc, File: "orders.go", Line: 10})
+	if err != nil {
+		t.Fatalf("failed to insert symbol: %v", err)
+	}
+	unlock, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/orders", Name: "unlock", Kind: SymbolKindFunc, File: "orders.go", Line: 40})
+	if err != nil {
+		t.Fatalf("failed to insert symbol: %v", err)
+	}
+	save, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/orders", Name: "save", Kind: SymbolKindFunc, File: "orders.go", Line: 50})
+	if err != nil {
+		t.Fatalf("failed to insert symbol: %v", err)
+	}
+	for _, e := range []CallEdge{
+		{CallerID: caller, CalleeID: unlock, CallerFile: "orders.go", CallerLine: 12, CallKind: CallKindDefer, Count: 1, Expr: "unlock(mu)"},
+		{CallerID: caller, CalleeID: unlock, CallerFile: "orders.go", CallerLine: 20, CallKind: CallKindStatic, Count: 2, Expr: "unlock(other)"},
+		{CallerID: caller, CalleeID: save, CallerFile: "orders.go", CallerLine: 15, CallKind: CallKindStatic, Count: 1},
+	} {
+		if err := st.InsertCallEdge(t.Context(), &e); err != nil {
+			t.Fatalf("failed to insert call edge: %v", err)
+		}
+	}
+
+	if st.HasCallPairs(t.Context()) {
+		t.Error("expected no call pairs before they are built")
+	}
+	if err := st.RebuildCallPairs(t.Context()); err != nil {
+		t.Fatalf("RebuildCallPairs failed: %v", err)
+	}
+	if !st.HasCallPairs(t.Context()) {
+		t.Error("expected call pairs after rebuilding them")
+	}
+
+	pairs, err := st.GetCallPairs(t.Context(), caller)
+	if err != nil {
+		t.Fatalf("GetCallPairs failed: %v", err)
+	}
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %+v", pairs)
+	}
+	// The deferred site is passed over as the representative
+	if p := pairs[1]; p.Symbol.ID != unlock || p.Count != 3 || p.Callsites != 2 || p.CallerLine != 20 || p.CallKind != CallKindStatic || p.Expr != "unlock(other)" {
+		t.Errorf("unexpected pair for unlock %+v", p)
+	}
+	if kinds := pairs[1].Kinds; len(kinds) != 2 || kinds[0] != CallKindDefer || kinds[1] != CallKindStatic {
+		t.Errorf("expected defer and static kinds, got %v", kinds)
+	}
+	if sites := pairs[1].Sites; len(sites) != 2 || sites[0].Line != 12 || sites[1].Line != 20 || filepath.Base(sites[0].File) != "orders.go" {
+		t.Errorf("expected both sites of unlock, got %+v", sites)
+	}
+
+	// Manual edges keep their pair current without a rebuild
+	if _, err := st.AddManualEdge(t.Context(), save, unlock, CallKindStatic, ""); err != nil {
+		t.Fatalf("failed to add manual edge: %v", err)
+	}
+	pairs, err = st.GetCallPairs(t.Context(), save)
+	if err != nil {
+		t.Fatalf("GetCallPairs failed: %v", err)
+	}
+	if len(pairs) != 1 || pairs[0].ResolvedBy != ResolvedManual {
+		t.Errorf("expected the manual edge as a pair, got %+v", pairs)
+	}
+	if _, err := st.DeleteManualEdge(t.Context(), save, unlock); err != nil {
+		t.Fatalf("failed to delete manual edge: %v", err)
+	}
+	if pairs, err = st.GetCallPairs(t.Context(), save); err != nil || len(pairs) != 0 {
+		t.Errorf("expected no pairs after deleting the manual edge, got %+v, %v", pairs, err)
+	}
+}
+
+func TestGetReachableCallees(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	ids := map[string]SymbolID{}
+	for _, sym := range []Symbol{
+		{PkgPath: "example.com/shop/api", Name: "Handle", Kind: SymbolKindFunc, File: "api.go", Line: 1},
+		{PkgPath: "example.com/shop/service", Name: "Place", Kind: SymbolKindFunc, File: "service.go", Line: 1},
+		{PkgPath: "example.com/shop/repo", Name: "Save", Kind: SymbolKindFunc, File: "repo.go", Line: 1},
+		{PkgPath: "database/sql", Name: "Exec", Kind: SymbolKindFunc, File: "sql.go", Line: 1},
+	} {
+		if err := st.InsertPackage(t.Context(), &Package{PkgPath: sym.PkgPath, Dir: "/path"}); err != nil {
+			t.Fatalf("failed to insert package: %v", err)
+		}
+		id, err := st.InsertSymbol(t.Context(), &sym)
+		if err != nil {
+			t.Fatalf("failed to insert symbol: %v", err)
+		}
+		ids[sym.Name] = id
+	}
+	for _, e := range []CallEdge{
+		{CallerID: ids["Handle"], CalleeID: ids["Place"], CallerFile: "api.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+		{CallerID: ids["Place"], CalleeID: ids["Save"], CallerFile: "service.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+		{CallerID: ids["Save"], CalleeID: ids["Exec"], CallerFile: "repo.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+	} {
+		if err := st.InsertCallEdge(t.Context(), &e); err != nil {
+			t.Fatalf("failed to insert call edge: %v", err)
+		}
+	}
+	if err := st.InsertTag(t.Context(), &Tag{SymbolID: ids["Save"], Tag: "io:db", Reason: "calls database/sql"}); err != nil {
+		t.Fatalf("failed to insert tag: %v", err)
+	}
+
+	tree, err := st.GetReachableCallees(t.Context(), ids["Handle"], ReachQuery{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("GetReachableCallees failed: %v", err)
+	}
+	if len(tree.Callees) != 2 {
+		t.Fatalf("expected Handle and Place expanded at depth 2, got %+v", tree.Callees)
+	}
+	place := tree.Callees[ids["Place"]]
+	if len(place) != 1 || place[0].Symbol.ID != ids["Save"] || len(place[0].Tags) != 1 || place[0].Tags[0].Tag != "io:db" {
+		t.Errorf("expected Place to call Save with its tags, got %+v", place)
+	}
+
+	// Save is expanded, without callees once Exec is hidden as stdlib
+	tree, err = st.GetReachableCallees(t.Context(), ids["Handle"], ReachQuery{MaxDepth: 5, SkipStdlib: true})
+	if err != nil {
+		t.Fatalf("GetReachableCallees failed: %v", err)
+	}
+	if _, ok := tree.Callees[ids["Save"]]; !ok || len(tree.Callees) != 3 {
+		t.Errorf("expected Handle, Place, and Save expanded, got %+v", tree.Callees)
+	}
+
+	tree, err = st.GetReachableCallees(t.Context(), ids["Handle"], ReachQuery{MaxDepth: 5, SkipStdlib: true, KeepStdlib: []string{"database/*"}})
+	if err != nil {
+		t.Fatalf("GetReachableCallees failed: %v", err)
+	}
+	if save := tree.Callees[ids["Save"]]; len(save) != 1 || save[0].Symbol.ID != ids["Exec"] {
+		t.Errorf("expected allowlisted Exec kept under Save, got %+v", save)
+	}
+
+	tree, err = st.GetReachableCallees(t.Context(), ids["Handle"], ReachQuery{MaxDepth: 5, StopAtIO: true})
+	if err != nil {
+		t.Fatalf("GetReachableCallees failed: %v", err)
+	}
+	if _, ok := tree.Callees[ids["Save"]]; ok {
+		t.Errorf("expected expansion to stop at the I/O function, got %+v", tree.Callees)
+	}
+
+	tree, err = st.GetReachableCallees(t.Context(), ids["Handle"], ReachQuery{MaxDepth: 5, StopPrefixes: []string{"example.com/shop/serv"}})
+	if err != nil {
+		t.Fatalf("GetReachableCallees failed: %v", err)
+	}
+	if len(tree.Callees) != 1 {
+		t.Errorf("expected expansion to stop at the service package, got %+v", tree.Callees)
+	}
+}
+
+func TestSharedIndexRepos(t *testing.T) {
+	st, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+	ctx := t.Context()
+
+	// insertLib indexes the lib repository, which api calls into
+	insertLib := func() SymbolID {
+		t.Helper()
+		if err := st.InsertPackage(ctx, &Package{PkgPath: "example.com/lib/users", Module: "example.com/lib", Repo: "lib"}); err != nil {
+			t.Fatal(err)
+		}
+		id, err := st.InsertSymbol(ctx, &Symbol{PkgPath: "example.com/lib/users", Name: "Find", Kind: SymbolKindMethod, RecvType: "*Repo", File: "users/repo.go", Line: 12, Repo: "lib"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	find := insertLib()
+
+	if err := st.InsertPackage(ctx, &Package{PkgPath: "example.com/api/handlers", Module: "example.com/api", Repo: "api"}); err != nil {
+		t.Fatal(err)
+	}
+	handle, err := st.InsertSymbol(ctx, &Symbol{PkgPath: "example.com/api/handlers", Name: "Handle", Kind: SymbolKindFunc, File: "handlers/h.go", Line: 5, Repo: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := st.BeginBatch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.InsertExternalCall(ctx, &ExternalCall{
+		CallerID: handle, Module: "example.com/lib", PkgPath: "example.com/lib/users", Func: "example.com/lib/users.Repo.Find",
+		Name: "Find", RecvType: "*Repo", CallKind: CallKindStatic, CallerFile: "handlers/h.go", CallerLine: 7,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	calleesOf := func() []CalleeInfo {
+		t.Helper()
+		callees, err := st.GetCallees(ctx, handle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return callees
+	}
+
+	n, err := st.ResolveCrossRepoEdges(ctx)
+	if err != nil {
+		t.Fatalf("ResolveCrossRepoEdges failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 cross-repo edge, got %d", n)
+	}
+	if callees := calleesOf(); len(callees) != 1 || callees[0].Symbol.ID != find || callees[0].ResolvedBy != ResolvedSSAStatic {
+		t.Errorf("expected api's Handle to call lib's Find, got %+v", callees)
+	}
+
+	repos, err := st.GetRepos(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 2 || repos[0].Name != "api" || repos[1].Name != "lib" || repos[1].SymbolCount != 1 ||
+		!reflect.DeepEqual(repos[1].Modules, []string{"example.com/lib"}) {
+		t.Errorf("unexpected repos %+v", repos)
+	}
+
+	// Clearing lib leaves api, and its recorded call, in place
+	if err := st.ClearRepo(ctx, "lib"); err != nil {
+		t.Fatalf("ClearRepo failed: %v", err)
+	}
+	if _, err := st.GetSymbolByID(ctx, find); err == nil {
+		t.Error("expected lib's symbols to be removed")
+	}
+	if sym, err := st.GetSymbolByID(ctx, handle); err != nil || sym.Repo != "api" {
+		t.Errorf("expected api's symbols to remain, got %+v, %v", sym, err)
+	}
+	if callees := calleesOf(); len(callees) != 0 {
+		t.Errorf("expected the edge into lib to be removed, got %+v", callees)
+	}
+
+	// Re-indexing lib restores the edge from the recorded call
+	find = insertLib()
+	if n, err := st.ResolveCrossRepoEdges(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 restored edge, got %d, %v", n, err)
+	}
+	if callees := calleesOf(); len(callees) != 1 || callees[0].Symbol.ID != find {
+		t.Errorf("expected the edge to lib's new Find, got %+v", callees)
+	}
+}
+
+func TestGetRouteConflicts(t *testing.T) {
+	st, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	if err := st.InsertPackage(t.Context(), &Package{PkgPath: "myapp/handlers", Dir: "/handlers"}); err != nil {
+		t.Fatal(err)
+	}
+	handler, err := st.InsertSymbol(t.Context(), &Symbol{PkgPath: "myapp/handlers", Name: "Handle", Kind: SymbolKindFunc, File: "h.go", Line: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []struct{ method, path string }{
+		{"GET", "/users/{id}"},
+		{"GET", "/users/:uid"}, // Duplicates the first
+		{"GET", "/users/me"},   // Overlaps both
+		{"POST", "/users/me"},
+		{"ANY", "POST /users/{id}"}, // Overlaps POST /users/me
+		{"GET", "/users/"},
+		{"GET", "/static/*filepath"},
+		{"ANY", "/static/css"}, // Overlaps the catch-all on GET
+		{"GET", "/orders/{id}"},
+		{"GET", "/"},                       // The root is no subtree
+		{"ANY", "GET a.example/orders/me"}, // Overlaps GET /orders/{id}
+		{"ANY", "GET b.example/orders/me"}, // But not the same path on another host
+		{"GET", "/assets/"},
+		{"GET", "/assets/app.js"}, // Below the subtree
+		{"GET", "/docs/{$}"},
+		{"GET", "/docs/index"}, // {$} matches the slash alone
+	} {
+		ep := &Entrypoint{Type: EntrypointHTTP, Label: r.method + " " + r.path, SymbolID: handler,
+			MetaJSON: fmt.Sprintf(`{"method":%q,"path":%q}`, r.method, r.path)}
+		if _, err := st.InsertEntrypoint(t.Context(), ep); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Found by signature, without a path
+	if _, err := st.InsertEntrypoint(t.Context(), &Entrypoint{Type: EntrypointHTTP, Label: "Handle", SymbolID: handler,
+		MetaJSON: `{"method":"ANY","path":""}`, DiscoveryMethod: "signature"}); err != nil {
+		t.Fatal(err)
+	}
+
+	conflicts, err := st.GetRouteConflicts(t.Context())
+	if err != nil {
+		t.Fatalf("GetRouteConflicts failed: %v", err)
+	}
+	var got []string
+	for _, c := range conflicts {
+		got = append(got, fmt.Sprintf("%s %s: %s | %s", c.Kind, c.Method, c.A.Label, c.B.Label))
+	}
+	want := []string{
+		"duplicate GET: GET /users/{id} | GET /users/:uid",
+		"overlap GET: GET /assets/ | GET /assets/app.js",
+		"overlap GET: GET /orders/{id} | ANY GET a.example/orders/me",
+		"overlap GET: GET /orders/{id} | ANY GET b.example/orders/me",
+		"overlap GET: GET /static/*filepath | ANY /static/css",
+		"overlap GET: GET /users/:uid | GET /users/",
+		"overlap GET: GET /users/:uid | GET /users/me",
+		"overlap GET: GET /users/me | GET /users/",
+		"overlap GET: GET /users/{id} | GET /users/",
+		"overlap GET: GET /users/{id} | GET /users/me",
+		"overlap POST: POST /users/me | ANY POST /users/{id}",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected conflicts:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestGetEntrypointLayers(t *testing.T) {
+	st, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	for _, p := range []Package{
+		{PkgPath: "myapp/handlers", Layer: "handler"},
+		{PkgPath: "myapp/service", Layer: "service"},
+		{PkgPath: "myapp/store", Layer: "store"},
+		{PkgPath: "myapp/util"},
+	} {
+		if err := st.InsertPackage(t.Context(), &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := make(map[string]SymbolID)
+	for _, sym := range []Symbol{
+		{PkgPath: "myapp/handlers", Name: "GetUser"},
+		{PkgPath: "myapp/handlers", Name: "ListUsers"},
+		{PkgPath: "myapp/service", Name: "Get", RecvType: "*Users"},
+		{PkgPath: "myapp/util", Name: "Format"},
+		{PkgPath: "myapp/store", Name: "FindUser"},
+	} {
+		sym.Kind, sym.File, sym.Line = SymbolKindFunc, "x.go", 1
+		id, err := st.InsertSymbol(t.Context(), &sym)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[sym.Name] = id
+	}
+	// GetUser goes through the service and an unlayered helper to the store,
+	// which calls back into the service; ListUsers calls the store directly
+	for _, e := range [][2]string{
+		{"GetUser", "Get"}, {"Get", "Format"}, {"Format", "FindUser"}, {"FindUser", "Get"}, {"ListUsers", "FindUser"},
+	} {
+		if err := st.InsertCallEdge(t.Context(), &CallEdge{CallerID: ids[e[0]], CalleeID: ids[e[1]], CallKind: CallKindStatic, Count: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for label, handler := range map[string]string{"GET /users/{id}": "GetUser", "GET /users": "ListUsers"} {
+		if _, err := st.InsertEntrypoint(t.Context(), &Entrypoint{Type: EntrypointHTTP, Label: label, SymbolID: ids[handler]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	layers, err := st.GetEntrypointLayers(t.Context())
+	if err != nil {
+		t.Fatalf("GetEntrypointLayers failed: %v", err)
+	}
+	if len(layers) != 2 {
+		t.Fatalf("expected 2 entrypoints, got %d", len(layers))
+	}
+
+	list, get := layers[0], layers[1]
+	if get.Entrypoint.Label != "GET /users/{id}" || get.MaxDepth != 3 || get.Transitions != 2 ||
+		!reflect.DeepEqual(get.Path, []string{"handler", "service", "store"}) {
+		t.Errorf("unexpected layers for GetUser: %+v", get)
+	}
+	// Calls through the unlayered helper aren't crossings
+	if len(get.Crossings) != 2 || get.Crossings[0].Caller != "myapp/handlers.GetUser" || get.Crossings[0].Callee != "myapp/service.(*Users).Get" ||
+		get.Crossings[1].CallerLayer != "store" || get.Crossings[1].CalleeLayer != "service" {
+		t.Errorf("unexpected crossings for GetUser: %+v", get.Crossings)
+	}
+	if list.Entrypoint.Label != "GET /users" || list.MaxDepth != 3 || list.Transitions != 2 ||
+		!reflect.DeepEqual(list.Path, []string{"handler", "store", "service"}) {
+		t.Errorf("unexpected layers for ListUsers: %+v", list)
+	}
+	if len(list.Crossings) != 2 || list.Crossings[0].CallerLayer != "handler" || list.Crossings[0].CalleeLayer != "store" {
+		t.Errorf("unexpected crossings for ListUsers: %+v", list.Crossings)
+	}
+}
+
+func TestRetainSnapshot(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	for _, at := range []string{"2026-03-01T09:00:00Z", "2026-03-02T09:00:00Z", "2026-03-03T09:00:00Z"} {
+		if err := st.SetMetadata(t.Context(), "indexed_at", at); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.RetainSnapshot(t.Context(), 2); err != nil {
+			t.Fatalf("RetainSnapshot failed: %v", err)
+		}
+	}
+
+	snapshots, err := ListSnapshots(st.DBPath())
+	if err != nil {
+		t.Fatalf("ListSnapshots failed: %v", err)
+	}
+	var names []string
+	for _, snap := range snapshots {
+		names = append(names, snap.Name)
+	}
+	if want := []string{"20260302T090000Z", "20260303T090000Z"}; strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("expected the 2 newest snapshots, got %v", names)
+	}
+
+	snap, err := FindSnapshot(st.DBPath(), "2026-03-02T12:00:00Z")
+	if err != nil || snap.Name != "20260302T090000Z" {
+		t.Errorf("expected the snapshot of March 2, got %+v (%v)", snap, err)
+	}
+	if _, err := FindSnapshot(st.DBPath(), "../index"); err == nil {
+		t.Error("expected an unknown snapshot name to be rejected")
+	}
+}
+
+func TestRetainSnapshotRepoDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := OpenFile(filepath.Join(tmpDir, ".flowlens", "index.db"), "")
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	repoDir := filepath.Join(tmpDir, "billing")
+	if err := st.SetRepoDir(t.Context(), "billing", repoDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetMetadata(t.Context(), "indexed_at", "2026-03-01T09:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := st.RetainSnapshot(t.Context(), 1)
+	if err != nil {
+		t.Fatalf("RetainSnapshot failed: %v", err)
+	}
+
+	view, err := OpenReadOnly(snap.Path, "")
+	if err != nil {
+		t.Fatalf("failed to open snapshot: %v", err)
+	}
+	defer view.Close()
+	if got := view.RepoRoot(t.Context(), "billing"); got != repoDir {
+		t.Errorf("expected the snapshot to resolve billing to %s, got %s", repoDir, got)
+	}
+	if got := st.RepoRoot(t.Context(), "billing"); got != repoDir {
+		t.Errorf("expected the index to still resolve billing to %s, got %s", repoDir, got)
+	}
+}
+
+func TestOpenEncrypted(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), ".flowlens", "index.db")
+	key := []byte(strings.Repeat("k", 32))
+
+	st, err := OpenEncrypted(dbPath, "", key, false)
+	if err != nil {
+		t.Fatalf("OpenEncrypted failed: %v", err)
+	}
+	if err := st.SetMetadata(t.Context(), "indexed_at", "2026-03-01T09:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(dbPath); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0600) {
+		t.Errorf("expected the decrypted index to be private, got %v (%v)", info.Mode(), err)
+	}
+
+	// The decrypted index stays while another connection has it open
+	reader, err := OpenEncrypted(dbPath, "", key, true)
+	if err != nil {
+		t.Fatalf("OpenEncrypted read-only failed: %v", err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Errorf("expected the index to stay decrypted while read: %v", err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("expected the decrypted index to be removed, got %v", err)
+	}
+	data, err := os.ReadFile(EncryptedPath(dbPath))
+	if err != nil || strings.Contains(string(data), "SQLite format") || strings.Contains(string(data), "indexed_at") {
+		t.Errorf("expected an encrypted index, got %d bytes (%v)", len(data), err)
+	}
+	if !Exists(dbPath) {
+		t.Error("expected the encrypted index to exist")
+	}
+
+	st, err = OpenEncrypted(dbPath, "", key, false)
+	if err != nil {
+		t.Fatalf("reopening failed: %v", err)
+	}
+	if at, err := st.GetMetadata(t.Context(), "indexed_at"); err != nil || at != "2026-03-01T09:00:00Z" {
+		t.Errorf("expected the index to survive encryption, got %q (%v)", at, err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if _, err := OpenEncrypted(dbPath, "", []byte(strings.Repeat("x", 32)), false); err == nil {
+		t.Error("expected a wrong key to be rejected")
+	}
+	if _, err := OpenEncrypted(dbPath, "", nil, false); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("expected ErrEncrypted without a key, got %v", err)
+	}
+
+	// A store whose index another one replaced, encrypted, and removed
+	// decrypts the new index on Reopen
+	reader, err = OpenEncrypted(dbPath, "", key, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err = OpenEncrypted(dbPath, "", key, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpPath := dbPath + ".tmp"
+	if err := st.CopyTo(t.Context(), tmpPath); err != nil {
+		t.Fatal(err)
+	}
+	work, err := OpenFile(tmpPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := work.SetMetadata(t.Context(), "indexed_at", "2026-03-02T09:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	work.Close()
+	if err := st.ReplaceWith(tmpPath); err != nil {
+		t.Fatalf("ReplaceWith failed: %v", err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if reopened, err := reader.Reopen(); err != nil || !reopened {
+		t.Fatalf("expected the reader to reopen the new index, got %v (%v)", reopened, err)
+	}
+	if at, err := reader.GetMetadata(t.Context(), "indexed_at"); err != nil || at != "2026-03-02T09:00:00Z" {
+		t.Errorf("expected the new index, got %q (%v)", at, err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("expected the decrypted index to be removed, got %v", err)
+	}
+
+	// Chunk boundaries, and files cut short
+	for _, size := range []int{0, encChunkSize, 2*encChunkSize + 5} {
+		src := filepath.Join(t.TempDir(), "plain")
+		if err := os.WriteFile(src, []byte(strings.Repeat("a", size)), 0600); err != nil {
+			t.Fatal(err)
+		}
+		enc := filepath.Join(t.TempDir(), "enc")
+		f, err := os.Create(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := encryptFile(src, f, key); err != nil {
+			t.Fatalf("encryptFile failed: %v", err)
+		}
+		f.Close()
+		var out strings.Builder
+		if err := decryptFile(enc, &out, key); err != nil || out.Len() != size {
+			t.Errorf("size %d: expected a round trip, got %d bytes (%v)", size, out.Len(), err)
+		}
+		if info, err := os.Stat(enc); err == nil && size > 0 {
+			os.Truncate(enc, info.Size()-int64(encChunkSize/2))
+			if err := decryptFile(enc, &out, key); err == nil {
+				t.Errorf("size %d: expected a truncated file to be rejected", size)
+			}
+		}
+	}
+}
+
+func TestLoadCallGraph(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := Open(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	ids := map[string]SymbolID{}
+	for _, sym := range []Symbol{
+		{PkgPath: "example.com/shop/api", Name: "Handle", Kind: SymbolKindFunc, File: "api.go", Line: 1},
+		{PkgPath: "example.com/shop/service", Name: "Place", Kind: SymbolKindFunc, File: "service.go", Line: 1},
+		{PkgPath: "example.com/shop/repo", Name: "Save", Kind: SymbolKindFunc, File: "repo.go", Line: 1},
+	} {
+		if err := st.InsertPackage(t.Context(), &Package{PkgPath: sym.PkgPath, Dir: "/path"}); err != nil {
+			t.Fatalf("failed to insert package: %v", err)
+		}
+		id, err := st.InsertSymbol(t.Context(), &sym)
+		if err != nil {
+			t.Fatalf("failed to insert symbol: %v", err)
+		}
+		ids[sym.Name] = id
+	}
+	for _, e := range []CallEdge{
+		{CallerID: ids["Handle"], CalleeID: ids["Save"], CallerFile: "api.go", CallerLine: 3, CallKind: CallKindDefer, Count: 1},
+		{CallerID: ids["Handle"], CalleeID: ids["Place"], CallerFile: "api.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+		{CallerID: ids["Handle"], CalleeID: ids["Place"], CallerFile: "api.go", CallerLine: 4, CallKind: CallKindStatic, Count: 2},
+		{CallerID: ids["Place"], CalleeID: ids["Save"], CallerFile: "service.go", CallerLine: 2, CallKind: CallKindStatic, Count: 1},
+	} {
+		if err := st.InsertCallEdge(t.Context(), &e); err != nil {
+			t.Fatalf("failed to insert call edge: %v", err)
+		}
+	}
+	if err := st.InsertTag(t.Context(), &Tag{SymbolID: ids["Save"], Tag: "io:db", Reason: "calls database/sql"}); err != nil {
+		t.Fatalf("failed to insert tag: %v", err)
+	}
+
+	// Without call pairs the graph holds call edges, as GetCallees lists them
+	g, err := st.LoadCallGraph(t.Context())
+	if err != nil {
+		t.Fatalf("LoadCallGraph failed: %v", err)
+	}
+	if g.Pairs || g.Edges != 4 || len(g.Symbols) != 3 {
+		t.Errorf("expected 3 symbols and 4 call edges, got %d symbols, %d edges, pairs %v", len(g.Symbols), g.Edges, g.Pairs)
+	}
+	if want, _ := st.GetCallees(t.Context(), ids["Handle"]); !reflect.DeepEqual(g.Callees[ids["Handle"]], want) {
+		t.Errorf("expected Handle's callees as GetCallees lists them:\n got %+v\nwant %+v", g.Callees[ids["Handle"]], want)
+	}
+
+	if err := st.RebuildCallPairs(t.Context()); err != nil {
+		t.Fatalf("RebuildCallPairs failed: %v", err)
+	}
+	if g, err = st.LoadCallGraph(t.Context()); err != nil {
+		t.Fatalf("LoadCallGraph failed: %v", err)
+	}
+	if !g.Pairs || g.Edges != 3 {
+		t.Errorf("expected 3 call pairs, got %d (pairs %v)", g.Edges, g.Pairs)
+	}
+	for _, name := range []string{"Handle", "Place", "Save"} {
+		want, err := st.GetCallPairs(t.Context(), ids[name])
+		if err != nil {
+			t.Fatalf("GetCallPairs failed: %v", err)
+		}
+		if got := g.Callees[ids[name]]; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %s's callees as GetCallPairs lists them:\n got %+v\nwant %+v", name, got, want)
+		}
+		if want, _ := st.GetCallees(t.Context(), ids[name]); !reflect.DeepEqual(g.Calls[ids[name]], want) {
+			t.Errorf("expected %s's calls as GetCallees lists them:\n got %+v\nwant %+v", name, g.Calls[ids[name]], want)
+		}
+		if want, _ := st.GetCallers(t.Context(), ids[name]); !reflect.DeepEqual(g.Callers[ids[name]], want) {
+			t.Errorf("expected %s's callers as GetCallers lists them:\n got %+v\nwant %+v", name, g.Callers[ids[name]], want)
+		}
+	}
+	if sym, tags := g.SymbolAndTags(ids["Save"]); sym == nil || sym.Name != "Save" || len(tags) != 1 || tags[0].Tag != "io:db" {
+		t.Errorf("expected Save with its io:db tag, got %+v %+v", sym, tags)
+	}
+}
@@ -0,0 +1,255 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pools are the connections a Store opened on its database file.
+type pools struct {
+	write *sql.DB     // Write connection
+	read  *sql.DB     // Read-only connection pool
+	file  os.FileInfo // Database file the pools were opened on
+}
+
+func (p *pools) close() error {
+	readErr := p.read.Close()
+	if p.write == p.read {
+		return readErr
+	}
+	if err := p.write.Close(); err != nil {
+		return err
+	}
+	return readErr
+}
+
+// livePools holds a store's current pools. They are replaced when the
+// database file is swapped for a new one (see Reopen).
+type livePools struct {
+	mu sync.RWMutex
+	p  *pools
+}
+
+func (l *livePools) get() *pools {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.p
+}
+
+// writeQuerier runs queries on the current write connection.
+type writeQuerier struct{ l *livePools }
+
+func (q writeQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return q.l.get().write.ExecContext(ctx, query, args...)
+}
+
+func (q writeQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return q.l.get().write.QueryContext(ctx, query, args...)
+}
+
+func (q writeQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return q.l.get().write.QueryRowContext(ctx, query, args...)
+}
+
+// readQuerier runs queries on the current read pool.
+type readQuerier struct{ l *livePools }
+
+func (q readQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return q.l.get().read.ExecContext(ctx, query, args...)
+}
+
+func (q readQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return q.l.get().read.QueryContext(ctx, query, args...)
+}
+
+func (q readQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return q.l.get().read.QueryRowContext(ctx, query, args...)
+}
+
+// CopyTo writes a consistent copy of the database to path, which must not
+// exist, e.g. to build a new index from the current one. The copy gets the
+// database's permissions, so one of an encrypted index stays private too.
+func (s *Store) CopyTo(ctx context.Context, path string) error {
+	if info, err := os.Stat(s.dbPath); err == nil {
+		// VACUUM INTO fills an empty file
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("copying database to %s: %w", path, err)
+		}
+		f.Close()
+	}
+	if _, err := s.pools.get().write.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("copying database to %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReplaceWith atomically replaces the database file with the closed
+// database at path, then reopens the store on it. The current database's
+// WAL is checkpointed and emptied first, so connections opening the new file
+// don't replay the old file's changes onto it; ReplaceWith fails if readers
+// in other connections keep the WAL from being emptied within the busy
+// timeout. A write committed by another process between the checkpoint and
+// the rename can still land in the old WAL, so the caller must keep such
+// writes brief and rare, e.g. only user annotations.
+func (s *Store) ReplaceWith(path string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if s.run != nil {
+		return fmt.Errorf("cannot replace the database during a run")
+	}
+	if err := s.emptyWAL(); err != nil {
+		return err
+	}
+	if err := os.Rename(path, s.dbPath); err != nil {
+		return fmt.Errorf("replacing %s: %w", s.dbPath, err)
+	}
+	_, err := s.Reopen()
+	return err
+}
+
+// emptyWAL checkpoints the whole WAL into the database file and truncates
+// it. The checkpoint reports busy rather than failing while other
+// connections are still reading frames it would discard, so it is retried
+// until they finish or the busy timeout passes.
+func (s *Store) emptyWAL() error {
+	deadline := time.Now().Add(busyTimeoutMs * time.Millisecond)
+	for {
+		var busy, logFrames, checkpointed int
+		err := s.pools.get().write.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed)
+		if err != nil {
+			return fmt.Errorf("checkpointing %s: %w", s.dbPath, err)
+		}
+		if busy == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("checkpointing %s: database is busy (%d of %d WAL frames checkpointed)", s.dbPath, checkpointed, logFrames)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Reopen reopens the store's connections if its database file was replaced,
+// e.g. by an indexing run renaming a new index into place, and reports
+// whether it was. Queries already running finish on the old file. An
+// encrypted index that the run encrypted and removed on closing it, not
+// knowing about this store's connections to the old file, is decrypted
+// again.
+func (s *Store) Reopen() (bool, error) {
+	if s.run != nil {
+		return false, nil
+	}
+	info, err := os.Stat(s.dbPath)
+	if os.IsNotExist(err) && s.key != nil {
+		if _, encErr := os.Stat(EncryptedPath(s.dbPath)); encErr == nil {
+			if err := decryptIndex(s.dbPath, s.key); err != nil {
+				return false, err
+			}
+			info, err = os.Stat(s.dbPath)
+		}
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("stat %s: %w", s.dbPath, err)
+	}
+	if os.SameFile(s.pools.get().file, info) {
+		return false, nil
+	}
+
+	s.pools.mu.Lock()
+	defer s.pools.mu.Unlock()
+	if os.SameFile(s.pools.p.file, info) {
+		return false, nil // Reopened by a concurrent call
+	}
+	p, err := openPools(s.dbPath, s.readOnly)
+	if err != nil {
+		return false, fmt.Errorf("reopening %s: %w", s.dbPath, err)
+	}
+	old := s.pools.p
+	s.pools.p = p
+	// Close waits for queries in progress, so don't hold up the caller
+	go old.close()
+	return true, nil
+}
+
+// userTables hold what users save in the index rather than what indexing
+// extracts: manual edges, bookmarks, views, and shares.
+var userTables = []string{"manual_edges", "pinned_symbols", "starred_entrypoints", "views", "shares"}
+
+// CopyUserData replaces the manual edges, bookmarks, views, and shares in s
+// with those in from, e.g. ones the UI server saved in the live index while
+// a run was building its copy. Manual call edges are removed from the call
+// graph, so ApplyManualEdges must be called afterwards.
+func (s *Store) CopyUserData(ctx context.Context, from *Store) error {
+	for _, table := range userTables {
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return fmt.Errorf("clearing %s: %w", table, err)
+		}
+		if err := copyTable(ctx, s.db, from.readDB, table); err != nil {
+			return fmt.Errorf("copying %s: %w", table, err)
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM call_edges WHERE resolved_by = ?", ResolvedManual); err != nil {
+		return fmt.Errorf("clearing manual call edges: %w", err)
+	}
+	at, err := from.GetMetadata(ctx, "manual_edges_at")
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading metadata: %w", err)
+	}
+	return s.SetMetadata(ctx, "manual_edges_at", at)
+}
+
+// copyTable inserts every row of table in src into the same table in dst.
+func copyTable(ctx context.Context, dst, src querier, table string) error {
+	rows, err := src.QueryContext(ctx, "SELECT * FROM "+table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (?%s)", table,
+		strings.Join(cols, ", "), strings.Repeat(", ?", len(cols)-1))
+
+	vals := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if _, err := dst.ExecContext(ctx, insert, vals...); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// RemoveFiles removes the database file at path and its WAL and shared
+// memory files, e.g. an unfinished index left by a failed run.
+func RemoveFiles(path string) error {
+	for _, f := range []string{path, path + "-wal", path + "-shm"} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Taint sink categories.
+const (
+	SinkExec = "exec" // Process execution
+	SinkSQL  = "sql"  // SQL query text
+	SinkFile = "file" // File creation or writes
+)
+
+// TaintFinding records a request input that reaches a sensitive sink
+// without passing a configured sanitizer.
+type TaintFinding struct {
+	EntrypointID    EntrypointID   `json:"entrypoint_id"`
+	EntrypointLabel string         `json:"entrypoint_label,omitempty"` // Filled in on read
+	EntrypointType  EntrypointType `json:"entrypoint_type,omitempty"`  // Filled in on read
+	Source          string         `json:"source"`                     // Tainted handler parameter, e.g. "r *net/http.Request"
+	Sink            string         `json:"sink"`                       // Called function, e.g. "os/exec.Command"
+	SinkCategory    string         `json:"sink_category"`              // SinkExec, SinkSQL, or SinkFile
+	File            string         `json:"file"`                       // Location of the sink call
+	Line            int            `json:"line"`
+	Path            []string       `json:"path"` // Functions from the handler to the one making the sink call
+}
+
+// InsertTaintFinding records a taint finding within the batch.
+func (b *BatchTx) InsertTaintFinding(ctx context.Context, f *TaintFinding) error {
+	path, err := json.Marshal(f.Path)
+	if err != nil {
+		return fmt.Errorf("encoding path: %w", err)
+	}
+	_, err = b.tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO taint_findings (entrypoint_id, source, sink, sink_category, file, line, path_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, f.EntrypointID, f.Source, f.Sink, f.SinkCategory, relPath(b.baseDir, f.File), f.Line, string(path))
+	return err
+}
+
+// GetTaintFindings retrieves all taint findings with their entrypoint labels,
+// ordered by entrypoint and sink location.
+func (s *Store) GetTaintFindings(ctx context.Context) ([]TaintFinding, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT t.entrypoint_id, e.label, e.type, t.source, t.sink, t.sink_category,
+		       t.file, t.line, COALESCE(t.path_json, '[]'), s.repo
+		FROM taint_findings t
+		JOIN entrypoints e ON t.entrypoint_id = e.id
+		JOIN symbols s ON e.symbol_id = s.id
+		ORDER BY e.type, e.label, t.file, t.line
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var findings []TaintFinding
+	for rows.Next() {
+		var f TaintFinding
+		var path, repo string
+		if err := rows.Scan(&f.EntrypointID, &f.EntrypointLabel, &f.EntrypointType, &f.Source,
+			&f.Sink, &f.SinkCategory, &f.File, &f.Line, &path, &repo); err != nil {
+			return nil, err
+		}
+		f.File = s.absPath(ctx, repo, f.File)
+		if err := json.Unmarshal([]byte(path), &f.Path); err != nil {
+			return nil, fmt.Errorf("decoding path: %w", err)
+		}
+		findings = append(findings, f)
+	}
+	return findings, rows.Err()
+}
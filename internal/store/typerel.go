@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Type relation kinds.
+const (
+	RelationField = "field" // Named struct field
+	RelationEmbed = "embed" // Embedded struct field or embedded interface
+)
+
+// TypeRelation is a composition link between a type and the type of one of
+// its fields or embeddings.
+type TypeRelation struct {
+	TypeID     SymbolID `json:"type_id"`             // Type holding the field
+	Field      string   `json:"field"`               // Field name; the type name for embeddings
+	Relation   string   `json:"relation"`            // RelationField or RelationEmbed
+	FieldType  string   `json:"field_type"`          // Full field type, e.g. "*database/sql.DB"
+	TargetPkg  string   `json:"target_pkg"`          // Package of the named type at the core of FieldType (empty for builtins)
+	TargetName string   `json:"target_name"`         // Name of that type, e.g. "DB"
+	TargetID   SymbolID `json:"target_id,omitempty"` // Its symbol, when it is indexed
+	Holder     *Symbol  `json:"holder,omitempty"`    // The holding type, on incoming relations
+}
+
+// InsertTypeRelation records a field or embedding of a type within the batch.
+func (b *BatchTx) InsertTypeRelation(ctx context.Context, rel *TypeRelation) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO type_relations (type_id, field, relation, field_type, target_pkg, target_name)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, rel.TypeID, rel.Field, rel.Relation, rel.FieldType, rel.TargetPkg, rel.TargetName)
+	return err
+}
+
+// GetTypeFields returns a type's fields and embeddings in declaration order.
+func (s *Store) GetTypeFields(ctx context.Context, typeID SymbolID) ([]TypeRelation, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT r.type_id, r.field, r.relation, r.field_type, r.target_pkg, r.target_name, COALESCE(t.id, 0)
+		FROM type_relations r
+		LEFT JOIN symbols t ON t.pkg_path = r.target_pkg AND t.name = r.target_name AND COALESCE(t.recv_type, '') = ''
+		WHERE r.type_id = ?
+		ORDER BY r.rowid
+	`, typeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rels []TypeRelation
+	for rows.Next() {
+		var rel TypeRelation
+		if err := rows.Scan(&rel.TypeID, &rel.Field, &rel.Relation, &rel.FieldType, &rel.TargetPkg, &rel.TargetName, &rel.TargetID); err != nil {
+			return nil, err
+		}
+		rels = append(rels, rel)
+	}
+	return rels, rows.Err()
+}
+
+// GetTypeHolders returns the relations of types that hold or embed the
+// named type pkgPath.name, ordered by holder.
+func (s *Store) GetTypeHolders(ctx context.Context, pkgPath, name string) ([]TypeRelation, error) {
+	targetID, err := s.GetSymbolID(ctx, pkgPath, name, "")
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT r.type_id, r.field, r.relation, r.field_type, r.target_pkg, r.target_name,
+		       h.pkg_path, h.name, h.kind, h.file, h.line, h.repo
+		FROM type_relations r
+		JOIN symbols h ON h.id = r.type_id
+		WHERE r.target_pkg = ? AND r.target_name = ?
+		ORDER BY h.pkg_path, h.name, r.field
+	`, pkgPath, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rels []TypeRelation
+	for rows.Next() {
+		rel := TypeRelation{TargetID: targetID, Holder: &Symbol{}}
+		h := rel.Holder
+		if err := rows.Scan(&rel.TypeID, &rel.Field, &rel.Relation, &rel.FieldType, &rel.TargetPkg, &rel.TargetName,
+			&h.PkgPath, &h.Name, &h.Kind, &h.File, &h.Line, &h.Repo); err != nil {
+			return nil, err
+		}
+		h.ID = rel.TypeID
+		h.File = s.absPath(ctx, h.Repo, h.File)
+		rels = append(rels, rel)
+	}
+	return rels, rows.Err()
+}
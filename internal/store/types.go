@@ -13,11 +13,12 @@ type EntrypointID int64
 type SymbolKind string
 
 const (
-	SymbolKindFunc   SymbolKind = "func"
-	SymbolKindMethod SymbolKind = "method"
-	SymbolKindType   SymbolKind = "type"
-	SymbolKindVar    SymbolKind = "var"
-	SymbolKindConst  SymbolKind = "const"
+	SymbolKindFunc      SymbolKind = "func"
+	SymbolKindMethod    SymbolKind = "method"
+	SymbolKindType      SymbolKind = "type"
+	SymbolKindInterface SymbolKind = "interface"
+	SymbolKindVar       SymbolKind = "var"
+	SymbolKindConst     SymbolKind = "const"
 )
 
 // CallKind represents how a call is made.
@@ -32,14 +33,26 @@ const (
 	CallKindUnknown   CallKind = "unknown"   // Dynamic dispatch, can't resolve
 )
 
+// ResolvedBy records how a call edge's callee was determined, from most to
+// least trustworthy.
+type ResolvedBy string
+
+const (
+	ResolvedSSAStatic          ResolvedBy = "ssa-static"          // Static callee from SSA
+	ResolvedInterfaceHeuristic ResolvedBy = "interface-heuristic" // Implementation matched by method name
+	ResolvedClosureTrace       ResolvedBy = "closure-trace"       // Function value traced back to its function
+	ResolvedManual             ResolvedBy = "manual"              // Declared by the user
+)
+
 // EntrypointType represents the type of entrypoint.
 type EntrypointType string
 
 const (
-	EntrypointHTTP EntrypointType = "http"
-	EntrypointGRPC EntrypointType = "grpc"
-	EntrypointCLI  EntrypointType = "cli"
-	EntrypointMain EntrypointType = "main"
+	EntrypointHTTP   EntrypointType = "http"
+	EntrypointGRPC   EntrypointType = "grpc"
+	EntrypointCLI    EntrypointType = "cli"
+	EntrypointMain   EntrypointType = "main"
+	EntrypointCustom EntrypointType = "custom" // Declared by an entrypoints rule in flowlens.yaml
 )
 
 // Symbol represents a Go symbol (function, method, type, etc.).
@@ -51,7 +64,18 @@ type Symbol struct {
 	RecvType string     `json:"recv_type,omitempty"` // For methods, the receiver type
 	File     string     `json:"file"`
 	Line     int        `json:"line"`
-	Sig      string     `json:"sig,omitempty"` // Function signature
+	Sig      string     `json:"sig,omitempty"`  // Function signature
+	Repo     string     `json:"repo,omitempty"` // Repository name when several share one index
+	Doc      string     `json:"doc,omitempty"`  // Leading doc comment, truncated; only set by GetSymbolByID
+	Value    string     `json:"value,omitempty"` // Resolved value of a constant
+	// Column, EndLine, and EndColumn complete the declaration span starting
+	// at Line; the end is exclusive. Zero when unknown; only set by
+	// GetSymbolByID.
+	Column    int `json:"column,omitempty"`
+	EndLine   int `json:"end_line,omitempty"`
+	EndColumn int `json:"end_column,omitempty"`
+	// Signature is the structured form of Sig, for functions and methods.
+	Signature *Signature `json:"signature,omitempty"`
 }
 
 // Package represents a Go package.
@@ -60,16 +84,36 @@ type Package struct {
 	Module  string `json:"module,omitempty"`
 	Dir     string `json:"dir"`
 	Layer   string `json:"layer,omitempty"` // handler, service, store, domain, or empty
+	// LayerSource records how Layer was assigned: LayerSourceConfig or LayerSourceInferred.
+	LayerSource string `json:"layer_source,omitempty"`
+	Repo        string `json:"repo,omitempty"` // Repository name when several share one index
 }
 
+// Layer sources.
+const (
+	LayerSourceConfig   = "config"   // Matched a configured layer pattern
+	LayerSourceInferred = "inferred" // Classified heuristically at index time
+)
+
 // CallEdge represents a call from one symbol to another.
 type CallEdge struct {
-	CallerID   SymbolID `json:"caller_id"`
-	CalleeID   SymbolID `json:"callee_id"`
-	CallerFile string   `json:"caller_file"`
-	CallerLine int      `json:"caller_line"`
-	CallKind   CallKind `json:"call_kind"`
-	Count      int      `json:"count"` // Number of times this call appears
+	CallerID   SymbolID   `json:"caller_id"`
+	CalleeID   SymbolID   `json:"callee_id"`
+	CallerFile string     `json:"caller_file"`
+	CallerLine int        `json:"caller_line"`
+	CallKind   CallKind   `json:"call_kind"`
+	ResolvedBy ResolvedBy `json:"resolved_by"`
+	Count      int        `json:"count"` // Number of times this call appears
+	Expr       string     `json:"expr,omitempty"` // Source of the call expression, e.g. "svc.Users.Create(ctx, req)"
+}
+
+// resolvedBy returns how the edge was resolved, treating an unset value as
+// a static SSA call.
+func (e *CallEdge) resolvedBy() ResolvedBy {
+	if e.ResolvedBy == "" {
+		return ResolvedSSAStatic
+	}
+	return e.ResolvedBy
 }
 
 // Entrypoint represents a program entrypoint.
@@ -88,3 +132,33 @@ type Tag struct {
 	Tag      string   `json:"tag"`    // e.g., "io:db", "pure", "layer:handler"
 	Reason   string   `json:"reason"` // Why this tag was applied
 }
+
+// ChangeEntity identifies what kind of indexed item changed between runs.
+type ChangeEntity string
+
+const (
+	ChangeEntitySymbol     ChangeEntity = "symbol"
+	ChangeEntityEntrypoint ChangeEntity = "entrypoint"
+	ChangeEntityEdge       ChangeEntity = "edge"
+)
+
+// ChangeKind describes how an item changed between runs.
+type ChangeKind string
+
+const (
+	ChangeAdded     ChangeKind = "added"
+	ChangeRemoved   ChangeKind = "removed"
+	ChangeRelocated ChangeKind = "relocated" // Same identity, different file/line or handler
+	ChangeMoved     ChangeKind = "moved"     // New identity (package or receiver) with the same name, kind, and signature
+)
+
+// Change is one entry in the change log of the latest indexing run.
+type Change struct {
+	Entity      ChangeEntity `json:"entity"`
+	Kind        ChangeKind   `json:"change"`
+	Key         string       `json:"key"`                    // Stable identity, e.g. "myapp/svc.(*UserService).GetUser"
+	OldKey      string       `json:"old_key,omitempty"`      // Identity in the previous index, for moved symbols
+	SymbolID    SymbolID     `json:"symbol_id,omitempty"`    // Symbol in the current index (absent for removals)
+	OldLocation string       `json:"old_location,omitempty"` // file:line, handler key, or empty
+	NewLocation string       `json:"new_location,omitempty"`
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"sort"
+)
+
+// Reasons a call site produced no call edge.
+const (
+	UnresolvedFuncval       = "funcval"        // Function value that could not be traced to a function
+	UnresolvedInterface     = "interface"      // Project interface method with no implementation found
+	UnresolvedMissingSymbol = "missing_symbol" // Static callee in a project package with no symbol
+)
+
+// UnresolvedReasons lists the unresolved-call reasons in display order.
+var UnresolvedReasons = []string{UnresolvedFuncval, UnresolvedInterface, UnresolvedMissingSymbol}
+
+// PackageUnresolved counts the unresolved calls made from one package.
+type PackageUnresolved struct {
+	PkgPath  string         `json:"pkg_path"`
+	ByReason map[string]int `json:"by_reason"`
+	Total    int            `json:"total"`
+}
+
+// SkippedFunction is a project function whose calls were all dropped because
+// it has no symbol of its own, such as an init function or a generic
+// instantiation.
+type SkippedFunction struct {
+	PkgPath string `json:"pkg_path"`
+	Name    string `json:"name"` // SSA name relative to the package, e.g. "init#1"
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Calls   int    `json:"calls"` // Call sites whose edges are missing
+}
+
+// AddUnresolvedCalls adds to a package's count of unresolved calls for a
+// reason within the batch.
+func (b *BatchTx) AddUnresolvedCalls(ctx context.Context, pkgPath, reason string, n int) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT INTO unresolved_calls (pkg_path, reason, count)
+		VALUES (?, ?, ?)
+		ON CONFLICT(pkg_path, reason) DO UPDATE SET
+			count = unresolved_calls.count + excluded.count
+	`, pkgPath, reason, n)
+	return err
+}
+
+// InsertSkippedFunction records a skipped function within the batch.
+func (b *BatchTx) InsertSkippedFunction(ctx context.Context, f *SkippedFunction) error {
+	_, err := b.tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO skipped_functions (pkg_path, name, file, line, calls)
+		VALUES (?, ?, ?, ?, ?)
+	`, f.PkgPath, f.Name, relPath(b.baseDir, f.File), f.Line, f.Calls)
+	return err
+}
+
+// GetUnresolvedCalls returns per-package unresolved call counts, packages
+// with the most unresolved calls first.
+func (s *Store) GetUnresolvedCalls(ctx context.Context) ([]PackageUnresolved, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB.QueryContext(ctx, `
+		SELECT pkg_path, reason, count FROM unresolved_calls ORDER BY pkg_path
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pkgs []PackageUnresolved
+	for rows.Next() {
+		var pkgPath, reason string
+		var count int
+		if err := rows.Scan(&pkgPath, &reason, &count); err != nil {
+			return nil, err
+		}
+		if n := len(pkgs); n == 0 || pkgs[n-1].PkgPath != pkgPath {
+			pkgs = append(pkgs, PackageUnresolved{PkgPath: pkgPath, ByReason: make(map[string]int)})
+		}
+		p := &pkgs[len(pkgs)-1]
+		p.ByReason[reason] += count
+		p.Total += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(pkgs, func(i, j int) bool { return pkgs[i].Total > pkgs[j].Total })
+	return pkgs, nil
+}
+
+// GetSkippedFunctions returns the skipped functions, optionally of one
+// package, ordered by location.
+func (s *Store) GetSkippedFunctions(ctx context.Context, pkgPath string) ([]SkippedFunction, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT sf.pkg_path, sf.name, sf.file, sf.line, sf.calls, COALESCE(p.repo, '')
+		FROM skipped_functions sf
+		LEFT JOIN packages p ON p.pkg_path = sf.pkg_path
+	`
+	var args []interface{}
+	if pkgPath != "" {
+		query += " WHERE sf.pkg_path = ?"
+		args = append(args, pkgPath)
+	}
+	query += " ORDER BY sf.pkg_path, sf.file, sf.line"
+
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var funcs []SkippedFunction
+	for rows.Next() {
+		var f SkippedFunction
+		var repo string
+		if err := rows.Scan(&f.PkgPath, &f.Name, &f.File, &f.Line, &f.Calls, &repo); err != nil {
+			return nil, err
+		}
+		f.File = s.absPath(ctx, repo, f.File)
+		funcs = append(funcs, f)
+	}
+	return funcs, rows.Err()
+}
+
+// getUnresolvedTotals returns unresolved call counts by reason, with every
+// reason present, and the number of skipped functions.
+func (s *Store) getUnresolvedTotals(ctx context.Context) (map[string]int, int, error) {
+	totals := make(map[string]int, len(UnresolvedReasons))
+	for _, r := range UnresolvedReasons {
+		totals[r] = 0
+	}
+
+	rows, err := s.readDB.QueryContext(ctx, "SELECT reason, SUM(count) FROM unresolved_calls GROUP BY reason")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var reason string
+		var count int
+		if err := rows.Scan(&reason, &count); err != nil {
+			return nil, 0, err
+		}
+		totals[reason] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var skipped int
+	if err := s.readDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM skipped_functions").Scan(&skipped); err != nil {
+		return nil, 0, err
+	}
+	return totals, skipped, nil
+}
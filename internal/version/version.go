@@ -0,0 +1,73 @@
+// Package version reports build information for the flowlens binary.
+//
+// Version, Commit and BuildDate are injected at build time:
+//
+//	go build -ldflags "-X github.com/abramin/flowlens/internal/version.Version=v0.3.0 \
+//	  -X github.com/abramin/flowlens/internal/version.Commit=$(git rev-parse HEAD)"
+//
+// Without ldflags they fall back to the module and VCS info embedded by the Go toolchain.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/abramin/flowlens/internal/store"
+)
+
+// Set via -ldflags "-X ...".
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version       string `json:"version"`
+	Commit        string `json:"commit"`
+	BuildDate     string `json:"build_date,omitempty"`
+	GoVersion     string `json:"go_version"`
+	SchemaVersion int    `json:"schema_version"` // Index schema this binary reads and writes
+}
+
+// Get returns the build information for the running binary.
+func Get() Info {
+	info := Info{
+		Version:       Version,
+		Commit:        Commit,
+		BuildDate:     BuildDate,
+		GoVersion:     runtime.Version(),
+		SchemaVersion: store.SchemaVersion,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			case "vcs.modified":
+				if s.Value == "true" && Commit == "" && info.Commit != "" {
+					info.Commit += "-dirty"
+				}
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
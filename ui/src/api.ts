@@ -1,11 +1,11 @@
-import type { Entrypoint, GraphResponse, GraphFilter, Stats, Symbol, Tag, SymbolDetails, SpineResponse, CFGInfo } from './types';
+import type { Entrypoint, GraphResponse, GraphFilter, GraphStreamEvent, Stats, Symbol, Tag, SymbolDetails, SpineResponse, CFGInfo, Bookmark, BookmarkKind, SavedView, InterfaceSummary, InterfaceDetails, TypeRelations, Reference, ErrorChain, EntrypointTree } from './types';
 
 const API_BASE = '/api';
 
-async function fetchJSON<T>(url: string): Promise<T> {
+async function fetchJSON<T>(url: string, init?: RequestInit): Promise<T> {
   let response: Response;
   try {
-    response = await fetch(url);
+    response = await fetch(url, init);
   } catch (err) {
     // Network error (server not running, CORS, etc.)
     const message = err instanceof Error ? err.message : 'Network error';
@@ -21,6 +21,10 @@ async function fetchJSON<T>(url: string): Promise<T> {
       if (errorJson.error) {
         errorMessage = errorJson.error;
       }
+      // Graph size guard (422) includes filters that would shrink the graph
+      if (Array.isArray(errorJson.suggestions) && errorJson.suggestions.length > 0) {
+        errorMessage = `${errorMessage}. Try: ${errorJson.suggestions.join(', ')}`;
+      }
     } catch {
       // Response wasn't JSON, include raw text if short
       if (text.length < 200) {
@@ -30,6 +34,10 @@ async function fetchJSON<T>(url: string): Promise<T> {
     throw new Error(errorMessage);
   }
 
+  if (response.status === 204) {
+    return undefined as T;
+  }
+
   try {
     return await response.json();
   } catch {
@@ -50,10 +58,18 @@ export async function getEntrypoints(type?: string, query?: string): Promise<Ent
   return fetchJSON<Entrypoint[]>(url);
 }
 
+export async function getEntrypointTree(): Promise<EntrypointTree> {
+  return fetchJSON<EntrypointTree>(`${API_BASE}/entrypoints?view=tree`);
+}
+
 export async function getEntrypointById(id: number): Promise<Entrypoint> {
   return fetchJSON<Entrypoint>(`${API_BASE}/entrypoints/${id}`);
 }
 
+export async function getEntrypointErrors(id: number): Promise<ErrorChain> {
+  return fetchJSON<ErrorChain>(`${API_BASE}/entrypoints/${id}/errors`);
+}
+
 export async function getEntrypointBySymbolId(symbolId: number): Promise<Entrypoint | null> {
   const entrypoints = await getEntrypoints();
   return entrypoints.find((e) => e.symbol_id === symbolId) ?? null;
@@ -63,6 +79,31 @@ export async function getSymbol(id: number): Promise<SymbolDetails> {
   return fetchJSON<SymbolDetails>(`${API_BASE}/symbol/${id}`);
 }
 
+export async function getReferences(id: number): Promise<Reference[]> {
+  const resp = await fetchJSON<{ references: Reference[] }>(`${API_BASE}/symbol/${id}/references`);
+  return resp.references;
+}
+
+export async function getInterfaces(pkg?: string): Promise<InterfaceSummary[]> {
+  const params = new URLSearchParams();
+  if (pkg) params.set('package', pkg);
+  const resp = await fetchJSON<{ interfaces: InterfaceSummary[] }>(`${API_BASE}/interfaces?${params}`);
+  return resp.interfaces;
+}
+
+export async function getInterface(id: number): Promise<InterfaceDetails> {
+  return fetchJSON<InterfaceDetails>(`${API_BASE}/interfaces/${id}`);
+}
+
+export async function getTypeRelations(id: number): Promise<TypeRelations> {
+  return fetchJSON<TypeRelations>(`${API_BASE}/types/${id}/relations`);
+}
+
+// Types holding or embedding any named type, e.g. "database/sql.DB"
+export async function getTypeHolders(target: string): Promise<TypeRelations> {
+  return fetchJSON<TypeRelations>(`${API_BASE}/types/relations?${new URLSearchParams({ target })}`);
+}
+
 export async function getGraphRoot(
   symbolId: number,
   depth?: number,
@@ -93,6 +134,47 @@ export async function getGraphExpand(
   return fetchJSON<GraphResponse>(url);
 }
 
+// streamGraph reads the NDJSON graph stream, calling onEvent for each node and
+// edge as the server discovers them. Resolves with the final summary event.
+export async function streamGraph(
+  symbolId: number,
+  onEvent: (event: GraphStreamEvent) => void,
+  depth?: number,
+  filters?: GraphFilter,
+  signal?: AbortSignal
+): Promise<GraphStreamEvent> {
+  const params = new URLSearchParams();
+  if (depth) params.set('depth', depth.toString());
+  if (filters) params.set('filters', JSON.stringify(filters));
+  const queryString = params.toString();
+  const url = queryString
+    ? `${API_BASE}/graph/stream/${symbolId}?${queryString}`
+    : `${API_BASE}/graph/stream/${symbolId}`;
+
+  const response = await fetch(url, { signal });
+  if (!response.ok || !response.body) {
+    throw new Error(`HTTP ${response.status}: ${response.statusText}`);
+  }
+
+  const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+  let buffered = '';
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) break;
+    buffered += value;
+    const lines = buffered.split('\n');
+    buffered = lines.pop() ?? '';
+    for (const line of lines) {
+      if (!line) continue;
+      const event = JSON.parse(line) as GraphStreamEvent;
+      if (event.type === 'error') throw new Error(event.error);
+      if (event.type === 'done') return event;
+      onEvent(event);
+    }
+  }
+  throw new Error('Graph stream ended unexpectedly');
+}
+
 export async function searchSymbols(query: string, limit?: number): Promise<Array<{ symbol: Symbol; tags: Tag[] }>> {
   const params = new URLSearchParams({ query });
   if (limit) params.set('limit', limit.toString());
@@ -102,11 +184,15 @@ export async function searchSymbols(query: string, limit?: number): Promise<Arra
 export async function getSpine(
   symbolId: number,
   depth?: number,
-  filters?: GraphFilter
+  filters?: GraphFilter,
+  seedPath?: number[],
+  via?: number[]
 ): Promise<SpineResponse> {
   const params = new URLSearchParams();
   if (depth) params.set('depth', depth.toString());
   if (filters) params.set('filters', JSON.stringify(filters));
+  if (seedPath?.length) params.set('seedPath', seedPath.join(','));
+  if (via?.length) params.set('via', via.join(','));
   const queryString = params.toString();
   const url = queryString
     ? `${API_BASE}/spine/${symbolId}?${queryString}`
@@ -117,3 +203,53 @@ export async function getSpine(
 export async function getCFG(symbolId: number): Promise<CFGInfo> {
   return fetchJSON<CFGInfo>(`${API_BASE}/cfg/${symbolId}`);
 }
+
+// URL of a function's CFG rendered as Graphviz DOT, for download links.
+export function cfgDotURL(symbolId: number): string {
+  return `${API_BASE}/cfg/${symbolId}/dot`;
+}
+
+// sendJSON sends a JSON body with a write method (POST, DELETE).
+function sendJSON<T>(url: string, method: string, body?: unknown): Promise<T> {
+  return fetchJSON<T>(url, {
+    method,
+    headers: body === undefined ? undefined : { 'Content-Type': 'application/json' },
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+}
+
+export async function getBookmarks(): Promise<Bookmark[]> {
+  const resp = await fetchJSON<{ bookmarks: Bookmark[] }>(`${API_BASE}/bookmarks`);
+  return resp.bookmarks;
+}
+
+export async function addBookmark(kind: BookmarkKind, id: number, note?: string): Promise<Bookmark> {
+  return sendJSON<Bookmark>(`${API_BASE}/bookmarks`, 'POST', { kind, id, note });
+}
+
+export async function deleteBookmark(kind: BookmarkKind, key: string): Promise<void> {
+  const params = new URLSearchParams({ kind, key });
+  return sendJSON<void>(`${API_BASE}/bookmarks?${params}`, 'DELETE');
+}
+
+export async function getViews<S>(): Promise<SavedView<S>[]> {
+  const resp = await fetchJSON<{ views: SavedView<S>[] }>(`${API_BASE}/views`);
+  return resp.views;
+}
+
+export async function saveView<S>(name: string, state: S): Promise<SavedView<S>> {
+  return sendJSON<SavedView<S>>(`${API_BASE}/views`, 'POST', { name, state });
+}
+
+export async function deleteView(name: string): Promise<void> {
+  return sendJSON<void>(`${API_BASE}/views?${new URLSearchParams({ name })}`, 'DELETE');
+}
+
+// Short share links: the server stores the view state under an ID
+export async function createShare<S>(state: S): Promise<{ id: string }> {
+  return sendJSON<{ id: string }>(`${API_BASE}/share`, 'POST', { state });
+}
+
+export async function getShare<S>(id: string): Promise<{ id: string; state: S }> {
+  return fetchJSON<{ id: string; state: S }>(`${API_BASE}/share/${encodeURIComponent(id)}`);
+}
@@ -23,23 +23,33 @@ export function CallSpineView({
 }: CallSpineViewProps) {
   const [expandedBranches, setExpandedBranches] = useState<Set<number>>(new Set());
   const [branchNodes, setBranchNodes] = useState<Map<number, SpineNode[]>>(new Map());
+  // Branch nodes the user rerouted the main path through, in order
+  const [via, setVia] = useState<number[]>([]);
 
   // Fetch spine data
   const { data: spineData, isLoading, error } = useQuery({
-    queryKey: ['spine', rootId, filters],
+    queryKey: ['spine', rootId, filters, via],
     queryFn: async () => {
       if (!rootId) return null;
-      return getSpine(rootId, filters.maxDepth ?? 10, filters);
+      return getSpine(rootId, filters.maxDepth ?? 10, filters, undefined, via);
     },
     enabled: !!rootId,
   });
 
-  // Reset expanded branches when root changes
+  // Reset expanded branches and the route when root changes
   useEffect(() => {
     setExpandedBranches(new Set());
     setBranchNodes(new Map());
+    setVia([]);
   }, [rootId]);
 
+  // Reroute the main path through a branch node
+  const handleRouteThrough = useCallback((nodeId: number) => {
+    setExpandedBranches(new Set());
+    setBranchNodes(new Map());
+    setVia((prev) => (prev.includes(nodeId) ? prev : [...prev, nodeId]));
+  }, []);
+
   // Notify parent when spine nodes change
   useEffect(() => {
     if (spineData?.nodes && onSpineNodesUpdate) {
@@ -125,6 +135,21 @@ export function CallSpineView({
 
   return (
     <div className="h-full flex flex-col bg-[#0d1117]">
+      {/* User-picked route */}
+      {via.length > 0 && (
+        <div className="flex items-center justify-between px-4 py-2 text-xs text-gray-400 border-b border-gray-800">
+          <span>
+            Main path rerouted through {via.length} {via.length === 1 ? 'call' : 'calls'}
+            {spineData.skipped_via?.length
+              ? ` (${spineData.skipped_via.length} unreachable)`
+              : ''}
+          </span>
+          <button className="text-blue-400 hover:text-blue-300" onClick={() => setVia([])}>
+            Reset route
+          </button>
+        </div>
+      )}
+
       {/* Spine visualization */}
       <div className="flex-1 overflow-auto">
         <div className="flex flex-col items-center py-8 px-4 min-h-full">
@@ -164,8 +189,18 @@ export function CallSpineView({
                               ? `(${branchNode.recv_type}).${branchNode.name}`
                               : branchNode.name}
                           </div>
-                          <div className="text-xs text-gray-600 mt-0.5">
-                            {branchNode.pkg_path.split('/').pop()}
+                          <div className="flex items-center justify-between text-xs text-gray-600 mt-0.5">
+                            <span>{branchNode.pkg_path.split('/').pop()}</span>
+                            <button
+                              className="text-blue-400 hover:text-blue-300"
+                              title="Make this call part of the main path"
+                              onClick={(e) => {
+                                e.stopPropagation();
+                                handleRouteThrough(branchNode.id);
+                              }}
+                            >
+                              Route through
+                            </button>
                           </div>
                         </div>
                       ))}
@@ -1,82 +1,44 @@
 import { useState, useMemo } from 'react';
 import { useQuery } from '@tanstack/react-query';
-import { getEntrypoints } from '../api';
-import type { Entrypoint, HTTPMeta } from '../types';
+import { getEntrypointTree } from '../api';
+import type { Entrypoint, EntrypointGroup as Group, HTTPMeta } from '../types';
 
 interface EntrypointsPanelProps {
   selectedId: number | null;
   onSelect: (entrypoint: Entrypoint) => void;
 }
 
-// Group entrypoints by path prefix (e.g., api/auth, api/users)
-function groupEntrypoints(entrypoints: Entrypoint[]): Map<string, Entrypoint[]> {
-  const groups = new Map<string, Entrypoint[]>();
-
-  for (const ep of entrypoints) {
-    let groupKey = 'other';
-
-    if (ep.type === 'http' && ep.meta_json) {
-      try {
-        const meta = JSON.parse(ep.meta_json) as HTTPMeta;
-        // Extract first two path segments (e.g., /api/auth/login -> api/auth)
-        const pathParts = meta.path.split('/').filter(Boolean);
-        if (pathParts.length >= 2) {
-          groupKey = `${pathParts[0]}/${pathParts[1]}`;
-        } else if (pathParts.length === 1) {
-          groupKey = pathParts[0];
-        }
-      } catch {
-        // Use package-based grouping for non-HTTP
-      }
-    } else if (ep.type === 'grpc') {
-      groupKey = 'grpc';
-    } else if (ep.type === 'cli') {
-      groupKey = 'cli';
-    } else if (ep.type === 'main') {
-      groupKey = 'main';
-    } else {
-      // Group by package for handlers without clear path
-      const pkgParts = ep.symbol.pkg_path.split('/');
-      const lastPart = pkgParts[pkgParts.length - 1];
-      if (lastPart === 'handler' || lastPart === 'handlers') {
-        const secondLast = pkgParts[pkgParts.length - 2];
-        groupKey = secondLast || lastPart;
-      } else {
-        groupKey = lastPart;
-      }
-    }
-
-    if (!groups.has(groupKey)) {
-      groups.set(groupKey, []);
-    }
-    groups.get(groupKey)!.push(ep);
-  }
-
-  return groups;
+// Prune a group to entrypoints matching the search query, or null when none match
+function filterGroup(group: Group, query: string): Group | null {
+  const entrypoints = (group.entrypoints ?? []).filter((ep) =>
+    ep.label.toLowerCase().includes(query) ||
+    ep.symbol.name.toLowerCase().includes(query)
+  );
+  const groups = (group.groups ?? [])
+    .map((g) => filterGroup(g, query))
+    .filter((g): g is Group => g !== null);
+  const count = entrypoints.length + groups.reduce((n, g) => n + g.count, 0);
+  if (count === 0) return null;
+  return { ...group, entrypoints, groups, count };
 }
 
 export function EntrypointsPanel({ selectedId, onSelect }: EntrypointsPanelProps) {
   const [searchQuery, setSearchQuery] = useState('');
   const [collapsedGroups, setCollapsedGroups] = useState<Set<string>>(new Set());
 
-  const { data: entrypoints = [], isLoading, error } = useQuery({
-    queryKey: ['entrypoints'],
-    queryFn: () => getEntrypoints(),
+  // Grouped server-side: HTTP by path prefix, gRPC by service, CLI by command tree
+  const { data: tree, isLoading, error } = useQuery({
+    queryKey: ['entrypoints', 'tree'],
+    queryFn: () => getEntrypointTree(),
     staleTime: 30000,
   });
 
-  const filteredEntrypoints = useMemo(() => {
-    if (!searchQuery.trim()) return entrypoints;
+  const groups = useMemo(() => {
+    const all = tree?.groups ?? [];
+    if (!searchQuery.trim()) return all;
     const query = searchQuery.toLowerCase();
-    return entrypoints.filter((ep) =>
-      ep.label.toLowerCase().includes(query) ||
-      ep.symbol.name.toLowerCase().includes(query)
-    );
-  }, [entrypoints, searchQuery]);
-
-  const groupedEntrypoints = useMemo(() => {
-    return groupEntrypoints(filteredEntrypoints);
-  }, [filteredEntrypoints]);
+    return all.map((g) => filterGroup(g, query)).filter((g): g is Group => g !== null);
+  }, [tree, searchQuery]);
 
   const toggleGroup = (groupKey: string) => {
     setCollapsedGroups((prev) => {
@@ -90,11 +52,6 @@ export function EntrypointsPanel({ selectedId, onSelect }: EntrypointsPanelProps
     });
   };
 
-  // Sort groups alphabetically
-  const sortedGroups = useMemo(() => {
-    return Array.from(groupedEntrypoints.entries()).sort(([a], [b]) => a.localeCompare(b));
-  }, [groupedEntrypoints]);
-
   return (
     <div className="flex flex-col h-full bg-[#0d1117] text-gray-100">
       {/* Header */}
@@ -129,20 +86,21 @@ export function EntrypointsPanel({ selectedId, onSelect }: EntrypointsPanelProps
             Error: {error instanceof Error ? error.message : 'Unknown error'}
           </div>
         )}
-        {!isLoading && !error && sortedGroups.length === 0 && (
+        {!isLoading && !error && groups.length === 0 && (
           <div className="p-4 text-center text-gray-600">
             {searchQuery ? 'No matches found' : 'No entrypoints'}
           </div>
         )}
 
-        {sortedGroups.map(([groupKey, entries]) => (
+        {groups.map((group) => (
           <EntrypointGroup
-            key={groupKey}
-            groupKey={groupKey}
-            entries={entries}
+            key={group.name}
+            groupKey={group.name}
+            group={group}
+            depth={0}
             selectedId={selectedId}
-            isCollapsed={collapsedGroups.has(groupKey)}
-            onToggle={() => toggleGroup(groupKey)}
+            collapsedGroups={collapsedGroups}
+            onToggle={toggleGroup}
             onSelect={onSelect}
           />
         ))}
@@ -152,55 +110,73 @@ export function EntrypointsPanel({ selectedId, onSelect }: EntrypointsPanelProps
 }
 
 interface EntrypointGroupProps {
-  groupKey: string;
-  entries: Entrypoint[];
+  groupKey: string;  // Unique across the tree: the type and path
+  group: Group;
+  depth: number;
   selectedId: number | null;
-  isCollapsed: boolean;
-  onToggle: () => void;
+  collapsedGroups: Set<string>;
+  onToggle: (groupKey: string) => void;
   onSelect: (entrypoint: Entrypoint) => void;
 }
 
 function EntrypointGroup({
   groupKey,
-  entries,
+  group,
+  depth,
   selectedId,
-  isCollapsed,
+  collapsedGroups,
   onToggle,
   onSelect
 }: EntrypointGroupProps) {
+  const isCollapsed = collapsedGroups.has(groupKey);
   return (
-    <div className="border-b border-gray-800/30">
+    <div className={depth === 0 ? 'border-b border-gray-800/30' : ''}>
       {/* Group header */}
       <button
-        onClick={onToggle}
-        className="w-full px-4 py-2.5 flex items-center justify-between hover:bg-[#161b22] transition-colors"
+        onClick={() => onToggle(groupKey)}
+        className="w-full pr-4 py-2.5 flex items-center justify-between hover:bg-[#161b22] transition-colors"
+        style={{ paddingLeft: 16 + depth * 12 }}
+        title={group.path || group.name}
       >
-        <div className="flex items-center gap-2">
+        <div className="flex items-center gap-2 min-w-0">
           <svg
-            className={`w-3 h-3 text-gray-500 transition-transform ${isCollapsed ? '' : 'rotate-90'}`}
+            className={`w-3 h-3 shrink-0 text-gray-500 transition-transform ${isCollapsed ? '' : 'rotate-90'}`}
             fill="currentColor"
             viewBox="0 0 20 20"
           >
             <path fillRule="evenodd" d="M7.293 14.707a1 1 0 010-1.414L10.586 10 7.293 6.707a1 1 0 011.414-1.414l4 4a1 1 0 010 1.414l-4 4a1 1 0 01-1.414 0z" clipRule="evenodd" />
           </svg>
-          <span className="text-sm text-gray-300 font-medium">{groupKey}</span>
+          <span className={`text-sm truncate ${depth === 0 ? 'text-gray-300 font-medium' : 'text-gray-400'}`}>{group.name}</span>
         </div>
         <span className="text-xs text-gray-600 bg-gray-800/50 px-2 py-0.5 rounded">
-          {entries.length}
+          {group.count}
         </span>
       </button>
 
-      {/* Group entries */}
+      {/* Group entries, then subgroups */}
       {!isCollapsed && (
         <div className="pb-1">
-          {entries.map((ep) => (
+          {(group.entrypoints ?? []).map((ep) => (
             <EntrypointItem
               key={ep.id}
               entrypoint={ep}
+              depth={depth}
               selected={selectedId === ep.symbol_id}
               onClick={() => onSelect(ep)}
             />
           ))}
+          {(group.groups ?? []).map((child) => (
+            <EntrypointGroup
+              key={child.name}
+              groupKey={`${groupKey}:${child.path}`}
+              group={child}
+              depth={depth + 1}
+              selectedId={selectedId}
+              collapsedGroups={collapsedGroups}
+              onToggle={onToggle}
+              onSelect={onSelect}
+            />
+          ))}
         </div>
       )}
     </div>
@@ -209,24 +185,51 @@ function EntrypointGroup({
 
 interface EntrypointItemProps {
   entrypoint: Entrypoint;
+  depth: number;
   selected: boolean;
   onClick: () => void;
 }
 
-function EntrypointItem({ entrypoint, selected, onClick }: EntrypointItemProps) {
+// Short form of a Go type name: "myapp/api.CreateUserRequest" -> "CreateUserRequest"
+function shortTypeName(name: string): string {
+  return name.replace(/[\w./-]*\//g, '').replace(/\b\w+\.(?=\w)/g, '');
+}
+
+// Request and response payload types of an HTTP entrypoint, e.g. "CreateUserRequest → User"
+function payloadSummary(entrypoint: Entrypoint): string | null {
+  if (entrypoint.type !== 'http' || !entrypoint.meta_json) return null;
+  try {
+    const meta = JSON.parse(entrypoint.meta_json) as HTTPMeta;
+    if (!meta.request_type && !meta.response_types?.length) return null;
+    const request = meta.request_type ? shortTypeName(meta.request_type) : '∅';
+    const responses = meta.response_types?.map(shortTypeName).join(' | ') || '∅';
+    return `${request} → ${responses}`;
+  } catch {
+    return null;
+  }
+}
+
+function EntrypointItem({ entrypoint, depth, selected, onClick }: EntrypointItemProps) {
   // Get method name from the handler
   const methodName = entrypoint.label;
+  const payload = payloadSummary(entrypoint);
 
   return (
     <button
       onClick={onClick}
-      className={`w-full pl-8 pr-4 py-2 text-left transition-colors ${
+      className={`w-full pr-4 py-2 text-left transition-colors ${
         selected
           ? 'bg-[#1e3a5f] border-l-2 border-l-blue-500'
           : 'hover:bg-[#161b22] border-l-2 border-l-transparent'
       }`}
+      style={{ paddingLeft: 32 + depth * 12 }}
     >
       <div className="text-sm text-gray-300 truncate">{methodName}</div>
+      {payload && (
+        <div className="text-xs text-gray-500 font-mono truncate" title={payload}>
+          {payload}
+        </div>
+      )}
     </button>
   );
 }
@@ -13,7 +13,8 @@ import {
 } from '@xyflow/react';
 import type { Node, Edge } from '@xyflow/react';
 import '@xyflow/react/dist/style.css';
-import type { GraphNode, GraphEdge, GraphFilter } from '../types';
+import type { GraphNode, GraphEdge, GraphFilter, NoiseSummary } from '../types';
+import { isIOTag } from '../types';
 import type { BreadcrumbItem } from '../hooks/useBreadcrumbs';
 import { Toolbar } from './Toolbar';
 import { Breadcrumbs } from './Breadcrumbs';
@@ -71,6 +72,7 @@ const TAG_COLORS: Record<string, string> = {
   'io:db': 'bg-amber-700 text-amber-100',
   'io:net': 'bg-orange-700 text-orange-100',
   'io:fs': 'bg-yellow-700 text-yellow-100',
+  'io:cache': 'bg-cyan-700 text-cyan-100',
   'io:bus': 'bg-red-700 text-red-100',
   'layer:handler': 'bg-blue-700 text-blue-100',
   'layer:service': 'bg-purple-700 text-purple-100',
@@ -82,7 +84,8 @@ const TAG_COLORS: Record<string, string> = {
 
 function getTagColor(tag: string): string {
   if (TAG_COLORS[tag]) return TAG_COLORS[tag];
-  if (tag.startsWith('io:')) return 'bg-amber-800 text-amber-200';
+  if (isIOTag(tag)) return 'bg-amber-800 text-amber-200';
+  if (tag.startsWith('io:')) return 'bg-amber-950 text-amber-400';
   if (tag.startsWith('layer:')) return 'bg-purple-800 text-purple-200';
   return 'bg-gray-600 text-gray-200';
 }
@@ -91,7 +94,7 @@ function getNodeColor(node: GraphNode, isRoot: boolean, isSelected: boolean, isP
   if (isSelected) return NODE_COLORS.selected;
   if (isRoot) return NODE_COLORS.root;
   if (isPinned) return NODE_COLORS.pinned;
-  if (node.tags.some((t) => t.startsWith('io:'))) return NODE_COLORS.io;
+  if (node.tags.some(isIOTag)) return NODE_COLORS.io;
   if (node.expanded) return NODE_COLORS.expanded;
   return NODE_COLORS.default;
 }
@@ -109,7 +112,23 @@ interface CustomNodeProps {
   };
 }
 
+function NoiseNode({ noise, name }: { noise: NoiseSummary; name: string }) {
+  return (
+    <div
+      className="px-3 py-1.5 rounded-lg border border-dashed border-gray-600 bg-gray-800/60 text-gray-400 text-xs italic"
+      style={{ minWidth: 140, maxWidth: 220 }}
+      title={`${noise.packages.join(', ')}\n${noise.labels.join('\n')}`}
+    >
+      <Handle type="target" position={Position.Top} className="!bg-gray-600" />
+      <div className="text-center truncate">{name}</div>
+      <Handle type="source" position={Position.Bottom} className="!bg-gray-600" />
+    </div>
+  );
+}
+
 function CustomNode({ data }: CustomNodeProps) {
+  if (data.node.noise) return <NoiseNode noise={data.node.noise} name={data.node.name} />;
+
   const colors = getNodeColor(data.node, data.isRoot, data.isSelected, data.isPinned);
   const layer = getNodeLayer(data.node.tags);
   const layerColors = layer ? LAYER_BADGE_COLORS[layer] : null;
@@ -185,6 +204,17 @@ function CustomNode({ data }: CustomNodeProps) {
           {data.node.pkg_path.split('/').pop()}
         </div>
 
+        {/* Folded wiring chain */}
+        {data.node.wiring && (
+          <div
+            className="text-[10px] opacity-70 italic"
+            style={{ color: colors.text }}
+            title={data.node.wiring.labels.join('\n')}
+          >
+            +{data.node.wiring.count} wiring
+          </div>
+        )}
+
         {/* Non-layer tags (IO, pure/impure, etc.) */}
         {visibleTags.length > 0 && (
           <div className="mt-1.5 flex flex-wrap justify-center gap-0.5">
@@ -373,6 +403,7 @@ export function GraphPanel({
           stroke: edgeColor,
           strokeWidth: edge.callsite_count > 1 ? 2.5 : 1.5,
           strokeDasharray: edge.call_kind === 'interface' ? '5,5' : undefined,
+          opacity: edge.resolved_by === 'interface-heuristic' ? 0.6 : 1, // Guessed implementations are less certain
         },
         label,
         labelStyle: { fontSize: 10, fill: '#d1d5db' },
@@ -469,7 +500,7 @@ export function GraphPanel({
           nodeColor={(node) => {
             const data = node.data as { isRoot: boolean; node: GraphNode };
             if (data?.isRoot) return '#3b82f6';
-            if (data?.node?.tags?.some((t: string) => t.startsWith('io:'))) return '#f59e0b';
+            if (data?.node?.tags?.some(isIOTag)) return '#f59e0b';
             return '#4b5563';
           }}
           className="!bg-gray-800 !border-gray-700"
@@ -1,7 +1,7 @@
 import { useState, useEffect, useMemo } from 'react';
 import { useQuery } from '@tanstack/react-query';
 import { getSymbol } from '../api';
-import type { GraphNode, GraphFilter, CallInfo, SpineNode } from '../types';
+import type { GraphNode, GraphFilter, CallInfo, SpineNode, Signature } from '../types';
 
 interface InspectorPanelProps {
   selectedNode: GraphNode | null;
@@ -43,6 +43,7 @@ const SIDE_EFFECT_INFO: Record<string, { label: string; icon: string; color: str
   'io:db': { label: 'Database', icon: '🗄️', color: '#f59e0b' },
   'io:net': { label: 'Network', icon: '🌐', color: '#3b82f6' },
   'io:fs': { label: 'Filesystem', icon: '📁', color: '#10b981' },
+  'io:cache': { label: 'Cache', icon: '⚡', color: '#06b6d4' },
   'io:bus': { label: 'Message Bus', icon: '📨', color: '#ec4899' },
 };
 
@@ -77,12 +78,23 @@ function inferSideEffects(nodes: GraphNode[] | SpineNode[]): Array<{ type: strin
         detail = `POST /${inferEndpoint(name)}`;
       } else if (name.includes('get') || name.includes('fetch')) {
         detail = `GET /${inferEndpoint(name)}`;
-      } else if (pkgPath.includes('redis') || pkgPath.includes('cache')) {
-        detail = `GET redis://cache:${inferCacheKey(name)}`;
       }
       effects.push({ type: 'io:net', label: SIDE_EFFECT_INFO['io:net'].label, detail, nodeId: node.id });
     }
 
+    // Cache operations
+    if (tags.includes('io:cache')) {
+      let detail = 'Cache access';
+      if (name.includes('set') || name.includes('put') || name.includes('store')) {
+        detail = `SET ${inferCacheKey(name)}`;
+      } else if (name.includes('get') || name.includes('fetch') || name.includes('load')) {
+        detail = `GET ${inferCacheKey(name)}`;
+      } else if (name.includes('del') || name.includes('evict') || name.includes('invalidate')) {
+        detail = `DEL ${inferCacheKey(name)}`;
+      }
+      effects.push({ type: 'io:cache', label: SIDE_EFFECT_INFO['io:cache'].label, detail, nodeId: node.id });
+    }
+
     // Message bus
     if (tags.includes('io:bus')) {
       const topic = inferTopicName(name);
@@ -98,6 +110,25 @@ function inferSideEffects(nodes: GraphNode[] | SpineNode[]): Array<{ type: strin
   return effects;
 }
 
+// Shorten package-qualified types: "*example.com/app/pb.GetUserRequest" -> "*pb.GetUserRequest"
+function shortType(type: string): string {
+  return type.replace(/[\w.\-]+(?:\/[\w.\-]+)+\./g, (qualified) => {
+    const pkg = qualified.slice(0, -1);
+    return pkg.slice(pkg.lastIndexOf('/') + 1) + '.';
+  });
+}
+
+function formatSignature(sig: Signature): string {
+  const params = sig.params.map((p, i) => {
+    let type = shortType(p.type);
+    if (sig.variadic && i === sig.params.length - 1) type = '...' + type.replace(/^\[\]/, '');
+    return p.name ? `${p.name} ${type}` : type;
+  });
+  const results = sig.results.map((r) => shortType(r.type));
+  const ret = results.length === 0 ? '' : results.length === 1 ? ` ${results[0]}` : ` (${results.join(', ')})`;
+  return `(${params.join(', ')})${ret}`;
+}
+
 function inferTableName(funcName: string, pkgPath: string): string {
   // Try to infer table name from function or package
   const parts = pkgPath.split('/');
@@ -221,7 +252,7 @@ export function InspectorPanel({
                 onChange={(e) => handleFilterChange({ collapseWiring: e.target.checked })}
                 className="w-4 h-4 rounded bg-[#161b22] border-gray-700 text-blue-600 focus:ring-blue-500 focus:ring-offset-0"
               />
-              <span>Hide wiring/config</span>
+              <span>Fold wiring/config</span>
               {filters.collapseWiring && (
                 <span className="ml-auto text-xs text-blue-400">ON</span>
               )}
@@ -253,6 +284,24 @@ export function InspectorPanel({
               />
               <span>Stop at I/O</span>
             </label>
+            <label className="flex items-center gap-3 text-sm text-gray-300 cursor-pointer">
+              <input
+                type="checkbox"
+                checked={(filters.stopAtIODistance ?? 0) > 0}
+                onChange={(e) => handleFilterChange({ stopAtIODistance: e.target.checked ? 2 : 0 })}
+                className="w-4 h-4 rounded bg-[#161b22] border-gray-700 text-blue-600 focus:ring-blue-500 focus:ring-offset-0"
+              />
+              <span>Stop one call before I/O</span>
+            </label>
+            <label className="flex items-center gap-3 text-sm text-gray-300 cursor-pointer">
+              <input
+                type="checkbox"
+                checked={filters.orderBy === 'count'}
+                onChange={(e) => handleFilterChange({ orderBy: e.target.checked ? 'count' : undefined })}
+                className="w-4 h-4 rounded bg-[#161b22] border-gray-700 text-blue-600 focus:ring-blue-500 focus:ring-offset-0"
+              />
+              <span>Order calls by count</span>
+            </label>
           </div>
         </div>
 
@@ -374,9 +423,28 @@ export function InspectorPanel({
 
               {/* Location */}
               <div className="text-xs text-gray-500">
-                {selectedNode.file.split('/').pop()}:{selectedNode.line}
+                {selectedNode.file.split(/[\\/]/).pop()}:{selectedNode.line}
               </div>
 
+              {/* Request/response types */}
+              {symbolData?.signature && (
+                <div className="text-xs font-mono text-gray-400 break-all" title={selectedNode.sig}>
+                  {formatSignature(symbolData.signature)}
+                </div>
+              )}
+
+              {/* Constant value */}
+              {symbolData?.value && (
+                <div className="text-xs font-mono text-gray-400 break-all">= {symbolData.value}</div>
+              )}
+
+              {/* Doc comment */}
+              {symbolData?.doc && (
+                <p className="text-xs text-gray-400 whitespace-pre-line leading-relaxed">
+                  {symbolData.doc}
+                </p>
+              )}
+
               {/* View CFG button */}
               {onShowCFG && (
                 <button
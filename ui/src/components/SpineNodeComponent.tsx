@@ -1,4 +1,5 @@
 import type { SpineNode } from '../types';
+import { isIOTag } from '../types';
 import { BranchBadgeComponent } from './BranchBadgeComponent';
 
 interface SpineNodeComponentProps {
@@ -23,11 +24,12 @@ const IO_COLORS: Record<string, { bg: string; text: string }> = {
   'io:db': { bg: '#f59e0b', text: '#ffffff' },
   'io:net': { bg: '#f97316', text: '#ffffff' },
   'io:fs': { bg: '#eab308', text: '#ffffff' },
+  'io:cache': { bg: '#06b6d4', text: '#ffffff' },
   'io:bus': { bg: '#ef4444', text: '#ffffff' },
 };
 
 function getIOTag(tags: string[]): string | null {
-  return tags.find((t) => t.startsWith('io:')) ?? null;
+  return tags.find(isIOTag) ?? null;
 }
 
 export function SpineNodeComponent({
@@ -110,6 +112,16 @@ export function SpineNodeComponent({
                 </span>
               </div>
             )}
+
+            {/* Collapsed noise calls (collapseNoise filter) */}
+            {node.noise && (
+              <div
+                className="text-[11px] text-gray-500 italic mt-1"
+                title={node.noise.labels.join('\n')}
+              >
+                {node.noise.count === 1 ? '1 observability call' : `${node.noise.count} observability calls`}
+              </div>
+            )}
           </div>
 
           {/* Right side - branch count indicator */}
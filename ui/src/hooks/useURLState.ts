@@ -1,7 +1,9 @@
 import { useCallback, useEffect, useRef, useState } from 'react';
 import type { URLState } from '../utils/urlState';
+import { getShare } from '../api';
 import {
   getURLState,
+  getShareID,
   setURLState,
   clearURLState,
   copyShareLink as copyShareLinkUtil,
@@ -15,9 +17,20 @@ interface UseURLStateReturn {
 }
 
 export function useURLState(): UseURLStateReturn {
-  const [initialState] = useState<URLState | null>(() => getURLState());
+  const [initialState, setInitialState] = useState<URLState | null>(() => getURLState());
   const debounceRef = useRef<ReturnType<typeof setTimeout> | null>(null);
 
+  // Resolve a short share link (?share=id) to its stored state
+  useEffect(() => {
+    const shareID = getShareID();
+    if (initialState || !shareID) return;
+    getShare<URLState>(shareID)
+      .then((share) => setInitialState(share.state))
+      .catch(() => {
+        // Unknown or expired link: start from an empty view
+      });
+  }, [initialState]);
+
   // Cleanup debounce on unmount
   useEffect(() => {
     return () => {
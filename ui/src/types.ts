@@ -1,8 +1,9 @@
 // API Types matching the Go backend
 
-export type SymbolKind = 'func' | 'method' | 'type' | 'var' | 'const';
+export type SymbolKind = 'func' | 'method' | 'type' | 'interface' | 'var' | 'const';
 export type CallKind = 'static' | 'interface' | 'funcval' | 'defer' | 'go' | 'unknown';
-export type EntrypointType = 'http' | 'grpc' | 'cli' | 'main';
+export type ResolvedBy = 'ssa-static' | 'interface-heuristic' | 'closure-trace' | 'manual';
+export type EntrypointType = 'http' | 'grpc' | 'cli' | 'main' | 'custom';
 
 export interface Symbol {
   id: number;
@@ -13,6 +14,21 @@ export interface Symbol {
   file: string;
   line: number;
   sig?: string;
+  doc?: string; // Leading doc comment (symbol details only)
+  signature?: Signature;
+  value?: string; // Resolved value of a constant
+}
+
+// Structured function signature; types carry full package paths
+export interface Signature {
+  params: Param[];
+  results: Param[];
+  variadic?: boolean;
+}
+
+export interface Param {
+  name?: string;
+  type: string;
 }
 
 export interface Tag {
@@ -28,6 +44,7 @@ export interface Entrypoint {
   symbol_id: number;
   meta_json?: string;
   symbol: Symbol;
+  unrecovered_panic?: boolean;  // A reachable panic is not recovered
 }
 
 export interface GraphNode {
@@ -42,15 +59,32 @@ export interface GraphNode {
   tags: string[];
   expanded: boolean;
   depth: number;
+  noise?: NoiseSummary;  // Set on pseudo-nodes for collapsed noise calls (negative id)
+  wiring?: WiringSummary;  // Wiring functions folded into this node (collapseWiring filter)
+}
+
+// Constructor/DI functions folded into a wiring node by the collapseWiring filter
+export interface WiringSummary {
+  count: number;
+  labels: string[];
+}
+
+// Noise-package calls folded into one node by the collapseNoise filter
+export interface NoiseSummary {
+  count: number;
+  packages: string[];
+  labels: string[];
 }
 
 export interface GraphEdge {
   source_id: number;
   target_id: number;
   call_kind: CallKind;
+  resolved_by: ResolvedBy;
   callsite_count: number;
   caller_file?: string;
   caller_line?: number;
+  order?: number;  // Position among the source's calls in source order, from 1
 }
 
 export interface GraphResponse {
@@ -59,17 +93,55 @@ export interface GraphResponse {
   root_id: number;
   max_depth: number;
   filtered_count: number;
+  cleanup?: CleanupSection[];  // Deferred calls per function (cleanupLane filter)
+}
+
+// A deferred call shown in the cleanup lane
+export interface CleanupCall {
+  id: number;
+  name: string;
+  pkg_path: string;
+  recv_type?: string;
+  caller_file: string;
+  caller_line: number;
+}
+
+export interface CleanupSection {
+  function_id: number;
+  calls: CleanupCall[];
+}
+
+export interface GraphStreamSummary {
+  root_id: number;
+  max_depth: number;
+  filtered_count: number;
+  node_count: number;
+  edge_count: number;
+}
+
+// One line of the NDJSON stream from /api/graph/stream/:id
+export interface GraphStreamEvent {
+  type: 'node' | 'edge' | 'cleanup' | 'done' | 'error';
+  node?: GraphNode;
+  edge?: GraphEdge;
+  cleanup?: CleanupSection;
+  done?: GraphStreamSummary;
+  error?: string;
 }
 
 export interface GraphFilter {
   hideStdlib?: boolean;
   hideVendors?: boolean;
   stopAtIO?: boolean;
+  stopAtIODistance?: number; // Stop at nodes within this many calls of I/O (io:<category>@N tags)
   stopAtPackagePrefix?: string[];
   maxDepth?: number;
   noisePackages?: string[];
-  collapseWiring?: boolean;  // Collapse wiring/config functions (default ON)
+  collapseWiring?: boolean;  // Fold wiring/config chains into their first function (default ON)
   hideCmdMain?: boolean;     // Hide cmd/* packages (default ON)
+  cleanupLane?: boolean;     // Move deferred calls into a separate cleanup section
+  collapseNoise?: boolean;   // Fold noise-package calls into "N observability calls" nodes
+  orderBy?: 'count';         // Order each function's calls by call count instead of source order
 }
 
 export interface Stats {
@@ -81,16 +153,44 @@ export interface Stats {
   indexed_at: string;
 }
 
+// Timeouts and retries along an entrypoint's flow
+export interface Resilience {
+  timeouts?: { kind: 'context' | 'deadline' | 'client'; call: string; duration?: string; function: string }[];
+  retries?: { call: string; target?: string; attempts?: number; function: string }[];
+  summary: string; // e.g. "5s timeout; retries payment.Client.Charge up to 3 times"
+}
+
 // HTTP metadata for entrypoints
 export interface HTTPMeta {
   method: string;
   path: string;
+  status_codes?: number[]; // Constant response statuses reachable from the handler
+  request_type?: string; // Decoded request body type
+  response_types?: string[]; // Encoded response types
+  resilience?: Resilience;
 }
 
 // gRPC metadata for entrypoints
 export interface GRPCMeta {
   service: string;
   method: string;
+  status_codes?: number[]; // gRPC codes returned via status.Error and friends
+  resilience?: Resilience;
+}
+
+// Node of the entrypoint tree (GET /api/entrypoints?view=tree): an HTTP path
+// prefix, gRPC service, or CLI command with its subcommands
+export interface EntrypointGroup {
+  name: string;
+  path: string;
+  count: number;            // Entrypoints in this group and its subgroups
+  entrypoints?: Entrypoint[];
+  groups?: EntrypointGroup[];
+}
+
+export interface EntrypointTree {
+  groups: EntrypointGroup[];  // One per entrypoint type
+  count: number;
 }
 
 // CLI metadata for entrypoints
@@ -99,6 +199,12 @@ export interface CLIMeta {
   uses_run_e?: boolean;
 }
 
+// isIOTag reports whether a tag marks a direct I/O boundary ("io:db"), as
+// opposed to a derived one for callers of I/O functions ("io:db@2").
+export function isIOTag(tag: string): boolean {
+  return tag.startsWith('io:') && !tag.includes('@');
+}
+
 export function parseEntrypointMeta(_type: EntrypointType, metaJson?: string): HTTPMeta | GRPCMeta | CLIMeta | null {
   if (!metaJson) return null;
   try {
@@ -112,15 +218,94 @@ export function parseEntrypointMeta(_type: EntrypointType, metaJson?: string): H
 export interface CallInfo {
   symbol: Symbol;
   call_kind: CallKind;
+  resolved_by: ResolvedBy;
   caller_file: string;
   caller_line: number;
   count: number;
   tags?: Tag[];
 }
 
+// Interfaces browser
+export interface InterfaceSummary {
+  symbol: Symbol;
+  method_count: number;
+  implementation_count: number;
+}
+
+export interface InterfaceMethod {
+  name: string;
+  sig: string;
+}
+
+export interface Implementation {
+  symbol: Symbol;
+  pointer?: boolean; // Only *T satisfies the interface
+}
+
+export interface InterfaceDetails {
+  interface: Symbol;
+  methods: InterfaceMethod[];
+  implementations: Implementation[];
+}
+
+// Struct fields and embeddings
+export type TypeRelationKind = 'field' | 'embed';
+
+export interface TypeRelation {
+  type_id: number;
+  field: string;
+  relation: TypeRelationKind;
+  field_type: string;
+  target_pkg: string;
+  target_name: string;
+  target_id?: number; // Set when the target type is indexed
+  holder?: Symbol; // Holding type, on held_by entries
+}
+
+export interface TypeRelations {
+  type?: Symbol;
+  fields: TypeRelation[];
+  held_by: TypeRelation[];
+}
+
+// A use of a constant, by the declaration containing it
+export interface Reference {
+  symbol_id: number;
+  from: Symbol;
+  file: string;
+  line: number;
+  column: number;
+}
+
+// A call that wraps, drops, or converts an error into a status
+export interface ErrorSite {
+  symbol_id: number;
+  kind: 'wrap' | 'swallow' | 'status';
+  call: string;
+  file: string;
+  line: number;
+}
+
+// Error sites of one function reachable from an entrypoint
+export interface FunctionErrors {
+  symbol: Symbol;
+  layer?: string;
+  depth: number;
+  sites: ErrorSite[];
+}
+
+export interface ErrorChain {
+  entrypoint: Entrypoint;
+  functions: FunctionErrors[];
+  by_layer: Record<string, Record<string, number>>;
+}
+
 // Extended symbol response with callers/callees
 export interface SymbolDetails {
   symbol: Symbol;
+  doc?: string; // Leading doc comment, truncated
+  signature?: Signature;
+  value?: string; // Resolved value of a constant
   tags: Tag[];
   package?: {
     pkg_path: string;
@@ -130,6 +315,7 @@ export interface SymbolDetails {
   };
   callees: CallInfo[];
   callers: CallInfo[];
+  implements?: Implementation[]; // Interfaces a type satisfies
 }
 
 // Call Spine Types
@@ -151,6 +337,7 @@ export interface SpineNode {
   is_main_path: boolean;
   branch_badge?: BranchBadge;
   layer?: string;
+  noise?: NoiseSummary;
 }
 
 export interface SpineResponse {
@@ -158,6 +345,8 @@ export interface SpineResponse {
   main_path: number[];
   total_nodes: number;
   collapsed_count: number;
+  cleanup?: CleanupSection[];
+  skipped_via?: number[]; // via symbols the main path could not be routed through
 }
 
 // CFG Types
@@ -168,6 +357,7 @@ export interface InstructionInfo {
   op: string;
   text: string;
   callee_id?: number;
+  line?: number;
 }
 
 export interface BasicBlockInfo {
@@ -178,6 +368,27 @@ export interface BasicBlockInfo {
   is_entry: boolean;
   is_exit: boolean;
   branch_cond?: string;
+  file?: string;
+  start_line?: number;
+  end_line?: number;
+  idom?: number;
+  is_loop_header?: boolean;
+  loop_depth?: number;
+}
+
+export interface CFGEdge {
+  from: number;
+  to: number;
+}
+
+export interface LoopInfo {
+  header: number;
+  blocks: number[];
+  back_edges: CFGEdge[];
+  exits: CFGEdge[];
+  guard?: string;
+  depth: number;
+  parent?: number;
 }
 
 export interface CFGInfo {
@@ -187,6 +398,28 @@ export interface CFGInfo {
   blocks: BasicBlockInfo[];
   entry_block: number;
   exit_blocks: number[];
+  dominator_edges?: CFGEdge[];
+  back_edges?: CFGEdge[];
+  loops?: LoopInfo[];
+}
+
+// Bookmarks: pinned symbols and starred entrypoints, shared via the server
+export type BookmarkKind = 'symbol' | 'entrypoint';
+
+export interface Bookmark {
+  kind: BookmarkKind;
+  key: string;
+  id?: number; // Absent when the symbol or entrypoint is no longer indexed
+  note?: string;
+  created_at: string;
+}
+
+// Named saved view of an exploration state
+export interface SavedView<S = unknown> {
+  name: string;
+  state: S;
+  created_at: string;
+  updated_at: string;
 }
 
 // Filter presets
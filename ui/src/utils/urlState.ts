@@ -1,5 +1,6 @@
 import { compressToEncodedURIComponent, decompressFromEncodedURIComponent } from 'lz-string';
 import type { GraphFilter } from '../types';
+import { createShare } from '../api';
 
 export interface URLState {
   entrypointId: number | null;
@@ -62,16 +63,23 @@ export function getURLState(): URLState | null {
   return decodeURLState(encoded);
 }
 
+// Short share link ID (?share=), resolved through the server
+export function getShareID(): string | null {
+  return new URLSearchParams(window.location.search).get('share');
+}
+
 export function setURLState(state: URLState): void {
   const encoded = encodeURLState(state);
   const url = new URL(window.location.href);
   url.searchParams.set('s', encoded);
+  url.searchParams.delete('share');
   window.history.replaceState({}, '', url.toString());
 }
 
 export function clearURLState(): void {
   const url = new URL(window.location.href);
   url.searchParams.delete('s');
+  url.searchParams.delete('share');
   window.history.replaceState({}, '', url.toString());
 }
 
@@ -82,7 +90,22 @@ export function buildShareURL(state: URLState): string {
   return url.toString();
 }
 
+export function buildShortShareURL(id: string): string {
+  const url = new URL(window.location.href);
+  url.search = '';
+  url.searchParams.set('share', id);
+  return url.toString();
+}
+
+// Copies a short link when the server can store the state, falling back to
+// a link carrying the encoded state.
 export async function copyShareLink(state: URLState): Promise<void> {
-  const url = buildShareURL(state);
+  let url: string;
+  try {
+    const { id } = await createShare(state);
+    url = buildShortShareURL(id);
+  } catch {
+    url = buildShareURL(state);
+  }
   await navigator.clipboard.writeText(url);
 }